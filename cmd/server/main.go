@@ -4,18 +4,69 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"my-vpn/internal/monitoring"
 	"my-vpn/internal/server"
+	"my-vpn/internal/system"
+	"my-vpn/internal/web"
+	"my-vpn/internal/wireguard"
 )
 
-// main initializes and starts the VPN server.
-// It creates a new server instance and starts it, handling any startup errors
-// by logging them and terminating the application.
+// main initializes and runs the VPN server.
+// It checks the JWT secret for the web interface, creates a new server instance,
+// restoring persisted state, attaches the monitor and web interface, and runs
+// until SIGINT or SIGTERM, then shuts everything down gracefully. Startup and
+// shutdown errors are logged and terminate the application.
 func main() {
+	jwtSecret := flag.String("jwt-secret", os.Getenv(web.JWTSecretEnv), "secret signing web interface tokens (default $"+web.JWTSecretEnv+")")
+	debug := flag.Bool("debug", false, "enable debug mode, which accepts the built-in JWT secret")
+	autoStartWireGuard := flag.Bool("autostart-wireguard", false, "bring the WireGuard interface up at startup")
+	stopWireGuard := flag.Bool("stop-wireguard", false, "bring the WireGuard interface down on shutdown")
+	flag.Parse()
+
 	log.Println("Starting VPN Server...")
-	
-	srv := server.New()
-	if err := srv.Start(); err != nil {
-		log.Fatal("Failed to start server:", err)
+
+	// Refuse to run with a secret that would let anyone forge tokens
+	webConfig := web.DefaultServerConfig()
+	webConfig.JWTSecret = *jwtSecret
+	webConfig.Debug = *debug
+	if _, err := webConfig.ResolveJWTSecret(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	wgServer := wireguard.NewWireGuardServer()
+	config := server.DefaultConfig()
+	config.WireGuard = wgServer
+	config.AutoStartWireGuard = *autoStartWireGuard
+	config.StopWireGuardOnShutdown = *stopWireGuard
+
+	srv, err := server.NewWithConfig(config)
+	if err != nil {
+		log.Fatal("Failed to initialize server:", err)
+	}
+
+	firewall := system.NewFirewallManager()
+	monitor := monitoring.NewMonitor(srv.GetDatabase(), wgServer, srv.GetIPPool(), firewall)
+	wgServer.SetLogger(monitor.GetLogManager().ForComponent(monitoring.LogComponentWireGuard))
+	webServer, err := web.NewServerWithConfig(srv.GetDatabase(), wgServer, srv.GetIPPool(), firewall, monitor, webConfig)
+	if err != nil {
+		log.Fatal("Failed to initialize web server:", err)
+	}
+	srv.SetMonitor(monitor)
+	srv.SetWebServer(webServer)
+	srv.SetLogManager(monitor.GetLogManager())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal("Server stopped with error:", err)
 	}
-}
\ No newline at end of file
+	log.Println("VPN Server stopped")
+}
@@ -4,7 +4,12 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,21 +17,71 @@ import (
 
 	"my-vpn/internal/auth"
 	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
 )
 
 // AuthAPI provides REST API endpoints for user authentication and management.
 // It handles user registration, login, token refresh, and user profile operations,
 // integrating with the authentication manager and database components.
 type AuthAPI struct {
-	db          *database.Database // Database interface for user data persistence
-	authManager *auth.AuthManager  // Authentication manager for token and password operations
+	db            *database.Database  // Database interface for user data persistence
+	authManager   *auth.AuthManager   // Authentication manager for token and password operations
+	loginRecorder FailedLoginRecorder // Optional recorder for failed login attempts
+	loginLimiter  *auth.LoginLimiter  // Locks usernames and IPs after repeated failed logins
+	resetSender   PasswordResetSender // Delivers password reset tokens to users (nil disables delivery)
+	registerMutex sync.Mutex          // Serializes registrations so only one can become the initial admin
+	logger        *monitoring.ComponentLogger // Records account and session changes (nil writes to the standard logger)
+}
+
+// passwordResetTokenExpiry is how long a password reset token can be used.
+const passwordResetTokenExpiry = time.Hour
+
+// forgotPasswordMessage is returned for every forgot-password request, so the
+// response doesn't reveal whether an account exists for the email.
+const forgotPasswordMessage = "If an account with that email exists, a password reset link has been sent"
+
+// PasswordReset describes a password reset token to deliver to a user.
+type PasswordReset struct {
+	UserID    uint      `json:"user_id"`    // User who requested the reset
+	Username  string    `json:"username"`   // Username of the account
+	Email     string    `json:"email"`      // Address the reset was requested for
+	Token     string    `json:"token"`      // Single-use reset token
+	ExpiresAt time.Time `json:"expires_at"` // When the token stops being accepted
+}
+
+// PasswordResetSender delivers password reset tokens to users, e.g. by email.
+type PasswordResetSender interface {
+	SendPasswordReset(reset PasswordReset) error
+}
+
+// webhookPasswordResetSender posts password resets to a webhook, which is
+// expected to forward them to the user.
+type webhookPasswordResetSender struct {
+	webhook *monitoring.WebhookNotifier
+}
+
+// SendPasswordReset posts the reset to the webhook.
+func (s *webhookPasswordResetSender) SendPasswordReset(reset PasswordReset) error {
+	return s.webhook.Post(reset)
+}
+
+// NewWebhookPasswordResetSender creates a sender posting password resets as
+// JSON to the given webhook URL.
+func NewWebhookPasswordResetSender(url string) PasswordResetSender {
+	return &webhookPasswordResetSender{webhook: monitoring.NewWebhookNotifier(url)}
+}
+
+// FailedLoginRecorder records failed login attempts as security events.
+// It is satisfied by monitoring.Monitor.
+type FailedLoginRecorder interface {
+	RecordFailedLogin(username, remoteIP string)
 }
 
 // Request/Response structures for authentication
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"` // Checked against the password policy
 }
 
 type LoginRequest struct {
@@ -54,9 +109,50 @@ type RefreshTokenRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes,omitempty"`  // Defaults to read and write
+	UserID *uint    `json:"user_id,omitempty"` // Owner of the key (defaults to the caller)
+}
+
+type APIKeyResponse struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyResponse is returned once when a key is created; the plaintext
+// key cannot be retrieved again.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// SessionResponse describes a token issued to the caller that hasn't expired.
+type SessionResponse struct {
+	TokenID   string    `json:"token_id"`
+	Label     string    `json:"label"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"` // Whether this is the token used for the request
 }
 
 type UpdateProfileRequest struct {
@@ -65,15 +161,40 @@ type UpdateProfileRequest struct {
 
 // NewAuthAPI creates a new authentication API instance.
 // It requires a database instance for user data persistence and an authentication manager
-// for token and password operations.
+// for token and password operations. Failed logins are limited with the default
+// auth.LoginLimiter settings.
 // Returns a pointer to the newly created AuthAPI.
 func NewAuthAPI(db *database.Database, authManager *auth.AuthManager) *AuthAPI {
 	return &AuthAPI{
-		db:          db,
-		authManager: authManager,
+		db:           db,
+		authManager:  authManager,
+		loginLimiter: auth.NewLoginLimiter(),
 	}
 }
 
+// SetLoginLimiter replaces the limiter used to lock out repeated failed logins,
+// e.g. to share it with another login endpoint or apply custom thresholds.
+func (api *AuthAPI) SetLoginLimiter(limiter *auth.LoginLimiter) {
+	api.loginLimiter = limiter
+}
+
+// SetFailedLoginRecorder sets the recorder notified about failed login attempts.
+func (api *AuthAPI) SetFailedLoginRecorder(recorder FailedLoginRecorder) {
+	api.loginRecorder = recorder
+}
+
+// SetPasswordResetSender sets the sender delivering password reset tokens.
+// Without a sender, forgot-password requests are accepted but no token is issued.
+func (api *AuthAPI) SetPasswordResetSender(sender PasswordResetSender) {
+	api.resetSender = sender
+}
+
+// SetLogger sets the logger recording account and session changes,
+// typically the log manager's LogComponentAuth logger.
+func (api *AuthAPI) SetLogger(logger *monitoring.ComponentLogger) {
+	api.logger = logger
+}
+
 // RegisterRoutes registers the authentication API routes.
 // It sets up all endpoints for user registration, login, token management, and profile operations.
 func (api *AuthAPI) RegisterRoutes(router *gin.Engine, middleware *auth.AuthMiddleware) {
@@ -82,6 +203,8 @@ func (api *AuthAPI) RegisterRoutes(router *gin.Engine, middleware *auth.AuthMidd
 		authGroup.POST("/register", api.Register)
 		authGroup.POST("/login", api.Login)
 		authGroup.POST("/refresh", api.RefreshToken)
+		authGroup.POST("/forgot-password", api.ForgotPassword)
+		authGroup.POST("/reset-password", api.ResetPassword)
 		
 		// Protected routes requiring authentication
 		protected := authGroup.Group("")
@@ -91,6 +214,15 @@ func (api *AuthAPI) RegisterRoutes(router *gin.Engine, middleware *auth.AuthMidd
 			protected.PUT("/profile", api.UpdateProfile)
 			protected.POST("/change-password", api.ChangePassword)
 			protected.POST("/logout", api.Logout)
+			protected.GET("/sessions", api.ListSessions)
+			protected.DELETE("/sessions/:jti", api.RevokeSession)
+			protected.POST("/api-keys", api.CreateAPIKey)
+			protected.GET("/api-keys", api.ListAPIKeys)
+			protected.DELETE("/api-keys/:id", api.RevokeAPIKey)
+			protected.GET("/users", api.ListUsers)
+			protected.POST("/users/:id/deactivate", api.DeactivateUser)
+			protected.POST("/users/:id/activate", api.ActivateUser)
+			protected.DELETE("/users/:id", api.DeleteUser)
 		}
 	}
 }
@@ -98,12 +230,22 @@ func (api *AuthAPI) RegisterRoutes(router *gin.Engine, middleware *auth.AuthMidd
 // Register handles user registration requests.
 // It validates the registration data, checks for existing users, hashes the password,
 // and creates a new user account in the database.
+// Accounts get the user role, except on a fresh installation: while no user
+// exists, the account registered first becomes the admin, since otherwise no
+// one could manage the server.
 func (api *AuthAPI) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
+	if err := api.authManager.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	api.registerMutex.Lock()
+	defer api.registerMutex.Unlock()
 
 	// Check if username already exists
 	_, err := api.db.GetUserByUsername(req.Username)
@@ -134,22 +276,26 @@ func (api *AuthAPI) Register(c *gin.Context) {
 		return
 	}
 
-	// Create user
+	// Create user; the first account on a fresh installation becomes the admin
 	user := &database.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Password: hashedPassword,
-		Role:     "user",
 		Active:   true,
 	}
 
-	if err := api.db.CreateUser(user); err != nil {
+	if err := api.db.RegisterUser(user); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create user"})
 		return
 	}
+	if user.Role == auth.RoleAdmin {
+		api.logger.Info(fmt.Sprintf("No users existed; registered %q as the initial admin", user.Username))
+	} else {
+		api.logger.Info(fmt.Sprintf("Registered user %q", user.Username))
+	}
 
 	// Generate token
-	token, err := api.authManager.GenerateToken(user.ID, user.Username)
+	token, expiresAt, err := api.IssueToken(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token"})
 		return
@@ -160,7 +306,7 @@ func (api *AuthAPI) Register(c *gin.Context) {
 
 	response := AuthResponse{
 		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // Should match token expiry
+		ExpiresAt: expiresAt,
 		User: UserInfo{
 			ID:        user.ID,
 			Username:  user.Username,
@@ -185,10 +331,18 @@ func (api *AuthAPI) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts while the username or IP is locked out
+	if remaining, locked := api.loginLimiter.Check(req.Username, c.ClientIP()); locked {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
 	// Get user by username
 	user, err := api.db.GetUserByUsername(req.Username)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
+			api.recordFailedLogin(c, req.Username)
 			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials"})
 			return
 		}
@@ -204,12 +358,13 @@ func (api *AuthAPI) Login(c *gin.Context) {
 
 	// Verify password
 	if !api.authManager.VerifyPassword(req.Password, user.Password) {
+		api.recordFailedLogin(c, req.Username)
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials"})
 		return
 	}
 
 	// Generate token
-	token, err := api.authManager.GenerateToken(user.ID, user.Username)
+	token, expiresAt, err := api.IssueToken(c, user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token"})
 		return
@@ -217,10 +372,12 @@ func (api *AuthAPI) Login(c *gin.Context) {
 
 	// Update last login
 	api.db.UpdateUserLastLogin(user.ID)
+	api.loginLimiter.Reset(req.Username)
+	api.logger.Info(fmt.Sprintf("User %q logged in from %s", user.Username, c.ClientIP()))
 
 	response := AuthResponse{
 		Token:     token,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // Should match token expiry
+		ExpiresAt: expiresAt,
 		User: UserInfo{
 			ID:        user.ID,
 			Username:  user.Username,
@@ -235,8 +392,67 @@ func (api *AuthAPI) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// IssueToken issues a token for the user and returns it together with
+// its expiry as encoded in the token's exp claim, so responses never drift
+// from the lifetime configured on the AuthManager. The token is recorded as
+// a session of the user, so every login path can be listed and revoked.
+func (api *AuthAPI) IssueToken(c *gin.Context, user *database.User) (string, time.Time, error) {
+	token, err := api.authManager.GenerateToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims, err := api.authManager.ValidateToken(token)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read token expiry: %w", err)
+	}
+
+	if err := api.recordSession(c, claims); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, claims.ExpiresAt.Time, nil
+}
+
+// recordSession stores the issued token as a session labelled with the
+// client's user agent and IP address. Sessions that have expired are pruned
+// on the way, as their tokens are rejected anyway.
+func (api *AuthAPI) recordSession(c *gin.Context, claims *auth.Claims) error {
+	now := time.Now()
+	if _, err := api.db.DeleteSessionsBefore(now); err != nil {
+		api.logger.Warn(fmt.Sprintf("Failed to prune expired sessions: %v", err))
+	}
+
+	issuedAt := now
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	session := &database.Session{
+		TokenID:   claims.ID,
+		UserID:    claims.UserID,
+		Label:     sessionLabel(c),
+		IssuedAt:  issuedAt,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if err := api.db.CreateSession(session); err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	return nil
+}
+
+// sessionLabel describes the client of a request, e.g. "curl/8.4.0 from 192.0.2.10".
+func sessionLabel(c *gin.Context) string {
+	userAgent := c.Request.UserAgent()
+	if userAgent == "" {
+		userAgent = "Unknown client"
+	}
+	return fmt.Sprintf("%s from %s", userAgent, c.ClientIP())
+}
+
 // RefreshToken handles token refresh requests.
 // It validates the existing token and generates a new one with extended expiry time.
+// Tokens of deactivated or deleted users are not refreshed.
 func (api *AuthAPI) RefreshToken(c *gin.Context) {
 	var req RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -244,30 +460,32 @@ func (api *AuthAPI) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Refresh token
-	newToken, err := api.authManager.RefreshToken(req.Token)
+	claims, err := api.authManager.ValidateToken(req.Token)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired token"})
 		return
 	}
 
-	// Validate new token to get user info
-	claims, err := api.authManager.ValidateToken(newToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to validate new token"})
+	// Deactivated and deleted accounts cannot extend their sessions
+	user, err := api.db.GetUser(claims.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get user"})
+		return
+	}
+	if err != nil || !user.Active {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired token"})
 		return
 	}
 
-	// Get user details
-	user, err := api.db.GetUser(claims.UserID)
+	newToken, expiresAt, err := api.IssueToken(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get user"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token"})
 		return
 	}
 
 	response := AuthResponse{
 		Token:     newToken,
-		ExpiresAt: time.Now().Add(24 * time.Hour), // Should match token expiry
+		ExpiresAt: expiresAt,
 		User: UserInfo{
 			ID:        user.ID,
 			Username:  user.Username,
@@ -391,6 +609,11 @@ func (api *AuthAPI) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if err := api.authManager.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	// Hash new password
 	hashedPassword, err := api.authManager.HashPassword(req.NewPassword)
 	if err != nil {
@@ -405,14 +628,524 @@ func (api *AuthAPI) ChangePassword(c *gin.Context) {
 		return
 	}
 
+	api.logger.Info(fmt.Sprintf("User %q changed their password", user.Username))
 	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
 }
 
+// ForgotPassword issues a time-limited password reset token for the account
+// registered with the given email and hands it to the reset sender.
+// It always responds with 200, so it cannot be used to probe for accounts.
+func (api *AuthAPI) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Failures below are not reported to the caller; they would reveal the account
+	if user, err := api.db.GetUserByEmail(req.Email); err == nil && user.Active && api.resetSender != nil {
+		api.issuePasswordReset(user)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+}
+
+// issuePasswordReset stores a new reset token for the user and delivers it.
+func (api *AuthAPI) issuePasswordReset(user *database.User) {
+	token, tokenHash, err := auth.GenerateResetToken()
+	if err != nil {
+		return
+	}
+
+	expiresAt := time.Now().Add(passwordResetTokenExpiry)
+	if err := api.db.CreatePasswordResetToken(&database.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return
+	}
+
+	api.resetSender.SendPasswordReset(PasswordReset{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// ResetPassword sets a new password using a token issued by ForgotPassword.
+// The token is invalidated on use; unknown, used and expired tokens are rejected alike.
+func (api *AuthAPI) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Checked before the token, so a weak password doesn't use it up
+	if err := api.authManager.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resetToken, err := api.db.GetPasswordResetTokenByHash(auth.HashResetToken(req.Token))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get reset token"})
+		return
+	}
+
+	user, err := api.db.GetUser(resetToken.UserID)
+	if err != nil || !user.Active {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid or expired reset token"})
+		return
+	}
+
+	hashedPassword, err := api.authManager.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to hash password"})
+		return
+	}
+
+	// Consume the token before changing the password, so it can only be used once
+	used, err := api.db.UsePasswordResetToken(resetToken.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to use reset token"})
+		return
+	}
+	if !used {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid or expired reset token"})
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := api.db.UpdateUser(user); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update password"})
+		return
+	}
+
+	api.logger.Info(fmt.Sprintf("User %q reset their password", user.Username))
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
 // Logout handles user logout requests.
-// Currently, this is a placeholder as JWT tokens are stateless.
-// In a production system, you might want to implement token blacklisting.
+// It revokes the token used for the request, so it is rejected by the
+// authentication middleware for the rest of its lifetime.
 func (api *AuthAPI) Logout(c *gin.Context) {
-	// For JWT tokens, logout is typically handled client-side by discarding the token
-	// In a production system, you might want to implement token blacklisting
+	claims, exists := auth.GetClaims(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	if err := api.authManager.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke token"})
+		return
+	}
+	if err := api.db.DeleteSession(claims.ID); err != nil {
+		api.logger.Warn(fmt.Sprintf("Failed to delete session %s: %v", claims.ID, err))
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
-}
\ No newline at end of file
+}
+
+// ListSessions lists the caller's sessions whose tokens haven't expired,
+// most recent first. Revoked sessions are not listed.
+func (api *AuthAPI) ListSessions(c *gin.Context) {
+	claims, exists := auth.GetClaims(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	sessions, err := api.db.ListSessions(claims.UserID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list sessions"})
+		return
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		response[i] = SessionResponse{
+			TokenID:   session.TokenID,
+			Label:     session.Label,
+			IssuedAt:  session.IssuedAt,
+			ExpiresAt: session.ExpiresAt,
+			Current:   session.TokenID == claims.ID,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": response})
+}
+
+// RevokeSession revokes one of the caller's sessions, e.g. on a lost device.
+// The session's token is blacklisted until it expires.
+func (api *AuthAPI) RevokeSession(c *gin.Context) {
+	claims, exists := auth.GetClaims(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "User not authenticated"})
+		return
+	}
+
+	// Sessions of other users are reported as missing, so their IDs can't be probed
+	session, err := api.db.GetSession(c.Param("jti"))
+	if err != nil || session.UserID != claims.UserID {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Session not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get session"})
+		return
+	}
+
+	if err := api.authManager.GetBlacklist().Revoke(session.TokenID, session.ExpiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke session"})
+		return
+	}
+	if err := api.db.DeleteSession(session.TokenID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete session"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateAPIKey creates an API key for automation (admin only).
+// The key acts as its owner, which defaults to the caller. The plaintext key
+// is only part of this response; just its hash is stored.
+func (api *AuthAPI) CreateAPIKey(c *gin.Context) {
+	admin, ok := api.requireAdminUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	scopes := req.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{auth.ScopeRead, auth.ScopeWrite}
+	}
+	for _, scope := range scopes {
+		if !auth.IsValidScope(scope) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Invalid scope %q", scope)})
+			return
+		}
+	}
+
+	ownerID := admin.ID
+	if req.UserID != nil {
+		owner, err := api.db.GetUser(*req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Owner user not found"})
+			return
+		}
+		ownerID = owner.ID
+	}
+
+	key, keyHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate API key"})
+		return
+	}
+
+	apiKey := &database.APIKey{
+		UserID:  ownerID,
+		Name:    req.Name,
+		KeyHash: keyHash,
+		Scopes:  joinList(scopes),
+	}
+	if err := api.db.CreateAPIKey(apiKey); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		APIKeyResponse: newAPIKeyResponse(apiKey),
+		Key:            key,
+	})
+}
+
+// ListAPIKeys lists all API keys, including revoked ones (admin only).
+func (api *AuthAPI) ListAPIKeys(c *gin.Context) {
+	if _, ok := api.requireAdminUser(c); !ok {
+		return
+	}
+
+	keys, err := api.db.ListAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list API keys"})
+		return
+	}
+
+	response := make([]APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = newAPIKeyResponse(&keys[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": response})
+}
+
+// RevokeAPIKey revokes an API key so it no longer authenticates requests (admin only).
+func (api *AuthAPI) RevokeAPIKey(c *gin.Context) {
+	if _, ok := api.requireAdminUser(c); !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid API key ID"})
+		return
+	}
+
+	revoked, err := api.db.RevokeAPIKey(uint(id), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to revoke API key"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "API key not found or already revoked"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUsers lists all user accounts (admin only).
+func (api *AuthAPI) ListUsers(c *gin.Context) {
+	if _, ok := api.requireAdminUser(c); !ok {
+		return
+	}
+
+	users, err := api.db.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to list users"})
+		return
+	}
+
+	response := make([]UserInfo, len(users))
+	for i := range users {
+		response[i] = newUserInfo(&users[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": response})
+}
+
+
+// DeactivateUser disables a user account so it can no longer log in or use its
+// API keys, and revokes its sessions (admin only). Admins cannot deactivate
+// their own account.
+func (api *AuthAPI) DeactivateUser(c *gin.Context) {
+	api.setUserActive(c, false)
+}
+
+// ActivateUser re-enables a deactivated user account (admin only).
+func (api *AuthAPI) ActivateUser(c *gin.Context) {
+	api.setUserActive(c, true)
+}
+
+// setUserActive implements DeactivateUser and ActivateUser.
+func (api *AuthAPI) setUserActive(c *gin.Context, active bool) {
+	admin, ok := api.requireAdminUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := api.lookupUser(c)
+	if !ok {
+		return
+	}
+	if !active && user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Cannot deactivate your own account"})
+		return
+	}
+
+	update := api.db.DeactivateUser
+	if active {
+		update = api.db.ActivateUser
+	}
+	if err := update(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update user"})
+		return
+	}
+	user.Active = active
+
+	if !active {
+		if err := api.revokeUserSessions(user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, newUserInfo(user))
+}
+
+// DeleteUser permanently removes a user account (admin only) and revokes
+// its sessions. Admins cannot delete their own account.
+func (api *AuthAPI) DeleteUser(c *gin.Context) {
+	admin, ok := api.requireAdminUser(c)
+	if !ok {
+		return
+	}
+
+	user, ok := api.lookupUser(c)
+	if !ok {
+		return
+	}
+	if user.ID == admin.ID {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Cannot delete your own account"})
+		return
+	}
+
+	if err := api.revokeUserSessions(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := api.db.DeleteUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete user"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// revokeUserSessions blacklists the tokens of all sessions of a user that
+// haven't expired and removes the sessions, so the user is signed out
+// everywhere.
+// Returns an error if a session cannot be revoked.
+func (api *AuthAPI) revokeUserSessions(userID uint) error {
+	sessions, err := api.db.ListSessions(userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		if err := api.authManager.GetBlacklist().Revoke(session.TokenID, session.ExpiresAt); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		if err := api.db.DeleteSession(session.TokenID); err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+	}
+	return nil
+}
+
+// lookupUser loads the user named by the :id path parameter, writing a 400 or
+// 404 response otherwise.
+// Returns the user and true if the request may proceed.
+func (api *AuthAPI) lookupUser(c *gin.Context) (*database.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid user ID"})
+		return nil, false
+	}
+
+	user, err := api.db.GetUser(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get user"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// ResolveAPIKey looks up the active user an API key acts as and records its use.
+// It implements auth.APIKeyResolver.
+// Returns auth.ErrInvalidAPIKey for unknown or revoked keys and inactive owners.
+func (api *AuthAPI) ResolveAPIKey(key string) (*auth.APIKeyIdentity, error) {
+	apiKey, err := api.db.GetActiveAPIKeyByHash(auth.HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+
+	user, err := api.db.GetUser(apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("failed to look up API key owner: %w", err)
+	}
+	if !user.Active {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	api.db.UpdateAPIKeyLastUsed(apiKey.ID, time.Now())
+
+	return &auth.APIKeyIdentity{
+		KeyID:    apiKey.ID,
+		UserID:   user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Scopes:   parseList(apiKey.Scopes),
+	}, nil
+}
+
+// requireAdminUser loads the authenticated user and checks for the admin role,
+// writing a 401 or 403 response otherwise.
+// Returns the admin user and true if the request may proceed.
+func (api *AuthAPI) requireAdminUser(c *gin.Context) (*database.User, bool) {
+	userID, exists := auth.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "User not authenticated"})
+		return nil, false
+	}
+
+	user, err := api.db.GetUser(userID)
+	if err != nil || !user.Active || user.Role != auth.RoleAdmin {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Admin privileges required"})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// newUserInfo converts a database user into its API representation,
+// leaving out the password hash.
+func newUserInfo(user *database.User) UserInfo {
+	return UserInfo{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		Active:    user.Active,
+		CreatedAt: user.CreatedAt,
+		LastLogin: user.LastLogin,
+	}
+}
+
+// newAPIKeyResponse converts a database API key into its API representation.
+func newAPIKeyResponse(key *database.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Scopes:     parseList(key.Scopes),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// recordFailedLogin counts a failed login attempt towards a lockout and reports
+// it if a recorder is configured.
+func (api *AuthAPI) recordFailedLogin(c *gin.Context, username string) {
+	api.loginLimiter.RecordFailure(username, c.ClientIP())
+	if api.loginRecorder != nil {
+		api.loginRecorder.RecordFailedLogin(username, c.ClientIP())
+	}
+}
@@ -6,10 +6,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -72,7 +76,7 @@ func TestAuthAPI_Register(t *testing.T) {
 		assert.NotEmpty(t, response.Token)
 		assert.Equal(t, "testuser", response.User.Username)
 		assert.Equal(t, "test@example.com", response.User.Email)
-		assert.Equal(t, "user", response.User.Role)
+		assert.Equal(t, "admin", response.User.Role) // First account on an empty database
 		assert.True(t, response.User.Active)
 		
 		// Verify user is in database
@@ -80,6 +84,30 @@ func TestAuthAPI_Register(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "testuser", user.Username)
 		assert.Equal(t, "test@example.com", user.Email)
+		assert.Equal(t, "admin", user.Role)
+	})
+
+	t.Run("should register later users as regular users", func(t *testing.T) {
+		body, err := json.Marshal(RegisterRequest{
+			Username: "seconduser",
+			Email:    "second-user@example.com",
+			Password: "testpassword123",
+		})
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "user", response.User.Role)
+
+		user, err := db.GetUserByUsername("seconduser")
+		require.NoError(t, err)
+		assert.Equal(t, "user", user.Role)
 	})
 
 	t.Run("should reject registration with existing username", func(t *testing.T) {
@@ -307,6 +335,142 @@ func TestAuthAPI_Login(t *testing.T) {
 	})
 }
 
+func TestAuthAPI_LoginLockout(t *testing.T) {
+	db, authManager, api, router := setupAuthTest(t)
+	defer os.Remove(":memory:")
+
+	api.SetLoginLimiter(auth.NewLoginLimiterWithConfig(&auth.LoginLimiterConfig{
+		MaxAttempts:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}))
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	for _, username := range []string{"testuser", "otheruser"} {
+		require.NoError(t, db.CreateUser(&database.User{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: hashedPassword,
+			Role:     "user",
+			Active:   true,
+		}))
+	}
+
+	login := func(username, password, remoteAddr string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(LoginRequest{Username: username, Password: password})
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should lock account after repeated bad passwords", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("testuser", "wrongpassword", "192.0.2.1:1234").Code)
+		}
+
+		w := login("testuser", "wrongpassword", "192.0.2.1:1234")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("should reject correct password during lockout", func(t *testing.T) {
+		// A different IP doesn't help, the username itself is locked
+		w := login("testuser", "testpassword123", "198.51.100.1:1234")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Contains(t, response.Error, "Too many failed login attempts")
+	})
+
+	t.Run("should lock IP guessing across usernames", func(t *testing.T) {
+		for _, username := range []string{"alice", "bob", "carol"} {
+			assert.Equal(t, http.StatusUnauthorized, login(username, "wrongpassword", "203.0.113.1:1234").Code)
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, login("otheruser", "testpassword123", "203.0.113.1:1234").Code)
+		assert.Equal(t, http.StatusOK, login("otheruser", "testpassword123", "203.0.113.2:1234").Code)
+	})
+
+	t.Run("should reset the username failures on successful login", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("otheruser", "wrongpassword", "203.0.113.3:1234").Code)
+		}
+		assert.Equal(t, http.StatusOK, login("otheruser", "testpassword123", "203.0.113.3:1234").Code)
+
+		for i := 0; i < 2; i++ {
+			assert.Equal(t, http.StatusUnauthorized, login("otheruser", "wrongpassword", "203.0.113.4:1234").Code)
+		}
+		assert.Equal(t, http.StatusOK, login("otheruser", "testpassword123", "203.0.113.4:1234").Code)
+	})
+
+	t.Run("should keep the IP failures on successful login", func(t *testing.T) {
+		// 203.0.113.3 failed twice above; a third failure locks it
+		assert.Equal(t, http.StatusUnauthorized, login("dave", "wrongpassword", "203.0.113.3:1234").Code)
+		assert.Equal(t, http.StatusTooManyRequests, login("otheruser", "testpassword123", "203.0.113.3:1234").Code)
+	})
+}
+
+func TestAuthAPI_TokenExpiry(t *testing.T) {
+	db, err := database.New(":memory:")
+	require.NoError(t, err)
+
+	authManager := auth.NewAuthManagerWithConfig("test-secret", time.Hour)
+	api := NewAuthAPI(db, authManager)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api.RegisterRoutes(router, auth.NewAuthMiddleware(authManager))
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	user := &database.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: hashedPassword,
+		Role:     "user",
+		Active:   true,
+	}
+	require.NoError(t, db.CreateUser(user))
+
+	post := func(t *testing.T, path string, reqBody interface{}) AuthResponse {
+		body, err := json.Marshal(reqBody)
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	assertMatchesClaim := func(t *testing.T, response AuthResponse) {
+		claims, err := authManager.ValidateToken(response.Token)
+		require.NoError(t, err)
+
+		assert.True(t, response.ExpiresAt.Equal(claims.ExpiresAt.Time))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), response.ExpiresAt, time.Minute)
+	}
+
+	t.Run("should report the token's expiry on login", func(t *testing.T) {
+		response := post(t, "/api/auth/login", LoginRequest{Username: "testuser", Password: "testpassword123"})
+		assertMatchesClaim(t, response)
+	})
+
+	t.Run("should report the token's expiry on refresh", func(t *testing.T) {
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
+		require.NoError(t, err)
+
+		response := post(t, "/api/auth/refresh", RefreshTokenRequest{Token: token})
+		assertMatchesClaim(t, response)
+	})
+}
+
 func TestAuthAPI_RefreshToken(t *testing.T) {
 	db, authManager, _, router := setupAuthTest(t)
 	defer os.Remove(":memory:")
@@ -324,7 +488,7 @@ func TestAuthAPI_RefreshToken(t *testing.T) {
 
 	t.Run("should refresh token successfully", func(t *testing.T) {
 		// Generate initial token
-		token, err := authManager.GenerateToken(user.ID, user.Username)
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
 		require.NoError(t, err)
 
 		reqBody := RefreshTokenRequest{
@@ -389,7 +553,7 @@ func TestAuthAPI_GetProfile(t *testing.T) {
 
 	t.Run("should get profile successfully", func(t *testing.T) {
 		// Generate token
-		token, err := authManager.GenerateToken(user.ID, user.Username)
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
 		require.NoError(t, err)
 
 		req, _ := http.NewRequest("GET", "/api/auth/profile", nil)
@@ -437,7 +601,7 @@ func TestAuthAPI_UpdateProfile(t *testing.T) {
 
 	t.Run("should update profile successfully", func(t *testing.T) {
 		// Generate token
-		token, err := authManager.GenerateToken(user.ID, user.Username)
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
 		require.NoError(t, err)
 
 		reqBody := UpdateProfileRequest{
@@ -486,7 +650,7 @@ func TestAuthAPI_ChangePassword(t *testing.T) {
 
 	t.Run("should change password successfully", func(t *testing.T) {
 		// Generate token
-		token, err := authManager.GenerateToken(user.ID, user.Username)
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
 		require.NoError(t, err)
 
 		reqBody := ChangePasswordRequest{
@@ -515,7 +679,7 @@ func TestAuthAPI_ChangePassword(t *testing.T) {
 
 	t.Run("should reject with wrong current password", func(t *testing.T) {
 		// Generate token
-		token, err := authManager.GenerateToken(user.ID, user.Username)
+		token, err := authManager.GenerateToken(user.ID, user.Username, user.Role)
 		require.NoError(t, err)
 
 		reqBody := ChangePasswordRequest{
@@ -540,4 +704,706 @@ func TestAuthAPI_ChangePassword(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "Current password is incorrect", response.Error)
 	})
-}
\ No newline at end of file
+}
+func TestAuthAPI_Logout(t *testing.T) {
+	db, authManager, _, router := setupAuthTest(t)
+	defer os.Remove(":memory:")
+
+	// Persist revocations like the web server does
+	blacklist, err := auth.NewTokenBlacklistWithStore(db)
+	require.NoError(t, err)
+	authManager.SetBlacklist(blacklist)
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	user := &database.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: hashedPassword,
+		Role:     "user",
+		Active:   true,
+	}
+	require.NoError(t, db.CreateUser(user))
+
+	login := func(t *testing.T) string {
+		body, err := json.Marshal(LoginRequest{Username: "testuser", Password: "testpassword123"})
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Token
+	}
+
+	authorized := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject the token after logout", func(t *testing.T) {
+		token := login(t)
+		assert.Equal(t, http.StatusOK, authorized("GET", "/api/auth/profile", token).Code)
+
+		assert.Equal(t, http.StatusOK, authorized("POST", "/api/auth/logout", token).Code)
+
+		w := authorized("GET", "/api/auth/profile", token)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "revoked")
+
+		assert.Equal(t, http.StatusUnauthorized, authorized("POST", "/api/auth/logout", token).Code)
+	})
+
+	t.Run("should keep other sessions valid", func(t *testing.T) {
+		first := login(t)
+		second := login(t)
+
+		assert.Equal(t, http.StatusOK, authorized("POST", "/api/auth/logout", first).Code)
+		assert.Equal(t, http.StatusOK, authorized("GET", "/api/auth/profile", second).Code)
+	})
+
+	t.Run("should keep the token revoked after reloading from the database", func(t *testing.T) {
+		token := login(t)
+		assert.Equal(t, http.StatusOK, authorized("POST", "/api/auth/logout", token).Code)
+
+		reloaded, err := auth.NewTokenBlacklistWithStore(db)
+		require.NoError(t, err)
+		authManager.SetBlacklist(reloaded)
+
+		assert.Equal(t, http.StatusUnauthorized, authorized("GET", "/api/auth/profile", token).Code)
+	})
+}
+
+func TestAuthAPI_Sessions(t *testing.T) {
+	db, authManager, _, router := setupAuthTest(t)
+	defer os.Remove(":memory:")
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	for _, username := range []string{"testuser", "otheruser"} {
+		require.NoError(t, db.CreateUser(&database.User{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: hashedPassword,
+			Role:     "user",
+			Active:   true,
+		}))
+	}
+
+	login := func(t *testing.T, username, userAgent string) string {
+		body, err := json.Marshal(LoginRequest{Username: username, Password: "testpassword123"})
+		require.NoError(t, err)
+
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Token
+	}
+
+	authorized := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	listSessions := func(t *testing.T, token string) []SessionResponse {
+		w := authorized("GET", "/api/auth/sessions", token)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Sessions []SessionResponse `json:"sessions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Sessions
+	}
+
+	laptop := login(t, "testuser", "laptop-browser")
+	phone := login(t, "testuser", "phone-app")
+
+	t.Run("should list the user's sessions", func(t *testing.T) {
+		sessions := listSessions(t, laptop)
+		require.Len(t, sessions, 2)
+
+		labels := sessions[0].Label + "\n" + sessions[1].Label
+		assert.Contains(t, labels, "laptop-browser")
+		assert.Contains(t, labels, "phone-app")
+		for _, session := range sessions {
+			assert.NotEmpty(t, session.TokenID)
+			assert.False(t, session.IssuedAt.IsZero())
+			assert.True(t, session.ExpiresAt.After(session.IssuedAt))
+			assert.Equal(t, strings.Contains(session.Label, "laptop-browser"), session.Current)
+		}
+	})
+
+	t.Run("should not list other users' sessions", func(t *testing.T) {
+		other := login(t, "otheruser", "other-browser")
+
+		sessions := listSessions(t, other)
+		require.Len(t, sessions, 1)
+		assert.Contains(t, sessions[0].Label, "other-browser")
+	})
+
+	t.Run("should reject only the revoked session's token", func(t *testing.T) {
+		var phoneSession SessionResponse
+		for _, session := range listSessions(t, laptop) {
+			if strings.Contains(session.Label, "phone-app") {
+				phoneSession = session
+			}
+		}
+		require.NotEmpty(t, phoneSession.TokenID)
+
+		w := authorized("DELETE", "/api/auth/sessions/"+phoneSession.TokenID, laptop)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, authorized("GET", "/api/auth/profile", phone).Code)
+		assert.Equal(t, http.StatusOK, authorized("GET", "/api/auth/profile", laptop).Code)
+
+		sessions := listSessions(t, laptop)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+	})
+
+	t.Run("should return not found for another user's session", func(t *testing.T) {
+		other := login(t, "otheruser", "other-browser")
+		sessions := listSessions(t, laptop)
+		require.Len(t, sessions, 1)
+
+		w := authorized("DELETE", "/api/auth/sessions/"+sessions[0].TokenID, other)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, http.StatusOK, authorized("GET", "/api/auth/profile", laptop).Code)
+	})
+
+	t.Run("should return not found for an unknown session", func(t *testing.T) {
+		w := authorized("DELETE", "/api/auth/sessions/unknown", laptop)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAuthAPI_PasswordPolicy(t *testing.T) {
+	db, authManager, api, router := setupAuthTest(t)
+	defer os.Remove(":memory:")
+
+	authManager.SetPasswordPolicy(&auth.PasswordPolicy{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		BlockCommon:   true,
+	})
+	sender := &fakePasswordResetSender{}
+	api.SetPasswordResetSender(sender)
+
+	send := func(method, path, token string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assertRejected := func(t *testing.T, w *httptest.ResponseRecorder, unmet ...string) {
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, (&auth.PasswordPolicyError{Unmet: unmet}).Error(), response.Error)
+	}
+
+	register := func(password string) *httptest.ResponseRecorder {
+		return send("POST", "/api/auth/register", "", RegisterRequest{
+			Username: "testuser",
+			Email:    "test@example.com",
+			Password: password,
+		})
+	}
+
+	t.Run("should reject registration with each unmet requirement", func(t *testing.T) {
+		testCases := []struct {
+			password string
+			unmet    string
+		}{
+			{password: "Sh0rt!", unmet: "at least 10 characters"},
+			{password: "no-upper-case-1", unmet: "an uppercase letter"},
+			{password: "NO-LOWER-CASE-1", unmet: "a lowercase letter"},
+			{password: "No-Digits-Here", unmet: "a digit"},
+			{password: "NoSymbolsHere1", unmet: "a symbol"},
+		}
+
+		for _, tc := range testCases {
+			assertRejected(t, register(tc.password), tc.unmet)
+		}
+
+		count, err := db.CountUsers()
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	t.Run("should list every unmet requirement", func(t *testing.T) {
+		assertRejected(t, register("password"),
+			"at least 10 characters", "an uppercase letter", "a digit", "a symbol", "not a commonly used password")
+	})
+
+	var token string
+	t.Run("should register with a compliant password", func(t *testing.T) {
+		w := register("Correct-Horse-42")
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		token = response.Token
+	})
+
+	t.Run("should enforce the policy when changing the password", func(t *testing.T) {
+		w := send("POST", "/api/auth/change-password", token, ChangePasswordRequest{
+			CurrentPassword: "Correct-Horse-42",
+			NewPassword:     "weakpassword",
+		})
+		assertRejected(t, w, "an uppercase letter", "a digit", "a symbol")
+
+		w = send("POST", "/api/auth/change-password", token, ChangePasswordRequest{
+			CurrentPassword: "Correct-Horse-42",
+			NewPassword:     "Battery-Staple-7",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should enforce the policy when resetting the password", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, send("POST", "/api/auth/forgot-password", "", ForgotPasswordRequest{Email: "test@example.com"}).Code)
+		require.Len(t, sender.resets, 1)
+		resetToken := sender.resets[0].Token
+
+		w := send("POST", "/api/auth/reset-password", "", ResetPasswordRequest{Token: resetToken, NewPassword: "weakpassword"})
+		assertRejected(t, w, "an uppercase letter", "a digit", "a symbol")
+
+		// The rejected attempt doesn't use up the token
+		w = send("POST", "/api/auth/reset-password", "", ResetPasswordRequest{Token: resetToken, NewPassword: "Tr0ub4dor&3x"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusOK, send("POST", "/api/auth/login", "", LoginRequest{Username: "testuser", Password: "Tr0ub4dor&3x"}).Code)
+	})
+}
+
+// fakePasswordResetSender records password resets instead of delivering them.
+type fakePasswordResetSender struct {
+	resets []PasswordReset
+}
+
+func (f *fakePasswordResetSender) SendPasswordReset(reset PasswordReset) error {
+	f.resets = append(f.resets, reset)
+	return nil
+}
+
+func TestAuthAPI_PasswordReset(t *testing.T) {
+	db, authManager, api, router := setupAuthTest(t)
+	defer os.Remove(":memory:")
+
+	sender := &fakePasswordResetSender{}
+	api.SetPasswordResetSender(sender)
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	user := &database.User{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: hashedPassword,
+		Role:     "user",
+		Active:   true,
+	}
+	require.NoError(t, db.CreateUser(user))
+
+	post := func(path string, payload interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	requestReset := func(t *testing.T) string {
+		before := len(sender.resets)
+		require.Equal(t, http.StatusOK, post("/api/auth/forgot-password", ForgotPasswordRequest{Email: "test@example.com"}).Code)
+		require.Len(t, sender.resets, before+1)
+		return sender.resets[before].Token
+	}
+
+	t.Run("should reset password with a valid token", func(t *testing.T) {
+		token := requestReset(t)
+		reset := sender.resets[len(sender.resets)-1]
+		assert.Equal(t, user.ID, reset.UserID)
+		assert.Equal(t, "test@example.com", reset.Email)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), reset.ExpiresAt, time.Minute)
+
+		// Only the hash of the token is stored
+		_, err := db.GetPasswordResetTokenByHash(token)
+		assert.Error(t, err)
+
+		w := post("/api/auth/reset-password", ResetPasswordRequest{Token: token, NewPassword: "newpassword456"})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, post("/api/auth/login", LoginRequest{Username: "testuser", Password: "testpassword123"}).Code)
+		assert.Equal(t, http.StatusOK, post("/api/auth/login", LoginRequest{Username: "testuser", Password: "newpassword456"}).Code)
+	})
+
+	t.Run("should reject a reused token", func(t *testing.T) {
+		token := requestReset(t)
+		require.Equal(t, http.StatusOK, post("/api/auth/reset-password", ResetPasswordRequest{Token: token, NewPassword: "anotherpassword1"}).Code)
+
+		w := post("/api/auth/reset-password", ResetPasswordRequest{Token: token, NewPassword: "attackerpassword1"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusOK, post("/api/auth/login", LoginRequest{Username: "testuser", Password: "anotherpassword1"}).Code)
+	})
+
+	t.Run("should reject an expired token", func(t *testing.T) {
+		token := requestReset(t)
+		require.NoError(t, db.Model(&database.PasswordResetToken{}).
+			Where("token_hash = ?", auth.HashResetToken(token)).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		w := post("/api/auth/reset-password", ResetPasswordRequest{Token: token, NewPassword: "newpassword789"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Invalid or expired reset token", response.Error)
+	})
+
+	t.Run("should invalidate earlier tokens when a new one is issued", func(t *testing.T) {
+		first := requestReset(t)
+		second := requestReset(t)
+
+		assert.Equal(t, http.StatusBadRequest, post("/api/auth/reset-password", ResetPasswordRequest{Token: first, NewPassword: "newpassword789"}).Code)
+		assert.Equal(t, http.StatusOK, post("/api/auth/reset-password", ResetPasswordRequest{Token: second, NewPassword: "newpassword789"}).Code)
+	})
+
+	t.Run("should not reveal whether an email exists", func(t *testing.T) {
+		before := len(sender.resets)
+
+		known := post("/api/auth/forgot-password", ForgotPasswordRequest{Email: "test@example.com"})
+		unknown := post("/api/auth/forgot-password", ForgotPasswordRequest{Email: "nobody@example.com"})
+
+		assert.Equal(t, http.StatusOK, unknown.Code)
+		assert.Equal(t, known.Body.String(), unknown.Body.String())
+		assert.Len(t, sender.resets, before+1)
+	})
+
+	t.Run("should reject unknown tokens", func(t *testing.T) {
+		w := post("/api/auth/reset-password", ResetPasswordRequest{Token: "not-a-token", NewPassword: "newpassword789"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthAPI_APIKeys(t *testing.T) {
+	db, err := database.New(":memory:")
+	require.NoError(t, err)
+
+	authManager := auth.NewAuthManager("test-secret")
+	api := NewAuthAPI(db, authManager)
+	middleware := auth.NewAuthMiddleware(authManager)
+	middleware.SetAPIKeyResolver(api)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api.RegisterRoutes(router, middleware)
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	admin := &database.User{Username: "admin", Email: "admin@example.com", Password: hashedPassword, Role: "admin", Active: true}
+	require.NoError(t, db.CreateUser(admin))
+	operator := &database.User{Username: "operator", Email: "operator@example.com", Password: hashedPassword, Role: "user", Active: true}
+	require.NoError(t, db.CreateUser(operator))
+
+	adminToken, err := authManager.GenerateToken(admin.ID, admin.Username, admin.Role)
+	require.NoError(t, err)
+	operatorToken, err := authManager.GenerateToken(operator.ID, operator.Username, operator.Role)
+	require.NoError(t, err)
+
+	withToken := func(method, path, token string, reqBody interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	withKey := func(method, path, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	createKey := func(t *testing.T, reqBody CreateAPIKeyRequest) CreateAPIKeyResponse {
+		w := withToken("POST", "/api/auth/api-keys", adminToken, reqBody)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response CreateAPIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("should create key and show plaintext only once", func(t *testing.T) {
+		created := createKey(t, CreateAPIKeyRequest{Name: "ci", UserID: &operator.ID})
+		assert.NotEmpty(t, created.Key)
+		assert.Equal(t, operator.ID, created.UserID)
+		assert.Equal(t, []string{"read", "write"}, created.Scopes)
+
+		stored, err := db.GetActiveAPIKeyByHash(auth.HashAPIKey(created.Key))
+		require.NoError(t, err)
+		assert.NotEqual(t, created.Key, stored.KeyHash)
+
+		w := withToken("GET", "/api/auth/api-keys", adminToken, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), created.Key)
+		assert.Contains(t, w.Body.String(), `"name":"ci"`)
+	})
+
+	t.Run("should authenticate requests as the key owner", func(t *testing.T) {
+		created := createKey(t, CreateAPIKeyRequest{Name: "profile-reader", Scopes: []string{"read"}, UserID: &operator.ID})
+
+		w := withKey("GET", "/api/auth/profile", created.Key)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"username":"operator"`)
+
+		stored, err := db.GetActiveAPIKeyByHash(auth.HashAPIKey(created.Key))
+		require.NoError(t, err)
+		assert.NotNil(t, stored.LastUsedAt)
+
+		assert.Equal(t, http.StatusForbidden, withKey("POST", "/api/auth/logout", created.Key).Code)
+	})
+
+	t.Run("should reject revoked keys", func(t *testing.T) {
+		created := createKey(t, CreateAPIKeyRequest{Name: "temporary"})
+		assert.Equal(t, http.StatusOK, withKey("GET", "/api/auth/profile", created.Key).Code)
+
+		w := withToken("DELETE", fmt.Sprintf("/api/auth/api-keys/%d", created.ID), adminToken, nil)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		assert.Equal(t, http.StatusUnauthorized, withKey("GET", "/api/auth/profile", created.Key).Code)
+
+		w = withToken("DELETE", fmt.Sprintf("/api/auth/api-keys/%d", created.ID), adminToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should restrict key management to admins", func(t *testing.T) {
+		w := withToken("POST", "/api/auth/api-keys", operatorToken, CreateAPIKeyRequest{Name: "sneaky"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = withToken("GET", "/api/auth/api-keys", operatorToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("should reject unknown scopes", func(t *testing.T) {
+		w := withToken("POST", "/api/auth/api-keys", adminToken, CreateAPIKeyRequest{Name: "bad", Scopes: []string{"root"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAuthAPI_UserManagement(t *testing.T) {
+	db, authManager, _, router := setupAuthTest(t)
+
+	hashedPassword, _ := authManager.HashPassword("testpassword123")
+	admin := &database.User{Username: "admin", Email: "admin@example.com", Password: hashedPassword, Role: "admin", Active: true}
+	require.NoError(t, db.CreateUser(admin))
+	operator := &database.User{Username: "operator", Email: "operator@example.com", Password: hashedPassword, Role: "user", Active: true}
+	require.NoError(t, db.CreateUser(operator))
+
+	adminToken, err := authManager.GenerateToken(admin.ID, admin.Username, admin.Role)
+	require.NoError(t, err)
+	operatorToken, err := authManager.GenerateToken(operator.ID, operator.Username, operator.Role)
+	require.NoError(t, err)
+
+	withToken := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should list users without password hashes", func(t *testing.T) {
+		w := withToken("GET", "/api/auth/users", adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), hashedPassword)
+		assert.NotContains(t, w.Body.String(), "password")
+
+		var response struct {
+			Users []UserInfo `json:"users"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Users, 2)
+		assert.Equal(t, "admin", response.Users[0].Username)
+		assert.Equal(t, "operator", response.Users[1].Username)
+	})
+
+	t.Run("should deactivate and reactivate a user", func(t *testing.T) {
+		w := withToken("POST", fmt.Sprintf("/api/auth/users/%d/deactivate", operator.ID), adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		var info UserInfo
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.False(t, info.Active)
+
+		_, err := db.AuthenticateUser("operator", "testpassword123")
+		assert.Error(t, err, "deactivated users cannot log in")
+
+		w = withToken("POST", fmt.Sprintf("/api/auth/users/%d/activate", operator.ID), adminToken)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.True(t, info.Active)
+
+		_, err = db.AuthenticateUser("operator", "testpassword123")
+		assert.NoError(t, err)
+	})
+
+	login := func(username string) string {
+		body, _ := json.Marshal(LoginRequest{Username: username, Password: "testpassword123"})
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AuthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Token
+	}
+	refresh := func(token string) int {
+		body, _ := json.Marshal(RefreshTokenRequest{Token: token})
+		req, _ := http.NewRequest("POST", "/api/auth/refresh", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("should revoke the sessions of a deactivated user", func(t *testing.T) {
+		sessionToken := login("operator")
+		require.Equal(t, http.StatusOK, withToken("GET", "/api/auth/profile", sessionToken).Code)
+
+		require.Equal(t, http.StatusOK, withToken("POST", fmt.Sprintf("/api/auth/users/%d/deactivate", operator.ID), adminToken).Code)
+
+		assert.Equal(t, http.StatusUnauthorized, withToken("GET", "/api/auth/profile", sessionToken).Code)
+		sessions, err := db.ListSessions(operator.ID, time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+
+		// Tokens issued outside a session can't be refreshed while deactivated
+		assert.Equal(t, http.StatusUnauthorized, refresh(operatorToken))
+
+		require.Equal(t, http.StatusOK, withToken("POST", fmt.Sprintf("/api/auth/users/%d/activate", operator.ID), adminToken).Code)
+		assert.Equal(t, http.StatusOK, refresh(operatorToken))
+	})
+
+	t.Run("should prevent admins from deactivating or deleting themselves", func(t *testing.T) {
+		w := withToken("POST", fmt.Sprintf("/api/auth/users/%d/deactivate", admin.ID), adminToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "your own account")
+
+		w = withToken("DELETE", fmt.Sprintf("/api/auth/users/%d", admin.ID), adminToken)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		stored, err := db.GetUser(admin.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Active)
+	})
+
+	t.Run("should restrict user management to admins", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, withToken("GET", "/api/auth/users", operatorToken).Code)
+		assert.Equal(t, http.StatusForbidden, withToken("POST", fmt.Sprintf("/api/auth/users/%d/deactivate", admin.ID), operatorToken).Code)
+		assert.Equal(t, http.StatusForbidden, withToken("POST", fmt.Sprintf("/api/auth/users/%d/activate", operator.ID), operatorToken).Code)
+		assert.Equal(t, http.StatusForbidden, withToken("DELETE", fmt.Sprintf("/api/auth/users/%d", admin.ID), operatorToken).Code)
+
+		req, _ := http.NewRequest("GET", "/api/auth/users", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should reject unknown and invalid user IDs", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, withToken("POST", "/api/auth/users/9999/deactivate", adminToken).Code)
+		assert.Equal(t, http.StatusNotFound, withToken("DELETE", "/api/auth/users/9999", adminToken).Code)
+		assert.Equal(t, http.StatusBadRequest, withToken("DELETE", "/api/auth/users/abc", adminToken).Code)
+	})
+
+	t.Run("should delete a user", func(t *testing.T) {
+		sessionToken := login("operator")
+
+		w := withToken("DELETE", fmt.Sprintf("/api/auth/users/%d", operator.ID), adminToken)
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, http.StatusUnauthorized, withToken("GET", "/api/auth/profile", sessionToken).Code)
+		assert.Equal(t, http.StatusUnauthorized, refresh(operatorToken))
+
+		_, err := db.GetUser(operator.ID)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, withToken("DELETE", fmt.Sprintf("/api/auth/users/%d", operator.ID), adminToken).Code)
+	})
+}
+
+func TestAuthAPI_RegisterInitialAdmin(t *testing.T) {
+	t.Run("should make only one of concurrent first registrations admin", func(t *testing.T) {
+		db, _, _, router := setupAuthTest(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				body, _ := json.Marshal(RegisterRequest{
+					Username: fmt.Sprintf("user%d", i),
+					Email:    fmt.Sprintf("user%d@example.com", i),
+					Password: "testpassword123",
+				})
+				req, _ := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(body))
+				req.Header.Set("Content-Type", "application/json")
+				router.ServeHTTP(httptest.NewRecorder(), req)
+			}(i)
+		}
+		wg.Wait()
+
+		users, err := db.ListUsers()
+		require.NoError(t, err)
+		require.Len(t, users, 5)
+
+		admins := 0
+		for _, user := range users {
+			if user.Role == "admin" {
+				admins++
+			}
+		}
+		assert.Equal(t, 1, admins)
+	})
+
+	t.Run("should not make anyone admin once users exist", func(t *testing.T) {
+		db, authManager, _, router := setupAuthTest(t)
+		hashedPassword, _ := authManager.HashPassword("testpassword123")
+		require.NoError(t, db.CreateUser(&database.User{Username: "existing", Email: "existing@example.com", Password: hashedPassword, Role: "user", Active: true}))
+
+		body, _ := json.Marshal(RegisterRequest{Username: "newcomer", Email: "newcomer@example.com", Password: "testpassword123"})
+		req, _ := http.NewRequest("POST", "/api/auth/register", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		user, err := db.GetUserByUsername("newcomer")
+		require.NoError(t, err)
+		assert.Equal(t, "user", user.Role)
+	})
+}
@@ -4,16 +4,26 @@
 package api
 
 import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
 	"my-vpn/internal/network"
+	"my-vpn/internal/system"
 	"my-vpn/internal/utils"
 	"my-vpn/internal/wireguard"
 )
@@ -22,14 +32,190 @@ import (
 // It handles client creation, configuration, and lifecycle management operations,
 // integrating with the database, IP pool, and WireGuard server components.
 type ClientAPI struct {
-	db       *database.Database         // Database interface for client data persistence
-	ipPool   *network.IPPool            // IP address pool for client IP allocation
-	wgServer *wireguard.WireGuardServer // WireGuard server instance for peer management
+	db         *database.Database         // Database interface for client data persistence
+	ipPool     *network.IPPool            // IP address pool for client IP allocation
+	wgServer   *wireguard.WireGuardServer // WireGuard server instance for peer management
+	peerSource PeerStatusSource           // Source of live peer status (defaults to wgServer)
+	peers      PeerManager                // Applies peers to the WireGuard interface (defaults to wgServer)
+	alerts     *monitoring.AlertManager   // Optional alert manager for peer failures
+	notifier   ActivationNotifier         // Optional notifier for queued clients that received an IP
+	queueMutex sync.Mutex                 // Serializes assignment of IPs to queued clients
+	config     *ClientAPIConfig           // Behavior configuration for client management
+	rateLimitSupport func() error         // Reports whether rate limits can be enforced (defaults to system.CheckRateLimitSupport)
+	shaper     RateLimitShaper            // Enforces client rate limits in the firewall (nil refuses rate limits)
+	qrCache    *utils.QRCodeCache         // Generated QR codes by config hash (nil when caching is disabled)
+	logger     *monitoring.ComponentLogger // Records client changes (nil writes to the standard logger)
 }
 
+// ClientAPIConfig represents configuration options for client management behavior.
+type ClientAPIConfig struct {
+	TagKeepalive            map[string]int `json:"tag_keepalive"`             // Default PersistentKeepalive (seconds) per client tag
+	PeerFailurePolicy       string         `json:"peer_failure_policy"`       // What to do when AddPeer fails: "lenient" or "strict"
+	PoolExhaustionPolicy    string         `json:"pool_exhaustion_policy"`    // What to do when the IP pool is exhausted: "reject" or "queue"
+	QueueWebhookURL         string         `json:"queue_webhook_url"`         // Webhook notified when a queued client is assigned an IP (empty disables)
+	RejectIncompleteConfigs bool           `json:"reject_incomplete_configs"` // Refuse to render configs with an empty server key/endpoint or placeholder values
+	DNSViaServer            bool           `json:"dns_via_server"`            // Default client DNS to the server's VPN IP instead of public resolvers
+	QRCodeCacheSize         int            `json:"qr_code_cache_size"`        // Number of generated QR codes kept in memory (0 disables caching)
+}
+
+// Policies for handling AddPeer failures during client creation
+const (
+	PeerFailureLenient = "lenient" // Keep the client, flag it as peer not applied and raise an alert
+	PeerFailureStrict  = "strict"  // Roll back the client and its IP if the interface is running
+)
+
+// Policies for handling IP pool exhaustion during client creation
+const (
+	PoolExhaustionReject = "reject" // Fail the creation request
+	PoolExhaustionQueue  = "queue"  // Create a pending client that gets an IP once one is released
+)
+
+// DefaultClientAPIConfig returns the default client API configuration.
+// Clients tagged "mobile" get a 25 second keepalive to survive strict NAT,
+// while all other clients get no keepalive unless they set one explicitly.
+// AddPeer failures are handled leniently so clients can be created while
+// WireGuard is unavailable, and creation fails once the IP pool is exhausted.
+// Configs that a client could not connect with are never rendered, and clients
+// without an explicit DNS setting use public resolvers. Up to 256 generated QR
+// codes are cached.
+func DefaultClientAPIConfig() *ClientAPIConfig {
+	return &ClientAPIConfig{
+		TagKeepalive: map[string]int{
+			"mobile": 25,
+		},
+		PeerFailurePolicy:       PeerFailureLenient,
+		PoolExhaustionPolicy:    PoolExhaustionReject,
+		RejectIncompleteConfigs: true,
+		QRCodeCacheSize:         256,
+	}
+}
+
+// PeerStatusSource reports the handshake, endpoint and transfer counters of each
+// peer on the running WireGuard interface, from which client connection states
+// are derived.
+type PeerStatusSource interface {
+	GetPeerStatus() ([]wireguard.PeerStatus, error)
+}
+
+// PeerManager keeps the peers of the WireGuard interface in step with the
+// clients: it edits the peers in the configuration file and applies the file
+// to the interface when it is running.
+type PeerManager interface {
+	IsRunning() bool
+	AddPeer(peer *wireguard.Peer) error
+	RemovePeer(publicKey string) error
+	HasPeer(publicKey string) (bool, error)
+	ApplyConfig() error
+}
+
+// RateLimitShaper enforces the bandwidth limits of clients in the firewall.
+// It is satisfied by *system.PfctlManager.
+type RateLimitShaper interface {
+	ApplyRateLimits(config *system.VPNConfig) error
+}
+
+// ClientActivation describes a queued client that was assigned an IP address.
+type ClientActivation struct {
+	ClientID  uint      `json:"client_id"`  // ID of the activated client
+	Name      string    `json:"name"`       // Name of the activated client
+	IPAddress string    `json:"ip_address"` // IP address assigned to the client
+	Timestamp time.Time `json:"timestamp"`  // When the client was activated
+}
+
+// ActivationNotifier is notified when a queued client is assigned an IP address.
+type ActivationNotifier interface {
+	NotifyClientActivated(activation ClientActivation) error
+}
+
+// webhookActivationNotifier posts client activations to a webhook.
+type webhookActivationNotifier struct {
+	webhook *monitoring.WebhookNotifier
+}
+
+// NotifyClientActivated posts the activation to the webhook.
+func (n *webhookActivationNotifier) NotifyClientActivated(activation ClientActivation) error {
+	return n.webhook.Post(activation)
+}
+
+// Connection states reported in ClientStatusResponse.
+const (
+	ConnectionStateConnected = "connected" // Handshake within the active window
+	ConnectionStateStale     = "stale"     // Peer is known but the last handshake is too old
+	ConnectionStateNever     = "never"     // Peer has never completed a handshake
+	ConnectionStateUnknown   = "unknown"   // Live status could not be retrieved
+)
+
+// Limits for the number of clients returned per page by GetClients.
+const (
+	defaultClientsPageLimit = 100
+	maxClientsPageLimit     = 500
+)
+
+// maxClientSearchResults caps the number of clients returned by SearchClients.
+const maxClientSearchResults = 50
+
+// Limits for the number of clients returned by the top talkers endpoint.
+const (
+	defaultTopClientsLimit = 10
+	maxTopClientsLimit     = 100
+)
+
+// errEndpointNotConfigured is returned when a client configuration is requested
+// before the server's public endpoint has been set.
+var errEndpointNotConfigured = errors.New("server endpoint is not configured; set it via the server configuration first")
+
+// errClientPending is returned when a client configuration is requested for a
+// queued client that has no IP address yet.
+var errClientPending = errors.New("client is waiting for an IP address")
+
+// errClientExpired is returned when a client configuration is requested for a
+// client whose expiry date has passed.
+var errClientExpired = errors.New("client access has expired")
+
+// errEndpointNotAllowed is returned when a client configuration is requested for
+// an endpoint that is neither the primary nor one of the alternate endpoints.
+var errEndpointNotAllowed = errors.New("endpoint is not one of the configured server endpoints")
+
+// Limits for client metadata entries.
+const (
+	maxMetadataEntries     = 32
+	maxMetadataValueLength = 1024
+)
+
+// metadataKeyPattern restricts metadata keys so they can be used in JSON paths.
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
 // Request/Response structures
 type CreateClientRequest struct {
-	Name string `json:"name" binding:"required,min=1"`
+	Name                string   `json:"name" binding:"required,min=1"`
+	Tags                []string `json:"tags,omitempty"`
+	PersistentKeepalive *int     `json:"persistent_keepalive,omitempty" binding:"omitempty,min=0,max=65535"`
+	DataCapSoftBytes    uint64   `json:"data_cap_soft_bytes,omitempty"`
+	DataCapHardBytes    uint64   `json:"data_cap_hard_bytes,omitempty"`
+	DataQuotaBytes      uint64   `json:"data_quota_bytes,omitempty"` // Total usage allowed until the quota is reset
+	Notes               string   `json:"notes,omitempty" binding:"max=4096"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	AllowedIPs          []string `json:"allowed_ips,omitempty"` // Overrides the server's routes, e.g. for split tunneling
+	DNS                 []string `json:"dns,omitempty"`         // Overrides the server's DNS servers
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"` // When access ends; must be in the future (omit to never expire)
+	PrivateKey          string   `json:"private_key,omitempty"`   // Existing key to keep, e.g. when migrating a client (omit to generate one)
+	PresharedKey        string   `json:"preshared_key,omitempty"` // Existing preshared key to keep (omit to generate one)
+	IPAddress           string   `json:"ip_address,omitempty"`    // Fixed address to pin the client to (omit to allocate the next free one)
+}
+
+// PreviewClientConfigRequest describes a hypothetical client whose config is
+// rendered without creating it. DNS and AllowedIPs override the server defaults.
+type PreviewClientConfigRequest struct {
+	Tags                []string `json:"tags,omitempty"`
+	PersistentKeepalive *int     `json:"persistent_keepalive,omitempty" binding:"omitempty,min=0,max=65535"`
+	DNS                 []string `json:"dns,omitempty"`
+	AllowedIPs          []string `json:"allowed_ips,omitempty"`
+}
+
+type PreviewClientConfigResponse struct {
+	Config    string `json:"config"`
+	Endpoint  string `json:"endpoint"`
+	IPAddress string `json:"ip_address"` // Address the client would likely get; not reserved
 }
 
 type CreateClientResponse struct {
@@ -39,11 +225,93 @@ type CreateClientResponse struct {
 	IPAddress string `json:"ip_address"`
 	Enabled   bool   `json:"enabled"`
 	CreatedAt time.Time `json:"created_at"`
+	Config    string `json:"config,omitempty"`  // Rendered config, only with ?include=config
+	QRCode    string `json:"qr_code,omitempty"` // Base64 PNG QR code, only with ?include=qr
+	PeerNotApplied bool `json:"peer_not_applied"` // AddPeer failed; the client is not reachable yet
+	Pending   bool   `json:"pending"`           // Queued until an IP address is released
 }
 
 type UpdateClientRequest struct {
+	Name                string   `json:"name,omitempty"`
+	Enabled             *bool    `json:"enabled,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	PersistentKeepalive *int     `json:"persistent_keepalive,omitempty" binding:"omitempty,min=0,max=65535"`
+	DataCapSoftBytes    *uint64  `json:"data_cap_soft_bytes,omitempty"`
+	DataCapHardBytes    *uint64  `json:"data_cap_hard_bytes,omitempty"`
+	DataQuotaBytes      *uint64  `json:"data_quota_bytes,omitempty"` // New data quota; 0 removes it. A client cut off by its quota stays disabled until the quota is reset
+	Notes               *string  `json:"notes,omitempty" binding:"omitempty,max=4096"`
+	Metadata            map[string]string `json:"metadata,omitempty"` // Replaces all metadata; an empty object clears it
+	AllowedIPs          []string `json:"allowed_ips"`                // Replaces the route overrides; an empty list restores server defaults
+	DNS                 []string `json:"dns"`                        // Replaces the DNS overrides; an empty list restores server defaults
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`     // New expiry date; the zero time removes it. An expired client stays disabled until re-enabled
+}
+
+type BulkClientsRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// BatchCreateClientsRequest lists the names of the clients to create at once.
+type BatchCreateClientsRequest struct {
+	Names []string `json:"names" binding:"required,min=1,max=100,dive,required"`
+}
+
+// BatchCreateClientsResponse reports the clients created by a batch and the
+// names that could not be created. Failures are identified by Name.
+type BatchCreateClientsResponse struct {
+	Created []CreateClientResponse `json:"created"`
+	Failed  []BulkItemError        `json:"failed"`
+}
+
+type BulkToggleClientsRequest struct {
+	IDs     []uint `json:"ids" binding:"required,min=1,max=500"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// BulkResult reports the outcome of a bulk operation item by item. Bulk
+// endpoints respond 200 once the batch was processed, even if some items
+// failed, so callers must inspect Failed to detect partial success.
+type BulkResult struct {
+	Succeeded []uint          `json:"succeeded"`
+	Failed    []BulkItemError `json:"failed"`
+}
+
+// BulkItemError describes why a single item of a bulk operation failed.
+// Items are identified by ID, or by Name when they have no ID yet.
+type BulkItemError struct {
+	ID      uint   `json:"id,omitempty"`
 	Name    string `json:"name,omitempty"`
-	Enabled *bool  `json:"enabled,omitempty"`
+	Code    string `json:"code"`    // Machine-readable failure code
+	Message string `json:"message"` // Human-readable failure description
+}
+
+// Failure codes of bulk operation items
+const (
+	BulkErrorNotFound = "not_found" // The item does not exist
+	BulkErrorInvalid  = "invalid"   // The item failed validation
+	BulkErrorConflict = "conflict"  // The item's current state does not allow the operation
+	BulkErrorInternal = "internal"  // The operation failed for a server-side reason
+)
+
+// newBulkResult creates an empty bulk result that encodes as empty lists.
+func newBulkResult() *BulkResult {
+	return &BulkResult{
+		Succeeded: []uint{},
+		Failed:    []BulkItemError{},
+	}
+}
+
+// succeed records a successfully processed item.
+func (r *BulkResult) succeed(id uint) {
+	r.Succeeded = append(r.Succeeded, id)
+}
+
+// fail records a failed item with its failure code and message.
+func (r *BulkResult) fail(id uint, code, message string) {
+	r.Failed = append(r.Failed, BulkItemError{ID: id, Code: code, Message: message})
+}
+
+type SetClientRateLimitRequest struct {
+	RateLimitMbps *int `json:"rate_limit_mbps" binding:"required,min=0,max=100000"` // Bandwidth limit in Mbps; 0 removes the limit
 }
 
 type ClientResponse struct {
@@ -57,15 +325,72 @@ type ClientResponse struct {
 	LastHandshake *time.Time `json:"last_handshake,omitempty"`
 	BytesReceived uint64     `json:"bytes_received"`
 	BytesSent     uint64     `json:"bytes_sent"`
+	Tags          []string   `json:"tags"`
+	PersistentKeepalive *int `json:"persistent_keepalive,omitempty"`
+	DataCapSoftBytes uint64  `json:"data_cap_soft_bytes"`
+	DataCapHardBytes uint64  `json:"data_cap_hard_bytes"`
+	DataCapExceeded  bool    `json:"data_cap_exceeded"`
+	DataQuotaBytes   uint64  `json:"data_quota_bytes"`                       // Total usage allowed until the quota is reset (0 means unlimited)
+	DataQuotaRemainingBytes *uint64 `json:"data_quota_remaining_bytes,omitempty"` // Bytes left before the client is cut off (absent without a quota)
+	DataQuotaExceeded bool   `json:"data_quota_exceeded"`                    // Whether the client was disabled for using up its quota
+	PeerNotApplied   bool    `json:"peer_not_applied"`
+	Pending          bool    `json:"pending"`
+	Notes            string  `json:"notes"`
+	Metadata         map[string]string `json:"metadata"`
+	ConfigStale      bool    `json:"config_stale"` // Server endpoint changed since the config was last downloaded
+	RateLimitMbps    int     `json:"rate_limit_mbps"` // Bandwidth limit in Mbps (0 means unlimited)
+	AllowedIPs       []string `json:"allowed_ips"`    // Route overrides (empty uses server defaults)
+	DNS              []string `json:"dns"`            // DNS overrides (empty uses server defaults)
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"` // When access ends (absent if the client never expires)
+	Expired          bool     `json:"expired"`        // Whether the expiry date has passed
+	Status        *ClientStatusResponse `json:"status,omitempty"`
+}
+
+type ClientStatusResponse struct {
+	State           string     `json:"state"`
+	Online          bool       `json:"online"` // Whether the last handshake is within the active window
+	LatestHandshake *time.Time `json:"latest_handshake,omitempty"`
+	Endpoint        string     `json:"endpoint,omitempty"`
+	BytesReceived   uint64     `json:"bytes_received"`
+	BytesSent       uint64     `json:"bytes_sent"`
 }
 
 type GetClientsResponse struct {
 	Clients []ClientResponse `json:"clients"`
-	Total   int              `json:"total"`
+	Total   int              `json:"total"`  // Number of clients matching the filters across all pages
+	Limit   int              `json:"limit"`  // Page size applied
+	Offset  int              `json:"offset"` // Number of matching clients skipped
+}
+
+type ClientCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+type TopClientResponse struct {
+	ID            uint   `json:"id"`
+	Name          string `json:"name"`
+	IPAddress     string `json:"ip_address"`
+	BytesReceived uint64 `json:"bytes_received"`
+	BytesSent     uint64 `json:"bytes_sent"`
+	BytesTotal    uint64 `json:"bytes_total"`
+}
+
+type TopClientsResponse struct {
+	By      string              `json:"by"`
+	Limit   int                 `json:"limit"`
+	Clients []TopClientResponse `json:"clients"`
 }
 
 type ClientConfigResponse struct {
-	Config string `json:"config"`
+	Config   string `json:"config"`
+	Endpoint string `json:"endpoint"`
+}
+
+// RenderedClientConfig is the rendered WireGuard configuration of one client.
+type RenderedClientConfig struct {
+	ClientID uint   `json:"client_id"`
+	Name     string `json:"name"`
+	Config   string `json:"config"`
 }
 
 type ClientQRCodeResponse struct {
@@ -79,11 +404,56 @@ type ErrorResponse struct {
 
 // NewClientAPI creates a new client API instance
 func NewClientAPI(db *database.Database, ipPool *network.IPPool, wgServer *wireguard.WireGuardServer) *ClientAPI {
-	return &ClientAPI{
-		db:       db,
-		ipPool:   ipPool,
-		wgServer: wgServer,
+	return NewClientAPIWithConfig(db, ipPool, wgServer, DefaultClientAPIConfig())
+}
+
+// NewClientAPIWithConfig creates a new client API instance with custom configuration
+func NewClientAPIWithConfig(db *database.Database, ipPool *network.IPPool, wgServer *wireguard.WireGuardServer, config *ClientAPIConfig) *ClientAPI {
+	api := &ClientAPI{
+		db:         db,
+		ipPool:     ipPool,
+		wgServer:   wgServer,
+		peerSource: wgServer,
+		peers:      wgServer,
+		config:     config,
+		rateLimitSupport: system.CheckRateLimitSupport,
 	}
+
+	if config.QueueWebhookURL != "" {
+		api.notifier = &webhookActivationNotifier{webhook: monitoring.NewWebhookNotifier(config.QueueWebhookURL)}
+	}
+	if config.QRCodeCacheSize > 0 {
+		api.qrCache = utils.NewQRCodeCache(config.QRCodeCacheSize)
+	}
+
+	return api
+}
+
+// SetAlertManager sets the alert manager used to report clients whose peer
+// could not be applied to the WireGuard interface.
+func (api *ClientAPI) SetAlertManager(alertManager *monitoring.AlertManager) {
+	api.alerts = alertManager
+}
+
+// SetLogger sets the logger recording client changes,
+// typically the log manager's LogComponentAPI logger.
+func (api *ClientAPI) SetLogger(logger *monitoring.ComponentLogger) {
+	api.logger = logger
+}
+
+// SetRateLimitShaper sets the firewall shaper enforcing client rate limits.
+// Without a shaper, setting a rate limit is refused.
+func (api *ClientAPI) SetRateLimitShaper(shaper RateLimitShaper) {
+	api.shaper = shaper
+}
+
+// SetActivationNotifier sets the notifier informed when a queued client is
+// assigned an IP address. Passing nil disables notifications.
+func (api *ClientAPI) SetActivationNotifier(notifier ActivationNotifier) {
+	api.queueMutex.Lock()
+	defer api.queueMutex.Unlock()
+
+	api.notifier = notifier
 }
 
 // RegisterRoutes registers the client API routes
@@ -93,12 +463,23 @@ func (api *ClientAPI) RegisterRoutes(router *gin.Engine) {
 		clients := apiGroup.Group("/clients")
 		{
 			clients.POST("", api.CreateClient)
+			clients.POST("/preview-config", api.PreviewClientConfig)
+			clients.POST("/batch", api.BatchCreateClients)
+			clients.POST("/bulk-delete", api.BulkDeleteClients)
+			clients.POST("/bulk-toggle", api.BulkToggleClients)
 			clients.GET("", api.GetClients)
+			clients.GET("/top", api.GetTopClients)
+			clients.GET("/count", api.GetClientCount)
+			clients.GET("/search", api.SearchClients)
+			clients.GET("/export", api.ExportClientConfigs)
 			clients.GET("/:id", api.GetClient)
 			clients.PUT("/:id", api.UpdateClient)
 			clients.DELETE("/:id", api.DeleteClient)
+			clients.GET("/:id/status", api.GetClientStatus)
 			clients.GET("/:id/config", api.GetClientConfig)
 			clients.GET("/:id/qrcode", api.GetClientQRCode)
+			clients.PUT("/:id/ratelimit", api.SetClientRateLimit)
+			clients.POST("/:id/quota/reset", api.ResetClientQuota)
 		}
 	}
 }
@@ -111,46 +492,159 @@ func (api *ClientAPI) CreateClient(c *gin.Context) {
 		return
 	}
 
-	// Generate key pair for client
-	keyPair, err := wireguard.GenerateKeyPair()
+	if err := validateDataCaps(req.DataCapSoftBytes, req.DataCapHardBytes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	metadata, err := encodeMetadata(req.Metadata)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate client keys"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	// Allocate IP address
-	clientIP, err := api.ipPool.AllocateIP()
+	dns, err := normalizeDNSServers(req.DNS)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to allocate IP address"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	req.DNS = dns
+	allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	req.AllowedIPs = allowedIPs
+
+	if req.ExpiresAt != nil {
+		if err := validateExpiry(*req.ExpiresAt, time.Now()); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if err := validateClientKeys(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Use the supplied keys or generate a key pair and preshared key for the client
+	keyPair, presharedKey, err := clientKeys(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate client keys"})
 		return
 	}
+	if req.PrivateKey != "" {
+		if _, err := api.db.GetClientByPublicKey(keyPair.PublicKey); err == nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "a client with this private_key already exists"})
+			return
+		}
+	}
+
+	// Embedding the config needs the server endpoint; fail before creating the client
+	includes := parseList(c.Query("include"))
+	includeConfig := containsString(includes, "config")
+	includeQR := containsString(includes, "qr")
+	if includeConfig || includeQR {
+		candidate := &database.Client{PrivateKey: keyPair.PrivateKey, PublicKey: keyPair.PublicKey, PresharedKey: presharedKey}
+		if _, err := api.buildClientConfig(candidate, c.Query("endpoint")); err != nil {
+			writeClientConfigError(c, err)
+			return
+		}
+	}
+
+	// Allocate the requested IP address or the next free one
+	var clientIP string
+	if req.IPAddress != "" {
+		if err := api.ipPool.AllocateSpecificIP(req.IPAddress); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, network.ErrIPAllocated) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, ErrorResponse{Error: err.Error()})
+			return
+		}
+		clientIP = net.ParseIP(req.IPAddress).String()
+	} else {
+		clientIP, err = api.ipPool.AllocateIP()
+		if err != nil {
+			if errors.Is(err, network.ErrPoolExhausted) && api.config.PoolExhaustionPolicy == PoolExhaustionQueue {
+				api.createPendingClient(c, &req, keyPair, presharedKey, metadata)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to allocate IP address"})
+			return
+		}
+	}
 
 	// Create client in database
 	client := &database.Client{
 		Name:       req.Name,
 		PublicKey:  keyPair.PublicKey,
 		PrivateKey: keyPair.PrivateKey,
+		PresharedKey: presharedKey,
 		IPAddress:  clientIP,
 		Enabled:    true,
+		Tags:       joinList(req.Tags),
+		PersistentKeepalive: req.PersistentKeepalive,
+		DataCapSoftBytes: req.DataCapSoftBytes,
+		DataCapHardBytes: req.DataCapHardBytes,
+		DataQuotaBytes: req.DataQuotaBytes,
+		Notes:      req.Notes,
+		Metadata:   metadata,
+		AllowedIPs: joinList(req.AllowedIPs),
+		DNS:        joinList(req.DNS),
+		ExpiresAt:  req.ExpiresAt,
 	}
 
-	if err := api.db.CreateClient(client); err != nil {
-		// Release the allocated IP if database creation fails
-		api.ipPool.ReleaseIP(clientIP)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create client"})
+	peer := NewClientPeer(client, api.defaultKeepalive())
+
+	// Insert the client and add its peer in one transaction, so a failure of
+	// either step leaves neither a client row, a peer nor an allocated IP behind
+	var peerErr error
+	peerAttempted := false
+	err = api.db.Transaction(func(tx *database.Database) error {
+		if err := tx.CreateClient(client); err != nil {
+			return err
+		}
+
+		peerAttempted = true
+		if peerErr = api.applyPeer(peer); peerErr != nil {
+			if api.config.PeerFailurePolicy == PeerFailureStrict && api.peers.IsRunning() {
+				return &peerApplyError{err: peerErr}
+			}
+
+			// Keep the client but make the failure visible; the peer will be
+			// applied when the server is (re)started from the stored configuration
+			client.PeerNotApplied = true
+			return tx.UpdateClient(client)
+		}
+		return nil
+	})
+	if err != nil {
+		if peerAttempted {
+			api.peers.RemovePeer(peer.PublicKey)
+		}
+		api.releaseIP(clientIP)
+
+		var applyErr *peerApplyError
+		switch {
+		case errors.Is(err, database.ErrDuplicateClientName):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		case errors.As(err, &applyErr):
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: fmt.Sprintf("Failed to apply peer to WireGuard interface: %v", applyErr.err),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create client"})
+		}
 		return
 	}
-
-	// Add peer to WireGuard configuration
-	peer := &wireguard.Peer{
-		PublicKey:  keyPair.PublicKey,
-		AllowedIPs: []string{clientIP + "/32"},
+	if peerErr != nil {
+		api.alertPeerNotApplied(client, peerErr)
 	}
 
-	if err := api.wgServer.AddPeer(peer); err != nil {
-		// Note: We continue even if adding peer fails as it might be due to WireGuard not being available
-		// The peer will be added when the server is started
-	}
+	api.logger.Info(fmt.Sprintf("Created client %q with IP %s", client.Name, client.IPAddress))
 
 	response := CreateClientResponse{
 		ID:        client.ID,
@@ -159,133 +653,364 @@ func (api *ClientAPI) CreateClient(c *gin.Context) {
 		IPAddress: client.IPAddress,
 		Enabled:   client.Enabled,
 		CreatedAt: client.CreatedAt,
+		PeerNotApplied: client.PeerNotApplied,
+	}
+
+	// Optionally embed the rendered config and/or QR code so mobile-first
+	// admins don't need a follow-up request
+	if includeConfig || includeQR {
+		clientConfig, err := api.buildClientConfig(client, c.Query("endpoint"))
+		if err != nil {
+			writeClientConfigError(c, err)
+			return
+		}
+		configString := clientConfig.GenerateConfigFile()
+
+		if includeConfig {
+			response.Config = configString
+		}
+		if includeQR {
+			qrCode, err := utils.GenerateWireGuardConfigQR(configString, utils.GetDefaultQRCodeOptions())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: fmt.Sprintf("Failed to generate QR code: %v", err),
+				})
+				return
+			}
+			response.QRCode = qrCode.(string)
+		}
 	}
 
 	c.JSON(http.StatusCreated, response)
 }
 
-// GetClients returns all clients
-func (api *ClientAPI) GetClients(c *gin.Context) {
-	clients, err := api.db.ListClients()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get clients"})
-		return
+// createPendingClient stores a client without an IP address while the pool is
+// exhausted. The client stays disabled until assignPendingClients hands it an
+// address released by another client. The config and QR code cannot be rendered
+// yet, so they are never embedded in the response.
+func (api *ClientAPI) createPendingClient(c *gin.Context, req *CreateClientRequest, keyPair *wireguard.KeyPair, presharedKey string, metadata string) {
+	client := &database.Client{
+		Name:       req.Name,
+		PublicKey:  keyPair.PublicKey,
+		PrivateKey: keyPair.PrivateKey,
+		PresharedKey: presharedKey,
+		Enabled:    false,
+		Pending:    true,
+		Tags:       joinList(req.Tags),
+		PersistentKeepalive: req.PersistentKeepalive,
+		DataCapSoftBytes: req.DataCapSoftBytes,
+		DataCapHardBytes: req.DataCapHardBytes,
+		DataQuotaBytes: req.DataQuotaBytes,
+		Notes:      req.Notes,
+		Metadata:   metadata,
+		AllowedIPs: joinList(req.AllowedIPs),
+		DNS:        joinList(req.DNS),
+		ExpiresAt:  req.ExpiresAt,
 	}
 
-	response := GetClientsResponse{
-		Clients: make([]ClientResponse, len(clients)),
-		Total:   len(clients),
+	// gorm skips zero-valued fields that have a default, so Enabled is set afterwards
+	if err := api.db.CreateClient(client); err != nil {
+		if errors.Is(err, database.ErrDuplicateClientName) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create client"})
+		return
+	}
+	if err := api.db.Model(client).Update("enabled", false).Error; err != nil {
+		api.db.DeleteClient(client.ID)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create client"})
+		return
 	}
 
-	for i, client := range clients {
-		response.Clients[i] = ClientResponse{
-			ID:            client.ID,
-			Name:          client.Name,
-			PublicKey:     client.PublicKey,
-			IPAddress:     client.IPAddress,
-			Enabled:       client.Enabled,
-			CreatedAt:     client.CreatedAt,
-			UpdatedAt:     client.UpdatedAt,
-			LastHandshake: client.LastHandshake,
-			BytesReceived: client.BytesReceived,
-			BytesSent:     client.BytesSent,
-		}
+	c.JSON(http.StatusAccepted, CreateClientResponse{
+		ID:        client.ID,
+		Name:      client.Name,
+		PublicKey: client.PublicKey,
+		Enabled:   client.Enabled,
+		CreatedAt: client.CreatedAt,
+		Pending:   client.Pending,
+	})
+}
+
+// releaseIP returns an address to the pool and, with the queue policy, hands
+// it to the oldest pending client in the background.
+func (api *ClientAPI) releaseIP(ip string) error {
+	if err := api.ipPool.ReleaseIP(ip); err != nil {
+		return err
 	}
 
-	c.JSON(http.StatusOK, response)
+	if api.config.PoolExhaustionPolicy == PoolExhaustionQueue {
+		go api.assignPendingClients()
+	}
+	return nil
 }
 
-// GetClient returns a specific client by ID
-func (api *ClientAPI) GetClient(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
+// assignPendingClients assigns free IP addresses to pending clients in the
+// order they were queued, enables them and adds their peers. It stops when the
+// pool is exhausted again; remaining clients wait for the next release.
+// Failures to add a peer are handled like during creation, and activations are
+// reported to the activation notifier on a best-effort basis.
+func (api *ClientAPI) assignPendingClients() {
+	api.queueMutex.Lock()
+	defer api.queueMutex.Unlock()
+
+	clients, err := api.db.ListPendingClients()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
 		return
 	}
 
-	client, err := api.db.GetClient(uint(id))
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+	for i := range clients {
+		client := &clients[i]
+
+		clientIP, err := api.ipPool.AllocateIP()
+		if err != nil {
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
-		return
-	}
 
-	response := ClientResponse{
-		ID:            client.ID,
-		Name:          client.Name,
-		PublicKey:     client.PublicKey,
-		IPAddress:     client.IPAddress,
-		Enabled:       client.Enabled,
-		CreatedAt:     client.CreatedAt,
-		UpdatedAt:     client.UpdatedAt,
-		LastHandshake: client.LastHandshake,
-		BytesReceived: client.BytesReceived,
-		BytesSent:     client.BytesSent,
-	}
+		client.IPAddress = clientIP
+		client.Enabled = true
+		client.Pending = false
+		if err := api.db.UpdateClient(client); err != nil {
+			api.ipPool.ReleaseIP(clientIP)
+			return
+		}
+		api.invalidateQRCodes(client.ID)
 
-	c.JSON(http.StatusOK, response)
+		if err := api.applyPeer(NewClientPeer(client, api.defaultKeepalive())); err != nil {
+			api.flagPeerNotApplied(client, err)
+		}
+
+		if api.notifier != nil {
+			api.notifier.NotifyClientActivated(ClientActivation{
+				ClientID:  client.ID,
+				Name:      client.Name,
+				IPAddress: client.IPAddress,
+				Timestamp: time.Now(),
+			})
+		}
+	}
 }
 
-// UpdateClient updates an existing client
-func (api *ClientAPI) UpdateClient(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+// GetClients returns a page of clients, optionally filtered by ?enabled=true|false,
+// ?tag= and ?metadata_key= (with an optional ?metadata_value=).
+// Pages are selected with ?limit=N (default 100, max 500) and ?offset=N and
+// sorted by ?sort=name|created_at|last_handshake (default ID) with ?order=asc|desc.
+func (api *ClientAPI) GetClients(c *gin.Context) {
+	filter, ok := parseClientFilter(c)
+	if !ok {
 		return
 	}
 
-	var req UpdateClientRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	page, ok := parseClientPage(c)
+	if !ok {
 		return
 	}
 
-	client, err := api.db.GetClient(uint(id))
+	clients, total, err := api.db.ListClientsPaged(filter, page)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get clients"})
 		return
 	}
 
-	// Update fields if provided
-	if req.Name != "" {
-		client.Name = req.Name
-	}
-	if req.Enabled != nil {
-		client.Enabled = *req.Enabled
+	response := GetClientsResponse{
+		Clients: make([]ClientResponse, len(clients)),
+		Total:   int(total),
+		Limit:   page.Limit,
+		Offset:  page.Offset,
 	}
 
-	if err := api.db.UpdateClient(client); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update client"})
-		return
+	for i := range clients {
+		response.Clients[i] = newClientResponse(&clients[i])
 	}
 
-	response := ClientResponse{
-		ID:            client.ID,
-		Name:          client.Name,
-		PublicKey:     client.PublicKey,
-		IPAddress:     client.IPAddress,
-		Enabled:       client.Enabled,
-		CreatedAt:     client.CreatedAt,
-		UpdatedAt:     client.UpdatedAt,
-		LastHandshake: client.LastHandshake,
-		BytesReceived: client.BytesReceived,
-		BytesSent:     client.BytesSent,
+	// Optionally join live peer status to avoid a round-trip per client
+	if c.Query("include") == "status" {
+		api.attachPeerStatus(response.Clients)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// DeleteClient deletes a client
-func (api *ClientAPI) DeleteClient(c *gin.Context) {
+// SearchClients finds clients by ?q=, matching names containing the query
+// (case-insensitive) and IP addresses starting with it.
+// At most 50 clients are returned, ordered by name.
+func (api *ClientAPI) SearchClients(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Search query is required"})
+		return
+	}
+
+	clients, err := api.db.SearchClients(query, maxClientSearchResults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to search clients"})
+		return
+	}
+
+	response := GetClientsResponse{
+		Clients: make([]ClientResponse, len(clients)),
+		Total:   len(clients),
+		Limit:   maxClientSearchResults,
+	}
+
+	for i := range clients {
+		response.Clients[i] = newClientResponse(&clients[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetClientCount returns the number of clients without loading them.
+// It supports the same ?enabled= and ?tag= filters as GetClients.
+func (api *ClientAPI) GetClientCount(c *gin.Context) {
+	filter, ok := parseClientFilter(c)
+	if !ok {
+		return
+	}
+
+	count, err := api.db.CountClients(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to count clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ClientCountResponse{Count: count})
+}
+
+// parseClientFilter reads the ?enabled=, ?tag=, ?metadata_key= and
+// ?metadata_value= client filters from the query.
+// It writes a 400 response and returns false if the filters are invalid.
+func parseClientFilter(c *gin.Context) (database.ClientFilter, bool) {
+	filter := database.ClientFilter{
+		Tag:           strings.TrimSpace(c.Query("tag")),
+		MetadataKey:   strings.TrimSpace(c.Query("metadata_key")),
+		MetadataValue: c.Query("metadata_value"),
+	}
+
+	if filter.MetadataKey != "" && !metadataKeyPattern.MatchString(filter.MetadataKey) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid metadata_key filter"})
+		return filter, false
+	}
+	if filter.MetadataValue != "" && filter.MetadataKey == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "metadata_value filter requires metadata_key"})
+		return filter, false
+	}
+
+	if enabledStr := c.Query("enabled"); enabledStr != "" {
+		enabled, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid enabled filter"})
+			return filter, false
+		}
+		filter.Enabled = &enabled
+	}
+
+	return filter, true
+}
+
+// parseClientPage reads the ?limit=, ?offset=, ?sort= and ?order= parameters
+// selecting a page of clients. Limits above the maximum are clamped.
+// It writes a 400 response and returns false if the parameters are invalid.
+func parseClientPage(c *gin.Context) (database.ClientPage, bool) {
+	page := database.ClientPage{Limit: defaultClientsPageLimit}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+			return page, false
+		}
+		page.Limit = limit
+	}
+	if page.Limit > maxClientsPageLimit {
+		page.Limit = maxClientsPageLimit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid offset"})
+			return page, false
+		}
+		page.Offset = offset
+	}
+
+	switch sort := c.Query("sort"); sort {
+	case "", database.ClientSortName, database.ClientSortCreatedAt, database.ClientSortLastHandshake:
+		page.Sort = sort
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Unsupported sort. Use 'name', 'created_at', or 'last_handshake'",
+		})
+		return page, false
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unsupported order. Use 'asc' or 'desc'"})
+		return page, false
+	}
+
+	return page, true
+}
+
+// GetTopClients returns the clients with the highest traffic, ordered by the
+// counter selected with ?by=received|sent|total (default total).
+// The number of clients is controlled by ?limit=N (default 10, max 100).
+func (api *ClientAPI) GetTopClients(c *gin.Context) {
+	by := c.DefaultQuery("by", database.TrafficTotal)
+	if by != database.TrafficReceived && by != database.TrafficSent && by != database.TrafficTotal {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Unsupported traffic counter. Use 'received', 'sent', or 'total'",
+		})
+		return
+	}
+
+	limit := defaultTopClientsLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	if limit > maxTopClientsLimit {
+		limit = maxTopClientsLimit
+	}
+
+	clients, err := api.db.ListTopClients(by, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get clients"})
+		return
+	}
+
+	response := TopClientsResponse{
+		By:      by,
+		Limit:   limit,
+		Clients: make([]TopClientResponse, len(clients)),
+	}
+
+	for i, client := range clients {
+		response.Clients[i] = TopClientResponse{
+			ID:            client.ID,
+			Name:          client.Name,
+			IPAddress:     client.IPAddress,
+			BytesReceived: client.BytesReceived,
+			BytesSent:     client.BytesSent,
+			BytesTotal:    client.BytesReceived + client.BytesSent,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetClient returns a specific client by ID
+func (api *ClientAPI) GetClient(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -303,27 +1028,47 @@ func (api *ClientAPI) DeleteClient(c *gin.Context) {
 		return
 	}
 
-	// Remove peer from WireGuard configuration
-	if err := api.wgServer.RemovePeer(client.PublicKey); err != nil {
-		// Note: We continue even if removing peer fails as it might be due to WireGuard not being available
+	c.JSON(http.StatusOK, newClientResponse(client))
+}
+
+// GetClientStatus returns the live connection status of a single client from
+// the WireGuard interface. Clients without a live peer are reported as never
+// connected, and the state is unknown if the live status cannot be retrieved.
+func (api *ClientAPI) GetClientStatus(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+		return
 	}
 
-	// Release IP address
-	if err := api.ipPool.ReleaseIP(client.IPAddress); err != nil {
-		// Log error but continue with deletion
+	client, err := api.db.GetClient(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
+		return
 	}
 
-	// Delete client from database
-	if err := api.db.DeleteClient(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete client"})
+	peers, err := api.peerSource.GetPeerStatus()
+	if err != nil {
+		c.JSON(http.StatusOK, ClientStatusResponse{State: ConnectionStateUnknown})
 		return
 	}
+	for _, peer := range peers {
+		if peer.PublicKey == client.PublicKey {
+			c.JSON(http.StatusOK, newClientStatusResponse(peer, time.Now()))
+			return
+		}
+	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, ClientStatusResponse{State: ConnectionStateNever})
 }
 
-// GetClientConfig returns the WireGuard configuration for a client
-func (api *ClientAPI) GetClientConfig(c *gin.Context) {
+// UpdateClient updates an existing client
+func (api *ClientAPI) UpdateClient(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -331,6 +1076,12 @@ func (api *ClientAPI) GetClientConfig(c *gin.Context) {
 		return
 	}
 
+	var req UpdateClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	client, err := api.db.GetClient(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -341,36 +1092,125 @@ func (api *ClientAPI) GetClientConfig(c *gin.Context) {
 		return
 	}
 
-	// Get server configuration to generate client config
-	serverIP := api.ipPool.GetServerIP()
-	serverConfig := &wireguard.ServerConfig{
-		PublicKey: "dummy-server-public-key", // This should come from actual server config
-		Address:   serverIP + "/24",
-		ListenPort: 51820,
+	// Update fields if provided
+	if req.Name != "" {
+		client.Name = req.Name
+	}
+	enabledChanged := false
+	if req.Enabled != nil {
+		if *req.Enabled && client.Pending {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: errClientPending.Error()})
+			return
+		}
+		enabledChanged = client.Enabled != *req.Enabled
+		client.Enabled = *req.Enabled
+	}
+	if req.Tags != nil {
+		client.Tags = joinList(req.Tags)
+	}
+	if req.PersistentKeepalive != nil {
+		client.PersistentKeepalive = req.PersistentKeepalive
+	}
+	if req.DataCapSoftBytes != nil {
+		client.DataCapSoftBytes = *req.DataCapSoftBytes
+	}
+	if req.DataCapHardBytes != nil {
+		client.DataCapHardBytes = *req.DataCapHardBytes
+	}
+	if req.DataQuotaBytes != nil {
+		client.DataQuotaBytes = *req.DataQuotaBytes
+	}
+	if req.Notes != nil {
+		client.Notes = *req.Notes
+	}
+	if req.Metadata != nil {
+		metadata, err := encodeMetadata(req.Metadata)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		client.Metadata = metadata
+	}
+	if req.AllowedIPs != nil {
+		allowedIPs, err := normalizeAllowedIPs(req.AllowedIPs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		client.AllowedIPs = joinList(allowedIPs)
+	}
+	if req.DNS != nil {
+		dns, err := normalizeDNSServers(req.DNS)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		client.DNS = joinList(dns)
+	}
+	if req.ExpiresAt != nil {
+		if req.ExpiresAt.IsZero() {
+			client.ExpiresAt = nil
+		} else {
+			if err := validateExpiry(*req.ExpiresAt, time.Now()); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+				return
+			}
+			client.ExpiresAt = req.ExpiresAt
+		}
 	}
 
-	// Create client configuration
-	clientConfig := &wireguard.ClientConfig{
-		PrivateKey:      client.PrivateKey,
-		PublicKey:       client.PublicKey,
-		Address:         client.IPAddress + "/32",
-		DNS:             []string{"8.8.8.8", "8.8.4.4"},
-		ServerPublicKey: serverConfig.PublicKey,
-		ServerEndpoint:  fmt.Sprintf("your-server-ip:%d", serverConfig.ListenPort),
-		AllowedIPs:      []string{"0.0.0.0/0"},
+	if err := validateDataCaps(client.DataCapSoftBytes, client.DataCapHardBytes); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if enabledChanged && client.Enabled && client.IsExpired(time.Now()) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "client access has expired; extend expires_at to enable it"})
+		return
+	}
+	if enabledChanged && client.Enabled && client.DataQuotaExceeded {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "client used up its data quota; reset the quota to enable it"})
+		return
+	}
+	if enabledChanged && client.Enabled && client.DataCapExceeded {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "client exceeded its data cap; wait for the next usage period to enable it"})
+		return
 	}
 
-	configString := clientConfig.GenerateConfigFile()
+	if err := api.db.UpdateClient(client); err != nil {
+		if errors.Is(err, database.ErrDuplicateClientName) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update client"})
+		return
+	}
 
-	response := ClientConfigResponse{
-		Config: configString,
+	// The peer follows the stored state, so a change that cannot be stored
+	// never reaches the interface
+	if enabledChanged {
+		if err := api.applyEnabledState(client); err != nil {
+			// Roll back so the stored state matches the interface
+			client.Enabled = !client.Enabled
+			api.db.UpdateClient(client)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error: fmt.Sprintf("Failed to apply peer to WireGuard interface: %v", err),
+			})
+			return
+		}
+		if err := api.db.UpdateClient(client); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update client"})
+			return
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, newClientResponse(client))
 }
 
-// GetClientQRCode returns a QR code for the WireGuard configuration of a client
-func (api *ClientAPI) GetClientQRCode(c *gin.Context) {
+// SetClientRateLimit sets or removes the bandwidth limit of a client and
+// applies the limits of all clients to the firewall. The limit is enforced by
+// the firewall, so it is refused on platforms where per-client shaping is not
+// supported or when no rate limit shaper is set.
+func (api *ClientAPI) SetClientRateLimit(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -378,19 +1218,18 @@ func (api *ClientAPI) GetClientQRCode(c *gin.Context) {
 		return
 	}
 
-	// Get query parameters for QR code options
-	format := c.DefaultQuery("format", "base64") // base64, png, terminal
-	sizeStr := c.DefaultQuery("size", "256")
-	size, err := strconv.Atoi(sizeStr)
-	if err != nil || size <= 0 {
-		size = 256
+	var req SetClientRateLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	// Validate format early
-	if format != "base64" && format != "png" && format != "terminal" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "Unsupported format. Use 'png', 'base64', or 'terminal'",
-		})
+	if err := api.rateLimitSupport(); err != nil {
+		c.JSON(http.StatusNotImplemented, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if api.shaper == nil {
+		c.JSON(http.StatusNotImplemented, ErrorResponse{Error: "per-client rate limiting is not available: no firewall shaper is configured"})
 		return
 	}
 
@@ -404,58 +1243,1216 @@ func (api *ClientAPI) GetClientQRCode(c *gin.Context) {
 		return
 	}
 
-	// Get server configuration to generate client config
-	serverIP := api.ipPool.GetServerIP()
-	serverConfig := &wireguard.ServerConfig{
-		PublicKey: "dummy-server-public-key", // This should come from actual server config
-		Address:   serverIP + "/24",
-		ListenPort: 51820,
+	client.RateLimitMbps = *req.RateLimitMbps
+	if err := api.db.UpdateClient(client); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update client"})
+		return
 	}
 
-	// Create client configuration
-	clientConfig := &wireguard.ClientConfig{
-		PrivateKey:      client.PrivateKey,
-		PublicKey:       client.PublicKey,
-		Address:         client.IPAddress + "/32",
-		DNS:             []string{"8.8.8.8", "8.8.4.4"},
-		ServerPublicKey: serverConfig.PublicKey,
-		ServerEndpoint:  fmt.Sprintf("your-server-ip:%d", serverConfig.ListenPort),
-		AllowedIPs:      []string{"0.0.0.0/0"},
+	if err := api.ApplyRateLimits(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to apply rate limits"})
+		return
 	}
 
-	configString := clientConfig.GenerateConfigFile()
+	c.JSON(http.StatusOK, newClientResponse(client))
+}
 
-	// Generate QR code options
-	qrOptions := utils.QRCodeOptions{
-		Size:          size,
-		RecoveryLevel: utils.GetDefaultQRCodeOptions().RecoveryLevel,
-		Format:        format,
+// ResetClientQuota restarts the data quota of a client from its current usage.
+// A client cut off by its quota is re-enabled and its peer restored, unless it
+// is also held back by its data cap or expiry date.
+func (api *ClientAPI) ResetClientQuota(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+		return
 	}
 
-	// Generate QR code
-	qrCodeData, err := utils.GenerateWireGuardConfigQR(configString, qrOptions)
+	client, err := api.db.GetClient(uint(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: fmt.Sprintf("Failed to generate QR code: %v", err),
-		})
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
 		return
 	}
 
-	// Handle different response formats
-	switch format {
-	case "png":
-		// Return PNG data directly
-		pngData := qrCodeData.([]byte)
-		c.Header("Content-Type", "image/png")
-		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=client-%d-config.png", id))
-		c.Data(http.StatusOK, "image/png", pngData)
-	case "base64", "terminal":
-		// Return JSON response
-		qrString := qrCodeData.(string)
-		response := ClientQRCodeResponse{
-			QRCode: qrString,
-			Format: format,
+	client.DataQuotaBaselineBytes = client.BytesReceived + client.BytesSent
+	if client.DataQuotaExceeded {
+		client.DataQuotaExceeded = false
+		if !client.DataCapExceeded && !client.IsExpired(time.Now()) {
+			client.Enabled = true
+			if err := api.applyEnabledState(client); err != nil {
+				c.JSON(http.StatusInternalServerError, ErrorResponse{
+					Error: fmt.Sprintf("Failed to apply peer to WireGuard interface: %v", err),
+				})
+				return
+			}
 		}
-		c.JSON(http.StatusOK, response)
 	}
-}
\ No newline at end of file
+
+	if err := api.db.UpdateClient(client); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update client"})
+		return
+	}
+	if api.alerts != nil {
+		api.alerts.ResolveAlert(monitoring.DataQuotaAlertID(client.ID))
+	}
+
+	c.JSON(http.StatusOK, newClientResponse(client))
+}
+
+// DeleteClient deletes a client
+func (api *ClientAPI) DeleteClient(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+		return
+	}
+
+	client, err := api.db.GetClient(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
+		return
+	}
+
+	if err := api.removeClient(client); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete client"})
+		return
+	}
+	api.logger.Info(fmt.Sprintf("Deleted client %q", client.Name))
+
+	c.Status(http.StatusNoContent)
+}
+
+// BatchCreateClients creates up to 100 clients with default settings at once.
+// Every client gets its keys and IP address and is stored before the peers
+// are written, so the running interface is synced once for the whole batch.
+// A client whose insert fails has its IP address released; items are never
+// queued, so once the pool is exhausted the remaining names fail. Peer
+// failures are handled per client according to PeerFailurePolicy.
+// Responds 200 with the created clients and the names that failed.
+func (api *ClientAPI) BatchCreateClients(c *gin.Context) {
+	var req BatchCreateClientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	response := BatchCreateClientsResponse{
+		Created: []CreateClientResponse{},
+		Failed:  []BulkItemError{},
+	}
+	fail := func(name, code, message string) {
+		response.Failed = append(response.Failed, BulkItemError{Name: name, Code: code, Message: message})
+	}
+
+	var clients []*database.Client
+	for _, name := range req.Names {
+		keyPair, err := wireguard.GenerateKeyPair()
+		if err != nil {
+			fail(name, BulkErrorInternal, "Failed to generate client keys")
+			continue
+		}
+		presharedKey, err := wireguard.GeneratePresharedKey()
+		if err != nil {
+			fail(name, BulkErrorInternal, "Failed to generate client keys")
+			continue
+		}
+
+		clientIP, err := api.ipPool.AllocateIP()
+		if err != nil {
+			if errors.Is(err, network.ErrPoolExhausted) {
+				fail(name, BulkErrorConflict, "IP pool is exhausted")
+			} else {
+				fail(name, BulkErrorInternal, "Failed to allocate IP address")
+			}
+			continue
+		}
+
+		client := &database.Client{
+			Name:         name,
+			PublicKey:    keyPair.PublicKey,
+			PrivateKey:   keyPair.PrivateKey,
+			PresharedKey: presharedKey,
+			IPAddress:    clientIP,
+			Enabled:      true,
+		}
+		if err := api.db.CreateClient(client); err != nil {
+			api.releaseIP(clientIP)
+			if errors.Is(err, database.ErrDuplicateClientName) {
+				fail(name, BulkErrorConflict, err.Error())
+			} else {
+				fail(name, BulkErrorInternal, "Failed to create client")
+			}
+			continue
+		}
+		clients = append(clients, client)
+	}
+
+	// Write all peers, then apply them to the running interface in one sync
+	peerErrors := make(map[uint]error)
+	defaultKeepalive := api.defaultKeepalive()
+	for _, client := range clients {
+		if err := api.peers.AddPeer(NewClientPeer(client, defaultKeepalive)); err != nil {
+			peerErrors[client.ID] = err
+		}
+	}
+	if len(peerErrors) < len(clients) {
+		if err := api.reloadPeers(); err != nil {
+			for _, client := range clients {
+				if _, failed := peerErrors[client.ID]; !failed {
+					peerErrors[client.ID] = err
+				}
+			}
+		}
+	}
+
+	for _, client := range clients {
+		if err, failed := peerErrors[client.ID]; failed {
+			if api.config.PeerFailurePolicy == PeerFailureStrict && api.peers.IsRunning() {
+				// Roll back so no unreachable client is left behind
+				api.peers.RemovePeer(client.PublicKey)
+				api.db.DeleteClient(client.ID)
+				api.releaseIP(client.IPAddress)
+				fail(client.Name, BulkErrorInternal, fmt.Sprintf("Failed to apply peer to WireGuard interface: %v", err))
+				continue
+			}
+			api.flagPeerNotApplied(client, err)
+		}
+
+		response.Created = append(response.Created, CreateClientResponse{
+			ID:             client.ID,
+			Name:           client.Name,
+			PublicKey:      client.PublicKey,
+			IPAddress:      client.IPAddress,
+			Enabled:        client.Enabled,
+			CreatedAt:      client.CreatedAt,
+			PeerNotApplied: client.PeerNotApplied,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BulkDeleteClients deletes several clients at once.
+// Responds 200 with a BulkResult listing deleted and failed client IDs.
+func (api *ClientAPI) BulkDeleteClients(c *gin.Context) {
+	var req BulkClientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := newBulkResult()
+	for _, id := range req.IDs {
+		client, ok := api.getBulkClient(result, id)
+		if !ok {
+			continue
+		}
+		if err := api.removeClient(client); err != nil {
+			result.fail(id, BulkErrorInternal, "Failed to delete client")
+			continue
+		}
+		result.succeed(id)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BulkToggleClients enables or disables several clients at once.
+// Responds 200 with a BulkResult listing updated and failed client IDs.
+func (api *ClientAPI) BulkToggleClients(c *gin.Context) {
+	var req BulkToggleClientsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	result := newBulkResult()
+	for _, id := range req.IDs {
+		client, ok := api.getBulkClient(result, id)
+		if !ok {
+			continue
+		}
+		if *req.Enabled && client.Pending {
+			result.fail(id, BulkErrorConflict, errClientPending.Error())
+			continue
+		}
+		if client.Enabled != *req.Enabled {
+			client.Enabled = *req.Enabled
+			if err := api.applyEnabledState(client); err != nil {
+				result.fail(id, BulkErrorInternal, fmt.Sprintf("Failed to apply peer to WireGuard interface: %v", err))
+				continue
+			}
+		}
+		if err := api.db.UpdateClient(client); err != nil {
+			result.fail(id, BulkErrorInternal, "Failed to update client")
+			continue
+		}
+		result.succeed(id)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getBulkClient loads a client for a bulk operation, recording a failure in
+// the result if it cannot be loaded. Returns the client and true on success.
+func (api *ClientAPI) getBulkClient(result *BulkResult, id uint) (*database.Client, bool) {
+	client, err := api.db.GetClient(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			result.fail(id, BulkErrorNotFound, "Client not found")
+		} else {
+			result.fail(id, BulkErrorInternal, "Failed to get client")
+		}
+		return nil, false
+	}
+	return client, true
+}
+
+// removeClient deletes a client and, unless it is still queued, removes its
+// peer and releases its IP address. Peer and IP cleanup failures are ignored
+// since WireGuard may be unavailable.
+// Returns an error only if the client cannot be deleted from the database.
+func (api *ClientAPI) removeClient(client *database.Client) error {
+	if err := api.db.DeleteClient(client.ID); err != nil {
+		return err
+	}
+	api.invalidateQRCodes(client.ID)
+
+	// Pending clients have neither a peer nor an IP address yet
+	if !client.Pending {
+		// Remove peer from WireGuard configuration and the running interface
+		if err := api.peers.RemovePeer(client.PublicKey); err != nil {
+			// Note: We continue even if removing peer fails as it might be due to WireGuard not being available
+		} else if err := api.reloadPeers(); err != nil {
+			// The peer is gone from the file and disappears on the next restart
+		}
+
+		// Release IP address, possibly handing it to a queued client
+		if err := api.releaseIP(client.IPAddress); err != nil {
+			// Log error but continue with deletion
+		}
+	}
+
+	return nil
+}
+
+// GetClientConfig returns the WireGuard configuration for a client.
+// With ?download=true the raw config is returned as a .conf file attachment.
+func (api *ClientAPI) GetClientConfig(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+		return
+	}
+
+	client, err := api.db.GetClient(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
+		return
+	}
+
+	clientConfig, err := api.buildClientConfig(client, c.Query("endpoint"))
+	if err != nil {
+		writeClientConfigError(c, err)
+		return
+	}
+	configString := clientConfig.GenerateConfigFile()
+	api.clearConfigStale(client)
+
+	// WireGuard tooling imports plain .conf files; the JSON form serves the web UI
+	if download, _ := strconv.ParseBool(c.Query("download")); download {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.conf"`, configFileName(client)))
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(configString))
+		return
+	}
+
+	response := ClientConfigResponse{
+		Config:   configString,
+		Endpoint: clientConfig.ServerEndpoint,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// PreviewClientConfig renders the WireGuard configuration a client with the
+// proposed settings would get. A throwaway key pair and the next free IP are
+// used; nothing is persisted and no IP address is allocated.
+func (api *ClientAPI) PreviewClientConfig(c *gin.Context) {
+	var req PreviewClientConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	dns, err := normalizeDNSServers(req.DNS)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	allowedIPs := make([]string, 0, len(req.AllowedIPs))
+	for _, route := range req.AllowedIPs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(route))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid allowed IP %q: must be a CIDR", route)})
+			return
+		}
+		allowedIPs = append(allowedIPs, ipNet.String())
+	}
+
+	keyPair, err := wireguard.GenerateKeyPair()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate client keys"})
+		return
+	}
+
+	clientIP, err := api.ipPool.PeekIP()
+	if err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	client := &database.Client{
+		PublicKey:           keyPair.PublicKey,
+		PrivateKey:          keyPair.PrivateKey,
+		IPAddress:           clientIP,
+		Tags:                joinList(req.Tags),
+		PersistentKeepalive: req.PersistentKeepalive,
+		AllowedIPs:          joinList(allowedIPs),
+		DNS:                 joinList(dns),
+	}
+
+	clientConfig, err := api.buildClientConfig(client, c.Query("endpoint"))
+	if err != nil {
+		writeClientConfigError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PreviewClientConfigResponse{
+		Config:    clientConfig.GenerateConfigFile(),
+		Endpoint:  clientConfig.ServerEndpoint,
+		IPAddress: clientIP,
+	})
+}
+
+// GetClientQRCode returns a QR code for the WireGuard configuration of a client
+func (api *ClientAPI) GetClientQRCode(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid client ID"})
+		return
+	}
+
+	// Get query parameters for QR code options
+	format := c.DefaultQuery("format", "base64") // base64, png, terminal
+	sizeStr := c.DefaultQuery("size", "256")
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size <= 0 {
+		size = 256
+	}
+
+	// Validate format early
+	if format != "base64" && format != "png" && format != "terminal" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Unsupported format. Use 'png', 'base64', or 'terminal'",
+		})
+		return
+	}
+
+	client, err := api.db.GetClient(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Client not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get client"})
+		return
+	}
+
+	clientConfig, err := api.buildClientConfig(client, c.Query("endpoint"))
+	if err != nil {
+		writeClientConfigError(c, err)
+		return
+	}
+	configString := clientConfig.GenerateConfigFile()
+
+	// Generate QR code options
+	qrOptions := utils.QRCodeOptions{
+		Size:          size,
+		RecoveryLevel: utils.GetDefaultQRCodeOptions().RecoveryLevel,
+		Format:        format,
+	}
+
+	// Generate QR code, reusing a cached one for an unchanged config
+	var qrCodeData interface{}
+	if api.qrCache != nil {
+		qrCodeData, err = api.qrCache.GenerateWireGuardConfigQR(qrCodeOwner(client.ID), configString, qrOptions)
+	} else {
+		qrCodeData, err = utils.GenerateWireGuardConfigQR(configString, qrOptions)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: fmt.Sprintf("Failed to generate QR code: %v", err),
+		})
+		return
+	}
+	api.clearConfigStale(client)
+
+	// Handle different response formats
+	switch format {
+	case "png":
+		// Return PNG data directly
+		pngData := qrCodeData.([]byte)
+		c.Header("Content-Type", "image/png")
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=client-%d-config.png", id))
+		c.Data(http.StatusOK, "image/png", pngData)
+	case "base64", "terminal":
+		// Return JSON response
+		qrString := qrCodeData.(string)
+		response := ClientQRCodeResponse{
+			QRCode: qrString,
+			Format: format,
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ExportClientConfigs streams the WireGuard configs of all enabled, unexpired
+// clients as a zip archive with one <name>.conf entry per client. Entries are written to
+// the response as they are rendered, so the archive is never held in memory.
+// Clients whose file names collide get their ID appended.
+// The configs contain private keys, so the route must be restricted to admins.
+func (api *ClientAPI) ExportClientConfigs(c *gin.Context) {
+	clients, err := api.db.ListClients()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get clients"})
+		return
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+
+	var archive *zip.Writer
+	startArchive := func() {
+		c.Header("Content-Type", "application/zip")
+		c.Header("Content-Disposition", `attachment; filename="client-configs.zip"`)
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+		archive = zip.NewWriter(c.Writer)
+	}
+
+	usedNames := make(map[string]bool)
+	now := time.Now()
+	for i := range clients {
+		client := &clients[i]
+		if !client.Enabled || client.Pending || client.IsExpired(now) {
+			continue
+		}
+
+		clientConfig, err := api.buildClientConfig(client, "")
+		if err != nil {
+			if archive == nil {
+				writeClientConfigError(c, err)
+			} else {
+				// The response is already streaming; a truncated archive signals the failure
+				c.Error(fmt.Errorf("failed to render config of client %d: %w", client.ID, err))
+			}
+			return
+		}
+
+		if archive == nil {
+			startArchive()
+		}
+
+		name := configFileName(client)
+		if usedNames[strings.ToLower(name)] {
+			name = fmt.Sprintf("%s-%d", name, client.ID)
+		}
+		usedNames[strings.ToLower(name)] = true
+
+		entry, err := archive.Create(name + ".conf")
+		if err != nil {
+			c.Error(fmt.Errorf("failed to add config of client %d: %w", client.ID, err))
+			return
+		}
+		if _, err := entry.Write([]byte(clientConfig.GenerateConfigFile())); err != nil {
+			c.Error(fmt.Errorf("failed to write config of client %d: %w", client.ID, err))
+			return
+		}
+	}
+
+	if archive == nil {
+		// No enabled clients: still answer with a valid, empty archive
+		startArchive()
+	}
+
+	if err := archive.Close(); err != nil {
+		c.Error(fmt.Errorf("failed to finish client config archive: %w", err))
+	}
+}
+
+// clearConfigStale clears the stale flag once a client's current
+// configuration has been handed out. Failures only leave the flag set.
+func (api *ClientAPI) clearConfigStale(client *database.Client) {
+	if client.ConfigStale {
+		api.db.ClearClientConfigStale(client.ID)
+	}
+}
+
+// RenderClientConfigs renders the current configuration of every enabled
+// client that has an IP address, e.g. to redistribute them after the server
+// endpoint changed. Pending, disabled and expired clients are skipped.
+// Returns the rendered configs ordered by client ID or an error if any fails.
+func (api *ClientAPI) RenderClientConfigs() ([]RenderedClientConfig, error) {
+	clients, err := api.db.ListClients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	configs := []RenderedClientConfig{}
+	now := time.Now()
+	for i := range clients {
+		client := &clients[i]
+		if !client.Enabled || client.Pending || client.IsExpired(now) {
+			continue
+		}
+
+		clientConfig, err := api.buildClientConfig(client, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to render config of client %d: %w", client.ID, err)
+		}
+		configs = append(configs, RenderedClientConfig{
+			ClientID: client.ID,
+			Name:     client.Name,
+			Config:   clientConfig.GenerateConfigFile(),
+		})
+	}
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ClientID < configs[j].ClientID })
+	return configs, nil
+}
+
+// ClientRateLimits returns the bandwidth limits of all enabled clients that
+// have an IP address, ready to be rendered into firewall shaping rules.
+// Returns the limits ordered by client ID or an error if clients cannot be listed.
+func (api *ClientAPI) ClientRateLimits() ([]system.ClientRateLimit, error) {
+	clients, err := api.db.ListClients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+
+	limits := []system.ClientRateLimit{}
+	for _, client := range clients {
+		if !client.Enabled || client.Pending || client.RateLimitMbps <= 0 {
+			continue
+		}
+		limits = append(limits, system.ClientRateLimit{
+			IPAddress: client.IPAddress,
+			Mbps:      client.RateLimitMbps,
+		})
+	}
+
+	return limits, nil
+}
+
+// ApplyRateLimits applies the bandwidth limits of all clients to the firewall
+// through the rate limit shaper. It is a no-op when no shaper is set.
+// Returns an error if the limits cannot be listed or applied.
+func (api *ClientAPI) ApplyRateLimits() error {
+	if api.shaper == nil {
+		return nil
+	}
+
+	limits, err := api.ClientRateLimits()
+	if err != nil {
+		return err
+	}
+
+	config := &system.VPNConfig{
+		VPNNetwork:       api.ipPool.GetNetworkInfo().Network,
+		ClientRateLimits: limits,
+	}
+	if err := api.shaper.ApplyRateLimits(config); err != nil {
+		return fmt.Errorf("failed to apply rate limits: %w", err)
+	}
+	return nil
+}
+
+// qrCodeOwner returns the QR code cache owner of a client.
+func qrCodeOwner(clientID uint) string {
+	return strconv.FormatUint(uint64(clientID), 10)
+}
+
+// invalidateQRCodes drops the cached QR codes of a client, e.g. after it was
+// assigned an IP address or deleted. Other changes miss the cache anyway since
+// it is keyed by the rendered config; this frees the stale codes early.
+func (api *ClientAPI) invalidateQRCodes(clientID uint) {
+	if api.qrCache != nil {
+		api.qrCache.RemoveOwner(qrCodeOwner(clientID))
+	}
+}
+
+// unsafeFileNameChars matches characters not allowed in download file names.
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// configFileName derives a safe file name (without extension) for a client's
+// config from its name, falling back to the client ID if nothing usable remains.
+func configFileName(client *database.Client) string {
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(client.Name, "_"), "._")
+	if name == "" {
+		return fmt.Sprintf("client-%d", client.ID)
+	}
+	return name
+}
+
+// newClientResponse converts a database client into its API representation.
+func newClientResponse(client *database.Client) ClientResponse {
+	response := ClientResponse{
+		ID:            client.ID,
+		Name:          client.Name,
+		PublicKey:     client.PublicKey,
+		IPAddress:     client.IPAddress,
+		Enabled:       client.Enabled,
+		CreatedAt:     client.CreatedAt,
+		UpdatedAt:     client.UpdatedAt,
+		LastHandshake: client.LastHandshake,
+		BytesReceived: client.BytesReceived,
+		BytesSent:     client.BytesSent,
+		Tags:          parseList(client.Tags),
+		PersistentKeepalive: client.PersistentKeepalive,
+		DataCapSoftBytes: client.DataCapSoftBytes,
+		DataCapHardBytes: client.DataCapHardBytes,
+		DataCapExceeded:  client.DataCapExceeded,
+		DataQuotaBytes:   client.DataQuotaBytes,
+		DataQuotaExceeded: client.DataQuotaExceeded,
+		PeerNotApplied:   client.PeerNotApplied,
+		Pending:          client.Pending,
+		Notes:            client.Notes,
+		Metadata:         decodeMetadata(client.Metadata),
+		ConfigStale:      client.ConfigStale,
+		RateLimitMbps:    client.RateLimitMbps,
+		AllowedIPs:       parseList(client.AllowedIPs),
+		DNS:              parseList(client.DNS),
+		ExpiresAt:        client.ExpiresAt,
+		Expired:          client.IsExpired(time.Now()),
+	}
+	if client.DataQuotaBytes > 0 {
+		remaining := client.DataQuotaRemaining()
+		response.DataQuotaRemainingBytes = &remaining
+	}
+	return response
+}
+
+// applyEnabledState adds or removes the client's peer so the WireGuard
+// configuration matches client.Enabled, then syncs the running interface.
+// An already present or already missing peer is left alone. Pending clients
+// have no peer and are skipped.
+// Failures follow the peer failure policy: under the strict policy with a
+// running interface the error is returned and the change must be refused;
+// otherwise the client is flagged as peer not applied and nil is returned.
+func (api *ClientAPI) applyEnabledState(client *database.Client) error {
+	if client.Pending {
+		return nil
+	}
+
+	if err := api.syncPeer(client); err != nil {
+		if api.config.PeerFailurePolicy == PeerFailureStrict && api.peers.IsRunning() {
+			return err
+		}
+		api.flagPeerNotApplied(client, err)
+		return nil
+	}
+
+	client.PeerNotApplied = false
+	return nil
+}
+
+// syncPeer adds the peer of an enabled client or removes the peer of a
+// disabled client and reloads the running interface if anything changed.
+func (api *ClientAPI) syncPeer(client *database.Client) error {
+	present, err := api.peers.HasPeer(client.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to look up peer: %w", err)
+	}
+
+	switch {
+	case client.Enabled && !present:
+		if err := api.peers.AddPeer(NewClientPeer(client, api.defaultKeepalive())); err != nil {
+			return fmt.Errorf("failed to add peer: %w", err)
+		}
+	case !client.Enabled && present:
+		if err := api.peers.RemovePeer(client.PublicKey); err != nil {
+			return fmt.Errorf("failed to remove peer: %w", err)
+		}
+	default:
+		return nil
+	}
+
+	return api.reloadPeers()
+}
+
+// applyPeer adds a peer to the WireGuard configuration and syncs the running
+// interface so the peer is reachable without a restart.
+func (api *ClientAPI) applyPeer(peer *wireguard.Peer) error {
+	if err := api.peers.AddPeer(peer); err != nil {
+		return err
+	}
+	return api.reloadPeers()
+}
+
+// peerApplyError reports that a peer could not be applied to the running
+// interface under the strict peer failure policy.
+type peerApplyError struct {
+	err error
+}
+
+func (e *peerApplyError) Error() string {
+	return fmt.Sprintf("failed to apply peer: %v", e.err)
+}
+
+func (e *peerApplyError) Unwrap() error {
+	return e.err
+}
+
+// reloadPeers applies the WireGuard configuration file to the running
+// interface with wg syncconf, leaving the tunnels of other peers up.
+// A stopped interface picks up the file on its next start and is left alone.
+func (api *ClientAPI) reloadPeers() error {
+	if err := api.peers.ApplyConfig(); err != nil {
+		return fmt.Errorf("failed to sync running interface: %w", err)
+	}
+	return nil
+}
+
+// flagPeerNotApplied marks a client whose peer could not be added to the
+// WireGuard interface and raises an alert if an alert manager is configured.
+func (api *ClientAPI) flagPeerNotApplied(client *database.Client, cause error) {
+	client.PeerNotApplied = true
+	api.db.UpdateClient(client)
+	api.alertPeerNotApplied(client, cause)
+}
+
+// alertPeerNotApplied raises an alert for a client whose peer could not be
+// added to the WireGuard interface if an alert manager is configured.
+func (api *ClientAPI) alertPeerNotApplied(client *database.Client, cause error) {
+	if api.alerts == nil {
+		return
+	}
+	api.alerts.RaiseAlert(fmt.Sprintf("application_peer_not_applied_%d", client.ID),
+		monitoring.AlertTypeApplication, monitoring.SeverityHigh,
+		"Client Peer Not Applied",
+		fmt.Sprintf("Failed to apply peer for client %s to the WireGuard interface: %v", client.Name, cause),
+		map[string]interface{}{
+			"client_id":  client.ID,
+			"public_key": client.PublicKey,
+		})
+}
+
+// encodeMetadata validates client metadata and stores it as a JSON object.
+// Keys may only contain letters, digits, '_', '-' and '.' so they can be
+// searched with JSON paths. Empty metadata is stored as an empty string.
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	if len(metadata) > maxMetadataEntries {
+		return "", fmt.Errorf("metadata must not have more than %d entries", maxMetadataEntries)
+	}
+	for key, value := range metadata {
+		if !metadataKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("invalid metadata key %q: use 1-64 letters, digits, '_', '-' or '.'", key)
+		}
+		if len(value) > maxMetadataValueLength {
+			return "", fmt.Errorf("metadata value of %q must not exceed %d bytes", key, maxMetadataValueLength)
+		}
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeMetadata parses stored client metadata.
+// Missing or malformed metadata is returned as an empty map.
+func decodeMetadata(value string) map[string]string {
+	metadata := map[string]string{}
+	if value == "" {
+		return metadata
+	}
+	if err := json.Unmarshal([]byte(value), &metadata); err != nil {
+		return map[string]string{}
+	}
+	return metadata
+}
+
+// validateDataCaps checks that the soft data cap does not exceed the hard cap.
+// A zero value disables the corresponding cap.
+func validateDataCaps(soft, hard uint64) error {
+	if soft > 0 && hard > 0 && soft > hard {
+		return fmt.Errorf("data_cap_soft_bytes must not exceed data_cap_hard_bytes")
+	}
+	return nil
+}
+
+// validateClientKeys checks the keys supplied with a client creation request.
+// Omitted keys are generated later and need no validation.
+func validateClientKeys(req *CreateClientRequest) error {
+	if req.PrivateKey != "" {
+		if err := wireguard.ValidateKey(req.PrivateKey); err != nil {
+			return fmt.Errorf("invalid private_key: %w", err)
+		}
+	}
+	if req.PresharedKey != "" {
+		if err := wireguard.ValidateKey(req.PresharedKey); err != nil {
+			return fmt.Errorf("invalid preshared_key: %w", err)
+		}
+	}
+	return nil
+}
+
+// clientKeys returns the key pair and preshared key for a new client, keeping
+// the keys supplied in the request and generating the missing ones.
+// The supplied keys must have been checked with validateClientKeys.
+func clientKeys(req *CreateClientRequest) (*wireguard.KeyPair, string, error) {
+	var keyPair *wireguard.KeyPair
+	if req.PrivateKey != "" {
+		publicKey, err := wireguard.DerivePublicKey(req.PrivateKey)
+		if err != nil {
+			return nil, "", err
+		}
+		keyPair = &wireguard.KeyPair{PrivateKey: req.PrivateKey, PublicKey: publicKey}
+	} else {
+		generated, err := wireguard.GenerateKeyPair()
+		if err != nil {
+			return nil, "", err
+		}
+		keyPair = generated
+	}
+
+	presharedKey := req.PresharedKey
+	if presharedKey == "" {
+		generated, err := wireguard.GeneratePresharedKey()
+		if err != nil {
+			return nil, "", err
+		}
+		presharedKey = generated
+	}
+
+	return keyPair, presharedKey, nil
+}
+
+// validateExpiry checks that a client expiry date lies in the future.
+func validateExpiry(expiresAt, now time.Time) error {
+	if !expiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	return nil
+}
+
+// buildClientConfig creates the WireGuard configuration for a client.
+// The server public key, endpoint, DNS and tunnel routes come from the stored
+// server configuration. DNS is taken from the client, the server's DefaultDNS
+// or the server's DNS, in that order, falling back to defaultDNS.
+// An empty endpoint selects the primary endpoint; any other value must be one
+// of the configured endpoints, which lets admins hand out failover variants of
+// the same config. Returns errEndpointNotConfigured until the server has been
+// initialized with a public endpoint, an error wrapping errClientExpired for
+// expired clients and, unless disabled, an error wrapping
+// wireguard.ErrIncompleteConfig if the result is unusable.
+func (api *ClientAPI) buildClientConfig(client *database.Client, endpoint string) (*wireguard.ClientConfig, error) {
+	if client.Pending {
+		return nil, errClientPending
+	}
+	if client.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("%w since %s; extend expires_at to restore access",
+			errClientExpired, client.ExpiresAt.Format(time.RFC3339))
+	}
+
+	serverConfig, err := api.db.GetServerConfig()
+	if err == gorm.ErrRecordNotFound {
+		return nil, errEndpointNotConfigured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server configuration: %w", err)
+	}
+	host, err := selectEndpoint(serverConfig, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	dns := api.defaultDNS()
+	if serverDNS := parseList(serverConfig.DNS); len(serverDNS) > 0 {
+		dns = serverDNS
+	}
+	if serverDefaultDNS := parseList(serverConfig.DefaultDNS); len(serverDefaultDNS) > 0 {
+		dns = serverDefaultDNS
+	}
+
+	allowedIPs := []string{"0.0.0.0/0"}
+	if serverConfig.TunnelMode == TunnelModeSplit {
+		allowedIPs = splitTunnelAllowedIPs(serverConfig.Network, parseList(serverConfig.PushedRoutes))
+	}
+
+	// Per-client overrides take precedence over the server defaults
+	if clientDNS := parseList(client.DNS); len(clientDNS) > 0 {
+		dns = clientDNS
+	}
+	if clientAllowedIPs := parseList(client.AllowedIPs); len(clientAllowedIPs) > 0 {
+		allowedIPs = clientAllowedIPs
+	}
+
+	clientConfig := &wireguard.ClientConfig{
+		PrivateKey:          client.PrivateKey,
+		PublicKey:           client.PublicKey,
+		Address:             wireguard.HostPrefix(client.IPAddress),
+		DNS:                 dns,
+		MTU:                 serverConfig.MTU,
+		ServerPublicKey:     serverConfig.PublicKey,
+		PresharedKey:        client.PresharedKey,
+		ServerEndpoint:      net.JoinHostPort(host, strconv.Itoa(serverConfig.ListenPort)),
+		AllowedIPs:          allowedIPs,
+		PersistentKeepalive: api.resolveKeepalive(client, serverConfig),
+	}
+
+	if api.config.RejectIncompleteConfigs {
+		if err := clientConfig.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return clientConfig, nil
+}
+
+// defaultDNS returns the DNS servers handed to clients when the server
+// configuration sets none. With DNSViaServer the server's own VPN IP is used, so
+// clients resolve through a resolver running on the server.
+func (api *ClientAPI) defaultDNS() []string {
+	if api.config.DNSViaServer {
+		return []string{api.ipPool.GetServerIP()}
+	}
+	return []string{"8.8.8.8", "8.8.4.4"}
+}
+
+// selectEndpoint returns the endpoint host a client configuration should use.
+// An empty request selects the primary endpoint; otherwise the requested host
+// must match the primary or one of the alternate endpoints (case-insensitive).
+func selectEndpoint(serverConfig *database.ServerConfig, requested string) (string, error) {
+	if serverConfig.Endpoint == "" {
+		return "", errEndpointNotConfigured
+	}
+
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		return serverConfig.Endpoint, nil
+	}
+
+	candidates := append([]string{serverConfig.Endpoint}, parseList(serverConfig.AlternateEndpoints)...)
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate, requested) {
+			return candidate, nil
+		}
+	}
+	return "", errEndpointNotAllowed
+}
+
+// writeClientConfigError reports a failure to build a client configuration.
+// A missing or unknown server endpoint is a client error, a pending client is a
+// conflict, and anything else is a server error. An incomplete server
+// configuration is reported with its cause so admins know what to fix.
+func writeClientConfigError(c *gin.Context, err error) {
+	if errors.Is(err, wireguard.ErrIncompleteConfig) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, errClientPending) {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, errClientExpired) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if errors.Is(err, errEndpointNotConfigured) || errors.Is(err, errEndpointNotAllowed) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to build client configuration"})
+}
+
+// splitTunnelAllowedIPs merges the VPN subnet with the pushed routes, dropping duplicates.
+func splitTunnelAllowedIPs(vpnNetwork string, pushedRoutes []string) []string {
+	allowedIPs := []string{vpnNetwork}
+	for _, route := range pushedRoutes {
+		if !containsString(allowedIPs, route) {
+			allowedIPs = append(allowedIPs, route)
+		}
+	}
+	return allowedIPs
+}
+
+// resolveKeepalive determines the PersistentKeepalive for a client.
+// An explicit per-client value always wins; otherwise the first of the client's
+// tags with a configured default is used, and clients without one get the
+// server's default keepalive (none unless configured).
+func (api *ClientAPI) resolveKeepalive(client *database.Client, serverConfig *database.ServerConfig) int {
+	if client.PersistentKeepalive != nil {
+		return *client.PersistentKeepalive
+	}
+
+	for _, tag := range parseList(client.Tags) {
+		if keepalive, ok := api.config.TagKeepalive[tag]; ok {
+			return keepalive
+		}
+	}
+
+	return serverConfig.DefaultKeepalive
+}
+
+// defaultKeepalive returns the server's default keepalive for peers of
+// clients without their own, or 0 if the server is not initialized.
+func (api *ClientAPI) defaultKeepalive() int {
+	serverConfig, err := api.db.GetServerConfig()
+	if err != nil {
+		return 0
+	}
+	return serverConfig.DefaultKeepalive
+}
+
+// NewClientPeer returns the WireGuard peer of a client. The server keeps the
+// tunnel alive with the client's own keepalive or, without one, defaultKeepalive.
+func NewClientPeer(client *database.Client, defaultKeepalive int) *wireguard.Peer {
+	return wireguard.NewClientPeer(client.PublicKey, client.PresharedKey, client.IPAddress, client.Keepalive(defaultKeepalive))
+}
+
+// parseList splits a comma-separated database value into trimmed, non-empty entries.
+func parseList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizeDNSServers validates that every DNS server is an IP address.
+// Returns the trimmed servers or an error naming the first invalid entry.
+func normalizeDNSServers(servers []string) ([]string, error) {
+	normalized := make([]string, 0, len(servers))
+	for _, server := range servers {
+		ip := net.ParseIP(strings.TrimSpace(server))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid DNS server %q: must be an IP address", server)
+		}
+		normalized = append(normalized, ip.String())
+	}
+	return normalized, nil
+}
+
+// normalizeAllowedIPs validates that every route is a CIDR or an IP address.
+// Bare addresses become host routes (/32 or /128) and CIDRs are reduced to
+// their network address, so "10.1.2.3/24" is stored as "10.1.2.0/24".
+func normalizeAllowedIPs(routes []string) ([]string, error) {
+	normalized := make([]string, 0, len(routes))
+	for _, route := range routes {
+		route = strings.TrimSpace(route)
+		if ip := net.ParseIP(route); ip != nil {
+			normalized = append(normalized, wireguard.HostPrefix(ip.String()))
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(route)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed IP %q: must be a CIDR or IP address", route)
+		}
+		normalized = append(normalized, ipNet.String())
+	}
+	return normalized, nil
+}
+
+// containsString reports whether items contains value.
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// joinList stores a list of values as a comma-separated database value.
+func joinList(items []string) string {
+	trimmed := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return strings.Join(trimmed, ",")
+}
+
+// attachPeerStatus joins live peer status into each client response by public key.
+// Clients without a matching live peer are marked as never connected, and if the
+// live status cannot be retrieved at all every client is marked as unknown.
+func (api *ClientAPI) attachPeerStatus(clients []ClientResponse) {
+	peers, err := api.peerSource.GetPeerStatus()
+	if err != nil {
+		for i := range clients {
+			clients[i].Status = &ClientStatusResponse{State: ConnectionStateUnknown}
+		}
+		return
+	}
+
+	peersByKey := make(map[string]wireguard.PeerStatus, len(peers))
+	for _, peer := range peers {
+		peersByKey[peer.PublicKey] = peer
+	}
+
+	now := time.Now()
+	for i := range clients {
+		peer, ok := peersByKey[clients[i].PublicKey]
+		if !ok {
+			clients[i].Status = &ClientStatusResponse{State: ConnectionStateNever}
+			continue
+		}
+		clients[i].Status = newClientStatusResponse(peer, now)
+	}
+}
+
+// newClientStatusResponse derives the connection state of a live peer.
+func newClientStatusResponse(peer wireguard.PeerStatus, now time.Time) *ClientStatusResponse {
+	status := &ClientStatusResponse{
+		State:           ConnectionStateNever,
+		LatestHandshake: peer.LatestHandshake,
+		Endpoint:        peer.Endpoint,
+		BytesReceived:   peer.BytesReceived,
+		BytesSent:       peer.BytesSent,
+	}
+
+	if peer.LatestHandshake != nil {
+		if now.Sub(*peer.LatestHandshake) < monitoring.ActivePeerWindow {
+			status.State = ConnectionStateConnected
+			status.Online = true
+		} else {
+			status.State = ConnectionStateStale
+		}
+	}
+
+	return status
+}
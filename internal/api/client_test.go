@@ -1,13 +1,23 @@
 package api
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +26,10 @@ import (
 	"gorm.io/gorm"
 
 	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
 	"my-vpn/internal/network"
+	"my-vpn/internal/system"
+	"my-vpn/internal/utils"
 	"my-vpn/internal/wireguard"
 )
 
@@ -103,6 +116,84 @@ func TestClientAPI_CreateClient(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, resp.Code)
 	})
 
+	t.Run("should keep supplied keys", func(t *testing.T) {
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		presharedKey, err := wireguard.GeneratePresharedKey()
+		require.NoError(t, err)
+
+		body, err := json.Marshal(CreateClientRequest{
+			Name:         "migrated-client",
+			PrivateKey:   keyPair.PrivateKey,
+			PresharedKey: presharedKey,
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, keyPair.PublicKey, response.PublicKey)
+
+		client, err := clientAPI.db.GetClient(response.ID)
+		require.NoError(t, err)
+		assert.Equal(t, keyPair.PrivateKey, client.PrivateKey)
+		assert.Equal(t, presharedKey, client.PresharedKey)
+
+		// The same key cannot be used twice
+		body, err = json.Marshal(CreateClientRequest{Name: "duplicate-key", PrivateKey: keyPair.PrivateKey})
+		require.NoError(t, err)
+		req = httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("should reject invalid supplied keys", func(t *testing.T) {
+		for _, createReq := range []CreateClientRequest{
+			{Name: "bad-private-key", PrivateKey: "not-a-key"},
+			{Name: "short-private-key", PrivateKey: base64.StdEncoding.EncodeToString(make([]byte, 16))},
+			{Name: "bad-preshared-key", PresharedKey: "not-a-key"},
+		} {
+			body, err := json.Marshal(createReq)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code, createReq.Name)
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Contains(t, response.Error, "invalid ", createReq.Name)
+		}
+	})
+
+	t.Run("should fail when soft data cap exceeds hard cap", func(t *testing.T) {
+		createReq := CreateClientRequest{
+			Name:             "capped-client",
+			DataCapSoftBytes: 2000,
+			DataCapHardBytes: 1000,
+		}
+
+		body, err := json.Marshal(createReq)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
 	t.Run("should fail when IP pool is exhausted", func(t *testing.T) {
 		// Create a small IP pool and exhaust it
 		smallPool, err := network.NewIPPool("10.1.0.0/29") // Only 6 hosts available (8 total - network - broadcast = 6, minus server = 5 client IPs)
@@ -138,6 +229,228 @@ func TestClientAPI_CreateClient(t *testing.T) {
 	})
 }
 
+func TestClientAPI_DuplicateClientName(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	createClient := func(t *testing.T, name string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(CreateClientRequest{Name: name})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject a name that is already taken", func(t *testing.T) {
+		require.Equal(t, http.StatusCreated, createClient(t, "office-laptop").Code)
+		allocated := clientAPI.ipPool.GetAllocatedCount()
+
+		resp := createClient(t, "office-laptop")
+		assert.Equal(t, http.StatusConflict, resp.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, database.ErrDuplicateClientName.Error(), response.Error)
+		assert.Equal(t, allocated, clientAPI.ipPool.GetAllocatedCount())
+	})
+
+	t.Run("should reject renaming a client to a taken name", func(t *testing.T) {
+		resp := createClient(t, "office-phone")
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		body, err := json.Marshal(map[string]string{"name": "office-laptop"})
+		require.NoError(t, err)
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", created.ID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		update := httptest.NewRecorder()
+		router.ServeHTTP(update, req)
+		assert.Equal(t, http.StatusConflict, update.Code)
+
+		client, err := clientAPI.db.GetClient(created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "office-phone", client.Name)
+	})
+
+	t.Run("should allow reusing the name of a deleted client", func(t *testing.T) {
+		resp := createClient(t, "contractor")
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", created.ID), nil)
+		deleted := httptest.NewRecorder()
+		router.ServeHTTP(deleted, req)
+		require.Equal(t, http.StatusNoContent, deleted.Code)
+
+		assert.Equal(t, http.StatusCreated, createClient(t, "contractor").Code)
+	})
+}
+
+func TestClientAPI_CreateClientWithInclude(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Store a real server configuration so the rendered config uses it
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+	serverConfig := &database.ServerConfig{
+		PrivateKey: keyPair.PrivateKey,
+		PublicKey:  keyPair.PublicKey,
+		ListenPort: 51999,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+		DNS:        "1.1.1.1",
+		Endpoint:   "vpn.example.com",
+	}
+	require.NoError(t, clientAPI.db.CreateServerConfig(serverConfig))
+
+	create := func(t *testing.T, query, name string) CreateClientResponse {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients"+query, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("should omit config and QR code by default", func(t *testing.T) {
+		response := create(t, "", "lean-client")
+
+		assert.Empty(t, response.Config)
+		assert.Empty(t, response.QRCode)
+	})
+
+	t.Run("should embed rendered config when requested", func(t *testing.T) {
+		response := create(t, "?include=config", "config-client")
+
+		assert.Contains(t, response.Config, "[Interface]")
+		assert.Contains(t, response.Config, "[Peer]")
+		assert.Contains(t, response.Config, "Address = "+response.IPAddress+"/32")
+		assert.Contains(t, response.Config, "PublicKey = "+keyPair.PublicKey)
+		assert.Contains(t, response.Config, "Endpoint = vpn.example.com:51999")
+		assert.Contains(t, response.Config, "DNS = 1.1.1.1")
+		assert.Empty(t, response.QRCode)
+	})
+
+	t.Run("should embed base64 QR code when requested", func(t *testing.T) {
+		response := create(t, "?include=qr", "qr-client")
+
+		assert.True(t, strings.HasPrefix(response.QRCode, "data:image/png;base64,"))
+		assert.Empty(t, response.Config)
+	})
+
+	t.Run("should embed both when requested", func(t *testing.T) {
+		response := create(t, "?include=config,qr", "both-client")
+
+		assert.Contains(t, response.Config, "[Interface]")
+		assert.True(t, strings.HasPrefix(response.QRCode, "data:image/png;base64,"))
+	})
+}
+
+func TestClientAPI_CreateClientWithIPAddress(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	peers := &fakePeerManager{running: true}
+	clientAPI.peers = peers
+
+	create := func(t *testing.T, name, ip string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(CreateClientRequest{Name: name, IPAddress: ip})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should pin client to the requested IP", func(t *testing.T) {
+		resp := create(t, "printer", "10.0.0.200")
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "10.0.0.200", response.IPAddress)
+		assert.True(t, clientAPI.ipPool.IsAllocated("10.0.0.200"))
+
+		require.NotEmpty(t, peers.added)
+		assert.Equal(t, []string{"10.0.0.200/32"}, peers.added[len(peers.added)-1].AllowedIPs)
+	})
+
+	t.Run("should keep auto-allocating without a requested IP", func(t *testing.T) {
+		resp := create(t, "laptop", "")
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.NotEqual(t, "10.0.0.200", response.IPAddress)
+	})
+
+	t.Run("should return conflict when the IP is taken", func(t *testing.T) {
+		resp := create(t, "second-printer", "10.0.0.200")
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "already allocated")
+	})
+
+	t.Run("should reject an address outside the network", func(t *testing.T) {
+		resp := create(t, "outsider", "192.168.1.10")
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "not in network range")
+	})
+
+	t.Run("should reject reserved and malformed addresses", func(t *testing.T) {
+		for _, ip := range []string{"10.0.0.1", "10.0.0.0", "10.0.0.255", "not-an-ip"} {
+			assert.Equal(t, http.StatusBadRequest, create(t, "reserved", ip).Code, ip)
+		}
+
+		count, err := clientAPI.db.CountClients(database.ClientFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+// seedServerEndpoint stores a server configuration with a public endpoint so
+// client configurations can be rendered.
+func seedServerEndpoint(t *testing.T, clientAPI *ClientAPI) *database.ServerConfig {
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+
+	serverConfig := &database.ServerConfig{
+		PrivateKey: keyPair.PrivateKey,
+		PublicKey:  keyPair.PublicKey,
+		ListenPort: 51820,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+		Endpoint:   "vpn.example.com",
+	}
+	require.NoError(t, clientAPI.db.CreateServerConfig(serverConfig))
+	return serverConfig
+}
+
+// newKeyedClient returns an enabled client with a generated key pair, so the
+// configurations rendered for it pass validation.
+func newKeyedClient(t *testing.T, name, ip string) *database.Client {
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+
+	return &database.Client{
+		Name:       name,
+		PublicKey:  keyPair.PublicKey,
+		PrivateKey: keyPair.PrivateKey,
+		IPAddress:  ip,
+		Enabled:    true,
+	}
+}
+
 func TestClientAPI_GetClients(t *testing.T) {
 	_, router, cleanup := setupTestAPI(t)
 	defer cleanup()
@@ -186,203 +499,2883 @@ func TestClientAPI_GetClients(t *testing.T) {
 	})
 }
 
-func TestClientAPI_GetClient(t *testing.T) {
-	_, router, cleanup := setupTestAPI(t)
+func TestClientAPI_GetClientsPaged(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
 	defer cleanup()
 
-	t.Run("should return client by ID", func(t *testing.T) {
-		// Create a client first
-		createReq := CreateClientRequest{Name: "test-client"}
-		body, _ := json.Marshal(createReq)
-		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
+	base := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	seeds := []struct {
+		name      string
+		enabled   bool
+		handshake time.Duration
+	}{
+		{"delta", true, 3 * time.Hour},
+		{"alpha", false, time.Hour},
+		{"charlie", true, 4 * time.Hour},
+		{"bravo", true, 2 * time.Hour},
+		{"echo", false, 5 * time.Hour},
+	}
+	for i, seed := range seeds {
+		handshake := base.Add(seed.handshake)
+		client := &database.Client{
+			Name:          seed.name,
+			PublicKey:     seed.name + "-key",
+			PrivateKey:    "private",
+			IPAddress:     fmt.Sprintf("10.0.0.%d", i+2),
+			CreatedAt:     base.Add(time.Duration(i) * time.Minute),
+			LastHandshake: &handshake,
+		}
+		require.NoError(t, clientAPI.db.CreateClient(client))
+		client.Enabled = seed.enabled
+		require.NoError(t, clientAPI.db.UpdateClient(client))
+	}
+
+	list := func(t *testing.T, query string) (int, GetClientsResponse) {
+		req := httptest.NewRequest("GET", "/api/clients?"+query, nil)
 		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
-		require.Equal(t, http.StatusCreated, resp.Code)
 
-		var createResponse CreateClientResponse
-		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
-		require.NoError(t, err)
+		var response GetClientsResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		}
+		return resp.Code, response
+	}
 
-		// Get the client
-		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
-		resp = httptest.NewRecorder()
-		router.ServeHTTP(resp, req)
+	names := func(response GetClientsResponse) []string {
+		result := make([]string, len(response.Clients))
+		for i, client := range response.Clients {
+			result[i] = client.Name
+		}
+		return result
+	}
 
-		assert.Equal(t, http.StatusOK, resp.Code)
+	t.Run("should return pages with the total of the full set", func(t *testing.T) {
+		code, response := list(t, "sort=name&limit=2")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"alpha", "bravo"}, names(response))
+		assert.Equal(t, 5, response.Total)
+		assert.Equal(t, 2, response.Limit)
 
-		var response ClientResponse
-		err = json.Unmarshal(resp.Body.Bytes(), &response)
-		require.NoError(t, err)
+		_, response = list(t, "sort=name&limit=2&offset=2")
+		assert.Equal(t, []string{"charlie", "delta"}, names(response))
+		assert.Equal(t, 2, response.Offset)
 
-		assert.Equal(t, createResponse.ID, response.ID)
-		assert.Equal(t, "test-client", response.Name)
+		_, response = list(t, "sort=name&limit=2&offset=4")
+		assert.Equal(t, []string{"echo"}, names(response))
+
+		_, response = list(t, "limit=2&offset=10")
+		assert.Empty(t, response.Clients)
+		assert.Equal(t, 5, response.Total)
 	})
 
-	t.Run("should return 404 for non-existent client", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/clients/999", nil)
-		resp := httptest.NewRecorder()
+	t.Run("should order by the requested field", func(t *testing.T) {
+		_, response := list(t, "sort=name&order=desc")
+		assert.Equal(t, []string{"echo", "delta", "charlie", "bravo", "alpha"}, names(response))
 
-		router.ServeHTTP(resp, req)
+		_, response = list(t, "sort=created_at")
+		assert.Equal(t, []string{"delta", "alpha", "charlie", "bravo", "echo"}, names(response))
 
-		assert.Equal(t, http.StatusNotFound, resp.Code)
+		_, response = list(t, "sort=last_handshake&order=desc&limit=2")
+		assert.Equal(t, []string{"echo", "charlie"}, names(response))
 	})
 
-	t.Run("should return 400 for invalid ID", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/clients/invalid", nil)
-		resp := httptest.NewRecorder()
+	t.Run("should filter by enabled state before paging", func(t *testing.T) {
+		_, response := list(t, "enabled=true&sort=name&limit=2")
+		assert.Equal(t, []string{"bravo", "charlie"}, names(response))
+		assert.Equal(t, 3, response.Total)
 
-		router.ServeHTTP(resp, req)
+		_, response = list(t, "enabled=false&sort=name")
+		assert.Equal(t, []string{"alpha", "echo"}, names(response))
+		assert.Equal(t, 2, response.Total)
+	})
 
-		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	t.Run("should clamp the limit", func(t *testing.T) {
+		_, response := list(t, "limit=10000")
+		assert.Equal(t, 500, response.Limit)
+
+		_, response = list(t, "")
+		assert.Equal(t, 100, response.Limit)
+	})
+
+	t.Run("should reject invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=abc", "offset=-1", "sort=public_key", "order=sideways"} {
+			code, _ := list(t, query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
 	})
 }
 
-func TestClientAPI_UpdateClient(t *testing.T) {
-	_, router, cleanup := setupTestAPI(t)
+func TestClientAPI_SearchClients(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
 	defer cleanup()
 
-	t.Run("should update client successfully", func(t *testing.T) {
-		// Create a client first
-		createReq := CreateClientRequest{Name: "original-name"}
-		body, _ := json.Marshal(createReq)
-		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
+	seeds := []struct {
+		name string
+		ip   string
+	}{
+		{"Alice-Laptop", "10.0.0.2"},
+		{"alice-phone", "10.0.0.3"},
+		{"bob-laptop", "10.0.0.25"},
+		{"carol_tablet", "10.0.1.4"},
+		{"dave", "10.0.0.20"},
+	}
+	for _, seed := range seeds {
+		require.NoError(t, clientAPI.db.CreateClient(&database.Client{
+			Name:       seed.name,
+			PublicKey:  seed.name + "-key",
+			PrivateKey: "private",
+			IPAddress:  seed.ip,
+			Enabled:    true,
+		}))
+	}
+
+	search := func(t *testing.T, query string) (int, []string) {
+		req := httptest.NewRequest("GET", "/api/clients/search?q="+url.QueryEscape(query), nil)
 		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
-		require.Equal(t, http.StatusCreated, resp.Code)
-
-		var createResponse CreateClientResponse
-		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
-		require.NoError(t, err)
 
-		// Update the client
-		enabled := false
-		updateReq := UpdateClientRequest{
-			Name:    "updated-name",
-			Enabled: &enabled,
+		var response GetClientsResponse
+		if resp.Code != http.StatusOK {
+			return resp.Code, nil
 		}
-		body, _ = json.Marshal(updateReq)
-		req = httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", createResponse.ID), bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		resp = httptest.NewRecorder()
-		router.ServeHTTP(resp, req)
-
-		assert.Equal(t, http.StatusOK, resp.Code)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		names := make([]string, len(response.Clients))
+		for i, client := range response.Clients {
+			names[i] = client.Name
+		}
+		return resp.Code, names
+	}
 
-		var response ClientResponse
-		err = json.Unmarshal(resp.Body.Bytes(), &response)
-		require.NoError(t, err)
+	t.Run("should match name substrings ignoring case", func(t *testing.T) {
+		code, names := search(t, "ALICE")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"Alice-Laptop", "alice-phone"}, names)
 
-		assert.Equal(t, "updated-name", response.Name)
-		assert.Equal(t, false, response.Enabled)
+		_, names = search(t, "laptop")
+		assert.Equal(t, []string{"Alice-Laptop", "bob-laptop"}, names)
 	})
 
-	t.Run("should return 404 for non-existent client", func(t *testing.T) {
-		updateReq := UpdateClientRequest{Name: "test"}
-		body, _ := json.Marshal(updateReq)
+	t.Run("should match IP prefixes", func(t *testing.T) {
+		_, names := search(t, "10.0.0.2")
+		assert.Equal(t, []string{"Alice-Laptop", "bob-laptop", "dave"}, names)
 
-		req := httptest.NewRequest("PUT", "/api/clients/999", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		resp := httptest.NewRecorder()
+		_, names = search(t, "10.0.1.")
+		assert.Equal(t, []string{"carol_tablet"}, names)
 
-		router.ServeHTTP(resp, req)
+		_, names = search(t, "0.0.2")
+		assert.Empty(t, names)
+	})
 
-		assert.Equal(t, http.StatusNotFound, resp.Code)
+	t.Run("should match wildcards literally", func(t *testing.T) {
+		_, names := search(t, "_")
+		assert.Equal(t, []string{"carol_tablet"}, names)
+
+		_, names = search(t, "%")
+		assert.Empty(t, names)
+	})
+
+	t.Run("should reject empty query", func(t *testing.T) {
+		code, _ := search(t, "  ")
+		assert.Equal(t, http.StatusBadRequest, code)
 	})
 }
 
-func TestClientAPI_DeleteClient(t *testing.T) {
-	_, router, cleanup := setupTestAPI(t)
+func TestClientAPI_GetClientCount(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
 	defer cleanup()
 
-	t.Run("should delete client successfully", func(t *testing.T) {
-		// Create a client first
-		createReq := CreateClientRequest{Name: "to-be-deleted"}
-		body, _ := json.Marshal(createReq)
+	seeds := []struct {
+		name    string
+		tags    string
+		enabled bool
+	}{
+		{"laptop", "work", true},
+		{"phone", "mobile,work", true},
+		{"tablet", "mobile", false},
+		{"guest", "", false},
+	}
+	for i, seed := range seeds {
+		client := &database.Client{
+			Name:       seed.name,
+			PublicKey:  fmt.Sprintf("count-public-key-%d", i),
+			PrivateKey: fmt.Sprintf("count-private-key-%d", i),
+			IPAddress:  fmt.Sprintf("10.0.0.%d", i+10),
+			Tags:       seed.tags,
+			Enabled:    true,
+		}
+		require.NoError(t, clientAPI.db.CreateClient(client))
+		if !seed.enabled {
+			client.Enabled = false
+			require.NoError(t, clientAPI.db.UpdateClient(client))
+		}
+	}
+
+	// Record the SQL of every query to verify no client rows are loaded
+	var queries []string
+	require.NoError(t, clientAPI.db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		queries = append(queries, tx.Statement.SQL.String())
+	}))
+
+	getCount := func(t *testing.T, query string) (int, ClientCountResponse) {
+		queries = nil
+		req := httptest.NewRequest("GET", "/api/clients/count"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response ClientCountResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		}
+		return resp.Code, response
+	}
+
+	t.Run("should count all clients", func(t *testing.T) {
+		code, response := getCount(t, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(4), response.Count)
+
+		require.Len(t, queries, 1)
+		assert.Contains(t, strings.ToLower(queries[0]), "count(*)")
+	})
+
+	t.Run("should respect the enabled filter", func(t *testing.T) {
+		code, response := getCount(t, "?enabled=true")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(2), response.Count)
+
+		code, response = getCount(t, "?enabled=false")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(2), response.Count)
+	})
+
+	t.Run("should respect the tag filter", func(t *testing.T) {
+		code, response := getCount(t, "?tag=mobile")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(2), response.Count)
+
+		code, response = getCount(t, "?tag=mobile&enabled=true")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(1), response.Count)
+
+		// Tags are matched as whole items, not substrings
+		code, response = getCount(t, "?tag=mob")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, int64(0), response.Count)
+	})
+
+	t.Run("should match the filtered client list", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clients?tag=work", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var list GetClientsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+
+		_, response := getCount(t, "?tag=work")
+		assert.Equal(t, int64(list.Total), response.Count)
+		assert.Equal(t, int64(2), response.Count)
+	})
+
+	t.Run("should reject invalid enabled filter", func(t *testing.T) {
+		code, _ := getCount(t, "?enabled=maybe")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestClientAPI_ClientMetadata(t *testing.T) {
+	_, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	send := func(t *testing.T, method, url string, payload interface{}) *httptest.ResponseRecorder {
+		var body *bytes.Buffer
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			require.NoError(t, err)
+			body = bytes.NewBuffer(data)
+		} else {
+			body = &bytes.Buffer{}
+		}
+		req := httptest.NewRequest(method, url, body)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	listClients := func(t *testing.T, query string) []ClientResponse {
+		resp := send(t, "GET", "/api/clients"+query, nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var list GetClientsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+		return list.Clients
+	}
+
+	resp := send(t, "POST", "/api/clients", CreateClientRequest{
+		Name:     "laptop",
+		Notes:    "Replacement for ticket #4711",
+		Metadata: map[string]string{"owner": "alice@example.com", "device": "ThinkPad X1"},
+	})
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var laptop CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &laptop))
+
+	resp = send(t, "POST", "/api/clients", CreateClientRequest{
+		Name:     "phone",
+		Metadata: map[string]string{"owner": "bob@example.com"},
+	})
+	require.Equal(t, http.StatusCreated, resp.Code)
+	var phone CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &phone))
+
+	require.Equal(t, http.StatusCreated, send(t, "POST", "/api/clients", CreateClientRequest{Name: "guest"}).Code)
+
+	t.Run("should return notes and metadata", func(t *testing.T) {
+		resp := send(t, "GET", fmt.Sprintf("/api/clients/%d", laptop.ID), nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		assert.Equal(t, "Replacement for ticket #4711", client.Notes)
+		assert.Equal(t, map[string]string{"owner": "alice@example.com", "device": "ThinkPad X1"}, client.Metadata)
+	})
+
+	t.Run("should search clients by metadata value", func(t *testing.T) {
+		clients := listClients(t, "?metadata_key=owner&metadata_value=bob@example.com")
+		require.Len(t, clients, 1)
+		assert.Equal(t, phone.ID, clients[0].ID)
+
+		clients = listClients(t, "?metadata_key=owner&metadata_value=carol@example.com")
+		assert.Empty(t, clients)
+	})
+
+	t.Run("should search clients by metadata key", func(t *testing.T) {
+		clients := listClients(t, "?metadata_key=owner")
+		assert.Len(t, clients, 2)
+
+		clients = listClients(t, "?metadata_key=device")
+		require.Len(t, clients, 1)
+		assert.Equal(t, laptop.ID, clients[0].ID)
+	})
+
+	t.Run("should update notes and replace metadata", func(t *testing.T) {
+		notes := "Returned to IT"
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", phone.ID), map[string]interface{}{
+			"notes":    notes,
+			"metadata": map[string]string{"owner": "carol@example.com"},
+		})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		assert.Equal(t, notes, client.Notes)
+		assert.Equal(t, map[string]string{"owner": "carol@example.com"}, client.Metadata)
+
+		clients := listClients(t, "?metadata_key=owner&metadata_value=carol@example.com")
+		require.Len(t, clients, 1)
+		assert.Equal(t, phone.ID, clients[0].ID)
+	})
+
+	t.Run("should keep metadata when omitted from update", func(t *testing.T) {
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", laptop.ID), map[string]interface{}{"name": "laptop-2"})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		assert.Equal(t, "Replacement for ticket #4711", client.Notes)
+		assert.Equal(t, "alice@example.com", client.Metadata["owner"])
+	})
+
+	t.Run("should clear metadata with an empty object", func(t *testing.T) {
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", laptop.ID), map[string]interface{}{"metadata": map[string]string{}})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		assert.Empty(t, client.Metadata)
+		assert.Empty(t, listClients(t, "?metadata_key=device"))
+	})
+
+	t.Run("should reject invalid metadata keys", func(t *testing.T) {
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{
+			Name:     "invalid",
+			Metadata: map[string]string{`bad"key`: "value"},
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = send(t, "GET", `/api/clients?metadata_key=bad"key`, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should require a key for a metadata value filter", func(t *testing.T) {
+		resp := send(t, "GET", "/api/clients?metadata_value=alice@example.com", nil)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestClientAPI_GetTopClients(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Seed clients with known traffic: received, sent, total
+	// heavy-down: 900, 100, 1000
+	// heavy-up:   50, 800, 850
+	// balanced:   400, 500, 900
+	// idle:       0, 0, 0
+	seeds := []struct {
+		name     string
+		received uint64
+		sent     uint64
+	}{
+		{"heavy-down", 900, 100},
+		{"heavy-up", 50, 800},
+		{"balanced", 400, 500},
+		{"idle", 0, 0},
+	}
+	for i, seed := range seeds {
+		require.NoError(t, clientAPI.db.CreateClient(&database.Client{
+			Name:          seed.name,
+			PublicKey:     fmt.Sprintf("top-public-key-%d", i),
+			PrivateKey:    fmt.Sprintf("top-private-key-%d", i),
+			IPAddress:     fmt.Sprintf("10.0.0.%d", i+10),
+			Enabled:       true,
+			BytesReceived: seed.received,
+			BytesSent:     seed.sent,
+		}))
+	}
+
+	getTop := func(t *testing.T, query string) (int, TopClientsResponse) {
+		req := httptest.NewRequest("GET", "/api/clients/top"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response TopClientsResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		}
+		return resp.Code, response
+	}
+
+	names := func(response TopClientsResponse) []string {
+		result := make([]string, len(response.Clients))
+		for i, client := range response.Clients {
+			result[i] = client.Name
+		}
+		return result
+	}
+
+	t.Run("should order by total traffic by default", func(t *testing.T) {
+		code, response := getTop(t, "")
+		require.Equal(t, http.StatusOK, code)
+
+		assert.Equal(t, "total", response.By)
+		assert.Equal(t, []string{"heavy-down", "balanced", "heavy-up", "idle"}, names(response))
+		assert.Equal(t, uint64(900), response.Clients[0].BytesReceived)
+		assert.Equal(t, uint64(100), response.Clients[0].BytesSent)
+		assert.Equal(t, uint64(1000), response.Clients[0].BytesTotal)
+	})
+
+	t.Run("should order by received traffic", func(t *testing.T) {
+		code, response := getTop(t, "?by=received")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"heavy-down", "balanced", "heavy-up", "idle"}, names(response))
+	})
+
+	t.Run("should order by sent traffic", func(t *testing.T) {
+		code, response := getTop(t, "?by=sent")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"heavy-up", "balanced", "heavy-down", "idle"}, names(response))
+	})
+
+	t.Run("should apply limit", func(t *testing.T) {
+		code, response := getTop(t, "?by=sent&limit=2")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 2, response.Limit)
+		assert.Equal(t, []string{"heavy-up", "balanced"}, names(response))
+	})
+
+	t.Run("should reject unknown counter", func(t *testing.T) {
+		code, _ := getTop(t, "?by=packets")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("should reject invalid limit", func(t *testing.T) {
+		code, _ := getTop(t, "?limit=-1")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+// fakePeerSource is a PeerStatusSource returning canned live peer data.
+type fakePeerSource struct {
+	peers []wireguard.PeerStatus
+	err   error
+}
+
+func (f *fakePeerSource) GetPeerStatus() ([]wireguard.PeerStatus, error) {
+	return f.peers, f.err
+}
+
+// fakePeerManager is a PeerManager whose AddPeer can be made to fail.
+type fakePeerManager struct {
+	running bool
+	err     error
+	syncErr error
+	added   []*wireguard.Peer
+	removed []string
+	synced  int
+}
+
+func (f *fakePeerManager) IsRunning() bool {
+	return f.running
+}
+
+func (f *fakePeerManager) AddPeer(peer *wireguard.Peer) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.added = append(f.added, peer)
+	return nil
+}
+
+func (f *fakePeerManager) RemovePeer(publicKey string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.removed = append(f.removed, publicKey)
+	return nil
+}
+
+func (f *fakePeerManager) HasPeer(publicKey string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakePeerManager) ApplyConfig() error {
+	if !f.running {
+		return nil
+	}
+	if f.syncErr != nil {
+		return f.syncErr
+	}
+	f.synced++
+	return nil
+}
+
+func TestClientAPI_UpdateClientEnabledPeer(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "wg0.conf")
+	require.NoError(t, os.WriteFile(configPath, []byte("[Interface]\nPrivateKey = server-private-key\nAddress = 10.0.0.1/24\n"), 0600))
+	wgServer := wireguard.NewWireGuardServerWithConfig(configDir, "wg0")
+	clientAPI.wgServer = wgServer
+	clientAPI.peers = wgServer
+
+	body, _ := json.Marshal(CreateClientRequest{Name: "laptop"})
+	req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	var created CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+	setEnabled := func(t *testing.T, enabled bool) {
+		body := fmt.Sprintf(`{"enabled":%t}`, enabled)
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", created.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	readConfig := func(t *testing.T) string {
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	peerCount := func(t *testing.T) int {
+		return strings.Count(readConfig(t), "PublicKey = "+created.PublicKey)
+	}
+
+	require.Equal(t, 1, peerCount(t))
+
+	t.Run("should remove the peer when disabling", func(t *testing.T) {
+		setEnabled(t, false)
+		assert.Equal(t, 0, peerCount(t))
+
+		setEnabled(t, false)
+		assert.Equal(t, 0, peerCount(t))
+	})
+
+	t.Run("should re-add the peer once when enabling", func(t *testing.T) {
+		setEnabled(t, true)
+		assert.Equal(t, 1, peerCount(t))
+		assert.Contains(t, readConfig(t), "AllowedIPs = "+created.IPAddress+"/32")
+
+		setEnabled(t, true)
+		assert.Equal(t, 1, peerCount(t))
+	})
+
+	t.Run("should apply bulk toggles to the config", func(t *testing.T) {
+		body := fmt.Sprintf(`{"ids":[%d],"enabled":false}`, created.ID)
+		req := httptest.NewRequest("POST", "/api/clients/bulk-toggle", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, 0, peerCount(t))
+	})
+
+	t.Run("should leave the peer alone when the update cannot be stored", func(t *testing.T) {
+		require.NoError(t, clientAPI.db.CreateClient(&database.Client{
+			Name:       "phone",
+			PublicKey:  "phone-key",
+			PrivateKey: "private",
+			IPAddress:  "10.0.0.50",
+			Enabled:    true,
+		}))
+
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", created.ID), strings.NewReader(`{"name":"phone","enabled":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusConflict, resp.Code)
+
+		assert.Equal(t, 0, peerCount(t))
+		client, err := clientAPI.db.GetClient(created.ID)
+		require.NoError(t, err)
+		assert.False(t, client.Enabled)
+	})
+
+	t.Run("should refuse the change in strict mode when the interface is running", func(t *testing.T) {
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+		clientAPI.peers = &fakePeerManager{running: true, err: fmt.Errorf("wg set failed")}
+
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", created.ID), strings.NewReader(`{"enabled":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		client, err := clientAPI.db.GetClient(created.ID)
+		require.NoError(t, err)
+		assert.False(t, client.Enabled)
+	})
+}
+
+func TestClientAPI_CreateClientPeerFailure(t *testing.T) {
+	create := func(t *testing.T, router *gin.Engine, name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should roll back client and IP in strict mode", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+		clientAPI.peers = &fakePeerManager{running: true, err: fmt.Errorf("wg set failed")}
+		allocatedBefore := clientAPI.ipPool.GetAllocatedCount()
+
+		resp := create(t, router, "strict-client")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Empty(t, clients)
+		assert.Equal(t, allocatedBefore, clientAPI.ipPool.GetAllocatedCount())
+	})
+
+	t.Run("should keep client in strict mode when interface is not running", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+		clientAPI.peers = &fakePeerManager{running: false, err: fmt.Errorf("config not found")}
+
+		resp := create(t, router, "offline-client")
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.True(t, response.PeerNotApplied)
+	})
+
+	t.Run("should flag client and raise alert in lenient mode", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		alertManager := monitoring.NewAlertManager()
+		clientAPI.SetAlertManager(alertManager)
+		clientAPI.peers = &fakePeerManager{running: true, err: fmt.Errorf("wg set failed")}
+
+		resp := create(t, router, "lenient-client")
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.True(t, response.PeerNotApplied)
+
+		client, err := clientAPI.db.GetClient(response.ID)
+		require.NoError(t, err)
+		assert.True(t, client.PeerNotApplied)
+
+		alerts := alertManager.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, monitoring.AlertTypeApplication, alerts[0].Type)
+		assert.Equal(t, fmt.Sprintf("application_peer_not_applied_%d", response.ID), alerts[0].ID)
+	})
+
+	t.Run("should not flag client when peer is applied", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+		peers := &fakePeerManager{running: true}
+		clientAPI.peers = peers
+
+		resp := create(t, router, "healthy-client")
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.False(t, response.PeerNotApplied)
+		require.Len(t, peers.added, 1)
+		assert.Equal(t, response.PublicKey, peers.added[0].PublicKey)
+		assert.Equal(t, 1, peers.synced)
+	})
+
+	t.Run("should roll back peer when live sync fails in strict mode", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+		peers := &fakePeerManager{running: true, syncErr: fmt.Errorf("wg syncconf failed")}
+		clientAPI.peers = peers
+
+		resp := create(t, router, "unsynced-client")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		require.Len(t, peers.added, 1)
+		assert.Equal(t, []string{peers.added[0].PublicKey}, peers.removed)
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Empty(t, clients)
+	})
+
+	t.Run("should not sync stopped interface", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		peers := &fakePeerManager{running: false}
+		clientAPI.peers = peers
+
+		resp := create(t, router, "offline-client")
+		require.Equal(t, http.StatusCreated, resp.Code)
+		assert.Len(t, peers.added, 1)
+		assert.Zero(t, peers.synced)
+	})
+}
+
+func TestClientAPI_CreateClientRollback(t *testing.T) {
+	create := func(t *testing.T, router *gin.Engine, name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	failWrite := func(tx *gorm.DB) {
+		tx.AddError(fmt.Errorf("disk I/O error"))
+	}
+
+	t.Run("should release the IP when the insert fails", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		peers := &fakePeerManager{running: true}
+		clientAPI.peers = peers
+		allocatedBefore := clientAPI.ipPool.GetAllocatedCount()
+		require.NoError(t, clientAPI.db.Callback().Create().Before("gorm:create").Register("test:fail_create", failWrite))
+
+		resp := create(t, router, "broken-insert")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		assert.Empty(t, peers.added)
+		assert.Equal(t, allocatedBefore, clientAPI.ipPool.GetAllocatedCount())
+	})
+
+	t.Run("should remove the peer and release the IP when a write after the peer add fails", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		alertManager := monitoring.NewAlertManager()
+		clientAPI.SetAlertManager(alertManager)
+		peers := &fakePeerManager{running: true, syncErr: fmt.Errorf("wg syncconf failed")}
+		clientAPI.peers = peers
+		allocatedBefore := clientAPI.ipPool.GetAllocatedCount()
+		require.NoError(t, clientAPI.db.Callback().Update().Before("gorm:update").Register("test:fail_update", failWrite))
+
+		resp := create(t, router, "broken-flag")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		require.Len(t, peers.added, 1)
+		assert.Equal(t, []string{peers.added[0].PublicKey}, peers.removed)
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Empty(t, clients)
+		assert.Equal(t, allocatedBefore, clientAPI.ipPool.GetAllocatedCount())
+		assert.Empty(t, alertManager.GetActiveAlerts())
+	})
+}
+
+func TestClientAPI_DeleteClientSyncsPeers(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	peers := &fakePeerManager{running: true}
+	clientAPI.peers = peers
+
+	client := &database.Client{Name: "leaving", PublicKey: "leaving-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}
+	require.NoError(t, clientAPI.db.CreateClient(client))
+
+	req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", client.ID), nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusNoContent, resp.Code)
+
+	assert.Equal(t, []string{"leaving-key"}, peers.removed)
+	assert.Equal(t, 1, peers.synced)
+}
+
+func TestClientAPI_ConcurrentPeerChanges(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Every connection to an in-memory database opens a new, empty one
+	sqlDB, err := clientAPI.db.DB.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	// A real config file, synced to an interface that reports being up
+	tempDir := t.TempDir()
+	runner := system.NewMockRunner()
+	wgServer := wireguard.NewWireGuardServerWithRunner(tempDir, "wg0", runner)
+	require.NoError(t, wgServer.WriteConfig(&wireguard.ServerConfig{
+		PrivateKey: "test-private-key",
+		Address:    "10.0.0.1/24",
+		ListenPort: 51820,
+		Interface:  "wg0",
+	}))
+	clientAPI.peers = wgServer
+
+	create := func(name string) (CreateClientResponse, int) {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response CreateClientResponse
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		return response, resp.Code
+	}
+
+	remove := func(id uint) int {
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// Clients created up front, half of which are deleted concurrently below
+	const count = 10
+	var doomed []uint
+	for i := 0; i < count; i++ {
+		client, code := create(fmt.Sprintf("doomed-%d", i))
+		require.Equal(t, http.StatusCreated, code)
+		doomed = append(doomed, client.ID)
+	}
+
+	var wait sync.WaitGroup
+	var mutex sync.Mutex
+	var kept []string
+	for i := 0; i < count; i++ {
+		wait.Add(2)
+		go func(i int) {
+			defer wait.Done()
+			client, code := create(fmt.Sprintf("concurrent-%d", i))
+			if assert.Equal(t, http.StatusCreated, code) {
+				mutex.Lock()
+				kept = append(kept, client.PublicKey)
+				mutex.Unlock()
+			}
+		}(i)
+		go func(id uint) {
+			defer wait.Done()
+			assert.Equal(t, http.StatusNoContent, remove(id))
+		}(doomed[i])
+	}
+	wait.Wait()
+
+	peers, err := wgServer.GetPeers()
+	require.NoError(t, err)
+	var configured []string
+	for _, peer := range peers {
+		configured = append(configured, peer.PublicKey)
+	}
+	assert.ElementsMatch(t, kept, configured)
+
+	clients, err := clientAPI.db.ListClients()
+	require.NoError(t, err)
+	var stored []string
+	for _, client := range clients {
+		stored = append(stored, client.PublicKey)
+	}
+	assert.ElementsMatch(t, kept, stored)
+
+	drift, err := wgServer.CheckConfigDrift()
+	require.NoError(t, err)
+	assert.False(t, drift.Drifted)
+}
+
+// fakeActivationNotifier forwards client activations to a channel.
+type fakeActivationNotifier struct {
+	activations chan ClientActivation
+}
+
+func (f *fakeActivationNotifier) NotifyClientActivated(activation ClientActivation) error {
+	f.activations <- activation
+	return nil
+}
+
+func TestClientAPI_AllocationQueue(t *testing.T) {
+	create := func(t *testing.T, router *gin.Engine, name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// setup returns an API whose pool has room for a single client
+	setup := func(t *testing.T, policy string) (*ClientAPI, *gin.Engine, func()) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		pool, err := network.NewIPPool("10.0.0.0/29")
+		require.NoError(t, err)
+		for _, ip := range []string{"10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"} {
+			require.NoError(t, pool.AllocateSpecificIP(ip))
+		}
+		clientAPI.ipPool = pool
+		clientAPI.config.PoolExhaustionPolicy = policy
+		return clientAPI, router, cleanup
+	}
+
+	t.Run("should reject creation on exhaustion by default", func(t *testing.T) {
+		clientAPI, router, cleanup := setup(t, PoolExhaustionReject)
+		defer cleanup()
+
+		require.Equal(t, http.StatusCreated, create(t, router, "first").Code)
+		assert.Equal(t, http.StatusInternalServerError, create(t, router, "second").Code)
+
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Len(t, clients, 1)
+	})
+
+	t.Run("should activate pending client after an IP is released", func(t *testing.T) {
+		clientAPI, router, cleanup := setup(t, PoolExhaustionQueue)
+		defer cleanup()
+		peers := &fakePeerManager{running: true}
+		clientAPI.peers = peers
+		notifier := &fakeActivationNotifier{activations: make(chan ClientActivation, 1)}
+		clientAPI.SetActivationNotifier(notifier)
+
+		resp := create(t, router, "first")
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var first CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &first))
+		assert.Equal(t, "10.0.0.2", first.IPAddress)
+
+		// The pool is exhausted, so the second client is queued
+		resp = create(t, router, "second")
+		require.Equal(t, http.StatusAccepted, resp.Code)
+		var second CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &second))
+		assert.True(t, second.Pending)
+		assert.False(t, second.Enabled)
+		assert.Empty(t, second.IPAddress)
+
+		pending, err := clientAPI.db.GetClient(second.ID)
+		require.NoError(t, err)
+		assert.True(t, pending.Pending)
+		assert.False(t, pending.Enabled)
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", second.ID), nil)
+		configResp := httptest.NewRecorder()
+		router.ServeHTTP(configResp, req)
+		assert.Equal(t, http.StatusConflict, configResp.Code)
+
+		// Deleting the first client frees its IP for the queued one
+		req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", first.ID), nil)
+		deleteResp := httptest.NewRecorder()
+		router.ServeHTTP(deleteResp, req)
+		require.Equal(t, http.StatusNoContent, deleteResp.Code)
+
+		select {
+		case activation := <-notifier.activations:
+			assert.Equal(t, second.ID, activation.ClientID)
+			assert.Equal(t, "second", activation.Name)
+			assert.Equal(t, "10.0.0.2", activation.IPAddress)
+		case <-time.After(time.Second):
+			t.Fatal("expected the pending client to be activated")
+		}
+
+		activated, err := clientAPI.db.GetClient(second.ID)
+		require.NoError(t, err)
+		assert.False(t, activated.Pending)
+		assert.True(t, activated.Enabled)
+		assert.Equal(t, "10.0.0.2", activated.IPAddress)
+		assert.True(t, clientAPI.ipPool.IsAllocated("10.0.0.2"))
+
+		require.Len(t, peers.added, 2)
+		assert.Equal(t, second.PublicKey, peers.added[1].PublicKey)
+		assert.Equal(t, []string{"10.0.0.2/32"}, peers.added[1].AllowedIPs)
+	})
+
+	t.Run("should queue several pending clients", func(t *testing.T) {
+		clientAPI, router, cleanup := setup(t, PoolExhaustionQueue)
+		defer cleanup()
+
+		require.Equal(t, http.StatusCreated, create(t, router, "first").Code)
+		require.Equal(t, http.StatusAccepted, create(t, router, "second").Code)
+		require.Equal(t, http.StatusAccepted, create(t, router, "third").Code)
+
+		pending, err := clientAPI.db.ListPendingClients()
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		assert.Equal(t, "second", pending[0].Name)
+		assert.Equal(t, "third", pending[1].Name)
+	})
+}
+
+func TestClientAPI_GetClientsWithStatus(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Create three clients: connected, stale, and never seen
+	keys := make(map[string]string)
+	for _, name := range []string{"connected", "stale", "never"} {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		keys[name] = created.PublicKey
+	}
+
+	recent := time.Now().Add(-30 * time.Second)
+	old := time.Now().Add(-time.Hour)
+	clientAPI.peerSource = &fakePeerSource{peers: []wireguard.PeerStatus{
+		{PublicKey: keys["connected"], Endpoint: "203.0.113.5:51820", LatestHandshake: &recent, BytesReceived: 100, BytesSent: 200},
+		{PublicKey: keys["stale"], LatestHandshake: &old},
+	}}
+
+	getStatuses := func(t *testing.T, url string) map[string]*ClientStatusResponse {
+		req := httptest.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response GetClientsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+
+		statuses := make(map[string]*ClientStatusResponse)
+		for _, client := range response.Clients {
+			statuses[client.Name] = client.Status
+		}
+		return statuses
+	}
+
+	t.Run("should join live status when requested", func(t *testing.T) {
+		statuses := getStatuses(t, "/api/clients?include=status")
+
+		require.NotNil(t, statuses["connected"])
+		assert.Equal(t, ConnectionStateConnected, statuses["connected"].State)
+		assert.Equal(t, "203.0.113.5:51820", statuses["connected"].Endpoint)
+		assert.Equal(t, uint64(100), statuses["connected"].BytesReceived)
+		assert.Equal(t, uint64(200), statuses["connected"].BytesSent)
+
+		require.NotNil(t, statuses["stale"])
+		assert.Equal(t, ConnectionStateStale, statuses["stale"].State)
+
+		require.NotNil(t, statuses["never"])
+		assert.Equal(t, ConnectionStateNever, statuses["never"].State)
+		assert.Nil(t, statuses["never"].LatestHandshake)
+	})
+
+	t.Run("should omit status by default", func(t *testing.T) {
+		statuses := getStatuses(t, "/api/clients")
+		for name, status := range statuses {
+			assert.Nil(t, status, "client %s should not include status", name)
+		}
+	})
+
+	t.Run("should mark status unknown when peer source fails", func(t *testing.T) {
+		clientAPI.peerSource = &fakePeerSource{err: fmt.Errorf("wg unavailable")}
+
+		statuses := getStatuses(t, "/api/clients?include=status")
+		for _, status := range statuses {
+			require.NotNil(t, status)
+			assert.Equal(t, ConnectionStateUnknown, status.State)
+		}
+	})
+}
+
+func TestClientAPI_GetClientStatus(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	ids := make(map[string]uint)
+	keys := make(map[string]string)
+	for _, name := range []string{"online", "offline", "idle", "missing"} {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		ids[name] = created.ID
+		keys[name] = created.PublicKey
+	}
+
+	// "idle" is configured but has never completed a handshake, "missing" is not on the interface
+	runner := system.NewMockRunner()
+	runner.On("wg show wg0 dump", "server-private-key\tserver-public-key\t51820\toff\n"+
+		fmt.Sprintf("%s\t(none)\t203.0.113.5:51820\t10.0.0.2/32\t%d\t1024\t2048\t25\n", keys["online"], time.Now().Add(-30*time.Second).Unix())+
+		fmt.Sprintf("%s\t(none)\t198.51.100.9:40000\t10.0.0.3/32\t%d\t10\t20\toff\n", keys["offline"], time.Now().Add(-time.Hour).Unix())+
+		fmt.Sprintf("%s\t(none)\t(none)\t10.0.0.4/32\t0\t0\t0\toff\n", keys["idle"]), nil)
+	clientAPI.peerSource = wireguard.NewWireGuardServerWithRunner("/tmp", "wg0", runner)
+
+	getStatus := func(t *testing.T, id uint) (int, ClientStatusResponse) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/status", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var status ClientStatusResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+		}
+		return resp.Code, status
+	}
+
+	t.Run("should report a recent handshake as online", func(t *testing.T) {
+		code, status := getStatus(t, ids["online"])
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, status.Online)
+		assert.Equal(t, ConnectionStateConnected, status.State)
+		assert.Equal(t, "203.0.113.5:51820", status.Endpoint)
+		assert.Equal(t, uint64(1024), status.BytesReceived)
+		assert.Equal(t, uint64(2048), status.BytesSent)
+		require.NotNil(t, status.LatestHandshake)
+		assert.WithinDuration(t, time.Now().Add(-30*time.Second), *status.LatestHandshake, 2*time.Second)
+	})
+
+	t.Run("should report an old handshake as offline", func(t *testing.T) {
+		code, status := getStatus(t, ids["offline"])
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, status.Online)
+		assert.Equal(t, ConnectionStateStale, status.State)
+		assert.Equal(t, "198.51.100.9:40000", status.Endpoint)
+		assert.NotNil(t, status.LatestHandshake)
+	})
+
+	t.Run("should report never connected without a handshake", func(t *testing.T) {
+		for _, name := range []string{"idle", "missing"} {
+			code, status := getStatus(t, ids[name])
+			require.Equal(t, http.StatusOK, code)
+			assert.False(t, status.Online, name)
+			assert.Equal(t, ConnectionStateNever, status.State, name)
+			assert.Nil(t, status.LatestHandshake, name)
+		}
+	})
+
+	t.Run("should return 404 for an unknown client", func(t *testing.T) {
+		code, _ := getStatus(t, 999)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("should report unknown when the interface cannot be queried", func(t *testing.T) {
+		runner.On("wg show wg0 dump", "wg: permission denied", fmt.Errorf("exit status 1"))
+
+		code, status := getStatus(t, ids["online"])
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, status.Online)
+		assert.Equal(t, ConnectionStateUnknown, status.State)
+	})
+}
+
+func TestClientAPI_GetClient(t *testing.T) {
+	_, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	t.Run("should return client by ID", func(t *testing.T) {
+		// Create a client first
+		createReq := CreateClientRequest{Name: "test-client"}
+		body, _ := json.Marshal(createReq)
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var createResponse CreateClientResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+		require.NoError(t, err)
+
+		// Get the client
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientResponse
+		err = json.Unmarshal(resp.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, createResponse.ID, response.ID)
+		assert.Equal(t, "test-client", response.Name)
+	})
+
+	t.Run("should return 404 for non-existent client", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clients/999", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should return 400 for invalid ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clients/invalid", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestClientAPI_UpdateClient(t *testing.T) {
+	_, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	t.Run("should update client successfully", func(t *testing.T) {
+		// Create a client first
+		createReq := CreateClientRequest{Name: "original-name"}
+		body, _ := json.Marshal(createReq)
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var createResponse CreateClientResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+		require.NoError(t, err)
+
+		// Update the client
+		enabled := false
+		updateReq := UpdateClientRequest{
+			Name:    "updated-name",
+			Enabled: &enabled,
+		}
+		body, _ = json.Marshal(updateReq)
+		req = httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d", createResponse.ID), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientResponse
+		err = json.Unmarshal(resp.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.Equal(t, "updated-name", response.Name)
+		assert.Equal(t, false, response.Enabled)
+	})
+
+	t.Run("should return 404 for non-existent client", func(t *testing.T) {
+		updateReq := UpdateClientRequest{Name: "test"}
+		body, _ := json.Marshal(updateReq)
+
+		req := httptest.NewRequest("PUT", "/api/clients/999", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestClientAPI_DeleteClient(t *testing.T) {
+	_, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	t.Run("should delete client successfully", func(t *testing.T) {
+		// Create a client first
+		createReq := CreateClientRequest{Name: "to-be-deleted"}
+		body, _ := json.Marshal(createReq)
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var createResponse CreateClientResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+		require.NoError(t, err)
+
+		// Delete the client
+		req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+
+		// Verify client is deleted
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should return 404 for non-existent client", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/clients/999", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestClientAPI_GetClientConfig(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// Create a client first
+	createReq := CreateClientRequest{Name: "config-client"}
+	body, _ := json.Marshal(createReq)
+	req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	var createResponse CreateClientResponse
+	err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+	require.NoError(t, err)
+
+	t.Run("should return 400 before the server endpoint is set", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", createResponse.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject embedded config before the server endpoint is set", func(t *testing.T) {
+		body, _ := json.Marshal(CreateClientRequest{Name: "early-client"})
+		req := httptest.NewRequest("POST", "/api/clients?include=config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Len(t, clients, 1)
+	})
+
+	t.Run("should return client config", func(t *testing.T) {
+		seedServerEndpoint(t, clientAPI)
+
+		// Get client config
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		err = json.Unmarshal(resp.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, response.Config)
+		assert.Contains(t, response.Config, "[Interface]")
+		assert.Contains(t, response.Config, "[Peer]")
+		assert.Contains(t, response.Config, "PrivateKey")
+		assert.Contains(t, response.Config, "Address")
+		assert.Contains(t, response.Config, "Endpoint = vpn.example.com:51820")
+	})
+
+	t.Run("should return 404 for non-existent client", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clients/999/config", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestClientAPI_GetClientConfigAlternateEndpoint(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	serverConfig, err := clientAPI.db.GetServerConfig()
+	require.NoError(t, err)
+	serverConfig.AlternateEndpoints = "vpn2.example.com,203.0.113.20"
+	require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+	body, _ := json.Marshal(CreateClientRequest{Name: "failover-client"})
+	req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	var createResponse CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &createResponse))
+
+	getConfig := func(t *testing.T, query string) (int, ClientConfigResponse) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config%s", createResponse.ID, query), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var response ClientConfigResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		}
+		return resp.Code, response
+	}
+
+	t.Run("should use the primary endpoint by default", func(t *testing.T) {
+		code, response := getConfig(t, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "vpn.example.com:51820", response.Endpoint)
+		assert.Contains(t, response.Config, "Endpoint = vpn.example.com:51820")
+	})
+
+	t.Run("should use a selected alternate endpoint", func(t *testing.T) {
+		code, response := getConfig(t, "?endpoint=vpn2.example.com")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "vpn2.example.com:51820", response.Endpoint)
+		assert.Contains(t, response.Config, "Endpoint = vpn2.example.com:51820")
+		assert.NotContains(t, response.Config, "vpn.example.com")
+
+		code, response = getConfig(t, "?endpoint=203.0.113.20")
+		require.Equal(t, http.StatusOK, code)
+		assert.Contains(t, response.Config, "Endpoint = 203.0.113.20:51820")
+	})
+
+	t.Run("should reject endpoints that are not configured", func(t *testing.T) {
+		code, _ := getConfig(t, "?endpoint=evil.example.com")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode?endpoint=evil.example.com", createResponse.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should embed config for a selected endpoint on create", func(t *testing.T) {
+		body, _ := json.Marshal(CreateClientRequest{Name: "failover-embedded"})
+		req := httptest.NewRequest("POST", "/api/clients?include=config&endpoint=vpn2.example.com", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Contains(t, response.Config, "Endpoint = vpn2.example.com:51820")
+	})
+
+	t.Run("should not create client for an unknown embedded endpoint", func(t *testing.T) {
+		body, _ := json.Marshal(CreateClientRequest{Name: "failover-rejected"})
+		req := httptest.NewRequest("POST", "/api/clients?include=config&endpoint=evil.example.com", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		count, err := clientAPI.db.CountClients(database.ClientFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func TestClientAPI_GetClientConfigDownload(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	download := func(t *testing.T, name string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(CreateClientRequest{Name: name})
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var createResponse CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &createResponse))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config?download=true", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should return raw config as attachment", func(t *testing.T) {
+		resp := download(t, "work-laptop")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, "text/plain; charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="work-laptop.conf"`, resp.Header().Get("Content-Disposition"))
+		assert.True(t, strings.HasPrefix(resp.Body.String(), "[Interface]"))
+		assert.Contains(t, resp.Body.String(), "Endpoint = vpn.example.com:51820")
+	})
+
+	t.Run("should sanitize the client name", func(t *testing.T) {
+		resp := download(t, `../Alice's "phone"`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `attachment; filename="Alice_s_phone.conf"`, resp.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("should fall back to the client ID", func(t *testing.T) {
+		resp := download(t, "日本")
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Regexp(t, `^attachment; filename="client-\d+\.conf"$`, resp.Header().Get("Content-Disposition"))
+	})
+}
+
+func TestClientAPI_ExportClientConfigs(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	serverConfig := seedServerEndpoint(t, clientAPI)
+
+	seeds := []struct {
+		name    string
+		enabled bool
+	}{
+		{"laptop", true},
+		{"phone", true},
+		{"laptop!", true}, // sanitizes to the same file name as "laptop"
+		{"retired", false},
+	}
+	var ids []uint
+	var privateKeys []string
+	for i, seed := range seeds {
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		client := &database.Client{
+			Name:       seed.name,
+			PublicKey:  keyPair.PublicKey,
+			PrivateKey: keyPair.PrivateKey,
+			IPAddress:  fmt.Sprintf("10.0.0.%d", i+2),
+		}
+		require.NoError(t, clientAPI.db.CreateClient(client))
+		client.Enabled = seed.enabled
+		require.NoError(t, clientAPI.db.UpdateClient(client))
+		ids = append(ids, client.ID)
+		privateKeys = append(privateKeys, keyPair.PrivateKey)
+	}
+
+	t.Run("should zip one config per enabled client", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/clients/export", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "attachment")
+
+		archive, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+		require.NoError(t, err)
+
+		contents := make(map[string]string)
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			reader.Close()
+			require.NoError(t, err)
+			contents[file.Name] = string(content)
+		}
+
+		duplicate := fmt.Sprintf("laptop-%d.conf", ids[2])
+		assert.Len(t, contents, 3)
+		require.Contains(t, contents, "laptop.conf")
+		require.Contains(t, contents, "phone.conf")
+		require.Contains(t, contents, duplicate)
+
+		assert.True(t, strings.HasPrefix(contents["laptop.conf"], "[Interface]"))
+		assert.Contains(t, contents["laptop.conf"], "PrivateKey = "+privateKeys[0])
+		assert.Contains(t, contents[duplicate], "PrivateKey = "+privateKeys[2])
+		for _, content := range contents {
+			assert.Contains(t, content, "PublicKey = "+serverConfig.PublicKey)
+			assert.Contains(t, content, "Endpoint = vpn.example.com:51820")
+		}
+	})
+}
+
+func TestClientAPI_GetClientConfigKeepalive(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	fetchConfig := func(t *testing.T, createReq CreateClientRequest) string {
+		body, _ := json.Marshal(createReq)
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var createResponse CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &createResponse))
+
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", createResponse.ID), nil)
+		resp = httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Config
+	}
+
+	t.Run("should include keepalive for mobile-tagged client", func(t *testing.T) {
+		config := fetchConfig(t, CreateClientRequest{Name: "phone", Tags: []string{"mobile"}})
+		assert.Contains(t, config, "PersistentKeepalive = 25")
+	})
+
+	t.Run("should omit keepalive for untagged client", func(t *testing.T) {
+		config := fetchConfig(t, CreateClientRequest{Name: "laptop"})
+		assert.NotContains(t, config, "PersistentKeepalive")
+	})
+
+	t.Run("should prefer explicit client keepalive over tag default", func(t *testing.T) {
+		keepalive := 15
+		config := fetchConfig(t, CreateClientRequest{Name: "tablet", Tags: []string{"mobile"}, PersistentKeepalive: &keepalive})
+		assert.Contains(t, config, "PersistentKeepalive = 15")
+		assert.NotContains(t, config, "PersistentKeepalive = 25")
+	})
+}
+
+func TestClientAPI_ServerDefaultKeepalive(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+	peers := &fakePeerManager{}
+	clientAPI.peers = peers
+
+	serverConfig, err := clientAPI.db.GetServerConfig()
+	require.NoError(t, err)
+	serverConfig.DefaultKeepalive = 30
+	require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+	create := func(t *testing.T, createReq CreateClientRequest) (uint, *wireguard.Peer) {
+		body, _ := json.Marshal(createReq)
 		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
-		require.Equal(t, http.StatusCreated, resp.Code)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		require.NotEmpty(t, peers.added)
+		return response.ID, peers.added[len(peers.added)-1]
+	}
+	fetchConfig := func(t *testing.T, id uint) string {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Config
+	}
+
+	t.Run("should apply the server default to the config and peer", func(t *testing.T) {
+		id, peer := create(t, CreateClientRequest{Name: "laptop"})
+
+		assert.Contains(t, fetchConfig(t, id), "PersistentKeepalive = 30")
+		assert.Equal(t, 30, peer.PersistentKA)
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("should prefer the client keepalive over the server default", func(t *testing.T) {
+		keepalive := 10
+		id, peer := create(t, CreateClientRequest{Name: "desktop", PersistentKeepalive: &keepalive})
+
+		config := fetchConfig(t, id)
+		assert.Contains(t, config, "PersistentKeepalive = 10")
+		assert.NotContains(t, config, "PersistentKeepalive = 30")
+		assert.Equal(t, 10, peer.PersistentKA)
+	})
+
+	t.Run("should encode the keepalive in the QR code", func(t *testing.T) {
+		id, _ := create(t, CreateClientRequest{Name: "tablet"})
+		config := fetchConfig(t, id)
+		require.Contains(t, config, "PersistentKeepalive = 30\n")
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientQRCodeResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		expected, err := utils.GenerateWireGuardConfigQR(config, utils.QRCodeOptions{
+			Size:          256,
+			RecoveryLevel: utils.GetDefaultQRCodeOptions().RecoveryLevel,
+			Format:        "base64",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expected, response.QRCode)
+	})
+
+	t.Run("should prefer tag defaults over the server default in configs", func(t *testing.T) {
+		id, _ := create(t, CreateClientRequest{Name: "phone", Tags: []string{"mobile"}})
+		assert.Contains(t, fetchConfig(t, id), "PersistentKeepalive = 25")
+	})
+
+	t.Run("should build peers with the client keepalive or the server default", func(t *testing.T) {
+		keepalive := 10
+		peer := NewClientPeer(&database.Client{PublicKey: "peer-key", IPAddress: "10.0.0.9"}, 30)
+		assert.Equal(t, 30, peer.PersistentKA)
+		peer = NewClientPeer(&database.Client{PublicKey: "peer-key", IPAddress: "10.0.0.9", PersistentKeepalive: &keepalive}, 30)
+		assert.Equal(t, 10, peer.PersistentKA)
+		assert.Equal(t, []string{"10.0.0.9/32"}, peer.AllowedIPs)
+	})
+}
+
+func TestClientAPI_GetClientConfigDNS(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	client := newKeyedClient(t, "laptop", "10.0.0.2")
+	require.NoError(t, clientAPI.db.CreateClient(client))
+
+	fetchConfig := func(t *testing.T) string {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", client.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Config
+	}
+
+	t.Run("should default to public resolvers", func(t *testing.T) {
+		assert.Contains(t, fetchConfig(t), "DNS = 8.8.8.8, 8.8.4.4")
+	})
+
+	t.Run("should use the server VPN IP when enabled", func(t *testing.T) {
+		clientAPI.config.DNSViaServer = true
+		defer func() { clientAPI.config.DNSViaServer = false }()
+
+		config := fetchConfig(t)
+		assert.Contains(t, config, "DNS = 10.0.0.1\n")
+		assert.NotContains(t, config, "8.8.8.8")
+	})
+
+	t.Run("should prefer explicit server DNS when enabled", func(t *testing.T) {
+		clientAPI.config.DNSViaServer = true
+		defer func() { clientAPI.config.DNSViaServer = false }()
+
+		serverConfig, err := clientAPI.db.GetServerConfig()
+		require.NoError(t, err)
+		serverConfig.DNS = "1.1.1.1"
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+		assert.Contains(t, fetchConfig(t), "DNS = 1.1.1.1\n")
+	})
+
+	t.Run("should prefer the server default DNS over the server DNS", func(t *testing.T) {
+		serverConfig, err := clientAPI.db.GetServerConfig()
+		require.NoError(t, err)
+		serverConfig.DefaultDNS = "192.168.1.53,192.168.1.54"
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+		config := fetchConfig(t)
+		assert.Contains(t, config, "DNS = 192.168.1.53, 192.168.1.54\n")
+		assert.NotContains(t, config, "1.1.1.1")
+	})
+
+	t.Run("should prefer client DNS over the server DNS", func(t *testing.T) {
+		client.DNS = "10.0.0.53"
+		require.NoError(t, clientAPI.db.UpdateClient(client))
+
+		config := fetchConfig(t)
+		assert.Contains(t, config, "DNS = 10.0.0.53\n")
+		assert.NotContains(t, config, "1.1.1.1")
+		assert.NotContains(t, config, "192.168.1.53")
+	})
+}
+
+func TestClientAPI_ClientRouteOverrides(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	send := func(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	create := func(t *testing.T, req CreateClientRequest) uint {
+		resp := send(t, "POST", "/api/clients", req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var response CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.ID
+	}
+	fetchConfig := func(t *testing.T, id uint) string {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", id), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Config
+	}
+
+	t.Run("should route only overridden networks for split-tunnel client", func(t *testing.T) {
+		id := create(t, CreateClientRequest{
+			Name:       "split",
+			AllowedIPs: []string{"10.0.0.0/24", " 192.168.1.5/24", "172.16.0.10"},
+			DNS:        []string{"10.0.0.1"},
+		})
+
+		config := fetchConfig(t, id)
+		assert.Contains(t, config, "AllowedIPs = 10.0.0.0/24, 192.168.1.0/24, 172.16.0.10/32\n")
+		assert.Contains(t, config, "DNS = 10.0.0.1\n")
+		assert.NotContains(t, config, "0.0.0.0/0")
+
+		client, err := clientAPI.db.GetClient(id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/24", "192.168.1.0/24", "172.16.0.10/32"}, newClientResponse(client).AllowedIPs)
+	})
+
+	t.Run("should default to full tunnel without overrides", func(t *testing.T) {
+		id := create(t, CreateClientRequest{Name: "full"})
+
+		config := fetchConfig(t, id)
+		assert.Contains(t, config, "AllowedIPs = 0.0.0.0/0\n")
+		assert.Contains(t, config, "DNS = 8.8.8.8, 8.8.4.4")
+	})
+
+	t.Run("should replace and clear overrides on update", func(t *testing.T) {
+		id := create(t, CreateClientRequest{Name: "updated", AllowedIPs: []string{"10.0.0.0/24"}})
+
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", id), UpdateClientRequest{DNS: []string{"1.1.1.1"}})
+		require.Equal(t, http.StatusOK, resp.Code)
+		config := fetchConfig(t, id)
+		assert.Contains(t, config, "AllowedIPs = 10.0.0.0/24\n")
+		assert.Contains(t, config, "DNS = 1.1.1.1\n")
+
+		resp = send(t, "PUT", fmt.Sprintf("/api/clients/%d", id), UpdateClientRequest{AllowedIPs: []string{}})
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, fetchConfig(t, id), "AllowedIPs = 0.0.0.0/0\n")
+	})
+
+	t.Run("should reject invalid entries", func(t *testing.T) {
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{Name: "bad", AllowedIPs: []string{"10.0.0.0/33"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "must be a CIDR or IP address")
+
+		resp = send(t, "POST", "/api/clients", CreateClientRequest{Name: "bad", DNS: []string{"resolver.local"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		id := create(t, CreateClientRequest{Name: "kept"})
+		resp = send(t, "PUT", fmt.Sprintf("/api/clients/%d", id), UpdateClientRequest{AllowedIPs: []string{"not-a-route"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestClientAPI_PresharedKey(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	serverConfig := seedServerEndpoint(t, clientAPI)
+	peers := &fakePeerManager{}
+	clientAPI.peers = peers
+
+	body, _ := json.Marshal(CreateClientRequest{Name: "hardened"})
+	req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	var created CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	client, err := clientAPI.db.GetClient(created.ID)
+	require.NoError(t, err)
+
+	t.Run("should generate a preshared key at creation", func(t *testing.T) {
+		assert.NotEmpty(t, client.PresharedKey)
+		require.Len(t, peers.added, 1)
+		assert.Equal(t, client.PresharedKey, peers.added[0].PresharedKey)
+	})
+
+	t.Run("should include the same key in client and server configs", func(t *testing.T) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", client.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Contains(t, response.Config, "PublicKey = "+serverConfig.PublicKey+"\nPresharedKey = "+client.PresharedKey+"\n")
+
+		serverAPI := NewServerAPI(clientAPI.db, clientAPI.ipPool, nil)
+		serverAPI.detectExternalInterface = func() (string, error) { return "eth0", nil }
+		serverConfig, err := clientAPI.db.GetServerConfig()
+		require.NoError(t, err)
+		fullConfig := serverAPI.renderFullConfig(serverConfig, []database.Client{*client})
+		assert.Contains(t, fullConfig, "PublicKey = "+client.PublicKey+"\nPresharedKey = "+client.PresharedKey+"\n")
+	})
+
+	t.Run("should omit the key for clients created without one", func(t *testing.T) {
+		legacy := newKeyedClient(t, "legacy", "10.0.0.9")
+		require.NoError(t, clientAPI.db.CreateClient(legacy))
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", legacy.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "PresharedKey")
+	})
+}
+
+func TestClientAPI_GetClientConfigMTU(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	client := newKeyedClient(t, "phone", "10.0.0.2")
+	require.NoError(t, clientAPI.db.CreateClient(client))
+
+	fetchConfig := func(t *testing.T) string {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", client.ID), nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response.Config
+	}
+
+	t.Run("should omit MTU by default", func(t *testing.T) {
+		assert.NotContains(t, fetchConfig(t), "MTU")
+	})
+
+	t.Run("should use the server MTU", func(t *testing.T) {
+		serverConfig, err := clientAPI.db.GetServerConfig()
+		require.NoError(t, err)
+		serverConfig.MTU = 1380
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+		assert.Contains(t, fetchConfig(t), "MTU = 1380\n")
+	})
+}
+
+func TestClientAPI_ClientExpiry(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+	clientAPI.peers = &fakePeerManager{}
+
+	send := func(t *testing.T, method, url string, payload interface{}) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			require.NoError(t, err)
+			body = bytes.NewBuffer(data)
+		}
+		req := httptest.NewRequest(method, url, body)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	getClient := func(t *testing.T, id uint) ClientResponse {
+		resp := send(t, "GET", fmt.Sprintf("/api/clients/%d", id), nil)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		return client
+	}
+
+	// createExpiredClient stores a client whose access ended an hour ago but
+	// that the monitor has not disabled yet
+	createExpiredClient := func(t *testing.T, name, ip string) *database.Client {
+		expiresAt := time.Now().Add(-time.Hour)
+		client := newKeyedClient(t, name, ip)
+		client.ExpiresAt = &expiresAt
+		require.NoError(t, clientAPI.db.CreateClient(client))
+		return client
+	}
+
+	t.Run("should create client with expiry date", func(t *testing.T) {
+		expiresAt := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Second)
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{Name: "contractor", ExpiresAt: &expiresAt})
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		client := getClient(t, created.ID)
+		require.NotNil(t, client.ExpiresAt)
+		assert.True(t, expiresAt.Equal(*client.ExpiresAt))
+		assert.False(t, client.Expired)
+	})
+
+	t.Run("should reject expiry date in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{Name: "late", ExpiresAt: &expiresAt})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "expires_at must be in the future")
+	})
+
+	t.Run("should report expired clients", func(t *testing.T) {
+		client := createExpiredClient(t, "report", "10.0.0.50")
+		assert.True(t, getClient(t, client.ID).Expired)
+	})
+
+	t.Run("should refuse config and QR code of expired client", func(t *testing.T) {
+		client := createExpiredClient(t, "refused", "10.0.0.51")
+
+		resp := send(t, "GET", fmt.Sprintf("/api/clients/%d/config", client.ID), nil)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "client access has expired")
+
+		resp = send(t, "GET", fmt.Sprintf("/api/clients/%d/qrcode", client.ID), nil)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "client access has expired")
+	})
+
+	t.Run("should refuse to re-enable expired client until expiry is extended", func(t *testing.T) {
+		client := createExpiredClient(t, "renewed", "10.0.0.52")
+		require.NoError(t, clientAPI.db.Model(client).Update("enabled", false).Error)
+
+		enabled := true
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", client.ID), UpdateClientRequest{Enabled: &enabled})
+		assert.Equal(t, http.StatusConflict, resp.Code)
+
+		expiresAt := time.Now().Add(24 * time.Hour)
+		resp = send(t, "PUT", fmt.Sprintf("/api/clients/%d", client.ID), UpdateClientRequest{Enabled: &enabled, ExpiresAt: &expiresAt})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		updated := getClient(t, client.ID)
+		assert.True(t, updated.Enabled)
+		assert.False(t, updated.Expired)
+
+		resp = send(t, "GET", fmt.Sprintf("/api/clients/%d/config", client.ID), nil)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("should remove expiry date with zero time", func(t *testing.T) {
+		client := createExpiredClient(t, "permanent", "10.0.0.53")
+
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", client.ID), UpdateClientRequest{ExpiresAt: &time.Time{}})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		updated := getClient(t, client.ID)
+		assert.Nil(t, updated.ExpiresAt)
+		assert.False(t, updated.Expired)
+	})
+}
+
+func TestClientAPI_ClientDataQuota(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	peers := &fakePeerManager{}
+	clientAPI.peers = peers
+	alertManager := monitoring.NewAlertManager()
+	clientAPI.SetAlertManager(alertManager)
+
+	send := func(t *testing.T, method, url string, payload interface{}) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		if payload != nil {
+			data, err := json.Marshal(payload)
+			require.NoError(t, err)
+			body = bytes.NewBuffer(data)
+		}
+		req := httptest.NewRequest(method, url, body)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	decodeClient := func(t *testing.T, resp *httptest.ResponseRecorder) ClientResponse {
+		require.Equal(t, http.StatusOK, resp.Code)
+		var client ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &client))
+		return client
+	}
+
+	// createMeteredClient stores a client that transferred 1500 of its 1000 byte quota
+	createMeteredClient := func(t *testing.T, name, ip string, exceeded bool) *database.Client {
+		client := &database.Client{
+			Name:              name,
+			PublicKey:         name + "-public-key",
+			PrivateKey:        name + "-private-key",
+			IPAddress:         ip,
+			Enabled:           true,
+			BytesReceived:     1000,
+			BytesSent:         500,
+			DataQuotaBytes:    1000,
+			DataQuotaExceeded: exceeded,
+		}
+		require.NoError(t, clientAPI.db.CreateClient(client))
+		if exceeded {
+			require.NoError(t, clientAPI.db.Model(client).Update("enabled", false).Error)
+		}
+		return client
+	}
+
+	t.Run("should create client with quota and report remaining bytes", func(t *testing.T) {
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{Name: "metered", DataQuotaBytes: 5000})
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		client := decodeClient(t, send(t, "GET", fmt.Sprintf("/api/clients/%d", created.ID), nil))
+		assert.Equal(t, uint64(5000), client.DataQuotaBytes)
+		require.NotNil(t, client.DataQuotaRemainingBytes)
+		assert.Equal(t, uint64(5000), *client.DataQuotaRemainingBytes)
+	})
+
+	t.Run("should omit remaining bytes without a quota", func(t *testing.T) {
+		resp := send(t, "POST", "/api/clients", CreateClientRequest{Name: "unmetered"})
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		resp = send(t, "GET", fmt.Sprintf("/api/clients/%d", created.ID), nil)
+		assert.NotContains(t, resp.Body.String(), "data_quota_remaining_bytes")
+	})
+
+	t.Run("should refuse to re-enable client that used up its quota", func(t *testing.T) {
+		client := createMeteredClient(t, "blocked", "10.0.0.60", true)
+
+		enabled := true
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", client.ID), UpdateClientRequest{Enabled: &enabled})
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "reset the quota")
+	})
+
+	t.Run("should refuse to re-enable client that exceeded its data cap", func(t *testing.T) {
+		client := createMeteredClient(t, "capped", "10.0.0.63", false)
+		require.NoError(t, clientAPI.db.Model(client).Updates(map[string]interface{}{"enabled": false, "data_cap_exceeded": true}).Error)
+
+		enabled := true
+		resp := send(t, "PUT", fmt.Sprintf("/api/clients/%d", client.ID), UpdateClientRequest{Enabled: &enabled})
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "data cap")
+
+		stored, err := clientAPI.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Enabled)
+	})
+
+	t.Run("should reset quota and restore client", func(t *testing.T) {
+		client := createMeteredClient(t, "restored", "10.0.0.61", true)
+		alertManager.RaiseAlert(monitoring.DataQuotaAlertID(client.ID), monitoring.AlertTypeConnection,
+			monitoring.SeverityHigh, "Client Data Quota Exceeded", "quota used up", nil)
+
+		updated := decodeClient(t, send(t, "POST", fmt.Sprintf("/api/clients/%d/quota/reset", client.ID), nil))
+		assert.True(t, updated.Enabled)
+		assert.False(t, updated.DataQuotaExceeded)
+		require.NotNil(t, updated.DataQuotaRemainingBytes)
+		assert.Equal(t, uint64(1000), *updated.DataQuotaRemainingBytes)
+
+		stored, err := clientAPI.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1500), stored.DataQuotaBaselineBytes)
+
+		require.NotEmpty(t, peers.added)
+		assert.Equal(t, client.PublicKey, peers.added[len(peers.added)-1].PublicKey)
+		assert.Empty(t, alertManager.GetActiveAlerts())
+	})
+
+	t.Run("should keep expired client disabled after quota reset", func(t *testing.T) {
+		client := createMeteredClient(t, "expired", "10.0.0.62", true)
+		expiresAt := time.Now().Add(-time.Hour)
+		client.ExpiresAt = &expiresAt
+		client.Enabled = false
+		require.NoError(t, clientAPI.db.UpdateClient(client))
+
+		updated := decodeClient(t, send(t, "POST", fmt.Sprintf("/api/clients/%d/quota/reset", client.ID), nil))
+		assert.False(t, updated.Enabled)
+		assert.False(t, updated.DataQuotaExceeded)
+	})
+
+	t.Run("should return 404 for unknown client", func(t *testing.T) {
+		resp := send(t, "POST", "/api/clients/9999/quota/reset", nil)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestClientAPI_PreviewClientConfig(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
+
+	preview := func(t *testing.T, previewReq PreviewClientConfigRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(previewReq)
+		req := httptest.NewRequest("POST", "/api/clients/preview-config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
 
-		var createResponse CreateClientResponse
-		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+	t.Run("should reflect proposed DNS, AllowedIPs and keepalive", func(t *testing.T) {
+		keepalive := 30
+		resp := preview(t, PreviewClientConfigRequest{
+			DNS:                 []string{"10.0.0.1", "1.1.1.1"},
+			AllowedIPs:          []string{"10.0.0.0/24", "192.168.1.0/24"},
+			PersistentKeepalive: &keepalive,
+		})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response PreviewClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "10.0.0.2", response.IPAddress)
+		assert.Equal(t, "vpn.example.com:51820", response.Endpoint)
+		assert.Contains(t, response.Config, "Address = 10.0.0.2/32")
+		assert.Contains(t, response.Config, "DNS = 10.0.0.1, 1.1.1.1")
+		assert.Contains(t, response.Config, "AllowedIPs = 10.0.0.0/24, 192.168.1.0/24")
+		assert.Contains(t, response.Config, "PersistentKeepalive = 30")
+	})
+
+	t.Run("should fall back to server defaults", func(t *testing.T) {
+		resp := preview(t, PreviewClientConfigRequest{Tags: []string{"mobile"}})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response PreviewClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Contains(t, response.Config, "DNS = 8.8.8.8, 8.8.4.4")
+		assert.Contains(t, response.Config, "AllowedIPs = 0.0.0.0/0")
+		assert.Contains(t, response.Config, "PersistentKeepalive = 25")
+	})
+
+	t.Run("should not persist a client or allocate an IP", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, preview(t, PreviewClientConfigRequest{}).Code)
+
+		clients, err := clientAPI.db.ListClients()
 		require.NoError(t, err)
+		assert.Empty(t, clients)
+		assert.False(t, clientAPI.ipPool.IsAllocated("10.0.0.2"))
+		assert.Empty(t, clientAPI.ipPool.GetAllocatedIPs())
+	})
 
-		// Delete the client
-		req = httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
-		resp = httptest.NewRecorder()
+	t.Run("should reject invalid DNS and AllowedIPs", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, preview(t, PreviewClientConfigRequest{DNS: []string{"resolver"}}).Code)
+		assert.Equal(t, http.StatusBadRequest, preview(t, PreviewClientConfigRequest{AllowedIPs: []string{"10.0.0.1"}}).Code)
+	})
+}
+
+func TestClientAPI_BatchCreateClients(t *testing.T) {
+	post := func(t *testing.T, router *gin.Engine, body string) (*httptest.ResponseRecorder, BatchCreateClientsResponse) {
+		req := httptest.NewRequest("POST", "/api/clients/batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
 
-		assert.Equal(t, http.StatusNoContent, resp.Code)
+		var response BatchCreateClientsResponse
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		}
+		return resp, response
+	}
 
-		// Verify client is deleted
-		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d", createResponse.ID), nil)
-		resp = httptest.NewRecorder()
-		router.ServeHTTP(resp, req)
-		assert.Equal(t, http.StatusNotFound, resp.Code)
+	t.Run("should create all clients and sync the interface once", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		peers := &fakePeerManager{running: true}
+		clientAPI.peers = peers
+
+		resp, response := post(t, router, `{"names":["alice","bob","carol"]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, response.Failed)
+		require.Len(t, response.Created, 3)
+
+		ips := map[string]bool{}
+		for i, name := range []string{"alice", "bob", "carol"} {
+			created := response.Created[i]
+			assert.Equal(t, name, created.Name)
+			assert.NotZero(t, created.ID)
+			assert.NotEmpty(t, created.PublicKey)
+			assert.True(t, clientAPI.ipPool.IsAllocated(created.IPAddress))
+			ips[created.IPAddress] = true
+		}
+		assert.Len(t, ips, 3)
+		assert.Len(t, peers.added, 3)
+		assert.Equal(t, 1, peers.synced)
+
+		count, err := clientAPI.db.CountClients(database.ClientFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), count)
 	})
 
-	t.Run("should return 404 for non-existent client", func(t *testing.T) {
-		req := httptest.NewRequest("DELETE", "/api/clients/999", nil)
-		resp := httptest.NewRecorder()
+	t.Run("should release the IP of a client whose insert fails", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		peers := &fakePeerManager{}
+		clientAPI.peers = peers
+
+		require.NoError(t, clientAPI.db.Callback().Create().Before("gorm:create").Register("test:fail_insert", func(tx *gorm.DB) {
+			if client, ok := tx.Statement.Dest.(*database.Client); ok && client.Name == "broken" {
+				tx.AddError(fmt.Errorf("disk I/O error"))
+			}
+		}))
+
+		resp, response := post(t, router, `{"names":["alice","broken","carol"]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Len(t, response.Created, 2)
+		assert.Equal(t, "alice", response.Created[0].Name)
+		assert.Equal(t, "carol", response.Created[1].Name)
+		require.Len(t, response.Failed, 1)
+		assert.Equal(t, "broken", response.Failed[0].Name)
+		assert.Equal(t, BulkErrorInternal, response.Failed[0].Code)
+
+		// Only the stored clients hold an address
+		assert.Len(t, clientAPI.ipPool.GetAllocatedIPs(), 2)
+		assert.Len(t, peers.added, 2)
+	})
 
+	t.Run("should fail the remaining names once the pool is exhausted", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.peers = &fakePeerManager{}
+
+		// Leave room for two clients
+		pool, err := network.NewIPPool("10.0.0.0/29")
+		require.NoError(t, err)
+		for _, ip := range []string{"10.0.0.4", "10.0.0.5", "10.0.0.6"} {
+			require.NoError(t, pool.AllocateSpecificIP(ip))
+		}
+		clientAPI.ipPool = pool
+
+		resp, response := post(t, router, `{"names":["one","two","three","four"]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Len(t, response.Created, 2)
+		assert.Equal(t, "one", response.Created[0].Name)
+		assert.Equal(t, "two", response.Created[1].Name)
+		require.Len(t, response.Failed, 2)
+		for i, name := range []string{"three", "four"} {
+			assert.Equal(t, name, response.Failed[i].Name)
+			assert.Equal(t, BulkErrorConflict, response.Failed[i].Code)
+		}
+
+		count, err := clientAPI.db.CountClients(database.ClientFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("should roll back clients whose peer fails with the strict policy", func(t *testing.T) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+		clientAPI.peers = &fakePeerManager{running: true, err: fmt.Errorf("wg set failed")}
+		clientAPI.config.PeerFailurePolicy = PeerFailureStrict
+
+		resp, response := post(t, router, `{"names":["alice","bob"]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, response.Created)
+		require.Len(t, response.Failed, 2)
+		assert.Contains(t, response.Failed[0].Message, "wg set failed")
+		assert.Empty(t, clientAPI.ipPool.GetAllocatedIPs())
+	})
+
+	t.Run("should reject empty and oversized batches", func(t *testing.T) {
+		_, router, cleanup := setupTestAPI(t)
+		defer cleanup()
+
+		names := make([]string, 101)
+		for i := range names {
+			names[i] = fmt.Sprintf("client-%d", i)
+		}
+		oversized, err := json.Marshal(BatchCreateClientsRequest{Names: names})
+		require.NoError(t, err)
+
+		for _, body := range []string{`{"names":[]}`, `{"names":["ok",""]}`, string(oversized)} {
+			resp, _ := post(t, router, body)
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		}
+	})
+}
+
+func TestClientAPI_BulkOperations(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	active := &database.Client{Name: "active", PublicKey: "active-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}
+	require.NoError(t, clientAPI.db.CreateClient(active))
+	queued := &database.Client{Name: "queued", PublicKey: "queued-key", PrivateKey: "private", Pending: true}
+	require.NoError(t, clientAPI.db.CreateClient(queued))
+	queued.Enabled = false
+	require.NoError(t, clientAPI.db.UpdateClient(queued))
+
+	post := func(t *testing.T, path, body string) (*httptest.ResponseRecorder, BulkResult) {
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
 
-		assert.Equal(t, http.StatusNotFound, resp.Code)
+		var result BulkResult
+		if resp.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		}
+		return resp, result
+	}
+
+	t.Run("should report partial success of bulk toggle", func(t *testing.T) {
+		resp, result := post(t, "/api/clients/bulk-toggle",
+			fmt.Sprintf(`{"ids":[%d,%d,999],"enabled":true}`, active.ID, queued.ID))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, []uint{active.ID}, result.Succeeded)
+		assert.Equal(t, []BulkItemError{
+			{ID: queued.ID, Code: BulkErrorConflict, Message: "client is waiting for an IP address"},
+			{ID: 999, Code: BulkErrorNotFound, Message: "Client not found"},
+		}, result.Failed)
+	})
+
+	t.Run("should report partial success of bulk delete", func(t *testing.T) {
+		resp, result := post(t, "/api/clients/bulk-delete", fmt.Sprintf(`{"ids":[%d,999]}`, active.ID))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, []uint{active.ID}, result.Succeeded)
+		require.Len(t, result.Failed, 1)
+		assert.Equal(t, uint(999), result.Failed[0].ID)
+		assert.Equal(t, BulkErrorNotFound, result.Failed[0].Code)
+
+		_, err := clientAPI.db.GetClient(active.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("should encode empty failure list on full success", func(t *testing.T) {
+		resp, _ := post(t, "/api/clients/bulk-delete", fmt.Sprintf(`{"ids":[%d]}`, queued.ID))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"failed":[]`)
+	})
+
+	t.Run("should reject empty batch", func(t *testing.T) {
+		resp, _ := post(t, "/api/clients/bulk-delete", `{"ids":[]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp, _ = post(t, "/api/clients/bulk-toggle", `{"ids":[1]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
 	})
 }
 
-func TestClientAPI_GetClientConfig(t *testing.T) {
-	_, router, cleanup := setupTestAPI(t)
+// fakeRateLimitShaper records the rate limits applied to the firewall.
+type fakeRateLimitShaper struct {
+	applied []*system.VPNConfig
+	err     error
+}
+
+func (f *fakeRateLimitShaper) ApplyRateLimits(config *system.VPNConfig) error {
+	f.applied = append(f.applied, config)
+	return f.err
+}
+
+func TestClientAPI_SetClientRateLimit(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
 	defer cleanup()
+	clientAPI.rateLimitSupport = func() error { return nil }
+	shaper := &fakeRateLimitShaper{}
+	clientAPI.SetRateLimitShaper(shaper)
 
-	t.Run("should return client config", func(t *testing.T) {
-		// Create a client first
-		createReq := CreateClientRequest{Name: "config-client"}
-		body, _ := json.Marshal(createReq)
-		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBuffer(body))
+	client := &database.Client{Name: "laptop", PublicKey: "laptop-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}
+	require.NoError(t, clientAPI.db.CreateClient(client))
+
+	setRateLimit := func(t *testing.T, id uint, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/clients/%d/ratelimit", id), bytes.NewBufferString(body))
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
-		require.Equal(t, http.StatusCreated, resp.Code)
+		return resp
+	}
 
-		var createResponse CreateClientResponse
-		err := json.Unmarshal(resp.Body.Bytes(), &createResponse)
+	t.Run("should store the rate limit", func(t *testing.T) {
+		resp := setRateLimit(t, client.ID, `{"rate_limit_mbps": 20}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 20, response.RateLimitMbps)
+
+		limits, err := clientAPI.ClientRateLimits()
 		require.NoError(t, err)
+		assert.Equal(t, []system.ClientRateLimit{{IPAddress: "10.0.0.2", Mbps: 20}}, limits)
+	})
 
-		// Get client config
-		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/config", createResponse.ID), nil)
-		resp = httptest.NewRecorder()
+	t.Run("should apply the limits to the firewall", func(t *testing.T) {
+		shaper.applied = nil
+		require.Equal(t, http.StatusOK, setRateLimit(t, client.ID, `{"rate_limit_mbps": 30}`).Code)
+
+		require.Len(t, shaper.applied, 1)
+		assert.Equal(t, "10.0.0.0/24", shaper.applied[0].VPNNetwork)
+		assert.Equal(t, []system.ClientRateLimit{{IPAddress: "10.0.0.2", Mbps: 30}}, shaper.applied[0].ClientRateLimits)
+	})
+
+	t.Run("should report limits the firewall refuses", func(t *testing.T) {
+		shaper.err = errors.New("dnctl failed")
+		defer func() { shaper.err = nil }()
+
+		assert.Equal(t, http.StatusInternalServerError, setRateLimit(t, client.ID, `{"rate_limit_mbps": 40}`).Code)
+	})
+
+	t.Run("should remove the rate limit with zero", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setRateLimit(t, client.ID, `{"rate_limit_mbps": 0}`).Code)
+
+		limits, err := clientAPI.ClientRateLimits()
+		require.NoError(t, err)
+		assert.Empty(t, limits)
+	})
+
+	t.Run("should reject missing or negative limits", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, setRateLimit(t, client.ID, `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, setRateLimit(t, client.ID, `{"rate_limit_mbps": -5}`).Code)
+	})
+
+	t.Run("should return 404 for unknown client", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, setRateLimit(t, 999, `{"rate_limit_mbps": 10}`).Code)
+	})
+
+	t.Run("should return clear error on unsupported platforms", func(t *testing.T) {
+		clientAPI.rateLimitSupport = func() error { return system.ErrRateLimitUnsupported }
+		defer func() { clientAPI.rateLimitSupport = func() error { return nil } }()
+
+		resp := setRateLimit(t, client.ID, `{"rate_limit_mbps": 10}`)
+		assert.Equal(t, http.StatusNotImplemented, resp.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, system.ErrRateLimitUnsupported.Error(), response.Error)
+	})
+
+	t.Run("should refuse limits without a firewall shaper", func(t *testing.T) {
+		clientAPI.SetRateLimitShaper(nil)
+		defer clientAPI.SetRateLimitShaper(shaper)
+
+		assert.Equal(t, http.StatusNotImplemented, setRateLimit(t, client.ID, `{"rate_limit_mbps": 10}`).Code)
+	})
+}
+
+func TestClientAPI_IPv6Pool(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	ipPool, err := network.NewIPPool("fd00:10::/64")
+	require.NoError(t, err)
+	clientAPI.ipPool = ipPool
+	peers := &fakePeerManager{}
+	clientAPI.peers = peers
+
+	serverConfig := seedServerEndpoint(t, clientAPI)
+	serverConfig.Network = "fd00:10::/64"
+	require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+	body, _ := json.Marshal(CreateClientRequest{Name: "v6-laptop"})
+	req := httptest.NewRequest("POST", "/api/clients?include=config", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusCreated, resp.Code)
+
+	var created CreateClientResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	require.Equal(t, "fd00:10::2", created.IPAddress)
+
+	t.Run("should give the client a /128 tunnel address", func(t *testing.T) {
+		assert.Contains(t, created.Config, "Address = fd00:10::2/128\n")
+
+		clientConfig, err := clientAPI.buildClientConfig(newKeyedClient(t, "v6-phone", created.IPAddress), "")
+		require.NoError(t, err)
+		ip, ipNet, err := net.ParseCIDR(clientConfig.Address)
+		require.NoError(t, err)
+		assert.Equal(t, created.IPAddress, ip.String())
+		ones, _ := ipNet.Mask.Size()
+		assert.Equal(t, 128, ones)
+	})
+
+	t.Run("should route only the client address to its peer", func(t *testing.T) {
+		require.Len(t, peers.added, 1)
+		assert.Equal(t, []string{"fd00:10::2/128"}, peers.added[0].AllowedIPs)
+		assert.Contains(t, peers.added[0].ConfigSection(), "AllowedIPs = fd00:10::2/128\n")
+	})
+
+	t.Run("should address the interface with the pool prefix", func(t *testing.T) {
+		wgConfig := NewWireGuardConfig(serverConfig, ipPool.GetNetworkInfo(), "eth0")
+		assert.Equal(t, "fd00:10::1/64", wgConfig.Address)
+	})
+}
+
+func TestClientAPI_GetClientConfigIncompleteServer(t *testing.T) {
+	clientAPI, router, cleanup := setupTestAPI(t)
+	defer cleanup()
+
+	// The endpoint is set but the server key pair was never generated
+	serverConfig := &database.ServerConfig{
+		ListenPort: 51820,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+		Endpoint:   "vpn.example.com",
+	}
+	require.NoError(t, clientAPI.db.CreateServerConfig(serverConfig))
+
+	client := newKeyedClient(t, "laptop", "10.0.0.2")
+	require.NoError(t, clientAPI.db.CreateClient(client))
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/%s", client.ID, path), nil)
+		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
+		return resp
+	}
 
-		assert.Equal(t, http.StatusOK, resp.Code)
+	t.Run("should refuse config and QR code without server public key", func(t *testing.T) {
+		for _, path := range []string{"config", "qrcode"} {
+			resp := get(t, path)
+			assert.Equal(t, http.StatusInternalServerError, resp.Code, path)
 
-		var response ClientConfigResponse
-		err = json.Unmarshal(resp.Body.Bytes(), &response)
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Contains(t, response.Error, "server not fully configured")
+			assert.Contains(t, response.Error, "public key")
+		}
+	})
+
+	t.Run("should refuse to create client with embedded config", func(t *testing.T) {
+		body, _ := json.Marshal(CreateClientRequest{Name: "phone"})
+		req := httptest.NewRequest("POST", "/api/clients?include=qr", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+		clients, err := clientAPI.db.ListClients()
+		require.NoError(t, err)
+		assert.Len(t, clients, 1)
+	})
+
+	t.Run("should refuse keys that are not WireGuard keys", func(t *testing.T) {
+		serverConfig.PublicKey = "dummy-server-public-key"
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+		resp := get(t, "config")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Body.String(), "server public key is invalid")
+	})
+
+	t.Run("should refuse placeholder values", func(t *testing.T) {
+		keyPair, err := wireguard.GenerateKeyPair()
 		require.NoError(t, err)
+		serverConfig.PublicKey = keyPair.PublicKey
+		serverConfig.Endpoint = "your-server-ip"
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+		defer func() {
+			serverConfig.Endpoint = "vpn.example.com"
+			require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+		}()
+
+		resp := get(t, "config")
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Body.String(), "placeholder")
+	})
 
-		assert.NotEmpty(t, response.Config)
-		assert.Contains(t, response.Config, "[Interface]")
-		assert.Contains(t, response.Config, "[Peer]")
-		assert.Contains(t, response.Config, "PrivateKey")
-		assert.Contains(t, response.Config, "Address")
+	t.Run("should render config when enforcement is disabled", func(t *testing.T) {
+		clientAPI.config.RejectIncompleteConfigs = false
+		defer func() { clientAPI.config.RejectIncompleteConfigs = true }()
+
+		assert.Equal(t, http.StatusOK, get(t, "config").Code)
 	})
 
-	t.Run("should return 404 for non-existent client", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/api/clients/999/config", nil)
+	t.Run("should succeed once the server is initialized", func(t *testing.T) {
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		serverConfig.PrivateKey = keyPair.PrivateKey
+		serverConfig.PublicKey = keyPair.PublicKey
+		require.NoError(t, clientAPI.db.UpdateServerConfig(serverConfig))
+
+		resp := get(t, "config")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ClientConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Contains(t, response.Config, "PublicKey = "+keyPair.PublicKey)
+
+		assert.Equal(t, http.StatusOK, get(t, "qrcode").Code)
+	})
+}
+
+func TestClientAPI_GetClientQRCodeCache(t *testing.T) {
+	setup := func(t *testing.T) (*ClientAPI, *gin.Engine, uint, func()) {
+		clientAPI, router, cleanup := setupTestAPI(t)
+		seedServerEndpoint(t, clientAPI)
+
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBufferString(`{"name":"qr-cache"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+		return clientAPI, router, created.ID, cleanup
+	}
+
+	getPNG := func(t *testing.T, router *gin.Engine, id uint) []byte {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode?format=png", id), nil)
 		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		return resp.Body.Bytes()
+	}
+
+	t.Run("should return identical bytes from the cache", func(t *testing.T) {
+		clientAPI, router, id, cleanup := setup(t)
+		defer cleanup()
+
+		first := getPNG(t, router, id)
+		second := getPNG(t, router, id)
+
+		assert.Equal(t, first, second)
+		hits, misses := clientAPI.qrCache.Stats()
+		assert.Equal(t, uint64(1), hits)
+		assert.Equal(t, uint64(1), misses)
+	})
+
+	t.Run("should cache each size and format separately", func(t *testing.T) {
+		clientAPI, router, id, cleanup := setup(t)
+		defer cleanup()
+
+		getPNG(t, router, id)
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode?format=png&size=512", id), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		req = httptest.NewRequest("GET", fmt.Sprintf("/api/clients/%d/qrcode", id), nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, 3, clientAPI.qrCache.Len())
+	})
+
+	t.Run("should regenerate after the keys are rotated", func(t *testing.T) {
+		clientAPI, router, id, cleanup := setup(t)
+		defer cleanup()
+
+		before := getPNG(t, router, id)
+
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		client, err := clientAPI.db.GetClient(id)
+		require.NoError(t, err)
+		client.PublicKey = keyPair.PublicKey
+		client.PrivateKey = keyPair.PrivateKey
+		require.NoError(t, clientAPI.db.UpdateClient(client))
+
+		after := getPNG(t, router, id)
+		assert.NotEqual(t, before, after)
+		hits, misses := clientAPI.qrCache.Stats()
+		assert.Equal(t, uint64(0), hits)
+		assert.Equal(t, uint64(2), misses)
+	})
 
+	t.Run("should drop the cached codes of a deleted client", func(t *testing.T) {
+		clientAPI, router, id, cleanup := setup(t)
+		defer cleanup()
+
+		getPNG(t, router, id)
+		require.Equal(t, 1, clientAPI.qrCache.Len())
+
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/clients/%d", id), nil)
+		resp := httptest.NewRecorder()
 		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusNoContent, resp.Code)
 
-		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Equal(t, 0, clientAPI.qrCache.Len())
+	})
+
+	t.Run("should generate without a cache when disabled", func(t *testing.T) {
+		clientAPI, _, cleanup := setupTestAPI(t)
+		defer cleanup()
+		seedServerEndpoint(t, clientAPI)
+
+		config := DefaultClientAPIConfig()
+		config.QRCodeCacheSize = 0
+		uncached := NewClientAPIWithConfig(clientAPI.db, clientAPI.ipPool, clientAPI.wgServer, config)
+		uncached.peers = &fakePeerManager{}
+		require.Nil(t, uncached.qrCache)
+
+		router := gin.New()
+		uncached.RegisterRoutes(router)
+		req := httptest.NewRequest("POST", "/api/clients", bytes.NewBufferString(`{"name":"uncached"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+		var created CreateClientResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+
+		assert.Equal(t, getPNG(t, router, created.ID), getPNG(t, router, created.ID))
 	})
 }
 
 func TestClientAPI_GetClientQRCode(t *testing.T) {
-	_, router, cleanup := setupTestAPI(t)
+	clientAPI, router, cleanup := setupTestAPI(t)
 	defer cleanup()
+	seedServerEndpoint(t, clientAPI)
 
 	// Create a client first for testing
 	createReq := CreateClientRequest{Name: "qr-client"}
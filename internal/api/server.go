@@ -1,8 +1,11 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -11,16 +14,52 @@ import (
 	"gorm.io/gorm"
 
 	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
 	"my-vpn/internal/network"
+	"my-vpn/internal/system"
 	"my-vpn/internal/wireguard"
 )
 
+// Tunnel modes controlling which traffic clients route through the VPN
+const (
+	TunnelModeFull  = "full"  // Route all client traffic through the VPN
+	TunnelModeSplit = "split" // Route only the VPN subnet and pushed routes
+)
+
 type ServerAPI struct {
 	db       *database.Database
 	ipPool   *network.IPPool
 	wgServer *wireguard.WireGuardServer
+	configs  ClientConfigRenderer // Renders client configs for endpoint rotation bundles (optional)
+	detectExternalInterface func() (string, error) // Detects the uplink interface (defaults to system.DetectExternalInterface)
+	logger   *monitoring.ComponentLogger // Records server configuration changes (nil writes to the standard logger)
+}
+
+// fallbackExternalInterface is used for NAT when the uplink cannot be detected
+// and none is configured.
+const fallbackExternalInterface = "en0"
+
+// Tunnel MTU bounds: 576 is the minimum IPv4 datagram size every host must
+// accept and 1500 the Ethernet MTU
+const (
+	minMTU = 576
+	maxMTU = 1500
+)
+
+// interfaceNamePattern matches valid network interface names (at most 15 characters on Linux).
+var interfaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,15}$`)
+
+// ClientConfigRenderer renders the configurations of all active clients.
+// It is satisfied by ClientAPI.
+type ClientConfigRenderer interface {
+	RenderClientConfigs() ([]RenderedClientConfig, error)
 }
 
+// endpointRotationGuidance tells admins what happens to connected clients
+// after the server endpoint changed.
+const endpointRotationGuidance = "Existing tunnels keep working until clients re-handshake with the new endpoint. " +
+	"Distribute the regenerated configs and keep the previous endpoint reachable until every client has been updated."
+
 // Request/Response structures
 type ServerStatusResponse struct {
 	State        string    `json:"state"`
@@ -39,9 +78,20 @@ type ServerConfigResponse struct {
 	ServerIP         string    `json:"server_ip"`
 	Interface        string    `json:"interface"`
 	ListenPort       int       `json:"listen_port"`
+	MTU              int       `json:"mtu"` // Tunnel MTU (0 lets WireGuard choose)
 	DNS              []string  `json:"dns"`
+	TunnelMode       string    `json:"tunnel_mode"`
+	PushedRoutes     []string  `json:"pushed_routes"`
+	Endpoint         string    `json:"endpoint"`
+	AlternateEndpoints []string `json:"alternate_endpoints"`
+	ExternalInterface string   `json:"external_interface"`          // Uplink interface used for NAT
+	ExternalInterfaceDetected bool `json:"external_interface_detected"` // Whether ExternalInterface was auto-detected rather than configured
+	DefaultKeepalive int       `json:"default_keepalive"` // PersistentKeepalive for clients without their own (0 disables it)
+	DefaultDNS       []string  `json:"default_dns"`       // DNS servers for clients without their own (empty falls back to DNS)
 	PublicKey        string    `json:"public_key"`
 	PrivateKey       string    `json:"private_key,omitempty"`
+	ConfigVersion    string    `json:"config_version"` // Hash of the WireGuard config file last written by the server ("" if never written)
+	ConfigDrifted    bool      `json:"config_drifted"` // The config file was edited since the server last wrote it
 	NetworkAddress   string    `json:"network_address"`
 	BroadcastAddress string    `json:"broadcast_address"`
 	TotalHosts       int       `json:"total_hosts"`
@@ -50,14 +100,59 @@ type ServerConfigResponse struct {
 }
 
 type UpdateServerConfigRequest struct {
-	ListenPort int      `json:"listen_port,omitempty"`
-	DNS        []string `json:"dns,omitempty"`
+	ListenPort   int      `json:"listen_port,omitempty"`
+	MTU          *int     `json:"mtu,omitempty"` // 0 restores the WireGuard default
+	DNS          []string `json:"dns,omitempty"`
+	TunnelMode   string   `json:"tunnel_mode,omitempty"`
+	PushedRoutes []string `json:"pushed_routes,omitempty"`
+	Endpoint     string   `json:"endpoint,omitempty"`
+	AlternateEndpoints []string `json:"alternate_endpoints,omitempty"`
+	ExternalInterface *string  `json:"external_interface,omitempty"` // Overrides auto-detection; an empty string restores it
+	DefaultKeepalive *int     `json:"default_keepalive,omitempty" binding:"omitempty,min=0,max=65535"` // 0 disables the default keepalive
+	DefaultDNS       []string `json:"default_dns,omitempty"` // Replaces the client DNS default; an empty list falls back to DNS
+}
+
+type RotateEndpointRequest struct {
+	Endpoint       string `json:"endpoint" binding:"required"`
+	IncludeConfigs bool   `json:"include_configs,omitempty"` // Return the regenerated client configs
+}
+
+type RotateEndpointResponse struct {
+	Endpoint         string                 `json:"endpoint"`
+	PreviousEndpoint string                 `json:"previous_endpoint"`
+	StaleClients     int64                  `json:"stale_clients"` // Clients whose config must be downloaded again
+	Guidance         string                 `json:"guidance"`
+	Configs          []RenderedClientConfig `json:"configs,omitempty"`
 }
 
 type InitializeServerRequest struct {
 	Network    string   `json:"network" binding:"required"`
 	ListenPort int      `json:"listen_port" binding:"required,min=1,max=65535"`
+	MTU        int      `json:"mtu,omitempty" binding:"omitempty,min=576,max=1500"`
 	DNS        []string `json:"dns,omitempty"`
+	Endpoint   string   `json:"endpoint,omitempty"`
+	AlternateEndpoints []string `json:"alternate_endpoints,omitempty"`
+	DefaultKeepalive int `json:"default_keepalive,omitempty" binding:"omitempty,min=0,max=65535"` // PersistentKeepalive for clients without their own
+	DefaultDNS []string `json:"default_dns,omitempty"` // DNS servers for clients without their own (omit to fall back to DNS)
+	PrivateKey string   `json:"private_key,omitempty"` // Existing server key to keep, e.g. when migrating (omit to generate one)
+	Force      bool     `json:"force,omitempty"`       // Replace an existing configuration instead of failing
+}
+
+type DeleteServerConfigResponse struct {
+	Message      string `json:"message"`
+	RevokedPeers int    `json:"revoked_peers"` // Clients whose peer was removed and that were disabled
+}
+
+type DeleteLogsResponse struct {
+	Before  time.Time `json:"before"`
+	Deleted int64     `json:"deleted"` // Number of connection logs removed
+}
+
+type VerifyKeysResponse struct {
+	Valid            bool   `json:"valid"`
+	StoredPublicKey  string `json:"stored_public_key"`
+	DerivedPublicKey string `json:"derived_public_key,omitempty"`
+	Message          string `json:"message"`
 }
 
 type ServerLogsResponse struct {
@@ -80,9 +175,22 @@ func NewServerAPI(db *database.Database, ipPool *network.IPPool, wgServer *wireg
 		db:       db,
 		ipPool:   ipPool,
 		wgServer: wgServer,
+		detectExternalInterface: system.DetectExternalInterface,
 	}
 }
 
+// SetClientConfigRenderer sets the renderer used to return regenerated client
+// configs when the endpoint is rotated.
+func (api *ServerAPI) SetClientConfigRenderer(renderer ClientConfigRenderer) {
+	api.configs = renderer
+}
+
+// SetLogger sets the logger recording server configuration changes,
+// typically the log manager's LogComponentAPI logger.
+func (api *ServerAPI) SetLogger(logger *monitoring.ComponentLogger) {
+	api.logger = logger
+}
+
 // RegisterRoutes registers the server API routes
 func (api *ServerAPI) RegisterRoutes(router *gin.Engine) {
 	apiGroup := router.Group("/api")
@@ -95,8 +203,13 @@ func (api *ServerAPI) RegisterRoutes(router *gin.Engine) {
 			server.POST("/restart", api.RestartServer)
 			server.GET("/config", api.GetConfig)
 			server.PUT("/config", api.UpdateConfig)
+			server.DELETE("/config", api.DeleteConfig)
+			server.PUT("/endpoint", api.RotateEndpoint)
 			server.POST("/initialize", api.InitializeServer)
 			server.GET("/logs", api.GetLogs)
+			server.DELETE("/logs", api.DeleteLogs)
+			server.GET("/full-config", api.GetFullConfig)
+			server.GET("/verify-keys", api.VerifyKeys)
 		}
 	}
 }
@@ -122,9 +235,13 @@ func (api *ServerAPI) GetStatus(c *gin.Context) {
 
 // StartServer starts the WireGuard server
 func (api *ServerAPI) StartServer(c *gin.Context) {
-	// Check if server config exists
-	serverConfig, err := api.getOrCreateServerConfig()
+	// Only an initialized server can be started
+	serverConfig, err := api.db.GetServerConfig()
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Server is not initialized"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
 		return
 	}
@@ -179,9 +296,8 @@ func (api *ServerAPI) RestartServer(c *gin.Context) {
 
 // GetConfig returns the current server configuration
 func (api *ServerAPI) GetConfig(c *gin.Context) {
-	serverConfig, err := api.getOrCreateServerConfig()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+	serverConfig, ok := api.loadServerConfig(c)
+	if !ok {
 		return
 	}
 
@@ -196,12 +312,23 @@ func (api *ServerAPI) GetConfig(c *gin.Context) {
 		}
 	}
 
+	externalInterface := api.externalInterface(serverConfig)
+
 	response := ServerConfigResponse{
 		Network:          networkInfo.Network,
 		ServerIP:         networkInfo.ServerIP,
 		Interface:        serverConfig.Interface,
 		ListenPort:       serverConfig.ListenPort,
+		MTU:              serverConfig.MTU,
 		DNS:              dns,
+		TunnelMode:       serverConfig.TunnelMode,
+		PushedRoutes:     parseList(serverConfig.PushedRoutes),
+		Endpoint:         serverConfig.Endpoint,
+		AlternateEndpoints: parseList(serverConfig.AlternateEndpoints),
+		ExternalInterface: externalInterface,
+		ExternalInterfaceDetected: serverConfig.ExternalInterface == "",
+		DefaultKeepalive: serverConfig.DefaultKeepalive,
+		DefaultDNS:       parseList(serverConfig.DefaultDNS),
 		PublicKey:        serverConfig.PublicKey,
 		PrivateKey:       serverConfig.PrivateKey,
 		NetworkAddress:   networkInfo.NetworkAddress,
@@ -211,6 +338,12 @@ func (api *ServerAPI) GetConfig(c *gin.Context) {
 		UpdatedAt:        serverConfig.UpdatedAt,
 	}
 
+	// The drift check is informational; an unreadable file leaves the fields empty
+	if drift, err := api.wgServer.CheckConfigDrift(); err == nil {
+		response.ConfigVersion = drift.AppliedHash
+		response.ConfigDrifted = drift.Drifted
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -228,9 +361,70 @@ func (api *ServerAPI) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	serverConfig, err := api.getOrCreateServerConfig()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+	// Validate MTU
+	if req.MTU != nil && *req.MTU != 0 && (*req.MTU < minMTU || *req.MTU > maxMTU) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("MTU must be between %d and %d", minMTU, maxMTU)})
+		return
+	}
+
+	// Validate tunnel mode
+	if req.TunnelMode != "" && req.TunnelMode != TunnelModeFull && req.TunnelMode != TunnelModeSplit {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Tunnel mode must be 'full' or 'split'"})
+		return
+	}
+
+	// Validate pushed routes
+	var pushedRoutes []string
+	if req.PushedRoutes != nil {
+		routes, err := normalizeRoutes(req.PushedRoutes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		pushedRoutes = routes
+	}
+
+	// Validate public endpoint
+	var endpoint string
+	if req.Endpoint != "" {
+		host, err := validateEndpointHost(req.Endpoint)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		endpoint = host
+	}
+
+	// Validate alternate endpoints
+	var alternateEndpoints []string
+	if req.AlternateEndpoints != nil {
+		endpoints, err := normalizeEndpoints(req.AlternateEndpoints)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		alternateEndpoints = endpoints
+	}
+
+	// Validate default client DNS
+	var defaultDNS []string
+	if req.DefaultDNS != nil {
+		servers, err := normalizeDNSServers(req.DefaultDNS)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		defaultDNS = servers
+	}
+
+	// Validate external interface
+	if req.ExternalInterface != nil && *req.ExternalInterface != "" && !interfaceNamePattern.MatchString(*req.ExternalInterface) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid external interface %q", *req.ExternalInterface)})
+		return
+	}
+
+	serverConfig, ok := api.loadServerConfig(c)
+	if !ok {
 		return
 	}
 
@@ -238,20 +432,130 @@ func (api *ServerAPI) UpdateConfig(c *gin.Context) {
 	if req.ListenPort != 0 {
 		serverConfig.ListenPort = req.ListenPort
 	}
+	if req.MTU != nil {
+		serverConfig.MTU = *req.MTU
+	}
 	if req.DNS != nil {
 		serverConfig.DNS = strings.Join(req.DNS, ",")
 	}
+	if req.TunnelMode != "" {
+		serverConfig.TunnelMode = req.TunnelMode
+	}
+	if req.PushedRoutes != nil {
+		serverConfig.PushedRoutes = strings.Join(pushedRoutes, ",")
+	}
+	endpointChanged := endpoint != "" && !strings.EqualFold(serverConfig.Endpoint, endpoint)
+	if endpoint != "" {
+		serverConfig.Endpoint = endpoint
+	}
+	if req.AlternateEndpoints != nil {
+		serverConfig.AlternateEndpoints = strings.Join(alternateEndpoints, ",")
+	}
+	if req.ExternalInterface != nil {
+		serverConfig.ExternalInterface = *req.ExternalInterface
+	}
+	if req.DefaultKeepalive != nil {
+		serverConfig.DefaultKeepalive = *req.DefaultKeepalive
+	}
+	if req.DefaultDNS != nil {
+		serverConfig.DefaultDNS = joinList(defaultDNS)
+	}
 
 	if err := api.db.UpdateServerConfig(serverConfig); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update server configuration"})
 		return
 	}
 
+	// Configs handed out so far point at the old endpoint
+	if endpointChanged {
+		if _, err := api.db.MarkClientConfigsStale(); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to mark client configs stale"})
+			return
+		}
+	}
+
 	// Return updated config
 	api.GetConfig(c)
 }
 
-// InitializeServer initializes the server with a new configuration
+// RotateEndpoint replaces the public endpoint of an initialized server, e.g.
+// after its IP address or hostname changed. Every client config is marked
+// stale, and with include_configs the regenerated configs are returned.
+func (api *ServerAPI) RotateEndpoint(c *gin.Context) {
+	var req RotateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	endpoint, err := validateEndpointHost(req.Endpoint)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.IncludeConfigs && api.configs == nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Client config rendering is not available"})
+		return
+	}
+
+	serverConfig, err := api.db.GetServerConfig()
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Server is not initialized"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+		return
+	}
+
+	response := RotateEndpointResponse{
+		Endpoint:         endpoint,
+		PreviousEndpoint: serverConfig.Endpoint,
+		Guidance:         endpointRotationGuidance,
+	}
+
+	if !strings.EqualFold(serverConfig.Endpoint, endpoint) {
+		// The new primary must not linger as an alternate of itself
+		alternates := []string{}
+		for _, alternate := range parseList(serverConfig.AlternateEndpoints) {
+			if !strings.EqualFold(alternate, endpoint) {
+				alternates = append(alternates, alternate)
+			}
+		}
+		serverConfig.Endpoint = endpoint
+		serverConfig.AlternateEndpoints = strings.Join(alternates, ",")
+
+		if err := api.db.UpdateServerConfig(serverConfig); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update server configuration"})
+			return
+		}
+
+		stale, err := api.db.MarkClientConfigsStale()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to mark client configs stale"})
+			return
+		}
+		response.StaleClients = stale
+	}
+
+	if req.IncludeConfigs {
+		configs, err := api.configs.RenderClientConfigs()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("Failed to render client configs: %v", err)})
+			return
+		}
+		response.Configs = configs
+	}
+
+	api.logger.Info(fmt.Sprintf("Rotated server endpoint from %s to %s", response.PreviousEndpoint, response.Endpoint))
+	c.JSON(http.StatusOK, response)
+}
+
+// InitializeServer initializes the server with a new configuration.
+// An existing configuration is only replaced when the request sets force.
+// If that replaces the server key, the peers of all clients are revoked as
+// with DeleteConfig, since their configs reference the discarded key.
 func (api *ServerAPI) InitializeServer(c *gin.Context) {
 	var req InitializeServerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -260,23 +564,62 @@ func (api *ServerAPI) InitializeServer(c *gin.Context) {
 	}
 
 	// Validate network
-	newIPPool, err := network.NewIPPool(req.Network)
-	if err != nil {
+	if _, err := network.NewIPPool(req.Network); err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid network CIDR"})
 		return
 	}
 
-	// Generate server keys
-	keyPair, err := wireguard.GenerateKeyPair()
+	// Validate public endpoint; it may also be set later through UpdateConfig
+	var endpoint string
+	if req.Endpoint != "" {
+		var err error
+		endpoint, err = validateEndpointHost(req.Endpoint)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	alternateEndpoints, err := normalizeEndpoints(req.AlternateEndpoints)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate server keys"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
+	defaultDNS, err := normalizeDNSServers(req.DefaultDNS)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	// Use the supplied server key or generate new server keys
+	var keyPair *wireguard.KeyPair
+	if req.PrivateKey != "" {
+		if err := wireguard.ValidateKey(req.PrivateKey); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid private_key: %v", err)})
+			return
+		}
+		publicKey, err := wireguard.DerivePublicKey(req.PrivateKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to derive server public key"})
+			return
+		}
+		keyPair = &wireguard.KeyPair{PrivateKey: req.PrivateKey, PublicKey: publicKey}
+	} else {
+		keyPair, err = wireguard.GenerateKeyPair()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate server keys"})
+			return
+		}
+	}
 
-	// Set default DNS if not provided
-	dns := req.DNS
-	if len(dns) == 0 {
-		dns = []string{"8.8.8.8", "8.8.4.4"}
+	previousConfig, err := api.db.GetServerConfig()
+	if err == nil {
+		if !req.Force {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Server is already initialized; set force to replace the configuration"})
+			return
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+		return
 	}
 
 	// Create server config
@@ -284,23 +627,152 @@ func (api *ServerAPI) InitializeServer(c *gin.Context) {
 		PrivateKey: keyPair.PrivateKey,
 		PublicKey:  keyPair.PublicKey,
 		ListenPort: req.ListenPort,
+		MTU:        req.MTU,
 		Network:    req.Network,
 		Interface:  "wg0",
-		DNS:        strings.Join(dns, ","),
+		DNS:        strings.Join(req.DNS, ","), // Empty lets client configs fall back to the deployment default
+		TunnelMode: TunnelModeFull,
+		Endpoint:   endpoint,
+		AlternateEndpoints: strings.Join(alternateEndpoints, ","),
+		DefaultKeepalive: req.DefaultKeepalive,
+		DefaultDNS: joinList(defaultDNS),
 	}
 
-	if err := api.db.CreateServerConfig(serverConfig); err != nil {
+	// Move the IP pool to the new network, keeping existing client addresses
+	previousNetwork := api.ipPool.GetNetworkInfo().Network
+	if err := api.ipPool.Reconfigure(req.Network); err != nil {
+		if errors.Is(err, network.ErrAllocationsOutOfRange) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid network CIDR"})
+		return
+	}
+
+	// Any previous configuration is removed along with saving the new one
+	if err := api.db.ReplaceServerConfig(serverConfig); err != nil {
+		// The previous network holds every allocation, so moving back cannot fail
+		api.ipPool.Reconfigure(previousNetwork)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save server configuration"})
 		return
 	}
+	api.logger.Info(fmt.Sprintf("Initialized server with network %s on port %d", serverConfig.Network, serverConfig.ListenPort))
 
-	// Update the IP pool
-	api.ipPool = newIPPool
+	// Client configs reference the server key being discarded
+	if previousConfig != nil && previousConfig.PublicKey != serverConfig.PublicKey {
+		revoked, err := api.revokeAllPeers()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		api.logger.Info(fmt.Sprintf("Replaced server keys, revoking %d peer(s)", revoked))
+	}
 
 	// Return the new config
 	api.GetConfig(c)
 }
 
+// DeleteConfig removes the server configuration, so the server can be
+// initialized again. With ?revoke_peers=true the peers of all clients are
+// removed from the WireGuard configuration and the clients are disabled,
+// since their configs reference the server key being discarded.
+func (api *ServerAPI) DeleteConfig(c *gin.Context) {
+	if _, err := api.db.GetServerConfig(); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Server is not initialized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+		return
+	}
+
+	revokedPeers := 0
+	if revoke, _ := strconv.ParseBool(c.Query("revoke_peers")); revoke {
+		var err error
+		revokedPeers, err = api.revokeAllPeers()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if _, err := api.db.DeleteServerConfig(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete server configuration"})
+		return
+	}
+	api.logger.Info(fmt.Sprintf("Deleted server configuration, revoking %d peer(s)", revokedPeers))
+
+	c.JSON(http.StatusOK, DeleteServerConfigResponse{
+		Message:      "Server configuration deleted",
+		RevokedPeers: revokedPeers,
+	})
+}
+
+// revokeAllPeers removes the peer of every enabled client from the WireGuard
+// configuration and disables the client, then syncs a running interface.
+// Peers missing from the configuration file are not an error.
+// Returns the number of revoked clients and an error if a client cannot be updated.
+func (api *ServerAPI) revokeAllPeers() (int, error) {
+	clients, err := api.db.ListClients()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	revoked := 0
+	for i := range clients {
+		client := &clients[i]
+		if !client.Enabled || client.Pending {
+			continue
+		}
+		// A missing config file holds no peers to remove
+		api.wgServer.RemovePeer(client.PublicKey)
+
+		client.Enabled = false
+		if err := api.db.UpdateClient(client); err != nil {
+			return revoked, fmt.Errorf("failed to disable client %s: %w", client.Name, err)
+		}
+		revoked++
+	}
+
+	if revoked > 0 {
+		if err := api.wgServer.ApplyConfig(); err != nil {
+			return revoked, fmt.Errorf("failed to sync running interface: %w", err)
+		}
+	}
+
+	return revoked, nil
+}
+
+// connectionLogDeleteBatchSize bounds the rows removed per statement when purging logs.
+const connectionLogDeleteBatchSize = 1000
+
+// DeleteLogs purges connection logs recorded before the RFC 3339 timestamp
+// in the before query parameter. Rows are deleted in batches.
+func (api *ServerAPI) DeleteLogs(c *gin.Context) {
+	beforeStr := c.Query("before")
+	if beforeStr == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "before is required"})
+		return
+	}
+	before, err := time.Parse(time.RFC3339, beforeStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "before must be an RFC 3339 timestamp"})
+		return
+	}
+
+	deleted, err := api.db.DeleteConnectionLogsBefore(before, connectionLogDeleteBatchSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete logs"})
+		return
+	}
+	api.logger.Info(fmt.Sprintf("Deleted %d connection logs recorded before %s", deleted, before.Format(time.RFC3339)))
+
+	c.JSON(http.StatusOK, DeleteLogsResponse{
+		Before:  before,
+		Deleted: deleted,
+	})
+}
+
 // GetLogs returns server connection logs
 func (api *ServerAPI) GetLogs(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "100")
@@ -334,42 +806,135 @@ func (api *ServerAPI) GetLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// Helper function to get or create server config
-func (api *ServerAPI) getOrCreateServerConfig() (*database.ServerConfig, error) {
-	serverConfig, err := api.db.GetServerConfig()
+// VerifyKeys re-derives the public key from the stored private key and compares it
+// with the stored public key. A mismatch (e.g. after a manual database edit or a bad
+// import) means clients are handed a wrong server key and silently fail to connect.
+func (api *ServerAPI) VerifyKeys(c *gin.Context) {
+	serverConfig, ok := api.loadServerConfig(c)
+	if !ok {
+		return
+	}
+
+	response := VerifyKeysResponse{
+		StoredPublicKey: serverConfig.PublicKey,
+	}
+
+	derived, err := wireguard.DerivePublicKey(serverConfig.PrivateKey)
+	switch {
+	case err != nil:
+		response.Message = fmt.Sprintf("Stored private key is invalid: %v", err)
+	case derived != serverConfig.PublicKey:
+		response.DerivedPublicKey = derived
+		response.Message = "Stored public key does not match the private key"
+	default:
+		response.Valid = true
+		response.DerivedPublicKey = derived
+		response.Message = "Server keys match"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetFullConfig returns the complete WireGuard server configuration as a text/plain
+// attachment: the [Interface] section followed by one [Peer] per enabled client.
+// The output contains the server private key, so it must only be exposed to admins
+// and its content is never logged.
+func (api *ServerAPI) GetFullConfig(c *gin.Context) {
+	serverConfig, ok := api.loadServerConfig(c)
+	if !ok {
+		return
+	}
+
+	clients, err := api.db.ListClients()
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			// Create default server config
-			keyPair, err := wireguard.GenerateKeyPair()
-			if err != nil {
-				return nil, err
-			}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get clients"})
+		return
+	}
 
-			networkInfo := api.ipPool.GetNetworkInfo()
-			serverConfig = &database.ServerConfig{
-				PrivateKey: keyPair.PrivateKey,
-				PublicKey:  keyPair.PublicKey,
-				ListenPort: 51820,
-				Network:    networkInfo.Network,
-				Interface:  "wg0",
-				DNS:        "8.8.8.8,8.8.4.4",
-			}
+	content := api.renderFullConfig(serverConfig, clients)
 
-			if err := api.db.CreateServerConfig(serverConfig); err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, err
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.conf", serverConfig.Interface))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(content))
+}
+
+// renderFullConfig renders the server [Interface] section with a [Peer] section
+// for every enabled client, built the same way as the peers written to the
+// configuration file.
+func (api *ServerAPI) renderFullConfig(serverConfig *database.ServerConfig, clients []database.Client) string {
+	wgConfig := api.convertToWireGuardConfig(serverConfig)
+
+	var config strings.Builder
+	config.WriteString(wgConfig.GenerateConfigFile())
+	for i := range clients {
+		if !clients[i].Enabled {
+			continue
 		}
+		config.WriteString(NewClientPeer(&clients[i], serverConfig.DefaultKeepalive).ConfigSection())
 	}
 
-	return serverConfig, nil
+	return config.String()
+}
+
+// loadServerConfig loads the stored server configuration, writing a 404
+// response if the server is not initialized or a 500 response otherwise.
+// Returns the configuration and true if the request may proceed.
+func (api *ServerAPI) loadServerConfig(c *gin.Context) (*database.ServerConfig, bool) {
+	serverConfig, err := api.db.GetServerConfig()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Server is not initialized"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get server configuration"})
+		return nil, false
+	}
+	return serverConfig, true
 }
 
 // Helper function to convert database config to WireGuard config
 func (api *ServerAPI) convertToWireGuardConfig(dbConfig *database.ServerConfig) *wireguard.ServerConfig {
-	networkInfo := api.ipPool.GetNetworkInfo()
-	
+	return NewWireGuardConfig(dbConfig, api.ipPool.GetNetworkInfo(), api.externalInterface(dbConfig))
+}
+
+// externalInterface returns the uplink interface used for NAT.
+func (api *ServerAPI) externalInterface(dbConfig *database.ServerConfig) string {
+	return resolveExternalInterface(dbConfig, api.detectExternalInterface)
+}
+
+// ResolveExternalInterface returns the configured uplink interface of the
+// server or, if none is configured, the detected default-route interface.
+func ResolveExternalInterface(dbConfig *database.ServerConfig) string {
+	return resolveExternalInterface(dbConfig, system.DetectExternalInterface)
+}
+
+// resolveExternalInterface prefers the configured interface over detection
+// and falls back to en0 if detection fails.
+func resolveExternalInterface(dbConfig *database.ServerConfig, detect func() (string, error)) string {
+	if dbConfig.ExternalInterface != "" {
+		return dbConfig.ExternalInterface
+	}
+	if iface, err := detect(); err == nil {
+		return iface
+	}
+	return fallbackExternalInterface
+}
+
+// interfaceAddress returns the server IP with the prefix length of the pool's
+// network, e.g. "10.0.0.1/23", so every client address in the pool is on-link.
+func interfaceAddress(pool network.NetworkInfo) string {
+	_, ipNet, err := net.ParseCIDR(pool.Network)
+	if err != nil {
+		return wireguard.HostPrefix(pool.ServerIP)
+	}
+	ones, _ := ipNet.Mask.Size()
+	return fmt.Sprintf("%s/%d", pool.ServerIP, ones)
+}
+
+// NewWireGuardConfig converts a stored server configuration into the WireGuard
+// interface configuration, using the server IP of the pool as the interface
+// address and masquerading client traffic out of externalInterface.
+func NewWireGuardConfig(dbConfig *database.ServerConfig, pool network.NetworkInfo, externalInterface string) *wireguard.ServerConfig {
 	// Parse DNS
 	var dns []string
 	if dbConfig.DNS != "" {
@@ -382,17 +947,88 @@ func (api *ServerAPI) convertToWireGuardConfig(dbConfig *database.ServerConfig)
 	return &wireguard.ServerConfig{
 		PrivateKey: dbConfig.PrivateKey,
 		PublicKey:  dbConfig.PublicKey,
-		Address:    fmt.Sprintf("%s/24", networkInfo.ServerIP),
+		Address:    interfaceAddress(pool),
 		ListenPort: dbConfig.ListenPort,
+		MTU:        dbConfig.MTU,
 		DNS:        dns,
 		PostUp: []string{
 			"iptables -A FORWARD -i " + dbConfig.Interface + " -j ACCEPT",
-			"iptables -t nat -A POSTROUTING -o en0 -j MASQUERADE",
+			"iptables -t nat -A POSTROUTING -o " + externalInterface + " -j MASQUERADE",
 		},
 		PostDown: []string{
 			"iptables -D FORWARD -i " + dbConfig.Interface + " -j ACCEPT",
-			"iptables -t nat -D POSTROUTING -o en0 -j MASQUERADE",
+			"iptables -t nat -D POSTROUTING -o " + externalInterface + " -j MASQUERADE",
 		},
 		Interface: dbConfig.Interface,
 	}
-}
\ No newline at end of file
+}
+
+// normalizeRoutes validates a list of CIDRs and returns them in canonical form.
+func normalizeRoutes(routes []string) ([]string, error) {
+	normalized := make([]string, 0, len(routes))
+	for _, route := range routes {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(route))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pushed route %q: must be a CIDR", route)
+		}
+		normalized = append(normalized, ipNet.String())
+	}
+	return normalized, nil
+}
+
+// validateEndpointHost checks that the public endpoint is a bare IP address or
+// hostname (without a port, which always comes from the listen port).
+// Returns the trimmed host or an error describing why it is invalid.
+func validateEndpointHost(endpoint string) (string, error) {
+	host := strings.TrimSpace(endpoint)
+	if host == "" {
+		return "", fmt.Errorf("endpoint must not be empty")
+	}
+
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+
+	if len(host) > 253 {
+		return "", fmt.Errorf("invalid endpoint %q: hostname is too long", endpoint)
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if !isValidHostnameLabel(label) {
+			return "", fmt.Errorf("invalid endpoint %q: must be a hostname or IP address without port", endpoint)
+		}
+	}
+
+	return host, nil
+}
+
+// normalizeEndpoints validates a list of alternate endpoints and returns the
+// trimmed hosts with duplicates removed.
+func normalizeEndpoints(endpoints []string) ([]string, error) {
+	normalized := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		host, err := validateEndpointHost(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(normalized, host) {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized, nil
+}
+
+// isValidHostnameLabel reports whether a single DNS label is valid (RFC 1123).
+func isValidHostnameLabel(label string) bool {
+	if len(label) == 0 || len(label) > 63 {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -2,10 +2,17 @@ package api
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +22,7 @@ import (
 
 	"my-vpn/internal/database"
 	"my-vpn/internal/network"
+	"my-vpn/internal/system"
 	"my-vpn/internal/wireguard"
 )
 
@@ -53,6 +61,25 @@ func setupTestServerAPI(t *testing.T) (*ServerAPI, *gin.Engine, func()) {
 	return serverAPI, router, cleanup
 }
 
+// seedServerConfig stores a server configuration with fresh keys, as an
+// initialized server would have.
+func seedServerConfig(t *testing.T, serverAPI *ServerAPI) *database.ServerConfig {
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+
+	serverConfig := &database.ServerConfig{
+		PrivateKey: keyPair.PrivateKey,
+		PublicKey:  keyPair.PublicKey,
+		ListenPort: 51820,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+		DNS:        "8.8.8.8,8.8.4.4",
+		TunnelMode: TunnelModeFull,
+	}
+	require.NoError(t, serverAPI.db.CreateServerConfig(serverConfig))
+	return serverConfig
+}
+
 func TestServerAPI_GetStatus(t *testing.T) {
 	_, router, cleanup := setupTestServerAPI(t)
 	defer cleanup()
@@ -75,11 +102,56 @@ func TestServerAPI_GetStatus(t *testing.T) {
 	})
 }
 
+func TestServerAPI_StartServerAfterResize(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	runner := system.NewMockRunner()
+	runner.On("wg show wg0", "Unable to access interface: No such device", errors.New("exit status 1"))
+	serverAPI.wgServer = wireguard.NewWireGuardServerWithRunner(t.TempDir(), "wg0", runner)
+
+	post := func(path string, body interface{}) *httptest.ResponseRecorder {
+		encoded, err := json.Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", path, bytes.NewBuffer(encoded))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	require.Equal(t, http.StatusOK, post("/api/server/initialize", InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820}).Code)
+	require.Equal(t, http.StatusOK, post("/api/server/initialize", InitializeServerRequest{Network: "10.0.0.0/23", ListenPort: 51820, Force: true}).Code)
+
+	t.Run("should write the interface address with the resized prefix", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, post("/api/server/start", nil).Code)
+
+		content, err := os.ReadFile(serverAPI.wgServer.GetConfigPath())
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Address = 10.0.0.1/23\n")
+	})
+}
+
 func TestServerAPI_StartServer(t *testing.T) {
-	_, router, cleanup := setupTestServerAPI(t)
+	serverAPI, router, cleanup := setupTestServerAPI(t)
 	defer cleanup()
 
+	t.Run("should refuse to start before initialization", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/server/start", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Server is not initialized")
+
+		_, err := serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
 	t.Run("should attempt to start server", func(t *testing.T) {
+		seedServerConfig(t, serverAPI)
+
 		req := httptest.NewRequest("POST", "/api/server/start", nil)
 		resp := httptest.NewRecorder()
 
@@ -147,10 +219,25 @@ func TestServerAPI_RestartServer(t *testing.T) {
 }
 
 func TestServerAPI_GetConfig(t *testing.T) {
-	_, router, cleanup := setupTestServerAPI(t)
+	serverAPI, router, cleanup := setupTestServerAPI(t)
 	defer cleanup()
 
+	t.Run("should return not found before initialization", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/server/config", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Server is not initialized")
+
+		_, err := serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
 	t.Run("should return server config", func(t *testing.T) {
+		seedServerConfig(t, serverAPI)
+
 		req := httptest.NewRequest("GET", "/api/server/config", nil)
 		resp := httptest.NewRecorder()
 
@@ -168,13 +255,77 @@ func TestServerAPI_GetConfig(t *testing.T) {
 		assert.Equal(t, 51820, response.ListenPort)
 		assert.NotEmpty(t, response.PublicKey)
 	})
+
+	t.Run("should return config version and drift", func(t *testing.T) {
+		tempDir := t.TempDir()
+		serverAPI.wgServer = wireguard.NewWireGuardServerWithConfig(tempDir, "wg0")
+		require.NoError(t, serverAPI.wgServer.WriteConfig(&wireguard.ServerConfig{
+			PrivateKey: "server-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51820,
+		}))
+
+		getConfig := func() ServerConfigResponse {
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httptest.NewRequest("GET", "/api/server/config", nil))
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var response ServerConfigResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			return response
+		}
+
+		response := getConfig()
+		applied, err := serverAPI.wgServer.AppliedConfigHash()
+		require.NoError(t, err)
+		assert.Equal(t, applied, response.ConfigVersion)
+		assert.False(t, response.ConfigDrifted)
+
+		// Rewriting the config with a new setting changes the version
+		require.NoError(t, serverAPI.wgServer.WriteConfig(&wireguard.ServerConfig{
+			PrivateKey: "server-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51821,
+		}))
+		updated := getConfig()
+		assert.NotEqual(t, response.ConfigVersion, updated.ConfigVersion)
+		assert.False(t, updated.ConfigDrifted)
+
+		// Editing the file by hand is reported as drift
+		configPath := filepath.Join(tempDir, "wg0.conf")
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(configPath, append(content, []byte("MTU = 1380\n")...), 0600))
+
+		drifted := getConfig()
+		assert.Equal(t, updated.ConfigVersion, drifted.ConfigVersion)
+		assert.True(t, drifted.ConfigDrifted)
+	})
 }
 
 func TestServerAPI_UpdateConfig(t *testing.T) {
-	_, router, cleanup := setupTestServerAPI(t)
+	serverAPI, router, cleanup := setupTestServerAPI(t)
 	defer cleanup()
 
+	t.Run("should return not found before initialization", func(t *testing.T) {
+		body, err := json.Marshal(UpdateServerConfigRequest{ListenPort: 51821})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("PUT", "/api/server/config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		_, err = serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
 	t.Run("should update server config", func(t *testing.T) {
+		seedServerConfig(t, serverAPI)
+
 		updateReq := UpdateServerConfigRequest{
 			ListenPort: 51821,
 			DNS:        []string{"1.1.1.1", "1.0.0.1"},
@@ -199,6 +350,53 @@ func TestServerAPI_UpdateConfig(t *testing.T) {
 		assert.Equal(t, []string{"1.1.1.1", "1.0.0.1"}, response.DNS)
 	})
 
+	t.Run("should update and validate the default keepalive", func(t *testing.T) {
+		send := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("PUT", "/api/server/config", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			return resp
+		}
+
+		resp := send(`{"default_keepalive":25}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 25, response.DefaultKeepalive)
+
+		assert.Equal(t, http.StatusBadRequest, send(`{"default_keepalive":-1}`).Code)
+
+		resp = send(`{"default_keepalive":0}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Zero(t, response.DefaultKeepalive)
+	})
+
+	t.Run("should update and validate the default DNS", func(t *testing.T) {
+		send := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("PUT", "/api/server/config", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			return resp
+		}
+
+		resp := send(`{"default_dns":["10.0.0.53"," 10.0.0.54 "]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, []string{"10.0.0.53", "10.0.0.54"}, response.DefaultDNS)
+
+		assert.Equal(t, http.StatusBadRequest, send(`{"default_dns":["dns.example.com"]}`).Code)
+
+		resp = send(`{"default_dns":[]}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		response = ServerConfigResponse{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Empty(t, response.DefaultDNS)
+	})
+
 	t.Run("should validate listen port range", func(t *testing.T) {
 		updateReq := UpdateServerConfigRequest{
 			ListenPort: 70000, // Invalid port
@@ -217,6 +415,131 @@ func TestServerAPI_UpdateConfig(t *testing.T) {
 	})
 }
 
+func TestServerAPI_ExternalInterface(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+	serverAPI.detectExternalInterface = func() (string, error) { return "ens5", nil }
+	seedServerConfig(t, serverAPI)
+
+	getConfig := func(t *testing.T) ServerConfigResponse {
+		req := httptest.NewRequest("GET", "/api/server/config", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response
+	}
+	update := func(t *testing.T, iface string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateServerConfigRequest{ExternalInterface: &iface})
+		req := httptest.NewRequest("PUT", "/api/server/config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	fullConfig := func(t *testing.T) string {
+		req := httptest.NewRequest("GET", "/api/server/full-config", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		return resp.Body.String()
+	}
+
+	t.Run("should use detected interface by default", func(t *testing.T) {
+		response := getConfig(t)
+		assert.Equal(t, "ens5", response.ExternalInterface)
+		assert.True(t, response.ExternalInterfaceDetected)
+		assert.Contains(t, fullConfig(t), "iptables -t nat -A POSTROUTING -o ens5 -j MASQUERADE")
+	})
+
+	t.Run("should prefer configured interface", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, update(t, "eth1").Code)
+
+		response := getConfig(t)
+		assert.Equal(t, "eth1", response.ExternalInterface)
+		assert.False(t, response.ExternalInterfaceDetected)
+		assert.Contains(t, fullConfig(t), "-o eth1 -j MASQUERADE")
+
+		require.Equal(t, http.StatusOK, update(t, "").Code)
+		assert.Equal(t, "ens5", getConfig(t).ExternalInterface)
+	})
+
+	t.Run("should reject invalid interface name", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, update(t, "eth0; reboot").Code)
+	})
+
+	t.Run("should fall back to en0 when detection fails", func(t *testing.T) {
+		serverAPI.detectExternalInterface = func() (string, error) { return "", fmt.Errorf("no default route") }
+		defer func() { serverAPI.detectExternalInterface = func() (string, error) { return "ens5", nil } }()
+
+		assert.Equal(t, "en0", getConfig(t).ExternalInterface)
+	})
+}
+
+func TestServerAPI_PushedRoutes(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+	seedServerConfig(t, serverAPI)
+
+	updateConfig := func(updateReq UpdateServerConfigRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(updateReq)
+		req := httptest.NewRequest("PUT", "/api/server/config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should store split tunnel with pushed routes", func(t *testing.T) {
+		resp := updateConfig(UpdateServerConfigRequest{
+			TunnelMode:   TunnelModeSplit,
+			PushedRoutes: []string{"10.50.0.0/16", "172.16.0.0/12"},
+			Endpoint:     "vpn.example.com",
+		})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, TunnelModeSplit, response.TunnelMode)
+		assert.Equal(t, []string{"10.50.0.0/16", "172.16.0.0/12"}, response.PushedRoutes)
+	})
+
+	t.Run("should push routes plus VPN subnet to client configs", func(t *testing.T) {
+		clientAPI := NewClientAPI(serverAPI.db, serverAPI.ipPool, serverAPI.wgServer)
+		client := newKeyedClient(t, "split-client", "10.0.0.2")
+
+		clientConfig, err := clientAPI.buildClientConfig(client, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.0/24", "10.50.0.0/16", "172.16.0.0/12"}, clientConfig.AllowedIPs)
+		assert.Contains(t, clientConfig.GenerateConfigFile(), "AllowedIPs = 10.0.0.0/24, 10.50.0.0/16, 172.16.0.0/12")
+	})
+
+	t.Run("should route everything in full tunnel mode", func(t *testing.T) {
+		resp := updateConfig(UpdateServerConfigRequest{TunnelMode: TunnelModeFull})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		clientAPI := NewClientAPI(serverAPI.db, serverAPI.ipPool, serverAPI.wgServer)
+		clientConfig, err := clientAPI.buildClientConfig(newKeyedClient(t, "full-client", "10.0.0.2"), "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0.0.0.0/0"}, clientConfig.AllowedIPs)
+	})
+
+	t.Run("should reject invalid pushed route", func(t *testing.T) {
+		resp := updateConfig(UpdateServerConfigRequest{PushedRoutes: []string{"10.50.0.0/33"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = updateConfig(UpdateServerConfigRequest{PushedRoutes: []string{"not-a-cidr"}})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject unknown tunnel mode", func(t *testing.T) {
+		resp := updateConfig(UpdateServerConfigRequest{TunnelMode: "partial"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
 func TestServerAPI_InitializeServer(t *testing.T) {
 	_, router, cleanup := setupTestServerAPI(t)
 	defer cleanup()
@@ -266,6 +589,538 @@ func TestServerAPI_InitializeServer(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, resp.Code)
 	})
+
+	t.Run("should fail with invalid endpoint", func(t *testing.T) {
+		initReq := InitializeServerRequest{
+			Network:    "192.168.100.0/24",
+			ListenPort: 51820,
+			Endpoint:   "vpn.example.com:51820",
+		}
+
+		body, err := json.Marshal(initReq)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should keep client allocations when expanding the network", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+		require.NoError(t, serverAPI.ipPool.AllocateSpecificIP("10.0.0.200"))
+		pool := serverAPI.ipPool
+
+		body, err := json.Marshal(InitializeServerRequest{Network: "10.0.0.0/23", ListenPort: 51820})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		// The pool shared with the client API is updated in place
+		assert.Same(t, pool, serverAPI.ipPool)
+		assert.Equal(t, "10.0.0.0/23", pool.GetNetworkInfo().Network)
+		assert.True(t, pool.IsAllocated("10.0.0.200"))
+	})
+
+	t.Run("should refuse a network that would orphan clients", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+		require.NoError(t, serverAPI.ipPool.AllocateSpecificIP("10.0.0.200"))
+
+		body, err := json.Marshal(InitializeServerRequest{Network: "10.0.0.0/25", ListenPort: 51820})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "10.0.0.200")
+		assert.Equal(t, "10.0.0.0/24", serverAPI.ipPool.GetNetworkInfo().Network)
+
+		_, err = serverAPI.db.GetServerConfig()
+		assert.Error(t, err, "nothing should be saved")
+	})
+
+	t.Run("should keep supplied private key", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+
+		body, err := json.Marshal(InitializeServerRequest{
+			Network:    "192.168.100.0/24",
+			ListenPort: 51820,
+			PrivateKey: keyPair.PrivateKey,
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, keyPair.PublicKey, response.PublicKey)
+	})
+
+	t.Run("should fail with invalid private key", func(t *testing.T) {
+		for _, privateKey := range []string{"not-a-key", base64.StdEncoding.EncodeToString(make([]byte, 31))} {
+			body, err := json.Marshal(InitializeServerRequest{
+				Network:    "192.168.100.0/24",
+				ListenPort: 51820,
+				PrivateKey: privateKey,
+			})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.Contains(t, resp.Body.String(), "invalid private_key")
+		}
+	})
+
+	t.Run("should refuse to initialize again without force", func(t *testing.T) {
+		body, err := json.Marshal(InitializeServerRequest{Network: "172.16.0.0/24", ListenPort: 51821})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "already initialized")
+	})
+
+	t.Run("should replace the configuration when forced", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		initialize := func(req InitializeServerRequest) ServerConfigResponse {
+			body, err := json.Marshal(req)
+			require.NoError(t, err)
+			httpReq := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httpReq)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var response ServerConfigResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			return response
+		}
+
+		first := initialize(InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820})
+		client := &database.Client{Name: "stale", PublicKey: "stale-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}
+		require.NoError(t, serverAPI.db.CreateClient(client))
+
+		second := initialize(InitializeServerRequest{Network: "10.0.0.0/23", ListenPort: 51821, Force: true})
+
+		assert.Equal(t, "10.0.0.0/23", second.Network)
+		assert.Equal(t, 51821, second.ListenPort)
+		assert.NotEqual(t, first.PublicKey, second.PublicKey)
+
+		var count int64
+		require.NoError(t, serverAPI.db.Model(&database.ServerConfig{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count, "the old configuration should be removed")
+
+		stored, err := serverAPI.db.GetServerConfig()
+		require.NoError(t, err)
+		assert.Equal(t, second.PublicKey, stored.PublicKey)
+
+		// The client config still references the discarded server key
+		revoked, err := serverAPI.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, revoked.Enabled)
+	})
+
+	t.Run("should keep clients when forced with the same server key", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		for _, req := range []InitializeServerRequest{
+			{Network: "10.0.0.0/24", ListenPort: 51820, PrivateKey: keyPair.PrivateKey},
+			{Network: "10.0.0.0/24", ListenPort: 51821, PrivateKey: keyPair.PrivateKey, Force: true},
+		} {
+			body, err := json.Marshal(req)
+			require.NoError(t, err)
+			httpReq := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+			httpReq.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, httpReq)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			if !req.Force {
+				require.NoError(t, serverAPI.db.CreateClient(&database.Client{Name: "kept", PublicKey: "kept-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}))
+			}
+		}
+
+		clients, err := serverAPI.db.ListClients()
+		require.NoError(t, err)
+		require.Len(t, clients, 1)
+		assert.True(t, clients[0].Enabled)
+	})
+}
+
+func TestServerAPI_DeleteConfig(t *testing.T) {
+	initialize := func(t *testing.T, router *gin.Engine) {
+		body, err := json.Marshal(InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820})
+		require.NoError(t, err)
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	deleteConfig := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should return not found before initialization", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		assert.Equal(t, http.StatusNotFound, deleteConfig(router, "/api/server/config").Code)
+	})
+
+	t.Run("should delete the configuration and allow initializing again", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+		initialize(t, router)
+
+		client := &database.Client{Name: "kept", PublicKey: "kept-key", PrivateKey: "private", IPAddress: "10.0.0.2", Enabled: true}
+		require.NoError(t, serverAPI.db.CreateClient(client))
+
+		resp := deleteConfig(router, "/api/server/config")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response DeleteServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 0, response.RevokedPeers)
+
+		_, err := serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+		// Clients are left alone unless peers are revoked
+		stored, err := serverAPI.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Enabled)
+
+		initialize(t, router)
+	})
+
+	t.Run("should revoke all peers when requested", func(t *testing.T) {
+		serverAPI, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+		initialize(t, router)
+
+		runner := system.NewMockRunner()
+		runner.On("wg show wg0", "Unable to access interface: No such device", errors.New("exit status 1"))
+		serverAPI.wgServer = wireguard.NewWireGuardServerWithRunner(t.TempDir(), "wg0", runner)
+		require.NoError(t, serverAPI.wgServer.WriteConfig(&wireguard.ServerConfig{
+			PrivateKey: "test-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51820,
+			Interface:  "wg0",
+		}))
+
+		keys := []string{
+			base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+			base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)),
+		}
+		for i, key := range keys {
+			ip := fmt.Sprintf("10.0.0.%d", i+2)
+			require.NoError(t, serverAPI.db.CreateClient(&database.Client{Name: fmt.Sprintf("client-%d", i), PublicKey: key, PrivateKey: "private", IPAddress: ip, Enabled: true}))
+			require.NoError(t, serverAPI.wgServer.AddPeer(&wireguard.Peer{PublicKey: key, AllowedIPs: []string{ip + "/32"}}))
+		}
+		disabled := &database.Client{Name: "disabled", PublicKey: "disabled-key", PrivateKey: "private", IPAddress: "10.0.0.9", Enabled: true}
+		require.NoError(t, serverAPI.db.CreateClient(disabled))
+		disabled.Enabled = false
+		require.NoError(t, serverAPI.db.UpdateClient(disabled))
+
+		resp := deleteConfig(router, "/api/server/config?revoke_peers=true")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response DeleteServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.RevokedPeers)
+
+		peers, err := serverAPI.wgServer.GetPeers()
+		require.NoError(t, err)
+		assert.Empty(t, peers)
+
+		clients, err := serverAPI.db.ListClients()
+		require.NoError(t, err)
+		require.Len(t, clients, 3)
+		for _, client := range clients {
+			assert.False(t, client.Enabled, "client %s should be disabled", client.Name)
+		}
+
+		_, err = serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestServerAPI_MTU(t *testing.T) {
+	send := func(t *testing.T, router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+	fullConfig := func(t *testing.T, router *gin.Engine) string {
+		req := httptest.NewRequest("GET", "/api/server/full-config", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+		return resp.Body.String()
+	}
+	mtu := func(value int) *int { return &value }
+
+	t.Run("should initialize server with MTU", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		resp := send(t, router, "POST", "/api/server/initialize", InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820, MTU: 1380})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, 1380, response.MTU)
+		assert.Contains(t, fullConfig(t, router), "ListenPort = 51820\nMTU = 1380\n")
+	})
+
+	t.Run("should reject MTU out of range on initialize", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		resp := send(t, router, "POST", "/api/server/initialize", InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820, MTU: 9000})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should update and clear MTU", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		resp := send(t, router, "POST", "/api/server/initialize", InitializeServerRequest{Network: "10.0.0.0/24", ListenPort: 51820})
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, fullConfig(t, router), "MTU")
+
+		resp = send(t, router, "PUT", "/api/server/config", UpdateServerConfigRequest{MTU: mtu(1420)})
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, fullConfig(t, router), "MTU = 1420\n")
+
+		resp = send(t, router, "PUT", "/api/server/config", UpdateServerConfigRequest{MTU: mtu(0)})
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.NotContains(t, fullConfig(t, router), "MTU")
+	})
+
+	t.Run("should reject MTU out of range on update", func(t *testing.T) {
+		_, router, cleanup := setupTestServerAPI(t)
+		defer cleanup()
+
+		for _, value := range []int{575, 1501} {
+			resp := send(t, router, "PUT", "/api/server/config", UpdateServerConfigRequest{MTU: mtu(value)})
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.Contains(t, resp.Body.String(), "MTU must be between 576 and 1500")
+		}
+	})
+}
+
+func TestServerAPI_Endpoint(t *testing.T) {
+	_, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	t.Run("should store endpoint on initialize", func(t *testing.T) {
+		body, _ := json.Marshal(InitializeServerRequest{
+			Network:    "192.168.100.0/24",
+			ListenPort: 51820,
+			Endpoint:   "vpn.example.com",
+		})
+		req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "vpn.example.com", response.Endpoint)
+	})
+
+	updateEndpoint := func(endpoint string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateServerConfigRequest{Endpoint: endpoint})
+		req := httptest.NewRequest("PUT", "/api/server/config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should update endpoint to an IP address", func(t *testing.T) {
+		for _, endpoint := range []string{"203.0.113.10", "2001:db8::1"} {
+			resp := updateEndpoint(endpoint)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var response ServerConfigResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, endpoint, response.Endpoint)
+		}
+	})
+
+	t.Run("should reject invalid endpoints", func(t *testing.T) {
+		for _, endpoint := range []string{"   ", "vpn.example.com:51820", "bad_host", "-vpn.example.com", "http://vpn.example.com"} {
+			resp := updateEndpoint(endpoint)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, endpoint)
+		}
+	})
+
+	updateAlternates := func(endpoints []string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(UpdateServerConfigRequest{AlternateEndpoints: endpoints})
+		req := httptest.NewRequest("PUT", "/api/server/config", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should store alternate endpoints", func(t *testing.T) {
+		resp := updateAlternates([]string{" vpn2.example.com ", "203.0.113.20", "vpn2.example.com"})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response ServerConfigResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, []string{"vpn2.example.com", "203.0.113.20"}, response.AlternateEndpoints)
+	})
+
+	t.Run("should reject invalid alternate endpoints", func(t *testing.T) {
+		resp := updateAlternates([]string{"vpn2.example.com", "vpn3.example.com:51820"})
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestServerAPI_RotateEndpoint(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	clientAPI := NewClientAPI(serverAPI.db, serverAPI.ipPool, serverAPI.wgServer)
+	clientAPI.RegisterRoutes(router)
+	serverAPI.SetClientConfigRenderer(clientAPI)
+
+	rotate := func(rotateReq RotateEndpointRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(rotateReq)
+		req := httptest.NewRequest("PUT", "/api/server/endpoint", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	get := func(path string, target interface{}) {
+		req := httptest.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code, path)
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), target))
+	}
+
+	t.Run("should reject rotation before the server is initialized", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, rotate(RotateEndpointRequest{Endpoint: "vpn.example.com"}).Code)
+	})
+
+	body, _ := json.Marshal(InitializeServerRequest{
+		Network:            "10.0.0.0/24",
+		ListenPort:         51820,
+		Endpoint:           "old.example.com",
+		AlternateEndpoints: []string{"new.example.com", "backup.example.com"},
+	})
+	req := httptest.NewRequest("POST", "/api/server/initialize", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	laptop := newKeyedClient(t, "laptop", "10.0.0.2")
+	require.NoError(t, serverAPI.db.CreateClient(laptop))
+	disabled := newKeyedClient(t, "old-phone", "10.0.0.3")
+	require.NoError(t, serverAPI.db.CreateClient(disabled))
+	disabled.Enabled = false
+	require.NoError(t, serverAPI.db.UpdateClient(disabled))
+
+	t.Run("should update the stored endpoint and mark configs stale", func(t *testing.T) {
+		resp := rotate(RotateEndpointRequest{Endpoint: "new.example.com", IncludeConfigs: true})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response RotateEndpointResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "new.example.com", response.Endpoint)
+		assert.Equal(t, "old.example.com", response.PreviousEndpoint)
+		assert.Equal(t, int64(2), response.StaleClients)
+		assert.NotEmpty(t, response.Guidance)
+
+		require.Len(t, response.Configs, 1)
+		assert.Equal(t, laptop.ID, response.Configs[0].ClientID)
+		assert.Contains(t, response.Configs[0].Config, "Endpoint = new.example.com:51820")
+
+		var config ServerConfigResponse
+		get("/api/server/config", &config)
+		assert.Equal(t, "new.example.com", config.Endpoint)
+		assert.Equal(t, []string{"backup.example.com"}, config.AlternateEndpoints)
+	})
+
+	t.Run("should generate client configs with the new endpoint", func(t *testing.T) {
+		var client ClientResponse
+		get(fmt.Sprintf("/api/clients/%d", laptop.ID), &client)
+		assert.True(t, client.ConfigStale)
+
+		var config ClientConfigResponse
+		get(fmt.Sprintf("/api/clients/%d/config", laptop.ID), &config)
+		assert.Equal(t, "new.example.com:51820", config.Endpoint)
+		assert.Contains(t, config.Config, "Endpoint = new.example.com:51820")
+
+		get(fmt.Sprintf("/api/clients/%d", laptop.ID), &client)
+		assert.False(t, client.ConfigStale)
+	})
+
+	t.Run("should not mark configs stale when the endpoint is unchanged", func(t *testing.T) {
+		resp := rotate(RotateEndpointRequest{Endpoint: "NEW.example.com"})
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response RotateEndpointResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Zero(t, response.StaleClients)
+		assert.Empty(t, response.Configs)
+
+		var client ClientResponse
+		get(fmt.Sprintf("/api/clients/%d", laptop.ID), &client)
+		assert.False(t, client.ConfigStale)
+	})
+
+	t.Run("should reject invalid endpoints", func(t *testing.T) {
+		for _, endpoint := range []string{"", "new.example.com:51820", "bad_host"} {
+			assert.Equal(t, http.StatusBadRequest, rotate(RotateEndpointRequest{Endpoint: endpoint}).Code, endpoint)
+		}
+	})
 }
 
 func TestServerAPI_GetLogs(t *testing.T) {
@@ -302,4 +1157,186 @@ func TestServerAPI_GetLogs(t *testing.T) {
 
 		assert.Empty(t, response.Logs)
 	})
-}
\ No newline at end of file
+}
+
+func TestServerAPI_DeleteLogs(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, ts := range []time.Time{now.AddDate(0, 0, -30), now.AddDate(0, 0, -10), now.AddDate(0, 0, -1), now} {
+		require.NoError(t, serverAPI.db.Create(&database.ConnectionLog{
+			ClientID:  1,
+			Action:    "connect",
+			IPAddress: "10.0.0.2",
+			Timestamp: ts,
+		}).Error)
+	}
+
+	deleteLogs := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/server/logs"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should require a cutoff", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, deleteLogs("").Code)
+		assert.Equal(t, http.StatusBadRequest, deleteLogs("?before=yesterday").Code)
+
+		logs, err := serverAPI.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		assert.Len(t, logs, 4)
+	})
+
+	t.Run("should delete logs recorded before the cutoff", func(t *testing.T) {
+		cutoff := now.AddDate(0, 0, -7)
+		resp := deleteLogs("?before=" + cutoff.Format(time.RFC3339))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response DeleteLogsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, int64(2), response.Deleted)
+		assert.True(t, response.Before.Equal(cutoff))
+
+		logs, err := serverAPI.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		for _, log := range logs {
+			assert.False(t, log.Timestamp.Before(cutoff))
+		}
+	})
+
+	t.Run("should report nothing deleted when no logs are older", func(t *testing.T) {
+		resp := deleteLogs("?before=" + now.AddDate(0, 0, -7).Format(time.RFC3339))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response DeleteLogsResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, int64(0), response.Deleted)
+	})
+}
+
+func TestServerAPI_GetFullConfig(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	t.Run("should return not found before initialization", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/server/full-config", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Server is not initialized")
+
+		_, err := serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, serverAPI.db.CreateServerConfig(&database.ServerConfig{
+		PrivateKey:       keyPair.PrivateKey,
+		PublicKey:        keyPair.PublicKey,
+		ListenPort:       51820,
+		Network:          "10.0.0.0/24",
+		Interface:        "wg0",
+		DefaultKeepalive: 30,
+	}))
+
+	keepalive := 15
+	clients := []*database.Client{
+		{Name: "laptop", PublicKey: "laptop-public-key", PrivateKey: "laptop-private-key", IPAddress: "10.0.0.2", Enabled: true},
+		{Name: "phone", PublicKey: "phone-public-key", PrivateKey: "phone-private-key", IPAddress: "10.0.0.3", Enabled: true, PersistentKeepalive: &keepalive},
+		{Name: "old", PublicKey: "old-public-key", PrivateKey: "old-private-key", IPAddress: "10.0.0.4", Enabled: true},
+	}
+	for _, client := range clients {
+		require.NoError(t, serverAPI.db.CreateClient(client))
+	}
+	// Disable one client; GORM skips zero values on create so update explicitly
+	clients[2].Enabled = false
+	require.NoError(t, serverAPI.db.UpdateClient(clients[2]))
+
+	t.Run("should return interface and a peer per enabled client", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/server/full-config", nil)
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "attachment; filename=wg0.conf")
+
+		config := resp.Body.String()
+		assert.Contains(t, config, "[Interface]")
+		assert.Contains(t, config, "PrivateKey = ")
+		assert.Contains(t, config, "ListenPort = 51820")
+		assert.Equal(t, 2, strings.Count(config, "[Peer]"))
+		assert.Contains(t, config, "PublicKey = laptop-public-key\nAllowedIPs = 10.0.0.2/32\nPersistentKeepalive = 30\n")
+		assert.Contains(t, config, "PublicKey = phone-public-key\nAllowedIPs = 10.0.0.3/32\nPersistentKeepalive = 15\n")
+		assert.NotContains(t, config, "old-public-key")
+		assert.NotContains(t, config, "laptop-private-key")
+	})
+}
+
+func TestServerAPI_VerifyKeys(t *testing.T) {
+	serverAPI, router, cleanup := setupTestServerAPI(t)
+	defer cleanup()
+
+	t.Run("should return not found before initialization", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/server/verify-keys", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Server is not initialized")
+
+		_, err := serverAPI.db.GetServerConfig()
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+	serverConfig := &database.ServerConfig{
+		PrivateKey: keyPair.PrivateKey,
+		PublicKey:  keyPair.PublicKey,
+		ListenPort: 51820,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+	}
+	require.NoError(t, serverAPI.db.CreateServerConfig(serverConfig))
+
+	verify := func() VerifyKeysResponse {
+		req := httptest.NewRequest("GET", "/api/server/verify-keys", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response VerifyKeysResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("should report matching keys as valid", func(t *testing.T) {
+		response := verify()
+
+		assert.True(t, response.Valid)
+		assert.Equal(t, keyPair.PublicKey, response.StoredPublicKey)
+		assert.Equal(t, keyPair.PublicKey, response.DerivedPublicKey)
+	})
+
+	t.Run("should flag a mismatched stored public key", func(t *testing.T) {
+		otherPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		serverConfig.PublicKey = otherPair.PublicKey
+		require.NoError(t, serverAPI.db.UpdateServerConfig(serverConfig))
+
+		response := verify()
+
+		assert.False(t, response.Valid)
+		assert.Equal(t, otherPair.PublicKey, response.StoredPublicKey)
+		assert.Equal(t, keyPair.PublicKey, response.DerivedPublicKey)
+		assert.Contains(t, response.Message, "does not match")
+	})
+}
@@ -0,0 +1,88 @@
+// Package auth provides authentication and authorization functionality for the VPN server.
+// It implements JWT-based authentication, user management, and session handling
+// with support for password hashing and middleware integration.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIKeyHeader is the request header carrying an API key.
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyPrefix marks API keys so they are recognizable in scripts and secret scanners.
+const apiKeyPrefix = "vpnk_"
+
+// API key scopes
+const (
+	ScopeRead  = "read"  // Allows safe requests (GET, HEAD, OPTIONS)
+	ScopeWrite = "write" // Allows requests that modify state
+)
+
+// ErrInvalidAPIKey is returned when an API key is unknown, revoked or belongs
+// to an inactive user.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyIdentity is the user identity an API key acts as.
+type APIKeyIdentity struct {
+	KeyID    uint     // ID of the API key
+	UserID   uint     // Owner of the key
+	Username string   // Username of the owner
+	Role     string   // Current role of the owner
+	Scopes   []string // Scopes granted to the key
+}
+
+// HasScope reports whether the key was granted the scope.
+func (i *APIKeyIdentity) HasScope(scope string) bool {
+	for _, granted := range i.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyResolver looks up the identity behind an API key.
+// It returns ErrInvalidAPIKey for keys that must be rejected.
+type APIKeyResolver interface {
+	ResolveAPIKey(key string) (*APIKeyIdentity, error)
+}
+
+// IsValidScope reports whether the scope is one of the known API key scopes.
+func IsValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite
+}
+
+// GenerateAPIKey creates a random API key for automation.
+// Only the returned hash should be stored; the key is shown to its owner once.
+// Returns the key, its hash, or an error if random generation fails.
+func GenerateAPIKey() (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash under which an API key is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// requiredScope returns the scope needed for a request with the given method.
+func requiredScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ScopeRead
+	default:
+		return ScopeWrite
+	}
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIKeyResolver resolves keys from a map instead of a database.
+type fakeAPIKeyResolver struct {
+	keys map[string]*APIKeyIdentity
+	err  error
+}
+
+func (f *fakeAPIKeyResolver) ResolveAPIKey(key string) (*APIKeyIdentity, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	identity, ok := f.keys[key]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return identity, nil
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	t.Run("should generate prefixed key with matching hash", func(t *testing.T) {
+		key, hash, err := GenerateAPIKey()
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(key, "vpnk_"))
+		assert.Equal(t, HashAPIKey(key), hash)
+		assert.NotContains(t, hash, key)
+	})
+
+	t.Run("should generate unique keys", func(t *testing.T) {
+		key1, _, err := GenerateAPIKey()
+		require.NoError(t, err)
+		key2, _, err := GenerateAPIKey()
+		require.NoError(t, err)
+
+		assert.NotEqual(t, key1, key2)
+	})
+}
+
+func TestAuthMiddleware_RequireAuthAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	resolver := &fakeAPIKeyResolver{keys: map[string]*APIKeyIdentity{
+		"full-key":     {KeyID: 1, UserID: 7, Username: "ci", Role: RoleAdmin, Scopes: []string{ScopeRead, ScopeWrite}},
+		"readonly-key": {KeyID: 2, UserID: 8, Username: "reporter", Scopes: []string{ScopeRead}},
+	}}
+	middleware := NewAuthMiddleware(NewAuthManager("test-secret"))
+	middleware.SetAPIKeyResolver(resolver)
+
+	router := gin.New()
+	router.Use(middleware.RequireAuth())
+	handler := func(c *gin.Context) {
+		userID, _ := GetUserID(c)
+		role, _ := GetUserRole(c)
+		_, viaKey := GetAPIKeyIdentity(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": role, "via_key": viaKey})
+	}
+	router.GET("/protected", handler)
+	router.POST("/protected", handler)
+
+	request := func(method, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/protected", nil)
+		req.Header.Set(APIKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should authenticate as the key owner", func(t *testing.T) {
+		w := request("POST", "full-key")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id":7,"role":"admin","via_key":true}`, w.Body.String())
+	})
+
+	t.Run("should default role of owner without role", func(t *testing.T) {
+		w := request("GET", "readonly-key")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"role":"user"`)
+	})
+
+	t.Run("should reject write request without write scope", func(t *testing.T) {
+		w := request("POST", "readonly-key")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "API key lacks the write scope")
+	})
+
+	t.Run("should reject unknown key", func(t *testing.T) {
+		w := request("GET", "unknown-key")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid or revoked API key")
+	})
+
+	t.Run("should fail when key cannot be verified", func(t *testing.T) {
+		resolver.err = errors.New("database locked")
+		defer func() { resolver.err = nil }()
+
+		w := request("GET", "full-key")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("should ignore API keys without resolver", func(t *testing.T) {
+		router := gin.New()
+		router.Use(NewAuthMiddleware(NewAuthManager("test-secret")).RequireAuth())
+		router.GET("/protected", handler)
+
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set(APIKeyHeader, "full-key")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Authorization header is required")
+	})
+}
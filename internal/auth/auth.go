@@ -5,7 +5,10 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,26 +19,40 @@ import (
 // AuthManager handles authentication operations including JWT token management
 // and password hashing. It provides a secure authentication system for the VPN server.
 type AuthManager struct {
-	jwtSecret   string        // Secret key for JWT token signing and verification
-	tokenExpiry time.Duration // Duration for which tokens remain valid
+	jwtSecret      string          // Secret key for JWT token signing and verification
+	tokenExpiry    time.Duration   // Duration for which tokens remain valid
+	blacklist      *TokenBlacklist // Revoked tokens that must be rejected before they expire
+	passwordPolicy *PasswordPolicy // Requirements for new passwords
 }
 
+// ErrTokenRevoked is returned when validating a token that has been revoked by logout.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 // Claims represents the JWT claims structure for authenticated users.
 // It contains user identification and authorization information embedded in tokens.
 type Claims struct {
-	UserID   uint   `json:"user_id"`  // Unique identifier for the user
-	Username string `json:"username"` // Username for display and identification
+	UserID   uint   `json:"user_id"`        // Unique identifier for the user
+	Username string `json:"username"`       // Username for display and identification
+	Role     string `json:"role,omitempty"` // User role: "admin" or "user"
 	jwt.RegisteredClaims
 }
 
+// User roles carried in the token claims.
+const (
+	RoleUser  = "user"  // Regular user; also assumed for tokens issued without a role
+	RoleAdmin = "admin" // Administrator
+)
+
 // NewAuthManager creates a new authentication manager with default settings.
 // The default token expiry is set to 24 hours for security balance between
 // usability and protection against token theft.
 // Returns a pointer to the newly created AuthManager.
 func NewAuthManager(jwtSecret string) *AuthManager {
 	return &AuthManager{
-		jwtSecret:   jwtSecret,
-		tokenExpiry: 24 * time.Hour,
+		jwtSecret:      jwtSecret,
+		tokenExpiry:    24 * time.Hour,
+		blacklist:      NewTokenBlacklist(),
+		passwordPolicy: DefaultPasswordPolicy(),
 	}
 }
 
@@ -44,11 +61,44 @@ func NewAuthManager(jwtSecret string) *AuthManager {
 // Returns a pointer to the newly created AuthManager.
 func NewAuthManagerWithConfig(jwtSecret string, tokenExpiry time.Duration) *AuthManager {
 	return &AuthManager{
-		jwtSecret:   jwtSecret,
-		tokenExpiry: tokenExpiry,
+		jwtSecret:      jwtSecret,
+		tokenExpiry:    tokenExpiry,
+		blacklist:      NewTokenBlacklist(),
+		passwordPolicy: DefaultPasswordPolicy(),
 	}
 }
 
+// SetBlacklist replaces the token blacklist, e.g. with one backed by the database.
+func (am *AuthManager) SetBlacklist(blacklist *TokenBlacklist) {
+	am.blacklist = blacklist
+}
+
+// GetTokenExpiry returns the lifetime of newly generated tokens.
+func (am *AuthManager) GetTokenExpiry() time.Duration {
+	return am.tokenExpiry
+}
+
+// GetBlacklist returns the token blacklist consulted by ValidateToken.
+func (am *AuthManager) GetBlacklist() *TokenBlacklist {
+	return am.blacklist
+}
+
+// SetPasswordPolicy replaces the requirements checked by ValidatePassword.
+func (am *AuthManager) SetPasswordPolicy(policy *PasswordPolicy) {
+	am.passwordPolicy = policy
+}
+
+// GetPasswordPolicy returns the requirements checked by ValidatePassword.
+func (am *AuthManager) GetPasswordPolicy() *PasswordPolicy {
+	return am.passwordPolicy
+}
+
+// ValidatePassword checks a new password against the password policy.
+// Returns a *PasswordPolicyError listing the unmet requirements, or nil.
+func (am *AuthManager) ValidatePassword(password string) error {
+	return am.passwordPolicy.Validate(password)
+}
+
 // HashPassword creates a bcrypt hash of the provided password.
 // It uses bcrypt's default cost factor for security while maintaining reasonable performance.
 // The salt is automatically generated and included in the hash.
@@ -70,19 +120,31 @@ func (am *AuthManager) VerifyPassword(password, hash string) bool {
 }
 
 // GenerateToken creates a new JWT token for the specified user.
-// The token includes user identification claims and is signed with the manager's secret.
+// The token includes user identification and role claims and is signed with the manager's secret.
+// An empty role is encoded as RoleUser. Each token gets a random ID (jti) so it can be revoked.
 // The token will expire after the configured duration.
 // Returns the signed JWT token string or an error if generation fails.
-func (am *AuthManager) GenerateToken(userID uint, username string) (string, error) {
+func (am *AuthManager) GenerateToken(userID uint, username, role string) (string, error) {
+	if role == "" {
+		role = RoleUser
+	}
+
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+
 	claims := &Claims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(am.tokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "vpn-server",
 			Subject:   fmt.Sprintf("user-%d", userID),
+			ID:        tokenID,
 		},
 	}
 
@@ -97,6 +159,8 @@ func (am *AuthManager) GenerateToken(userID uint, username string) (string, erro
 
 // ValidateToken parses and validates a JWT token string.
 // It verifies the token signature, expiration, and other standard claims.
+// Tokens issued before roles were embedded carry no role and are treated as RoleUser.
+// Revoked tokens are rejected with ErrTokenRevoked.
 // Returns the parsed claims if the token is valid, or an error if validation fails.
 func (am *AuthManager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -112,6 +176,12 @@ func (am *AuthManager) ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.ID != "" && am.blacklist.IsRevoked(claims.ID) {
+			return nil, ErrTokenRevoked
+		}
+		if claims.Role == "" {
+			claims.Role = RoleUser
+		}
 		return claims, nil
 	}
 
@@ -129,7 +199,32 @@ func (am *AuthManager) RefreshToken(tokenString string) (string, error) {
 	}
 
 	// Generate new token with the same user information
-	return am.GenerateToken(claims.UserID, claims.Username)
+	return am.GenerateToken(claims.UserID, claims.Username, claims.Role)
+}
+
+// RevokeToken blacklists the token described by the claims until it expires,
+// so it is rejected even though its signature is still valid.
+// Returns an error if the token has no ID or the revocation cannot be persisted.
+func (am *AuthManager) RevokeToken(claims *Claims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("token has no ID and cannot be revoked")
+	}
+
+	expiresAt := time.Now().Add(am.tokenExpiry)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return am.blacklist.Revoke(claims.ID, expiresAt)
+}
+
+// generateTokenID creates a random identifier for the jti claim.
+func generateTokenID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
 }
 
 // Valid implements the jwt.Claims interface to validate custom claims.
@@ -154,4 +249,23 @@ func GenerateSecureSecret() (string, error) {
 		return "", fmt.Errorf("failed to generate secure secret: %w", err)
 	}
 	return base64.URLEncoding.EncodeToString(bytes), nil
-}
\ No newline at end of file
+}
+
+// GenerateResetToken creates a random single-use token for password resets.
+// Only the returned hash should be stored, so a leaked database cannot be used
+// to reset passwords; the token itself is handed to the user.
+// Returns the token, its hash, or an error if random generation fails.
+func GenerateResetToken() (string, string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashResetToken(token), nil
+}
+
+// HashResetToken returns the hex-encoded SHA-256 hash under which a reset token is stored.
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -101,7 +101,7 @@ func TestAuthManager_GenerateToken(t *testing.T) {
 		userID := uint(123)
 		username := "testuser"
 		
-		token, err := manager.GenerateToken(userID, username)
+		token, err := manager.GenerateToken(userID, username, RoleUser)
 		
 		require.NoError(t, err)
 		assert.NotEmpty(t, token)
@@ -109,10 +109,10 @@ func TestAuthManager_GenerateToken(t *testing.T) {
 	})
 
 	t.Run("should generate different tokens for different users", func(t *testing.T) {
-		token1, err := manager.GenerateToken(1, "user1")
+		token1, err := manager.GenerateToken(1, "user1", RoleUser)
 		require.NoError(t, err)
 		
-		token2, err := manager.GenerateToken(2, "user2")
+		token2, err := manager.GenerateToken(2, "user2", RoleUser)
 		require.NoError(t, err)
 		
 		assert.NotEqual(t, token1, token2)
@@ -126,13 +126,50 @@ func TestAuthManager_ValidateToken(t *testing.T) {
 		userID := uint(123)
 		username := "testuser"
 		
-		token, err := manager.GenerateToken(userID, username)
+		token, err := manager.GenerateToken(userID, username, RoleUser)
 		require.NoError(t, err)
 		
 		claims, err := manager.ValidateToken(token)
 		require.NoError(t, err)
 		assert.Equal(t, userID, claims.UserID)
 		assert.Equal(t, username, claims.Username)
+		assert.Equal(t, RoleUser, claims.Role)
+	})
+
+	t.Run("should carry admin role", func(t *testing.T) {
+		token, err := manager.GenerateToken(1, "admin", RoleAdmin)
+		require.NoError(t, err)
+		
+		claims, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, RoleAdmin, claims.Role)
+	})
+
+	t.Run("should default empty role to user", func(t *testing.T) {
+		token, err := manager.GenerateToken(123, "testuser", "")
+		require.NoError(t, err)
+		
+		claims, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, RoleUser, claims.Role)
+	})
+
+	t.Run("should treat token without role claim as user", func(t *testing.T) {
+		// Tokens issued before roles were embedded carry no role claim
+		legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+			UserID:   123,
+			Username: "testuser",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+			},
+		})
+		token, err := legacy.SignedString([]byte("test-secret"))
+		require.NoError(t, err)
+		
+		claims, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, RoleUser, claims.Role)
 	})
 
 	t.Run("should reject invalid token", func(t *testing.T) {
@@ -146,7 +183,7 @@ func TestAuthManager_ValidateToken(t *testing.T) {
 		wrongManager := NewAuthManager("wrong-secret")
 		rightManager := NewAuthManager("right-secret")
 		
-		token, err := wrongManager.GenerateToken(123, "testuser")
+		token, err := wrongManager.GenerateToken(123, "testuser", RoleUser)
 		require.NoError(t, err)
 		
 		_, err = rightManager.ValidateToken(token)
@@ -157,7 +194,7 @@ func TestAuthManager_ValidateToken(t *testing.T) {
 		// Create manager with very short expiry
 		shortManager := NewAuthManagerWithConfig("test-secret", 1*time.Millisecond)
 		
-		token, err := shortManager.GenerateToken(123, "testuser")
+		token, err := shortManager.GenerateToken(123, "testuser", RoleUser)
 		require.NoError(t, err)
 		
 		// Wait for token to expire
@@ -175,7 +212,7 @@ func TestAuthManager_RefreshToken(t *testing.T) {
 		userID := uint(123)
 		username := "testuser"
 		
-		originalToken, err := manager.GenerateToken(userID, username)
+		originalToken, err := manager.GenerateToken(userID, username, RoleAdmin)
 		require.NoError(t, err)
 		
 		// Wait to ensure different timestamps
@@ -190,6 +227,7 @@ func TestAuthManager_RefreshToken(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, userID, claims.UserID)
 		assert.Equal(t, username, claims.Username)
+		assert.Equal(t, RoleAdmin, claims.Role)
 		
 		// Also verify that both tokens are valid (for grace period)
 		originalClaims, err := manager.ValidateToken(originalToken)
@@ -205,6 +243,46 @@ func TestAuthManager_RefreshToken(t *testing.T) {
 	})
 }
 
+func TestAuthManager_RevokeToken(t *testing.T) {
+	manager := NewAuthManager("test-secret")
+
+	t.Run("should reject revoked token", func(t *testing.T) {
+		token, err := manager.GenerateToken(123, "testuser", RoleUser)
+		require.NoError(t, err)
+
+		claims, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+		require.NotEmpty(t, claims.ID)
+
+		require.NoError(t, manager.RevokeToken(claims))
+
+		_, err = manager.ValidateToken(token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		_, err = manager.RefreshToken(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("should not affect other tokens of the same user", func(t *testing.T) {
+		first, err := manager.GenerateToken(123, "testuser", RoleUser)
+		require.NoError(t, err)
+		second, err := manager.GenerateToken(123, "testuser", RoleUser)
+		require.NoError(t, err)
+
+		claims, err := manager.ValidateToken(first)
+		require.NoError(t, err)
+		require.NoError(t, manager.RevokeToken(claims))
+
+		_, err = manager.ValidateToken(second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should refuse tokens without ID", func(t *testing.T) {
+		err := manager.RevokeToken(&Claims{UserID: 123, Username: "testuser"})
+		assert.Error(t, err)
+	})
+}
+
 func TestClaims_Valid(t *testing.T) {
 	t.Run("should validate non-expired claims", func(t *testing.T) {
 		claims := &Claims{
@@ -251,4 +329,21 @@ func TestGenerateSecureSecret(t *testing.T) {
 		
 		assert.NotEqual(t, secret1, secret2)
 	})
-}
\ No newline at end of file
+}
+func TestGenerateResetToken(t *testing.T) {
+	t.Run("should return token with matching hash", func(t *testing.T) {
+		token, hash, err := GenerateResetToken()
+		require.NoError(t, err)
+		assert.NotEmpty(t, token)
+		assert.Equal(t, HashResetToken(token), hash)
+		assert.NotEqual(t, token, hash)
+	})
+
+	t.Run("should generate different tokens", func(t *testing.T) {
+		first, _, err := GenerateResetToken()
+		require.NoError(t, err)
+		second, _, err := GenerateResetToken()
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+}
@@ -0,0 +1,157 @@
+// Package auth provides authentication and authorization functionality for the VPN server.
+// It implements JWT-based authentication, user management, and session handling
+// with support for password hashing and middleware integration.
+package auth
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RevokedTokenStore persists revoked token IDs so logouts survive restarts.
+// It is satisfied by database.Database.
+type RevokedTokenStore interface {
+	SaveRevokedToken(tokenID string, expiresAt time.Time) error
+	ListRevokedTokens() (map[string]time.Time, error)
+	DeleteRevokedTokensBefore(before time.Time) (int64, error)
+}
+
+// TokenBlacklist tracks revoked tokens by their JWT ID (jti) until they expire.
+// Entries are kept in memory and, if a store is configured, persisted as well.
+// Expired entries are removed by Cleanup, which StartCleanup runs periodically.
+type TokenBlacklist struct {
+	mutex   sync.RWMutex
+	entries map[string]time.Time // Token ID -> token expiry
+	store   RevokedTokenStore    // Optional persistent store (nil keeps entries in memory only)
+	stop    chan struct{}        // Closed to stop the periodic cleanup
+}
+
+// NewTokenBlacklist creates an in-memory token blacklist.
+// Returns a pointer to the newly created TokenBlacklist.
+func NewTokenBlacklist() *TokenBlacklist {
+	return &TokenBlacklist{
+		entries: make(map[string]time.Time),
+	}
+}
+
+// NewTokenBlacklistWithStore creates a token blacklist backed by a persistent store.
+// Unexpired revocations already in the store are loaded into memory.
+// Returns the blacklist or an error if the stored revocations cannot be loaded.
+func NewTokenBlacklistWithStore(store RevokedTokenStore) (*TokenBlacklist, error) {
+	stored, err := store.ListRevokedTokens()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revoked tokens: %w", err)
+	}
+
+	blacklist := NewTokenBlacklist()
+	blacklist.store = store
+
+	now := time.Now()
+	for tokenID, expiresAt := range stored {
+		if expiresAt.After(now) {
+			blacklist.entries[tokenID] = expiresAt
+		}
+	}
+
+	return blacklist, nil
+}
+
+// Revoke adds a token ID to the blacklist until the given expiry.
+// Returns an error if the revocation cannot be persisted; the token is
+// still rejected by this process in that case.
+func (b *TokenBlacklist) Revoke(tokenID string, expiresAt time.Time) error {
+	b.mutex.Lock()
+	b.entries[tokenID] = expiresAt
+	b.mutex.Unlock()
+
+	if b.store != nil {
+		if err := b.store.SaveRevokedToken(tokenID, expiresAt); err != nil {
+			return fmt.Errorf("failed to persist revoked token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// IsRevoked reports whether the token ID has been revoked and not yet expired.
+func (b *TokenBlacklist) IsRevoked(tokenID string) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	expiresAt, ok := b.entries[tokenID]
+	return ok && expiresAt.After(time.Now())
+}
+
+// Count returns the number of entries currently held in memory.
+func (b *TokenBlacklist) Count() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	return len(b.entries)
+}
+
+// Cleanup removes entries whose tokens expired before now, since expired
+// tokens are rejected anyway. Returns the number of in-memory entries removed
+// or an error if the store could not be cleaned.
+func (b *TokenBlacklist) Cleanup(now time.Time) (int, error) {
+	b.mutex.Lock()
+	removed := 0
+	for tokenID, expiresAt := range b.entries {
+		if !expiresAt.After(now) {
+			delete(b.entries, tokenID)
+			removed++
+		}
+	}
+	b.mutex.Unlock()
+
+	if b.store != nil {
+		if _, err := b.store.DeleteRevokedTokensBefore(now); err != nil {
+			return removed, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+		}
+	}
+
+	return removed, nil
+}
+
+// StartCleanup runs Cleanup every interval in the background until StopCleanup
+// is called. Calling it while a cleanup is already running has no effect.
+func (b *TokenBlacklist) StartCleanup(interval time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	b.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := b.Cleanup(time.Now()); err != nil {
+					log.Printf("Token blacklist cleanup error: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopCleanup stops the periodic cleanup started by StartCleanup.
+// It is safe to call when no cleanup is running.
+func (b *TokenBlacklist) StopCleanup() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+}
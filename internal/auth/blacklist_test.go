@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRevokedTokenStore keeps revoked tokens in a map instead of a database.
+type fakeRevokedTokenStore struct {
+	tokens  map[string]time.Time
+	loadErr error
+}
+
+func newFakeRevokedTokenStore() *fakeRevokedTokenStore {
+	return &fakeRevokedTokenStore{tokens: make(map[string]time.Time)}
+}
+
+func (f *fakeRevokedTokenStore) SaveRevokedToken(tokenID string, expiresAt time.Time) error {
+	f.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (f *fakeRevokedTokenStore) ListRevokedTokens() (map[string]time.Time, error) {
+	if f.loadErr != nil {
+		return nil, f.loadErr
+	}
+	tokens := make(map[string]time.Time, len(f.tokens))
+	for tokenID, expiresAt := range f.tokens {
+		tokens[tokenID] = expiresAt
+	}
+	return tokens, nil
+}
+
+func (f *fakeRevokedTokenStore) DeleteRevokedTokensBefore(before time.Time) (int64, error) {
+	var deleted int64
+	for tokenID, expiresAt := range f.tokens {
+		if expiresAt.Before(before) {
+			delete(f.tokens, tokenID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func TestTokenBlacklist_Revoke(t *testing.T) {
+	t.Run("should report revoked token until it expires", func(t *testing.T) {
+		blacklist := NewTokenBlacklist()
+		require.NoError(t, blacklist.Revoke("token-1", time.Now().Add(time.Hour)))
+
+		assert.True(t, blacklist.IsRevoked("token-1"))
+		assert.False(t, blacklist.IsRevoked("token-2"))
+	})
+
+	t.Run("should not report expired revocations", func(t *testing.T) {
+		blacklist := NewTokenBlacklist()
+		require.NoError(t, blacklist.Revoke("token-1", time.Now().Add(-time.Minute)))
+
+		assert.False(t, blacklist.IsRevoked("token-1"))
+	})
+
+	t.Run("should persist revocations to the store", func(t *testing.T) {
+		store := newFakeRevokedTokenStore()
+		blacklist, err := NewTokenBlacklistWithStore(store)
+		require.NoError(t, err)
+
+		expiresAt := time.Now().Add(time.Hour)
+		require.NoError(t, blacklist.Revoke("token-1", expiresAt))
+		assert.Equal(t, expiresAt, store.tokens["token-1"])
+	})
+}
+
+func TestNewTokenBlacklistWithStore(t *testing.T) {
+	t.Run("should load unexpired revocations", func(t *testing.T) {
+		store := newFakeRevokedTokenStore()
+		store.tokens["active"] = time.Now().Add(time.Hour)
+		store.tokens["expired"] = time.Now().Add(-time.Hour)
+
+		blacklist, err := NewTokenBlacklistWithStore(store)
+		require.NoError(t, err)
+
+		assert.True(t, blacklist.IsRevoked("active"))
+		assert.Equal(t, 1, blacklist.Count())
+	})
+
+	t.Run("should return error when store cannot be read", func(t *testing.T) {
+		store := newFakeRevokedTokenStore()
+		store.loadErr = errors.New("database locked")
+
+		_, err := NewTokenBlacklistWithStore(store)
+		assert.Error(t, err)
+	})
+}
+
+func TestTokenBlacklist_Cleanup(t *testing.T) {
+	t.Run("should remove expired entries from memory and store", func(t *testing.T) {
+		store := newFakeRevokedTokenStore()
+		blacklist, err := NewTokenBlacklistWithStore(store)
+		require.NoError(t, err)
+
+		now := time.Now()
+		require.NoError(t, blacklist.Revoke("expired", now.Add(-time.Minute)))
+		require.NoError(t, blacklist.Revoke("active", now.Add(time.Hour)))
+
+		removed, err := blacklist.Cleanup(now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, removed)
+		assert.Equal(t, 1, blacklist.Count())
+		assert.True(t, blacklist.IsRevoked("active"))
+		assert.NotContains(t, store.tokens, "expired")
+		assert.Contains(t, store.tokens, "active")
+	})
+
+	t.Run("should clean up periodically once started", func(t *testing.T) {
+		blacklist := NewTokenBlacklist()
+		require.NoError(t, blacklist.Revoke("expiring", time.Now().Add(20*time.Millisecond)))
+
+		blacklist.StartCleanup(10 * time.Millisecond)
+		defer blacklist.StopCleanup()
+
+		assert.Eventually(t, func() bool {
+			return blacklist.Count() == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should allow stopping without a running cleanup", func(t *testing.T) {
+		blacklist := NewTokenBlacklist()
+		assert.NotPanics(t, blacklist.StopCleanup)
+	})
+}
@@ -0,0 +1,152 @@
+// Package auth provides authentication and authorization functionality for the VPN server.
+// It implements JWT-based authentication, user management, and session handling
+// with support for password hashing and middleware integration.
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginLimiterConfig controls brute-force protection for logins.
+type LoginLimiterConfig struct {
+	MaxAttempts     int           `json:"max_attempts"`     // Failed attempts within Window that trigger a lockout
+	Window          time.Duration `json:"window"`           // Period over which failed attempts are counted
+	LockoutDuration time.Duration `json:"lockout_duration"` // How long logins are refused once locked
+}
+
+// DefaultLoginLimiterConfig returns the default login limiter configuration.
+// Five failed attempts within 15 minutes lock the username or IP for 15 minutes.
+func DefaultLoginLimiterConfig() *LoginLimiterConfig {
+	return &LoginLimiterConfig{
+		MaxAttempts:     5,
+		Window:          15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// LoginLimiter tracks failed login attempts per username and per remote IP
+// and temporarily locks either once too many attempts fail. Attempts are kept
+// in memory and forgotten once they fall outside the counting window.
+type LoginLimiter struct {
+	mutex    sync.Mutex
+	config   *LoginLimiterConfig
+	attempts map[string]*loginAttempts // Keyed by "user:<name>" or "ip:<address>"
+	now      func() time.Time          // Clock, replaceable in tests
+}
+
+// loginAttempts holds the recent failures of a username or IP.
+type loginAttempts struct {
+	failures    []time.Time // Failed attempts within the counting window
+	lockedUntil time.Time   // Zero unless a lockout is in effect
+}
+
+// NewLoginLimiter creates a login limiter with default settings.
+// Returns a pointer to the newly created LoginLimiter.
+func NewLoginLimiter() *LoginLimiter {
+	return NewLoginLimiterWithConfig(DefaultLoginLimiterConfig())
+}
+
+// NewLoginLimiterWithConfig creates a login limiter with custom settings.
+// Returns a pointer to the newly created LoginLimiter.
+func NewLoginLimiterWithConfig(config *LoginLimiterConfig) *LoginLimiter {
+	return &LoginLimiter{
+		config:   config,
+		attempts: make(map[string]*loginAttempts),
+		now:      time.Now,
+	}
+}
+
+// Check reports whether logins for the username or from the IP are locked.
+// Returns the remaining lockout duration and true while a lockout is in effect.
+func (l *LoginLimiter) Check(username, remoteIP string) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	var remaining time.Duration
+	for _, key := range limiterKeys(username, remoteIP) {
+		if entry, ok := l.attempts[key]; ok && entry.lockedUntil.After(now) {
+			if left := entry.lockedUntil.Sub(now); left > remaining {
+				remaining = left
+			}
+		}
+	}
+
+	return remaining, remaining > 0
+}
+
+// RecordFailure counts a failed login for the username and the IP.
+// Reaching MaxAttempts within Window locks the affected key for LockoutDuration.
+// Returns true if the failure caused a lockout.
+func (l *LoginLimiter) RecordFailure(username, remoteIP string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	locked := false
+	for _, key := range limiterKeys(username, remoteIP) {
+		entry, ok := l.attempts[key]
+		if !ok {
+			entry = &loginAttempts{}
+			l.attempts[key] = entry
+		}
+
+		entry.failures = append(entry.failures, now)
+		if len(entry.failures) >= l.config.MaxAttempts {
+			entry.lockedUntil = now.Add(l.config.LockoutDuration)
+			entry.failures = nil
+			locked = true
+		}
+	}
+
+	return locked
+}
+
+// Reset forgets the failed attempts of the username after a successful login.
+// Failures recorded for the IP are kept until they expire, so an attacker
+// cannot clear them by logging in to an account they own.
+func (l *LoginLimiter) Reset(username string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.attempts, userLimiterKey(username))
+}
+
+// prune drops failures outside the counting window and entries that are
+// neither locked nor have recent failures, so the map doesn't grow unbounded.
+// The caller must hold the mutex.
+func (l *LoginLimiter) prune(now time.Time) {
+	cutoff := now.Add(-l.config.Window)
+	for key, entry := range l.attempts {
+		recent := entry.failures[:0]
+		for _, failure := range entry.failures {
+			if failure.After(cutoff) {
+				recent = append(recent, failure)
+			}
+		}
+		entry.failures = recent
+
+		if len(entry.failures) == 0 && !entry.lockedUntil.After(now) {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+// limiterKeys returns the tracking keys for a login attempt. Usernames are
+// compared case-insensitively; an empty IP is not tracked.
+func limiterKeys(username, remoteIP string) []string {
+	keys := []string{userLimiterKey(username)}
+	if remoteIP != "" {
+		keys = append(keys, "ip:"+remoteIP)
+	}
+	return keys
+}
+
+// userLimiterKey returns the tracking key of a username.
+func userLimiterKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLoginLimiter creates a limiter locking after 3 failures in 15 minutes
+// for 10 minutes, driven by the returned clock.
+func newTestLoginLimiter() (*LoginLimiter, *time.Time) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	limiter := NewLoginLimiterWithConfig(&LoginLimiterConfig{
+		MaxAttempts:     3,
+		Window:          15 * time.Minute,
+		LockoutDuration: 10 * time.Minute,
+	})
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLoginLimiter_RecordFailure(t *testing.T) {
+	t.Run("should lock username after max attempts", func(t *testing.T) {
+		limiter, _ := newTestLoginLimiter()
+
+		assert.False(t, limiter.RecordFailure("alice", "192.0.2.1"))
+		assert.False(t, limiter.RecordFailure("alice", "192.0.2.2"))
+		_, locked := limiter.Check("alice", "192.0.2.3")
+		assert.False(t, locked)
+
+		assert.True(t, limiter.RecordFailure("alice", "192.0.2.3"))
+		remaining, locked := limiter.Check("alice", "192.0.2.4")
+		assert.True(t, locked)
+		assert.Equal(t, 10*time.Minute, remaining)
+	})
+
+	t.Run("should lock IP across usernames", func(t *testing.T) {
+		limiter, _ := newTestLoginLimiter()
+
+		for _, username := range []string{"alice", "bob", "carol"} {
+			limiter.RecordFailure(username, "192.0.2.1")
+		}
+
+		_, locked := limiter.Check("dave", "192.0.2.1")
+		assert.True(t, locked)
+		_, locked = limiter.Check("dave", "192.0.2.2")
+		assert.False(t, locked)
+	})
+
+	t.Run("should treat usernames case-insensitively", func(t *testing.T) {
+		limiter, _ := newTestLoginLimiter()
+
+		limiter.RecordFailure("Alice", "")
+		limiter.RecordFailure("ALICE", "")
+		limiter.RecordFailure("alice", "")
+
+		_, locked := limiter.Check("alice", "")
+		assert.True(t, locked)
+	})
+
+	t.Run("should forget failures outside the window", func(t *testing.T) {
+		limiter, now := newTestLoginLimiter()
+
+		limiter.RecordFailure("alice", "")
+		limiter.RecordFailure("alice", "")
+		*now = now.Add(20 * time.Minute)
+
+		assert.False(t, limiter.RecordFailure("alice", ""))
+		_, locked := limiter.Check("alice", "")
+		assert.False(t, locked)
+	})
+
+	t.Run("should unlock once the lockout expires", func(t *testing.T) {
+		limiter, now := newTestLoginLimiter()
+
+		for i := 0; i < 3; i++ {
+			limiter.RecordFailure("alice", "")
+		}
+		*now = now.Add(10 * time.Minute)
+
+		_, locked := limiter.Check("alice", "")
+		assert.False(t, locked)
+	})
+}
+
+func TestLoginLimiter_Reset(t *testing.T) {
+	t.Run("should clear failures after successful login", func(t *testing.T) {
+		limiter, _ := newTestLoginLimiter()
+
+		limiter.RecordFailure("alice", "")
+		limiter.RecordFailure("alice", "")
+		limiter.Reset("alice")
+
+		assert.False(t, limiter.RecordFailure("alice", ""))
+		_, locked := limiter.Check("alice", "")
+		assert.False(t, locked)
+	})
+
+	t.Run("should keep the failures of the IP", func(t *testing.T) {
+		limiter, _ := newTestLoginLimiter()
+
+		limiter.RecordFailure("alice", "192.0.2.1")
+		limiter.RecordFailure("bob", "192.0.2.1")
+		limiter.Reset("mallory")
+
+		assert.True(t, limiter.RecordFailure("carol", "192.0.2.1"))
+		_, locked := limiter.Check("mallory", "192.0.2.1")
+		assert.True(t, locked)
+	})
+}
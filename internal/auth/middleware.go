@@ -4,6 +4,7 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -14,7 +15,8 @@ import (
 // It validates JWT tokens in request headers and provides user context
 // for authenticated routes in the VPN server application.
 type AuthMiddleware struct {
-	authManager *AuthManager // Authentication manager for token validation
+	authManager *AuthManager   // Authentication manager for token validation
+	apiKeys     APIKeyResolver // Optional resolver enabling X-API-Key authentication
 }
 
 // ErrorResponse represents an authentication error response.
@@ -31,12 +33,25 @@ func NewAuthMiddleware(authManager *AuthManager) *AuthMiddleware {
 	}
 }
 
+// SetAPIKeyResolver enables authentication with an X-API-Key header as an
+// alternative to Bearer tokens. Passing nil disables API keys.
+func (am *AuthMiddleware) SetAPIKeyResolver(resolver APIKeyResolver) {
+	am.apiKeys = resolver
+}
+
 // RequireAuth is a middleware function that requires authentication for protected routes.
 // It extracts the Authorization header, validates the JWT token, and sets user context.
-// If authentication fails, it returns a 401 Unauthorized response.
+// If an API key resolver is set, an X-API-Key header is accepted instead; the key
+// must carry the scope the request method requires.
+// If authentication fails or the token has been revoked, it returns a 401 Unauthorized response.
 // On success, it adds the user claims to the Gin context for use in handlers.
 func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" && am.apiKeys != nil {
+			am.authenticateAPIKey(c, key)
+			return
+		}
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -68,6 +83,13 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 
 		// Validate the token
 		claims, err := am.authManager.ValidateToken(token)
+		if errors.Is(err, ErrTokenRevoked) {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
 				Error: "Invalid or expired token",
@@ -79,6 +101,7 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		// Set user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Set("claims", claims)
 
 		// Continue to the next middleware/handler
@@ -86,6 +109,48 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey authenticates the request with an API key and sets the
+// owner's identity in the context, or aborts with 401 or 403.
+func (am *AuthMiddleware) authenticateAPIKey(c *gin.Context, key string) {
+	identity, err := am.apiKeys.ResolveAPIKey(key)
+	if errors.Is(err, ErrInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "Invalid or revoked API key",
+		})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Failed to verify API key",
+		})
+		c.Abort()
+		return
+	}
+
+	if scope := requiredScope(c.Request.Method); !identity.HasScope(scope) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error: "API key lacks the " + scope + " scope",
+		})
+		c.Abort()
+		return
+	}
+
+	role := identity.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	// Set user information in context
+	c.Set("user_id", identity.UserID)
+	c.Set("username", identity.Username)
+	c.Set("role", role)
+	c.Set("api_key", identity)
+
+	// Continue to the next middleware/handler
+	c.Next()
+}
+
 // OptionalAuth is a middleware function that provides optional authentication.
 // It extracts and validates the JWT token if present, but doesn't require it.
 // This is useful for routes that provide different functionality for authenticated users
@@ -126,6 +191,7 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		// Set user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Set("claims", claims)
 
 		// Continue to the next middleware/handler
@@ -159,6 +225,19 @@ func GetUsername(c *gin.Context) (string, bool) {
 	return name, ok
 }
 
+// GetUserRole extracts the user role from the Gin context.
+// This should be called after RequireAuth middleware has run.
+// Returns the role and a boolean indicating if it was found.
+func GetUserRole(c *gin.Context) (string, bool) {
+	role, exists := c.Get("role")
+	if !exists {
+		return "", false
+	}
+
+	name, ok := role.(string)
+	return name, ok
+}
+
 // GetClaims extracts the JWT claims from the Gin context.
 // This should be called after RequireAuth middleware has run.
 // Returns the claims and a boolean indicating if they were found.
@@ -172,6 +251,19 @@ func GetClaims(c *gin.Context) (*Claims, bool) {
 	return claimsObj, ok
 }
 
+// GetAPIKeyIdentity extracts the API key identity from the Gin context.
+// This should be called after RequireAuth middleware has run.
+// Returns the identity and a boolean indicating if the request used an API key.
+func GetAPIKeyIdentity(c *gin.Context) (*APIKeyIdentity, bool) {
+	identity, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+
+	identityObj, ok := identity.(*APIKeyIdentity)
+	return identityObj, ok
+}
+
 // IsAuthenticated checks if the current request is authenticated.
 // Returns true if the user is authenticated, false otherwise.
 func IsAuthenticated(c *gin.Context) bool {
@@ -54,7 +54,7 @@ func TestAuthMiddleware_RequireAuth(t *testing.T) {
 		})
 		
 		// Generate valid token
-		token, err := authManager.GenerateToken(123, "testuser")
+		token, err := authManager.GenerateToken(123, "testuser", RoleUser)
 		require.NoError(t, err)
 		
 		// Create request with valid token
@@ -76,6 +76,60 @@ func TestAuthMiddleware_RequireAuth(t *testing.T) {
 		assert.Equal(t, "testuser", response["username"])
 	})
 	
+	t.Run("should expose role from token", func(t *testing.T) {
+		router := gin.New()
+		router.Use(middleware.RequireAuth())
+		router.GET("/protected", func(c *gin.Context) {
+			role, exists := GetUserRole(c)
+			if !exists {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "role not found"})
+				return
+			}
+			
+			c.JSON(http.StatusOK, gin.H{"role": role})
+		})
+		
+		token, err := authManager.GenerateToken(1, "admin", RoleAdmin)
+		require.NoError(t, err)
+		
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		
+		var response map[string]interface{}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		
+		assert.Equal(t, RoleAdmin, response["role"])
+	})
+	
+	t.Run("should reject revoked token", func(t *testing.T) {
+		router := gin.New()
+		router.Use(middleware.RequireAuth())
+		router.GET("/protected", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+		
+		token, err := authManager.GenerateToken(123, "testuser", RoleUser)
+		require.NoError(t, err)
+		claims, err := authManager.ValidateToken(token)
+		require.NoError(t, err)
+		require.NoError(t, authManager.RevokeToken(claims))
+		
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "revoked")
+	})
+	
 	t.Run("should reject request without authorization header", func(t *testing.T) {
 		router := gin.New()
 		router.Use(middleware.RequireAuth())
@@ -203,7 +257,7 @@ func TestAuthMiddleware_OptionalAuth(t *testing.T) {
 		})
 		
 		// Generate valid token
-		token, err := authManager.GenerateToken(123, "testuser")
+		token, err := authManager.GenerateToken(123, "testuser", RoleUser)
 		require.NoError(t, err)
 		
 		// Create request with valid token
@@ -321,6 +375,27 @@ func TestGetUserID(t *testing.T) {
 	})
 }
 
+func TestGetUserRole(t *testing.T) {
+	t.Run("should return role when present", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("role", RoleAdmin)
+		
+		role, exists := GetUserRole(c)
+		assert.True(t, exists)
+		assert.Equal(t, RoleAdmin, role)
+	})
+	
+	t.Run("should return false when not present", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		
+		role, exists := GetUserRole(c)
+		assert.False(t, exists)
+		assert.Empty(t, role)
+	})
+}
+
 func TestGetUsername(t *testing.T) {
 	t.Run("should return username when present", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
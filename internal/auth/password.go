@@ -0,0 +1,99 @@
+// Package auth provides authentication and authorization functionality for the VPN server.
+// It implements JWT-based authentication, user management, and session handling
+// with support for password hashing and middleware integration.
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicy lists the requirements new passwords must meet.
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`     // Minimum number of characters
+	RequireUpper  bool `json:"require_upper"`  // Require an uppercase letter
+	RequireLower  bool `json:"require_lower"`  // Require a lowercase letter
+	RequireDigit  bool `json:"require_digit"`  // Require a digit
+	RequireSymbol bool `json:"require_symbol"` // Require a character that is neither a letter nor a digit
+	BlockCommon   bool `json:"block_common"`   // Reject passwords on the common password list
+}
+
+// DefaultPasswordPolicy returns the default password policy.
+// Passwords need at least 8 characters including a lowercase letter and a
+// digit, and must not be a commonly used password.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:    8,
+		RequireLower: true,
+		RequireDigit: true,
+		BlockCommon:  true,
+	}
+}
+
+// PasswordPolicyError is returned for passwords that don't meet the policy.
+type PasswordPolicyError struct {
+	Unmet []string // Descriptions of the unmet requirements
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the requirements: " + strings.Join(e.Unmet, ", ")
+}
+
+// commonPasswords holds frequently used passwords, compared case-insensitively.
+var commonPasswords = map[string]struct{}{
+	"password": {}, "password1": {}, "password12": {}, "password123": {}, "password1234": {},
+	"passw0rd": {}, "p@ssw0rd": {}, "p@ssword1": {}, "12345678": {}, "123456789": {},
+	"1234567890": {}, "qwerty123": {}, "qwertyuiop": {}, "1q2w3e4r": {}, "1qaz2wsx": {},
+	"abc12345": {}, "abcd1234": {}, "iloveyou1": {}, "letmein1": {}, "welcome1": {},
+	"welcome123": {}, "admin123": {}, "admin1234": {}, "administrator": {}, "changeme1": {},
+	"sunshine1": {}, "football1": {}, "baseball1": {}, "monkey123": {}, "dragon123": {},
+	"trustno1": {}, "master123": {}, "superman1": {}, "qwerty12": {}, "11111111": {},
+	"00000000": {}, "87654321": {}, "asdfghjk": {}, "zaq12wsx": {}, "vpnpassword1": {},
+}
+
+// Validate checks the password against the policy.
+// Returns a *PasswordPolicyError listing every unmet requirement, or nil.
+func (p *PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var unmet []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		unmet = append(unmet, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		unmet = append(unmet, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		unmet = append(unmet, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		unmet = append(unmet, "a symbol")
+	}
+	if p.BlockCommon {
+		if _, common := commonPasswords[strings.ToLower(password)]; common {
+			unmet = append(unmet, "not a commonly used password")
+		}
+	}
+
+	if len(unmet) > 0 {
+		return &PasswordPolicyError{Unmet: unmet}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strict := &PasswordPolicy{
+		MinLength:     12,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		BlockCommon:   true,
+	}
+
+	testCases := []struct {
+		name     string
+		password string
+		unmet    string
+	}{
+		{name: "too short", password: "Sh0rt!pass", unmet: "at least 12 characters"},
+		{name: "missing uppercase", password: "lowercase-only-1", unmet: "an uppercase letter"},
+		{name: "missing lowercase", password: "UPPERCASE-ONLY-1", unmet: "a lowercase letter"},
+		{name: "missing digit", password: "No-Digits-Here!", unmet: "a digit"},
+		{name: "missing symbol", password: "NoSymbolsHere123", unmet: "a symbol"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("should reject a password with "+tc.name, func(t *testing.T) {
+			err := strict.Validate(tc.password)
+			require.Error(t, err)
+
+			var policyErr *PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			assert.Equal(t, []string{tc.unmet}, policyErr.Unmet)
+			assert.Contains(t, err.Error(), tc.unmet)
+		})
+	}
+
+	t.Run("should reject a common password regardless of case", func(t *testing.T) {
+		policy := DefaultPasswordPolicy()
+
+		var policyErr *PasswordPolicyError
+		require.ErrorAs(t, policy.Validate("Password123"), &policyErr)
+		assert.Equal(t, []string{"not a commonly used password"}, policyErr.Unmet)
+	})
+
+	t.Run("should allow a common password when the blocklist is disabled", func(t *testing.T) {
+		policy := DefaultPasswordPolicy()
+		policy.BlockCommon = false
+
+		assert.NoError(t, policy.Validate("password123"))
+	})
+
+	t.Run("should list every unmet requirement", func(t *testing.T) {
+		var policyErr *PasswordPolicyError
+		require.ErrorAs(t, strict.Validate("password"), &policyErr)
+		assert.Equal(t, []string{
+			"at least 12 characters",
+			"an uppercase letter",
+			"a digit",
+			"a symbol",
+			"not a commonly used password",
+		}, policyErr.Unmet)
+	})
+
+	t.Run("should count characters rather than bytes", func(t *testing.T) {
+		policy := &PasswordPolicy{MinLength: 8}
+
+		assert.Error(t, policy.Validate("пароль1"))
+		assert.NoError(t, policy.Validate("пароль12"))
+	})
+
+	t.Run("should accept a compliant password", func(t *testing.T) {
+		assert.NoError(t, strict.Validate("Correct-Horse-42"))
+		assert.NoError(t, DefaultPasswordPolicy().Validate("testpassword123"))
+	})
+}
+
+func TestAuthManager_ValidatePassword(t *testing.T) {
+	t.Run("should use the default policy", func(t *testing.T) {
+		am := NewAuthManager("test-secret")
+
+		assert.Equal(t, DefaultPasswordPolicy(), am.GetPasswordPolicy())
+		assert.Error(t, am.ValidatePassword("password"))
+		assert.NoError(t, am.ValidatePassword("testpassword123"))
+	})
+
+	t.Run("should use a configured policy", func(t *testing.T) {
+		am := NewAuthManager("test-secret")
+		am.SetPasswordPolicy(&PasswordPolicy{MinLength: 10, RequireUpper: true})
+
+		assert.Error(t, am.ValidatePassword("testpassword123"))
+		assert.NoError(t, am.ValidatePassword("Testpassword"))
+	})
+}
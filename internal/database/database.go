@@ -1,7 +1,9 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 	
 	"golang.org/x/crypto/bcrypt"
@@ -10,6 +12,10 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// ErrDuplicateClientName is returned when a client is created or renamed
+// to a name that another client already uses.
+var ErrDuplicateClientName = errors.New("a client with this name already exists")
+
 // Database wraps a GORM database instance and provides high-level operations
 // for VPN server data management. It encapsulates all database interactions
 // for clients, server configuration, and connection logging.
@@ -29,18 +35,65 @@ func New(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := db.AutoMigrate(&User{}, &Client{}, &ServerConfig{}, &ConnectionLog{}); err != nil {
+	// Client names used to be free-form; suffix older duplicates with their
+	// ID so the unique name index can be created
+	if db.Migrator().HasTable(&Client{}) && !db.Migrator().HasIndex(&Client{}, "idx_clients_name") {
+		if err := db.Exec("UPDATE clients SET name = name || '-' || id WHERE id NOT IN (SELECT MIN(id) FROM clients GROUP BY name)").Error; err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	if err := db.AutoMigrate(&User{}, &Client{}, &ServerConfig{}, &ConnectionLog{}, &RevokedToken{}, &Session{}, &PasswordResetToken{}, &APIKey{}, &MetricsSnapshot{}); err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Client IPs used to be unique including the empty address, which would
+	// allow only one pending client; the partial index above replaces it
+	if db.Migrator().HasIndex(&Client{}, "idx_clients_ip_address") {
+		if err := db.Migrator().DropIndex(&Client{}, "idx_clients_ip_address"); err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
 	return &Database{DB: db}, nil
 }
 
+// Transaction runs fn in a database transaction. The tx passed to fn provides
+// the same operations as db; its changes are committed if fn returns nil and
+// rolled back if fn returns an error or panics.
+// Returns the error of fn or of the commit.
+func (db *Database) Transaction(fn func(tx *Database) error) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Database{DB: tx})
+	})
+}
+
+// Ping checks that the database connection is alive.
+// Returns an error if the database cannot be reached.
+func (db *Database) Ping() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	return sqlDB.Ping()
+}
+
+// Close closes the underlying database connection.
+// Returns an error if the connection cannot be closed.
+func (db *Database) Close() error {
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	return sqlDB.Close()
+}
+
 // CreateClient inserts a new client record into the database.
 // The client parameter must have all required fields populated.
-// Returns an error if the creation fails due to validation or database constraints.
+// Returns ErrDuplicateClientName if the name is taken, or another error if the
+// creation fails due to validation or database constraints.
 func (db *Database) CreateClient(client *Client) error {
-	return db.Create(client).Error
+	return translateClientError(db.Create(client).Error)
 }
 
 // GetClient retrieves a client by their unique ID.
@@ -68,11 +121,215 @@ func (db *Database) ListClients() ([]Client, error) {
 	return clients, err
 }
 
+// ListPendingClients retrieves the clients queued for an IP address.
+// The clients are returned in the order they were created (oldest first).
+// Returns a slice of pending clients and an error if the query fails.
+func (db *Database) ListPendingClients() ([]Client, error) {
+	var clients []Client
+	err := db.Where("pending = ?", true).Order("id asc").Find(&clients).Error
+	return clients, err
+}
+
+// ListExpiredClients retrieves the enabled clients whose expiry date is at or before now.
+// Expiry dates are compared in Go because SQLite stores them as text that only
+// sorts chronologically within a single time zone.
+// Returns a slice of expired clients and an error if the query fails.
+func (db *Database) ListExpiredClients(now time.Time) ([]Client, error) {
+	var candidates []Client
+	if err := db.Where("enabled = ? AND expires_at IS NOT NULL", true).Order("id asc").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var clients []Client
+	for _, client := range candidates {
+		if client.IsExpired(now) {
+			clients = append(clients, client)
+		}
+	}
+	return clients, nil
+}
+
+// ClientFilter restricts the clients returned by ListClientsByFilter and CountClients.
+// Zero values do not filter.
+type ClientFilter struct {
+	Enabled       *bool  // Only clients with this enabled state
+	Tag           string // Only clients carrying this tag
+	MetadataKey   string // Only clients having this metadata key
+	MetadataValue string // Only clients whose MetadataKey has this value (requires MetadataKey)
+}
+
+// apply adds the filter conditions to a client query.
+// Tags are stored comma-separated, so the tag is matched as a whole list item.
+// Metadata is stored as a JSON object and matched with SQLite's JSON functions;
+// clients without valid metadata never match a metadata filter.
+func (f ClientFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Enabled != nil {
+		query = query.Where("enabled = ?", *f.Enabled)
+	}
+	if f.Tag != "" {
+		query = query.Where("(',' || tags || ',') LIKE ?", "%,"+f.Tag+",%")
+	}
+	if f.MetadataKey != "" {
+		path := fmt.Sprintf(`$."%s"`, f.MetadataKey)
+		if f.MetadataValue != "" {
+			query = query.Where("(CASE WHEN json_valid(metadata) THEN json_extract(metadata, ?) END) = ?", path, f.MetadataValue)
+		} else {
+			query = query.Where("(CASE WHEN json_valid(metadata) THEN json_type(metadata, ?) END) IS NOT NULL", path)
+		}
+	}
+	return query
+}
+
+// ListClientsByFilter retrieves the client records matching the filter.
+// Returns a slice of matching clients and an error if the query fails.
+func (db *Database) ListClientsByFilter(filter ClientFilter) ([]Client, error) {
+	var clients []Client
+	err := filter.apply(db.Model(&Client{})).Find(&clients).Error
+	return clients, err
+}
+
+// Orders that ListClientsPaged can sort clients by.
+const (
+	ClientSortName          = "name"           // Client name
+	ClientSortCreatedAt     = "created_at"     // Creation time
+	ClientSortLastHandshake = "last_handshake" // Latest WireGuard handshake (clients without one sort first)
+)
+
+// ClientPage selects the slice of clients returned by ListClientsPaged.
+type ClientPage struct {
+	Limit      int    // Maximum number of clients to return
+	Offset     int    // Number of clients to skip
+	Sort       string // One of the ClientSort orders (empty sorts by ID)
+	Descending bool   // Reverse the sort order
+}
+
+// ListClientsPaged retrieves one page of the client records matching the filter.
+// Sorting, limiting and offsetting are done in SQL; clients with equal sort keys
+// are ordered by ID to keep pages stable.
+// Returns the page, the number of clients matching the filter and an error if
+// the sort order is unknown or a query fails.
+func (db *Database) ListClientsPaged(filter ClientFilter, page ClientPage) ([]Client, int64, error) {
+	column := "id"
+	switch page.Sort {
+	case "":
+	case ClientSortName, ClientSortCreatedAt, ClientSortLastHandshake:
+		column = page.Sort
+	default:
+		return nil, 0, fmt.Errorf("unsupported client sort order: %s", page.Sort)
+	}
+
+	direction := "asc"
+	if page.Descending {
+		direction = "desc"
+	}
+
+	total, err := db.CountClients(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var clients []Client
+	order := fmt.Sprintf("%s %s, id %s", column, direction, direction)
+	err = filter.apply(db.Model(&Client{})).Order(order).Limit(page.Limit).Offset(page.Offset).Find(&clients).Error
+	return clients, total, err
+}
+
+// likeEscaper escapes the LIKE wildcards of user input so it is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchClients finds clients whose name contains the query, ignoring case,
+// or whose IP address starts with it. The query is passed as a parameter with
+// LIKE wildcards escaped, so it is always matched literally.
+// Returns at most limit clients ordered by name and an error if the query fails.
+func (db *Database) SearchClients(query string, limit int) ([]Client, error) {
+	escaped := likeEscaper.Replace(query)
+
+	var clients []Client
+	err := db.Where(`LOWER(name) LIKE ? ESCAPE '\' OR ip_address LIKE ? ESCAPE '\'`,
+		"%"+strings.ToLower(escaped)+"%", escaped+"%").
+		Order("name, id").
+		Limit(limit).
+		Find(&clients).Error
+	return clients, err
+}
+
+// CountClients counts the client records matching the filter.
+// The count is computed in SQL, so no client rows are loaded.
+// Returns the number of matching clients and an error if the query fails.
+func (db *Database) CountClients(filter ClientFilter) (int64, error) {
+	var count int64
+	err := filter.apply(db.Model(&Client{})).Count(&count).Error
+	return count, err
+}
+
+// Traffic counters that clients can be ranked by in ListTopClients.
+const (
+	TrafficReceived = "received" // Bytes received by the client
+	TrafficSent     = "sent"     // Bytes sent by the client
+	TrafficTotal    = "total"    // Sum of bytes received and sent
+)
+
+// ListTopClients retrieves the clients with the highest traffic for the given counter.
+// Ordering and limiting are done in SQL so only the top clients are loaded.
+// Clients with equal traffic are ordered by ID to keep the result stable.
+// Returns a slice of at most limit clients and an error if the counter is unknown or the query fails.
+func (db *Database) ListTopClients(counter string, limit int) ([]Client, error) {
+	var order string
+	switch counter {
+	case TrafficReceived:
+		order = "bytes_received desc"
+	case TrafficSent:
+		order = "bytes_sent desc"
+	case TrafficTotal:
+		order = "(bytes_received + bytes_sent) desc"
+	default:
+		return nil, fmt.Errorf("unknown traffic counter: %s", counter)
+	}
+
+	var clients []Client
+	err := db.Order(order).Order("id asc").Limit(limit).Find(&clients).Error
+	return clients, err
+}
+
 // UpdateClient updates an existing client record in the database.
 // The client parameter must have the ID field set to identify the record to update.
-// Returns an error if the update fails.
+// Returns ErrDuplicateClientName if the client was renamed to a taken name,
+// or another error if the update fails.
 func (db *Database) UpdateClient(client *Client) error {
-	return db.Save(client).Error
+	return translateClientError(db.Save(client).Error)
+}
+
+// translateClientError maps a violation of the unique client name index to
+// ErrDuplicateClientName and returns other errors unchanged.
+func translateClientError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed: clients.name") {
+		return ErrDuplicateClientName
+	}
+	return err
+}
+
+// UpdateClientTraffic persists only the traffic counters and latest handshake of a client.
+// Other columns are left untouched, so concurrent edits of the client are not overwritten.
+// Returns an error if the update fails.
+func (db *Database) UpdateClientTraffic(client *Client) error {
+	return db.Model(client).
+		Select("BytesReceived", "BytesSent", "LastRxCounter", "LastTxCounter", "LastHandshake").
+		Updates(client).Error
+}
+
+// MarkClientConfigsStale flags every client whose configuration must be
+// downloaded again, e.g. after the server endpoint changed.
+// Returns the number of flagged clients and an error if the update fails.
+func (db *Database) MarkClientConfigsStale() (int64, error) {
+	result := db.Model(&Client{}).Where("1 = 1").Update("config_stale", true)
+	return result.RowsAffected, result.Error
+}
+
+// ClearClientConfigStale clears the stale flag of a client once its current
+// configuration has been handed out.
+// Returns an error if the update fails.
+func (db *Database) ClearClientConfigStale(id uint) error {
+	return db.Model(&Client{}).Where("id = ?", id).Update("config_stale", false).Error
 }
 
 // DeleteClient removes a client record from the database by ID.
@@ -105,6 +362,26 @@ func (db *Database) UpdateServerConfig(config *ServerConfig) error {
 	return db.Save(config).Error
 }
 
+// ReplaceServerConfig deletes every existing server configuration and inserts
+// the given one in a single transaction, e.g. when the server is reinitialized.
+// Returns an error if the operation fails, in which case nothing is changed.
+func (db *Database) ReplaceServerConfig(config *ServerConfig) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&ServerConfig{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(config).Error
+	})
+}
+
+// DeleteServerConfig removes every server configuration record, returning
+// the server to its uninitialized state.
+// Returns the number of deleted records and an error if the deletion fails.
+func (db *Database) DeleteServerConfig() (int64, error) {
+	result := db.Where("1 = 1").Delete(&ServerConfig{})
+	return result.RowsAffected, result.Error
+}
+
 // LogConnection records a client connection event in the database.
 // This is used for auditing and monitoring client connections and disconnections.
 // The action parameter should be either "connect" or "disconnect".
@@ -118,6 +395,59 @@ func (db *Database) LogConnection(clientID uint, action, ipAddress string) error
 	return db.Create(log).Error
 }
 
+// DeleteConnectionLogsBefore deletes connection log entries older than the given time.
+// Rows are deleted in batches of at most batchSize, so purging a large table
+// never holds a long-running write lock on the database.
+// Returns the total number of deleted rows and an error if a batch fails.
+func (db *Database) DeleteConnectionLogsBefore(before time.Time, batchSize int) (int64, error) {
+	var total int64
+	for {
+		batch := db.Model(&ConnectionLog{}).Select("id").Where("timestamp < ?", before).Limit(batchSize)
+		result := db.Where("id IN (?)", batch).Delete(&ConnectionLog{})
+		if result.Error != nil {
+			return total, fmt.Errorf("failed to delete connection logs: %w", result.Error)
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// CreateMetricsSnapshot stores a metrics snapshot, normalizing its timestamp to UTC.
+// Returns an error if the insert fails.
+func (db *Database) CreateMetricsSnapshot(snapshot *MetricsSnapshot) error {
+	snapshot.Timestamp = snapshot.Timestamp.UTC()
+	return db.Create(snapshot).Error
+}
+
+// ListMetricsSnapshots retrieves the snapshots taken in [from, to), oldest first.
+// Returns a slice of snapshots and an error if the query fails.
+func (db *Database) ListMetricsSnapshots(from, to time.Time) ([]MetricsSnapshot, error) {
+	var snapshots []MetricsSnapshot
+	err := db.Where("timestamp >= ? AND timestamp < ?", from.UTC(), to.UTC()).
+		Order("timestamp asc").Find(&snapshots).Error
+	return snapshots, err
+}
+
+// DeleteMetricsSnapshotsBefore deletes snapshots older than the given time in
+// batches of at most batchSize, like DeleteConnectionLogsBefore.
+// Returns the total number of deleted rows and an error if a batch fails.
+func (db *Database) DeleteMetricsSnapshotsBefore(before time.Time, batchSize int) (int64, error) {
+	var total int64
+	for {
+		batch := db.Model(&MetricsSnapshot{}).Select("id").Where("timestamp < ?", before.UTC()).Limit(batchSize)
+		result := db.Where("id IN (?)", batch).Delete(&MetricsSnapshot{})
+		if result.Error != nil {
+			return total, fmt.Errorf("failed to delete metrics snapshots: %w", result.Error)
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
 // GetConnectionLogs retrieves the most recent connection log entries.
 // The logs are returned in descending order by timestamp (most recent first).
 // The limit parameter controls the maximum number of records to return.
@@ -128,6 +458,71 @@ func (db *Database) GetConnectionLogs(limit int) ([]ConnectionLog, error) {
 	return logs, err
 }
 
+// SaveRevokedToken records a revoked token ID with the token's expiry.
+// Revoking the same token twice keeps a single record.
+// Returns an error if the database operation fails.
+func (db *Database) SaveRevokedToken(tokenID string, expiresAt time.Time) error {
+	return db.Save(&RevokedToken{TokenID: tokenID, ExpiresAt: expiresAt}).Error
+}
+
+// ListRevokedTokens retrieves all revoked token IDs with their expiry.
+// Returns a map from token ID to expiry and an error if the query fails.
+func (db *Database) ListRevokedTokens() (map[string]time.Time, error) {
+	var tokens []RevokedToken
+	if err := db.Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]time.Time, len(tokens))
+	for _, token := range tokens {
+		revoked[token.TokenID] = token.ExpiresAt
+	}
+	return revoked, nil
+}
+
+// DeleteRevokedTokensBefore deletes revoked tokens that expired before the given time.
+// Returns the number of deleted rows and an error if the deletion fails.
+func (db *Database) DeleteRevokedTokensBefore(before time.Time) (int64, error) {
+	result := db.Where("expires_at < ?", before).Delete(&RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateSession records a newly issued token.
+// Returns an error if the database operation fails.
+func (db *Database) CreateSession(session *Session) error {
+	return db.Create(session).Error
+}
+
+// ListSessions retrieves a user's sessions whose tokens expire after now,
+// most recently issued first.
+// Returns the sessions and an error if the query fails.
+func (db *Database) ListSessions(userID uint, now time.Time) ([]Session, error) {
+	var sessions []Session
+	err := db.Where("user_id = ? AND expires_at > ?", userID, now).Order("issued_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetSession retrieves a session by its token ID.
+// Returns the session and an error if it is not found or the query fails.
+func (db *Database) GetSession(tokenID string) (*Session, error) {
+	var session Session
+	err := db.Where("token_id = ?", tokenID).First(&session).Error
+	return &session, err
+}
+
+// DeleteSession removes a session, e.g. after its token was revoked.
+// Deleting a session that doesn't exist is not an error.
+func (db *Database) DeleteSession(tokenID string) error {
+	return db.Where("token_id = ?", tokenID).Delete(&Session{}).Error
+}
+
+// DeleteSessionsBefore deletes sessions whose tokens expired before the given time.
+// Returns the number of deleted rows and an error if the deletion fails.
+func (db *Database) DeleteSessionsBefore(before time.Time) (int64, error) {
+	result := db.Where("expires_at < ?", before).Delete(&Session{})
+	return result.RowsAffected, result.Error
+}
+
 // CreateUser inserts a new user record into the database.
 // The user parameter must have all required fields populated including hashed password.
 // Returns an error if the creation fails due to validation or database constraints.
@@ -161,6 +556,34 @@ func (db *Database) GetUserByEmail(email string) (*User, error) {
 	return &user, err
 }
 
+// RegisterUser creates an account that signed up through registration and
+// assigns its role. While no user exists, the account becomes the admin,
+// since otherwise no one could manage the server; later accounts get the
+// user role. Counting and creating run in one transaction.
+// Returns an error if the user cannot be created.
+func (db *Database) RegisterUser(user *User) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&User{}).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count users: %w", err)
+		}
+
+		user.Role = "user"
+		if count == 0 {
+			user.Role = "admin"
+		}
+		return tx.Create(user).Error
+	})
+}
+
+// CountUsers returns the number of user accounts, active or not.
+// Returns the count and an error if the query fails.
+func (db *Database) CountUsers() (int64, error) {
+	var count int64
+	err := db.Model(&User{}).Count(&count).Error
+	return count, err
+}
+
 // ListUsers retrieves all user records from the database.
 // Returns a slice of all users and an error if the query fails.
 func (db *Database) ListUsers() ([]User, error) {
@@ -233,6 +656,83 @@ func (db *Database) AuthenticateUser(username, password string) (*User, error) {
 	return user, nil
 }
 
+// CreatePasswordResetToken stores a new password reset token and invalidates
+// any unused tokens previously issued to the same user, so only the most
+// recent reset link works.
+// Returns an error if the database operation fails.
+func (db *Database) CreatePasswordResetToken(token *PasswordResetToken) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by its hash.
+// Returns the token and an error if not found or query fails.
+func (db *Database) GetPasswordResetTokenByHash(tokenHash string) (*PasswordResetToken, error) {
+	var token PasswordResetToken
+	err := db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// UsePasswordResetToken marks an unused, unexpired token as used.
+// The check and update happen in one statement, so a token can only be used once
+// even by concurrent requests.
+// Returns true if the token was consumed and an error if the update fails.
+func (db *Database) UsePasswordResetToken(id uint, now time.Time) (bool, error) {
+	result := db.Model(&PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL AND expires_at > ?", id, now).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateAPIKey stores a new API key.
+// Returns an error if the creation fails due to validation or database constraints.
+func (db *Database) CreateAPIKey(key *APIKey) error {
+	return db.Create(key).Error
+}
+
+// ListAPIKeys retrieves all API keys, including revoked ones, ordered by ID.
+// Returns a slice of API keys and an error if the query fails.
+func (db *Database) ListAPIKeys() ([]APIKey, error) {
+	var keys []APIKey
+	err := db.Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// GetActiveAPIKeyByHash retrieves an API key that has not been revoked by its hash.
+// Returns the key and an error if not found or query fails.
+func (db *Database) GetActiveAPIKeyByHash(keyHash string) (*APIKey, error) {
+	var key APIKey
+	err := db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey marks an active API key as revoked.
+// Returns true if the key was revoked and an error if the update fails.
+func (db *Database) RevokeAPIKey(id uint, now time.Time) (bool, error) {
+	result := db.Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateAPIKeyLastUsed records when an API key last authenticated a request.
+// Returns an error if the update fails.
+func (db *Database) UpdateAPIKeyLastUsed(id uint, now time.Time) error {
+	return db.Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", now).Error
+}
+
 // CreateUserWithCredentials creates a new user with username, email, and password.
 // It hashes the password before storing it in the database.
 // Returns the created user and an error if creation fails.
@@ -258,5 +758,27 @@ func (db *Database) CreateUserWithCredentials(username, email, password string)
 		return nil, err
 	}
 
+	return user, nil
+}
+
+// RegisterUserWithCredentials registers a new user with username, email, and
+// password like RegisterUser, hashing the password before storing it.
+// Returns the created user and an error if creation fails.
+func (db *Database) RegisterUserWithCredentials(username, email, password string) (*User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &User{
+		Username: username,
+		Email:    email,
+		Password: string(hashedPassword),
+		Active:   true,
+	}
+	if err := db.RegisterUser(user); err != nil {
+		return nil, err
+	}
+
 	return user, nil
 }
\ No newline at end of file
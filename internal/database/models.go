@@ -27,16 +27,38 @@ type User struct {
 // cryptographic keys, network configuration, and connection statistics.
 type Client struct {
 	ID            uint       `gorm:"primaryKey" json:"id"`                       // Unique identifier for the client
-	Name          string     `gorm:"not null" json:"name"`                       // Human-readable name for the client
+	Name          string     `gorm:"uniqueIndex:idx_clients_name;not null" json:"name"` // Human-readable name for the client (unique)
 	PublicKey     string     `gorm:"uniqueIndex;not null" json:"public_key"`     // WireGuard public key (unique)
 	PrivateKey    string     `gorm:"not null" json:"private_key"`                // WireGuard private key
-	IPAddress     string     `gorm:"uniqueIndex;not null" json:"ip_address"`     // Assigned IP address (unique)
+	PresharedKey  string     `json:"preshared_key"`                              // WireGuard preshared key shared with the server (empty for clients created before PSK support)
+	IPAddress     string     `gorm:"uniqueIndex:idx_clients_assigned_ip,where:ip_address <> '';not null" json:"ip_address"` // Assigned IP address (unique, empty while pending)
 	Enabled       bool       `gorm:"default:true" json:"enabled"`                // Whether the client is active
 	CreatedAt     time.Time  `json:"created_at"`                                 // Creation timestamp
 	UpdatedAt     time.Time  `json:"updated_at"`                                 // Last update timestamp
 	LastHandshake *time.Time `json:"last_handshake,omitempty"`                   // Last WireGuard handshake time
 	BytesReceived uint64     `gorm:"default:0" json:"bytes_received"`            // Total bytes received by client
 	BytesSent     uint64     `gorm:"default:0" json:"bytes_sent"`                // Total bytes sent by client
+	Tags          string     `gorm:"type:text" json:"tags"`                      // Client tags (comma-separated, e.g. "mobile")
+	PersistentKeepalive *int `json:"persistent_keepalive,omitempty"`             // Explicit keepalive in seconds (nil uses tag defaults)
+	DataCapSoftBytes   uint64     `gorm:"default:0" json:"data_cap_soft_bytes"`    // Per-period usage that raises an alert (0 uses tag defaults)
+	DataCapHardBytes   uint64     `gorm:"default:0" json:"data_cap_hard_bytes"`    // Per-period usage that disables the client (0 uses tag defaults)
+	DataCapExceeded    bool       `gorm:"default:false" json:"data_cap_exceeded"`  // Whether the client was disabled for exceeding its hard cap
+	UsagePeriodStart   *time.Time `json:"usage_period_start,omitempty"`           // Start of the current accounting period
+	UsageBaselineBytes uint64     `gorm:"default:0" json:"usage_baseline_bytes"`   // Total bytes transferred when the period started
+	PeerNotApplied     bool       `gorm:"default:false" json:"peer_not_applied"`   // Whether adding the WireGuard peer failed at creation
+	LastRxCounter      uint64     `gorm:"default:0" json:"-"`                      // Raw WireGuard transfer-rx counter seen in the last sync
+	LastTxCounter      uint64     `gorm:"default:0" json:"-"`                      // Raw WireGuard transfer-tx counter seen in the last sync
+	Pending            bool       `gorm:"default:false" json:"pending"`            // Whether the client is queued for an IP address
+	Notes              string     `gorm:"type:text" json:"notes"`                  // Freeform operational notes (owner, device, ticket)
+	Metadata           string     `gorm:"type:text" json:"metadata"`               // Structured key/value metadata (JSON object)
+	ConfigStale        bool       `gorm:"default:false" json:"config_stale"`       // Whether the server endpoint changed since the config was last downloaded
+	RateLimitMbps      int        `gorm:"default:0" json:"rate_limit_mbps"`        // Bandwidth limit in megabits per second (0 means unlimited)
+	AllowedIPs         string     `gorm:"type:text" json:"allowed_ips"`            // Routes sent through the tunnel (comma-separated CIDRs, empty uses server defaults)
+	DNS                string     `gorm:"type:text" json:"dns"`                    // DNS servers for the client (comma-separated, empty uses server defaults)
+	ExpiresAt          *time.Time `gorm:"index" json:"expires_at,omitempty"`      // When access ends and the client is disabled (nil never expires)
+	DataQuotaBytes         uint64 `gorm:"default:0" json:"data_quota_bytes"`          // Total usage allowed until the quota is reset (0 means unlimited)
+	DataQuotaBaselineBytes uint64 `gorm:"default:0" json:"data_quota_baseline_bytes"` // Total bytes transferred when the quota was last reset
+	DataQuotaExceeded      bool   `gorm:"default:false" json:"data_quota_exceeded"`   // Whether the client was disabled for using up its quota
 }
 
 // ServerConfig represents the WireGuard server configuration in the database.
@@ -46,9 +68,17 @@ type ServerConfig struct {
 	PrivateKey string    `gorm:"not null" json:"private_key"`    // WireGuard server private key
 	PublicKey  string    `gorm:"not null" json:"public_key"`     // WireGuard server public key
 	ListenPort int       `gorm:"not null" json:"listen_port"`    // UDP port for WireGuard to listen on
+	MTU        int       `gorm:"default:0" json:"mtu"`           // Tunnel MTU for server and clients (0 lets WireGuard choose)
 	Network    string    `gorm:"not null" json:"network"`        // VPN network CIDR (e.g., "10.0.0.0/24")
 	Interface  string    `gorm:"default:wg0" json:"interface"`   // WireGuard interface name
 	DNS        string    `gorm:"type:text" json:"dns"`           // DNS servers for clients (comma-separated)
+	TunnelMode string    `gorm:"default:full" json:"tunnel_mode"` // Client routing mode: "full" or "split"
+	PushedRoutes string  `gorm:"type:text" json:"pushed_routes"`  // Routes pushed to clients in split mode (comma-separated CIDRs)
+	Endpoint   string    `json:"endpoint"`                       // Public hostname or IP clients connect to (without port)
+	AlternateEndpoints string `gorm:"type:text" json:"alternate_endpoints"` // Fallback endpoints clients may use instead (comma-separated)
+	ExternalInterface string `json:"external_interface"`          // Uplink interface used for NAT (empty auto-detects the default route)
+	DefaultKeepalive int     `gorm:"default:0" json:"default_keepalive"` // PersistentKeepalive (seconds) for clients without their own (0 disables it)
+	DefaultDNS       string  `gorm:"type:text" json:"default_dns"`       // DNS servers for clients without their own (comma-separated, empty falls back to DNS)
 	CreatedAt  time.Time `json:"created_at"`                     // Creation timestamp
 	UpdatedAt  time.Time `json:"updated_at"`                     // Last update timestamp
 }
@@ -64,6 +94,68 @@ type ConnectionLog struct {
 	IPAddress string    `json:"ip_address"`                     // Client's remote IP address
 }
 
+// RevokedToken represents a JWT revoked by logout before its expiry.
+// Rows can be deleted once ExpiresAt has passed, as the token is rejected anyway.
+type RevokedToken struct {
+	TokenID   string    `gorm:"primaryKey" json:"token_id"`          // JWT ID (jti) of the revoked token
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`    // Expiry of the revoked token
+	CreatedAt time.Time `json:"created_at"`                          // When the token was revoked
+}
+
+// Session represents a JWT issued to a user at login, registration or refresh,
+// so users can see which devices hold valid tokens and revoke them.
+// Rows can be deleted once ExpiresAt has passed, as the token is rejected anyway.
+type Session struct {
+	TokenID   string    `gorm:"primaryKey" json:"token_id"`       // JWT ID (jti) of the issued token
+	UserID    uint      `gorm:"index;not null" json:"user_id"`    // User the token was issued to
+	Label     string    `json:"label"`                            // Describes the client, e.g. its user agent and IP address
+	IssuedAt  time.Time `gorm:"not null" json:"issued_at"`        // When the token was issued
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"` // Expiry of the token
+}
+
+// PasswordResetToken represents a single-use token allowing a user to set a new
+// password without being logged in. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`                    // Unique identifier for the token
+	UserID    uint       `gorm:"index;not null" json:"user_id"`           // User whose password may be reset
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`           // Hex-encoded SHA-256 hash of the token
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`              // When the token stops being accepted
+	UsedAt    *time.Time `json:"used_at,omitempty"`                       // When the token was used or invalidated (nil while usable)
+	CreatedAt time.Time  `json:"created_at"`                              // When the token was issued
+}
+
+// APIKey represents a long-lived credential for automation that acts as its
+// owner. Only the SHA-256 hash of the key is stored; the key itself is shown
+// once at creation.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`                    // Unique identifier for the key
+	UserID     uint       `gorm:"index;not null" json:"user_id"`           // User the key acts as
+	Name       string     `gorm:"not null" json:"name"`                    // Human-readable label (e.g. "ci-provisioning")
+	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`           // Hex-encoded SHA-256 hash of the key
+	Scopes     string     `gorm:"not null" json:"scopes"`                  // Granted scopes (comma-separated, e.g. "read,write")
+	CreatedAt  time.Time  `json:"created_at"`                              // When the key was created
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`                  // When the key last authenticated a request
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`                    // When the key was revoked (nil while active)
+}
+
+// MetricsSnapshot is a compact record of the server metrics at one collection
+// cycle, kept to draw history graphs. Timestamps are stored in UTC so that
+// range queries compare correctly.
+type MetricsSnapshot struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`         // Unique identifier for the snapshot
+	Timestamp       time.Time `gorm:"index;not null" json:"timestamp"` // When the metrics were collected (UTC)
+	TotalClients    int       `json:"total_clients"`                // Number of configured clients
+	ActiveClients   int       `json:"active_clients"`               // Number of connected clients
+	PoolUtilization float64   `json:"pool_utilization"`             // Percentage of the IP pool in use
+	BytesReceived   uint64    `json:"bytes_received"`               // Total bytes received from clients
+	BytesSent       uint64    `json:"bytes_sent"`                   // Total bytes sent to clients
+	CPUUsage        float64   `json:"cpu_usage"`                    // CPU usage percentage
+	MemoryUsage     float64   `json:"memory_usage"`                 // Memory usage percentage
+	FailedLogins       int    `json:"failed_logins"`       // Failed login attempts within the last hour
+	BlockedConnections int    `json:"blocked_connections"` // Packets dropped by the firewall rules within the last hour
+	ThreatLevel        string `json:"threat_level"`        // Threat assessment of the security scan
+}
+
 // TableName returns the database table name for User model.
 // This implements the GORM Tabler interface to specify custom table names.
 func (User) TableName() string {
@@ -76,6 +168,40 @@ func (Client) TableName() string {
 	return "clients"
 }
 
+// IsExpired reports whether the client's access ended at or before now.
+// Clients without an expiry date never expire.
+func (c *Client) IsExpired(now time.Time) bool {
+	return c.ExpiresAt != nil && !c.ExpiresAt.After(now)
+}
+
+// Keepalive returns the client's PersistentKeepalive in seconds, or
+// defaultKeepalive if the client does not set its own.
+func (c *Client) Keepalive(defaultKeepalive int) int {
+	if c.PersistentKeepalive != nil {
+		return *c.PersistentKeepalive
+	}
+	return defaultKeepalive
+}
+
+// DataQuotaUsage returns the bytes transferred since the data quota was last reset.
+func (c *Client) DataQuotaUsage() uint64 {
+	total := c.BytesReceived + c.BytesSent
+	if total < c.DataQuotaBaselineBytes {
+		return 0
+	}
+	return total - c.DataQuotaBaselineBytes
+}
+
+// DataQuotaRemaining returns the bytes left before the data quota is used up.
+// Clients without a quota report zero.
+func (c *Client) DataQuotaRemaining() uint64 {
+	usage := c.DataQuotaUsage()
+	if usage >= c.DataQuotaBytes {
+		return 0
+	}
+	return c.DataQuotaBytes - usage
+}
+
 // TableName returns the database table name for ServerConfig model.
 // This implements the GORM Tabler interface to specify custom table names.
 func (ServerConfig) TableName() string {
@@ -86,4 +212,28 @@ func (ServerConfig) TableName() string {
 // This implements the GORM Tabler interface to specify custom table names.
 func (ConnectionLog) TableName() string {
 	return "connection_logs"
-}
\ No newline at end of file
+}
+
+// TableName returns the database table name for RevokedToken model.
+// This implements the GORM Tabler interface to specify custom table names.
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// TableName returns the database table name for PasswordResetToken model.
+// This implements the GORM Tabler interface to specify custom table names.
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// TableName returns the database table name for APIKey model.
+// This implements the GORM Tabler interface to specify custom table names.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// TableName returns the database table name for MetricsSnapshot model.
+// This implements the GORM Tabler interface to specify custom table names.
+func (MetricsSnapshot) TableName() string {
+	return "metrics_snapshots"
+}
@@ -5,6 +5,7 @@ package monitoring
 
 import (
 	"fmt"
+	"log"
 	"sync"
 	"time"
 )
@@ -17,6 +18,7 @@ type AlertManager struct {
 	config       AlertConfig       // Alert configuration and thresholds
 	mutex        sync.RWMutex      // Mutex for thread-safe operations
 	lastEvalTime time.Time         // Last time alerts were evaluated
+	logManager   *LogManager       // Optional log manager for notification failures
 }
 
 // AlertConfig represents configuration for alert thresholds and notification settings.
@@ -29,7 +31,25 @@ type AlertConfig struct {
 	ErrorRateThreshold float64       `json:"error_rate_threshold"` // Max acceptable error rate (percentage)
 	EnableAlerts       bool          `json:"enable_alerts"`        // Whether alerts are enabled
 	AlertCooldown      time.Duration `json:"alert_cooldown"`       // Minimum time between identical alerts
-	NotificationChannels []string    `json:"notification_channels"` // Enabled notification channels
+	NotificationChannels []string    `json:"notification_channels"` // Enabled notification channels ("log", "webhook", "email")
+	WebhookURL         string        `json:"webhook_url"`          // Endpoint for the "webhook" channel
+	WebhookFormat      string        `json:"webhook_format"`       // Payload format for the "webhook" channel: "generic" (default), "slack" or "discord"
+	Email              EmailConfig   `json:"email"`                // SMTP settings and recipients for the "email" channel
+}
+
+// NotificationChannelWebhook posts alert transitions to AlertConfig.WebhookURL.
+const NotificationChannelWebhook = "webhook"
+
+// AlertNotification is the payload sent to notification channels when an
+// alert becomes active or is resolved.
+type AlertNotification struct {
+	ID          string      `json:"id"`          // Alert identifier
+	Type        AlertType   `json:"type"`        // Type/category of the alert
+	Severity    Severity    `json:"severity"`    // Severity level of the alert
+	Title       string      `json:"title"`       // Human-readable alert title
+	Description string      `json:"description"` // Detailed alert description
+	Status      AlertStatus `json:"status"`      // Status the alert transitioned to
+	Timestamp   time.Time   `json:"timestamp"`   // When the transition happened
 }
 
 // Alert represents an active alert in the system.
@@ -45,6 +65,9 @@ type Alert struct {
 	Status      AlertStatus `json:"status"`    // Current status of the alert
 	Metadata    map[string]interface{} `json:"metadata"` // Additional alert metadata
 	Count       int       `json:"count"`       // Number of times this alert has been triggered
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty"` // When notification channels were last informed about the alert
+
+	notifiedActive bool // Whether the last notification sent reported the alert as active
 }
 
 // AlertType represents the type/category of an alert.
@@ -117,11 +140,16 @@ func (am *AlertManager) EvaluateMetrics(metrics *ServerMetrics) {
 	am.mutex.Lock()
 	defer am.mutex.Unlock()
 
+	am.evaluateMetrics(metrics, time.Now())
+}
+
+// evaluateMetrics evaluates the metrics as of the given time.
+// The caller must hold the lock.
+func (am *AlertManager) evaluateMetrics(metrics *ServerMetrics, now time.Time) {
 	if !am.config.EnableAlerts {
 		return
 	}
 
-	now := time.Now()
 	am.lastEvalTime = now
 
 	// Evaluate system resource alerts
@@ -196,6 +224,7 @@ func (am *AlertManager) ResolveAlert(alertID string) error {
 	alert.Status = AlertStatusResolved
 	alert.ResolvedAt = &now
 	alert.UpdatedAt = now
+	am.notifyResolved(alert, now)
 
 	return nil
 }
@@ -224,6 +253,72 @@ func (am *AlertManager) SuppressAlert(alertID string, duration time.Duration) er
 	return nil
 }
 
+// RaiseAlert creates a new active alert or updates an existing one with the same ID.
+// This allows components outside of metric evaluation (e.g. key verification or
+// data cap enforcement) to report conditions through the alert system.
+func (am *AlertManager) RaiseAlert(id string, alertType AlertType, severity Severity, title, description string, metadata map[string]interface{}) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.createOrUpdateAlert(id, alertType, severity, title, description, time.Now(), metadata)
+}
+
+// SuppressByType suppresses all active alerts of the given type for the specified duration.
+// This is useful during a known incident when every alert of a category is expected.
+// Returns the number of alerts that were suppressed.
+func (am *AlertManager) SuppressByType(alertType AlertType, duration time.Duration) int {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := time.Now()
+	count := 0
+	for _, alert := range am.alerts {
+		if alert.Type != alertType || alert.Status != AlertStatusActive {
+			continue
+		}
+
+		alert.Status = AlertStatusSuppressed
+		alert.UpdatedAt = now
+		if alert.Metadata == nil {
+			alert.Metadata = make(map[string]interface{})
+		}
+		alert.Metadata["suppressed_until"] = now.Add(duration)
+		count++
+	}
+
+	return count
+}
+
+// ResolveByType resolves all active alerts of the given type.
+// Returns the number of alerts that were resolved.
+func (am *AlertManager) ResolveByType(alertType AlertType) int {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	now := time.Now()
+	count := 0
+	for id, alert := range am.alerts {
+		if alert.Type != alertType || alert.Status != AlertStatusActive {
+			continue
+		}
+
+		am.resolveAlert(id, now)
+		count++
+	}
+
+	return count
+}
+
+// IsValidAlertType reports whether the given type is a known alert type.
+func IsValidAlertType(alertType AlertType) bool {
+	switch alertType {
+	case AlertTypeSystem, AlertTypeNetwork, AlertTypeSecurity, AlertTypeConnection, AlertTypePerformance, AlertTypeApplication:
+		return true
+	default:
+		return false
+	}
+}
+
 // evaluateSystemAlerts checks system resource metrics against thresholds.
 func (am *AlertManager) evaluateSystemAlerts(stats SystemStats, now time.Time) {
 	// CPU usage alert
@@ -292,7 +387,7 @@ func (am *AlertManager) evaluateSecurityAlerts(stats SecurityStats, now time.Tim
 	if !stats.FirewallEnabled {
 		am.createOrUpdateAlert("security_firewall_disabled", AlertTypeSecurity, SeverityCritical,
 			"Firewall Disabled",
-			"The pfctl firewall is disabled, leaving the server vulnerable",
+			"The firewall is disabled, leaving the server vulnerable",
 			now, map[string]interface{}{
 				"firewall_enabled": stats.FirewallEnabled,
 			})
@@ -372,9 +467,23 @@ func (am *AlertManager) createOrUpdateAlert(id string, alertType AlertType, seve
 		for k, v := range metadata {
 			alert.Metadata[k] = v
 		}
+
+		// A resolved alert that triggers again becomes active again
+		if alert.Status == AlertStatusResolved {
+			alert.Status = AlertStatusActive
+			alert.ResolvedAt = nil
+			alert.Description = description
+		}
+
+		// So does a suppressed alert whose suppression window has elapsed
+		if alert.Status == AlertStatusSuppressed && suppressionExpired(alert, now) {
+			alert.Status = AlertStatusActive
+			alert.Description = description
+			delete(alert.Metadata, "suppressed_until")
+		}
 	} else {
 		// Create new alert
-		am.alerts[id] = &Alert{
+		alert = &Alert{
 			ID:          id,
 			Type:        alertType,
 			Severity:    severity,
@@ -386,9 +495,22 @@ func (am *AlertManager) createOrUpdateAlert(id string, alertType AlertType, seve
 			Metadata:    metadata,
 			Count:       1,
 		}
+		am.alerts[id] = alert
+	}
+
+	// Suppressed alerts keep counting but stay silent
+	if alert.Status == AlertStatusActive {
+		am.notifyActive(alert, now)
 	}
 }
 
+// suppressionExpired reports whether the suppression window of a suppressed
+// alert has passed at the given time.
+func suppressionExpired(alert *Alert, now time.Time) bool {
+	until, ok := alert.Metadata["suppressed_until"].(time.Time)
+	return ok && !now.Before(until)
+}
+
 // resolveAlert resolves an alert if it exists and is active.
 func (am *AlertManager) resolveAlert(id string, now time.Time) {
 	alert, exists := am.alerts[id]
@@ -396,19 +518,143 @@ func (am *AlertManager) resolveAlert(id string, now time.Time) {
 		alert.Status = AlertStatusResolved
 		alert.ResolvedAt = &now
 		alert.UpdatedAt = now
+		am.notifyResolved(alert, now)
+	}
+}
+
+// SetLogManager sets the log manager used to report notification failures.
+// Without one, failures are written to the standard logger.
+func (am *AlertManager) SetLogManager(logManager *LogManager) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	am.logManager = logManager
+}
+
+// notificationDue reports whether the cooldown since the last notification
+// about the alert has elapsed.
+func (am *AlertManager) notificationDue(alert *Alert, now time.Time) bool {
+	return alert.LastNotifiedAt == nil || now.Sub(*alert.LastNotifiedAt) >= am.config.AlertCooldown
+}
+
+// notifyActive notifies the channels about a triggered alert.
+// An alert that keeps triggering is re-notified at most once per AlertCooldown,
+// which also keeps a flapping condition from spamming the channels.
+// The caller must hold the lock.
+func (am *AlertManager) notifyActive(alert *Alert, now time.Time) {
+	if !am.notificationDue(alert, now) {
+		return
+	}
+
+	alert.LastNotifiedAt = &now
+	alert.notifiedActive = true
+	am.dispatch(alert, now)
+}
+
+// notifyResolved notifies the channels about a resolved alert, but only if
+// they were last told the alert is active; resolutions of alerts whose
+// activation fell into the cooldown are not sent.
+// The caller must hold the lock.
+func (am *AlertManager) notifyResolved(alert *Alert, now time.Time) {
+	if !alert.notifiedActive {
+		return
+	}
+
+	alert.LastNotifiedAt = &now
+	alert.notifiedActive = false
+	am.dispatch(alert, now)
+}
+
+// dispatch sends the current state of an alert to the enabled notification channels.
+// Delivery happens in the background and failures are logged, never returned.
+// The caller must hold the lock.
+func (am *AlertManager) dispatch(alert *Alert, now time.Time) {
+	if containsChannel(am.config.NotificationChannels, "log") && am.logManager != nil {
+		am.logManager.LogWarn(fmt.Sprintf("Alert %s is %s: %s", alert.ID, alert.Status, alert.Description))
+	}
+
+	webhookEnabled := containsChannel(am.config.NotificationChannels, NotificationChannelWebhook) && am.config.WebhookURL != ""
+	emailEnabled := containsChannel(am.config.NotificationChannels, NotificationChannelEmail) &&
+		am.config.Email.Enabled() && am.config.Email.Accepts(alert.Severity)
+	if !webhookEnabled && !emailEnabled {
+		return
+	}
+
+	notification := AlertNotification{
+		ID:          alert.ID,
+		Type:        alert.Type,
+		Severity:    alert.Severity,
+		Title:       alert.Title,
+		Description: alert.Description,
+		Status:      alert.Status,
+		Timestamp:   now,
+	}
+	logManager := am.logManager
+	logFailure := func(channel string, err error) {
+		message := fmt.Sprintf("Failed to send alert %s notification for %s: %v", channel, notification.ID, err)
+		if logManager != nil {
+			logManager.LogError(message)
+		} else {
+			log.Print(message)
+		}
+	}
+
+	if webhookEnabled {
+		webhook := NewWebhookNotifier(am.config.WebhookURL)
+		payload := webhookPayload(notification, am.config.WebhookFormat)
+		go func() {
+			if err := webhook.Post(payload); err != nil {
+				logFailure(NotificationChannelWebhook, err)
+			}
+		}()
+	}
+	if emailEnabled {
+		email := NewEmailNotifier(am.config.Email)
+		go func() {
+			if err := email.Send(notification); err != nil {
+				logFailure(NotificationChannelEmail, err)
+			}
+		}()
 	}
 }
 
+// containsChannel reports whether channels contains channel.
+func containsChannel(channels []string, channel string) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
 // cleanupResolvedAlerts removes old resolved alerts to prevent memory leaks.
 func (am *AlertManager) cleanupResolvedAlerts(now time.Time) {
+	// Remove alerts resolved more than 24 hours ago
+	am.purgeResolvedBefore(now.Add(-24 * time.Hour))
+}
+
+// PurgeResolvedBefore removes resolved alerts that were resolved before the given time.
+// Active and suppressed alerts are never removed.
+// Returns the number of alerts that were removed.
+func (am *AlertManager) PurgeResolvedBefore(before time.Time) int {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	return am.purgeResolvedBefore(before)
+}
+
+// purgeResolvedBefore removes resolved alerts resolved before the given time.
+// The caller must hold the lock.
+func (am *AlertManager) purgeResolvedBefore(before time.Time) int {
+	count := 0
 	for id, alert := range am.alerts {
-		if alert.Status == AlertStatusResolved && alert.ResolvedAt != nil {
-			// Remove alerts resolved more than 24 hours ago
-			if now.Sub(*alert.ResolvedAt) > 24*time.Hour {
-				delete(am.alerts, id)
-			}
+		if alert.Status == AlertStatusResolved && alert.ResolvedAt != nil && alert.ResolvedAt.Before(before) {
+			delete(am.alerts, id)
+			count++
 		}
 	}
+	return count
 }
 
 // AlertError represents an error related to alert operations.
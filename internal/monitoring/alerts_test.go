@@ -4,6 +4,9 @@
 package monitoring
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -281,6 +284,352 @@ func TestAlertManager_SuppressAlert(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "alert not found")
 	})
+
+	highCPU := &ServerMetrics{
+		SystemStats: SystemStats{
+			CPUUsage: 90.0, // Above threshold
+		},
+		SecurityStats: SecurityStats{
+			FirewallEnabled: true, // Prevent firewall alert
+		},
+	}
+
+	t.Run("should reactivate an alert once the suppression expires", func(t *testing.T) {
+		am := NewAlertManager()
+		now := time.Now()
+		am.evaluateMetrics(highCPU, now)
+		require.NoError(t, am.SuppressAlert("system_cpu_high", time.Minute))
+
+		// Still inside the window
+		am.evaluateMetrics(highCPU, now.Add(30*time.Second))
+		assert.Empty(t, am.GetActiveAlerts())
+
+		// Past the window with the condition still true
+		am.evaluateMetrics(highCPU, now.Add(2*time.Minute))
+		alerts := am.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "system_cpu_high", alerts[0].ID)
+		assert.Nil(t, alerts[0].Metadata["suppressed_until"])
+	})
+
+	t.Run("should notify when a reactivated alert is past its cooldown", func(t *testing.T) {
+		am := NewAlertManager()
+		now := time.Now()
+		am.evaluateMetrics(highCPU, now)
+		require.NoError(t, am.SuppressAlert("system_cpu_high", time.Hour))
+
+		later := now.Add(2 * time.Hour)
+		am.evaluateMetrics(highCPU, later)
+		alerts := am.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		require.NotNil(t, alerts[0].LastNotifiedAt)
+		assert.True(t, alerts[0].LastNotifiedAt.Equal(later))
+	})
+
+	t.Run("should keep an expired suppression while the condition is clear", func(t *testing.T) {
+		am := NewAlertManager()
+		now := time.Now()
+		am.evaluateMetrics(highCPU, now)
+		require.NoError(t, am.SuppressAlert("system_cpu_high", time.Minute))
+
+		normal := *highCPU
+		normal.SystemStats.CPUUsage = 50.0
+		am.evaluateMetrics(&normal, now.Add(2*time.Minute))
+		assert.Empty(t, am.GetActiveAlerts())
+	})
+}
+
+func TestAlertManager_BulkByType(t *testing.T) {
+	setup := func() *AlertManager {
+		am := NewAlertManager()
+		now := time.Now()
+		am.createOrUpdateAlert("net_1", AlertTypeNetwork, SeverityMedium, "Network 1", "desc", now, nil)
+		am.createOrUpdateAlert("net_2", AlertTypeNetwork, SeverityHigh, "Network 2", "desc", now, nil)
+		am.createOrUpdateAlert("net_3", AlertTypeNetwork, SeverityLow, "Network 3", "desc", now, nil)
+		am.createOrUpdateAlert("sys_1", AlertTypeSystem, SeverityMedium, "System 1", "desc", now, nil)
+		return am
+	}
+
+	t.Run("should suppress all active alerts of a type", func(t *testing.T) {
+		am := setup()
+
+		count := am.SuppressByType(AlertTypeNetwork, time.Hour)
+		assert.Equal(t, 3, count)
+
+		allAlerts := am.GetAllAlerts(time.Now().Add(-time.Hour))
+		for _, id := range []string{"net_1", "net_2", "net_3"} {
+			alert := findAlertByID(allAlerts, id)
+			require.NotNil(t, alert)
+			assert.Equal(t, AlertStatusSuppressed, alert.Status)
+			assert.NotNil(t, alert.Metadata["suppressed_until"])
+		}
+
+		active := am.GetActiveAlerts()
+		require.Len(t, active, 1)
+		assert.Equal(t, "sys_1", active[0].ID)
+	})
+
+	t.Run("should resolve all active alerts of a type", func(t *testing.T) {
+		am := setup()
+
+		count := am.ResolveByType(AlertTypeNetwork)
+		assert.Equal(t, 3, count)
+
+		allAlerts := am.GetAllAlerts(time.Now().Add(-time.Hour))
+		for _, id := range []string{"net_1", "net_2", "net_3"} {
+			alert := findAlertByID(allAlerts, id)
+			require.NotNil(t, alert)
+			assert.Equal(t, AlertStatusResolved, alert.Status)
+			assert.NotNil(t, alert.ResolvedAt)
+		}
+
+		system := findAlertByID(allAlerts, "sys_1")
+		require.NotNil(t, system)
+		assert.Equal(t, AlertStatusActive, system.Status)
+	})
+
+	t.Run("should not count alerts that are not active", func(t *testing.T) {
+		am := setup()
+		require.NoError(t, am.ResolveAlert("net_1"))
+
+		assert.Equal(t, 2, am.SuppressByType(AlertTypeNetwork, time.Hour))
+		assert.Equal(t, 0, am.ResolveByType(AlertTypeNetwork))
+		assert.Equal(t, 0, am.ResolveByType(AlertTypeSecurity))
+	})
+}
+
+func TestAlertManager_PurgeResolvedBefore(t *testing.T) {
+	now := time.Now()
+	cutoff := now.Add(-time.Hour)
+
+	setup := func() *AlertManager {
+		am := NewAlertManager()
+		am.createOrUpdateAlert("old_resolved", AlertTypeNetwork, SeverityMedium, "Old", "desc", now.Add(-3*time.Hour), nil)
+		am.resolveAlert("old_resolved", now.Add(-2*time.Hour))
+		am.createOrUpdateAlert("recent_resolved", AlertTypeNetwork, SeverityMedium, "Recent", "desc", now.Add(-3*time.Hour), nil)
+		am.resolveAlert("recent_resolved", now.Add(-30*time.Minute))
+		am.createOrUpdateAlert("old_active", AlertTypeSystem, SeverityHigh, "Active", "desc", now.Add(-3*time.Hour), nil)
+		am.createOrUpdateAlert("old_suppressed", AlertTypeSystem, SeverityLow, "Suppressed", "desc", now.Add(-3*time.Hour), nil)
+		require.NoError(t, am.SuppressAlert("old_suppressed", time.Hour))
+		return am
+	}
+
+	t.Run("should remove only alerts resolved before the cutoff", func(t *testing.T) {
+		am := setup()
+
+		assert.Equal(t, 1, am.PurgeResolvedBefore(cutoff))
+
+		allAlerts := am.GetAllAlerts(time.Time{})
+		assert.Nil(t, findAlertByID(allAlerts, "old_resolved"))
+		assert.NotNil(t, findAlertByID(allAlerts, "recent_resolved"))
+		assert.NotNil(t, findAlertByID(allAlerts, "old_active"))
+		assert.NotNil(t, findAlertByID(allAlerts, "old_suppressed"))
+	})
+
+	t.Run("should never remove active or suppressed alerts", func(t *testing.T) {
+		am := setup()
+
+		assert.Equal(t, 2, am.PurgeResolvedBefore(now.Add(time.Hour)))
+
+		allAlerts := am.GetAllAlerts(time.Time{})
+		require.Len(t, allAlerts, 2)
+		assert.NotNil(t, findAlertByID(allAlerts, "old_active"))
+		assert.NotNil(t, findAlertByID(allAlerts, "old_suppressed"))
+	})
+
+	t.Run("should return zero when nothing is old enough", func(t *testing.T) {
+		am := setup()
+
+		assert.Equal(t, 0, am.PurgeResolvedBefore(now.Add(-24*time.Hour)))
+		assert.Len(t, am.GetAllAlerts(time.Time{}), 4)
+	})
+}
+
+func TestAlertManager_WebhookNotifications(t *testing.T) {
+	// newWebhook returns a test server forwarding received notifications to a channel
+	newWebhook := func(t *testing.T, status int) (*httptest.Server, chan AlertNotification) {
+		received := make(chan AlertNotification, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var notification AlertNotification
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+			received <- notification
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+		return server, received
+	}
+
+	newManager := func(url string, cooldown time.Duration) *AlertManager {
+		config := NewAlertManager().GetConfig()
+		config.NotificationChannels = []string{"log", NotificationChannelWebhook}
+		config.WebhookURL = url
+		config.AlertCooldown = cooldown
+		return NewAlertManagerWithConfig(config)
+	}
+
+	expectNotification := func(t *testing.T, received chan AlertNotification) AlertNotification {
+		select {
+		case notification := <-received:
+			return notification
+		case <-time.After(time.Second):
+			t.Fatal("expected an alert notification")
+			return AlertNotification{}
+		}
+	}
+
+	expectNoNotification := func(t *testing.T, received chan AlertNotification) {
+		select {
+		case notification := <-received:
+			t.Fatalf("unexpected notification: %+v", notification)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	highCPU := &ServerMetrics{SystemStats: SystemStats{CPUUsage: 95.0}, SecurityStats: SecurityStats{FirewallEnabled: true}}
+	normalCPU := &ServerMetrics{SystemStats: SystemStats{CPUUsage: 10.0}, SecurityStats: SecurityStats{FirewallEnabled: true}}
+
+	t.Run("should post payload on alert creation and resolution", func(t *testing.T) {
+		server, received := newWebhook(t, http.StatusOK)
+		am := newManager(server.URL, 5*time.Minute)
+
+		am.EvaluateMetrics(highCPU)
+		created := expectNotification(t, received)
+		assert.Equal(t, "system_cpu_high", created.ID)
+		assert.Equal(t, AlertTypeSystem, created.Type)
+		assert.Equal(t, SeverityHigh, created.Severity)
+		assert.Equal(t, "High CPU Usage", created.Title)
+		assert.Contains(t, created.Description, "95.0%")
+		assert.Equal(t, AlertStatusActive, created.Status)
+		assert.NotZero(t, created.Timestamp)
+
+		// Still above threshold: no new transition
+		am.EvaluateMetrics(highCPU)
+		expectNoNotification(t, received)
+
+		am.EvaluateMetrics(normalCPU)
+		resolved := expectNotification(t, received)
+		assert.Equal(t, "system_cpu_high", resolved.ID)
+		assert.Equal(t, AlertStatusResolved, resolved.Status)
+	})
+
+	t.Run("should not notify again within cooldown", func(t *testing.T) {
+		server, received := newWebhook(t, http.StatusOK)
+		am := newManager(server.URL, time.Hour)
+
+		am.EvaluateMetrics(highCPU)
+		expectNotification(t, received)
+		am.EvaluateMetrics(normalCPU)
+		expectNotification(t, received)
+
+		// Flapping back to active and resolved again is suppressed by the cooldown
+		am.EvaluateMetrics(highCPU)
+		am.EvaluateMetrics(normalCPU)
+		expectNoNotification(t, received)
+
+		alerts := am.GetAllAlerts(time.Now().Add(-time.Hour))
+		require.Len(t, alerts, 1)
+		assert.Equal(t, AlertStatusResolved, alerts[0].Status)
+	})
+
+	t.Run("should notify manual resolution", func(t *testing.T) {
+		server, received := newWebhook(t, http.StatusOK)
+		am := newManager(server.URL, time.Hour)
+
+		am.RaiseAlert("application_test", AlertTypeApplication, SeverityMedium, "Test Alert", "raised by test", nil)
+		assert.Equal(t, AlertStatusActive, expectNotification(t, received).Status)
+
+		require.NoError(t, am.ResolveAlert("application_test"))
+		resolved := expectNotification(t, received)
+		assert.Equal(t, "application_test", resolved.ID)
+		assert.Equal(t, AlertStatusResolved, resolved.Status)
+	})
+
+	t.Run("should keep alerting when webhook fails", func(t *testing.T) {
+		server, received := newWebhook(t, http.StatusInternalServerError)
+		am := newManager(server.URL, time.Hour)
+
+		am.EvaluateMetrics(highCPU)
+		expectNotification(t, received)
+		assert.Len(t, am.GetActiveAlerts(), 1)
+	})
+
+	t.Run("should not post without the webhook channel", func(t *testing.T) {
+		server, received := newWebhook(t, http.StatusOK)
+		am := newManager(server.URL, time.Hour)
+		config := am.GetConfig()
+		config.NotificationChannels = []string{"log"}
+		am.UpdateConfig(config)
+
+		am.EvaluateMetrics(highCPU)
+		expectNoNotification(t, received)
+	})
+}
+
+func TestAlertManager_NotificationCooldown(t *testing.T) {
+	highCPU := &ServerMetrics{SystemStats: SystemStats{CPUUsage: 95.0}, SecurityStats: SecurityStats{FirewallEnabled: true}}
+
+	newManager := func(cooldown time.Duration) (*AlertManager, chan AlertNotification) {
+		received := make(chan AlertNotification, 10)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var notification AlertNotification
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&notification))
+			received <- notification
+		}))
+		t.Cleanup(server.Close)
+
+		config := NewAlertManager().GetConfig()
+		config.NotificationChannels = []string{NotificationChannelWebhook}
+		config.WebhookURL = server.URL
+		config.AlertCooldown = cooldown
+		return NewAlertManagerWithConfig(config), received
+	}
+
+	// drain collects the notifications delivered shortly after the evaluations
+	drain := func(received chan AlertNotification) []AlertNotification {
+		var notifications []AlertNotification
+		for {
+			select {
+			case notification := <-received:
+				notifications = append(notifications, notification)
+			case <-time.After(100 * time.Millisecond):
+				return notifications
+			}
+		}
+	}
+
+	t.Run("should notify once for repeated triggers within cooldown", func(t *testing.T) {
+		am, received := newManager(5 * time.Minute)
+
+		for i := 0; i < 3; i++ {
+			am.EvaluateMetrics(highCPU)
+		}
+
+		notifications := drain(received)
+		require.Len(t, notifications, 1)
+		assert.Equal(t, "system_cpu_high", notifications[0].ID)
+		assert.Equal(t, AlertStatusActive, notifications[0].Status)
+
+		// The alert state itself keeps updating
+		alerts := am.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 3, alerts[0].Count)
+		require.NotNil(t, alerts[0].LastNotifiedAt)
+		assert.True(t, alerts[0].LastNotifiedAt.Equal(alerts[0].CreatedAt))
+	})
+
+	t.Run("should notify again once cooldown elapsed", func(t *testing.T) {
+		am, received := newManager(20 * time.Millisecond)
+
+		am.EvaluateMetrics(highCPU)
+		am.EvaluateMetrics(highCPU)
+		time.Sleep(30 * time.Millisecond)
+		am.EvaluateMetrics(highCPU)
+
+		notifications := drain(received)
+		require.Len(t, notifications, 2)
+		assert.Equal(t, AlertStatusActive, notifications[1].Status)
+	})
 }
 
 func TestAlertManager_GetAllAlerts(t *testing.T) {
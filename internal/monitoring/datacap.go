@@ -0,0 +1,243 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/wireguard"
+)
+
+// DataCapEnforcer enforces per-client data caps within monthly accounting periods.
+// Crossing the soft limit raises an alert while the client stays connected;
+// crossing the hard limit removes the client's peer and disables the client
+// until the next accounting period starts.
+type DataCapEnforcer struct {
+	db           *database.Database         // Database connection for client usage and state
+	wgServer     *wireguard.WireGuardServer // WireGuard server for removing and restoring peers
+	alertManager *AlertManager              // Alert manager for cap notifications
+	config       DataCapConfig              // Data cap configuration
+}
+
+// DataCapConfig represents configuration options for data cap enforcement.
+type DataCapConfig struct {
+	TagPolicies map[string]DataCapPolicy `json:"tag_policies"` // Default caps per client tag
+	ResetDay    int                      `json:"reset_day"`    // Day of the month (1-28) the accounting period starts
+}
+
+// DataCapPolicy represents the soft and hard usage thresholds for an accounting period.
+// A zero value disables the corresponding threshold.
+type DataCapPolicy struct {
+	SoftLimitBytes uint64 `json:"soft_limit_bytes"` // Usage that raises an alert
+	HardLimitBytes uint64 `json:"hard_limit_bytes"` // Usage that disables the client
+}
+
+// NewDataCapEnforcer creates a new data cap enforcer with default configuration.
+// By default only caps set directly on clients are enforced and the accounting
+// period starts on the first day of each month.
+// Returns a pointer to the newly created DataCapEnforcer.
+func NewDataCapEnforcer(db *database.Database, wgServer *wireguard.WireGuardServer, alertManager *AlertManager) *DataCapEnforcer {
+	config := DataCapConfig{
+		TagPolicies: map[string]DataCapPolicy{},
+		ResetDay:    1,
+	}
+
+	return NewDataCapEnforcerWithConfig(db, wgServer, alertManager, config)
+}
+
+// NewDataCapEnforcerWithConfig creates a new data cap enforcer with custom configuration.
+// Returns a pointer to the newly created DataCapEnforcer.
+func NewDataCapEnforcerWithConfig(db *database.Database, wgServer *wireguard.WireGuardServer, alertManager *AlertManager, config DataCapConfig) *DataCapEnforcer {
+	if config.ResetDay < 1 || config.ResetDay > 28 {
+		config.ResetDay = 1
+	}
+
+	return &DataCapEnforcer{
+		db:           db,
+		wgServer:     wgServer,
+		alertManager: alertManager,
+		config:       config,
+	}
+}
+
+// Enforce evaluates the usage of every client in the current accounting period
+// and applies the soft and hard thresholds. Clients disabled by a hard cap are
+// re-enabled once a new accounting period starts.
+func (e *DataCapEnforcer) Enforce(now time.Time) error {
+	clients, err := e.db.ListClients()
+	if err != nil {
+		return fmt.Errorf("failed to get clients: %w", err)
+	}
+
+	periodStart := e.periodStart(now)
+	for i := range clients {
+		if err := e.enforceClient(&clients[i], periodStart); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enforceClient applies the data cap policy to a single client.
+func (e *DataCapEnforcer) enforceClient(client *database.Client, periodStart time.Time) error {
+	total := client.BytesReceived + client.BytesSent
+
+	// Start a new accounting period, lifting any hard cap from the previous one
+	if client.UsagePeriodStart == nil || client.UsagePeriodStart.Before(periodStart) {
+		return e.resetPeriod(client, periodStart, total)
+	}
+
+	// Counters went backwards (e.g. interface recreated), restart the baseline
+	if total < client.UsageBaselineBytes {
+		client.UsageBaselineBytes = total
+		if err := e.db.UpdateClient(client); err != nil {
+			return fmt.Errorf("failed to update client usage baseline: %w", err)
+		}
+		return nil
+	}
+
+	usage := total - client.UsageBaselineBytes
+	policy := e.policyFor(client)
+
+	if policy.HardLimitBytes > 0 && usage >= policy.HardLimitBytes {
+		if client.DataCapExceeded {
+			return nil
+		}
+		return e.applyHardCap(client, usage, policy.HardLimitBytes)
+	}
+
+	if policy.SoftLimitBytes > 0 && usage >= policy.SoftLimitBytes {
+		e.alertManager.RaiseAlert(softCapAlertID(client.ID), AlertTypeNetwork, SeverityMedium,
+			"Client Data Cap Warning",
+			fmt.Sprintf("Client %s used %d bytes, exceeding its soft cap of %d bytes", client.Name, usage, policy.SoftLimitBytes),
+			map[string]interface{}{
+				"client_id":  client.ID,
+				"usage":      usage,
+				"soft_limit": policy.SoftLimitBytes,
+			})
+	}
+
+	return nil
+}
+
+// applyHardCap disables a client that crossed its hard cap and removes its peer.
+func (e *DataCapEnforcer) applyHardCap(client *database.Client, usage, limit uint64) error {
+	client.Enabled = false
+	client.DataCapExceeded = true
+	if err := e.db.UpdateClient(client); err != nil {
+		return fmt.Errorf("failed to disable client: %w", err)
+	}
+
+	e.alertManager.RaiseAlert(hardCapAlertID(client.ID), AlertTypeNetwork, SeverityHigh,
+		"Client Data Cap Exceeded",
+		fmt.Sprintf("Client %s used %d bytes, exceeding its hard cap of %d bytes, and was disabled", client.Name, usage, limit),
+		map[string]interface{}{
+			"client_id":  client.ID,
+			"usage":      usage,
+			"hard_limit": limit,
+		})
+
+	// Removing the peer may fail if the interface is not configured; the client
+	// stays disabled in the database either way
+	if err := e.wgServer.RemovePeer(client.PublicKey); err != nil {
+		return nil
+	}
+	if err := e.wgServer.ApplyConfig(); err != nil {
+		return fmt.Errorf("failed to sync WireGuard interface: %w", err)
+	}
+	return nil
+}
+
+// resetPeriod starts a new accounting period for a client.
+func (e *DataCapEnforcer) resetPeriod(client *database.Client, periodStart time.Time, total uint64) error {
+	start := periodStart
+	client.UsagePeriodStart = &start
+	client.UsageBaselineBytes = total
+
+	restorePeer := false
+	if client.DataCapExceeded {
+		client.DataCapExceeded = false
+		// A client that also used up its data quota stays disabled until the quota is reset
+		if !client.DataQuotaExceeded {
+			client.Enabled = true
+			restorePeer = true
+		}
+	}
+
+	if err := e.db.UpdateClient(client); err != nil {
+		return fmt.Errorf("failed to reset client usage period: %w", err)
+	}
+
+	e.alertManager.ResolveAlert(softCapAlertID(client.ID))
+	e.alertManager.ResolveAlert(hardCapAlertID(client.ID))
+
+	if !restorePeer {
+		return nil
+	}
+
+	// The peer may still be configured if removing it failed when the cap was hit
+	present, err := e.wgServer.HasPeer(client.PublicKey)
+	if err != nil {
+		return fmt.Errorf("failed to restore peer of client %s: %w", client.Name, err)
+	}
+	if present {
+		return nil
+	}
+
+	defaultKeepalive := 0
+	if serverConfig, err := e.db.GetServerConfig(); err == nil {
+		defaultKeepalive = serverConfig.DefaultKeepalive
+	}
+	peer := wireguard.NewClientPeer(client.PublicKey, client.PresharedKey, client.IPAddress, client.Keepalive(defaultKeepalive))
+	if err := e.wgServer.AddPeer(peer); err != nil {
+		return fmt.Errorf("failed to restore peer of client %s: %w", client.Name, err)
+	}
+	if err := e.wgServer.ApplyConfig(); err != nil {
+		return fmt.Errorf("failed to sync WireGuard interface: %w", err)
+	}
+	return nil
+}
+
+// policyFor returns the effective data cap policy for a client.
+// Thresholds set on the client take precedence over those of its tags.
+func (e *DataCapEnforcer) policyFor(client *database.Client) DataCapPolicy {
+	policy := DataCapPolicy{
+		SoftLimitBytes: client.DataCapSoftBytes,
+		HardLimitBytes: client.DataCapHardBytes,
+	}
+
+	for _, tag := range strings.Split(client.Tags, ",") {
+		tagPolicy, ok := e.config.TagPolicies[strings.TrimSpace(tag)]
+		if !ok {
+			continue
+		}
+		if policy.SoftLimitBytes == 0 {
+			policy.SoftLimitBytes = tagPolicy.SoftLimitBytes
+		}
+		if policy.HardLimitBytes == 0 {
+			policy.HardLimitBytes = tagPolicy.HardLimitBytes
+		}
+	}
+
+	return policy
+}
+
+// periodStart returns the start of the accounting period containing now.
+func (e *DataCapEnforcer) periodStart(now time.Time) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), e.config.ResetDay, 0, 0, 0, 0, time.UTC)
+	if start.After(now) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+func softCapAlertID(clientID uint) string {
+	return fmt.Sprintf("network_data_cap_soft_%d", clientID)
+}
+
+func hardCapAlertID(clientID uint) string {
+	return fmt.Sprintf("network_data_cap_hard_%d", clientID)
+}
@@ -0,0 +1,279 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/system"
+	"my-vpn/internal/wireguard"
+)
+
+// cappedClientKey is a well-formed public key, as AddPeer rejects malformed keys
+const cappedClientKey = "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE="
+
+const dataCapTestConfig = `[Interface]
+PrivateKey = test-private-key
+Address = 10.0.0.1/24
+ListenPort = 51820
+
+[Peer]
+PublicKey = ` + cappedClientKey + `
+AllowedIPs = 10.0.0.2/32
+`
+
+func setupTestDataCapEnforcer(t *testing.T) (*DataCapEnforcer, *database.Database, *wireguard.WireGuardServer) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&database.Client{}))
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(dataCapTestConfig), 0600))
+
+	testDB := &database.Database{DB: db}
+	wgServer := wireguard.NewWireGuardServerWithConfig(tempDir, "wg0")
+
+	return NewDataCapEnforcer(testDB, wgServer, NewAlertManager()), testDB, wgServer
+}
+
+func createCappedClient(t *testing.T, db *database.Database, soft, hard uint64) *database.Client {
+	client := &database.Client{
+		Name:             "capped",
+		PublicKey:        cappedClientKey,
+		PrivateKey:       "capped-private-key",
+		IPAddress:        "10.0.0.2",
+		Enabled:          true,
+		DataCapSoftBytes: soft,
+		DataCapHardBytes: hard,
+	}
+	require.NoError(t, db.CreateClient(client))
+	return client
+}
+
+func setClientUsage(t *testing.T, db *database.Database, id uint, received, sent uint64) {
+	client, err := db.GetClient(id)
+	require.NoError(t, err)
+	client.BytesReceived = received
+	client.BytesSent = sent
+	require.NoError(t, db.UpdateClient(client))
+}
+
+func TestDataCapEnforcer_Enforce(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should alert without disconnecting when only soft cap is crossed", func(t *testing.T) {
+		enforcer, db, wgServer := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		// First pass starts the accounting period
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 800, 700)
+		require.NoError(t, enforcer.Enforce(now))
+
+		alerts := enforcer.alertManager.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, softCapAlertID(client.ID), alerts[0].ID)
+		assert.Equal(t, SeverityMedium, alerts[0].Severity)
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.Enabled)
+		assert.False(t, updated.DataCapExceeded)
+
+		peers, err := wgServer.GetPeers()
+		require.NoError(t, err)
+		assert.Len(t, peers, 1)
+	})
+
+	t.Run("should disable client and remove peer when hard cap is crossed", func(t *testing.T) {
+		enforcer, db, wgServer := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 3000, 2500)
+		require.NoError(t, enforcer.Enforce(now))
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.Enabled)
+		assert.True(t, updated.DataCapExceeded)
+
+		peers, err := wgServer.GetPeers()
+		require.NoError(t, err)
+		assert.Empty(t, peers)
+
+		alerts := enforcer.alertManager.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, hardCapAlertID(client.ID), alerts[0].ID)
+		assert.Equal(t, SeverityHigh, alerts[0].Severity)
+	})
+
+	t.Run("should only count usage since the start of the period", func(t *testing.T) {
+		enforcer, db, _ := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+		setClientUsage(t, db, client.ID, 10000, 10000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		require.NoError(t, enforcer.Enforce(now))
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.Enabled)
+		assert.Equal(t, uint64(20000), updated.UsageBaselineBytes)
+		assert.Empty(t, enforcer.alertManager.GetActiveAlerts())
+	})
+
+	t.Run("should re-enable client at the accounting boundary", func(t *testing.T) {
+		enforcer, db, wgServer := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 6000, 0)
+		require.NoError(t, enforcer.Enforce(now))
+
+		require.NoError(t, enforcer.Enforce(now.AddDate(0, 1, 0)))
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.Enabled)
+		assert.False(t, updated.DataCapExceeded)
+		assert.Empty(t, enforcer.alertManager.GetActiveAlerts())
+
+		peers, err := wgServer.GetPeers()
+		require.NoError(t, err)
+		require.Len(t, peers, 1)
+		assert.Equal(t, cappedClientKey, peers[0].PublicKey)
+	})
+
+	t.Run("should not add the peer twice when it is still configured at the boundary", func(t *testing.T) {
+		enforcer, db, wgServer := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 6000, 0)
+		require.NoError(t, enforcer.Enforce(now))
+		require.NoError(t, wgServer.AddPeer(wireguard.NewClientPeer(cappedClientKey, "", "10.0.0.2", 0)))
+
+		require.NoError(t, enforcer.Enforce(now.AddDate(0, 1, 0)))
+
+		peers, err := wgServer.GetPeers()
+		require.NoError(t, err)
+		assert.Len(t, peers, 1)
+	})
+
+	t.Run("should keep client disabled at the boundary while its data quota is used up", func(t *testing.T) {
+		enforcer, db, _ := setupTestDataCapEnforcer(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 6000, 0)
+		require.NoError(t, enforcer.Enforce(now))
+
+		capped, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		capped.DataQuotaExceeded = true
+		require.NoError(t, db.UpdateClient(capped))
+
+		require.NoError(t, enforcer.Enforce(now.AddDate(0, 1, 0)))
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.Enabled)
+		assert.False(t, updated.DataCapExceeded)
+	})
+
+	t.Run("should fall back to tag policies", func(t *testing.T) {
+		enforcer, db, _ := setupTestDataCapEnforcer(t)
+		enforcer.config.TagPolicies = map[string]DataCapPolicy{
+			"guest": {SoftLimitBytes: 100, HardLimitBytes: 200},
+		}
+		client := createCappedClient(t, db, 0, 0)
+		client.Tags = "mobile,guest"
+		require.NoError(t, db.UpdateClient(client))
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 150, 100)
+		require.NoError(t, enforcer.Enforce(now))
+
+		updated, err := db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.Enabled)
+		assert.True(t, updated.DataCapExceeded)
+	})
+}
+
+func TestDataCapEnforcer_SyncRunningInterface(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	// setup swaps in a WireGuard server whose mock runner reports the
+	// interface as running
+	setup := func(t *testing.T) (*DataCapEnforcer, *database.Database, *system.MockRunner) {
+		enforcer, db, wgServer := setupTestDataCapEnforcer(t)
+		runner := system.NewMockRunner()
+		enforcer.wgServer = wireguard.NewWireGuardServerWithRunner(filepath.Dir(wgServer.GetConfigPath()), "wg0", runner)
+		return enforcer, db, runner
+	}
+
+	t.Run("should disconnect the client live when the hard cap is crossed", func(t *testing.T) {
+		enforcer, db, runner := setup(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 3000, 2500)
+		require.NoError(t, enforcer.Enforce(now))
+
+		assert.Contains(t, runner.Commands(), "wg-quick strip "+enforcer.wgServer.GetConfigPath())
+	})
+
+	t.Run("should reconnect the client live at the accounting boundary", func(t *testing.T) {
+		enforcer, db, runner := setup(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 6000, 0)
+		require.NoError(t, enforcer.Enforce(now))
+		runner.Reset()
+
+		require.NoError(t, enforcer.Enforce(now.AddDate(0, 1, 0)))
+
+		hasPeer, err := enforcer.wgServer.HasPeer(cappedClientKey)
+		require.NoError(t, err)
+		assert.True(t, hasPeer)
+		assert.Contains(t, runner.Commands(), "wg-quick strip "+enforcer.wgServer.GetConfigPath())
+	})
+
+	t.Run("should report a peer that cannot be restored", func(t *testing.T) {
+		enforcer, db, _ := setup(t)
+		client := createCappedClient(t, db, 1000, 5000)
+
+		require.NoError(t, enforcer.Enforce(now))
+		setClientUsage(t, db, client.ID, 6000, 0)
+		require.NoError(t, enforcer.Enforce(now))
+		require.NoError(t, os.Remove(enforcer.wgServer.GetConfigPath()))
+
+		err := enforcer.Enforce(now.AddDate(0, 1, 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to restore peer")
+	})
+}
+
+func TestDataCapEnforcer_PeriodStart(t *testing.T) {
+	enforcer := NewDataCapEnforcerWithConfig(nil, nil, nil, DataCapConfig{ResetDay: 10})
+
+	t.Run("should use reset day of the current month", func(t *testing.T) {
+		start := enforcer.periodStart(time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2026, time.March, 10, 0, 0, 0, 0, time.UTC), start)
+	})
+
+	t.Run("should use reset day of the previous month before the boundary", func(t *testing.T) {
+		start := enforcer.periodStart(time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, time.Date(2025, time.December, 10, 0, 0, 0, 0, time.UTC), start)
+	})
+}
@@ -0,0 +1,152 @@
+package monitoring
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NotificationChannelEmail mails alert transitions to AlertConfig.Email.To.
+const NotificationChannelEmail = "email"
+
+// Defaults for the email channel.
+const (
+	defaultSMTPPort = 587              // Mail submission port
+	smtpTimeout     = 10 * time.Second // Bounds a single delivery, like webhookTimeout
+)
+
+// EmailConfig holds the SMTP settings of the "email" notification channel.
+type EmailConfig struct {
+	Host        string   `json:"host"`         // SMTP server host
+	Port        int      `json:"port"`         // SMTP server port (0 uses 587)
+	Username    string   `json:"username"`     // SMTP user (empty sends without authentication)
+	Password    string   `json:"-"`            // SMTP password
+	From        string   `json:"from"`         // Sender address
+	To          []string `json:"to"`           // Recipient addresses
+	MinSeverity Severity `json:"min_severity"` // Lowest severity that is mailed (empty mails only critical alerts)
+}
+
+// Enabled reports whether the server and at least one recipient are configured.
+func (c *EmailConfig) Enabled() bool {
+	return c.Host != "" && len(c.To) > 0
+}
+
+// Accepts reports whether alerts of the given severity are mailed.
+func (c *EmailConfig) Accepts(severity Severity) bool {
+	minSeverity := c.MinSeverity
+	if minSeverity == "" {
+		minSeverity = SeverityCritical
+	}
+	return severityRank(severity) >= severityRank(minSeverity)
+}
+
+// severityRank orders severities from low to critical.
+func severityRank(severity Severity) int {
+	switch severity {
+	case SeverityLow:
+		return 0
+	case SeverityMedium:
+		return 1
+	case SeverityHigh:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// EmailNotifier mails alert notifications through an SMTP server.
+// STARTTLS is used when the server offers it.
+type EmailNotifier struct {
+	config EmailConfig
+}
+
+// NewEmailNotifier creates a notifier mailing alerts with the given settings.
+func NewEmailNotifier(config EmailConfig) *EmailNotifier {
+	return &EmailNotifier{config: config}
+}
+
+// Send mails the notification to all recipients.
+// Returns an error if connecting to the server or any SMTP command fails.
+func (n *EmailNotifier) Send(notification AlertNotification) error {
+	port := n.config.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(n.config.Host, strconv.Itoa(port))
+
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, n.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.config.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if n.config.Username != "" {
+		auth := smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+		}
+	}
+
+	if err := client.Mail(n.config.From); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	for _, recipient := range n.config.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed to add recipient %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := writer.Write(formatAlertEmail(n.config.From, n.config.To, notification)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// formatAlertEmail renders the notification as a plain text message with headers.
+func formatAlertEmail(from string, to []string, notification AlertNotification) []byte {
+	var message strings.Builder
+
+	subject := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(notification.Severity)), notification.Title, notification.Status)
+	message.WriteString("From: " + from + "\r\n")
+	message.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	message.WriteString("Subject: " + subject + "\r\n")
+	message.WriteString("Date: " + notification.Timestamp.Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("\r\n")
+
+	message.WriteString(fmt.Sprintf("Alert:       %s\r\n", notification.ID))
+	message.WriteString(fmt.Sprintf("Status:      %s\r\n", notification.Status))
+	message.WriteString(fmt.Sprintf("Severity:    %s\r\n", notification.Severity))
+	message.WriteString(fmt.Sprintf("Type:        %s\r\n", notification.Type))
+	message.WriteString(fmt.Sprintf("Time:        %s\r\n", notification.Timestamp.Format(time.RFC3339)))
+	message.WriteString("\r\n")
+	message.WriteString(notification.Description + "\r\n")
+
+	return []byte(message.String())
+}
@@ -0,0 +1,252 @@
+package monitoring
+
+import (
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedEmail is a message received by fakeSMTPServer.
+type capturedEmail struct {
+	auth string   // Decoded AUTH PLAIN credentials ("" if none were sent)
+	from string   // MAIL FROM address
+	to   []string // RCPT TO addresses
+	data string   // Message headers and body
+}
+
+// fakeSMTPServer speaks just enough SMTP for net/smtp to deliver a message
+// and forwards every received message to a channel.
+type fakeSMTPServer struct {
+	listener net.Listener
+	received chan capturedEmail
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeSMTPServer{listener: listener, received: make(chan capturedEmail, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return server
+}
+
+// config returns email settings pointing at the fake server.
+func (s *fakeSMTPServer) config() EmailConfig {
+	host, portStr, _ := net.SplitHostPort(s.listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return EmailConfig{
+		Host:     host,
+		Port:     port,
+		Username: "alerts",
+		Password: "secret",
+		From:     "vpn@example.com",
+		To:       []string{"ops@example.com", "oncall@example.com"},
+	}
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake SMTP ready")
+
+	var email capturedEmail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-fake greets you")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			_, encoded, _ := strings.Cut(argument, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			email.auth = string(decoded)
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			email.from = strings.Trim(strings.TrimPrefix(argument, "FROM:"), "<>")
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			email.to = append(email.to, strings.Trim(strings.TrimPrefix(argument, "TO:"), "<>"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 send the message")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			email.data = strings.Join(lines, "\n")
+			text.PrintfLine("250 queued")
+			s.received <- email
+			email = capturedEmail{auth: email.auth}
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) expectEmail(t *testing.T) capturedEmail {
+	select {
+	case email := <-s.received:
+		return email
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected an email")
+		return capturedEmail{}
+	}
+}
+
+func (s *fakeSMTPServer) expectNoEmail(t *testing.T) {
+	select {
+	case email := <-s.received:
+		t.Fatalf("unexpected email: %+v", email)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEmailConfig_Accepts(t *testing.T) {
+	t.Run("should only accept critical alerts by default", func(t *testing.T) {
+		config := EmailConfig{}
+
+		assert.True(t, config.Accepts(SeverityCritical))
+		assert.False(t, config.Accepts(SeverityHigh))
+		assert.False(t, config.Accepts(SeverityLow))
+	})
+
+	t.Run("should accept alerts at or above the minimum severity", func(t *testing.T) {
+		config := EmailConfig{MinSeverity: SeverityMedium}
+
+		assert.True(t, config.Accepts(SeverityCritical))
+		assert.True(t, config.Accepts(SeverityHigh))
+		assert.True(t, config.Accepts(SeverityMedium))
+		assert.False(t, config.Accepts(SeverityLow))
+	})
+}
+
+func TestEmailNotifier_Send(t *testing.T) {
+	t.Run("should deliver a formatted message to all recipients", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		notifier := NewEmailNotifier(server.config())
+
+		err := notifier.Send(AlertNotification{
+			ID:          "security_firewall_disabled",
+			Type:        AlertTypeSecurity,
+			Severity:    SeverityCritical,
+			Title:       "Firewall Disabled",
+			Description: "The firewall is disabled",
+			Status:      AlertStatusActive,
+			Timestamp:   time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+
+		email := server.expectEmail(t)
+		assert.Equal(t, "\x00alerts\x00secret", email.auth)
+		assert.Equal(t, "vpn@example.com", email.from)
+		assert.Equal(t, []string{"ops@example.com", "oncall@example.com"}, email.to)
+		assert.Contains(t, email.data, "Subject: [CRITICAL] Firewall Disabled: active")
+		assert.Contains(t, email.data, "To: ops@example.com, oncall@example.com")
+		assert.Contains(t, email.data, "Alert:       security_firewall_disabled")
+		assert.Contains(t, email.data, "The firewall is disabled")
+	})
+
+	t.Run("should fail when the server is unreachable", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		config := server.config()
+		server.listener.Close()
+
+		err := NewEmailNotifier(config).Send(AlertNotification{ID: "test"})
+		assert.Error(t, err)
+	})
+}
+
+func TestAlertManager_EmailNotifications(t *testing.T) {
+	newManager := func(email EmailConfig, cooldown time.Duration) *AlertManager {
+		config := NewAlertManager().GetConfig()
+		config.NotificationChannels = []string{"log", NotificationChannelEmail}
+		config.Email = email
+		config.AlertCooldown = cooldown
+		return NewAlertManagerWithConfig(config)
+	}
+
+	t.Run("should mail critical alerts when raised and resolved", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		am := newManager(server.config(), time.Hour)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+		raised := server.expectEmail(t)
+		assert.Contains(t, raised.data, "Subject: [CRITICAL] Breach Detected: active")
+		assert.Contains(t, raised.data, "Unexpected peer")
+
+		require.NoError(t, am.ResolveAlert("security_breach"))
+		resolved := server.expectEmail(t)
+		assert.Contains(t, resolved.data, "Subject: [CRITICAL] Breach Detected: resolved")
+	})
+
+	t.Run("should skip alerts below the minimum severity", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		am := newManager(server.config(), time.Hour)
+
+		am.RaiseAlert("application_test", AlertTypeApplication, SeverityHigh, "Test Alert", "raised by test", nil)
+		server.expectNoEmail(t)
+
+		config := server.config()
+		config.MinSeverity = SeverityHigh
+		am = newManager(config, time.Hour)
+		am.RaiseAlert("application_test", AlertTypeApplication, SeverityHigh, "Test Alert", "raised by test", nil)
+		server.expectEmail(t)
+	})
+
+	t.Run("should respect the cooldown", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		am := newManager(server.config(), time.Hour)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+		server.expectEmail(t)
+		require.NoError(t, am.ResolveAlert("security_breach"))
+		server.expectEmail(t)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+		server.expectNoEmail(t)
+	})
+
+	t.Run("should not mail without the email channel", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		am := newManager(server.config(), time.Hour)
+		config := am.GetConfig()
+		config.NotificationChannels = []string{"log"}
+		am.UpdateConfig(config)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+		server.expectNoEmail(t)
+	})
+
+	t.Run("should keep alerting when delivery fails", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		config := server.config()
+		server.listener.Close()
+		am := newManager(config, time.Hour)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+		assert.Len(t, am.GetActiveAlerts(), 1)
+	})
+}
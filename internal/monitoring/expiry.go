@@ -0,0 +1,42 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// disableExpiredClients disables every enabled client whose expiry date is at
+// or before now and removes its peer. A running interface is synced once all
+// peers are removed, so expired clients lose access without a restart.
+// Returns an error if a client cannot be disabled or the interface cannot be
+// synced; clients disabled before the failure stay disabled.
+func (m *Monitor) disableExpiredClients(now time.Time) error {
+	clients, err := m.db.ListExpiredClients(now)
+	if err != nil {
+		return fmt.Errorf("failed to get expired clients: %w", err)
+	}
+	if len(clients) == 0 {
+		return nil
+	}
+
+	for i := range clients {
+		client := &clients[i]
+		client.Enabled = false
+		if err := m.db.UpdateClient(client); err != nil {
+			return fmt.Errorf("failed to disable expired client %s: %w", client.Name, err)
+		}
+
+		// Removing the peer may fail if the interface is not configured; the
+		// client stays disabled in the database either way
+		m.wgServer.RemovePeer(client.PublicKey)
+
+		m.logManager.LogInfo(fmt.Sprintf("Client %s expired at %s and was disabled",
+			client.Name, client.ExpiresAt.Format(time.RFC3339)))
+	}
+
+	if err := m.wgServer.ApplyConfig(); err != nil {
+		return fmt.Errorf("failed to sync WireGuard interface: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/system"
+	"my-vpn/internal/wireguard"
+)
+
+const expiryTestConfig = `[Interface]
+PrivateKey = test-private-key
+Address = 10.0.0.1/24
+ListenPort = 51820
+
+[Peer]
+PublicKey = contractor-key
+AllowedIPs = 10.0.0.2/32
+
+[Peer]
+PublicKey = employee-key
+AllowedIPs = 10.0.0.3/32
+`
+
+func TestMonitor_DisableExpiredClients(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	// setup returns a monitor whose WireGuard server uses a temporary config
+	// and a mock runner reporting the interface as running
+	setup := func(t *testing.T) (*Monitor, *system.MockRunner, func()) {
+		monitor, cleanup := setupTestMonitor(t)
+
+		tempDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(expiryTestConfig), 0600))
+		runner := system.NewMockRunner()
+		monitor.wgServer = wireguard.NewWireGuardServerWithRunner(tempDir, "wg0", runner)
+
+		return monitor, runner, cleanup
+	}
+
+	createClient := func(t *testing.T, monitor *Monitor, name, publicKey, ip string, expiresAt *time.Time) *database.Client {
+		client := &database.Client{
+			Name:       name,
+			PublicKey:  publicKey,
+			PrivateKey: name + "-private-key",
+			IPAddress:  ip,
+			Enabled:    true,
+			ExpiresAt:  expiresAt,
+		}
+		require.NoError(t, monitor.db.CreateClient(client))
+		return client
+	}
+
+	t.Run("should disable expired clients and remove their peers", func(t *testing.T) {
+		monitor, runner, cleanup := setup(t)
+		defer cleanup()
+
+		expired := now.Add(-time.Hour)
+		contractor := createClient(t, monitor, "contractor", "contractor-key", "10.0.0.2", &expired)
+		employee := createClient(t, monitor, "employee", "employee-key", "10.0.0.3", nil)
+
+		require.NoError(t, monitor.disableExpiredClients(now))
+
+		stored, err := monitor.db.GetClient(contractor.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Enabled)
+
+		stored, err = monitor.db.GetClient(employee.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Enabled)
+
+		hasPeer, err := monitor.wgServer.HasPeer("contractor-key")
+		require.NoError(t, err)
+		assert.False(t, hasPeer)
+		hasPeer, err = monitor.wgServer.HasPeer("employee-key")
+		require.NoError(t, err)
+		assert.True(t, hasPeer)
+
+		assert.Contains(t, runner.Commands(), "wg-quick strip "+monitor.wgServer.GetConfigPath())
+	})
+
+	t.Run("should keep clients that have not expired yet", func(t *testing.T) {
+		monitor, runner, cleanup := setup(t)
+		defer cleanup()
+
+		expiresAt := now.Add(time.Hour)
+		contractor := createClient(t, monitor, "contractor", "contractor-key", "10.0.0.2", &expiresAt)
+
+		require.NoError(t, monitor.disableExpiredClients(now))
+
+		stored, err := monitor.db.GetClient(contractor.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Enabled)
+		assert.Empty(t, runner.Commands())
+	})
+
+	t.Run("should compare expiry dates across time zones", func(t *testing.T) {
+		monitor, _, cleanup := setup(t)
+		defer cleanup()
+
+		// 12:30 in UTC+9 is 03:30 UTC, well before now
+		expired := time.Date(2026, time.March, 15, 12, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+		contractor := createClient(t, monitor, "contractor", "contractor-key", "10.0.0.2", &expired)
+
+		require.NoError(t, monitor.disableExpiredClients(now))
+
+		stored, err := monitor.db.GetClient(contractor.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Enabled)
+	})
+}
@@ -0,0 +1,182 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+)
+
+// Subsystem names reported by the detailed health check.
+const (
+	SubsystemDatabase  = "database"
+	SubsystemWireGuard = "wireguard"
+	SubsystemFirewall  = "firewall"
+	SubsystemIPPool    = "ip_pool"
+	SubsystemMonitor   = "monitor"
+)
+
+// IP pool utilization thresholds (percent) used by the detailed health check.
+const (
+	ipPoolDegradedUtilization = 90.0
+	ipPoolDownUtilization     = 100.0
+)
+
+// HealthReport represents the health of each server subsystem together with
+// the aggregated overall status.
+type HealthReport struct {
+	Status     ServerStatus      `json:"status"`     // Worst status across all subsystems
+	Timestamp  time.Time         `json:"timestamp"`  // When the report was generated
+	Subsystems []SubsystemHealth `json:"subsystems"` // Per-subsystem health details
+}
+
+// SubsystemHealth represents the health of a single server subsystem.
+type SubsystemHealth struct {
+	Name    string       `json:"name"`    // Subsystem name (e.g., "database", "firewall")
+	Status  ServerStatus `json:"status"`  // healthy, degraded or down
+	Message string       `json:"message"` // Human readable explanation of the status
+}
+
+// collectorErrors holds the failures of the collectors in the latest metrics
+// collection, so the detailed health check can report them without collecting again.
+type collectorErrors struct {
+	database  error
+	wireGuard error
+	security  error
+}
+
+// healthInputs holds the raw observations a health report is evaluated from.
+type healthInputs struct {
+	dbErr           error
+	wgStats         WireGuardStats
+	wgErr           error
+	securityStats   SecurityStats
+	securityErr     error
+	poolUtilization float64
+	poolAvailable   int
+	monitorRunning  bool
+	lastUpdate      time.Time
+	updateInterval  time.Duration
+}
+
+// GetDetailedHealth checks each subsystem individually and returns a report
+// pinpointing which of them are unhealthy. The report is built from the
+// metrics of the latest collection, so it matches what the monitoring loop
+// observes and runs no external commands. Collector failures are reported
+// with fixed messages; their details are logged when metrics are collected.
+func (m *Monitor) GetDetailedHealth() *HealthReport {
+	m.mutex.RLock()
+	inputs := healthInputs{
+		dbErr:          m.collectErrors.database,
+		wgStats:        m.metrics.WireGuardStats,
+		wgErr:          m.collectErrors.wireGuard,
+		securityStats:  m.metrics.SecurityStats,
+		securityErr:    m.collectErrors.security,
+		monitorRunning: m.running,
+		lastUpdate:     m.lastUpdateTime,
+		updateInterval: m.config.UpdateInterval,
+	}
+	m.mutex.RUnlock()
+
+	inputs.poolUtilization = m.ipPool.GetUtilization()
+	inputs.poolAvailable = m.ipPool.GetAvailableCount()
+
+	return evaluateHealth(inputs, time.Now())
+}
+
+// evaluateHealth turns raw subsystem observations into a health report.
+func evaluateHealth(inputs healthInputs, now time.Time) *HealthReport {
+	subsystems := []SubsystemHealth{
+		evaluateDatabaseHealth(inputs),
+		evaluateWireGuardHealth(inputs),
+		evaluateFirewallHealth(inputs),
+		evaluateIPPoolHealth(inputs),
+		evaluateMonitorHealth(inputs, now),
+	}
+
+	overall := StatusHealthy
+	for _, subsystem := range subsystems {
+		if healthSeverity(subsystem.Status) > healthSeverity(overall) {
+			overall = subsystem.Status
+		}
+	}
+
+	return &HealthReport{
+		Status:     overall,
+		Timestamp:  now,
+		Subsystems: subsystems,
+	}
+}
+
+func evaluateDatabaseHealth(inputs healthInputs) SubsystemHealth {
+	if inputs.dbErr != nil {
+		return SubsystemHealth{Name: SubsystemDatabase, Status: StatusDown, Message: "database is unreachable"}
+	}
+	return SubsystemHealth{Name: SubsystemDatabase, Status: StatusHealthy, Message: "database is reachable"}
+}
+
+func evaluateWireGuardHealth(inputs healthInputs) SubsystemHealth {
+	if inputs.wgErr != nil {
+		return SubsystemHealth{Name: SubsystemWireGuard, Status: StatusDown, Message: "WireGuard status is unavailable"}
+	}
+	if inputs.wgStats.InterfaceStatus != "up" {
+		return SubsystemHealth{Name: SubsystemWireGuard, Status: StatusDown, Message: "WireGuard interface is down"}
+	}
+	return SubsystemHealth{Name: SubsystemWireGuard, Status: StatusHealthy, Message: "WireGuard interface is up"}
+}
+
+func evaluateFirewallHealth(inputs healthInputs) SubsystemHealth {
+	if inputs.securityErr != nil {
+		return SubsystemHealth{Name: SubsystemFirewall, Status: StatusDown, Message: "firewall status is unavailable"}
+	}
+	if !inputs.securityStats.FirewallEnabled {
+		return SubsystemHealth{Name: SubsystemFirewall, Status: StatusDegraded, Message: "firewall is disabled"}
+	}
+	return SubsystemHealth{
+		Name:    SubsystemFirewall,
+		Status:  StatusHealthy,
+		Message: fmt.Sprintf("firewall is enabled with %d active rules", inputs.securityStats.ActiveRules),
+	}
+}
+
+func evaluateIPPoolHealth(inputs healthInputs) SubsystemHealth {
+	message := fmt.Sprintf("%.1f%% of IP pool in use, %d addresses available", inputs.poolUtilization, inputs.poolAvailable)
+
+	switch {
+	case inputs.poolUtilization >= ipPoolDownUtilization:
+		return SubsystemHealth{Name: SubsystemIPPool, Status: StatusDown, Message: message}
+	case inputs.poolUtilization >= ipPoolDegradedUtilization:
+		return SubsystemHealth{Name: SubsystemIPPool, Status: StatusDegraded, Message: message}
+	default:
+		return SubsystemHealth{Name: SubsystemIPPool, Status: StatusHealthy, Message: message}
+	}
+}
+
+func evaluateMonitorHealth(inputs healthInputs, now time.Time) SubsystemHealth {
+	if !inputs.monitorRunning {
+		return SubsystemHealth{Name: SubsystemMonitor, Status: StatusDegraded, Message: "monitor is not running"}
+	}
+
+	// Metrics are considered stale once two update cycles have been missed
+	age := now.Sub(inputs.lastUpdate)
+	if age > 2*inputs.updateInterval {
+		return SubsystemHealth{
+			Name:    SubsystemMonitor,
+			Status:  StatusDegraded,
+			Message: fmt.Sprintf("metrics are stale, last updated %s ago", age.Round(time.Second)),
+		}
+	}
+	return SubsystemHealth{Name: SubsystemMonitor, Status: StatusHealthy, Message: "metrics are up to date"}
+}
+
+// healthSeverity orders statuses so the worst one can be selected.
+func healthSeverity(status ServerStatus) int {
+	switch status {
+	case StatusHealthy:
+		return 0
+	case StatusDegraded:
+		return 1
+	case StatusUnhealthy:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,134 @@
+package monitoring
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func healthyInputs(now time.Time) healthInputs {
+	return healthInputs{
+		wgStats:         WireGuardStats{InterfaceStatus: "up"},
+		securityStats:   SecurityStats{FirewallEnabled: true, ActiveRules: 3},
+		poolUtilization: 10,
+		poolAvailable:   200,
+		monitorRunning:  true,
+		lastUpdate:      now.Add(-10 * time.Second),
+		updateInterval:  30 * time.Second,
+	}
+}
+
+func findSubsystem(report *HealthReport, name string) *SubsystemHealth {
+	for i := range report.Subsystems {
+		if report.Subsystems[i].Name == name {
+			return &report.Subsystems[i]
+		}
+	}
+	return nil
+}
+
+func TestEvaluateHealth(t *testing.T) {
+	now := time.Now()
+
+	t.Run("should report healthy when all subsystems are healthy", func(t *testing.T) {
+		report := evaluateHealth(healthyInputs(now), now)
+
+		assert.Equal(t, StatusHealthy, report.Status)
+		assert.Len(t, report.Subsystems, 5)
+		for _, subsystem := range report.Subsystems {
+			assert.Equal(t, StatusHealthy, subsystem.Status, subsystem.Name)
+		}
+	})
+
+	t.Run("should pinpoint disabled firewall as degraded", func(t *testing.T) {
+		inputs := healthyInputs(now)
+		inputs.securityStats.FirewallEnabled = false
+
+		report := evaluateHealth(inputs, now)
+
+		assert.Equal(t, StatusDegraded, report.Status)
+		firewall := findSubsystem(report, SubsystemFirewall)
+		require.NotNil(t, firewall)
+		assert.Equal(t, StatusDegraded, firewall.Status)
+		assert.Contains(t, firewall.Message, "disabled")
+
+		for _, subsystem := range report.Subsystems {
+			if subsystem.Name != SubsystemFirewall {
+				assert.Equal(t, StatusHealthy, subsystem.Status, subsystem.Name)
+			}
+		}
+	})
+
+	t.Run("should report down when database is unreachable", func(t *testing.T) {
+		inputs := healthyInputs(now)
+		inputs.dbErr = fmt.Errorf("database is locked")
+
+		report := evaluateHealth(inputs, now)
+
+		assert.Equal(t, StatusDown, report.Status)
+		assert.Equal(t, StatusDown, findSubsystem(report, SubsystemDatabase).Status)
+	})
+
+	t.Run("should flag low IP pool headroom and stale metrics", func(t *testing.T) {
+		inputs := healthyInputs(now)
+		inputs.poolUtilization = 95
+		inputs.lastUpdate = now.Add(-5 * time.Minute)
+
+		report := evaluateHealth(inputs, now)
+
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.Equal(t, StatusDegraded, findSubsystem(report, SubsystemIPPool).Status)
+		assert.Equal(t, StatusDegraded, findSubsystem(report, SubsystemMonitor).Status)
+	})
+}
+
+func TestMonitor_GetDetailedHealth(t *testing.T) {
+	monitor, cleanup := setupTestMonitor(t)
+	defer cleanup()
+
+	t.Run("should report every subsystem", func(t *testing.T) {
+		report := monitor.GetDetailedHealth()
+		require.NotNil(t, report)
+
+		for _, name := range []string{SubsystemDatabase, SubsystemWireGuard, SubsystemFirewall, SubsystemIPPool, SubsystemMonitor} {
+			assert.NotNil(t, findSubsystem(report, name), name)
+		}
+		assert.Equal(t, StatusHealthy, findSubsystem(report, SubsystemDatabase).Status)
+		assert.Equal(t, StatusHealthy, findSubsystem(report, SubsystemIPPool).Status)
+	})
+
+	t.Run("should report the latest collection without collecting again", func(t *testing.T) {
+		monitor.mutex.Lock()
+		monitor.metrics = &ServerMetrics{
+			WireGuardStats: WireGuardStats{InterfaceStatus: "up"},
+			SecurityStats:  SecurityStats{FirewallEnabled: true, ActiveRules: 4},
+		}
+		monitor.collectErrors = collectorErrors{}
+		monitor.mutex.Unlock()
+
+		report := monitor.GetDetailedHealth()
+
+		assert.Equal(t, StatusHealthy, findSubsystem(report, SubsystemWireGuard).Status)
+		assert.Equal(t, "firewall is enabled with 4 active rules", findSubsystem(report, SubsystemFirewall).Message)
+		assert.Empty(t, monitor.blockedPackets.samples)
+	})
+
+	t.Run("should hide collector error details", func(t *testing.T) {
+		monitor.mutex.Lock()
+		monitor.collectErrors = collectorErrors{
+			database:  fmt.Errorf("unable to open /var/lib/vpn/vpn.db"),
+			wireGuard: fmt.Errorf("wg show wg0 failed: permission denied"),
+			security:  fmt.Errorf("pfctl -s info failed: operation not permitted"),
+		}
+		monitor.mutex.Unlock()
+
+		report := monitor.GetDetailedHealth()
+
+		assert.Equal(t, "database is unreachable", findSubsystem(report, SubsystemDatabase).Message)
+		assert.Equal(t, "WireGuard status is unavailable", findSubsystem(report, SubsystemWireGuard).Message)
+		assert.Equal(t, "firewall status is unavailable", findSubsystem(report, SubsystemFirewall).Message)
+	})
+}
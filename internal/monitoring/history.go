@@ -0,0 +1,120 @@
+package monitoring
+
+import (
+	"fmt"
+	"time"
+
+	"my-vpn/internal/database"
+)
+
+// maxHistoryBuckets bounds the number of buckets a history query may span.
+const maxHistoryBuckets = 1000
+
+// MetricsBucket aggregates the metrics snapshots taken within one time step.
+// Gauges are averaged over the bucket; the byte counters are cumulative, so
+// the last value in the bucket is reported. The security scan results report
+// the most failed logins and the highest threat level seen in the bucket.
+type MetricsBucket struct {
+	Start              time.Time `json:"start"`               // Start of the bucket (inclusive)
+	Samples            int       `json:"samples"`             // Number of snapshots in the bucket
+	TotalClients       float64   `json:"total_clients"`       // Average number of configured clients
+	ActiveClients      float64   `json:"active_clients"`      // Average number of connected clients
+	PoolUtilization    float64   `json:"pool_utilization"`    // Average IP pool utilization percentage
+	BytesReceived      uint64    `json:"bytes_received"`      // Total bytes received at the end of the bucket
+	BytesSent          uint64    `json:"bytes_sent"`          // Total bytes sent at the end of the bucket
+	CPUUsage           float64   `json:"cpu_usage"`           // Average CPU usage percentage
+	MemoryUsage        float64   `json:"memory_usage"`        // Average memory usage percentage
+	FailedLogins       int       `json:"failed_logins"`       // Most failed logins within an hour
+	BlockedConnections int       `json:"blocked_connections"` // Packets dropped by the firewall within the hour before the end of the bucket
+	ThreatLevel        string    `json:"threat_level"`        // Highest threat level
+}
+
+// recordMetricsSnapshot persists a compact snapshot of the current metrics
+// for history graphs. It is a single insert per collection cycle.
+func (m *Monitor) recordMetricsSnapshot() error {
+	metrics := m.GetMetrics()
+
+	snapshot := &database.MetricsSnapshot{
+		Timestamp:          metrics.Timestamp,
+		TotalClients:       metrics.ConnectionStats.TotalClients,
+		ActiveClients:      metrics.ConnectionStats.ActiveClients,
+		PoolUtilization:    metrics.NetworkStats.IPPoolUtilization,
+		BytesReceived:      metrics.NetworkStats.BytesReceived,
+		BytesSent:          metrics.NetworkStats.BytesSent,
+		CPUUsage:           metrics.SystemStats.CPUUsage,
+		MemoryUsage:        metrics.SystemStats.MemoryUsage,
+		FailedLogins:       metrics.SecurityStats.FailedLogins,
+		BlockedConnections: metrics.SecurityStats.BlockedConnections,
+		ThreatLevel:        metrics.SecurityStats.ThreatLevel,
+	}
+	if err := m.db.CreateMetricsSnapshot(snapshot); err != nil {
+		return fmt.Errorf("failed to store metrics snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetricsHistory returns the stored metrics in [from, to) aggregated into
+// buckets of length step, oldest first. Buckets start at from; those without
+// snapshots are omitted. Returns an error if the range is empty, step is not
+// positive, the range spans more than maxHistoryBuckets buckets, or the
+// snapshots cannot be read.
+func (m *Monitor) GetMetricsHistory(from, to time.Time, step time.Duration) ([]MetricsBucket, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("history range end must be after its start")
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("history step must be positive")
+	}
+	if to.Sub(from)/step >= maxHistoryBuckets {
+		return nil, fmt.Errorf("history range spans more than %d steps", maxHistoryBuckets)
+	}
+
+	snapshots, err := m.db.ListMetricsSnapshots(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics snapshots: %w", err)
+	}
+
+	buckets := []MetricsBucket{}
+	var current *MetricsBucket
+	for _, snapshot := range snapshots {
+		start := from.Add(snapshot.Timestamp.Sub(from) / step * step).UTC()
+		if current == nil || !current.Start.Equal(start) {
+			finishBucket(current)
+			buckets = append(buckets, MetricsBucket{Start: start})
+			current = &buckets[len(buckets)-1]
+		}
+
+		current.Samples++
+		current.TotalClients += float64(snapshot.TotalClients)
+		current.ActiveClients += float64(snapshot.ActiveClients)
+		current.PoolUtilization += snapshot.PoolUtilization
+		current.CPUUsage += snapshot.CPUUsage
+		current.MemoryUsage += snapshot.MemoryUsage
+		current.BytesReceived = snapshot.BytesReceived
+		current.BytesSent = snapshot.BytesSent
+		current.BlockedConnections = snapshot.BlockedConnections
+		if snapshot.FailedLogins > current.FailedLogins {
+			current.FailedLogins = snapshot.FailedLogins
+		}
+		if threatLevelRank(snapshot.ThreatLevel) > threatLevelRank(current.ThreatLevel) {
+			current.ThreatLevel = snapshot.ThreatLevel
+		}
+	}
+	finishBucket(current)
+
+	return buckets, nil
+}
+
+// finishBucket turns the summed gauges of a bucket into averages.
+func finishBucket(bucket *MetricsBucket) {
+	if bucket == nil || bucket.Samples == 0 {
+		return
+	}
+	samples := float64(bucket.Samples)
+	bucket.TotalClients /= samples
+	bucket.ActiveClients /= samples
+	bucket.PoolUtilization /= samples
+	bucket.CPUUsage /= samples
+	bucket.MemoryUsage /= samples
+}
@@ -0,0 +1,153 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"my-vpn/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonitor_RecordMetricsSnapshot(t *testing.T) {
+	t.Run("should store a snapshot of the current metrics", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.mutex.Lock()
+		monitor.metrics.Timestamp = time.Now()
+		monitor.metrics.ConnectionStats.TotalClients = 4
+		monitor.metrics.ConnectionStats.ActiveClients = 3
+		monitor.metrics.NetworkStats.IPPoolUtilization = 12.5
+		monitor.metrics.NetworkStats.BytesReceived = 1000
+		monitor.metrics.NetworkStats.BytesSent = 2000
+		monitor.metrics.SystemStats.CPUUsage = 40
+		monitor.metrics.SystemStats.MemoryUsage = 60
+		monitor.metrics.SecurityStats.FailedLogins = 7
+		monitor.metrics.SecurityStats.BlockedConnections = 150
+		monitor.metrics.SecurityStats.ThreatLevel = ThreatLevelMedium
+		monitor.mutex.Unlock()
+
+		require.NoError(t, monitor.recordMetricsSnapshot())
+
+		snapshots, err := monitor.db.ListMetricsSnapshots(time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.Equal(t, 4, snapshots[0].TotalClients)
+		assert.Equal(t, 3, snapshots[0].ActiveClients)
+		assert.Equal(t, 12.5, snapshots[0].PoolUtilization)
+		assert.Equal(t, uint64(1000), snapshots[0].BytesReceived)
+		assert.Equal(t, uint64(2000), snapshots[0].BytesSent)
+		assert.Equal(t, 40.0, snapshots[0].CPUUsage)
+		assert.Equal(t, 60.0, snapshots[0].MemoryUsage)
+		assert.Equal(t, 7, snapshots[0].FailedLogins)
+		assert.Equal(t, 150, snapshots[0].BlockedConnections)
+		assert.Equal(t, ThreatLevelMedium, snapshots[0].ThreatLevel)
+	})
+}
+
+func TestMonitor_GetMetricsHistory(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	seedSnapshots := func(t *testing.T, monitor *Monitor) {
+		for i, offset := range []time.Duration{0, 30 * time.Second, 90 * time.Second, 4 * time.Minute, 10 * time.Minute} {
+			require.NoError(t, monitor.db.CreateMetricsSnapshot(&database.MetricsSnapshot{
+				Timestamp:       base.Add(offset),
+				TotalClients:    10,
+				ActiveClients:   i + 1,
+				PoolUtilization: float64(10 * (i + 1)),
+				BytesReceived:   uint64(100 * (i + 1)),
+				BytesSent:       uint64(200 * (i + 1)),
+				CPUUsage:        float64(i),
+				MemoryUsage:     50,
+				FailedLogins:    []int{1, 6, 2, 0, 0}[i],
+				ThreatLevel:     []string{ThreatLevelLow, ThreatLevelMedium, ThreatLevelLow, ThreatLevelLow, ThreatLevelLow}[i],
+			}))
+		}
+	}
+
+	t.Run("should aggregate snapshots into time buckets", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+		seedSnapshots(t, monitor)
+
+		buckets, err := monitor.GetMetricsHistory(base, base.Add(5*time.Minute), 2*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, buckets, 2)
+
+		assert.True(t, buckets[0].Start.Equal(base))
+		assert.Equal(t, 3, buckets[0].Samples)
+		assert.Equal(t, 10.0, buckets[0].TotalClients)
+		assert.Equal(t, 2.0, buckets[0].ActiveClients)
+		assert.Equal(t, 20.0, buckets[0].PoolUtilization)
+		assert.Equal(t, 1.0, buckets[0].CPUUsage)
+		assert.Equal(t, 50.0, buckets[0].MemoryUsage)
+		assert.Equal(t, uint64(300), buckets[0].BytesReceived)
+		assert.Equal(t, uint64(600), buckets[0].BytesSent)
+		assert.Equal(t, 6, buckets[0].FailedLogins)
+		assert.Equal(t, ThreatLevelMedium, buckets[0].ThreatLevel)
+
+		// The 2-4 minute bucket has no snapshots and is omitted
+		assert.True(t, buckets[1].Start.Equal(base.Add(4*time.Minute)))
+		assert.Equal(t, 1, buckets[1].Samples)
+		assert.Equal(t, 4.0, buckets[1].ActiveClients)
+		assert.Equal(t, uint64(400), buckets[1].BytesReceived)
+	})
+
+	t.Run("should align buckets to the start of the range", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+		seedSnapshots(t, monitor)
+
+		buckets, err := monitor.GetMetricsHistory(base.Add(time.Minute), base.Add(11*time.Minute), 5*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, buckets, 2)
+		assert.True(t, buckets[0].Start.Equal(base.Add(time.Minute)))
+		assert.Equal(t, 2, buckets[0].Samples)
+		assert.True(t, buckets[1].Start.Equal(base.Add(6*time.Minute)))
+		assert.Equal(t, 1, buckets[1].Samples)
+	})
+
+	t.Run("should return no buckets for an empty range", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+		seedSnapshots(t, monitor)
+
+		buckets, err := monitor.GetMetricsHistory(base.Add(-time.Hour), base, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, buckets)
+	})
+
+	t.Run("should reject invalid ranges and steps", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		_, err := monitor.GetMetricsHistory(base, base, time.Minute)
+		assert.Error(t, err)
+
+		_, err = monitor.GetMetricsHistory(base, base.Add(time.Hour), 0)
+		assert.Error(t, err)
+
+		_, err = monitor.GetMetricsHistory(base, base.Add(24*time.Hour), time.Second)
+		assert.Error(t, err)
+	})
+
+	t.Run("should prune snapshots older than metrics retention", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		now := time.Now()
+		monitor.config.MetricsRetention = 24 * time.Hour
+		for _, ts := range []time.Time{now.Add(-72 * time.Hour), now.Add(-25 * time.Hour), now.Add(-time.Hour)} {
+			require.NoError(t, monitor.db.CreateMetricsSnapshot(&database.MetricsSnapshot{Timestamp: ts}))
+		}
+
+		require.NoError(t, monitor.cleanupOldData())
+
+		snapshots, err := monitor.db.ListMetricsSnapshots(now.Add(-100*time.Hour), now)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		assert.WithinDuration(t, now.Add(-time.Hour), snapshots[0].Timestamp, time.Second)
+	})
+}
@@ -4,11 +4,14 @@
 package monitoring
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +26,10 @@ type LogManager struct {
 	mutex      sync.RWMutex       // Mutex for thread-safe operations
 	logBuffer  []LogEntry         // Buffer for recent log entries
 	bufferSize int                // Maximum buffer size
+	lastReopenCheck map[LogLevel]time.Time // Last time each log file was checked for external rotation
+	subscribers     map[<-chan LogEntry]chan LogEntry // Live log subscribers keyed by the channel handed out
+	subscriberMutex sync.Mutex             // Mutex for subscriber registration and broadcast
+	syslog          syslogSink             // Connection to the syslog daemon (nil unless LogToSyslog)
 }
 
 // LogConfig represents configuration options for the logging system.
@@ -36,6 +43,25 @@ type LogConfig struct {
 	CompressOldLogs bool     `json:"compress_old_logs"` // Whether to compress rotated logs
 	IncludeSource   bool     `json:"include_source"`   // Whether to include source file/line
 	BufferSize      int      `json:"buffer_size"`      // Number of recent logs to keep in memory
+	ReopenCheckInterval time.Duration `json:"reopen_check_interval"` // How often to check for externally rotated log files (0 checks on every write)
+	LogToSyslog     bool     `json:"log_to_syslog"`    // Whether to send logs to a syslog daemon
+	SyslogNetwork   string   `json:"syslog_network"`   // Network of the syslog daemon: "udp", "tcp", "unix" or "unixgram" (empty uses the local daemon)
+	SyslogAddress   string   `json:"syslog_address"`   // Address of the syslog daemon, e.g. "logs.example.com:514" or "/dev/log"
+	SingleFile      bool     `json:"single_file"`      // Whether all levels write to one combined log file instead of one file per level
+	SingleFileName  string   `json:"single_file_name"` // Name of the combined log file in LogDirectory (empty uses "vpn-server.log")
+}
+
+// defaultSingleFileName is the name of the combined log file of SingleFile.
+const defaultSingleFileName = "vpn-server.log"
+
+// syslogTag identifies the server's messages in syslog.
+const syslogTag = "vpn-server"
+
+// syslogSink is a connection to a syslog daemon that log levels write through.
+// It is only available on platforms with log/syslog.
+type syslogSink interface {
+	writerFor(level LogLevel) io.Writer // Writer sending lines with the syslog priority of the level
+	Close() error
 }
 
 // LogLevel represents the severity level of a log entry.
@@ -50,6 +76,17 @@ const (
 	LogLevelFatal                 // Fatal level - fatal errors
 )
 
+// ParseLogLevel returns the log level with the given name, e.g. "warn".
+// Names are matched case-insensitively against LogLevel.String.
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level := LogLevelTrace; level <= LogLevelFatal; level++ {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
 // String returns the string representation of a log level.
 func (ll LogLevel) String() string {
 	switch ll {
@@ -80,12 +117,18 @@ type LogEntry struct {
 	Metadata  map[string]interface{} `json:"metadata"` // Additional metadata
 }
 
-// NewLogManager creates a new log manager with default configuration.
-// It initializes logging with sensible defaults for production use,
-// including file logging and appropriate log levels.
-// Returns a pointer to the newly created LogManager.
-func NewLogManager() *LogManager {
-	config := LogConfig{
+// Components that tag log entries, so logs of one subsystem can be retrieved
+// with GetLogsByComponent.
+const (
+	LogComponentServer    = "vpn-server" // Server-wide and monitoring entries (used when no component is given)
+	LogComponentAPI       = "api"        // Client and server management API
+	LogComponentAuth      = "auth"       // Authentication, sessions and accounts
+	LogComponentWireGuard = "wireguard"  // WireGuard interface and configuration changes
+)
+
+// DefaultLogConfig returns the logging configuration used by NewLogManager.
+func DefaultLogConfig() LogConfig {
+	return LogConfig{
 		LogLevel:        LogLevelInfo,
 		LogToFile:       true,
 		LogToStdout:     true,
@@ -95,18 +138,16 @@ func NewLogManager() *LogManager {
 		CompressOldLogs: true,
 		IncludeSource:   false,
 		BufferSize:      1000,
+		ReopenCheckInterval: time.Second,
 	}
+}
 
-	manager := &LogManager{
-		config:     config,
-		loggers:    make(map[LogLevel]*log.Logger),
-		logFiles:   make(map[LogLevel]*os.File),
-		logBuffer:  make([]LogEntry, 0, config.BufferSize),
-		bufferSize: config.BufferSize,
-	}
-
-	manager.initializeLoggers()
-	return manager
+// NewLogManager creates a new log manager with default configuration.
+// It initializes logging with sensible defaults for production use,
+// including file logging and appropriate log levels.
+// Returns a pointer to the newly created LogManager.
+func NewLogManager() *LogManager {
+	return NewLogManagerWithConfig(DefaultLogConfig())
 }
 
 // NewLogManagerWithConfig creates a new log manager with custom configuration.
@@ -119,6 +160,8 @@ func NewLogManagerWithConfig(config LogConfig) *LogManager {
 		logFiles:   make(map[LogLevel]*os.File),
 		logBuffer:  make([]LogEntry, 0, config.BufferSize),
 		bufferSize: config.BufferSize,
+		lastReopenCheck: make(map[LogLevel]time.Time),
+		subscribers:     make(map[<-chan LogEntry]chan LogEntry),
 	}
 
 	manager.initializeLoggers()
@@ -135,114 +178,227 @@ func (lm *LogManager) initializeLoggers() {
 		}
 	}
 
+	// Connect to syslog, falling back to the other outputs if it is unavailable
+	if lm.config.LogToSyslog {
+		sink, err := dialSyslog(lm.config.SyslogNetwork, lm.config.SyslogAddress)
+		if err != nil {
+			log.Printf("Failed to connect to syslog, disabling syslog output: %v", err)
+			lm.config.LogToSyslog = false
+		} else {
+			lm.syslog = sink
+		}
+	}
+
 	// Initialize loggers for each level
 	levels := []LogLevel{LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError, LogLevelFatal}
 	
 	for _, level := range levels {
-		var writers []io.Writer
-
-		// Add stdout writer if enabled
-		if lm.config.LogToStdout {
-			writers = append(writers, os.Stdout)
-		}
-
-		// Add file writer if enabled
-		if lm.config.LogToFile {
-			filename := filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log", level.String()))
+		// Add file writer if enabled; levels sharing the combined file open it once
+		owner := lm.fileOwner(level)
+		if lm.config.LogToFile && owner == level {
+			filename := lm.logFilePath(level)
 			file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 			if err != nil {
 				log.Printf("Failed to open log file %s: %v", filename, err)
 			} else {
-				writers = append(writers, file)
 				lm.logFiles[level] = file
 			}
 		}
 
-		// Create multi-writer if we have multiple outputs
-		var writer io.Writer
-		if len(writers) == 1 {
-			writer = writers[0]
-		} else if len(writers) > 1 {
-			writer = io.MultiWriter(writers...)
-		} else {
-			writer = io.Discard
-		}
+		lm.loggers[level] = lm.newLogger(level, lm.logFiles[owner])
+	}
+}
 
-		// Create logger with appropriate flags
-		flags := log.LstdFlags
-		if lm.config.IncludeSource {
-			flags |= log.Lshortfile
-		}
+// fileOwner returns the level under which the log file of a level is kept in
+// logFiles. Each level owns its file, except with SingleFile, where every
+// level shares the combined file kept under LogLevelTrace.
+func (lm *LogManager) fileOwner(level LogLevel) LogLevel {
+	if lm.config.SingleFile {
+		return LogLevelTrace
+	}
+	return level
+}
 
-		lm.loggers[level] = log.New(writer, fmt.Sprintf("[%s] ", level.String()), flags)
+// newLogger creates the logger of a level writing to stdout, the given file
+// (nil for none) and syslog, as enabled in the configuration.
+func (lm *LogManager) newLogger(level LogLevel, file *os.File) *log.Logger {
+	var writers []io.Writer
+	if lm.config.LogToStdout {
+		writers = append(writers, os.Stdout)
 	}
+	if file != nil {
+		writers = append(writers, file)
+	}
+	if lm.syslog != nil {
+		writers = append(writers, lm.syslog.writerFor(level))
+	}
+
+	// Create multi-writer if we have multiple outputs
+	var writer io.Writer
+	if len(writers) == 1 {
+		writer = writers[0]
+	} else if len(writers) > 1 {
+		writer = io.MultiWriter(writers...)
+	} else {
+		writer = io.Discard
+	}
+
+	// Create logger with appropriate flags
+	flags := log.LstdFlags
+	if lm.config.IncludeSource {
+		flags |= log.Lshortfile
+	}
+
+	return log.New(writer, fmt.Sprintf("[%s] ", level.String()), flags)
 }
 
-// Log writes a log entry with the specified level and message.
+// Log writes a log entry with the specified component, level and message.
 // This is the main logging method that handles formatting, filtering,
 // and routing log messages to appropriate destinations.
-func (lm *LogManager) Log(level LogLevel, message string, metadata map[string]interface{}) {
+// An empty component tags the entry with LogComponentServer.
+func (lm *LogManager) Log(component string, level LogLevel, message string, metadata map[string]interface{}) {
 	// Check if this log level should be recorded
 	if level < lm.config.LogLevel {
 		return
 	}
 
+	if component == "" {
+		component = LogComponentServer
+	}
+
 	// Create log entry
 	entry := LogEntry{
 		Timestamp: time.Now(),
 		Level:     level,
 		Message:   message,
-		Component: "vpn-server",
+		Component: component,
 		Metadata:  metadata,
 	}
 
-	// Add to buffer
+	// Add to buffer and hand to live subscribers
 	lm.addToBuffer(entry)
+	lm.broadcast(entry)
 
-	// Get the appropriate logger
-	logger, exists := lm.loggers[level]
-	if !exists {
-		logger = lm.loggers[LogLevelInfo] // Fallback to info logger
-	}
+	// Get the appropriate logger, reopening its file if it was rotated externally
+	logger := lm.loggerFor(level)
 
 	// Format and write the log message
 	formattedMessage := lm.formatMessage(entry)
 	logger.Print(formattedMessage)
 }
 
+// Subscribe registers a subscriber that receives every log entry recorded
+// after the call. The channel buffers up to bufferSize entries; while it is
+// full, new entries are dropped for that subscriber so a slow consumer never
+// blocks logging. Call Unsubscribe with the returned channel when done.
+func (lm *LogManager) Subscribe(bufferSize int) <-chan LogEntry {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	entries := make(chan LogEntry, bufferSize)
+
+	lm.subscriberMutex.Lock()
+	defer lm.subscriberMutex.Unlock()
+
+	lm.subscribers[entries] = entries
+	return entries
+}
+
+// Unsubscribe removes a subscriber registered with Subscribe and closes its
+// channel. Unknown or already removed channels are ignored.
+func (lm *LogManager) Unsubscribe(entries <-chan LogEntry) {
+	lm.subscriberMutex.Lock()
+	defer lm.subscriberMutex.Unlock()
+
+	if channel, ok := lm.subscribers[entries]; ok {
+		delete(lm.subscribers, entries)
+		close(channel)
+	}
+}
+
+// broadcast hands a log entry to every subscriber without blocking.
+func (lm *LogManager) broadcast(entry LogEntry) {
+	lm.subscriberMutex.Lock()
+	defer lm.subscriberMutex.Unlock()
+
+	for _, channel := range lm.subscribers {
+		select {
+		case channel <- entry:
+		default:
+			// Subscriber is not keeping up; drop the entry
+		}
+	}
+}
+
 // LogTrace logs a trace-level message.
 func (lm *LogManager) LogTrace(message string) {
-	lm.Log(LogLevelTrace, message, nil)
+	lm.Log(LogComponentServer, LogLevelTrace, message, nil)
 }
 
 // LogDebug logs a debug-level message.
 func (lm *LogManager) LogDebug(message string) {
-	lm.Log(LogLevelDebug, message, nil)
+	lm.Log(LogComponentServer, LogLevelDebug, message, nil)
 }
 
 // LogInfo logs an info-level message.
 func (lm *LogManager) LogInfo(message string) {
-	lm.Log(LogLevelInfo, message, nil)
+	lm.Log(LogComponentServer, LogLevelInfo, message, nil)
 }
 
 // LogWarn logs a warning-level message.
 func (lm *LogManager) LogWarn(message string) {
-	lm.Log(LogLevelWarn, message, nil)
+	lm.Log(LogComponentServer, LogLevelWarn, message, nil)
 }
 
 // LogError logs an error-level message.
 func (lm *LogManager) LogError(message string) {
-	lm.Log(LogLevelError, message, nil)
+	lm.Log(LogComponentServer, LogLevelError, message, nil)
 }
 
 // LogFatal logs a fatal-level message.
 func (lm *LogManager) LogFatal(message string) {
-	lm.Log(LogLevelFatal, message, nil)
+	lm.Log(LogComponentServer, LogLevelFatal, message, nil)
+}
+
+// LogWithMetadata logs a message of a component with additional metadata.
+func (lm *LogManager) LogWithMetadata(component string, level LogLevel, message string, metadata map[string]interface{}) {
+	lm.Log(component, level, message, metadata)
+}
+
+// ForComponent returns a logger that tags every entry with the component.
+func (lm *LogManager) ForComponent(component string) *ComponentLogger {
+	return &ComponentLogger{logManager: lm, component: component}
+}
+
+// ComponentLogger logs messages of a single component.
+// A nil ComponentLogger writes to the standard logger instead, so packages can
+// log unconditionally whether or not a log manager was attached.
+type ComponentLogger struct {
+	logManager *LogManager
+	component  string
 }
 
-// LogWithMetadata logs a message with additional metadata.
-func (lm *LogManager) LogWithMetadata(level LogLevel, message string, metadata map[string]interface{}) {
-	lm.Log(level, message, metadata)
+// Info logs an info-level message.
+func (cl *ComponentLogger) Info(message string) {
+	cl.log(LogLevelInfo, message)
+}
+
+// Warn logs a warning-level message.
+func (cl *ComponentLogger) Warn(message string) {
+	cl.log(LogLevelWarn, message)
+}
+
+// Error logs an error-level message.
+func (cl *ComponentLogger) Error(message string) {
+	cl.log(LogLevelError, message)
+}
+
+func (cl *ComponentLogger) log(level LogLevel, message string) {
+	if cl == nil || cl.logManager == nil {
+		log.Print(message)
+		return
+	}
+	cl.logManager.Log(cl.component, level, message, nil)
 }
 
 // GetRecentLogs returns recent log entries from the in-memory buffer.
@@ -278,6 +434,27 @@ func (lm *LogManager) GetLogsByLevel(level LogLevel, count int) []LogEntry {
 	return filtered
 }
 
+// GetLogsByComponent returns the most recent count log entries of a component,
+// oldest first. A count of zero or less returns all buffered entries of the component.
+func (lm *LogManager) GetLogsByComponent(component string, count int) []LogEntry {
+	lm.mutex.RLock()
+	defer lm.mutex.RUnlock()
+
+	var filtered []LogEntry
+	for i := len(lm.logBuffer) - 1; i >= 0 && (count <= 0 || len(filtered) < count); i-- {
+		if lm.logBuffer[i].Component == component {
+			filtered = append(filtered, lm.logBuffer[i])
+		}
+	}
+
+	// Collected newest first; restore chronological order
+	for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+		filtered[i], filtered[j] = filtered[j], filtered[i]
+	}
+
+	return filtered
+}
+
 // GetLogsSince returns log entries created after the specified time.
 func (lm *LogManager) GetLogsSince(since time.Time) []LogEntry {
 	lm.mutex.RLock()
@@ -293,6 +470,24 @@ func (lm *LogManager) GetLogsSince(since time.Time) []LogEntry {
 	return result
 }
 
+// PruneBefore removes log entries created before the given time from the in-memory buffer.
+// Log files are not affected. Returns the number of entries removed.
+func (lm *LogManager) PruneBefore(before time.Time) int {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	kept := lm.logBuffer[:0]
+	for _, entry := range lm.logBuffer {
+		if !entry.Timestamp.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+
+	removed := len(lm.logBuffer) - len(kept)
+	lm.logBuffer = kept
+	return removed
+}
+
 // RotateLogs rotates log files when they exceed the maximum size.
 // This prevents log files from growing too large and manages disk space.
 func (lm *LogManager) RotateLogs() error {
@@ -320,40 +515,105 @@ func (lm *LogManager) RotateLogs() error {
 			}
 
 			// Reopen file
-			filename := filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log", level.String()))
-			newFile, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-			if err != nil {
-				return fmt.Errorf("failed to reopen log file: %w", err)
+			if err := lm.reopenLogFile(level); err != nil {
+				return err
 			}
+		}
+	}
 
-			lm.logFiles[level] = newFile
+	return nil
+}
 
-			// Update logger
-			var writers []io.Writer
-			if lm.config.LogToStdout {
-				writers = append(writers, os.Stdout)
-			}
-			writers = append(writers, newFile)
+// loggerFor returns the logger for a level. When logging to file, it first checks
+// whether the log file was renamed or removed out from under the process (e.g. by
+// an external logrotate) and reopens it so that logs are not written to a stale inode.
+func (lm *LogManager) loggerFor(level LogLevel) *log.Logger {
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
 
-			var writer io.Writer
-			if len(writers) == 1 {
-				writer = writers[0]
-			} else {
-				writer = io.MultiWriter(writers...)
+	owner := lm.fileOwner(level)
+	if file := lm.logFiles[owner]; file != nil {
+		now := time.Now()
+		if now.Sub(lm.lastReopenCheck[owner]) >= lm.config.ReopenCheckInterval {
+			lm.lastReopenCheck[owner] = now
+			if lm.isRotatedExternally(owner, file) {
+				file.Close()
+				if err := lm.reopenLogFile(owner); err != nil {
+					log.Printf("Failed to reopen rotated log file, logging to stderr: %v", err)
+					lm.dropLogFile(owner)
+				}
 			}
+		}
+	}
 
-			flags := log.LstdFlags
-			if lm.config.IncludeSource {
-				flags |= log.Lshortfile
-			}
+	logger, exists := lm.loggers[level]
+	if !exists {
+		logger = lm.loggers[LogLevelInfo] // Fallback to info logger
+	}
+	return logger
+}
 
-			lm.loggers[level] = log.New(writer, fmt.Sprintf("[%s] ", level.String()), flags)
-		}
+// isRotatedExternally reports whether the open log file no longer matches
+// the file at its expected path.
+func (lm *LogManager) isRotatedExternally(level LogLevel, file *os.File) bool {
+	pathInfo, err := os.Stat(lm.logFilePath(level))
+	if err != nil {
+		// The file was moved or deleted
+		return true
 	}
 
+	openInfo, err := file.Stat()
+	if err != nil {
+		return true
+	}
+
+	return !os.SameFile(pathInfo, openInfo)
+}
+
+// reopenLogFile opens the log file owned by a level at its expected path
+// and rebuilds the loggers of every level sharing it to write to it.
+func (lm *LogManager) reopenLogFile(level LogLevel) error {
+	newFile, err := os.OpenFile(lm.logFilePath(level), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file: %w", err)
+	}
+
+	lm.logFiles[level] = newFile
+
+	// Update loggers
+	for other := LogLevelTrace; other <= LogLevelFatal; other++ {
+		if lm.fileOwner(other) == level {
+			lm.loggers[other] = lm.newLogger(other, newFile)
+		}
+	}
 	return nil
 }
 
+// dropLogFile forgets the closed log file owned by a level and points the
+// loggers of every level sharing it at stderr instead.
+func (lm *LogManager) dropLogFile(level LogLevel) {
+	delete(lm.logFiles, level)
+
+	for other := LogLevelTrace; other <= LogLevelFatal; other++ {
+		if lm.fileOwner(other) == level {
+			lm.loggers[other] = lm.newLogger(other, os.Stderr)
+		}
+	}
+}
+
+// logFilePath returns the expected path of the log file for a level.
+// With SingleFile every level shares the combined file.
+func (lm *LogManager) logFilePath(level LogLevel) string {
+	if lm.config.SingleFile {
+		name := lm.config.SingleFileName
+		if name == "" {
+			name = defaultSingleFileName
+		}
+		return filepath.Join(lm.config.LogDirectory, name)
+	}
+	return filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log", level.String()))
+}
+
 // Close gracefully closes all log files and cleans up resources.
 func (lm *LogManager) Close() error {
 	lm.mutex.Lock()
@@ -367,6 +627,13 @@ func (lm *LogManager) Close() error {
 		}
 	}
 
+	if lm.syslog != nil {
+		if err := lm.syslog.Close(); err != nil {
+			return fmt.Errorf("failed to close syslog connection: %w", err)
+		}
+		lm.syslog = nil
+	}
+
 	return nil
 }
 
@@ -401,11 +668,15 @@ func (lm *LogManager) formatMessage(entry LogEntry) string {
 	return message
 }
 
+// rotatedLogTimestampPattern matches the timestamp suffix of rotated log files.
+const rotatedLogTimestampPattern = "[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]-[0-9][0-9][0-9][0-9][0-9][0-9]"
+
 // rotateFile rotates a log file by renaming it with a timestamp.
+// With CompressOldLogs the rotated file is gzipped and the uncompressed copy removed.
 func (lm *LogManager) rotateFile(level LogLevel) error {
-	originalPath := filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log", level.String()))
+	originalPath := lm.logFilePath(level)
 	timestamp := time.Now().Format("20060102-150405")
-	rotatedPath := filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log.%s", level.String(), timestamp))
+	rotatedPath := fmt.Sprintf("%s.%s", originalPath, timestamp)
 
 	// Rename current file
 	if err := os.Rename(originalPath, rotatedPath); err != nil {
@@ -414,30 +685,94 @@ func (lm *LogManager) rotateFile(level LogLevel) error {
 
 	// Compress if enabled
 	if lm.config.CompressOldLogs {
-		// This would implement compression logic
-		// For now, it's a placeholder
+		if err := compressLogFile(rotatedPath); err != nil {
+			return fmt.Errorf("failed to compress rotated log file: %w", err)
+		}
 	}
 
 	// Clean up old files
 	return lm.cleanupOldLogFiles(level)
 }
 
-// cleanupOldLogFiles removes old log files exceeding the retention limit.
-func (lm *LogManager) cleanupOldLogFiles(level LogLevel) error {
-	pattern := filepath.Join(lm.config.LogDirectory, fmt.Sprintf("%s.log.*", level.String()))
-	matches, err := filepath.Glob(pattern)
+// compressLogFile gzips a rotated log file to path.gz and removes the original.
+// The file is streamed, so memory use does not depend on its size. On failure
+// the partial archive is removed and the original is kept.
+func compressLogFile(path string) (err error) {
+	source, err := os.Open(path)
 	if err != nil {
 		return err
 	}
+	defer source.Close()
 
-	// If we have more files than the limit, remove the oldest
-	if len(matches) > lm.config.MaxFiles {
-		// Sort by modification time and remove oldest
-		// This is a simplified implementation
-		for i := 0; i < len(matches)-lm.config.MaxFiles; i++ {
-			if err := os.Remove(matches[i]); err != nil {
-				return err
+	compressedPath := path + ".gz"
+	target, err := os.OpenFile(compressedPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			target.Close()
+			os.Remove(compressedPath)
+		}
+	}()
+
+	writer := gzip.NewWriter(target)
+	writer.Name = filepath.Base(path)
+	if _, err = io.Copy(writer, source); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if err = target.Close(); err != nil {
+		return err
+	}
+
+	source.Close()
+	return os.Remove(path)
+}
+
+// cleanupOldLogFiles removes old log files exceeding the retention limit.
+// Rotated files are counted whether or not they were compressed, and the files
+// with the oldest modification times are removed first.
+func (lm *LogManager) cleanupOldLogFiles(level LogLevel) error {
+	type rotatedFile struct {
+		path    string
+		modTime time.Time
+	}
+
+	var files []rotatedFile
+	for _, suffix := range []string{"", ".gz"} {
+		pattern := fmt.Sprintf("%s.%s%s", lm.logFilePath(level), rotatedLogTimestampPattern, suffix)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				// Removed since the glob, e.g. by a concurrent cleanup
+				continue
 			}
+			files = append(files, rotatedFile{path: path, modTime: info.ModTime()})
+		}
+	}
+
+	if len(files) <= lm.config.MaxFiles {
+		return nil
+	}
+
+	// Oldest first; equal modification times fall back to the timestamp in the name
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].modTime.Equal(files[j].modTime) {
+			return files[i].modTime.Before(files[j].modTime)
+		}
+		return files[i].path < files[j].path
+	})
+
+	for _, file := range files[:len(files)-lm.config.MaxFiles] {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return err
 		}
 	}
 
@@ -464,6 +799,10 @@ func (lm *LogManager) UpdateConfig(config LogConfig) error {
 			file.Close()
 		}
 	}
+	if lm.syslog != nil {
+		lm.syslog.Close()
+		lm.syslog = nil
+	}
 
 	// Update configuration
 	lm.config = config
@@ -4,9 +4,12 @@
 package monitoring
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +19,9 @@ import (
 
 func TestNewLogManager(t *testing.T) {
 	t.Run("should create log manager with default configuration", func(t *testing.T) {
+		// The default directory is relative to the working directory
+		t.Chdir(t.TempDir())
+
 		lm := NewLogManager()
 		defer lm.Close()
 
@@ -85,12 +91,12 @@ func TestLogManager_Log(t *testing.T) {
 
 	t.Run("should log messages at or above configured level", func(t *testing.T) {
 		// These should be logged (at or above DEBUG level)
-		lm.Log(LogLevelDebug, "Debug message", nil)
-		lm.Log(LogLevelInfo, "Info message", nil)
-		lm.Log(LogLevelError, "Error message", nil)
+		lm.Log(LogComponentServer, LogLevelDebug, "Debug message", nil)
+		lm.Log(LogComponentServer, LogLevelInfo, "Info message", nil)
+		lm.Log(LogComponentServer, LogLevelError, "Error message", nil)
 
 		// This should not be logged (below DEBUG level)
-		lm.Log(LogLevelTrace, "Trace message", nil)
+		lm.Log(LogComponentServer, LogLevelTrace, "Trace message", nil)
 
 		// Check buffer contains only the logged messages
 		recent := lm.GetRecentLogs(10)
@@ -106,7 +112,7 @@ func TestLogManager_Log(t *testing.T) {
 			"action":  "login",
 		}
 
-		lm.Log(LogLevelInfo, "User action", metadata)
+		lm.Log(LogComponentServer, LogLevelInfo, "User action", metadata)
 
 		recent := lm.GetRecentLogs(1)
 		assert.Len(t, recent, 1)
@@ -219,6 +225,58 @@ func TestLogManager_GetLogsByLevel(t *testing.T) {
 	})
 }
 
+func TestLogManager_GetLogsByComponent(t *testing.T) {
+	config := LogConfig{
+		LogLevel:     LogLevelTrace,
+		LogToFile:    false,
+		LogToStdout:  false,
+		BufferSize:   100,
+	}
+
+	lm := NewLogManagerWithConfig(config)
+	defer lm.Close()
+
+	t.Run("should return logs filtered by component", func(t *testing.T) {
+		lm.Log(LogComponentAuth, LogLevelInfo, "Auth 1", nil)
+		lm.Log(LogComponentWireGuard, LogLevelInfo, "WireGuard 1", nil)
+		lm.LogWithMetadata(LogComponentAuth, LogLevelWarn, "Auth 2", map[string]interface{}{"username": "alice"})
+		lm.ForComponent(LogComponentWireGuard).Error("WireGuard 2")
+
+		authLogs := lm.GetLogsByComponent(LogComponentAuth, 10)
+		require.Len(t, authLogs, 2)
+		assert.Equal(t, "Auth 1", authLogs[0].Message)
+		assert.Equal(t, "Auth 2", authLogs[1].Message)
+		assert.Equal(t, LogLevelWarn, authLogs[1].Level)
+		assert.Equal(t, "alice", authLogs[1].Metadata["username"])
+
+		wireGuardLogs := lm.GetLogsByComponent(LogComponentWireGuard, 10)
+		require.Len(t, wireGuardLogs, 2)
+		assert.Equal(t, "WireGuard 1", wireGuardLogs[0].Message)
+		assert.Equal(t, "WireGuard 2", wireGuardLogs[1].Message)
+		assert.Equal(t, LogLevelError, wireGuardLogs[1].Level)
+
+		assert.Empty(t, lm.GetLogsByComponent(LogComponentAPI, 10))
+	})
+
+	t.Run("should respect count limit", func(t *testing.T) {
+		authLogs := lm.GetLogsByComponent(LogComponentAuth, 1)
+		require.Len(t, authLogs, 1)
+		assert.Equal(t, "Auth 2", authLogs[0].Message) // Should be the most recent
+
+		assert.Len(t, lm.GetLogsByComponent(LogComponentAuth, 0), 2)
+	})
+
+	t.Run("should tag logs without a component as the server", func(t *testing.T) {
+		lm.LogInfo("Server 1")
+		lm.Log("", LogLevelInfo, "Server 2", nil)
+
+		serverLogs := lm.GetLogsByComponent(LogComponentServer, 10)
+		require.Len(t, serverLogs, 2)
+		assert.Equal(t, "Server 1", serverLogs[0].Message)
+		assert.Equal(t, "Server 2", serverLogs[1].Message)
+	})
+}
+
 func TestLogManager_GetLogsSince(t *testing.T) {
 	config := LogConfig{
 		LogLevel:     LogLevelInfo,
@@ -257,6 +315,32 @@ func TestLogManager_GetLogsSince(t *testing.T) {
 	})
 }
 
+func TestLogManager_PruneBefore(t *testing.T) {
+	config := LogConfig{
+		LogLevel:     LogLevelInfo,
+		LogToFile:    false,
+		LogToStdout:  false,
+		BufferSize:   100,
+	}
+
+	lm := NewLogManagerWithConfig(config)
+	defer lm.Close()
+
+	t.Run("should remove only entries older than cutoff", func(t *testing.T) {
+		lm.LogInfo("Old message")
+		time.Sleep(10 * time.Millisecond)
+		cutoff := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		lm.LogInfo("New message")
+
+		assert.Equal(t, 1, lm.PruneBefore(cutoff))
+
+		logs := lm.GetRecentLogs(10)
+		assert.Len(t, logs, 1)
+		assert.Equal(t, "New message", logs[0].Message)
+	})
+}
+
 func TestLogManager_BufferManagement(t *testing.T) {
 	config := LogConfig{
 		LogLevel:     LogLevelInfo,
@@ -323,8 +407,384 @@ func TestLogManager_FileLogging(t *testing.T) {
 	})
 }
 
+func TestLogManager_SingleFile(t *testing.T) {
+	newSingleFileManager := func(t *testing.T, name string) (*LogManager, string) {
+		tempDir := t.TempDir()
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:        LogLevelDebug,
+			LogToFile:       true,
+			LogToStdout:     false,
+			LogDirectory:    tempDir,
+			MaxFileSize:     10,
+			MaxFiles:        2,
+			CompressOldLogs: false,
+			BufferSize:      100,
+			SingleFile:      true,
+			SingleFileName:  name,
+		})
+		t.Cleanup(func() { lm.Close() })
+		return lm, tempDir
+	}
+
+	t.Run("should write all levels to one file in order", func(t *testing.T) {
+		lm, tempDir := newSingleFileManager(t, "combined.log")
+
+		lm.LogInfo("First message")
+		lm.LogError("Second message")
+		lm.LogDebug("Third message")
+		lm.LogWarn("Fourth message")
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "combined.log"))
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+		require.Len(t, lines, 4)
+		assert.True(t, strings.HasPrefix(lines[0], "[INFO] "))
+		assert.Contains(t, lines[0], "First message")
+		assert.True(t, strings.HasPrefix(lines[1], "[ERROR] "))
+		assert.Contains(t, lines[1], "Second message")
+		assert.True(t, strings.HasPrefix(lines[2], "[DEBUG] "))
+		assert.Contains(t, lines[2], "Third message")
+		assert.True(t, strings.HasPrefix(lines[3], "[WARN] "))
+		assert.Contains(t, lines[3], "Fourth message")
+
+		// No per-level files are created
+		entries, err := os.ReadDir(tempDir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "combined.log", entries[0].Name())
+	})
+
+	t.Run("should use the default file name", func(t *testing.T) {
+		lm, tempDir := newSingleFileManager(t, "")
+		lm.LogInfo("Message")
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "vpn-server.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Message")
+	})
+
+	t.Run("should rotate the combined file for all levels", func(t *testing.T) {
+		lm, tempDir := newSingleFileManager(t, "combined.log")
+		lm.LogInfo("Message before rotation")
+		require.NoError(t, lm.RotateLogs())
+
+		rotated, err := filepath.Glob(filepath.Join(tempDir, "combined.log.*"))
+		require.NoError(t, err)
+		require.Len(t, rotated, 1)
+		content, err := os.ReadFile(rotated[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Message before rotation")
+
+		lm.LogInfo("Info after rotation")
+		lm.LogError("Error after rotation")
+		content, err = os.ReadFile(filepath.Join(tempDir, "combined.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Info after rotation")
+		assert.Contains(t, string(content), "Error after rotation")
+		assert.NotContains(t, string(content), "Message before rotation")
+	})
+
+	t.Run("should reopen the combined file after external rotation", func(t *testing.T) {
+		lm, tempDir := newSingleFileManager(t, "combined.log")
+		logPath := filepath.Join(tempDir, "combined.log")
+
+		lm.LogInfo("Before rotation")
+		require.NoError(t, os.Rename(logPath, logPath+".1"))
+		lm.LogError("After rotation")
+
+		content, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "After rotation")
+		assert.NotContains(t, string(content), "Before rotation")
+	})
+}
+
+func TestLogManager_ReopenRotatedFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "vpn_log_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	config := LogConfig{
+		LogLevel:     LogLevelInfo,
+		LogToFile:    true,
+		LogToStdout:  false,
+		LogDirectory: tempDir,
+		BufferSize:   100,
+	}
+
+	lm := NewLogManagerWithConfig(config)
+	defer lm.Close()
+
+	t.Run("should reopen log file after external rename", func(t *testing.T) {
+		infoLogPath := filepath.Join(tempDir, "INFO.log")
+		rotatedPath := infoLogPath + ".1"
+
+		lm.LogInfo("Before rotation")
+
+		// Simulate logrotate moving the file out from under the process
+		require.NoError(t, os.Rename(infoLogPath, rotatedPath))
+
+		lm.LogInfo("After rotation")
+
+		content, err := os.ReadFile(infoLogPath)
+		require.NoError(t, err, "a fresh log file should be created")
+		assert.Contains(t, string(content), "After rotation")
+		assert.NotContains(t, string(content), "Before rotation")
+
+		rotated, err := os.ReadFile(rotatedPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(rotated), "Before rotation")
+		assert.NotContains(t, string(rotated), "After rotation")
+	})
+
+	t.Run("should reopen log file after external removal", func(t *testing.T) {
+		errorLogPath := filepath.Join(tempDir, "ERROR.log")
+		lm.LogError("Before removal")
+		require.NoError(t, os.Remove(errorLogPath))
+
+		lm.LogError("After removal")
+
+		content, err := os.ReadFile(errorLogPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "After removal")
+	})
+
+	t.Run("should fall back to stderr when the log file cannot be reopened", func(t *testing.T) {
+		warnLogPath := filepath.Join(tempDir, "WARN.log")
+		lm.LogWarn("Before removal")
+		require.NoError(t, os.Remove(warnLogPath))
+		// A directory in place of the log file makes reopening it fail
+		require.NoError(t, os.Mkdir(warnLogPath, 0755))
+
+		lm.LogWarn("After removal")
+
+		assert.Nil(t, lm.logFiles[LogLevelWarn])
+		assert.Equal(t, os.Stderr, lm.loggers[LogLevelWarn].Writer())
+	})
+}
+
+func TestLogManager_CompressRotatedLogs(t *testing.T) {
+	newRotatingManager := func(t *testing.T, compress bool) (*LogManager, string) {
+		tempDir := t.TempDir()
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:        LogLevelInfo,
+			LogToFile:       true,
+			LogToStdout:     false,
+			LogDirectory:    tempDir,
+			MaxFileSize:     10,
+			MaxFiles:        2,
+			CompressOldLogs: compress,
+			BufferSize:      100,
+		})
+		t.Cleanup(func() { lm.Close() })
+		return lm, tempDir
+	}
+
+	t.Run("should gzip rotated log file", func(t *testing.T) {
+		lm, tempDir := newRotatingManager(t, true)
+		lm.LogInfo("Message before rotation")
+		require.NoError(t, lm.RotateLogs())
+
+		compressed, err := filepath.Glob(filepath.Join(tempDir, "INFO.log.*.gz"))
+		require.NoError(t, err)
+		require.Len(t, compressed, 1)
+
+		file, err := os.Open(compressed[0])
+		require.NoError(t, err)
+		defer file.Close()
+		reader, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Message before rotation")
+
+		uncompressed := strings.TrimSuffix(compressed[0], ".gz")
+		_, err = os.Stat(uncompressed)
+		assert.True(t, os.IsNotExist(err), "uncompressed copy should be removed")
+	})
+
+	t.Run("should keep rotated log file uncompressed when disabled", func(t *testing.T) {
+		lm, tempDir := newRotatingManager(t, false)
+		lm.LogInfo("Message before rotation")
+		require.NoError(t, lm.RotateLogs())
+
+		rotated, err := filepath.Glob(filepath.Join(tempDir, "INFO.log.*"))
+		require.NoError(t, err)
+		require.Len(t, rotated, 1)
+		assert.False(t, strings.HasSuffix(rotated[0], ".gz"))
+	})
+
+	t.Run("should remove oldest compressed logs beyond the retention limit", func(t *testing.T) {
+		lm, tempDir := newRotatingManager(t, true)
+		oldest := filepath.Join(tempDir, "INFO.log.20200101-000000.gz")
+		older := filepath.Join(tempDir, "INFO.log.20200102-000000")
+		require.NoError(t, os.WriteFile(oldest, []byte("old"), 0644))
+		require.NoError(t, os.WriteFile(older, []byte("old"), 0644))
+		require.NoError(t, os.Chtimes(oldest, time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)))
+		require.NoError(t, os.Chtimes(older, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+		lm.LogInfo("Message before rotation")
+		require.NoError(t, lm.RotateLogs())
+
+		_, err := os.Stat(oldest)
+		assert.True(t, os.IsNotExist(err), "oldest rotated file should be removed")
+		_, err = os.Stat(older)
+		assert.NoError(t, err)
+
+		compressed, err := filepath.Glob(filepath.Join(tempDir, "INFO.log.2*.gz"))
+		require.NoError(t, err)
+		assert.Len(t, compressed, 1)
+	})
+}
+
+func TestLogManager_CleanupOldLogFiles(t *testing.T) {
+	t.Run("should remove the files with the oldest modification times", func(t *testing.T) {
+		tempDir := t.TempDir()
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:     LogLevelInfo,
+			LogToStdout:  false,
+			LogDirectory: tempDir,
+			MaxFiles:     2,
+			BufferSize:   100,
+		})
+		defer lm.Close()
+
+		// Name order deliberately differs from modification order, e.g. after
+		// the clock was adjusted between rotations
+		base := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+		files := []struct {
+			name    string
+			modTime time.Time
+		}{
+			{"INFO.log.20260103-120000", base},                       // oldest
+			{"INFO.log.20260101-120000.gz", base.Add(time.Hour)},     // second oldest
+			{"INFO.log.20260102-120000", base.Add(3 * time.Hour)},    // newest
+			{"INFO.log.20260104-120000.gz", base.Add(2 * time.Hour)}, // second newest
+			{"ERROR.log.20200101-000000", base.Add(-time.Hour)},      // other level, untouched
+		}
+		for _, file := range files {
+			path := filepath.Join(tempDir, file.name)
+			require.NoError(t, os.WriteFile(path, []byte(file.name), 0644))
+			require.NoError(t, os.Chtimes(path, file.modTime, file.modTime))
+		}
+
+		require.NoError(t, lm.cleanupOldLogFiles(LogLevelInfo))
+
+		exists := func(name string) bool {
+			_, err := os.Stat(filepath.Join(tempDir, name))
+			return err == nil
+		}
+		assert.False(t, exists("INFO.log.20260103-120000"))
+		assert.False(t, exists("INFO.log.20260101-120000.gz"))
+		assert.True(t, exists("INFO.log.20260102-120000"))
+		assert.True(t, exists("INFO.log.20260104-120000.gz"))
+		assert.True(t, exists("ERROR.log.20200101-000000"))
+	})
+
+	t.Run("should keep all files within the retention limit", func(t *testing.T) {
+		tempDir := t.TempDir()
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:     LogLevelInfo,
+			LogToStdout:  false,
+			LogDirectory: tempDir,
+			MaxFiles:     3,
+			BufferSize:   100,
+		})
+		defer lm.Close()
+
+		for _, name := range []string{"INFO.log.20260101-120000", "INFO.log.20260102-120000.gz"} {
+			require.NoError(t, os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644))
+		}
+
+		require.NoError(t, lm.cleanupOldLogFiles(LogLevelInfo))
+
+		remaining, err := filepath.Glob(filepath.Join(tempDir, "INFO.log.*"))
+		require.NoError(t, err)
+		assert.Len(t, remaining, 2)
+	})
+}
+
+func TestLogManager_Subscribe(t *testing.T) {
+	newManager := func(t *testing.T) *LogManager {
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:    LogLevelInfo,
+			LogToStdout: false,
+			BufferSize:  100,
+		})
+		t.Cleanup(func() { lm.Close() })
+		return lm
+	}
+
+	t.Run("should deliver new entries to every subscriber", func(t *testing.T) {
+		lm := newManager(t)
+		lm.LogInfo("Before subscribing")
+
+		first := lm.Subscribe(10)
+		second := lm.Subscribe(10)
+		defer lm.Unsubscribe(first)
+		defer lm.Unsubscribe(second)
+
+		lm.LogInfo("Hello")
+		lm.LogError("Oops")
+
+		for _, entries := range []<-chan LogEntry{first, second} {
+			require.Len(t, entries, 2)
+			assert.Equal(t, "Hello", (<-entries).Message)
+			entry := <-entries
+			assert.Equal(t, "Oops", entry.Message)
+			assert.Equal(t, LogLevelError, entry.Level)
+		}
+	})
+
+	t.Run("should drop entries for a full subscriber without blocking", func(t *testing.T) {
+		lm := newManager(t)
+		entries := lm.Subscribe(2)
+		defer lm.Unsubscribe(entries)
+
+		for i := 1; i <= 5; i++ {
+			lm.LogInfo(fmt.Sprintf("Message %d", i))
+		}
+
+		require.Len(t, entries, 2)
+		assert.Equal(t, "Message 1", (<-entries).Message)
+		assert.Equal(t, "Message 2", (<-entries).Message)
+		assert.Len(t, lm.GetRecentLogs(10), 5)
+	})
+
+	t.Run("should close the channel on unsubscribe", func(t *testing.T) {
+		lm := newManager(t)
+		entries := lm.Subscribe(10)
+		lm.Unsubscribe(entries)
+		lm.Unsubscribe(entries)
+
+		lm.LogInfo("After unsubscribing")
+
+		_, ok := <-entries
+		assert.False(t, ok)
+	})
+}
+
+func TestParseLogLevel(t *testing.T) {
+	t.Run("should parse level names case-insensitively", func(t *testing.T) {
+		level, err := ParseLogLevel("warn")
+		require.NoError(t, err)
+		assert.Equal(t, LogLevelWarn, level)
+
+		level, err = ParseLogLevel("ERROR")
+		require.NoError(t, err)
+		assert.Equal(t, LogLevelError, level)
+	})
+
+	t.Run("should reject unknown level names", func(t *testing.T) {
+		_, err := ParseLogLevel("verbose")
+		assert.Error(t, err)
+	})
+}
+
 func TestLogManager_UpdateConfig(t *testing.T) {
-	lm := NewLogManager()
+	config := DefaultLogConfig()
+	config.LogDirectory = t.TempDir()
+	lm := NewLogManagerWithConfig(config)
 	defer lm.Close()
 
 	t.Run("should update configuration", func(t *testing.T) {
@@ -347,7 +807,9 @@ func TestLogManager_UpdateConfig(t *testing.T) {
 }
 
 func TestLogManager_FormatMessage(t *testing.T) {
-	lm := NewLogManager()
+	config := DefaultLogConfig()
+	config.LogDirectory = t.TempDir()
+	lm := NewLogManagerWithConfig(config)
 	defer lm.Close()
 
 	t.Run("should format message without metadata", func(t *testing.T) {
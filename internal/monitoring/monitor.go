@@ -6,10 +6,18 @@ package monitoring
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
 
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/load"
+	"gorm.io/gorm"
+
 	"my-vpn/internal/database"
 	"my-vpn/internal/network"
 	"my-vpn/internal/system"
@@ -22,16 +30,24 @@ import (
 type Monitor struct {
 	db              *database.Database         // Database connection for logging and metrics storage
 	wgServer        *wireguard.WireGuardServer // WireGuard server instance for connection monitoring
+	peerSource      PeerStatusSource           // Live peer status of the running interface
 	ipPool          *network.IPPool            // IP pool for network metrics
-	pfctlManager    *system.PfctlManager       // Firewall manager for security monitoring
+	firewall        system.FirewallManager     // Firewall manager for security monitoring
 	config          *MonitorConfig             // Configuration for monitoring behavior
 	metrics         *ServerMetrics             // Current server metrics
 	alertManager    *AlertManager              // Alert management system
+	dataCaps        *DataCapEnforcer           // Per-client data cap enforcement
+	notifier        StatusNotifier             // Notified about server status transitions (optional)
+	lastStatus      ServerStatus               // Status calculated in the previous cycle
 	logManager      *LogManager                // Log management system
 	running         bool                       // Whether monitoring is currently active
 	stopCh          chan struct{}              // Channel to signal monitoring stop
 	mutex           sync.RWMutex               // Mutex for thread-safe operations
 	lastUpdateTime  time.Time                  // Last metrics update timestamp
+	blockedPackets  blockedPacketWindow        // Firewall drop counter history for SecurityStats
+	collectErrors   collectorErrors            // Collector failures of the latest metrics collection
+	metricsSubscribers map[<-chan struct{}]chan struct{} // Notified after each metrics collection
+	subscriberMutex    sync.Mutex                        // Mutex for metrics subscriber registration and notification
 }
 
 // MonitorConfig represents configuration options for the monitoring system.
@@ -42,6 +58,9 @@ type MonitorConfig struct {
 	AlertThresholds   AlertConfig   `json:"alert_thresholds"`    // Alert configuration
 	EnableSystemStats bool          `json:"enable_system_stats"` // Whether to collect system statistics
 	EnableDebugLogs   bool          `json:"enable_debug_logs"`   // Whether to enable debug logging
+	StatusWebhookURL  string        `json:"status_webhook_url"`  // Webhook notified on server status changes (optional)
+	PeerCountTolerance int          `json:"peer_count_tolerance"` // Allowed difference between enabled clients and live peers before alerting (default: 0)
+	LogDirectory      string        `json:"log_directory"`       // Directory for application log files (default: ./logs)
 }
 
 // ServerMetrics represents current server state and performance metrics.
@@ -101,12 +120,12 @@ type SystemStats struct {
 
 // SecurityStats represents security and firewall status.
 type SecurityStats struct {
-	FirewallEnabled    bool      `json:"firewall_enabled"`     // Whether pfctl is enabled
+	FirewallEnabled    bool      `json:"firewall_enabled"`     // Whether the firewall rules are enabled
 	ActiveRules        int       `json:"active_rules"`         // Number of active firewall rules
-	BlockedConnections int       `json:"blocked_connections"`  // Number of blocked connection attempts
-	FailedLogins       int       `json:"failed_logins"`        // Number of failed login attempts
+	BlockedConnections int       `json:"blocked_connections"`  // Number of packets dropped by the firewall rules within the last hour
+	FailedLogins       int       `json:"failed_logins"`        // Number of failed login attempts within the last hour
 	LastSecurityScan   time.Time `json:"last_security_scan"`   // Last security check timestamp
-	ThreatLevel        string    `json:"threat_level"`         // Current threat assessment
+	ThreatLevel        string    `json:"threat_level"`         // Current threat assessment: "low", "medium" or "high"
 }
 
 // WireGuardStats represents WireGuard-specific metrics.
@@ -117,7 +136,8 @@ type WireGuardStats struct {
 	TotalPeers        int       `json:"total_peers"`         // Total configured peers
 	ActivePeers       int       `json:"active_peers"`        // Currently active peers
 	LastHandshake     time.Time `json:"last_handshake"`      // Most recent peer handshake
-	ConfigVersion     string    `json:"config_version"`      // Configuration version
+	ConfigVersion     string    `json:"config_version"`      // Hash of the last-applied configuration file
+	ConfigDrifted     bool      `json:"config_drifted"`      // Configuration file changed since it was last applied
 }
 
 // PerformanceMetrics represents performance-related metrics.
@@ -129,12 +149,20 @@ type PerformanceMetrics struct {
 	DatabaseLatency  time.Duration `json:"database_latency"`   // Average database query time
 }
 
-// NewMonitor creates a new monitoring instance with default configuration.
-// It initializes all monitoring components including metrics collection,
-// alerting, and logging with sensible defaults for production use.
-// Returns a pointer to the newly created Monitor.
-func NewMonitor(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, pfctlManager *system.PfctlManager) *Monitor {
-	config := &MonitorConfig{
+// ActivePeerWindow is how recent a peer's latest handshake must be for it to count as active.
+// WireGuard re-handshakes every 2 minutes while traffic flows, so 3 minutes allows for jitter.
+const ActivePeerWindow = 3 * time.Minute
+
+// PeerStatusSource reports the live state of each peer on the running WireGuard
+// interface, as shown by `wg show`. Active clients and peers are counted from
+// the latest handshakes it reports.
+type PeerStatusSource interface {
+	GetPeerStatus() ([]wireguard.PeerStatus, error)
+}
+
+// DefaultMonitorConfig returns the monitoring configuration used by NewMonitor.
+func DefaultMonitorConfig() *MonitorConfig {
+	return &MonitorConfig{
 		UpdateInterval:    30 * time.Second,
 		LogRetentionDays:  30,
 		MetricsRetention:  7 * 24 * time.Hour,
@@ -142,30 +170,51 @@ func NewMonitor(db *database.Database, wgServer *wireguard.WireGuardServer, ipPo
 		EnableDebugLogs:   false,
 		AlertThresholds:   getDefaultAlertConfig(),
 	}
+}
 
-	return &Monitor{
+// NewMonitor creates a new monitoring instance with default configuration.
+// It initializes all monitoring components including metrics collection,
+// alerting, and logging with sensible defaults for production use.
+// Returns a pointer to the newly created Monitor.
+func NewMonitor(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, firewall system.FirewallManager) *Monitor {
+	return NewMonitorWithConfig(db, wgServer, ipPool, firewall, DefaultMonitorConfig())
+}
+
+// NewMonitorWithConfig creates a new monitoring instance with custom configuration.
+// This allows fine-tuning of monitoring behavior for specific deployment requirements.
+// Returns a pointer to the newly created Monitor.
+func NewMonitorWithConfig(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, firewall system.FirewallManager, config *MonitorConfig) *Monitor {
+	logConfig := DefaultLogConfig()
+	if config.LogDirectory != "" {
+		logConfig.LogDirectory = config.LogDirectory
+	}
+
+	alertManager := NewAlertManager()
+	logManager := NewLogManagerWithConfig(logConfig)
+	alertManager.SetLogManager(logManager)
+
+	monitor := &Monitor{
 		db:              db,
 		wgServer:        wgServer,
+		peerSource:      wgServer,
 		ipPool:          ipPool,
-		pfctlManager:    pfctlManager,
+		firewall:        firewall,
 		config:          config,
 		metrics:         &ServerMetrics{
 			ServerStatus: StatusHealthy,
 			Timestamp:    time.Now(),
 		},
-		alertManager:    NewAlertManager(),
-		logManager:      NewLogManager(),
+		alertManager:    alertManager,
+		lastStatus:      StatusHealthy,
+		dataCaps:        NewDataCapEnforcer(db, wgServer, alertManager),
+		logManager:      logManager,
 		stopCh:          make(chan struct{}),
 		lastUpdateTime:  time.Now(),
+		metricsSubscribers: make(map[<-chan struct{}]chan struct{}),
+	}
+	if config.StatusWebhookURL != "" {
+		monitor.notifier = NewWebhookNotifier(config.StatusWebhookURL)
 	}
-}
-
-// NewMonitorWithConfig creates a new monitoring instance with custom configuration.
-// This allows fine-tuning of monitoring behavior for specific deployment requirements.
-// Returns a pointer to the newly created Monitor.
-func NewMonitorWithConfig(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, pfctlManager *system.PfctlManager, config *MonitorConfig) *Monitor {
-	monitor := NewMonitor(db, wgServer, ipPool, pfctlManager)
-	monitor.config = config
 	return monitor
 }
 
@@ -183,6 +232,11 @@ func (m *Monitor) Start(ctx context.Context) error {
 	m.running = true
 	m.logManager.LogInfo("Starting VPN server monitoring")
 
+	// Catch out-of-sync server keys before clients start failing silently
+	if err := m.verifyServerKeys(); err != nil {
+		m.logManager.LogError(fmt.Sprintf("Failed to verify server keys: %v", err))
+	}
+
 	// Start the monitoring goroutine
 	go m.monitorLoop(ctx)
 
@@ -227,6 +281,47 @@ func (m *Monitor) GetMetrics() *ServerMetrics {
 	return &metricsCopy
 }
 
+// SubscribeMetrics registers a subscriber that is notified each time new
+// metrics have been collected; read them with GetMetrics. Notifications do not
+// queue up: a subscriber that has not consumed the previous one receives a
+// single notification for several collections. Call UnsubscribeMetrics with
+// the returned channel when done.
+func (m *Monitor) SubscribeMetrics() <-chan struct{} {
+	updates := make(chan struct{}, 1)
+
+	m.subscriberMutex.Lock()
+	defer m.subscriberMutex.Unlock()
+
+	m.metricsSubscribers[updates] = updates
+	return updates
+}
+
+// UnsubscribeMetrics removes a subscriber registered with SubscribeMetrics and
+// closes its channel. Unknown or already removed channels are ignored.
+func (m *Monitor) UnsubscribeMetrics(updates <-chan struct{}) {
+	m.subscriberMutex.Lock()
+	defer m.subscriberMutex.Unlock()
+
+	if channel, ok := m.metricsSubscribers[updates]; ok {
+		delete(m.metricsSubscribers, updates)
+		close(channel)
+	}
+}
+
+// publishMetrics notifies metrics subscribers without blocking.
+func (m *Monitor) publishMetrics() {
+	m.subscriberMutex.Lock()
+	defer m.subscriberMutex.Unlock()
+
+	for _, channel := range m.metricsSubscribers {
+		select {
+		case channel <- struct{}{}:
+		default:
+			// A notification is already pending
+		}
+	}
+}
+
 // GetServerStatus returns the current overall server status.
 // This provides a quick health check result that can be used for
 // load balancers, health checks, and monitoring dashboards.
@@ -237,12 +332,81 @@ func (m *Monitor) GetServerStatus() ServerStatus {
 	return m.metrics.ServerStatus
 }
 
+// GetAlertManager returns the alert manager used by this monitor.
+// This allows operators to resolve or suppress alerts through the API.
+func (m *Monitor) GetAlertManager() *AlertManager {
+	return m.alertManager
+}
+
+// GetLogManager returns the log manager used by this monitor, so other
+// components can write to the same application log.
+func (m *Monitor) GetLogManager() *LogManager {
+	return m.logManager
+}
+
+// SetDataCapConfig replaces the data cap configuration, e.g. to define
+// default caps for client tags or change the accounting period reset day.
+func (m *Monitor) SetDataCapConfig(config DataCapConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.dataCaps = NewDataCapEnforcerWithConfig(m.db, m.wgServer, m.alertManager, config)
+}
+
+// SetLogRetentionDays changes how many days of connection logs the periodic
+// cleanup keeps. Zero or less disables the cleanup.
+func (m *Monitor) SetLogRetentionDays(days int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.config.LogRetentionDays = days
+}
+
+// GetLogRetentionDays returns how many days of connection logs are kept.
+func (m *Monitor) GetLogRetentionDays() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.config.LogRetentionDays
+}
+
 // IsHealthy returns true if the server is in a healthy state.
 // This is a convenience method for quick health checks.
 func (m *Monitor) IsHealthy() bool {
 	return m.GetServerStatus() == StatusHealthy
 }
 
+// verifyServerKeys checks that the stored server public key matches the one derived
+// from the stored private key, raising a critical security alert on mismatch.
+func (m *Monitor) verifyServerKeys() error {
+	serverConfig, err := m.db.GetServerConfig()
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			// Nothing to verify until the server has been initialized
+			return nil
+		}
+		return fmt.Errorf("failed to get server configuration: %w", err)
+	}
+
+	derived, err := wireguard.DerivePublicKey(serverConfig.PrivateKey)
+	if err == nil && derived == serverConfig.PublicKey {
+		return nil
+	}
+
+	description := "The stored server public key does not match the stored private key; clients will fail to connect"
+	if err != nil {
+		description = fmt.Sprintf("The stored server private key is invalid: %v", err)
+	}
+
+	m.alertManager.RaiseAlert("security_server_key_mismatch", AlertTypeSecurity, SeverityCritical,
+		"Server Key Mismatch", description, map[string]interface{}{
+			"stored_public_key": serverConfig.PublicKey,
+		})
+
+	m.logManager.LogError(description)
+	return nil
+}
+
 // monitorLoop is the main monitoring loop that runs in a separate goroutine.
 // It periodically collects metrics, processes alerts, and manages logs.
 func (m *Monitor) monitorLoop(ctx context.Context) {
@@ -258,15 +422,46 @@ func (m *Monitor) monitorLoop(ctx context.Context) {
 			m.logManager.LogInfo("Monitor stop signal received, stopping monitoring loop")
 			return
 		case <-ticker.C:
+			if err := m.syncClientTraffic(); err != nil {
+				m.logManager.LogError(fmt.Sprintf("Error syncing client traffic: %v", err))
+			}
 			if err := m.collectMetrics(); err != nil {
 				m.logManager.LogError(fmt.Sprintf("Error collecting metrics: %v", err))
+			} else {
+				if err := m.recordMetricsSnapshot(); err != nil {
+					m.logManager.LogError(fmt.Sprintf("Error recording metrics history: %v", err))
+				}
+				m.publishMetrics()
+			}
+			m.enforceDataCaps()
+			if err := m.enforceDataQuotas(); err != nil {
+				m.logManager.LogError(fmt.Sprintf("Error enforcing data quotas: %v", err))
+			}
+			if err := m.disableExpiredClients(time.Now()); err != nil {
+				m.logManager.LogError(fmt.Sprintf("Error disabling expired clients: %v", err))
+			}
+			if err := m.reconcilePeerCount(); err != nil {
+				m.logManager.LogWarn(fmt.Sprintf("Skipping peer count reconciliation: %v", err))
 			}
 			m.processAlerts()
-			m.cleanupOldData()
+			if err := m.cleanupOldData(); err != nil {
+				m.logManager.LogError(fmt.Sprintf("Error cleaning up old data: %v", err))
+			}
 		}
 	}
 }
 
+// enforceDataCaps applies per-client data caps based on the latest transfer counters.
+func (m *Monitor) enforceDataCaps() {
+	m.mutex.RLock()
+	dataCaps := m.dataCaps
+	m.mutex.RUnlock()
+
+	if err := dataCaps.Enforce(time.Now()); err != nil {
+		m.logManager.LogError(fmt.Sprintf("Error enforcing data caps: %v", err))
+	}
+}
+
 // collectMetrics gathers all current metrics from various sources.
 // This includes system stats, connection stats, network stats, and security stats.
 func (m *Monitor) collectMetrics() error {
@@ -277,9 +472,9 @@ func (m *Monitor) collectMetrics() error {
 	m.lastUpdateTime = now
 
 	// Collect connection statistics
-	connectionStats, err := m.collectConnectionStats()
-	if err != nil {
-		m.logManager.LogError(fmt.Sprintf("Failed to collect connection stats: %v", err))
+	connectionStats, connectionErr := m.collectConnectionStats()
+	if connectionErr != nil {
+		m.logManager.LogError(fmt.Sprintf("Failed to collect connection stats: %v", connectionErr))
 	}
 
 	// Collect network statistics
@@ -295,24 +490,29 @@ func (m *Monitor) collectMetrics() error {
 	}
 
 	// Collect security statistics
-	securityStats, err := m.collectSecurityStats()
-	if err != nil {
-		m.logManager.LogError(fmt.Sprintf("Failed to collect security stats: %v", err))
+	securityStats, securityErr := m.collectSecurityStats()
+	if securityErr != nil {
+		m.logManager.LogError(fmt.Sprintf("Failed to collect security stats: %v", securityErr))
 	}
 
 	// Collect WireGuard statistics
-	wgStats, err := m.collectWireGuardStats()
-	if err != nil {
-		m.logManager.LogError(fmt.Sprintf("Failed to collect WireGuard stats: %v", err))
+	wgStats, wgErr := m.collectWireGuardStats()
+	if wgErr != nil {
+		m.logManager.LogError(fmt.Sprintf("Failed to collect WireGuard stats: %v", wgErr))
 	}
+	m.collectErrors = collectorErrors{database: connectionErr, wireGuard: wgErr, security: securityErr}
 
 	// Collect performance metrics
 	performanceStats := m.collectPerformanceStats()
 
+	// Determine overall status and notify on transitions
+	status, factors := m.evaluateServerStatus(connectionStats, systemStats, securityStats)
+	m.trackStatusChange(status, factors, now)
+
 	// Update metrics
 	m.metrics = &ServerMetrics{
 		Timestamp:       now,
-		ServerStatus:    m.calculateServerStatus(connectionStats, systemStats, securityStats),
+		ServerStatus:    status,
 		ConnectionStats: connectionStats,
 		NetworkStats:    networkStats,
 		SystemStats:     systemStats,
@@ -337,12 +537,25 @@ func (m *Monitor) collectConnectionStats() (ConnectionStats, error) {
 		return ConnectionStats{}, fmt.Errorf("failed to get clients: %w", err)
 	}
 
-	// Count active clients (those with recent handshakes)
+	// Count active clients (those with recent handshakes), preferring the live
+	// interface state over the handshakes stored in the database
 	activeCount := 0
 	now := time.Now()
-	for _, client := range clients {
-		if client.LastHandshake != nil && now.Sub(*client.LastHandshake) < 5*time.Minute {
-			activeCount++
+	if peers, err := m.peerSource.GetPeerStatus(); err == nil {
+		handshakes := make(map[string]*time.Time, len(peers))
+		for _, peer := range peers {
+			handshakes[peer.PublicKey] = peer.LatestHandshake
+		}
+		for _, client := range clients {
+			if isActiveHandshake(handshakes[client.PublicKey], now) {
+				activeCount++
+			}
+		}
+	} else {
+		for _, client := range clients {
+			if client.LastHandshake != nil && now.Sub(*client.LastHandshake) < ActivePeerWindow {
+				activeCount++
+			}
 		}
 	}
 
@@ -378,11 +591,6 @@ func (m *Monitor) collectConnectionStats() (ConnectionStats, error) {
 
 // collectNetworkStats gathers network usage and performance statistics.
 func (m *Monitor) collectNetworkStats() (NetworkStats, error) {
-	// Get IP pool utilization
-	totalIPs := m.ipPool.GetTotalIPs()
-	allocatedIPs := m.ipPool.GetAllocatedCount()
-	utilization := float64(allocatedIPs) / float64(totalIPs) * 100
-
 	// Get aggregate client stats
 	clients, err := m.db.ListClients()
 	if err != nil {
@@ -402,49 +610,101 @@ func (m *Monitor) collectNetworkStats() (NetworkStats, error) {
 		PacketsReceived:   0, // Would need system-level monitoring
 		PacketsSent:       0, // Would need system-level monitoring
 		PacketsDropped:    0, // Would need system-level monitoring
-		IPPoolUtilization: utilization,
+		IPPoolUtilization: m.ipPool.GetUtilization(),
 		LastUpdate:        time.Now(),
 	}, nil
 }
 
 // collectSystemStats gathers system resource usage statistics.
+// CPU, disk, load and uptime are read from the host; a value that cannot be
+// collected on this platform is logged and reported as zero.
 func (m *Monitor) collectSystemStats() SystemStats {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
-	return SystemStats{
-		CPUUsage:    0.0, // Would need system-level monitoring
+	stats := SystemStats{
 		MemoryUsage: float64(memStats.Alloc) / float64(memStats.Sys) * 100,
-		DiskUsage:   0.0, // Would need system-level monitoring
-		LoadAverage: 0.0, // Would need system-level monitoring
-		Uptime:      time.Since(time.Now().Add(-time.Hour)), // Placeholder
 		GoRoutines:  runtime.NumGoroutine(),
 		LastUpdate:  time.Now(),
 	}
+
+	// Usage since the previous call, or since boot on the first call
+	if percents, err := cpu.Percent(0, false); err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to collect CPU usage: %v", err))
+	} else if len(percents) > 0 {
+		stats.CPUUsage = percents[0]
+	}
+
+	if usage, err := disk.Usage(m.diskStatsPath()); err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to collect disk usage: %v", err))
+	} else {
+		stats.DiskUsage = usage.UsedPercent
+	}
+
+	if avg, err := load.Avg(); err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to collect load average: %v", err))
+	} else {
+		stats.LoadAverage = avg.Load1
+	}
+
+	if uptime, err := host.Uptime(); err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to collect uptime: %v", err))
+	} else {
+		stats.Uptime = time.Duration(uptime) * time.Second
+	}
+
+	return stats
+}
+
+// diskStatsPath returns the path whose filesystem is reported as disk usage:
+// the log directory, or its closest existing parent if it has not been created yet.
+func (m *Monitor) diskStatsPath() string {
+	path, err := filepath.Abs(m.logManager.config.LogDirectory)
+	if err != nil {
+		return "/"
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
 }
 
 // collectSecurityStats gathers security and firewall status.
 func (m *Monitor) collectSecurityStats() (SecurityStats, error) {
 	// Check firewall status
-	firewallEnabled, err := m.pfctlManager.IsEnabled()
+	firewallEnabled, err := m.firewall.IsEnabled()
 	if err != nil {
 		return SecurityStats{}, fmt.Errorf("failed to check firewall status: %w", err)
 	}
 
 	// Get active firewall rules
-	rules, err := m.pfctlManager.GetActiveRules()
+	rules, err := m.firewall.GetActiveRules()
 	if err != nil {
 		return SecurityStats{}, fmt.Errorf("failed to get firewall rules: %w", err)
 	}
 
-	return SecurityStats{
+	// Count packets dropped by the firewall rules; the counter is cumulative
+	blocked, err := m.firewall.GetBlockedCount()
+	if err != nil {
+		return SecurityStats{}, fmt.Errorf("failed to get blocked connections: %w", err)
+	}
+
+	now := time.Now()
+	stats := SecurityStats{
 		FirewallEnabled:    firewallEnabled,
 		ActiveRules:        len(rules),
-		BlockedConnections: 0, // Would need log analysis
-		FailedLogins:       0, // Would need authentication log analysis
-		LastSecurityScan:   time.Now(),
-		ThreatLevel:        "low", // Would need threat analysis
-	}, nil
+		BlockedConnections: m.blockedPackets.observe(now, blocked, failedLoginWindow),
+		FailedLogins:       m.countFailedLogins(now.Add(-failedLoginWindow)),
+		LastSecurityScan:   now,
+	}
+	stats.ThreatLevel = assessThreatLevel(stats)
+	return stats, nil
 }
 
 // collectWireGuardStats gathers WireGuard-specific metrics.
@@ -468,17 +728,57 @@ func (m *Monitor) collectWireGuardStats() (WireGuardStats, error) {
 		peers = []wireguard.Peer{} // Use empty slice if error
 	}
 
+	// Count active peers from the live interface state
+	liveStatus, err := m.peerSource.GetPeerStatus()
+	if err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to get live peer status: %v", err))
+		liveStatus = []wireguard.PeerStatus{}
+	}
+
+	now := time.Now()
+	activePeers := 0
+	var lastHandshake time.Time
+	for _, peer := range liveStatus {
+		if isActiveHandshake(peer.LatestHandshake, now) {
+			activePeers++
+		}
+		if peer.LatestHandshake != nil && peer.LatestHandshake.After(lastHandshake) {
+			lastHandshake = *peer.LatestHandshake
+		}
+	}
+
+	// Identify the configuration by its hash, falling back to the file on disk
+	// if the server never wrote it
+	var configVersion string
+	var configDrifted bool
+	drift, err := m.wgServer.CheckConfigDrift()
+	if err != nil {
+		m.logManager.LogWarn(fmt.Sprintf("Failed to check config drift: %v", err))
+	} else {
+		configVersion = drift.AppliedHash
+		if configVersion == "" {
+			configVersion = drift.CurrentHash
+		}
+		configDrifted = drift.Drifted
+	}
+
 	return WireGuardStats{
 		InterfaceStatus: status,
 		ListenPort:      config.ListenPort,
 		PublicKey:       config.PublicKey,
 		TotalPeers:      len(peers),
-		ActivePeers:     0, // Would need to check peer status
-		LastHandshake:   time.Now(),
-		ConfigVersion:   "1.0", // Placeholder
+		ActivePeers:     activePeers,
+		LastHandshake:   lastHandshake,
+		ConfigVersion:   configVersion,
+		ConfigDrifted:   configDrifted,
 	}, nil
 }
 
+// isActiveHandshake reports whether a peer with the given latest handshake counts as active.
+func isActiveHandshake(handshake *time.Time, now time.Time) bool {
+	return handshake != nil && now.Sub(*handshake) < ActivePeerWindow
+}
+
 // collectPerformanceStats gathers performance-related metrics.
 func (m *Monitor) collectPerformanceStats() PerformanceMetrics {
 	return PerformanceMetrics{
@@ -492,16 +792,29 @@ func (m *Monitor) collectPerformanceStats() PerformanceMetrics {
 
 // calculateServerStatus determines the overall server health status.
 func (m *Monitor) calculateServerStatus(conn ConnectionStats, sys SystemStats, sec SecurityStats) ServerStatus {
+	status, _ := m.evaluateServerStatus(conn, sys, sec)
+	return status
+}
+
+// evaluateServerStatus determines the overall server health status together
+// with the factors that contributed to it. A healthy server has no factors.
+func (m *Monitor) evaluateServerStatus(conn ConnectionStats, sys SystemStats, sec SecurityStats) (ServerStatus, []string) {
 	// Simple health calculation based on various factors
+	factors := []string{}
 	if !sec.FirewallEnabled {
-		return StatusDegraded
+		factors = append(factors, "firewall disabled")
 	}
-
-	if sys.MemoryUsage > 90 || sys.GoRoutines > 1000 {
-		return StatusDegraded
+	if sys.MemoryUsage > 90 {
+		factors = append(factors, fmt.Sprintf("memory usage %.1f%%", sys.MemoryUsage))
+	}
+	if sys.GoRoutines > 1000 {
+		factors = append(factors, fmt.Sprintf("%d goroutines", sys.GoRoutines))
 	}
 
-	return StatusHealthy
+	if len(factors) > 0 {
+		return StatusDegraded, factors
+	}
+	return StatusHealthy, factors
 }
 
 // processAlerts evaluates current metrics against alert thresholds.
@@ -509,10 +822,37 @@ func (m *Monitor) processAlerts() {
 	m.alertManager.EvaluateMetrics(m.metrics)
 }
 
-// cleanupOldData removes old metrics and logs based on retention policies.
-func (m *Monitor) cleanupOldData() {
-	// This would contain cleanup logic for old data
-	// For now, it's a placeholder
+// connectionLogDeleteBatchSize is the number of connection log and metrics
+// snapshot rows deleted per statement.
+const connectionLogDeleteBatchSize = 1000
+
+// cleanupOldData removes old data based on retention policies.
+// Connection logs older than LogRetentionDays are deleted from the database and
+// buffered log entries older than MetricsRetention are dropped from memory.
+// A retention of zero or less disables the corresponding cleanup.
+func (m *Monitor) cleanupOldData() error {
+	now := time.Now()
+
+	if m.config.MetricsRetention > 0 {
+		cutoff := now.Add(-m.config.MetricsRetention)
+		m.logManager.PruneBefore(cutoff)
+		if _, err := m.db.DeleteMetricsSnapshotsBefore(cutoff, connectionLogDeleteBatchSize); err != nil {
+			return fmt.Errorf("failed to clean up metrics history: %w", err)
+		}
+	}
+
+	if retentionDays := m.GetLogRetentionDays(); retentionDays > 0 {
+		cutoff := now.AddDate(0, 0, -retentionDays)
+		deleted, err := m.db.DeleteConnectionLogsBefore(cutoff, connectionLogDeleteBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to clean up connection logs: %w", err)
+		}
+		if deleted > 0 {
+			m.logManager.LogInfo(fmt.Sprintf("Deleted %d connection logs older than %d days", deleted, retentionDays))
+		}
+	}
+
+	return nil
 }
 
 // getDefaultAlertConfig returns default alert configuration.
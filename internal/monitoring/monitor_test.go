@@ -5,6 +5,11 @@ package monitoring
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,13 +24,23 @@ import (
 	"my-vpn/internal/wireguard"
 )
 
+// fakePeerSource returns fixed live peer status instead of running `wg show`.
+type fakePeerSource struct {
+	peers []wireguard.PeerStatus
+	err   error
+}
+
+func (f *fakePeerSource) GetPeerStatus() ([]wireguard.PeerStatus, error) {
+	return f.peers, f.err
+}
+
 func setupTestMonitor(t *testing.T) (*Monitor, func()) {
 	// Create in-memory database
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
 	// Auto-migrate tables
-	err = db.AutoMigrate(&database.User{}, &database.Client{}, &database.ServerConfig{}, &database.ConnectionLog{})
+	err = db.AutoMigrate(&database.User{}, &database.Client{}, &database.ServerConfig{}, &database.ConnectionLog{}, &database.MetricsSnapshot{})
 	require.NoError(t, err)
 
 	database := &database.Database{DB: db}
@@ -40,8 +55,10 @@ func setupTestMonitor(t *testing.T) (*Monitor, func()) {
 	// Create pfctl manager
 	pfctlManager := system.NewPfctlManager()
 
-	// Create monitor
-	monitor := NewMonitor(database, wgServer, ipPool, pfctlManager)
+	// Create monitor, keeping its log files out of the source tree
+	config := DefaultMonitorConfig()
+	config.LogDirectory = t.TempDir()
+	monitor := NewMonitorWithConfig(database, wgServer, ipPool, pfctlManager, config)
 
 	cleanup := func() {
 		monitor.Stop()
@@ -49,6 +66,7 @@ func setupTestMonitor(t *testing.T) (*Monitor, func()) {
 		db.Exec("DROP TABLE IF EXISTS clients")
 		db.Exec("DROP TABLE IF EXISTS server_configs")
 		db.Exec("DROP TABLE IF EXISTS connection_logs")
+		db.Exec("DROP TABLE IF EXISTS metrics_snapshots")
 	}
 
 	return monitor, cleanup
@@ -70,13 +88,14 @@ func TestNewMonitor(t *testing.T) {
 	})
 }
 
+
 func TestNewMonitorWithConfig(t *testing.T) {
 	// Create in-memory database
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
 	// Auto-migrate tables
-	err = db.AutoMigrate(&database.User{}, &database.Client{}, &database.ServerConfig{}, &database.ConnectionLog{})
+	err = db.AutoMigrate(&database.User{}, &database.Client{}, &database.ServerConfig{}, &database.ConnectionLog{}, &database.MetricsSnapshot{})
 	require.NoError(t, err)
 
 	database := &database.Database{DB: db}
@@ -90,6 +109,7 @@ func TestNewMonitorWithConfig(t *testing.T) {
 			LogRetentionDays:  60,
 			EnableSystemStats: false,
 			EnableDebugLogs:   true,
+			LogDirectory:      t.TempDir(),
 		}
 
 		monitor := NewMonitorWithConfig(database, wgServer, ipPool, pfctlManager, config)
@@ -147,6 +167,48 @@ func TestMonitor_StartStop(t *testing.T) {
 	})
 }
 
+func TestMonitor_VerifyServerKeys(t *testing.T) {
+	t.Run("should not alert when keys match", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		require.NoError(t, monitor.db.CreateServerConfig(&database.ServerConfig{
+			PrivateKey: keyPair.PrivateKey,
+			PublicKey:  keyPair.PublicKey,
+			ListenPort: 51820,
+			Network:    "10.0.0.0/24",
+		}))
+
+		require.NoError(t, monitor.Start(context.Background()))
+		assert.Empty(t, monitor.alertManager.GetActiveAlerts())
+	})
+
+	t.Run("should raise security alert at startup on mismatch", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		keyPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		otherPair, err := wireguard.GenerateKeyPair()
+		require.NoError(t, err)
+		require.NoError(t, monitor.db.CreateServerConfig(&database.ServerConfig{
+			PrivateKey: keyPair.PrivateKey,
+			PublicKey:  otherPair.PublicKey,
+			ListenPort: 51820,
+			Network:    "10.0.0.0/24",
+		}))
+
+		require.NoError(t, monitor.Start(context.Background()))
+
+		alerts := monitor.alertManager.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, AlertTypeSecurity, alerts[0].Type)
+		assert.Equal(t, SeverityCritical, alerts[0].Severity)
+	})
+}
+
 func TestMonitor_GetMetrics(t *testing.T) {
 	monitor, cleanup := setupTestMonitor(t)
 	defer cleanup()
@@ -167,6 +229,44 @@ func TestMonitor_GetMetrics(t *testing.T) {
 	})
 }
 
+func TestMonitor_SubscribeMetrics(t *testing.T) {
+	monitor, cleanup := setupTestMonitor(t)
+	defer cleanup()
+
+	t.Run("should notify every subscriber after metrics are published", func(t *testing.T) {
+		first := monitor.SubscribeMetrics()
+		second := monitor.SubscribeMetrics()
+		defer monitor.UnsubscribeMetrics(first)
+		defer monitor.UnsubscribeMetrics(second)
+
+		monitor.publishMetrics()
+
+		assert.Len(t, first, 1)
+		assert.Len(t, second, 1)
+	})
+
+	t.Run("should coalesce notifications for a slow subscriber", func(t *testing.T) {
+		updates := monitor.SubscribeMetrics()
+		defer monitor.UnsubscribeMetrics(updates)
+
+		monitor.publishMetrics()
+		monitor.publishMetrics()
+		monitor.publishMetrics()
+
+		assert.Len(t, updates, 1)
+	})
+
+	t.Run("should close the channel on unsubscribe", func(t *testing.T) {
+		updates := monitor.SubscribeMetrics()
+		monitor.UnsubscribeMetrics(updates)
+		monitor.UnsubscribeMetrics(updates)
+
+		monitor.publishMetrics()
+
+		_, ok := <-updates
+		assert.False(t, ok)
+	})
+}
 func TestMonitor_GetServerStatus(t *testing.T) {
 	monitor, cleanup := setupTestMonitor(t)
 	defer cleanup()
@@ -237,6 +337,127 @@ func TestMonitor_CollectConnectionStats(t *testing.T) {
 	})
 }
 
+func TestMonitor_CollectConnectionStats_LivePeers(t *testing.T) {
+	monitor, cleanup := setupTestMonitor(t)
+	defer cleanup()
+
+	for i, key := range []string{"key1", "key2", "key3"} {
+		require.NoError(t, monitor.db.CreateClient(&database.Client{
+			Name:      key,
+			PublicKey: key,
+			IPAddress: fmt.Sprintf("10.0.0.%d", i+2),
+			Enabled:   true,
+		}))
+	}
+
+	recent := time.Now().Add(-time.Minute)
+	stale := time.Now().Add(-10 * time.Minute)
+
+	t.Run("should count clients with a recent live handshake", func(t *testing.T) {
+		monitor.peerSource = &fakePeerSource{peers: []wireguard.PeerStatus{
+			{PublicKey: "key1", LatestHandshake: &recent},
+			{PublicKey: "key2", LatestHandshake: &stale},
+			{PublicKey: "key3"},
+		}}
+
+		stats, err := monitor.collectConnectionStats()
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.TotalClients)
+		assert.Equal(t, 1, stats.ActiveClients)
+	})
+
+	t.Run("should report no active clients when the interface is down", func(t *testing.T) {
+		monitor.peerSource = &fakePeerSource{peers: []wireguard.PeerStatus{}}
+
+		stats, err := monitor.collectConnectionStats()
+		require.NoError(t, err)
+		assert.Equal(t, 0, stats.ActiveClients)
+	})
+
+	t.Run("should apply the same window to stored handshakes without live status", func(t *testing.T) {
+		monitor.peerSource = &fakePeerSource{err: errors.New("wg not found")}
+		aged := time.Now().Add(-4 * time.Minute)
+		for key, handshake := range map[string]*time.Time{"key1": &recent, "key2": &aged} {
+			client, err := monitor.db.GetClientByPublicKey(key)
+			require.NoError(t, err)
+			client.LastHandshake = handshake
+			require.NoError(t, monitor.db.UpdateClient(client))
+		}
+
+		stats, err := monitor.collectConnectionStats()
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.ActiveClients)
+	})
+}
+
+func TestMonitor_CollectWireGuardStats(t *testing.T) {
+	monitor, cleanup := setupTestMonitor(t)
+	defer cleanup()
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(dataCapTestConfig), 0600))
+	monitor.wgServer = wireguard.NewWireGuardServerWithConfig(tempDir, "wg0")
+
+	t.Run("should count peers with a handshake within 3 minutes as active", func(t *testing.T) {
+		recent := time.Now().Add(-2 * time.Minute)
+		newest := time.Now().Add(-30 * time.Second)
+		stale := time.Now().Add(-4 * time.Minute)
+		monitor.peerSource = &fakePeerSource{peers: []wireguard.PeerStatus{
+			{PublicKey: "peer-a", LatestHandshake: &recent},
+			{PublicKey: "peer-b", LatestHandshake: &newest},
+			{PublicKey: "peer-c", LatestHandshake: &stale},
+			{PublicKey: "peer-d"},
+		}}
+
+		stats, err := monitor.collectWireGuardStats()
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.ActivePeers)
+		assert.Equal(t, 1, stats.TotalPeers)
+		assert.True(t, stats.LastHandshake.Equal(newest))
+	})
+
+	t.Run("should report no active peers when status is unavailable", func(t *testing.T) {
+		monitor.peerSource = &fakePeerSource{err: errors.New("wg not found")}
+
+		stats, err := monitor.collectWireGuardStats()
+		require.NoError(t, err)
+		assert.Equal(t, 0, stats.ActivePeers)
+		assert.True(t, stats.LastHandshake.IsZero())
+	})
+
+	t.Run("should report the config hash and drift", func(t *testing.T) {
+		monitor.peerSource = &fakePeerSource{}
+		configPath := filepath.Join(tempDir, "wg0.conf")
+
+		// A file the server never wrote is identified by its current hash
+		stats, err := monitor.collectWireGuardStats()
+		require.NoError(t, err)
+		assert.Equal(t, wireguard.ConfigHash([]byte(dataCapTestConfig)), stats.ConfigVersion)
+		assert.False(t, stats.ConfigDrifted)
+
+		require.NoError(t, monitor.wgServer.WriteConfig(&wireguard.ServerConfig{
+			PrivateKey: "test-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51820,
+		}))
+		stats, err = monitor.collectWireGuardStats()
+		require.NoError(t, err)
+		applied := stats.ConfigVersion
+		assert.NotEqual(t, wireguard.ConfigHash([]byte(dataCapTestConfig)), applied)
+		assert.False(t, stats.ConfigDrifted)
+
+		// Editing the file keeps the applied version but flags the drift
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(configPath, []byte(strings.Replace(string(content), "51820", "51821", 1)), 0600))
+
+		stats, err = monitor.collectWireGuardStats()
+		require.NoError(t, err)
+		assert.Equal(t, applied, stats.ConfigVersion)
+		assert.True(t, stats.ConfigDrifted)
+	})
+}
+
 func TestMonitor_CollectNetworkStats(t *testing.T) {
 	monitor, cleanup := setupTestMonitor(t)
 	defer cleanup()
@@ -265,6 +486,20 @@ func TestMonitor_CollectSystemStats(t *testing.T) {
 		assert.Greater(t, stats.GoRoutines, 0)
 		assert.NotZero(t, stats.LastUpdate)
 	})
+
+	t.Run("should report host values within plausible ranges", func(t *testing.T) {
+		stats := monitor.collectSystemStats()
+		assert.LessOrEqual(t, stats.CPUUsage, 100.0)
+		assert.Greater(t, stats.DiskUsage, 0.0)
+		assert.LessOrEqual(t, stats.DiskUsage, 100.0)
+		assert.Greater(t, stats.Uptime, time.Duration(0))
+	})
+
+	t.Run("should resolve disk path for a missing log directory", func(t *testing.T) {
+		monitor.logManager.config.LogDirectory = filepath.Join(t.TempDir(), "missing", "logs")
+		path := monitor.diskStatsPath()
+		assert.DirExists(t, path)
+	})
 }
 
 func TestServerStatus_String(t *testing.T) {
@@ -345,4 +580,107 @@ func TestMonitor_CalculateServerStatus(t *testing.T) {
 		status := monitor.calculateServerStatus(connStats, sysStats, secStats)
 		assert.Equal(t, StatusDegraded, status)
 	})
-}
\ No newline at end of file
+}
+func TestMonitor_CleanupOldData(t *testing.T) {
+	seedLogs := func(t *testing.T, monitor *Monitor, timestamps ...time.Time) {
+		for _, ts := range timestamps {
+			require.NoError(t, monitor.db.Create(&database.ConnectionLog{
+				ClientID:  1,
+				Action:    "connect",
+				IPAddress: "10.0.0.2",
+				Timestamp: ts,
+			}).Error)
+		}
+	}
+
+	t.Run("should delete connection logs older than retention", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		now := time.Now()
+		monitor.config.LogRetentionDays = 7
+		seedLogs(t, monitor, now.AddDate(0, 0, -30), now.AddDate(0, 0, -8), now.AddDate(0, 0, -1), now)
+
+		require.NoError(t, monitor.cleanupOldData())
+
+		logs, err := monitor.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		for _, log := range logs {
+			assert.True(t, log.Timestamp.After(now.AddDate(0, 0, -7)))
+		}
+	})
+
+	t.Run("should keep connection logs when retention is disabled", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.config.LogRetentionDays = 0
+		seedLogs(t, monitor, time.Now().AddDate(-1, 0, 0))
+
+		require.NoError(t, monitor.cleanupOldData())
+
+		logs, err := monitor.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+	})
+
+	t.Run("should use the retention changed at runtime", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		now := time.Now()
+		seedLogs(t, monitor, now.AddDate(0, 0, -20), now.AddDate(0, 0, -5), now)
+
+		monitor.SetLogRetentionDays(10)
+		assert.Equal(t, 10, monitor.GetLogRetentionDays())
+		require.NoError(t, monitor.cleanupOldData())
+
+		logs, err := monitor.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		assert.Len(t, logs, 2)
+
+		monitor.SetLogRetentionDays(1)
+		require.NoError(t, monitor.cleanupOldData())
+
+		logs, err = monitor.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+	})
+
+	t.Run("should delete connection logs in batches", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		now := time.Now()
+		old := now.AddDate(0, 0, -10)
+		seedLogs(t, monitor, old, old, old, old, old, now)
+
+		deleted, err := monitor.db.DeleteConnectionLogsBefore(now.AddDate(0, 0, -1), 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), deleted)
+
+		logs, err := monitor.db.GetConnectionLogs(10)
+		require.NoError(t, err)
+		assert.Len(t, logs, 1)
+	})
+
+	t.Run("should prune buffered logs older than metrics retention", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.logManager.mutex.Lock()
+		monitor.logManager.logBuffer = []LogEntry{
+			{Timestamp: time.Now().Add(-48 * time.Hour), Level: LogLevelInfo, Message: "old"},
+			{Timestamp: time.Now(), Level: LogLevelInfo, Message: "recent"},
+		}
+		monitor.logManager.mutex.Unlock()
+		monitor.config.MetricsRetention = 24 * time.Hour
+
+		require.NoError(t, monitor.cleanupOldData())
+
+		logs := monitor.logManager.GetRecentLogs(10)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "recent", logs[0].Message)
+	})
+}
@@ -0,0 +1,107 @@
+package monitoring
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds how long a single webhook delivery may take.
+const webhookTimeout = 10 * time.Second
+
+// StatusChange describes a transition of the overall server status.
+type StatusChange struct {
+	From      ServerStatus `json:"from"`      // Status before the transition
+	To        ServerStatus `json:"to"`        // Status after the transition
+	Factors   []string     `json:"factors"`   // Conditions that contributed to the new status
+	Timestamp time.Time    `json:"timestamp"` // When the transition was detected
+}
+
+// StatusNotifier is notified when the overall server status changes.
+type StatusNotifier interface {
+	NotifyStatusChange(change StatusChange) error
+}
+
+// WebhookNotifier delivers status changes as JSON POST requests to a webhook URL.
+type WebhookNotifier struct {
+	url    string       // Webhook endpoint receiving the notifications
+	client *http.Client // HTTP client used for delivery
+}
+
+// NewWebhookNotifier creates a notifier posting status changes to the given URL.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// NotifyStatusChange posts the status change to the webhook.
+// Returns an error if the request fails or the webhook responds with a non-2xx status.
+func (n *WebhookNotifier) NotifyStatusChange(change StatusChange) error {
+	return n.Post(change)
+}
+
+// Post sends an arbitrary JSON payload to the webhook, so other components can
+// reuse the same delivery for their own events.
+// Returns an error if the request fails or the webhook responds with a non-2xx status.
+func (n *WebhookNotifier) Post(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// SetStatusNotifier sets the notifier informed about server status transitions.
+// Passing nil disables notifications.
+func (m *Monitor) SetStatusNotifier(notifier StatusNotifier) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.notifier = notifier
+}
+
+// trackStatusChange records the newly calculated status and notifies the status
+// notifier if it differs from the previous one. Cycles without a transition do
+// not notify. Delivery happens in the background so a slow webhook does not
+// hold up metrics collection.
+func (m *Monitor) trackStatusChange(status ServerStatus, factors []string, now time.Time) {
+	previous := m.lastStatus
+	m.lastStatus = status
+	if status == previous {
+		return
+	}
+
+	m.logManager.LogInfo(fmt.Sprintf("Server status changed from %s to %s", previous, status))
+
+	if m.notifier == nil {
+		return
+	}
+
+	change := StatusChange{
+		From:      previous,
+		To:        status,
+		Factors:   factors,
+		Timestamp: now,
+	}
+	notifier := m.notifier
+	go func() {
+		if err := notifier.NotifyStatusChange(change); err != nil {
+			m.logManager.LogError(fmt.Sprintf("Failed to send status change notification: %v", err))
+		}
+	}()
+}
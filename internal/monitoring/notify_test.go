@@ -0,0 +1,146 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStatusNotifier forwards status changes to a channel.
+type fakeStatusNotifier struct {
+	changes chan StatusChange
+}
+
+func newFakeStatusNotifier() *fakeStatusNotifier {
+	return &fakeStatusNotifier{changes: make(chan StatusChange, 10)}
+}
+
+func (f *fakeStatusNotifier) NotifyStatusChange(change StatusChange) error {
+	f.changes <- change
+	return nil
+}
+
+func TestMonitor_StatusChangeNotification(t *testing.T) {
+	healthySec := SecurityStats{FirewallEnabled: true}
+	healthySys := SystemStats{MemoryUsage: 50.0, GoRoutines: 100}
+	degradedSys := SystemStats{MemoryUsage: 95.0, GoRoutines: 100}
+
+	t.Run("should notify once when status moves from healthy to degraded", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		notifier := newFakeStatusNotifier()
+		monitor.SetStatusNotifier(notifier)
+
+		cycles := []SystemStats{healthySys, healthySys, degradedSys, degradedSys}
+		for _, sys := range cycles {
+			status, factors := monitor.evaluateServerStatus(ConnectionStats{}, sys, healthySec)
+			monitor.trackStatusChange(status, factors, time.Now())
+		}
+
+		select {
+		case change := <-notifier.changes:
+			assert.Equal(t, StatusHealthy, change.From)
+			assert.Equal(t, StatusDegraded, change.To)
+			assert.Equal(t, []string{"memory usage 95.0%"}, change.Factors)
+			assert.NotZero(t, change.Timestamp)
+		case <-time.After(time.Second):
+			t.Fatal("expected a status change notification")
+		}
+
+		select {
+		case change := <-notifier.changes:
+			t.Fatalf("unexpected notification: %+v", change)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should not notify while status stays healthy", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		notifier := newFakeStatusNotifier()
+		monitor.SetStatusNotifier(notifier)
+
+		for i := 0; i < 3; i++ {
+			status, factors := monitor.evaluateServerStatus(ConnectionStats{}, healthySys, healthySec)
+			monitor.trackStatusChange(status, factors, time.Now())
+		}
+
+		select {
+		case change := <-notifier.changes:
+			t.Fatalf("unexpected notification: %+v", change)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should report every contributing factor", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		status, factors := monitor.evaluateServerStatus(ConnectionStats{}, SystemStats{MemoryUsage: 95.0, GoRoutines: 1500}, SecurityStats{})
+		assert.Equal(t, StatusDegraded, status)
+		assert.Equal(t, []string{"firewall disabled", "memory usage 95.0%", "1500 goroutines"}, factors)
+	})
+}
+
+func TestWebhookNotifier_NotifyStatusChange(t *testing.T) {
+	change := StatusChange{
+		From:      StatusHealthy,
+		To:        StatusDegraded,
+		Factors:   []string{"firewall disabled"},
+		Timestamp: time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("should post the status change as JSON", func(t *testing.T) {
+		var received StatusChange
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhookNotifier(server.URL).NotifyStatusChange(change))
+		assert.Equal(t, change.From, received.From)
+		assert.Equal(t, change.To, received.To)
+		assert.Equal(t, change.Factors, received.Factors)
+		assert.True(t, change.Timestamp.Equal(received.Timestamp))
+	})
+
+	t.Run("should fail on non-2xx response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		err := NewWebhookNotifier(server.URL).NotifyStatusChange(change)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "500")
+	})
+}
+
+func TestNewMonitorWithConfig_StatusWebhook(t *testing.T) {
+	monitor, cleanup := setupTestMonitor(t)
+	defer cleanup()
+
+	t.Run("should configure webhook notifier from config", func(t *testing.T) {
+		config := *monitor.config
+		config.StatusWebhookURL = "http://example.com/hook"
+
+		configured := NewMonitorWithConfig(monitor.db, monitor.wgServer, monitor.ipPool, monitor.firewall, &config)
+		require.IsType(t, &WebhookNotifier{}, configured.notifier)
+		assert.Equal(t, "http://example.com/hook", configured.notifier.(*WebhookNotifier).url)
+	})
+
+	t.Run("should leave notifier unset without webhook URL", func(t *testing.T) {
+		configured := NewMonitorWithConfig(monitor.db, monitor.wgServer, monitor.ipPool, monitor.firewall, monitor.config)
+		assert.Nil(t, configured.notifier)
+	})
+}
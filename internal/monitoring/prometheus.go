@@ -0,0 +1,169 @@
+package monitoring
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// UnmatchedRoute is the route label used for requests that did not match any route.
+// Collapsing them into one label keeps scanners probing random paths from
+// blowing up the metric cardinality.
+const UnmatchedRoute = "unmatched"
+
+// HTTPMetrics collects Prometheus metrics about HTTP requests served by the API.
+// It keeps its own registry so multiple servers (and tests) don't collide on
+// the global default registry.
+type HTTPMetrics struct {
+	registry        *prometheus.Registry     // Registry holding all exported metrics
+	requestDuration *prometheus.HistogramVec // Request latency per method, route and status
+}
+
+// NewHTTPMetrics creates a new HTTP metrics collector with default latency buckets.
+// Returns a pointer to the newly created HTTPMetrics.
+func NewHTTPMetrics() *HTTPMetrics {
+	return NewHTTPMetricsWithBuckets(prometheus.DefBuckets)
+}
+
+// NewHTTPMetricsWithBuckets creates a new HTTP metrics collector with custom
+// latency buckets (in seconds), for deployments with specific SLO boundaries.
+// Returns a pointer to the newly created HTTPMetrics.
+func NewHTTPMetricsWithBuckets(buckets []float64) *HTTPMetrics {
+	registry := prometheus.NewRegistry()
+
+	requestDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds, partitioned by method, route template and status code.",
+		Buckets: buckets,
+	}, []string{"method", "route", "status"})
+
+	registry.MustRegister(requestDuration)
+
+	return &HTTPMetrics{
+		registry:        registry,
+		requestDuration: requestDuration,
+	}
+}
+
+// ObserveRequest records the latency of a single HTTP request.
+// The route must be the route template (e.g. "/api/clients/:id"), not the raw
+// request path, so that the number of label values stays bounded.
+func (hm *HTTPMetrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = UnmatchedRoute
+	}
+
+	hm.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// Registry returns the Prometheus registry holding the HTTP metrics.
+// Additional collectors can be registered on it to be exported by Handler.
+func (hm *HTTPMetrics) Registry() *prometheus.Registry {
+	return hm.registry
+}
+
+// Handler returns an HTTP handler exposing the metrics in the Prometheus text format.
+func (hm *HTTPMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(hm.registry, promhttp.HandlerOpts{})
+}
+
+// MetricsSource provides the server metrics of the latest collection cycle,
+// which MetricsCollector reads on every scrape instead of collecting anew.
+type MetricsSource interface {
+	GetMetrics() *ServerMetrics
+}
+
+// alertSeverities lists the severities exported by MetricsCollector, so every
+// severity is reported even when it has no active alerts.
+var alertSeverities = []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// MetricsCollector exports the ServerMetrics collected by the Monitor as
+// Prometheus metrics. Values are read from the source on every scrape, so
+// they reflect the most recent monitoring cycle.
+type MetricsCollector struct {
+	source MetricsSource // Source of the latest server metrics
+
+	serverUp         *prometheus.Desc
+	clientsTotal     *prometheus.Desc
+	clientsActive    *prometheus.Desc
+	peersActive      *prometheus.Desc
+	poolUtilization  *prometheus.Desc
+	bytesReceived    *prometheus.Desc
+	bytesSent        *prometheus.Desc
+	bytesTransferred *prometheus.Desc
+	goroutines       *prometheus.Desc
+	alertsActive     *prometheus.Desc
+}
+
+// NewMetricsCollector creates a collector exporting the metrics of the given source.
+// Returns a pointer to the newly created MetricsCollector.
+func NewMetricsCollector(source MetricsSource) *MetricsCollector {
+	return &MetricsCollector{
+		source: source,
+		serverUp: prometheus.NewDesc("vpn_server_up",
+			"Whether the VPN server is up (1) or down (0).", nil, nil),
+		clientsTotal: prometheus.NewDesc("vpn_clients_total",
+			"Number of configured clients.", nil, nil),
+		clientsActive: prometheus.NewDesc("vpn_clients_active",
+			"Number of clients with a recent handshake.", nil, nil),
+		peersActive: prometheus.NewDesc("vpn_wireguard_peers_active",
+			"Number of WireGuard peers with a recent handshake.", nil, nil),
+		poolUtilization: prometheus.NewDesc("vpn_ip_pool_utilization_percent",
+			"Percentage of the IP pool that is allocated.", nil, nil),
+		bytesReceived: prometheus.NewDesc("vpn_bytes_received_total",
+			"Total bytes received from clients.", nil, nil),
+		bytesSent: prometheus.NewDesc("vpn_bytes_sent_total",
+			"Total bytes sent to clients.", nil, nil),
+		bytesTransferred: prometheus.NewDesc("vpn_bytes_transferred_total",
+			"Total bytes transferred through the VPN.", nil, nil),
+		goroutines: prometheus.NewDesc("vpn_goroutines",
+			"Number of goroutines in the management server.", nil, nil),
+		alertsActive: prometheus.NewDesc("vpn_alerts_active",
+			"Number of active alerts, partitioned by severity.", []string{"severity"}, nil),
+	}
+}
+
+// Describe sends the descriptors of all exported metrics.
+func (mc *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mc.serverUp
+	ch <- mc.clientsTotal
+	ch <- mc.clientsActive
+	ch <- mc.peersActive
+	ch <- mc.poolUtilization
+	ch <- mc.bytesReceived
+	ch <- mc.bytesSent
+	ch <- mc.bytesTransferred
+	ch <- mc.goroutines
+	ch <- mc.alertsActive
+}
+
+// Collect reads the latest server metrics and sends them as Prometheus metrics.
+func (mc *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := mc.source.GetMetrics()
+
+	up := 1.0
+	if metrics.ServerStatus == StatusDown {
+		up = 0
+	}
+	ch <- prometheus.MustNewConstMetric(mc.serverUp, prometheus.GaugeValue, up)
+
+	ch <- prometheus.MustNewConstMetric(mc.clientsTotal, prometheus.GaugeValue, float64(metrics.ConnectionStats.TotalClients))
+	ch <- prometheus.MustNewConstMetric(mc.clientsActive, prometheus.GaugeValue, float64(metrics.ConnectionStats.ActiveClients))
+	ch <- prometheus.MustNewConstMetric(mc.peersActive, prometheus.GaugeValue, float64(metrics.WireGuardStats.ActivePeers))
+	ch <- prometheus.MustNewConstMetric(mc.poolUtilization, prometheus.GaugeValue, metrics.NetworkStats.IPPoolUtilization)
+	ch <- prometheus.MustNewConstMetric(mc.bytesReceived, prometheus.CounterValue, float64(metrics.NetworkStats.BytesReceived))
+	ch <- prometheus.MustNewConstMetric(mc.bytesSent, prometheus.CounterValue, float64(metrics.NetworkStats.BytesSent))
+	ch <- prometheus.MustNewConstMetric(mc.bytesTransferred, prometheus.CounterValue, float64(metrics.NetworkStats.BytesTransferred))
+	ch <- prometheus.MustNewConstMetric(mc.goroutines, prometheus.GaugeValue, float64(metrics.SystemStats.GoRoutines))
+
+	counts := make(map[Severity]int, len(alertSeverities))
+	for _, alert := range metrics.Alerts {
+		counts[alert.Severity]++
+	}
+	for _, severity := range alertSeverities {
+		ch <- prometheus.MustNewConstMetric(mc.alertsActive, prometheus.GaugeValue, float64(counts[severity]), string(severity))
+	}
+}
@@ -0,0 +1,149 @@
+package monitoring
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findHistogram(t *testing.T, hm *HTTPMetrics, route string) *dto.Histogram {
+	families, err := hm.Registry().Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "route" && label.GetValue() == route {
+					return metric.GetHistogram()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestHTTPMetrics_ObserveRequest(t *testing.T) {
+	t.Run("should bucket observations per route", func(t *testing.T) {
+		hm := NewHTTPMetricsWithBuckets([]float64{0.01, 0.1, 1})
+
+		hm.ObserveRequest("GET", "/api/clients/:id", 200, 5*time.Millisecond)
+		hm.ObserveRequest("GET", "/api/clients/:id", 200, 50*time.Millisecond)
+		hm.ObserveRequest("GET", "/api/server/status", 200, 500*time.Millisecond)
+
+		clients := findHistogram(t, hm, "/api/clients/:id")
+		require.NotNil(t, clients)
+		assert.Equal(t, uint64(2), clients.GetSampleCount())
+		require.Len(t, clients.GetBucket(), 3)
+		assert.Equal(t, uint64(1), clients.GetBucket()[0].GetCumulativeCount()) // <= 10ms
+		assert.Equal(t, uint64(2), clients.GetBucket()[1].GetCumulativeCount()) // <= 100ms
+
+		status := findHistogram(t, hm, "/api/server/status")
+		require.NotNil(t, status)
+		assert.Equal(t, uint64(1), status.GetSampleCount())
+		assert.Equal(t, uint64(0), status.GetBucket()[1].GetCumulativeCount())
+		assert.Equal(t, uint64(1), status.GetBucket()[2].GetCumulativeCount())
+	})
+
+	t.Run("should collapse unmatched routes into one label", func(t *testing.T) {
+		hm := NewHTTPMetrics()
+
+		hm.ObserveRequest("GET", "", 404, time.Millisecond)
+		hm.ObserveRequest("GET", "", 404, time.Millisecond)
+
+		unmatched := findHistogram(t, hm, UnmatchedRoute)
+		require.NotNil(t, unmatched)
+		assert.Equal(t, uint64(2), unmatched.GetSampleCount())
+	})
+
+	t.Run("should expose metrics in Prometheus text format", func(t *testing.T) {
+		hm := NewHTTPMetrics()
+		hm.ObserveRequest("POST", "/api/clients", 201, time.Millisecond)
+
+		resp := httptest.NewRecorder()
+		hm.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `http_request_duration_seconds_count{method="POST",route="/api/clients",status="201"} 1`)
+	})
+}
+
+// staticMetricsSource returns fixed server metrics.
+type staticMetricsSource struct {
+	metrics ServerMetrics
+}
+
+func (s *staticMetricsSource) GetMetrics() *ServerMetrics {
+	metrics := s.metrics
+	return &metrics
+}
+
+func TestMetricsCollector(t *testing.T) {
+	source := &staticMetricsSource{metrics: ServerMetrics{
+		ServerStatus:    StatusHealthy,
+		ConnectionStats: ConnectionStats{TotalClients: 5, ActiveClients: 3},
+		NetworkStats: NetworkStats{
+			BytesReceived:     1000,
+			BytesSent:         2000,
+			BytesTransferred:  3000,
+			IPPoolUtilization: 12.5,
+		},
+		SystemStats:    SystemStats{GoRoutines: 42},
+		WireGuardStats: WireGuardStats{ActivePeers: 2},
+		Alerts: []Alert{
+			{ID: "a", Severity: SeverityHigh},
+			{ID: "b", Severity: SeverityHigh},
+			{ID: "c", Severity: SeverityLow},
+		},
+	}}
+
+	scrape := func(t *testing.T) string {
+		hm := NewHTTPMetrics()
+		hm.Registry().MustRegister(NewMetricsCollector(source))
+
+		resp := httptest.NewRecorder()
+		hm.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("should export server metrics", func(t *testing.T) {
+		body := scrape(t)
+
+		assert.Contains(t, body, "vpn_server_up 1")
+		assert.Contains(t, body, "vpn_clients_total 5")
+		assert.Contains(t, body, "vpn_clients_active 3")
+		assert.Contains(t, body, "vpn_wireguard_peers_active 2")
+		assert.Contains(t, body, "vpn_ip_pool_utilization_percent 12.5")
+		assert.Contains(t, body, "vpn_bytes_received_total 1000")
+		assert.Contains(t, body, "vpn_bytes_sent_total 2000")
+		assert.Contains(t, body, "vpn_bytes_transferred_total 3000")
+		assert.Contains(t, body, "vpn_goroutines 42")
+		assert.Contains(t, body, "# TYPE vpn_bytes_transferred_total counter")
+	})
+
+	t.Run("should count active alerts per severity", func(t *testing.T) {
+		body := scrape(t)
+
+		assert.Contains(t, body, `vpn_alerts_active{severity="high"} 2`)
+		assert.Contains(t, body, `vpn_alerts_active{severity="low"} 1`)
+		assert.Contains(t, body, `vpn_alerts_active{severity="critical"} 0`)
+	})
+
+	t.Run("should report server down", func(t *testing.T) {
+		source.metrics.ServerStatus = StatusDown
+		defer func() { source.metrics.ServerStatus = StatusHealthy }()
+
+		assert.Contains(t, scrape(t), "vpn_server_up 0")
+	})
+}
@@ -0,0 +1,63 @@
+package monitoring
+
+import (
+	"fmt"
+
+	"my-vpn/internal/database"
+)
+
+// DataQuotaAlertID identifies the alert raised when a client used up its data
+// quota. The alert is resolved when the quota is reset.
+func DataQuotaAlertID(clientID uint) string {
+	return fmt.Sprintf("connection_data_quota_%d", clientID)
+}
+
+// enforceDataQuotas disables every enabled client whose usage since the last
+// quota reset reached its data quota, removes its peer and raises a connection
+// alert. Unlike data caps, quotas never reset on their own. A running interface
+// is synced once all peers are removed, so the clients are cut off immediately.
+// Returns an error if a client cannot be disabled or the interface cannot be synced.
+func (m *Monitor) enforceDataQuotas() error {
+	enabled := true
+	clients, err := m.db.ListClientsByFilter(database.ClientFilter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("failed to get clients: %w", err)
+	}
+
+	removed := false
+	for i := range clients {
+		client := &clients[i]
+		if client.DataQuotaBytes == 0 || client.DataQuotaUsage() < client.DataQuotaBytes {
+			continue
+		}
+
+		client.Enabled = false
+		client.DataQuotaExceeded = true
+		if err := m.db.UpdateClient(client); err != nil {
+			return fmt.Errorf("failed to disable client %s: %w", client.Name, err)
+		}
+
+		// Removing the peer may fail if the interface is not configured; the
+		// client stays disabled in the database either way
+		m.wgServer.RemovePeer(client.PublicKey)
+		removed = true
+
+		m.alertManager.RaiseAlert(DataQuotaAlertID(client.ID), AlertTypeConnection, SeverityHigh,
+			"Client Data Quota Exceeded",
+			fmt.Sprintf("Client %s used %d bytes, exhausting its data quota of %d bytes, and was disconnected",
+				client.Name, client.DataQuotaUsage(), client.DataQuotaBytes),
+			map[string]interface{}{
+				"client_id": client.ID,
+				"usage":     client.DataQuotaUsage(),
+				"quota":     client.DataQuotaBytes,
+			})
+	}
+
+	if removed {
+		if err := m.wgServer.ApplyConfig(); err != nil {
+			return fmt.Errorf("failed to sync WireGuard interface: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/system"
+	"my-vpn/internal/wireguard"
+)
+
+const quotaTestConfig = `[Interface]
+PrivateKey = test-private-key
+Address = 10.0.0.1/24
+ListenPort = 51820
+
+[Peer]
+PublicKey = quota-client-key
+AllowedIPs = 10.0.0.2/32
+`
+
+func TestMonitor_EnforceDataQuotas(t *testing.T) {
+	// setup returns a monitor whose WireGuard server uses a temporary config
+	// and a mock runner reporting the interface as running, plus a client
+	// with a quota of 1000 bytes
+	setup := func(t *testing.T) (*Monitor, *system.MockRunner, *database.Client, func()) {
+		monitor, cleanup := setupTestMonitor(t)
+
+		tempDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(quotaTestConfig), 0600))
+		runner := system.NewMockRunner()
+		monitor.wgServer = wireguard.NewWireGuardServerWithRunner(tempDir, "wg0", runner)
+
+		client := &database.Client{
+			Name:           "metered",
+			PublicKey:      "quota-client-key",
+			PrivateKey:     "quota-private-key",
+			IPAddress:      "10.0.0.2",
+			Enabled:        true,
+			DataQuotaBytes: 1000,
+		}
+		require.NoError(t, monitor.db.CreateClient(client))
+
+		return monitor, runner, client, cleanup
+	}
+
+	setUsage := func(t *testing.T, monitor *Monitor, id uint, received, sent uint64) {
+		client, err := monitor.db.GetClient(id)
+		require.NoError(t, err)
+		client.BytesReceived = received
+		client.BytesSent = sent
+		require.NoError(t, monitor.db.UpdateClient(client))
+	}
+
+	t.Run("should keep client connected below its quota", func(t *testing.T) {
+		monitor, runner, client, cleanup := setup(t)
+		defer cleanup()
+
+		setUsage(t, monitor, client.ID, 400, 500)
+		require.NoError(t, monitor.enforceDataQuotas())
+
+		stored, err := monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Enabled)
+		assert.False(t, stored.DataQuotaExceeded)
+		assert.Empty(t, monitor.alertManager.GetActiveAlerts())
+		assert.Empty(t, runner.Commands())
+	})
+
+	t.Run("should disable client and remove its peer once the quota is used up", func(t *testing.T) {
+		monitor, runner, client, cleanup := setup(t)
+		defer cleanup()
+
+		setUsage(t, monitor, client.ID, 600, 500)
+		require.NoError(t, monitor.enforceDataQuotas())
+
+		stored, err := monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.False(t, stored.Enabled)
+		assert.True(t, stored.DataQuotaExceeded)
+
+		hasPeer, err := monitor.wgServer.HasPeer("quota-client-key")
+		require.NoError(t, err)
+		assert.False(t, hasPeer)
+		assert.Contains(t, runner.Commands(), "wg-quick strip "+monitor.wgServer.GetConfigPath())
+
+		alerts := monitor.alertManager.GetActiveAlerts()
+		require.Len(t, alerts, 1)
+		assert.Equal(t, DataQuotaAlertID(client.ID), alerts[0].ID)
+		assert.Equal(t, AlertTypeConnection, alerts[0].Type)
+		assert.Equal(t, uint64(1100), alerts[0].Metadata["usage"])
+	})
+
+	t.Run("should only count usage since the last reset", func(t *testing.T) {
+		monitor, _, client, cleanup := setup(t)
+		defer cleanup()
+
+		stored, err := monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		stored.BytesReceived = 5000
+		stored.DataQuotaBaselineBytes = 4500
+		require.NoError(t, monitor.db.UpdateClient(stored))
+
+		require.NoError(t, monitor.enforceDataQuotas())
+
+		stored, err = monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.Enabled)
+	})
+}
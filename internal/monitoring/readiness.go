@@ -0,0 +1,101 @@
+package monitoring
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Component names reported by the readiness check.
+const (
+	ReadinessDatabase        = "database"
+	ReadinessMonitor         = "monitor"
+	ReadinessWireGuardConfig = "wireguard_config"
+)
+
+// ReadinessReport tells whether the server is ready to serve traffic,
+// e.g. for a load balancer or orchestrator readiness probe.
+type ReadinessReport struct {
+	Ready      bool                 `json:"ready"`      // Whether every component is ready
+	Timestamp  time.Time            `json:"timestamp"`  // When the report was generated
+	Components []ComponentReadiness `json:"components"` // Per-component readiness
+}
+
+// ComponentReadiness represents the readiness of a single component.
+type ComponentReadiness struct {
+	Name    string `json:"name"`    // Component name (e.g., "database")
+	Ready   bool   `json:"ready"`   // Whether the component is ready
+	Message string `json:"message"` // Human readable explanation
+}
+
+// readinessInputs holds the raw observations a readiness report is evaluated from.
+type readinessInputs struct {
+	dbErr          error
+	monitorRunning bool
+	wgConfigPath   string
+	wgConfigErr    error
+}
+
+// GetReadiness checks the dependencies needed to serve traffic: the database
+// is reachable, the monitor is running and the WireGuard configuration has
+// been written. Unlike GetDetailedHealth, which reports the latest metrics
+// collection, it checks the dependencies directly; it runs no external
+// commands, so it is cheap enough to be probed frequently.
+func (m *Monitor) GetReadiness() *ReadinessReport {
+	m.mutex.RLock()
+	inputs := readinessInputs{monitorRunning: m.running}
+	m.mutex.RUnlock()
+
+	inputs.dbErr = m.db.Ping()
+	inputs.wgConfigPath = m.wgServer.GetConfigPath()
+	_, inputs.wgConfigErr = os.Stat(inputs.wgConfigPath)
+
+	return evaluateReadiness(inputs, time.Now())
+}
+
+// evaluateReadiness turns raw component observations into a readiness report.
+func evaluateReadiness(inputs readinessInputs, now time.Time) *ReadinessReport {
+	components := []ComponentReadiness{
+		evaluateDatabaseReadiness(inputs),
+		evaluateMonitorReadiness(inputs),
+		evaluateWireGuardConfigReadiness(inputs),
+	}
+
+	ready := true
+	for _, component := range components {
+		ready = ready && component.Ready
+	}
+
+	return &ReadinessReport{
+		Ready:      ready,
+		Timestamp:  now,
+		Components: components,
+	}
+}
+
+func evaluateDatabaseReadiness(inputs readinessInputs) ComponentReadiness {
+	if inputs.dbErr != nil {
+		return ComponentReadiness{Name: ReadinessDatabase, Message: inputs.dbErr.Error()}
+	}
+	return ComponentReadiness{Name: ReadinessDatabase, Ready: true, Message: "database is reachable"}
+}
+
+func evaluateMonitorReadiness(inputs readinessInputs) ComponentReadiness {
+	if !inputs.monitorRunning {
+		return ComponentReadiness{Name: ReadinessMonitor, Message: "monitor is not running"}
+	}
+	return ComponentReadiness{Name: ReadinessMonitor, Ready: true, Message: "monitor is running"}
+}
+
+func evaluateWireGuardConfigReadiness(inputs readinessInputs) ComponentReadiness {
+	if os.IsNotExist(inputs.wgConfigErr) {
+		return ComponentReadiness{
+			Name:    ReadinessWireGuardConfig,
+			Message: fmt.Sprintf("WireGuard configuration %s has not been written", inputs.wgConfigPath),
+		}
+	}
+	if inputs.wgConfigErr != nil {
+		return ComponentReadiness{Name: ReadinessWireGuardConfig, Message: inputs.wgConfigErr.Error()}
+	}
+	return ComponentReadiness{Name: ReadinessWireGuardConfig, Ready: true, Message: "WireGuard configuration is present"}
+}
@@ -0,0 +1,98 @@
+package monitoring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"my-vpn/internal/wireguard"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findComponent(report *ReadinessReport, name string) *ComponentReadiness {
+	for i := range report.Components {
+		if report.Components[i].Name == name {
+			return &report.Components[i]
+		}
+	}
+	return nil
+}
+
+func TestEvaluateReadiness(t *testing.T) {
+	now := time.Now()
+	readyInputs := readinessInputs{monitorRunning: true, wgConfigPath: "/etc/wireguard/wg0.conf"}
+
+	t.Run("should be ready when all components are ready", func(t *testing.T) {
+		report := evaluateReadiness(readyInputs, now)
+
+		assert.True(t, report.Ready)
+		assert.Len(t, report.Components, 3)
+		for _, component := range report.Components {
+			assert.True(t, component.Ready, component.Name)
+		}
+	})
+
+	t.Run("should not be ready when the database is unreachable", func(t *testing.T) {
+		inputs := readyInputs
+		inputs.dbErr = errors.New("database is closed")
+
+		report := evaluateReadiness(inputs, now)
+
+		assert.False(t, report.Ready)
+		database := findComponent(report, ReadinessDatabase)
+		require.NotNil(t, database)
+		assert.False(t, database.Ready)
+		assert.Equal(t, "database is closed", database.Message)
+		assert.True(t, findComponent(report, ReadinessMonitor).Ready)
+	})
+
+	t.Run("should not be ready when the monitor is stopped", func(t *testing.T) {
+		inputs := readyInputs
+		inputs.monitorRunning = false
+
+		report := evaluateReadiness(inputs, now)
+
+		assert.False(t, report.Ready)
+		assert.False(t, findComponent(report, ReadinessMonitor).Ready)
+	})
+
+	t.Run("should not be ready without WireGuard configuration", func(t *testing.T) {
+		inputs := readyInputs
+		inputs.wgConfigErr = os.ErrNotExist
+
+		report := evaluateReadiness(inputs, now)
+
+		assert.False(t, report.Ready)
+		wgConfig := findComponent(report, ReadinessWireGuardConfig)
+		assert.False(t, wgConfig.Ready)
+		assert.Contains(t, wgConfig.Message, "/etc/wireguard/wg0.conf")
+	})
+}
+
+func TestMonitor_GetReadiness(t *testing.T) {
+	t.Run("should check the database, monitor and WireGuard configuration", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		configDir := t.TempDir()
+		monitor.wgServer = wireguard.NewWireGuardServerWithConfig(configDir, "wg0")
+
+		report := monitor.GetReadiness()
+		assert.False(t, report.Ready)
+		assert.True(t, findComponent(report, ReadinessDatabase).Ready)
+		assert.False(t, findComponent(report, ReadinessMonitor).Ready)
+		assert.False(t, findComponent(report, ReadinessWireGuardConfig).Ready)
+
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, "wg0.conf"), []byte("[Interface]\n"), 0600))
+		monitor.mutex.Lock()
+		monitor.running = true
+		monitor.mutex.Unlock()
+
+		report = monitor.GetReadiness()
+		assert.True(t, report.Ready)
+	})
+}
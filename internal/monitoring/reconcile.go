@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"fmt"
+
+	"my-vpn/internal/database"
+)
+
+// peerCountAlertID identifies the alert raised when the enabled clients in the
+// database and the peers on the live interface diverge.
+const peerCountAlertID = "application_peer_count_mismatch"
+
+// reconcilePeerCount compares the number of enabled clients in the database
+// with the number of peers on the live WireGuard interface. If they differ by
+// more than PeerCountTolerance an application alert is raised prompting a sync
+// of the interface; once the counts agree again the alert is resolved.
+// Returns an error if either count cannot be determined, e.g. while the
+// interface is down; the alert state is left unchanged in that case.
+func (m *Monitor) reconcilePeerCount() error {
+	peers, err := m.peerSource.GetPeerStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get peer status: %w", err)
+	}
+
+	enabled := true
+	clients, err := m.db.CountClients(database.ClientFilter{Enabled: &enabled})
+	if err != nil {
+		return fmt.Errorf("failed to count enabled clients: %w", err)
+	}
+
+	difference := len(peers) - int(clients)
+	if difference < 0 {
+		difference = -difference
+	}
+
+	if difference <= m.config.PeerCountTolerance {
+		m.alertManager.ResolveAlert(peerCountAlertID)
+		return nil
+	}
+
+	m.alertManager.RaiseAlert(peerCountAlertID, AlertTypeApplication, SeverityMedium,
+		"Peer Count Mismatch",
+		fmt.Sprintf("The WireGuard interface has %d peers but %d clients are enabled; sync the interface configuration", len(peers), clients),
+		map[string]interface{}{
+			"live_peers":      len(peers),
+			"enabled_clients": clients,
+			"tolerance":       m.config.PeerCountTolerance,
+		})
+
+	return nil
+}
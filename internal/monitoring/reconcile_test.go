@@ -0,0 +1,131 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/wireguard"
+)
+
+func TestMonitor_ReconcilePeerCount(t *testing.T) {
+	// seedClients stores enabled clients and returns matching live peers
+	seedClients := func(t *testing.T, monitor *Monitor, count int) []wireguard.PeerStatus {
+		peers := make([]wireguard.PeerStatus, count)
+		for i := 0; i < count; i++ {
+			client := &database.Client{
+				Name:       fmt.Sprintf("client-%d", i),
+				PublicKey:  fmt.Sprintf("public-key-%d", i),
+				PrivateKey: fmt.Sprintf("private-key-%d", i),
+				IPAddress:  fmt.Sprintf("10.0.0.%d", i+2),
+				Enabled:    true,
+			}
+			require.NoError(t, monitor.db.CreateClient(client))
+			peers[i] = wireguard.PeerStatus{PublicKey: client.PublicKey}
+		}
+		return peers
+	}
+
+	findAlert := func(monitor *Monitor) *Alert {
+		for _, alert := range monitor.alertManager.GetActiveAlerts() {
+			if alert.ID == peerCountAlertID {
+				return &alert
+			}
+		}
+		return nil
+	}
+
+	t.Run("should raise alert when live peers are missing", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		peers := seedClients(t, monitor, 3)
+		monitor.peerSource = &fakePeerSource{peers: peers[:1]}
+
+		require.NoError(t, monitor.reconcilePeerCount())
+
+		alert := findAlert(monitor)
+		require.NotNil(t, alert)
+		assert.Equal(t, AlertTypeApplication, alert.Type)
+		assert.Equal(t, 1, alert.Metadata["live_peers"])
+		assert.Equal(t, int64(3), alert.Metadata["enabled_clients"])
+	})
+
+	t.Run("should raise alert when the interface has extra peers", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		peers := seedClients(t, monitor, 1)
+		peers = append(peers, wireguard.PeerStatus{PublicKey: "manually-added-key"})
+		monitor.peerSource = &fakePeerSource{peers: peers}
+
+		require.NoError(t, monitor.reconcilePeerCount())
+		assert.NotNil(t, findAlert(monitor))
+	})
+
+	t.Run("should not alert when counts match", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		peers := seedClients(t, monitor, 3)
+		monitor.peerSource = &fakePeerSource{peers: peers}
+
+		require.NoError(t, monitor.reconcilePeerCount())
+		assert.Nil(t, findAlert(monitor))
+	})
+
+	t.Run("should ignore disabled clients", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		peers := seedClients(t, monitor, 2)
+		require.NoError(t, monitor.db.Model(&database.Client{}).Where("public_key = ?", peers[1].PublicKey).Update("enabled", false).Error)
+		monitor.peerSource = &fakePeerSource{peers: peers[:1]}
+
+		require.NoError(t, monitor.reconcilePeerCount())
+		assert.Nil(t, findAlert(monitor))
+	})
+
+	t.Run("should not alert within tolerance", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.config.PeerCountTolerance = 1
+		peers := seedClients(t, monitor, 3)
+		monitor.peerSource = &fakePeerSource{peers: peers[:2]}
+
+		require.NoError(t, monitor.reconcilePeerCount())
+		assert.Nil(t, findAlert(monitor))
+	})
+
+	t.Run("should resolve alert once counts match again", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		peers := seedClients(t, monitor, 2)
+		source := &fakePeerSource{peers: peers[:1]}
+		monitor.peerSource = source
+
+		require.NoError(t, monitor.reconcilePeerCount())
+		require.NotNil(t, findAlert(monitor))
+
+		source.peers = peers
+		require.NoError(t, monitor.reconcilePeerCount())
+		assert.Nil(t, findAlert(monitor))
+	})
+
+	t.Run("should return error when peer status is unavailable", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		seedClients(t, monitor, 1)
+		monitor.peerSource = &fakePeerSource{err: errors.New("interface wg0 not found")}
+
+		assert.Error(t, monitor.reconcilePeerCount())
+		assert.Nil(t, findAlert(monitor))
+	})
+}
@@ -0,0 +1,216 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecurityEventType identifies the kind of a security event in the feed.
+type SecurityEventType string
+
+// securityEventKey is the log metadata key marking a log entry as a security event.
+const securityEventKey = "security_event"
+
+// Security event types recorded directly rather than derived from alerts.
+const (
+	SecurityEventFailedLogin SecurityEventType = "failed_login" // A login attempt with invalid credentials
+)
+
+// Sources a security event can originate from.
+const (
+	SecuritySourceAlert = "alert" // Derived from a security alert
+	SecuritySourceLog   = "log"   // Derived from a security log entry
+)
+
+// failedLoginWindow is how far back failed logins and blocked packets are counted in SecurityStats.
+const failedLoginWindow = time.Hour
+
+// Threat levels reported in SecurityStats.ThreatLevel, from least to most severe.
+const (
+	ThreatLevelLow    = "low"
+	ThreatLevelMedium = "medium"
+	ThreatLevelHigh   = "high"
+)
+
+// Failed logins and packets dropped by the firewall within failedLoginWindow
+// from which the threat level is raised to medium and high.
+const (
+	failedLoginsMediumThreat       = 5
+	failedLoginsHighThreat         = 20
+	blockedConnectionsMediumThreat = 100
+	blockedConnectionsHighThreat   = 1000
+)
+
+// SecurityEvent represents a single entry in the unified security feed.
+type SecurityEvent struct {
+	Timestamp time.Time              `json:"timestamp"`          // When the event occurred
+	Type      SecurityEventType      `json:"type"`               // Kind of event (e.g., "failed_login", "firewall_disabled")
+	Severity  Severity               `json:"severity"`           // Severity of the event
+	Source    string                 `json:"source"`             // Where the event came from: "alert" or "log"
+	Message   string                 `json:"message"`            // Human readable description
+	Metadata  map[string]interface{} `json:"metadata,omitempty"` // Additional event details
+}
+
+// RecordFailedLogin records a failed login attempt as a security event.
+// The attempt is written to the security log, shows up in the security feed
+// and counts towards SecurityStats.FailedLogins.
+func (m *Monitor) RecordFailedLogin(username, remoteIP string) {
+	m.logManager.LogWithMetadata(LogComponentAuth, LogLevelWarn,
+		fmt.Sprintf("Failed login attempt for user %q from %s", username, remoteIP),
+		map[string]interface{}{
+			securityEventKey: string(SecurityEventFailedLogin),
+			"username":       username,
+			"remote_ip":      remoteIP,
+		})
+}
+
+// GetSecurityFeed returns security events from alerts and logs, newest first.
+// Security alerts (firewall disabled, key mismatch, ...) are combined with
+// security log entries such as failed logins into a single timeline.
+func (m *Monitor) GetSecurityFeed() []SecurityEvent {
+	events := make([]SecurityEvent, 0)
+
+	for _, alert := range m.alertManager.GetAllAlerts(time.Time{}) {
+		if alert.Type != AlertTypeSecurity {
+			continue
+		}
+		events = append(events, SecurityEvent{
+			Timestamp: alert.CreatedAt,
+			Type:      SecurityEventType(strings.TrimPrefix(alert.ID, "security_")),
+			Severity:  alert.Severity,
+			Source:    SecuritySourceAlert,
+			Message:   alert.Description,
+			Metadata:  alert.Metadata,
+		})
+	}
+
+	for _, entry := range m.logManager.GetLogsSince(time.Time{}) {
+		eventType, ok := entry.Metadata[securityEventKey].(string)
+		if !ok {
+			continue
+		}
+		events = append(events, SecurityEvent{
+			Timestamp: entry.Timestamp,
+			Type:      SecurityEventType(eventType),
+			Severity:  logLevelSeverity(entry.Level),
+			Source:    SecuritySourceLog,
+			Message:   entry.Message,
+			Metadata:  entry.Metadata,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.After(events[j].Timestamp)
+	})
+
+	return events
+}
+
+// countFailedLogins returns the number of failed logins recorded since the given time.
+func (m *Monitor) countFailedLogins(since time.Time) int {
+	count := 0
+	for _, entry := range m.logManager.GetLogsSince(since) {
+		if entry.Metadata[securityEventKey] == string(SecurityEventFailedLogin) {
+			count++
+		}
+	}
+	return count
+}
+
+// blockedPacketWindow derives the packets dropped within a window from samples
+// of the firewall's cumulative drop counter.
+type blockedPacketWindow struct {
+	samples []blockedPacketSample // Counter readings, oldest first
+	mutex   sync.Mutex            // Mutex for thread-safe sampling
+}
+
+// blockedPacketSample is a single reading of the cumulative drop counter.
+type blockedPacketSample struct {
+	at    time.Time
+	count int
+}
+
+// observe records a counter reading and returns the packets dropped since the
+// last reading at or before the start of the window. The first reading has no
+// baseline and counts as 0; a counter that went backwards (e.g. rules reloaded)
+// restarts the history from zero.
+func (w *blockedPacketWindow) observe(now time.Time, count int, window time.Duration) int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if n := len(w.samples); n > 0 && count < w.samples[n-1].count {
+		w.samples = []blockedPacketSample{{at: now, count: 0}}
+	}
+	w.samples = append(w.samples, blockedPacketSample{at: now, count: count})
+
+	// Keep the newest sample outside the window as the baseline
+	cutoff := now.Add(-window)
+	for len(w.samples) > 1 && !w.samples[1].at.After(cutoff) {
+		w.samples = w.samples[1:]
+	}
+
+	return count - w.samples[0].count
+}
+
+// assessThreatLevel derives the threat level from the security stats. Failed
+// logins and blocked connections each map to a level and the higher one wins;
+// a disabled firewall raises the result by one level.
+func assessThreatLevel(stats SecurityStats) string {
+	level := 0
+	switch {
+	case stats.FailedLogins >= failedLoginsHighThreat:
+		level = 2
+	case stats.FailedLogins >= failedLoginsMediumThreat:
+		level = 1
+	}
+	switch {
+	case stats.BlockedConnections >= blockedConnectionsHighThreat:
+		level = 2
+	case stats.BlockedConnections >= blockedConnectionsMediumThreat && level < 1:
+		level = 1
+	}
+	if !stats.FirewallEnabled {
+		level++
+	}
+
+	switch {
+	case level >= 2:
+		return ThreatLevelHigh
+	case level == 1:
+		return ThreatLevelMedium
+	default:
+		return ThreatLevelLow
+	}
+}
+
+// threatLevelRank orders threat levels from least to most severe.
+// Unknown and empty levels rank below ThreatLevelLow.
+func threatLevelRank(level string) int {
+	switch level {
+	case ThreatLevelLow:
+		return 1
+	case ThreatLevelMedium:
+		return 2
+	case ThreatLevelHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// logLevelSeverity maps the level of a security log entry to an alert severity.
+func logLevelSeverity(level LogLevel) Severity {
+	switch {
+	case level >= LogLevelFatal:
+		return SeverityCritical
+	case level >= LogLevelError:
+		return SeverityHigh
+	case level >= LogLevelWarn:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
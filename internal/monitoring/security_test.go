@@ -0,0 +1,154 @@
+package monitoring
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/system"
+)
+
+func TestMonitor_SecurityFeed(t *testing.T) {
+	t.Run("should return empty feed without security events", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.alertManager.RaiseAlert("network_high_latency", AlertTypeNetwork, SeverityMedium, "High Latency", "latency", nil)
+		monitor.logManager.LogInfo("unrelated message")
+
+		assert.Empty(t, monitor.GetSecurityFeed())
+	})
+
+	t.Run("should merge alerts and failed logins newest first", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.RecordFailedLogin("mallory", "198.51.100.7")
+		time.Sleep(2 * time.Millisecond)
+		monitor.alertManager.RaiseAlert("security_server_key_mismatch", AlertTypeSecurity, SeverityCritical, "Server Key Mismatch", "mismatch", nil)
+
+		events := monitor.GetSecurityFeed()
+		require.Len(t, events, 2)
+		assert.Equal(t, SecurityEventType("server_key_mismatch"), events[0].Type)
+		assert.Equal(t, SecuritySourceAlert, events[0].Source)
+		assert.Equal(t, SecurityEventFailedLogin, events[1].Type)
+		assert.Equal(t, SecuritySourceLog, events[1].Source)
+		assert.Equal(t, "198.51.100.7", events[1].Metadata["remote_ip"])
+	})
+
+	t.Run("should count recent failed logins in security stats", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.RecordFailedLogin("mallory", "198.51.100.7")
+		monitor.RecordFailedLogin("mallory", "198.51.100.7")
+
+		assert.Equal(t, 2, monitor.countFailedLogins(time.Now().Add(-failedLoginWindow)))
+		assert.Equal(t, 0, monitor.countFailedLogins(time.Now().Add(time.Second)))
+	})
+}
+
+func TestAssessThreatLevel(t *testing.T) {
+	t.Run("should raise the level with failed logins", func(t *testing.T) {
+		assert.Equal(t, ThreatLevelLow, assessThreatLevel(SecurityStats{FirewallEnabled: true, FailedLogins: 4}))
+		assert.Equal(t, ThreatLevelMedium, assessThreatLevel(SecurityStats{FirewallEnabled: true, FailedLogins: 5}))
+		assert.Equal(t, ThreatLevelHigh, assessThreatLevel(SecurityStats{FirewallEnabled: true, FailedLogins: 20}))
+	})
+
+	t.Run("should raise the level with blocked connections", func(t *testing.T) {
+		assert.Equal(t, ThreatLevelLow, assessThreatLevel(SecurityStats{FirewallEnabled: true, BlockedConnections: 99}))
+		assert.Equal(t, ThreatLevelMedium, assessThreatLevel(SecurityStats{FirewallEnabled: true, BlockedConnections: 100}))
+		assert.Equal(t, ThreatLevelHigh, assessThreatLevel(SecurityStats{FirewallEnabled: true, BlockedConnections: 1000}))
+	})
+
+	t.Run("should raise the level by one while the firewall is disabled", func(t *testing.T) {
+		assert.Equal(t, ThreatLevelMedium, assessThreatLevel(SecurityStats{}))
+		assert.Equal(t, ThreatLevelHigh, assessThreatLevel(SecurityStats{FailedLogins: 5}))
+		assert.Equal(t, ThreatLevelHigh, assessThreatLevel(SecurityStats{FailedLogins: 20}))
+	})
+}
+
+func TestMonitor_CollectSecurityStats(t *testing.T) {
+	// newFirewall returns an enabled iptables firewall whose forward chain
+	// dropped the given number of packets
+	newFirewall := func(blocked int) system.FirewallManager {
+		runner := system.NewMockRunner()
+		runner.On("iptables -t filter -L MYVPN-FORWARD", fmt.Sprintf(
+			"Chain MYVPN-FORWARD (1 references)\n"+
+				"    pkts      bytes target     prot opt in     out     source               destination\n"+
+				"%8d        0 DROP       all  --  *      *       10.0.0.0/24          0.0.0.0/0\n", blocked), nil)
+		return system.NewIptablesManagerWithRunner("/tmp/vpn.rules", runner)
+	}
+
+	t.Run("should move from low to medium to high with failed logins", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+		monitor.firewall = newFirewall(0)
+
+		stats, err := monitor.collectSecurityStats()
+		require.NoError(t, err)
+		assert.True(t, stats.FirewallEnabled)
+		assert.Equal(t, 0, stats.FailedLogins)
+		assert.Equal(t, ThreatLevelLow, stats.ThreatLevel)
+
+		for i := 0; i < failedLoginsMediumThreat; i++ {
+			monitor.RecordFailedLogin("mallory", "198.51.100.7")
+		}
+		stats, err = monitor.collectSecurityStats()
+		require.NoError(t, err)
+		assert.Equal(t, failedLoginsMediumThreat, stats.FailedLogins)
+		assert.Equal(t, ThreatLevelMedium, stats.ThreatLevel)
+
+		for i := failedLoginsMediumThreat; i < failedLoginsHighThreat; i++ {
+			monitor.RecordFailedLogin("mallory", "198.51.100.7")
+		}
+		stats, err = monitor.collectSecurityStats()
+		require.NoError(t, err)
+		assert.Equal(t, failedLoginsHighThreat, stats.FailedLogins)
+		assert.Equal(t, ThreatLevelHigh, stats.ThreatLevel)
+	})
+
+	t.Run("should report packets dropped by the firewall since the first reading", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+		monitor.firewall = newFirewall(5000)
+
+		stats, err := monitor.collectSecurityStats()
+		require.NoError(t, err)
+		assert.Equal(t, 0, stats.BlockedConnections)
+		assert.Equal(t, ThreatLevelLow, stats.ThreatLevel)
+		assert.WithinDuration(t, time.Now(), stats.LastSecurityScan, time.Second)
+
+		monitor.firewall = newFirewall(5250)
+		stats, err = monitor.collectSecurityStats()
+		require.NoError(t, err)
+		assert.Equal(t, 250, stats.BlockedConnections)
+		assert.Equal(t, ThreatLevelMedium, stats.ThreatLevel)
+	})
+}
+
+func TestBlockedPacketWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("should count only packets dropped within the window", func(t *testing.T) {
+		var window blockedPacketWindow
+
+		assert.Equal(t, 0, window.observe(start, 10000, time.Hour))
+		assert.Equal(t, 500, window.observe(start.Add(30*time.Minute), 10500, time.Hour))
+		assert.Equal(t, 700, window.observe(start.Add(time.Hour), 10700, time.Hour))
+		// The first reading left the window, the one at 30 minutes is the baseline
+		assert.Equal(t, 300, window.observe(start.Add(90*time.Minute), 10800, time.Hour))
+		assert.Equal(t, 100, window.observe(start.Add(150*time.Minute), 10900, time.Hour))
+	})
+
+	t.Run("should restart when the counter goes backwards", func(t *testing.T) {
+		var window blockedPacketWindow
+
+		assert.Equal(t, 0, window.observe(start, 10000, time.Hour))
+		assert.Equal(t, 40, window.observe(start.Add(time.Minute), 40, time.Hour))
+		assert.Equal(t, 60, window.observe(start.Add(2*time.Minute), 60, time.Hour))
+	})
+}
@@ -0,0 +1,62 @@
+//go:build !windows && !plan9
+
+package monitoring
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// syslogWriter sends log lines to a syslog daemon over a single connection.
+type syslogWriter struct {
+	writer *syslog.Writer
+}
+
+// dialSyslog connects to the syslog daemon at address over network.
+// An empty network and address use the local daemon (e.g. /dev/log).
+func dialSyslog(network, address string) (syslogSink, error) {
+	writer, err := syslog.Dial(network, address, syslog.LOG_DAEMON|syslog.LOG_INFO, syslogTag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogWriter{writer: writer}, nil
+}
+
+// writerFor returns a writer that sends lines with the syslog priority of the level.
+func (s *syslogWriter) writerFor(level LogLevel) io.Writer {
+	return syslogLevelWriter{writer: s.writer, level: level}
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *syslogWriter) Close() error {
+	return s.writer.Close()
+}
+
+// syslogLevelWriter writes every line with the syslog priority of one log level.
+type syslogLevelWriter struct {
+	writer *syslog.Writer
+	level  LogLevel
+}
+
+// Write sends p as a single syslog message.
+func (w syslogLevelWriter) Write(p []byte) (int, error) {
+	message := string(p)
+
+	var err error
+	switch w.level {
+	case LogLevelTrace, LogLevelDebug:
+		err = w.writer.Debug(message)
+	case LogLevelInfo:
+		err = w.writer.Info(message)
+	case LogLevelWarn:
+		err = w.writer.Warning(message)
+	case LogLevelError:
+		err = w.writer.Err(message)
+	default:
+		err = w.writer.Crit(message)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,91 @@
+//go:build !windows && !plan9
+
+package monitoring
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSyslogListener starts a UDP listener standing in for a syslog daemon.
+func newSyslogListener(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readSyslogMessage returns the next message received by the listener.
+func readSyslogMessage(t *testing.T, conn net.PacketConn) string {
+	buffer := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	n, _, err := conn.ReadFrom(buffer)
+	require.NoError(t, err)
+	return string(buffer[:n])
+}
+
+func TestLogManager_Syslog(t *testing.T) {
+	newManager := func(address string) *LogManager {
+		return NewLogManagerWithConfig(LogConfig{
+			LogLevel:      LogLevelTrace,
+			BufferSize:    100,
+			LogToSyslog:   true,
+			SyslogNetwork: "udp",
+			SyslogAddress: address,
+		})
+	}
+
+	t.Run("should send messages with the priority of their level", func(t *testing.T) {
+		listener := newSyslogListener(t)
+		lm := newManager(listener.LocalAddr().String())
+		defer lm.Close()
+
+		lm.LogError("Failed to sync peers")
+		message := readSyslogMessage(t, listener)
+		assert.Contains(t, message, "<27>") // LOG_DAEMON | LOG_ERR
+		assert.Contains(t, message, syslogTag)
+		assert.Contains(t, message, "[ERROR]")
+		assert.Contains(t, message, "Failed to sync peers")
+
+		lm.LogWarn("Disk almost full")
+		assert.Contains(t, readSyslogMessage(t, listener), "<28>") // LOG_DAEMON | LOG_WARNING
+
+		lm.LogInfo("Server started")
+		assert.Contains(t, readSyslogMessage(t, listener), "<30>") // LOG_DAEMON | LOG_INFO
+	})
+
+	t.Run("should keep logging when syslog is unavailable", func(t *testing.T) {
+		lm := NewLogManagerWithConfig(LogConfig{
+			LogLevel:      LogLevelTrace,
+			BufferSize:    100,
+			LogToSyslog:   true,
+			SyslogNetwork: "bogus",
+		})
+		defer lm.Close()
+
+		assert.False(t, lm.GetConfig().LogToSyslog)
+		lm.LogInfo("Still logged")
+		recent := lm.GetRecentLogs(1)
+		require.Len(t, recent, 1)
+		assert.Equal(t, "Still logged", recent[0].Message)
+	})
+
+	t.Run("should stop sending after the syslog output is disabled", func(t *testing.T) {
+		listener := newSyslogListener(t)
+		lm := newManager(listener.LocalAddr().String())
+		defer lm.Close()
+
+		config := lm.GetConfig()
+		config.LogToSyslog = false
+		require.NoError(t, lm.UpdateConfig(config))
+
+		lm.LogError("Not sent")
+		require.NoError(t, listener.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+		_, _, err := listener.ReadFrom(make([]byte, 4096))
+		assert.Error(t, err)
+	})
+}
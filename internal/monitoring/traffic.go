@@ -0,0 +1,63 @@
+package monitoring
+
+import (
+	"fmt"
+)
+
+// syncClientTraffic copies the live WireGuard transfer counters into the client
+// records, matching peers to clients by public key.
+//
+// WireGuard counters restart at zero when the interface is restarted, so the
+// stored totals are advanced by the difference to the counters seen in the
+// previous sync. A counter that went down is treated as a reset and its whole
+// value counts as new traffic, keeping BytesReceived and BytesSent cumulative.
+func (m *Monitor) syncClientTraffic() error {
+	peers, err := m.peerSource.GetPeerStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get peer status: %w", err)
+	}
+	if len(peers) == 0 {
+		return nil
+	}
+
+	clients, err := m.db.ListClients()
+	if err != nil {
+		return fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	clientsByKey := make(map[string]int, len(clients))
+	for i := range clients {
+		clientsByKey[clients[i].PublicKey] = i
+	}
+
+	for _, peer := range peers {
+		i, ok := clientsByKey[peer.PublicKey]
+		if !ok {
+			continue
+		}
+		client := &clients[i]
+
+		client.BytesReceived += counterDelta(client.LastRxCounter, peer.BytesReceived)
+		client.BytesSent += counterDelta(client.LastTxCounter, peer.BytesSent)
+		client.LastRxCounter = peer.BytesReceived
+		client.LastTxCounter = peer.BytesSent
+		if peer.LatestHandshake != nil {
+			client.LastHandshake = peer.LatestHandshake
+		}
+
+		if err := m.db.UpdateClientTraffic(client); err != nil {
+			return fmt.Errorf("failed to update traffic of client %d: %w", client.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// counterDelta returns the traffic counted since the previous counter value.
+// A decrease means the interface restarted and the counter began again at zero.
+func counterDelta(previous, current uint64) uint64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
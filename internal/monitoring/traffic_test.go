@@ -0,0 +1,94 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/wireguard"
+)
+
+func TestMonitor_SyncClientTraffic(t *testing.T) {
+	t.Run("should keep totals cumulative across a counter reset", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		client := &database.Client{Name: "laptop", PublicKey: "laptop-key", IPAddress: "10.0.0.2", Enabled: true}
+		require.NoError(t, monitor.db.CreateClient(client))
+
+		source := &fakePeerSource{}
+		monitor.peerSource = source
+
+		// First cycle: counters since the interface came up
+		handshake := time.Now().Add(-time.Minute).Truncate(time.Second)
+		source.peers = []wireguard.PeerStatus{
+			{PublicKey: "laptop-key", BytesReceived: 1000, BytesSent: 4000, LatestHandshake: &handshake},
+		}
+		require.NoError(t, monitor.syncClientTraffic())
+
+		updated, err := monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1000), updated.BytesReceived)
+		assert.Equal(t, uint64(4000), updated.BytesSent)
+		require.NotNil(t, updated.LastHandshake)
+		assert.True(t, updated.LastHandshake.Equal(handshake))
+
+		// Interface restarted: counters dropped and started again from zero
+		source.peers = []wireguard.PeerStatus{
+			{PublicKey: "laptop-key", BytesReceived: 300, BytesSent: 500},
+		}
+		require.NoError(t, monitor.syncClientTraffic())
+
+		updated, err = monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1300), updated.BytesReceived)
+		assert.Equal(t, uint64(4500), updated.BytesSent)
+
+		// Regular growth after the reset only adds the difference
+		source.peers = []wireguard.PeerStatus{
+			{PublicKey: "laptop-key", BytesReceived: 800, BytesSent: 600},
+		}
+		require.NoError(t, monitor.syncClientTraffic())
+
+		updated, err = monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1800), updated.BytesReceived)
+		assert.Equal(t, uint64(4600), updated.BytesSent)
+
+		stats, err := monitor.collectNetworkStats()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1800), stats.BytesReceived)
+		assert.Equal(t, uint64(4600), stats.BytesSent)
+		assert.Equal(t, uint64(6400), stats.BytesTransferred)
+	})
+
+	t.Run("should only update clients matching a peer", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		client := &database.Client{Name: "phone", PublicKey: "phone-key", IPAddress: "10.0.0.3", Enabled: true}
+		require.NoError(t, monitor.db.CreateClient(client))
+
+		monitor.peerSource = &fakePeerSource{peers: []wireguard.PeerStatus{
+			{PublicKey: "unknown-key", BytesReceived: 500, BytesSent: 500},
+		}}
+		require.NoError(t, monitor.syncClientTraffic())
+
+		updated, err := monitor.db.GetClient(client.ID)
+		require.NoError(t, err)
+		assert.Zero(t, updated.BytesReceived)
+		assert.Zero(t, updated.BytesSent)
+	})
+
+	t.Run("should return error when peer status is unavailable", func(t *testing.T) {
+		monitor, cleanup := setupTestMonitor(t)
+		defer cleanup()
+
+		monitor.peerSource = &fakePeerSource{err: errors.New("wg not found")}
+		assert.Error(t, monitor.syncClientTraffic())
+	})
+}
@@ -0,0 +1,119 @@
+package monitoring
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Payload formats for the "webhook" notification channel.
+const (
+	WebhookFormatGeneric = "generic" // AlertNotification as JSON
+	WebhookFormatSlack   = "slack"   // Slack incoming webhook message with an attachment
+	WebhookFormatDiscord = "discord" // Discord webhook message with an embed
+)
+
+// slackMessage is the payload of a Slack incoming webhook.
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// slackAttachment renders the alert details next to a severity colored bar.
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text"`
+	Fields []slackField `json:"fields"`
+	Footer string       `json:"footer"`
+	TS     int64        `json:"ts"` // Unix time of the transition
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// discordMessage is the payload of a Discord webhook.
+type discordMessage struct {
+	Content string         `json:"content"`
+	Embeds  []discordEmbed `json:"embeds"`
+}
+
+// discordEmbed renders the alert details with a severity colored border.
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+	Timestamp   string         `json:"timestamp"` // ISO 8601 time of the transition
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// severityColor returns the RGB color alerts of the severity are shown in.
+// Resolved alerts are always green.
+func severityColor(severity Severity, status AlertStatus) int {
+	if status == AlertStatusResolved {
+		return 0x2EB67D
+	}
+	switch severity {
+	case SeverityLow:
+		return 0x439FE0
+	case SeverityMedium:
+		return 0xECB22E
+	case SeverityHigh:
+		return 0xE8912D
+	default:
+		return 0xE01E5A
+	}
+}
+
+// webhookPayload shapes the notification for the given webhook format.
+// Empty and unknown formats use the generic AlertNotification JSON.
+func webhookPayload(notification AlertNotification, format string) interface{} {
+	summary := fmt.Sprintf("[%s] %s is %s", strings.ToUpper(string(notification.Severity)), notification.Title, notification.Status)
+	color := severityColor(notification.Severity, notification.Status)
+
+	switch format {
+	case WebhookFormatSlack:
+		return slackMessage{
+			Text: summary,
+			Attachments: []slackAttachment{{
+				Color: fmt.Sprintf("#%06X", color),
+				Title: notification.Title,
+				Text:  notification.Description,
+				Fields: []slackField{
+					{Title: "Severity", Value: string(notification.Severity), Short: true},
+					{Title: "Status", Value: string(notification.Status), Short: true},
+					{Title: "Type", Value: string(notification.Type), Short: true},
+					{Title: "Alert", Value: notification.ID, Short: true},
+				},
+				Footer: "VPN Server",
+				TS:     notification.Timestamp.Unix(),
+			}},
+		}
+	case WebhookFormatDiscord:
+		return discordMessage{
+			Content: summary,
+			Embeds: []discordEmbed{{
+				Title:       notification.Title,
+				Description: notification.Description,
+				Color:       color,
+				Fields: []discordField{
+					{Name: "Severity", Value: string(notification.Severity), Inline: true},
+					{Name: "Status", Value: string(notification.Status), Inline: true},
+					{Name: "Type", Value: string(notification.Type), Inline: true},
+					{Name: "Alert", Value: notification.ID, Inline: true},
+				},
+				Timestamp: notification.Timestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			}},
+		}
+	default:
+		return notification
+	}
+}
@@ -0,0 +1,111 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	notification := AlertNotification{
+		ID:          "system_cpu_high",
+		Type:        AlertTypeSystem,
+		Severity:    SeverityHigh,
+		Title:       "High CPU Usage",
+		Description: "CPU usage is 95.0%",
+		Status:      AlertStatusActive,
+		Timestamp:   time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("should keep the generic payload by default", func(t *testing.T) {
+		assert.Equal(t, notification, webhookPayload(notification, ""))
+		assert.Equal(t, notification, webhookPayload(notification, WebhookFormatGeneric))
+	})
+
+	t.Run("should shape a slack message with a severity color", func(t *testing.T) {
+		message, ok := webhookPayload(notification, WebhookFormatSlack).(slackMessage)
+		require.True(t, ok)
+
+		assert.Equal(t, "[HIGH] High CPU Usage is active", message.Text)
+		require.Len(t, message.Attachments, 1)
+		attachment := message.Attachments[0]
+		assert.Equal(t, "#E8912D", attachment.Color)
+		assert.Equal(t, "High CPU Usage", attachment.Title)
+		assert.Equal(t, "CPU usage is 95.0%", attachment.Text)
+		assert.Equal(t, notification.Timestamp.Unix(), attachment.TS)
+	})
+
+	t.Run("should map each severity to its own color", func(t *testing.T) {
+		colors := make(map[string]bool)
+		for _, severity := range []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical} {
+			alert := notification
+			alert.Severity = severity
+			message := webhookPayload(alert, WebhookFormatSlack).(slackMessage)
+			colors[message.Attachments[0].Color] = true
+		}
+		assert.Len(t, colors, 4)
+
+		critical := notification
+		critical.Severity = SeverityCritical
+		assert.Equal(t, "#E01E5A", webhookPayload(critical, WebhookFormatSlack).(slackMessage).Attachments[0].Color)
+	})
+
+	t.Run("should color resolved alerts green", func(t *testing.T) {
+		resolved := notification
+		resolved.Status = AlertStatusResolved
+
+		message := webhookPayload(resolved, WebhookFormatSlack).(slackMessage)
+		assert.Equal(t, "#2EB67D", message.Attachments[0].Color)
+	})
+
+	t.Run("should shape a discord message with an embed", func(t *testing.T) {
+		message, ok := webhookPayload(notification, WebhookFormatDiscord).(discordMessage)
+		require.True(t, ok)
+
+		assert.Equal(t, "[HIGH] High CPU Usage is active", message.Content)
+		require.Len(t, message.Embeds, 1)
+		embed := message.Embeds[0]
+		assert.Equal(t, 0xE8912D, embed.Color)
+		assert.Equal(t, "High CPU Usage", embed.Title)
+		assert.Equal(t, "CPU usage is 95.0%", embed.Description)
+		assert.Equal(t, "2026-03-15T12:00:00Z", embed.Timestamp)
+	})
+}
+
+func TestAlertManager_SlackWebhook(t *testing.T) {
+	t.Run("should post the slack payload", func(t *testing.T) {
+		received := make(chan map[string]interface{}, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]interface{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			received <- payload
+		}))
+		defer server.Close()
+
+		config := NewAlertManager().GetConfig()
+		config.NotificationChannels = []string{NotificationChannelWebhook}
+		config.WebhookURL = server.URL
+		config.WebhookFormat = WebhookFormatSlack
+		am := NewAlertManagerWithConfig(config)
+
+		am.RaiseAlert("security_breach", AlertTypeSecurity, SeverityCritical, "Breach Detected", "Unexpected peer", nil)
+
+		select {
+		case payload := <-received:
+			assert.Equal(t, "[CRITICAL] Breach Detected is active", payload["text"])
+			attachments, ok := payload["attachments"].([]interface{})
+			require.True(t, ok)
+			require.Len(t, attachments, 1)
+			attachment := attachments[0].(map[string]interface{})
+			assert.Equal(t, "#E01E5A", attachment["color"])
+			assert.Equal(t, "Breach Detected", attachment["title"])
+		case <-time.After(time.Second):
+			t.Fatal("expected a webhook notification")
+		}
+	})
+}
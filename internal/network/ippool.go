@@ -4,24 +4,84 @@
 package network
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"net"
 	"sort"
+	"strings"
 	"sync"
 )
 
+// AllocationStrategy determines how AllocateIP picks the next free address.
+type AllocationStrategy string
+
+const (
+	StrategySequential AllocationStrategy = "sequential" // Lowest free address first (default)
+	StrategyRandom     AllocationStrategy = "random"     // Uniformly random free address
+)
+
+// sparseHostBits is the number of host bits above which a pool is too large to scan.
+// Larger pools (e.g. an IPv6 /64) only track allocated addresses and hand out
+// addresses by incrementing from the last one handed out.
+const sparseHostBits = 16
+
+// ErrPoolExhausted is returned by AllocateIP when every address in the pool is allocated.
+var ErrPoolExhausted = errors.New("no available IP addresses in pool")
+
+// ErrIPAllocated is returned by AllocateSpecificIP when the address is already allocated.
+var ErrIPAllocated = errors.New("IP address already allocated")
+
+// ErrAllocationsOutOfRange is returned by Reconfigure when allocated addresses
+// would not be usable in the new network. The returned error is an
+// *OrphanedAllocationsError listing the addresses.
+var ErrAllocationsOutOfRange = errors.New("allocated IP addresses do not fit the new network")
+
+// OrphanedAllocationsError lists the allocated addresses that prevent a pool
+// from being moved to a new network.
+type OrphanedAllocationsError struct {
+	Network string   // Requested network in CIDR notation
+	IPs     []string // Allocated addresses outside the network or reserved in it, sorted
+}
+
+// Error implements the error interface for OrphanedAllocationsError.
+func (e *OrphanedAllocationsError) Error() string {
+	return fmt.Sprintf("%v: %s would orphan %s", ErrAllocationsOutOfRange, e.Network, strings.Join(e.IPs, ", "))
+}
+
+// Unwrap lets errors.Is match ErrAllocationsOutOfRange.
+func (e *OrphanedAllocationsError) Unwrap() error {
+	return ErrAllocationsOutOfRange
+}
+
+// MaxListedAvailableIPs caps the addresses returned by GetAvailableIPs, since
+// large pools (e.g. an IPv6 /64) have far too many free addresses to list.
+const MaxListedAvailableIPs = 256
+
+// maxRandomAttempts bounds random probing in sparse pools before falling back
+// to sequential allocation.
+const maxRandomAttempts = 64
+
 // IPPool manages a pool of IP addresses for VPN client allocation.
-// It provides thread-safe operations for allocating and releasing IP addresses
-// within a specified network range, while reserving the first usable IP for the server.
+// It provides thread-safe operations for allocating and releasing IPv4 or IPv6
+// addresses within a specified network range, while reserving the first usable
+// IP for the server.
 type IPPool struct {
-	mu               sync.RWMutex    // Protects concurrent access to the pool
-	network          string          // Original CIDR notation (e.g., "10.0.0.0/24")
-	ipNet            *net.IPNet      // Parsed network information
-	serverIP         string          // Reserved IP address for the VPN server
-	allocated        map[string]bool // Tracks which IP addresses are currently allocated
-	networkAddress   string          // Network address (e.g., "10.0.0.0")
-	broadcastAddress string          // Broadcast address (e.g., "10.0.0.255")
-	totalHosts       int             // Total number of usable host addresses
+	mu               sync.RWMutex       // Protects concurrent access to the pool
+	network          string             // Original CIDR notation (e.g., "10.0.0.0/24")
+	ipNet            *net.IPNet         // Parsed network information
+	serverIP         string             // Reserved IP address for the VPN server
+	allocated        map[string]bool    // Tracks which IP addresses are currently allocated
+	networkAddress   string             // Network address (e.g., "10.0.0.0")
+	broadcastAddress string             // Broadcast address (e.g., "10.0.0.255"), empty for IPv6
+	totalHosts       int                // Total number of usable host addresses (capped at math.MaxInt)
+	strategy         AllocationStrategy // Strategy used by AllocateIP
+	base             *big.Int           // Network address as an integer
+	lastHost         *big.Int           // Offset of the last usable host address from the network address
+	sparse           bool               // Whether the pool is too large to scan
+	cursor           *big.Int           // Offset where the next sparse sequential allocation starts
 }
 
 // NetworkInfo provides detailed information about the network configuration.
@@ -36,8 +96,10 @@ type NetworkInfo struct {
 
 // NewIPPool creates a new IP pool from the given CIDR notation.
 // It validates the network range, calculates available addresses, and reserves
-// the first usable IP address for the VPN server. The network must be at least /29
-// to provide sufficient addresses for meaningful VPN usage.
+// the first usable IP address for the VPN server. The network must have at least
+// 3 host bits (/29 for IPv4, /125 for IPv6) to provide sufficient addresses for
+// meaningful VPN usage. IPv4 pools exclude the network and broadcast addresses;
+// IPv6 pools exclude the subnet-router anycast address (the all-zeros host).
 // Returns an IPPool instance or an error if the CIDR is invalid or too small.
 func NewIPPool(cidr string) (*IPPool, error) {
 	_, ipNet, err := net.ParseCIDR(cidr)
@@ -45,72 +107,219 @@ func NewIPPool(cidr string) (*IPPool, error) {
 		return nil, fmt.Errorf("invalid CIDR: %w", err)
 	}
 
-	// Check if it's IPv4
-	if ipNet.IP.To4() == nil {
-		return nil, fmt.Errorf("IPv6 not supported")
-	}
-
 	// Calculate network size
 	ones, bits := ipNet.Mask.Size()
-	if bits-ones < 3 {
-		return nil, fmt.Errorf("network too small, need at least /29")
+	hostBits := bits - ones
+	if hostBits < 3 {
+		return nil, fmt.Errorf("network too small, need at least /29 (IPv4) or /125 (IPv6)")
 	}
 
-	// Calculate total hosts (excluding network and broadcast)
-	totalHosts := (1 << (bits - ones)) - 2
-
-	// Get network and broadcast addresses
 	networkAddr := ipNet.IP.Mask(ipNet.Mask)
-	broadcastAddr := make(net.IP, len(networkAddr))
-	copy(broadcastAddr, networkAddr)
-	
-	// Calculate broadcast address
-	for i := 0; i < len(broadcastAddr); i++ {
-		broadcastAddr[i] |= ^ipNet.Mask[i]
+	size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+
+	pool := &IPPool{
+		network:        cidr,
+		ipNet:          ipNet,
+		allocated:      make(map[string]bool),
+		networkAddress: networkAddr.String(),
+		strategy:       StrategySequential,
+		base:           new(big.Int).SetBytes(networkAddr),
+		sparse:         hostBits > sparseHostBits,
+		cursor:         big.NewInt(2),
 	}
 
-	// Server IP is typically the first usable IP (network + 1)
-	serverIP := incrementIP(networkAddr, 1)
+	// IPv4 reserves the broadcast address; IPv6 has no broadcast, so only the
+	// network (subnet-router anycast) address is excluded
+	total := new(big.Int).Sub(size, big.NewInt(1))
+	pool.lastHost = new(big.Int).Sub(size, big.NewInt(1))
+	if ipNet.IP.To4() != nil {
+		total.Sub(total, big.NewInt(1))
+		pool.lastHost.Sub(pool.lastHost, big.NewInt(1))
+		pool.broadcastAddress = pool.ipAt(new(big.Int).Sub(size, big.NewInt(1))).String()
+	}
 
-	pool := &IPPool{
-		network:          cidr,
-		ipNet:            ipNet,
-		serverIP:         serverIP.String(),
-		allocated:        make(map[string]bool),
-		networkAddress:   networkAddr.String(),
-		broadcastAddress: broadcastAddr.String(),
-		totalHosts:       totalHosts,
+	pool.totalHosts = math.MaxInt
+	if total.IsInt64() && total.Int64() < math.MaxInt {
+		pool.totalHosts = int(total.Int64())
 	}
 
-	// Mark server IP as allocated
+	// Server IP is typically the first usable IP (network + 1)
+	pool.serverIP = pool.ipAt(big.NewInt(1)).String()
 	pool.allocated[pool.serverIP] = true
 
 	return pool, nil
 }
 
+// NewIPPoolWithStrategy creates a new IP pool that allocates addresses using the given strategy.
+// It behaves like NewIPPool but allows choosing between sequential and random allocation.
+// Returns an IPPool instance or an error if the CIDR or strategy is invalid.
+func NewIPPoolWithStrategy(cidr string, strategy AllocationStrategy) (*IPPool, error) {
+	pool, err := NewIPPool(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pool.SetAllocationStrategy(strategy); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// SetAllocationStrategy changes the strategy used by AllocateIP for future allocations.
+// Existing allocations are not affected.
+// Returns an error if the strategy is not recognized.
+func (p *IPPool) SetAllocationStrategy(strategy AllocationStrategy) error {
+	switch strategy {
+	case StrategySequential, StrategyRandom:
+	default:
+		return fmt.Errorf("unknown allocation strategy: %s", strategy)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.strategy = strategy
+	return nil
+}
+
+// GetAllocationStrategy returns the strategy currently used by AllocateIP.
+func (p *IPPool) GetAllocationStrategy() AllocationStrategy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.strategy
+}
+
 // AllocateIP allocates the next available IP address from the pool.
-// It performs a sequential search starting from the second usable IP address
-// (since the first is reserved for the server) and returns the first available address.
+// With the sequential strategy it searches from the second usable IP address
+// (since the first is reserved for the server) and returns the first available address;
+// in sparse pools it continues from the last address handed out instead.
+// With the random strategy it picks uniformly among all free addresses.
 // This method is thread-safe and will not allocate network, broadcast, or server addresses.
 // Returns the allocated IP address as a string or an error if no addresses are available.
 func (p *IPPool) AllocateIP() (string, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.strategy == StrategyRandom {
+		return p.allocateRandomIP()
+	}
+
+	if p.sparse {
+		return p.allocateNextIP()
+	}
+
 	// Start from the second IP (server IP is first)
-	currentIP := incrementIP(p.ipNet.IP.Mask(p.ipNet.Mask), 2)
-	broadcastIP := net.ParseIP(p.broadcastAddress)
+	for offset := int64(2); offset <= p.lastHost.Int64(); offset++ {
+		ipStr := p.ipAt(big.NewInt(offset)).String()
+		if !p.allocated[ipStr] {
+			p.allocated[ipStr] = true
+			return ipStr, nil
+		}
+	}
+
+	return "", ErrPoolExhausted
+}
+
+// allocateNextIP allocates the first free address at or after the cursor,
+// wrapping around to the start of the range. Only allocated addresses can be
+// skipped, so at most len(allocated)+1 candidates are checked.
+// The caller must hold the write lock.
+func (p *IPPool) allocateNextIP() (string, error) {
+	offset := new(big.Int).Set(p.cursor)
+	for i := 0; i <= len(p.allocated); i++ {
+		if offset.Cmp(p.lastHost) > 0 {
+			offset.SetInt64(2)
+		}
 
-	for !currentIP.Equal(broadcastIP) {
-		ipStr := currentIP.String()
+		ipStr := p.ipAt(offset).String()
+		offset.Add(offset, big.NewInt(1))
 		if !p.allocated[ipStr] {
 			p.allocated[ipStr] = true
+			p.cursor = offset
+			return ipStr, nil
+		}
+	}
+
+	return "", ErrPoolExhausted
+}
+
+// allocateRandomIP picks a uniformly random free address from the pool.
+// Sparse pools probe random addresses and fall back to sequential allocation
+// if every probe hits an allocated address.
+// The caller must hold the write lock.
+func (p *IPPool) allocateRandomIP() (string, error) {
+	// Client addresses are the offsets [2, lastHost]
+	span := new(big.Int).Sub(p.lastHost, big.NewInt(1))
+
+	if p.sparse {
+		for i := 0; i < maxRandomAttempts; i++ {
+			offset, err := rand.Int(rand.Reader, span)
+			if err != nil {
+				return "", fmt.Errorf("failed to pick random IP address: %w", err)
+			}
+
+			ipStr := p.ipAt(offset.Add(offset, big.NewInt(2))).String()
+			if !p.allocated[ipStr] {
+				p.allocated[ipStr] = true
+				return ipStr, nil
+			}
+		}
+		return p.allocateNextIP()
+	}
+
+	var free []string
+	for offset := int64(2); offset <= p.lastHost.Int64(); offset++ {
+		ipStr := p.ipAt(big.NewInt(offset)).String()
+		if !p.allocated[ipStr] {
+			free = append(free, ipStr)
+		}
+	}
+
+	if len(free) == 0 {
+		return "", ErrPoolExhausted
+	}
+
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(free))))
+	if err != nil {
+		return "", fmt.Errorf("failed to pick random IP address: %w", err)
+	}
+
+	ipStr := free[index.Int64()]
+	p.allocated[ipStr] = true
+	return ipStr, nil
+}
+
+// PeekIP returns a free IP address without allocating it.
+// With the sequential strategy this is the address AllocateIP would hand out
+// next; with the random strategy it is simply the first free address.
+// The address is not reserved, so a concurrent allocation may take it.
+// Returns ErrPoolExhausted if no addresses are available.
+func (p *IPPool) PeekIP() (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	offset := big.NewInt(2)
+	if p.sparse && p.strategy != StrategyRandom {
+		offset.Set(p.cursor)
+	}
+
+	// Only allocated addresses can be skipped, so at most len(allocated)+1
+	// candidates are checked
+	for i := 0; i <= len(p.allocated); i++ {
+		if offset.Cmp(p.lastHost) > 0 {
+			offset.SetInt64(2)
+		}
+
+		ipStr := p.ipAt(offset).String()
+		offset.Add(offset, big.NewInt(1))
+		if !p.allocated[ipStr] {
 			return ipStr, nil
 		}
-		currentIP = incrementIP(currentIP, 1)
 	}
 
-	return "", fmt.Errorf("no available IP addresses in pool")
+	return "", ErrPoolExhausted
 }
 
 // AllocateSpecificIP allocates a specific IP address if it's available.
@@ -122,10 +331,11 @@ func (p *IPPool) AllocateSpecificIP(ip string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	parsedIP := net.ParseIP(ip)
+	parsedIP, canonical := canonicalIP(ip)
 	if parsedIP == nil {
 		return fmt.Errorf("invalid IP address: %s", ip)
 	}
+	ip = canonical
 
 	// Check if IP is in network range
 	if !p.ipNet.Contains(parsedIP) {
@@ -137,8 +347,8 @@ func (p *IPPool) AllocateSpecificIP(ip string) error {
 		return fmt.Errorf("cannot allocate network address: %s", ip)
 	}
 
-	// Check if it's the broadcast address
-	if ip == p.broadcastAddress {
+	// Check if it's the broadcast address (IPv4 only)
+	if p.broadcastAddress != "" && ip == p.broadcastAddress {
 		return fmt.Errorf("cannot allocate broadcast address: %s", ip)
 	}
 
@@ -149,13 +359,87 @@ func (p *IPPool) AllocateSpecificIP(ip string) error {
 
 	// Check if already allocated
 	if p.allocated[ip] {
-		return fmt.Errorf("IP address already allocated: %s", ip)
+		return fmt.Errorf("%w: %s", ErrIPAllocated, ip)
 	}
 
 	p.allocated[ip] = true
 	return nil
 }
 
+// Reconfigure moves the pool to a new network, e.g. to grow a /24 into a /23,
+// keeping every client allocation. The network, broadcast and server addresses
+// and the capacity are recomputed for the new range; the allocation strategy is kept.
+// The pool is left unchanged if any allocated client address would fall outside
+// the new network or on one of its reserved addresses.
+// Returns an error if the CIDR is invalid or too small, or an
+// *OrphanedAllocationsError listing the addresses that do not fit.
+func (p *IPPool) Reconfigure(cidr string) error {
+	next, err := NewIPPool(cidr)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var orphaned []string
+	for ip := range p.allocated {
+		if ip == p.serverIP {
+			continue
+		}
+		parsedIP, _ := canonicalIP(ip)
+		if !next.ipNet.Contains(parsedIP) || ip == next.networkAddress ||
+			ip == next.broadcastAddress || ip == next.serverIP {
+			orphaned = append(orphaned, ip)
+			continue
+		}
+		next.allocated[ip] = true
+	}
+	if len(orphaned) > 0 {
+		sort.Strings(orphaned)
+		return &OrphanedAllocationsError{Network: cidr, IPs: orphaned}
+	}
+
+	p.network = next.network
+	p.ipNet = next.ipNet
+	p.serverIP = next.serverIP
+	p.allocated = next.allocated
+	p.networkAddress = next.networkAddress
+	p.broadcastAddress = next.broadcastAddress
+	p.totalHosts = next.totalHosts
+	p.base = next.base
+	p.lastHost = next.lastHost
+	p.sparse = next.sparse
+	p.cursor = next.cursor
+	return nil
+}
+
+// RestoreAllocations marks previously assigned IP addresses as allocated.
+// It is used at startup to rebuild the in-memory pool from the clients stored
+// in the database, so AllocateIP does not hand out addresses already in use.
+// Addresses that are already allocated are left as they are. Addresses that are
+// invalid, outside the network range, or reserved (network, broadcast, server)
+// are not restored.
+// Returns the addresses that were skipped.
+func (p *IPPool) RestoreAllocations(ips []string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var skipped []string
+	for _, ip := range ips {
+		parsedIP, canonical := canonicalIP(ip)
+		if parsedIP == nil || !p.ipNet.Contains(parsedIP) || canonical == p.networkAddress ||
+			canonical == p.broadcastAddress || canonical == p.serverIP {
+			skipped = append(skipped, ip)
+			continue
+		}
+
+		p.allocated[canonical] = true
+	}
+
+	return skipped
+}
+
 // ReleaseIP releases a previously allocated IP address back to the pool.
 // The released address becomes available for future allocation to other clients.
 // This method validates that the IP is within the network range and currently allocated.
@@ -165,10 +449,11 @@ func (p *IPPool) ReleaseIP(ip string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	parsedIP := net.ParseIP(ip)
+	parsedIP, canonical := canonicalIP(ip)
 	if parsedIP == nil {
 		return fmt.Errorf("invalid IP address: %s", ip)
 	}
+	ip = canonical
 
 	// Check if IP is in network range
 	if !p.ipNet.Contains(parsedIP) {
@@ -197,6 +482,7 @@ func (p *IPPool) IsAllocated(ip string) bool {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	_, ip = canonicalIP(ip)
 	return p.allocated[ip]
 }
 
@@ -204,6 +490,9 @@ func (p *IPPool) IsAllocated(ip string) bool {
 // This address is automatically reserved during pool creation and cannot be allocated to clients.
 // Returns the server IP address as a string.
 func (p *IPPool) GetServerIP() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
 	return p.serverIP
 }
 
@@ -240,6 +529,49 @@ func (p *IPPool) GetAvailableCount() int {
 	return p.totalHosts - 1 - allocatedCount // -1 for server IP
 }
 
+// GetUtilization returns the percentage of usable addresses that are allocated,
+// counting the server IP. It is 0 for a pool without usable addresses rather than
+// dividing by zero, and never exceeds 100.
+func (p *IPPool) GetUtilization() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.totalHosts <= 0 {
+		return 0
+	}
+
+	utilization := float64(len(p.allocated)) / float64(p.totalHosts) * 100
+	return math.Min(utilization, 100)
+}
+
+// IsExhausted reports whether every client address is allocated, i.e. whether
+// AllocateIP would return ErrPoolExhausted.
+func (p *IPPool) IsExhausted() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.allocated) >= p.totalHosts
+}
+
+// GetAvailableIPs returns free client addresses in ascending order, e.g. for the
+// UI to suggest a fixed address. At most MaxListedAvailableIPs addresses are
+// returned, so the list is incomplete for larger pools.
+// Returns an empty slice if the pool is exhausted.
+func (p *IPPool) GetAvailableIPs() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ips := []string{}
+	for offset := big.NewInt(2); offset.Cmp(p.lastHost) <= 0 && len(ips) < MaxListedAvailableIPs; offset.Add(offset, big.NewInt(1)) {
+		ipStr := p.ipAt(offset).String()
+		if !p.allocated[ipStr] {
+			ips = append(ips, ipStr)
+		}
+	}
+
+	return ips
+}
+
 // GetNetworkInfo returns comprehensive information about the network configuration.
 // This includes the network topology, addressing scheme, and capacity information.
 // The returned information is useful for monitoring, configuration, and troubleshooting.
@@ -281,20 +613,24 @@ func (p *IPPool) GetAllocatedCount() int {
 	return len(p.allocated)
 }
 
-// incrementIP increments an IP address by the given amount.
-// This is a helper function that performs arithmetic on IP addresses,
-// properly handling byte overflow across octets. It's used internally
-// for sequential IP address allocation and network calculations.
-// Returns a new IP address that is 'inc' positions higher than the input.
-func incrementIP(ip net.IP, inc int) net.IP {
-	result := make(net.IP, len(ip))
-	copy(result, ip)
+// ipAt returns the address at the given offset from the network address.
+// The result has the same length as the network address (4 bytes for IPv4,
+// 16 bytes for IPv6), so its String form matches net.ParseIP round-trips.
+func (p *IPPool) ipAt(offset *big.Int) net.IP {
+	value := new(big.Int).Add(p.base, offset)
 
-	for i := len(result) - 1; i >= 0 && inc > 0; i-- {
-		val := int(result[i]) + inc
-		result[i] = byte(val & 0xFF)
-		inc = val >> 8
-	}
+	ip := make(net.IP, len(p.ipNet.IP))
+	value.FillBytes(ip)
+	return ip
+}
 
-	return result
-}
\ No newline at end of file
+// canonicalIP parses an IP address and returns its canonical string form,
+// so equivalent IPv6 spellings (e.g. "fd00:0::2" and "fd00::2") match.
+// Returns the parsed address and its canonical form, or nil if the address is invalid.
+func canonicalIP(ip string) (net.IP, string) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return nil, ""
+	}
+	return parsedIP, parsedIP.String()
+}
@@ -1,6 +1,9 @@
 package network
 
 import (
+	"math/big"
+	"net"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,10 +25,12 @@ func TestNewIPPool(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid CIDR")
 	})
 
-	t.Run("should fail with IPv6", func(t *testing.T) {
-		_, err := NewIPPool("2001:db8::/32")
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "IPv6 not supported")
+	t.Run("should create IPv6 pool", func(t *testing.T) {
+		pool, err := NewIPPool("fd00::/64")
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::", pool.networkAddress)
+		assert.Equal(t, "fd00::1", pool.serverIP)
+		assert.Empty(t, pool.broadcastAddress)
 	})
 
 	t.Run("should fail with single host", func(t *testing.T) {
@@ -64,11 +69,161 @@ func TestIPPool_AllocateIP(t *testing.T) {
 
 		// Now pool should be exhausted
 		_, err = pool.AllocateIP()
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrPoolExhausted)
 		assert.Contains(t, err.Error(), "no available IP addresses")
 	})
 }
 
+func TestIPPool_IPv6(t *testing.T) {
+	t.Run("should allocate from a /112", func(t *testing.T) {
+		pool, err := NewIPPool("fd00:1::/112")
+		require.NoError(t, err)
+		assert.Equal(t, 65535, pool.GetTotalIPs()) // 2^16 minus the subnet-router anycast address
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00:1::2", ip)
+
+		require.NoError(t, pool.AllocateSpecificIP("fd00:1::3"))
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00:1::4", ip)
+
+		// The last address is usable since IPv6 has no broadcast
+		require.NoError(t, pool.AllocateSpecificIP("fd00:1::ffff"))
+		assert.Error(t, pool.AllocateSpecificIP("fd00:1::"))
+		assert.Error(t, pool.AllocateSpecificIP("fd00:2::5"))
+
+		require.NoError(t, pool.ReleaseIP("fd00:1::2"))
+		assert.False(t, pool.IsAllocated("fd00:1::2"))
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00:1::2", ip)
+	})
+
+	t.Run("should allocate from a /64 without scanning the range", func(t *testing.T) {
+		pool, err := NewIPPool("fd00::/64")
+		require.NoError(t, err)
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::2", ip)
+
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::3", ip)
+
+		// Allocation continues from the last handed-out address, skipping taken ones
+		require.NoError(t, pool.AllocateSpecificIP("fd00::4"))
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::5", ip)
+
+		// Equivalent spellings refer to the same address
+		assert.True(t, pool.IsAllocated("fd00:0:0:0::5"))
+		require.NoError(t, pool.ReleaseIP("fd00:0::5"))
+		assert.False(t, pool.IsAllocated("fd00::5"))
+
+		assert.Equal(t, 4, pool.GetAllocatedCount())
+		assert.Equal(t, []string{"fd00::2", "fd00::3", "fd00::4"}, pool.GetAllocatedIPs())
+	})
+
+	t.Run("should wrap around at the end of a /64", func(t *testing.T) {
+		pool, err := NewIPPool("fd00::/64")
+		require.NoError(t, err)
+
+		require.NoError(t, pool.AllocateSpecificIP("fd00::ffff:ffff:ffff:fffe"))
+		pool.cursor = new(big.Int).Set(pool.lastHost)
+		pool.cursor.Sub(pool.cursor, big.NewInt(1))
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::ffff:ffff:ffff:ffff", ip)
+
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "fd00::2", ip)
+	})
+
+	t.Run("should allocate random addresses in a /64", func(t *testing.T) {
+		pool, err := NewIPPoolWithStrategy("fd00::/64", StrategyRandom)
+		require.NoError(t, err)
+
+		seen := make(map[string]bool)
+		for i := 0; i < 20; i++ {
+			ip, err := pool.AllocateIP()
+			require.NoError(t, err)
+			assert.False(t, seen[ip])
+			seen[ip] = true
+
+			parsed := net.ParseIP(ip)
+			require.NotNil(t, parsed)
+			assert.True(t, pool.ipNet.Contains(parsed))
+			assert.NotEqual(t, "fd00::", ip)
+			assert.NotEqual(t, "fd00::1", ip)
+		}
+	})
+}
+
+func TestIPPool_AllocationStrategy(t *testing.T) {
+	t.Run("should default to sequential strategy", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/24")
+		require.NoError(t, err)
+		assert.Equal(t, StrategySequential, pool.GetAllocationStrategy())
+	})
+
+	t.Run("should reject unknown strategy", func(t *testing.T) {
+		_, err := NewIPPoolWithStrategy("10.0.0.0/24", "round-robin")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unknown allocation strategy")
+	})
+
+	t.Run("random strategy should not always return the lowest address", func(t *testing.T) {
+		lowestCount := 0
+		for i := 0; i < 20; i++ {
+			pool, err := NewIPPoolWithStrategy("10.0.0.0/24", StrategyRandom)
+			require.NoError(t, err)
+
+			ip, err := pool.AllocateIP()
+			require.NoError(t, err)
+			if ip == "10.0.0.2" {
+				lowestCount++
+			}
+		}
+		assert.Less(t, lowestCount, 20)
+	})
+
+	t.Run("random strategy should never double-allocate", func(t *testing.T) {
+		pool, err := NewIPPoolWithStrategy("10.0.0.0/27", StrategyRandom)
+		require.NoError(t, err)
+
+		seen := make(map[string]bool)
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for i := 0; i < 29; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ip, err := pool.AllocateIP()
+				assert.NoError(t, err)
+
+				mu.Lock()
+				defer mu.Unlock()
+				assert.False(t, seen[ip], "IP %s allocated twice", ip)
+				seen[ip] = true
+			}()
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, 29)
+		assert.NotContains(t, seen, pool.GetServerIP())
+
+		// Pool is now exhausted
+		_, err = pool.AllocateIP()
+		assert.Error(t, err)
+	})
+}
+
 func TestIPPool_AllocateSpecificIP(t *testing.T) {
 	pool, err := NewIPPool("10.0.0.0/28") // 16 addresses
 	require.NoError(t, err)
@@ -82,6 +237,7 @@ func TestIPPool_AllocateSpecificIP(t *testing.T) {
 	t.Run("should fail to allocate already allocated IP", func(t *testing.T) {
 		err := pool.AllocateSpecificIP("10.0.0.5")
 		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrIPAllocated)
 		assert.Contains(t, err.Error(), "IP address already allocated")
 	})
 
@@ -136,6 +292,167 @@ func TestIPPool_ReleaseIP(t *testing.T) {
 	})
 }
 
+func TestIPPool_RestoreAllocations(t *testing.T) {
+	t.Run("should skip restored IPs when allocating", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/28")
+		require.NoError(t, err)
+
+		skipped := pool.RestoreAllocations([]string{"10.0.0.2", "10.0.0.3", "10.0.0.5"})
+		assert.Empty(t, skipped)
+		assert.Equal(t, []string{"10.0.0.2", "10.0.0.3", "10.0.0.5"}, pool.GetAllocatedIPs())
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.4", ip)
+
+		ip, err = pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.6", ip)
+	})
+
+	t.Run("should skip IPs outside the network or reserved", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/28")
+		require.NoError(t, err)
+
+		skipped := pool.RestoreAllocations([]string{"10.0.0.4", "192.168.1.10", "10.0.0.1", "10.0.0.15", "not-an-ip"})
+		assert.Equal(t, []string{"192.168.1.10", "10.0.0.1", "10.0.0.15", "not-an-ip"}, skipped)
+		assert.Equal(t, []string{"10.0.0.4"}, pool.GetAllocatedIPs())
+	})
+
+	t.Run("should ignore IPs that are already allocated", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/28")
+		require.NoError(t, err)
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+
+		assert.Empty(t, pool.RestoreAllocations([]string{ip}))
+		assert.Equal(t, 2, pool.GetAllocatedCount())
+	})
+}
+
+func TestIPPool_Reconfigure(t *testing.T) {
+	setup := func(t *testing.T) *IPPool {
+		pool, err := NewIPPool("10.0.0.0/24")
+		require.NoError(t, err)
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.2"))
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.200"))
+		return pool
+	}
+
+	t.Run("should keep allocations when expanding", func(t *testing.T) {
+		pool := setup(t)
+
+		require.NoError(t, pool.Reconfigure("10.0.0.0/23"))
+
+		info := pool.GetNetworkInfo()
+		assert.Equal(t, "10.0.0.0/23", info.Network)
+		assert.Equal(t, "10.0.0.1", info.ServerIP)
+		assert.Equal(t, "10.0.1.255", info.BroadcastAddress)
+		assert.Equal(t, 510, info.TotalHosts)
+		assert.Equal(t, []string{"10.0.0.2", "10.0.0.200"}, pool.GetAllocatedIPs())
+		assert.True(t, pool.IsAllocated("10.0.0.1"))
+		assert.Equal(t, 507, pool.GetAvailableCount())
+
+		// Addresses beyond the old range can be allocated now
+		require.NoError(t, pool.AllocateSpecificIP("10.0.1.10"))
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.3", ip)
+	})
+
+	t.Run("should refuse a shrink that would orphan clients", func(t *testing.T) {
+		pool := setup(t)
+
+		err := pool.Reconfigure("10.0.0.0/25")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrAllocationsOutOfRange)
+
+		var orphaned *OrphanedAllocationsError
+		require.ErrorAs(t, err, &orphaned)
+		assert.Equal(t, []string{"10.0.0.200"}, orphaned.IPs)
+		assert.Contains(t, err.Error(), "10.0.0.200")
+
+		// The pool is unchanged
+		assert.Equal(t, "10.0.0.0/24", pool.GetNetworkInfo().Network)
+		assert.Equal(t, []string{"10.0.0.2", "10.0.0.200"}, pool.GetAllocatedIPs())
+	})
+
+	t.Run("should refuse addresses that become reserved", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/24")
+		require.NoError(t, err)
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.127"))
+
+		// 10.0.0.127 is the broadcast address of the /25
+		var orphaned *OrphanedAllocationsError
+		require.ErrorAs(t, pool.Reconfigure("10.0.0.0/25"), &orphaned)
+		assert.Equal(t, []string{"10.0.0.127"}, orphaned.IPs)
+	})
+
+	t.Run("should allow a shrink that keeps every client", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/24")
+		require.NoError(t, err)
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.5"))
+
+		require.NoError(t, pool.Reconfigure("10.0.0.0/28"))
+		assert.Equal(t, "10.0.0.15", pool.GetNetworkInfo().BroadcastAddress)
+		assert.Equal(t, []string{"10.0.0.5"}, pool.GetAllocatedIPs())
+	})
+
+	t.Run("should move an empty pool to a different network", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/24")
+		require.NoError(t, err)
+
+		require.NoError(t, pool.Reconfigure("192.168.50.0/24"))
+		assert.Equal(t, "192.168.50.1", pool.GetServerIP())
+		assert.False(t, pool.IsAllocated("10.0.0.1"))
+		assert.True(t, pool.IsAllocated("192.168.50.1"))
+	})
+
+	t.Run("should keep the allocation strategy", func(t *testing.T) {
+		pool, err := NewIPPoolWithStrategy("10.0.0.0/24", StrategyRandom)
+		require.NoError(t, err)
+
+		require.NoError(t, pool.Reconfigure("10.0.0.0/23"))
+		assert.Equal(t, StrategyRandom, pool.GetAllocationStrategy())
+	})
+
+	t.Run("should reject an invalid CIDR", func(t *testing.T) {
+		pool := setup(t)
+
+		assert.Error(t, pool.Reconfigure("invalid"))
+		assert.Error(t, pool.Reconfigure("10.0.0.0/30"))
+		assert.Equal(t, "10.0.0.0/24", pool.GetNetworkInfo().Network)
+	})
+}
+
+func TestIPPool_PeekIP(t *testing.T) {
+	pool, err := NewIPPool("10.0.0.0/29")
+	require.NoError(t, err)
+
+	t.Run("should return next free IP without allocating it", func(t *testing.T) {
+		ip, err := pool.PeekIP()
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.2", ip)
+		assert.False(t, pool.IsAllocated(ip))
+		assert.Equal(t, 5, pool.GetAvailableCount())
+
+		allocated, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, ip, allocated)
+	})
+
+	t.Run("should return error when pool is exhausted", func(t *testing.T) {
+		for pool.GetAvailableCount() > 0 {
+			_, err := pool.AllocateIP()
+			require.NoError(t, err)
+		}
+
+		_, err := pool.PeekIP()
+		assert.ErrorIs(t, err, ErrPoolExhausted)
+	})
+}
+
 func TestIPPool_IsAllocated(t *testing.T) {
 	pool, err := NewIPPool("10.0.0.0/28")
 	require.NoError(t, err)
@@ -216,6 +533,65 @@ func TestIPPool_GetAvailableCount(t *testing.T) {
 	})
 }
 
+func TestIPPool_Capacity(t *testing.T) {
+	t.Run("should report an empty pool", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/29") // 6 usable addresses, one for the server
+		require.NoError(t, err)
+
+		assert.InDelta(t, 100.0/6, pool.GetUtilization(), 0.001)
+		assert.False(t, pool.IsExhausted())
+		assert.Equal(t, []string{"10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}, pool.GetAvailableIPs())
+	})
+
+	t.Run("should report a partially allocated pool", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/29")
+		require.NoError(t, err)
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.3"))
+		require.NoError(t, pool.AllocateSpecificIP("10.0.0.5"))
+
+		assert.InDelta(t, 50.0, pool.GetUtilization(), 0.001)
+		assert.False(t, pool.IsExhausted())
+		assert.Equal(t, []string{"10.0.0.2", "10.0.0.4", "10.0.0.6"}, pool.GetAvailableIPs())
+	})
+
+	t.Run("should report a full pool", func(t *testing.T) {
+		pool, err := NewIPPool("10.0.0.0/29")
+		require.NoError(t, err)
+		for i := 0; i < 5; i++ {
+			_, err := pool.AllocateIP()
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 100.0, pool.GetUtilization())
+		assert.True(t, pool.IsExhausted())
+		assert.Empty(t, pool.GetAvailableIPs())
+		assert.NotNil(t, pool.GetAvailableIPs())
+
+		_, err = pool.AllocateIP()
+		assert.ErrorIs(t, err, ErrPoolExhausted)
+	})
+
+	t.Run("should not divide by zero without usable addresses", func(t *testing.T) {
+		pool := &IPPool{}
+
+		assert.Equal(t, 0.0, pool.GetUtilization())
+		assert.True(t, pool.IsExhausted())
+	})
+
+	t.Run("should cap the listed addresses for large pools", func(t *testing.T) {
+		pool, err := NewIPPool("fd00::/64")
+		require.NoError(t, err)
+		require.NoError(t, pool.AllocateSpecificIP("fd00::3"))
+
+		ips := pool.GetAvailableIPs()
+		require.Len(t, ips, MaxListedAvailableIPs)
+		assert.Equal(t, "fd00::2", ips[0])
+		assert.Equal(t, "fd00::4", ips[1])
+		assert.False(t, pool.IsExhausted())
+		assert.Less(t, pool.GetUtilization(), 0.001)
+	})
+}
+
 func TestIPPool_GetNetworkInfo(t *testing.T) {
 	pool, err := NewIPPool("172.16.0.0/16")
 	require.NoError(t, err)
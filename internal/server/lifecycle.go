@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"my-vpn/internal/monitoring"
+)
+
+// MonitorRunner collects metrics in the background while the server runs.
+// Run starts it before serving requests and Shutdown stops it once the web
+// server has drained. Alerts raised by the server go to its alert manager.
+// It is satisfied by *monitoring.Monitor.
+type MonitorRunner interface {
+	Start(ctx context.Context) error
+	Stop() error
+	GetAlertManager() *monitoring.AlertManager
+}
+
+// WebServer serves the management UI and API. Start blocks until the listener
+// is closed; Stop drains open requests until its context is done.
+type WebServer interface {
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+// LogCloser closes log files on shutdown.
+// It is satisfied by *monitoring.LogManager.
+type LogCloser interface {
+	Close() error
+}
+
+// SetMonitor attaches the monitor that Run starts and Shutdown stops.
+func (s *Server) SetMonitor(monitor MonitorRunner) {
+	s.monitor = monitor
+}
+
+// SetWebServer attaches the web server that replaces the built-in status pages.
+func (s *Server) SetWebServer(web WebServer) {
+	s.web = web
+}
+
+// SetLogManager attaches the log manager whose files Shutdown closes.
+func (s *Server) SetLogManager(logs LogCloser) {
+	s.logs = logs
+}
+
+// Run brings up the WireGuard interface if AutoStartWireGuard is set, starts
+// the monitor and the HTTP server and blocks until ctx is cancelled, e.g. by
+// SIGINT or SIGTERM, or the HTTP server fails.
+// Either way the server is shut down with Shutdown, bounded by the
+// configured ShutdownTimeout.
+// Returns the error of the HTTP server or of the shutdown, if any.
+func (s *Server) Run(ctx context.Context) error {
+	if s.config.AutoStartWireGuard {
+		s.autoStartWireGuard()
+	}
+
+	if s.monitor != nil {
+		// The monitor is stopped by Shutdown rather than by ctx, so its
+		// shutdown is ordered after the HTTP server's
+		if err := s.monitor.Start(context.Background()); err != nil {
+			return fmt.Errorf("failed to start monitor: %w", err)
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		err := s.Start()
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		serveErr <- err
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down VPN Server...")
+	case err := <-serveErr:
+		if err != nil {
+			runErr = fmt.Errorf("server stopped: %w", err)
+		}
+		serveErr = nil
+	}
+
+	timeout := s.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+	if serveErr != nil {
+		if err := <-serveErr; err != nil {
+			runErr = errors.Join(runErr, fmt.Errorf("server stopped: %w", err))
+		}
+	}
+
+	return runErr
+}
+
+// Shutdown stops the server and releases its resources in order: the HTTP
+// server stops accepting requests and drains open ones until ctx is done,
+// then the monitor is stopped, the log files are closed, the WireGuard
+// interface is brought down if StopWireGuardOnShutdown is set, and finally
+// the database is closed. Every step runs even if an earlier one fails.
+// Returns the errors of all failed steps joined together.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	if s.web != nil {
+		if err := s.web.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop web server: %w", err))
+		}
+	} else if err := s.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop HTTP server: %w", err))
+	}
+
+	if s.monitor != nil {
+		if err := s.monitor.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop monitor: %w", err))
+		}
+	}
+
+	if s.logs != nil {
+		if err := s.logs.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close logs: %w", err))
+		}
+	}
+
+	if s.config.StopWireGuardOnShutdown {
+		if err := s.wgServer.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop WireGuard: %w", err))
+		}
+	}
+
+	if err := s.db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close database: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/monitoring"
+)
+
+// shutdownRecorder records the order in which components are started and stopped.
+type shutdownRecorder struct {
+	mutex sync.Mutex
+	calls []string
+}
+
+func (r *shutdownRecorder) record(call string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *shutdownRecorder) Calls() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+type fakeMonitor struct {
+	recorder *shutdownRecorder
+	stopErr  error
+	alerts   *monitoring.AlertManager
+}
+
+func (f *fakeMonitor) Start(ctx context.Context) error {
+	f.recorder.record("monitor.start")
+	return nil
+}
+
+func (f *fakeMonitor) Stop() error {
+	f.recorder.record("monitor.stop")
+	return f.stopErr
+}
+
+func (f *fakeMonitor) GetAlertManager() *monitoring.AlertManager {
+	return f.alerts
+}
+
+// fakeWebServer serves until Stop is called, like an http.Server.
+type fakeWebServer struct {
+	recorder *shutdownRecorder
+	startErr error
+	stopCh   chan struct{}
+	once     sync.Once
+}
+
+func newFakeWebServer(recorder *shutdownRecorder) *fakeWebServer {
+	return &fakeWebServer{recorder: recorder, stopCh: make(chan struct{})}
+}
+
+func (f *fakeWebServer) Start() error {
+	f.recorder.record("web.start")
+	if f.startErr != nil {
+		return f.startErr
+	}
+	<-f.stopCh
+	return http.ErrServerClosed
+}
+
+func (f *fakeWebServer) Stop(ctx context.Context) error {
+	f.recorder.record("web.stop")
+	f.once.Do(func() { close(f.stopCh) })
+	return nil
+}
+
+type fakeLogCloser struct {
+	recorder *shutdownRecorder
+}
+
+func (f *fakeLogCloser) Close() error {
+	f.recorder.record("logs.close")
+	return nil
+}
+
+func TestServer_Run(t *testing.T) {
+	setup := func(t *testing.T, stopWireGuard bool) (*Server, *shutdownRecorder, *fakeWebServer, *fakeMonitor, *fakeWireGuardRunner) {
+		recorder := &shutdownRecorder{}
+		runner := &fakeWireGuardRunner{}
+
+		config := DefaultConfig()
+		config.DatabasePath = filepath.Join(t.TempDir(), "test.db")
+		config.WireGuard = runner
+		config.StopWireGuardOnShutdown = stopWireGuard
+		config.ShutdownTimeout = time.Second
+
+		srv, err := NewWithConfig(config)
+		require.NoError(t, err)
+
+		web := newFakeWebServer(recorder)
+		monitor := &fakeMonitor{recorder: recorder, alerts: monitoring.NewAlertManager()}
+		srv.SetWebServer(web)
+		srv.SetMonitor(monitor)
+		srv.SetLogManager(&fakeLogCloser{recorder: recorder})
+
+		return srv, recorder, web, monitor, runner
+	}
+
+	// run runs the server until ctx is cancelled and returns its result
+	run := func(t *testing.T, srv *Server, ctx context.Context) <-chan error {
+		done := make(chan error, 1)
+		go func() { done <- srv.Run(ctx) }()
+		return done
+	}
+
+	t.Run("should shut down components in order when the context is cancelled", func(t *testing.T) {
+		srv, recorder, _, _, runner := setup(t, false)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := run(t, srv, ctx)
+
+		require.Eventually(t, func() bool {
+			return len(recorder.Calls()) == 2
+		}, time.Second, 5*time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after the context was cancelled")
+		}
+
+		assert.Equal(t, []string{"monitor.start", "web.start", "web.stop", "monitor.stop", "logs.close"}, recorder.Calls())
+		assert.False(t, runner.stopped)
+		assert.Error(t, srv.GetDatabase().Exec("SELECT 1").Error, "database should be closed")
+	})
+
+	t.Run("should bring WireGuard down when configured", func(t *testing.T) {
+		srv, _, _, _, runner := setup(t, true)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, <-run(t, srv, ctx))
+
+		assert.True(t, runner.stopped)
+	})
+
+	t.Run("should shut down when the web server fails", func(t *testing.T) {
+		srv, recorder, web, _, _ := setup(t, false)
+		web.startErr = errors.New("address already in use")
+
+		err := <-run(t, srv, context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "address already in use")
+		assert.Contains(t, recorder.Calls(), "monitor.stop")
+		assert.Contains(t, recorder.Calls(), "logs.close")
+	})
+
+	t.Run("should report failed steps and still run the others", func(t *testing.T) {
+		srv, recorder, _, monitor, runner := setup(t, true)
+		monitor.stopErr = errors.New("monitor is not running")
+		runner.stopErr = errors.New("wg-quick failed")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := <-run(t, srv, ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to stop monitor")
+		assert.Contains(t, err.Error(), "failed to stop WireGuard")
+		assert.Contains(t, recorder.Calls(), "logs.close")
+	})
+}
+
+func TestServer_ShutdownBuiltinServer(t *testing.T) {
+	t.Run("should stop the built-in status server", func(t *testing.T) {
+		config := DefaultConfig()
+		config.DatabasePath = filepath.Join(t.TempDir(), "test.db")
+		config.Port = "127.0.0.1:0"
+
+		srv, err := NewWithConfig(config)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- srv.Run(ctx) }()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after the context was cancelled")
+		}
+	})
+}
@@ -1,38 +1,245 @@
 // Package server provides HTTP server functionality for the VPN management interface.
-// It handles basic web requests and provides health check endpoints for monitoring.
+// It bootstraps the persistent server state (database and IP pool), optionally
+// brings up the WireGuard interface, handles basic web requests and provides
+// health check endpoints for monitoring.
 package server
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"my-vpn/internal/api"
+	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
+	"my-vpn/internal/network"
+	"my-vpn/internal/wireguard"
 )
 
+// wireGuardStartAlertID identifies the alert raised when auto-start fails.
+const wireGuardStartAlertID = "application_wireguard_autostart_failed"
+
+// defaultShutdownTimeout bounds how long Run waits for Shutdown to complete.
+const defaultShutdownTimeout = 30 * time.Second
+
 // Server represents an HTTP server instance for the VPN management interface.
-// It encapsulates the server configuration and provides methods for starting
-// and handling HTTP requests.
+// It encapsulates the server configuration together with the database and
+// IP pool restored at startup, and provides methods for starting and
+// handling HTTP requests.
 type Server struct {
-	port string // The port on which the server listens (e.g., ":8080")
+	port       string             // The port on which the server listens (e.g., ":8080")
+	config     *Config            // Bootstrap configuration
+	db         *database.Database // Database connection for persistent state
+	ipPool     *network.IPPool    // IP pool rebuilt from stored clients
+	wgServer   WireGuardRunner    // Controls the WireGuard interface
+	httpServer *http.Server       // Built-in status server, used when no web server is attached
+	monitor    MonitorRunner      // Background monitoring (optional)
+	web        WebServer          // Management web server (optional)
+	logs       LogCloser          // Log files closed on shutdown (optional)
+}
+
+// WireGuardRunner controls the WireGuard interface at bootstrap and shutdown:
+// it writes the interface configuration with the peers of enabled clients and
+// brings the interface up and down.
+type WireGuardRunner interface {
+	WriteConfig(config *wireguard.ServerConfig) error
+	AddPeer(peer *wireguard.Peer) error
+	Start() error
+	Stop() error
+}
+
+// Config represents the configuration used to bootstrap the server.
+type Config struct {
+	Port                    string          // The port on which the server listens (e.g., ":8080")
+	DatabasePath            string          // Path to the SQLite database file
+	DefaultNetwork          string          // VPN network CIDR used until a server configuration is stored
+	AutoStartWireGuard      bool            // Bring up the WireGuard interface when Run starts
+	StopWireGuardOnShutdown bool            // Bring the WireGuard interface down on shutdown
+	ShutdownTimeout         time.Duration   // How long Run waits for shutdown to complete (0 uses 30s)
+	WireGuard               WireGuardRunner // WireGuard interface controller; nil uses the default server
+}
+
+// DefaultConfig returns the default bootstrap configuration.
+// The server listens on port 8080, stores its state in vpn.db and uses
+// 10.0.0.0/24 until the VPN network has been initialized. The WireGuard
+// interface is not started automatically.
+func DefaultConfig() *Config {
+	return &Config{
+		Port:           ":8080",
+		DatabasePath:   "vpn.db",
+		DefaultNetwork: "10.0.0.0/24",
+	}
 }
 
 // New creates a new Server instance with default configuration.
-// The server is configured to listen on port 8080 by default.
-// Returns a pointer to the newly created Server.
-func New() *Server {
-	return &Server{
-		port: ":8080",
+// Returns a pointer to the newly created Server or an error if bootstrapping fails.
+func New() (*Server, error) {
+	return NewWithConfig(DefaultConfig())
+}
+
+// NewWithConfig creates a new Server instance with custom configuration.
+// It opens the database, creates the IP pool for the stored VPN network and
+// re-registers the IP addresses of all existing clients, so addresses that
+// are already assigned are never handed out again after a restart.
+// Returns a pointer to the newly created Server or an error if bootstrapping fails.
+func NewWithConfig(config *Config) (*Server, error) {
+	db, err := database.New(config.DatabasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	wgServer := config.WireGuard
+	if wgServer == nil {
+		wgServer = wireguard.NewWireGuardServer()
+	}
+
+	s := &Server{
+		port:     config.Port,
+		config:   config,
+		db:       db,
+		wgServer: wgServer,
+	}
+
+	if err := s.initIPPool(); err != nil {
+		return nil, err
+	}
+
+	if count, err := db.CountUsers(); err == nil && count == 0 {
+		log.Println("No user accounts exist; the first account registered will be made admin")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.indexHandler)
+	mux.HandleFunc("/health", s.healthHandler)
+	s.httpServer = &http.Server{Addr: config.Port, Handler: mux}
+
+	return s, nil
+}
+
+// autoStartWireGuard brings up the WireGuard interface if a server configuration
+// has been stored. Failures are logged and raised as an alert on the monitor's
+// alert manager, if a monitor is attached, so the management server stays
+// available to fix the problem.
+func (s *Server) autoStartWireGuard() {
+	if err := s.startWireGuard(); err != nil {
+		log.Printf("Failed to auto-start WireGuard: %v", err)
+		if s.monitor == nil {
+			return
+		}
+		s.monitor.GetAlertManager().RaiseAlert(wireGuardStartAlertID, monitoring.AlertTypeApplication, monitoring.SeverityHigh,
+			"WireGuard auto-start failed",
+			fmt.Sprintf("The WireGuard interface could not be started at boot: %v", err),
+			nil)
+	}
+}
+
+// startWireGuard writes the WireGuard configuration with a peer for every enabled
+// client and starts the interface. It is a no-op until the server is initialized.
+func (s *Server) startWireGuard() error {
+	serverConfig, err := s.db.GetServerConfig()
+	if err == gorm.ErrRecordNotFound {
+		log.Println("Skipping WireGuard auto-start: server is not initialized")
+		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("failed to get server configuration: %w", err)
+	}
+
+	if err := s.wgServer.WriteConfig(api.NewWireGuardConfig(serverConfig, s.ipPool.GetNetworkInfo(), api.ResolveExternalInterface(serverConfig))); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
+	}
+
+	clients, err := s.db.ListClients()
+	if err != nil {
+		return fmt.Errorf("failed to list clients: %w", err)
+	}
+	peerCount := 0
+	for _, client := range clients {
+		if !client.Enabled {
+			continue
+		}
+		if err := s.wgServer.AddPeer(api.NewClientPeer(&client, serverConfig.DefaultKeepalive)); err != nil {
+			return fmt.Errorf("failed to add peer for client %s: %w", client.Name, err)
+		}
+		peerCount++
+	}
+
+	if err := s.wgServer.Start(); err != nil {
+		return err
+	}
+
+	log.Printf("WireGuard interface started with %d peers", peerCount)
+	return nil
 }
 
-// Start initializes and starts the HTTP server.
-// It registers route handlers for the root path and health check endpoint,
-// then begins listening for incoming connections on the configured port.
+// initIPPool creates the IP pool for the configured VPN network and restores
+// the allocations of existing clients. Client IPs outside the current network
+// (e.g. after the network was changed) are logged and skipped.
+func (s *Server) initIPPool() error {
+	cidr := s.config.DefaultNetwork
+	serverConfig, err := s.db.GetServerConfig()
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return fmt.Errorf("failed to get server configuration: %w", err)
+	}
+	if err == nil {
+		cidr = serverConfig.Network
+	}
+
+	ipPool, err := network.NewIPPool(cidr)
+	if err != nil {
+		return fmt.Errorf("failed to create IP pool: %w", err)
+	}
+
+	clients, err := s.db.ListClients()
+	if err != nil {
+		return fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	ips := make([]string, 0, len(clients))
+	clientNames := make(map[string]string, len(clients))
+	for _, client := range clients {
+		// Pending clients are still waiting for an address
+		if client.Pending {
+			continue
+		}
+		ips = append(ips, client.IPAddress)
+		clientNames[client.IPAddress] = client.Name
+	}
+
+	skipped := ipPool.RestoreAllocations(ips)
+	for _, ip := range skipped {
+		log.Printf("Skipping IP %s of client %s: not a usable address in %s", ip, clientNames[ip], cidr)
+	}
+	log.Printf("Restored %d client IP allocations", len(ips)-len(skipped))
+
+	s.ipPool = ipPool
+	return nil
+}
+
+// GetIPPool returns the IP pool restored at startup.
+func (s *Server) GetIPPool() *network.IPPool {
+	return s.ipPool
+}
+
+// GetDatabase returns the database opened at startup.
+func (s *Server) GetDatabase() *database.Database {
+	return s.db
+}
+
+// Start starts the HTTP server and blocks until it stops.
+// It serves the attached web server, or else the built-in status pages with
+// the root path and health check endpoint on the configured port.
 // Returns an error if the server fails to start or bind to the port.
 func (s *Server) Start() error {
-	http.HandleFunc("/", s.indexHandler)
-	http.HandleFunc("/health", s.healthHandler)
-	
+	if s.web != nil {
+		return s.web.Start()
+	}
+
 	fmt.Printf("Server starting on port %s\n", s.port)
-	return http.ListenAndServe(s.port, nil)
+	return s.httpServer.ListenAndServe()
 }
 
 // indexHandler handles requests to the root path ("/").
@@ -49,4 +256,4 @@ func (s *Server) indexHandler(w http.ResponseWriter, r *http.Request) {
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{"status": "ok"}`)
-}
\ No newline at end of file
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/database"
+	"my-vpn/internal/monitoring"
+	"my-vpn/internal/wireguard"
+)
+
+// fakeWireGuardRunner records bootstrap calls instead of touching the system.
+type fakeWireGuardRunner struct {
+	config   *wireguard.ServerConfig
+	peers    []*wireguard.Peer
+	started  bool
+	startErr error
+	stopped  bool
+	stopErr  error
+}
+
+func (f *fakeWireGuardRunner) WriteConfig(config *wireguard.ServerConfig) error {
+	f.config = config
+	return nil
+}
+
+func (f *fakeWireGuardRunner) AddPeer(peer *wireguard.Peer) error {
+	f.peers = append(f.peers, peer)
+	return nil
+}
+
+func (f *fakeWireGuardRunner) Start() error {
+	f.started = true
+	return f.startErr
+}
+
+func (f *fakeWireGuardRunner) Stop() error {
+	f.stopped = true
+	return f.stopErr
+}
+
+func TestNewWithConfig_RestoresIPAllocations(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Seed the database as a previous run would have left it
+	db, err := database.New(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, db.CreateServerConfig(&database.ServerConfig{
+		PrivateKey: "server-private-key",
+		PublicKey:  "server-public-key",
+		ListenPort: 51820,
+		Network:    "10.8.0.0/24",
+	}))
+	for i, ip := range []string{"10.8.0.2", "10.8.0.3", "10.8.0.4", "192.168.50.2"} {
+		require.NoError(t, db.CreateClient(&database.Client{
+			Name:       fmt.Sprintf("client-%d", i),
+			PublicKey:  fmt.Sprintf("public-key-%d", i),
+			PrivateKey: fmt.Sprintf("private-key-%d", i),
+			IPAddress:  ip,
+			Enabled:    true,
+		}))
+	}
+
+	config := DefaultConfig()
+	config.DatabasePath = dbPath
+
+	t.Run("should re-register stored client IPs", func(t *testing.T) {
+		srv, err := NewWithConfig(config)
+		require.NoError(t, err)
+
+		pool := srv.GetIPPool()
+		assert.Equal(t, "10.8.0.0/24", pool.GetNetworkInfo().Network)
+		assert.Equal(t, []string{"10.8.0.2", "10.8.0.3", "10.8.0.4"}, pool.GetAllocatedIPs())
+
+		ip, err := pool.AllocateIP()
+		require.NoError(t, err)
+		assert.Equal(t, "10.8.0.5", ip)
+	})
+
+	t.Run("should skip client IPs outside the network", func(t *testing.T) {
+		srv, err := NewWithConfig(config)
+		require.NoError(t, err)
+
+		assert.False(t, srv.GetIPPool().IsAllocated("192.168.50.2"))
+	})
+}
+
+func TestNewWithConfig_DefaultNetwork(t *testing.T) {
+	config := DefaultConfig()
+	config.DatabasePath = filepath.Join(t.TempDir(), "test.db")
+
+	srv, err := NewWithConfig(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, "10.0.0.0/24", srv.GetIPPool().GetNetworkInfo().Network)
+	assert.Empty(t, srv.GetIPPool().GetAllocatedIPs())
+}
+
+func TestServer_AutoStartWireGuard(t *testing.T) {
+	setup := func(t *testing.T, initialized bool) *Config {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+		db, err := database.New(dbPath)
+		require.NoError(t, err)
+
+		if initialized {
+			require.NoError(t, db.CreateServerConfig(&database.ServerConfig{
+				PrivateKey: "server-private-key",
+				PublicKey:  "server-public-key",
+				ListenPort: 51820,
+				Network:    "10.8.0.0/24",
+				Interface:  "wg0",
+			}))
+			require.NoError(t, db.CreateClient(&database.Client{
+				Name:       "enabled",
+				PublicKey:  "enabled-public-key",
+				PrivateKey: "enabled-private-key",
+				IPAddress:  "10.8.0.2",
+				Enabled:    true,
+			}))
+			disabled := &database.Client{
+				Name:       "disabled",
+				PublicKey:  "disabled-public-key",
+				PrivateKey: "disabled-private-key",
+				IPAddress:  "10.8.0.3",
+				Enabled:    true,
+			}
+			require.NoError(t, db.CreateClient(disabled))
+			disabled.Enabled = false
+			require.NoError(t, db.UpdateClient(disabled))
+		}
+
+		config := DefaultConfig()
+		config.DatabasePath = dbPath
+		config.ShutdownTimeout = time.Second
+		return config
+	}
+
+	// run runs the server with a cancelled context, so it starts up and shuts
+	// down right away, and returns the alerts raised on the monitor
+	run := func(t *testing.T, config *Config) []monitoring.Alert {
+		srv, err := NewWithConfig(config)
+		require.NoError(t, err)
+
+		recorder := &shutdownRecorder{}
+		monitor := &fakeMonitor{recorder: recorder, alerts: monitoring.NewAlertManager()}
+		srv.SetMonitor(monitor)
+		srv.SetWebServer(newFakeWebServer(recorder))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, srv.Run(ctx))
+
+		return monitor.alerts.GetActiveAlerts()
+	}
+
+	t.Run("should start WireGuard at boot when enabled", func(t *testing.T) {
+		runner := &fakeWireGuardRunner{}
+		config := setup(t, true)
+		config.AutoStartWireGuard = true
+		config.WireGuard = runner
+
+		alerts := run(t, config)
+
+		assert.True(t, runner.started)
+		require.NotNil(t, runner.config)
+		assert.Equal(t, "server-private-key", runner.config.PrivateKey)
+		assert.Equal(t, "10.8.0.1/24", runner.config.Address)
+		require.Len(t, runner.peers, 1)
+		assert.Equal(t, "enabled-public-key", runner.peers[0].PublicKey)
+		assert.Equal(t, []string{"10.8.0.2/32"}, runner.peers[0].AllowedIPs)
+		assert.Empty(t, alerts)
+	})
+
+	t.Run("should not start WireGuard when disabled", func(t *testing.T) {
+		runner := &fakeWireGuardRunner{}
+		config := setup(t, true)
+		config.WireGuard = runner
+
+		run(t, config)
+
+		assert.False(t, runner.started)
+		assert.Nil(t, runner.config)
+	})
+
+	t.Run("should skip auto-start before the server is initialized", func(t *testing.T) {
+		runner := &fakeWireGuardRunner{}
+		config := setup(t, false)
+		config.AutoStartWireGuard = true
+		config.WireGuard = runner
+
+		alerts := run(t, config)
+
+		assert.False(t, runner.started)
+		assert.Empty(t, alerts)
+	})
+
+	t.Run("should alert on the monitor without failing startup when start fails", func(t *testing.T) {
+		runner := &fakeWireGuardRunner{startErr: errors.New("wg-quick not found")}
+		config := setup(t, true)
+		config.AutoStartWireGuard = true
+		config.WireGuard = runner
+
+		alerts := run(t, config)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, wireGuardStartAlertID, alerts[0].ID)
+		assert.Equal(t, monitoring.AlertTypeApplication, alerts[0].Type)
+		assert.Contains(t, alerts[0].Description, "wg-quick not found")
+	})
+}
@@ -0,0 +1,95 @@
+// Package system provides system-level integration for macOS firewall management.
+// It handles pfctl (Packet Filter) configuration for WireGuard VPN traffic routing,
+// NAT rules, and firewall management specific to macOS environments.
+package system
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// CommandRunner runs external commands such as wg, wg-quick and pfctl.
+// It is satisfied by ExecRunner and can be replaced with a MockRunner in tests.
+type CommandRunner interface {
+	// Run executes the command and returns its combined stdout and stderr.
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands on the host system using os/exec.
+type ExecRunner struct{}
+
+// Run executes the command and returns its combined output.
+func (ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// DefaultRunner is the CommandRunner used when none is injected.
+var DefaultRunner CommandRunner = ExecRunner{}
+
+// MockResult is the canned outcome of a command run by a MockRunner.
+type MockResult struct {
+	Output string // Combined output returned to the caller
+	Err    error  // Error returned to the caller (nil for success)
+}
+
+// MockRunner records commands instead of running them and answers with
+// canned results. It is safe for concurrent use.
+type MockRunner struct {
+	mutex    sync.Mutex
+	commands []string              // Command lines in the order they were run
+	results  map[string]MockResult // Canned results keyed by command line prefix
+}
+
+// NewMockRunner creates a mock runner whose commands succeed without output
+// until results are registered with On.
+// Returns a pointer to the newly created MockRunner.
+func NewMockRunner() *MockRunner {
+	return &MockRunner{results: make(map[string]MockResult)}
+}
+
+// On registers the result of every command whose command line (the name and
+// arguments joined by spaces) starts with prefix. The longest matching prefix wins.
+func (m *MockRunner) On(prefix, output string, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.results[prefix] = MockResult{Output: output, Err: err}
+}
+
+// Run records the command and returns the registered result, if any.
+func (m *MockRunner) Run(name string, args ...string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	command := strings.Join(append([]string{name}, args...), " ")
+	m.commands = append(m.commands, command)
+
+	match, matched := "", false
+	for prefix := range m.results {
+		if strings.HasPrefix(command, prefix) && (!matched || len(prefix) > len(match)) {
+			match, matched = prefix, true
+		}
+	}
+	if !matched {
+		return nil, nil
+	}
+	result := m.results[match]
+	return []byte(result.Output), result.Err
+}
+
+// Commands returns the command lines run so far, in order.
+func (m *MockRunner) Commands() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]string(nil), m.commands...)
+}
+
+// Reset forgets the recorded commands but keeps the registered results.
+func (m *MockRunner) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.commands = nil
+}
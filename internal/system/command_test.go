@@ -0,0 +1,58 @@
+package system
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockRunner(t *testing.T) {
+	t.Run("should record commands and succeed by default", func(t *testing.T) {
+		runner := NewMockRunner()
+
+		output, err := runner.Run("wg", "show", "wg0")
+		require.NoError(t, err)
+		assert.Empty(t, output)
+		assert.Equal(t, []string{"wg show wg0"}, runner.Commands())
+	})
+
+	t.Run("should answer with the longest matching prefix", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.On("wg show", "interface: wg0", nil)
+		runner.On("wg show wg0 dump", "", errors.New("exit status 1"))
+
+		output, err := runner.Run("wg", "show", "wg0")
+		require.NoError(t, err)
+		assert.Equal(t, "interface: wg0", string(output))
+
+		_, err = runner.Run("wg", "show", "wg0", "dump")
+		assert.Error(t, err)
+	})
+
+	t.Run("should forget commands on reset", func(t *testing.T) {
+		runner := NewMockRunner()
+		runner.On("pfctl -e", "", errors.New("exit status 1"))
+		runner.Run("pfctl", "-e")
+
+		runner.Reset()
+		assert.Empty(t, runner.Commands())
+
+		_, err := runner.Run("pfctl", "-e")
+		assert.Error(t, err)
+	})
+}
+
+func TestExecRunner(t *testing.T) {
+	t.Run("should return combined output of the command", func(t *testing.T) {
+		output, err := ExecRunner{}.Run("sh", "-c", "echo out; echo err >&2")
+		require.NoError(t, err)
+		assert.Equal(t, "out\nerr\n", string(output))
+	})
+
+	t.Run("should return error for failing command", func(t *testing.T) {
+		_, err := ExecRunner{}.Run("sh", "-c", "exit 3")
+		assert.Error(t, err)
+	})
+}
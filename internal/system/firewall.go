@@ -0,0 +1,27 @@
+// Package system provides system-level integration for macOS firewall management.
+// It handles pfctl (Packet Filter) configuration for WireGuard VPN traffic routing,
+// NAT rules, and firewall management specific to macOS environments.
+package system
+
+import "runtime"
+
+// FirewallManager installs and inspects the NAT and forwarding rules for VPN
+// traffic. It is satisfied by PfctlManager on macOS and IptablesManager on Linux.
+type FirewallManager interface {
+	GenerateConfig(config *VPNConfig) string // Renders the rules for the configuration
+	WriteConfig(config *VPNConfig) error     // Validates the configuration and writes the rules file
+	EnableRules() error                      // Loads the written rules and activates them
+	DisableRules() error                     // Deactivates the VPN rules
+	IsEnabled() (bool, error)                // Reports whether the VPN rules are active
+	GetActiveRules() ([]PfctlRule, error)    // Lists the active rules
+	GetBlockedCount() (int, error)           // Counts the packets dropped by blocking rules
+}
+
+// NewFirewallManager creates the firewall manager for the current platform:
+// iptables on Linux and pfctl everywhere else.
+func NewFirewallManager() FirewallManager {
+	if runtime.GOOS == "linux" {
+		return NewIptablesManager()
+	}
+	return NewPfctlManager()
+}
@@ -0,0 +1,288 @@
+// Package system provides system-level integration for macOS firewall management.
+// It handles pfctl (Packet Filter) configuration for WireGuard VPN traffic routing,
+// NAT rules, and firewall management specific to macOS environments.
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Chains holding the VPN rules. Keeping the rules in dedicated chains lets
+// them be replaced atomically and removed without touching other rules.
+const (
+	iptablesInputChain   = "MYVPN-INPUT"
+	iptablesForwardChain = "MYVPN-FORWARD"
+	iptablesNATChain     = "MYVPN-POSTROUTING"
+)
+
+// iptablesJump is a built-in chain that jumps to one of the VPN chains.
+type iptablesJump struct {
+	table string // Table of both chains ("filter" or "nat")
+	from  string // Built-in chain (e.g. "FORWARD")
+	to    string // VPN chain
+}
+
+// iptablesJumps lists the jumps that activate the VPN chains.
+var iptablesJumps = []iptablesJump{
+	{table: "filter", from: "INPUT", to: iptablesInputChain},
+	{table: "filter", from: "FORWARD", to: iptablesForwardChain},
+	{table: "nat", from: "POSTROUTING", to: iptablesNATChain},
+}
+
+// IptablesManager manages Linux iptables firewall configuration for VPN operations.
+// Rules are rendered in iptables-restore format into dedicated chains, loaded
+// without flushing other rules, and activated by jumps from the built-in chains.
+type IptablesManager struct {
+	rulesPath string        // Path to the VPN rules file in iptables-restore format
+	runner    CommandRunner // Runs iptables and iptables-restore
+}
+
+// NewIptablesManager creates a new iptables manager with default configuration
+func NewIptablesManager() *IptablesManager {
+	return NewIptablesManagerWithConfig("/tmp/iptables_vpn.rules")
+}
+
+// NewIptablesManagerWithConfig creates a new iptables manager with custom configuration
+func NewIptablesManagerWithConfig(rulesPath string) *IptablesManager {
+	return NewIptablesManagerWithRunner(rulesPath, DefaultRunner)
+}
+
+// NewIptablesManagerWithRunner creates a new iptables manager that runs
+// iptables through the given runner, e.g. a MockRunner in tests
+func NewIptablesManagerWithRunner(rulesPath string, runner CommandRunner) *IptablesManager {
+	return &IptablesManager{
+		rulesPath: rulesPath,
+		runner:    runner,
+	}
+}
+
+// GenerateConfig generates iptables-restore rules for VPN.
+// Declaring the VPN chains flushes them on load, so reloading is idempotent.
+// Per-client rate limits need dummynet and are not rendered.
+func (im *IptablesManager) GenerateConfig(config *VPNConfig) string {
+	var rules strings.Builder
+
+	rules.WriteString("# WireGuard VPN NAT Rules\n")
+	rules.WriteString("# Generated by VPN Server\n")
+
+	// Filter rules
+	rules.WriteString("*filter\n")
+	rules.WriteString(fmt.Sprintf(":%s - [0:0]\n", iptablesInputChain))
+	rules.WriteString(fmt.Sprintf(":%s - [0:0]\n", iptablesForwardChain))
+
+	// WireGuard listen port
+	if config.ListenPort > 0 {
+		rules.WriteString(fmt.Sprintf("-A %s -i %s -p udp --dport %d -j ACCEPT\n",
+			iptablesInputChain, config.ExternalInterface, config.ListenPort))
+	}
+
+	// Allowed ports for VPN clients
+	if len(config.AllowedPorts) > 0 {
+		portList := make([]string, len(config.AllowedPorts))
+		for i, port := range config.AllowedPorts {
+			portList[i] = strconv.Itoa(port)
+		}
+		rules.WriteString(fmt.Sprintf("-A %s -i %s -o %s -p tcp -m multiport --dports %s -j ACCEPT\n",
+			iptablesForwardChain, config.Interface, config.ExternalInterface, strings.Join(portList, ",")))
+	}
+
+	// Basic rules
+	rules.WriteString(fmt.Sprintf("-A %s -s %s -d %s -j ACCEPT\n",
+		iptablesForwardChain, config.VPNNetwork, config.VPNNetwork))
+	rules.WriteString(fmt.Sprintf("-A %s -i %s -o %s -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT\n",
+		iptablesForwardChain, config.ExternalInterface, config.Interface))
+	if len(config.AllowedPorts) > 0 {
+		// Only the allowed ports may leave the VPN network
+		rules.WriteString(fmt.Sprintf("-A %s -s %s -j DROP\n", iptablesForwardChain, config.VPNNetwork))
+	} else {
+		rules.WriteString(fmt.Sprintf("-A %s -i %s -o %s -j ACCEPT\n",
+			iptablesForwardChain, config.Interface, config.ExternalInterface))
+	}
+	rules.WriteString("COMMIT\n")
+
+	// NAT rules
+	rules.WriteString("*nat\n")
+	rules.WriteString(fmt.Sprintf(":%s - [0:0]\n", iptablesNATChain))
+	rules.WriteString(fmt.Sprintf("-A %s -s %s -o %s -j MASQUERADE\n",
+		iptablesNATChain, config.VPNNetwork, config.ExternalInterface))
+	rules.WriteString("COMMIT\n")
+
+	return rules.String()
+}
+
+// WriteConfig writes the VPN configuration to file
+func (im *IptablesManager) WriteConfig(config *VPNConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid VPN configuration: %w", err)
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(im.rulesPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := os.WriteFile(im.rulesPath, []byte(im.GenerateConfig(config)), 0644); err != nil {
+		return fmt.Errorf("failed to write iptables configuration: %w", err)
+	}
+
+	return nil
+}
+
+// EnableRules loads the VPN chains and jumps to them from the built-in chains
+func (im *IptablesManager) EnableRules() error {
+	// Load the VPN chains, keeping all other rules
+	output, err := im.runner.Run("iptables-restore", "--noflush", im.rulesPath)
+	if err != nil {
+		return fmt.Errorf("failed to load iptables rules: %w, output: %s", err, string(output))
+	}
+
+	// Insert each jump once
+	for _, jump := range iptablesJumps {
+		if _, err := im.runner.Run("iptables", "-t", jump.table, "-C", jump.from, "-j", jump.to); err == nil {
+			continue
+		}
+		output, err := im.runner.Run("iptables", "-t", jump.table, "-I", jump.from, "-j", jump.to)
+		if err != nil {
+			return fmt.Errorf("failed to enable iptables rules: %w, output: %s", err, string(output))
+		}
+	}
+
+	return nil
+}
+
+// DisableRules removes the jumps to the VPN chains and empties them
+func (im *IptablesManager) DisableRules() error {
+	for _, jump := range iptablesJumps {
+		output, err := im.runner.Run("iptables", "-t", jump.table, "-D", jump.from, "-j", jump.to)
+		if err != nil && !isMissingIptablesRule(string(output)) {
+			return fmt.Errorf("failed to disable iptables rules: %w, output: %s", err, string(output))
+		}
+
+		output, err = im.runner.Run("iptables", "-t", jump.table, "-F", jump.to)
+		if err != nil && !isMissingIptablesRule(string(output)) {
+			return fmt.Errorf("failed to flush iptables chain %s: %w, output: %s", jump.to, err, string(output))
+		}
+	}
+
+	return nil
+}
+
+// IsEnabled checks if the VPN forwarding rules are active
+func (im *IptablesManager) IsEnabled() (bool, error) {
+	output, err := im.runner.Run("iptables", "-C", "FORWARD", "-j", iptablesForwardChain)
+	if err == nil {
+		return true, nil
+	}
+
+	// iptables exits with an error when the jump is missing or we lack permission
+	outputStr := string(output)
+	if isMissingIptablesRule(outputStr) ||
+		strings.Contains(outputStr, "Permission denied") {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check iptables status: %w", err)
+}
+
+// GetActiveRules returns the rules in the VPN chains
+func (im *IptablesManager) GetActiveRules() ([]PfctlRule, error) {
+	var rules []PfctlRule
+
+	for _, jump := range iptablesJumps {
+		output, err := im.runner.Run("iptables", "-t", jump.table, "-S", jump.to)
+		outputStr := string(output)
+
+		if err != nil {
+			// A missing chain or no permission means there are no VPN rules to list
+			if isMissingIptablesRule(outputStr) ||
+				strings.Contains(outputStr, "Permission denied") {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get iptables rules: %w", err)
+		}
+
+		for _, line := range strings.Split(outputStr, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "-A ") {
+				continue
+			}
+
+			rules = append(rules, PfctlRule{
+				ID:     len(rules),
+				Action: iptablesRuleAction(line),
+				Rule:   line,
+			})
+		}
+	}
+
+	return rules, nil
+}
+
+// GetBlockedCount returns the number of packets dropped or rejected by the
+// rules of the VPN filter chains, read from the iptables rule counters
+func (im *IptablesManager) GetBlockedCount() (int, error) {
+	blocked := 0
+
+	for _, jump := range iptablesJumps {
+		if jump.table != "filter" {
+			continue
+		}
+
+		output, err := im.runner.Run("iptables", "-t", jump.table, "-L", jump.to, "-v", "-x", "-n")
+		outputStr := string(output)
+
+		if err != nil {
+			// A missing chain or no permission means nothing was blocked by the VPN rules
+			if isMissingIptablesRule(outputStr) ||
+				strings.Contains(outputStr, "Permission denied") {
+				continue
+			}
+			return 0, fmt.Errorf("failed to get iptables rule counters: %w", err)
+		}
+
+		// Rule lines start with the packet and byte counters followed by the target:
+		// "  12   720 DROP  all  --  *  *  10.0.0.0/24  0.0.0.0/0"
+		for _, line := range strings.Split(outputStr, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || (fields[2] != "DROP" && fields[2] != "REJECT") {
+				continue
+			}
+			packets, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse iptables rule counters: %w", err)
+			}
+			blocked += packets
+		}
+	}
+
+	return blocked, nil
+}
+
+// iptablesRuleAction maps an iptables rule target to the action names used by pfctl
+func iptablesRuleAction(rule string) string {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] != "-j" {
+			continue
+		}
+		switch fields[i+1] {
+		case "ACCEPT":
+			return "pass"
+		case "DROP", "REJECT":
+			return "block"
+		case "MASQUERADE", "SNAT", "DNAT":
+			return "nat"
+		}
+	}
+	return "other"
+}
+
+// isMissingIptablesRule reports whether iptables output says the rule or chain doesn't exist
+func isMissingIptablesRule(output string) bool {
+	return strings.Contains(output, "does a matching rule exist") ||
+		strings.Contains(output, "No chain/target/match by that name") ||
+		strings.Contains(output, "Bad rule")
+}
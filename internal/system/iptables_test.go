@@ -0,0 +1,196 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFirewallManager(t *testing.T) {
+	t.Run("should select backend for the platform", func(t *testing.T) {
+		manager := NewFirewallManager()
+
+		if runtime.GOOS == "linux" {
+			assert.IsType(t, &IptablesManager{}, manager)
+		} else {
+			assert.IsType(t, &PfctlManager{}, manager)
+		}
+	})
+}
+
+func TestIptablesManager_GenerateConfig(t *testing.T) {
+	manager := NewIptablesManager()
+
+	t.Run("should generate NAT and forward rules", func(t *testing.T) {
+		config := &VPNConfig{
+			Interface:         "wg0",
+			VPNNetwork:        "10.0.0.0/24",
+			ExternalInterface: "eth0",
+			ListenPort:        51820,
+		}
+
+		expected := `# WireGuard VPN NAT Rules
+# Generated by VPN Server
+*filter
+:MYVPN-INPUT - [0:0]
+:MYVPN-FORWARD - [0:0]
+-A MYVPN-INPUT -i eth0 -p udp --dport 51820 -j ACCEPT
+-A MYVPN-FORWARD -s 10.0.0.0/24 -d 10.0.0.0/24 -j ACCEPT
+-A MYVPN-FORWARD -i eth0 -o wg0 -m conntrack --ctstate RELATED,ESTABLISHED -j ACCEPT
+-A MYVPN-FORWARD -i wg0 -o eth0 -j ACCEPT
+COMMIT
+*nat
+:MYVPN-POSTROUTING - [0:0]
+-A MYVPN-POSTROUTING -s 10.0.0.0/24 -o eth0 -j MASQUERADE
+COMMIT
+`
+		assert.Equal(t, expected, manager.GenerateConfig(config))
+	})
+
+	t.Run("should only forward allowed ports when restricted", func(t *testing.T) {
+		config := &VPNConfig{
+			Interface:         "wg0",
+			VPNNetwork:        "10.0.0.0/24",
+			ExternalInterface: "eth0",
+			AllowedPorts:      []int{80, 443},
+		}
+
+		rules := manager.GenerateConfig(config)
+		assert.Contains(t, rules, "-A MYVPN-FORWARD -i wg0 -o eth0 -p tcp -m multiport --dports 80,443 -j ACCEPT\n")
+		assert.Contains(t, rules, "-A MYVPN-FORWARD -s 10.0.0.0/24 -j DROP\n")
+		assert.NotContains(t, rules, "-A MYVPN-FORWARD -i wg0 -o eth0 -j ACCEPT")
+		assert.NotContains(t, rules, "--dport 51820")
+	})
+}
+
+func TestIptablesManager_WriteConfig(t *testing.T) {
+	rulesPath := filepath.Join(t.TempDir(), "rules", "vpn.rules")
+	manager := NewIptablesManagerWithConfig(rulesPath)
+
+	t.Run("should write rules file", func(t *testing.T) {
+		config := &VPNConfig{Interface: "wg0", VPNNetwork: "10.0.0.0/24", ExternalInterface: "eth0"}
+
+		require.NoError(t, manager.WriteConfig(config))
+		content, err := os.ReadFile(rulesPath)
+		require.NoError(t, err)
+		assert.Equal(t, manager.GenerateConfig(config), string(content))
+	})
+
+	t.Run("should reject invalid config", func(t *testing.T) {
+		err := manager.WriteConfig(&VPNConfig{Interface: "wg0", VPNNetwork: "invalid", ExternalInterface: "eth0"})
+		assert.Error(t, err)
+	})
+}
+
+func TestIptablesManager_Commands(t *testing.T) {
+	missing := errors.New("exit status 1")
+	newManager := func() (*IptablesManager, *MockRunner) {
+		runner := NewMockRunner()
+		return NewIptablesManagerWithRunner("/tmp/vpn.rules", runner), runner
+	}
+
+	t.Run("should load chains and insert missing jumps", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables -t filter -C", "iptables: Bad rule (does a matching rule exist in that chain?).", missing)
+		runner.On("iptables -t nat -C", "", nil)
+
+		require.NoError(t, manager.EnableRules())
+		assert.Equal(t, []string{
+			"iptables-restore --noflush /tmp/vpn.rules",
+			"iptables -t filter -C INPUT -j MYVPN-INPUT",
+			"iptables -t filter -I INPUT -j MYVPN-INPUT",
+			"iptables -t filter -C FORWARD -j MYVPN-FORWARD",
+			"iptables -t filter -I FORWARD -j MYVPN-FORWARD",
+			"iptables -t nat -C POSTROUTING -j MYVPN-POSTROUTING",
+		}, runner.Commands())
+	})
+
+	t.Run("should fail when rules cannot be loaded", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables-restore", "iptables-restore: line 3 failed", missing)
+
+		err := manager.EnableRules()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load iptables rules")
+		assert.Len(t, runner.Commands(), 1)
+	})
+
+	t.Run("should remove jumps and flush chains", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables -t nat -D", "iptables: No chain/target/match by that name.", missing)
+
+		require.NoError(t, manager.DisableRules())
+		assert.Equal(t, []string{
+			"iptables -t filter -D INPUT -j MYVPN-INPUT",
+			"iptables -t filter -F MYVPN-INPUT",
+			"iptables -t filter -D FORWARD -j MYVPN-FORWARD",
+			"iptables -t filter -F MYVPN-FORWARD",
+			"iptables -t nat -D POSTROUTING -j MYVPN-POSTROUTING",
+			"iptables -t nat -F MYVPN-POSTROUTING",
+		}, runner.Commands())
+	})
+
+	t.Run("should report enabled state from forward jump", func(t *testing.T) {
+		manager, runner := newManager()
+
+		enabled, err := manager.IsEnabled()
+		require.NoError(t, err)
+		assert.True(t, enabled)
+
+		runner.On("iptables -C FORWARD", "iptables: Bad rule (does a matching rule exist in that chain?).", missing)
+		enabled, err = manager.IsEnabled()
+		require.NoError(t, err)
+		assert.False(t, enabled)
+
+		runner.On("iptables -C FORWARD", "iptables: unexpected failure", missing)
+		_, err = manager.IsEnabled()
+		assert.Error(t, err)
+	})
+
+	t.Run("should list rules of the VPN chains", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables -t filter -S MYVPN-INPUT", "-N MYVPN-INPUT\n-A MYVPN-INPUT -i eth0 -p udp -m udp --dport 51820 -j ACCEPT\n", nil)
+		runner.On("iptables -t filter -S MYVPN-FORWARD", "-N MYVPN-FORWARD\n-A MYVPN-FORWARD -s 10.0.0.0/24 -j DROP\n", nil)
+		runner.On("iptables -t nat -S", "iptables: No chain/target/match by that name.", missing)
+
+		rules, err := manager.GetActiveRules()
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, "pass", rules[0].Action)
+		assert.Equal(t, "block", rules[1].Action)
+		assert.Equal(t, 1, rules[1].ID)
+	})
+
+	t.Run("should count packets dropped by the VPN filter chains", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables -t filter -L MYVPN-INPUT", "Chain MYVPN-INPUT (1 references)\n"+
+			"    pkts      bytes target     prot opt in     out     source               destination\n"+
+			"     120     7200 ACCEPT     udp  --  eth0   *       0.0.0.0/0            0.0.0.0/0            udp dpt:51820\n"+
+			"       3      180 REJECT     tcp  --  *      *       0.0.0.0/0            0.0.0.0/0\n", nil)
+		runner.On("iptables -t filter -L MYVPN-FORWARD", "Chain MYVPN-FORWARD (1 references)\n"+
+			"    pkts      bytes target     prot opt in     out     source               destination\n"+
+			"      12      720 DROP       all  --  *      *       10.0.0.0/24          0.0.0.0/0\n", nil)
+
+		blocked, err := manager.GetBlockedCount()
+		require.NoError(t, err)
+		assert.Equal(t, 15, blocked)
+		assert.Equal(t, []string{
+			"iptables -t filter -L MYVPN-INPUT -v -x -n",
+			"iptables -t filter -L MYVPN-FORWARD -v -x -n",
+		}, runner.Commands())
+	})
+
+	t.Run("should count nothing when the VPN chains are missing", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("iptables -t filter -L", "iptables: No chain/target/match by that name.", missing)
+
+		blocked, err := manager.GetBlockedCount()
+		require.NoError(t, err)
+		assert.Zero(t, blocked)
+	})
+}
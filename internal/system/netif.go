@@ -0,0 +1,99 @@
+// Package system provides system-level integration for macOS firewall management.
+// It handles pfctl (Packet Filter) configuration for WireGuard VPN traffic routing,
+// NAT rules, and firewall management specific to macOS environments.
+package system
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// commonExternalInterfaces are tried in order when the default route cannot be read.
+var commonExternalInterfaces = []string{"en0", "en1", "eth0", "ens5", "wlan0"}
+
+// externalInterfaceCache holds the last successfully detected external interface.
+var externalInterfaceCache struct {
+	mutex sync.Mutex
+	name  string
+}
+
+// GetExternalInterface attempts to detect the default external interface.
+// It reads the default route with `route get default` on macOS and
+// `ip route show default` on Linux, falling back to common interface names.
+func GetExternalInterface() (string, error) {
+	if runtime.GOOS == "linux" {
+		if output, err := DefaultRunner.Run("ip", "route", "show", "default"); err == nil {
+			if iface := ParseIPRouteInterface(string(output)); iface != "" {
+				return iface, nil
+			}
+		}
+	} else {
+		if output, err := DefaultRunner.Run("route", "get", "default"); err == nil {
+			if iface := ParseRouteGetInterface(string(output)); iface != "" {
+				return iface, nil
+			}
+		}
+	}
+
+	// Fallback to common interface names
+	for _, iface := range commonExternalInterfaces {
+		if _, err := net.InterfaceByName(iface); err == nil {
+			return iface, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not detect external interface")
+}
+
+// DetectExternalInterface returns the external interface, detecting it with
+// GetExternalInterface on first use. Successful detections are cached for the
+// lifetime of the process; failures are retried on the next call.
+func DetectExternalInterface() (string, error) {
+	externalInterfaceCache.mutex.Lock()
+	defer externalInterfaceCache.mutex.Unlock()
+
+	if externalInterfaceCache.name != "" {
+		return externalInterfaceCache.name, nil
+	}
+
+	iface, err := GetExternalInterface()
+	if err != nil {
+		return "", err
+	}
+	externalInterfaceCache.name = iface
+	return iface, nil
+}
+
+// ParseRouteGetInterface extracts the interface from macOS `route get default`
+// output, e.g. "  interface: en0". Returns an empty string if none is listed.
+func ParseRouteGetInterface(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if found && key == "interface" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// ParseIPRouteInterface extracts the interface from Linux `ip route show default`
+// output, e.g. "default via 192.0.2.1 dev eth0 proto dhcp metric 100".
+// With several default routes the first one listed wins.
+// Returns an empty string if no default route names a device.
+func ParseIPRouteInterface(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "default" {
+			continue
+		}
+		for i := 1; i < len(fields)-1; i++ {
+			if fields[i] == "dev" {
+				return fields[i+1]
+			}
+		}
+	}
+	return ""
+}
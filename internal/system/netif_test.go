@@ -0,0 +1,47 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRouteGetInterface(t *testing.T) {
+	t.Run("should extract interface from route get output", func(t *testing.T) {
+		output := `   route to: default
+destination: default
+       mask: default
+    gateway: 192.168.1.1
+  interface: en0
+      flags: <UP,GATEWAY,DONE,STATIC,PRCLONING>
+ recvpipe  sendpipe  ssthresh  rtt,msec    rttvar  hopcount      mtu     expire
+       0         0         0         0         0         0      1500         0
+`
+		assert.Equal(t, "en0", ParseRouteGetInterface(output))
+	})
+
+	t.Run("should return empty string without interface line", func(t *testing.T) {
+		assert.Empty(t, ParseRouteGetInterface("route: writing to routing socket: not in table\n"))
+	})
+}
+
+func TestParseIPRouteInterface(t *testing.T) {
+	t.Run("should extract device from default route", func(t *testing.T) {
+		output := "default via 172.31.0.1 dev ens5 proto dhcp src 172.31.10.20 metric 100\n"
+		assert.Equal(t, "ens5", ParseIPRouteInterface(output))
+	})
+
+	t.Run("should use the first of several default routes", func(t *testing.T) {
+		output := "default via 192.0.2.1 dev eth0 metric 100\ndefault via 198.51.100.1 dev wlan0 metric 600\n"
+		assert.Equal(t, "eth0", ParseIPRouteInterface(output))
+	})
+
+	t.Run("should ignore non-default routes", func(t *testing.T) {
+		output := "10.0.0.0/24 dev wg0 proto kernel scope link src 10.0.0.1\n"
+		assert.Empty(t, ParseIPRouteInterface(output))
+	})
+
+	t.Run("should return empty string for empty output", func(t *testing.T) {
+		assert.Empty(t, ParseIPRouteInterface(""))
+	})
+}
@@ -4,11 +4,12 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +21,7 @@ import (
 type PfctlManager struct {
 	configPath    string // Path to the main pfctl configuration file
 	vpnConfigPath string // Path to the VPN-specific pfctl configuration file
+	runner        CommandRunner // Runs pfctl and dnctl
 }
 
 // VPNConfig represents the network configuration parameters for pfctl rule generation.
@@ -31,6 +33,28 @@ type VPNConfig struct {
 	ExternalInterface string `json:"external_interface"`  // External network interface (e.g., "en0")
 	ListenPort        int    `json:"listen_port,omitempty"` // WireGuard listen port (optional)
 	AllowedPorts      []int  `json:"allowed_ports,omitempty"` // Additional allowed ports (optional)
+	ClientRateLimits  []ClientRateLimit `json:"client_rate_limits,omitempty"` // Per-client bandwidth limits (optional)
+}
+
+// ClientRateLimit limits the bandwidth of a single VPN client.
+// The limit is enforced with dummynet pipes matched on the client's VPN IP,
+// one for upload and one for download.
+type ClientRateLimit struct {
+	IPAddress string `json:"ip_address"` // Client VPN IP address
+	Mbps      int    `json:"mbps"`       // Bandwidth limit in megabits per second
+}
+
+// ErrRateLimitUnsupported is returned when per-client rate limiting is not
+// available on the current platform.
+var ErrRateLimitUnsupported = errors.New("per-client rate limiting requires pf with dummynet and is only supported on macOS")
+
+// CheckRateLimitSupport reports whether per-client rate limiting can be
+// enforced on this platform. Returns ErrRateLimitUnsupported otherwise.
+func CheckRateLimitSupport() error {
+	if runtime.GOOS != "darwin" {
+		return ErrRateLimitUnsupported
+	}
+	return nil
 }
 
 // PfctlStatus represents the current status of the pfctl firewall system.
@@ -49,17 +73,21 @@ type PfctlRule struct {
 
 // NewPfctlManager creates a new pfctl manager with default configuration
 func NewPfctlManager() *PfctlManager {
-	return &PfctlManager{
-		configPath:    "/etc/pf.conf",
-		vpnConfigPath: "/tmp/pf_vpn.conf",
-	}
+	return NewPfctlManagerWithConfig("/etc/pf.conf", "/tmp/pf_vpn.conf")
 }
 
 // NewPfctlManagerWithConfig creates a new pfctl manager with custom configuration
 func NewPfctlManagerWithConfig(configPath, vpnConfigPath string) *PfctlManager {
+	return NewPfctlManagerWithRunner(configPath, vpnConfigPath, DefaultRunner)
+}
+
+// NewPfctlManagerWithRunner creates a new pfctl manager that runs pfctl and
+// dnctl through the given runner, e.g. a MockRunner in tests
+func NewPfctlManagerWithRunner(configPath, vpnConfigPath string, runner CommandRunner) *PfctlManager {
 	return &PfctlManager{
 		configPath:    configPath,
 		vpnConfigPath: vpnConfigPath,
+		runner:        runner,
 	}
 }
 
@@ -95,6 +123,18 @@ func (pm *PfctlManager) GenerateConfig(config *VPNConfig) string {
 			strings.Join(portList, " ")))
 	}
 	
+	// Per-client rate limits
+	if len(config.ClientRateLimits) > 0 {
+		pfConfig.WriteString("\n# Per-client rate limits\n")
+		for i, limit := range config.ClientRateLimits {
+			uploadPipe, downloadPipe := rateLimitPipes(i)
+			pfConfig.WriteString(fmt.Sprintf("dummynet in quick on %s from %s to any pipe %d\n",
+				config.Interface, limit.IPAddress, uploadPipe))
+			pfConfig.WriteString(fmt.Sprintf("dummynet out quick on %s from any to %s pipe %d\n",
+				config.Interface, limit.IPAddress, downloadPipe))
+		}
+	}
+	
 	// Block all other traffic from VPN network to prevent leaks
 	pfConfig.WriteString(fmt.Sprintf("\n# Security rules\nblock out from %s to any\n", config.VPNNetwork))
 	pfConfig.WriteString(fmt.Sprintf("pass out from %s to %s\n", config.VPNNetwork, config.VPNNetwork))
@@ -102,6 +142,46 @@ func (pm *PfctlManager) GenerateConfig(config *VPNConfig) string {
 	return pfConfig.String()
 }
 
+// GeneratePipeCommands generates the dnctl commands configuring the dummynet
+// pipes referenced by the per-client rate limit rules of GenerateConfig
+func (pm *PfctlManager) GeneratePipeCommands(config *VPNConfig) [][]string {
+	var commands [][]string
+	for i, limit := range config.ClientRateLimits {
+		bandwidth := fmt.Sprintf("%dMbit/s", limit.Mbps)
+		uploadPipe, downloadPipe := rateLimitPipes(i)
+		commands = append(commands,
+			[]string{"dnctl", "pipe", strconv.Itoa(uploadPipe), "config", "bw", bandwidth},
+			[]string{"dnctl", "pipe", strconv.Itoa(downloadPipe), "config", "bw", bandwidth},
+		)
+	}
+	return commands
+}
+
+// ApplyRateLimits configures the dummynet pipes for the per-client rate limits.
+// Returns ErrRateLimitUnsupported on platforms without dummynet.
+func (pm *PfctlManager) ApplyRateLimits(config *VPNConfig) error {
+	if len(config.ClientRateLimits) == 0 {
+		return nil
+	}
+	if err := CheckRateLimitSupport(); err != nil {
+		return err
+	}
+	
+	for _, args := range pm.GeneratePipeCommands(config) {
+		output, err := pm.runner.Run(args[0], args[1:]...)
+		if err != nil {
+			return fmt.Errorf("failed to configure dummynet pipe: %w, output: %s", err, string(output))
+		}
+	}
+	
+	return nil
+}
+
+// rateLimitPipes returns the upload and download pipe numbers of the i-th rate limit
+func rateLimitPipes(i int) (int, int) {
+	return 2*i + 1, 2*i + 2
+}
+
 // WriteConfig writes the VPN configuration to file
 func (pm *PfctlManager) WriteConfig(config *VPNConfig) error {
 	if err := config.Validate(); err != nil {
@@ -128,15 +208,13 @@ func (pm *PfctlManager) WriteConfig(config *VPNConfig) error {
 // EnableRules enables the pfctl rules
 func (pm *PfctlManager) EnableRules() error {
 	// Load the VPN rules
-	cmd := exec.Command("pfctl", "-f", pm.vpnConfigPath)
-	output, err := cmd.CombinedOutput()
+	output, err := pm.runner.Run("pfctl", "-f", pm.vpnConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to load pfctl rules: %w, output: %s", err, string(output))
 	}
 	
 	// Enable pfctl
-	cmd = exec.Command("pfctl", "-e")
-	output, err = cmd.CombinedOutput()
+	output, err = pm.runner.Run("pfctl", "-e")
 	if err != nil {
 		return fmt.Errorf("failed to enable pfctl rules: %w, output: %s", err, string(output))
 	}
@@ -147,8 +225,7 @@ func (pm *PfctlManager) EnableRules() error {
 // DisableRules disables the pfctl rules
 func (pm *PfctlManager) DisableRules() error {
 	// Disable pfctl
-	cmd := exec.Command("pfctl", "-d")
-	output, err := cmd.CombinedOutput()
+	output, err := pm.runner.Run("pfctl", "-d")
 	if err != nil {
 		return fmt.Errorf("failed to disable pfctl: %w, output: %s", err, string(output))
 	}
@@ -158,8 +235,7 @@ func (pm *PfctlManager) DisableRules() error {
 
 // IsEnabled checks if pfctl is currently enabled
 func (pm *PfctlManager) IsEnabled() (bool, error) {
-	cmd := exec.Command("pfctl", "-s", "info")
-	output, err := cmd.CombinedOutput()
+	output, err := pm.runner.Run("pfctl", "-s", "info")
 	outputStr := string(output)
 	
 	if err != nil {
@@ -208,8 +284,7 @@ func (pm *PfctlManager) GetStatus() (*PfctlStatus, error) {
 
 // GetActiveRules returns the currently active pfctl rules
 func (pm *PfctlManager) GetActiveRules() ([]PfctlRule, error) {
-	cmd := exec.Command("pfctl", "-s", "rules")
-	output, err := cmd.CombinedOutput()
+	output, err := pm.runner.Run("pfctl", "-s", "rules")
 	outputStr := string(output)
 	
 	if err != nil {
@@ -252,6 +327,54 @@ func (pm *PfctlManager) GetActiveRules() ([]PfctlRule, error) {
 	return rules, nil
 }
 
+// GetBlockedCount returns the number of packets matched by the active block
+// rules, read from the rule counters of pfctl -v -s rules
+func (pm *PfctlManager) GetBlockedCount() (int, error) {
+	output, err := pm.runner.Run("pfctl", "-v", "-s", "rules")
+	outputStr := string(output)
+	
+	if err != nil {
+		// If pfctl is disabled or no permission, nothing has been blocked
+		if strings.Contains(outputStr, "pf not enabled") ||
+		   strings.Contains(outputStr, "Permission denied") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get pfctl rule counters: %w", err)
+	}
+	
+	// Each rule is followed by indented counter lines such as
+	// "[ Evaluations: 10 Packets: 4 Bytes: 240 States: 0 ]"
+	blocked := 0
+	inBlockRule := false
+	for _, line := range strings.Split(outputStr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") {
+			inBlockRule = strings.HasPrefix(line, "block")
+			continue
+		}
+		if !inBlockRule {
+			continue
+		}
+		
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields)-1; i++ {
+			if fields[i] != "Packets:" {
+				continue
+			}
+			packets, err := strconv.Atoi(fields[i+1])
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse pfctl rule counters: %w", err)
+			}
+			blocked += packets
+		}
+	}
+	
+	return blocked, nil
+}
+
 // CreateBackup creates a backup of the current pfctl configuration
 func (pm *PfctlManager) CreateBackup(backupPath string) error {
 	// Ensure backup directory exists
@@ -296,8 +419,7 @@ func (pm *PfctlManager) RestoreFromBackup(backupPath string) error {
 	}
 	
 	// Reload pfctl configuration
-	cmd := exec.Command("pfctl", "-f", pm.configPath)
-	output, err := cmd.CombinedOutput()
+	output, err := pm.runner.Run("pfctl", "-f", pm.configPath)
 	if err != nil {
 		return fmt.Errorf("failed to reload pfctl configuration: %w, output: %s", err, string(output))
 	}
@@ -333,35 +455,15 @@ func (config *VPNConfig) Validate() error {
 		}
 	}
 	
-	return nil
-}
-
-// GetExternalInterface attempts to detect the default external interface
-func GetExternalInterface() (string, error) {
-	// Try to find the default route interface
-	cmd := exec.Command("route", "get", "default")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("failed to get default route: %w", err)
-	}
-	
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "interface:") {
-			parts := strings.Split(line, ":")
-			if len(parts) > 1 {
-				return strings.TrimSpace(parts[1]), nil
-			}
+	// Validate client rate limits
+	for _, limit := range config.ClientRateLimits {
+		if net.ParseIP(limit.IPAddress) == nil {
+			return fmt.Errorf("invalid rate limit IP address %q", limit.IPAddress)
 		}
-	}
-	
-	// Fallback to common interface names
-	commonInterfaces := []string{"en0", "en1", "eth0", "wlan0"}
-	for _, iface := range commonInterfaces {
-		if _, err := net.InterfaceByName(iface); err == nil {
-			return iface, nil
+		if limit.Mbps < 1 {
+			return fmt.Errorf("invalid rate limit for %s: must be at least 1 Mbps", limit.IPAddress)
 		}
 	}
 	
-	return "", fmt.Errorf("could not detect external interface")
-}
\ No newline at end of file
+	return nil
+}
@@ -1,8 +1,10 @@
 package system
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -63,6 +65,58 @@ func TestPfctlManager_GenerateConfig(t *testing.T) {
 		assert.Contains(t, pfConfig, "pass in on en0 proto udp to port 51820")
 		assert.Contains(t, pfConfig, "pass out proto tcp to port { 80 443 22 }")
 	})
+
+	t.Run("should not include shaping rules without rate limits", func(t *testing.T) {
+		config := &VPNConfig{
+			Interface:         "wg0",
+			VPNNetwork:        "10.0.0.0/24",
+			ExternalInterface: "en0",
+		}
+		
+		pfConfig := manager.GenerateConfig(config)
+		
+		assert.NotContains(t, pfConfig, "dummynet")
+		assert.Empty(t, manager.GeneratePipeCommands(config))
+	})
+
+	t.Run("should include shaping rules keyed on client IP", func(t *testing.T) {
+		config := &VPNConfig{
+			Interface:         "wg0",
+			VPNNetwork:        "10.0.0.0/24",
+			ExternalInterface: "en0",
+			ClientRateLimits: []ClientRateLimit{
+				{IPAddress: "10.0.0.2", Mbps: 10},
+				{IPAddress: "10.0.0.3", Mbps: 50},
+			},
+		}
+		
+		pfConfig := manager.GenerateConfig(config)
+		
+		assert.Contains(t, pfConfig, "# Per-client rate limits")
+		assert.Contains(t, pfConfig, "dummynet in quick on wg0 from 10.0.0.2 to any pipe 1")
+		assert.Contains(t, pfConfig, "dummynet out quick on wg0 from any to 10.0.0.2 pipe 2")
+		assert.Contains(t, pfConfig, "dummynet in quick on wg0 from 10.0.0.3 to any pipe 3")
+		assert.Contains(t, pfConfig, "dummynet out quick on wg0 from any to 10.0.0.3 pipe 4")
+		
+		assert.Equal(t, [][]string{
+			{"dnctl", "pipe", "1", "config", "bw", "10Mbit/s"},
+			{"dnctl", "pipe", "2", "config", "bw", "10Mbit/s"},
+			{"dnctl", "pipe", "3", "config", "bw", "50Mbit/s"},
+			{"dnctl", "pipe", "4", "config", "bw", "50Mbit/s"},
+		}, manager.GeneratePipeCommands(config))
+	})
+}
+
+func TestCheckRateLimitSupport(t *testing.T) {
+	t.Run("should only support rate limiting on macOS", func(t *testing.T) {
+		err := CheckRateLimitSupport()
+		
+		if runtime.GOOS == "darwin" {
+			assert.NoError(t, err)
+		} else {
+			assert.ErrorIs(t, err, ErrRateLimitUnsupported)
+		}
+	})
 }
 
 func TestPfctlManager_WriteConfig(t *testing.T) {
@@ -208,6 +262,97 @@ func TestPfctlManager_GetActiveRules(t *testing.T) {
 	})
 }
 
+func TestPfctlManager_Commands(t *testing.T) {
+	newManager := func() (*PfctlManager, *MockRunner) {
+		runner := NewMockRunner()
+		return NewPfctlManagerWithRunner("/etc/pf.conf", "/tmp/pf_vpn.conf", runner), runner
+	}
+
+	t.Run("should load VPN rules before enabling pf", func(t *testing.T) {
+		manager, runner := newManager()
+
+		require.NoError(t, manager.EnableRules())
+		assert.Equal(t, []string{"pfctl -f /tmp/pf_vpn.conf", "pfctl -e"}, runner.Commands())
+	})
+
+	t.Run("should not enable pf when loading rules fails", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("pfctl -f", "syntax error", errors.New("exit status 1"))
+
+		err := manager.EnableRules()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load pfctl rules")
+		assert.Equal(t, []string{"pfctl -f /tmp/pf_vpn.conf"}, runner.Commands())
+	})
+
+	t.Run("should disable pf", func(t *testing.T) {
+		manager, runner := newManager()
+
+		require.NoError(t, manager.DisableRules())
+		assert.Equal(t, []string{"pfctl -d"}, runner.Commands())
+	})
+
+	t.Run("should report status and rule count", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("pfctl -s info", "Status: Enabled for 0 days 01:02:03", nil)
+		runner.On("pfctl -s rules", "nat on en0 from 10.0.0.0/24 to any -> (en0)\npass in on wg0 all\n", nil)
+
+		status, err := manager.GetStatus()
+		require.NoError(t, err)
+		assert.Equal(t, "enabled", status.State)
+		assert.Equal(t, 2, status.RuleCount)
+		assert.Equal(t, []string{"pfctl -s info", "pfctl -s rules"}, runner.Commands())
+	})
+
+	t.Run("should treat disabled pf as not enabled", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("pfctl -s info", "pfctl: pf not enabled", errors.New("exit status 1"))
+
+		enabled, err := manager.IsEnabled()
+		require.NoError(t, err)
+		assert.False(t, enabled)
+	})
+
+	t.Run("should count packets matched by block rules", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("pfctl -v -s rules", "block drop in on en0 proto tcp from any to any port 22\n"+
+			"  [ Evaluations: 500       Packets: 42        Bytes: 2520        States: 0     ]\n"+
+			"  [ Inserted: uid 0 pid 1 State Creations: 0     ]\n"+
+			"pass in on wg0 all flags S/SA keep state\n"+
+			"  [ Evaluations: 900       Packets: 800       Bytes: 96000       States: 3     ]\n"+
+			"block return out quick on en0 from 10.0.0.0/24 to any\n"+
+			"  [ Evaluations: 10        Packets: 8         Bytes: 480         States: 0     ]\n", nil)
+
+		blocked, err := manager.GetBlockedCount()
+		require.NoError(t, err)
+		assert.Equal(t, 50, blocked)
+	})
+
+	t.Run("should count nothing while pf is disabled", func(t *testing.T) {
+		manager, runner := newManager()
+		runner.On("pfctl -v -s rules", "pfctl: pf not enabled", errors.New("exit status 1"))
+
+		blocked, err := manager.GetBlockedCount()
+		require.NoError(t, err)
+		assert.Zero(t, blocked)
+	})
+
+	t.Run("should configure one dummynet pipe per direction", func(t *testing.T) {
+		if runtime.GOOS != "darwin" {
+			t.Skip("dummynet is only available on macOS")
+		}
+		manager, runner := newManager()
+
+		require.NoError(t, manager.ApplyRateLimits(&VPNConfig{
+			ClientRateLimits: []ClientRateLimit{{IPAddress: "10.0.0.2", Mbps: 10}},
+		}))
+		assert.Equal(t, []string{
+			"dnctl pipe 1 config bw 10Mbit/s",
+			"dnctl pipe 2 config bw 10Mbit/s",
+		}, runner.Commands())
+	})
+}
+
 func TestVPNConfig_Validate(t *testing.T) {
 	t.Run("should validate valid config", func(t *testing.T) {
 		config := &VPNConfig{
@@ -270,6 +415,19 @@ func TestVPNConfig_Validate(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid allowed port")
 	})
+
+	t.Run("should fail with invalid rate limit", func(t *testing.T) {
+		config := &VPNConfig{
+			Interface:         "wg0",
+			VPNNetwork:        "10.0.0.0/24",
+			ExternalInterface: "en0",
+			ClientRateLimits:  []ClientRateLimit{{IPAddress: "10.0.0.2", Mbps: 0}},
+		}
+		
+		err := config.Validate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid rate limit")
+	})
 }
 
 func TestPfctlManager_BackupRestore(t *testing.T) {
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// QRCodeCache is a least recently used cache of generated QR codes, keyed by a
+// hash of the encoded content and the options that affect the output. Entries
+// can be tagged with an owner (e.g. a client ID) so all codes of that owner
+// can be dropped at once when its content changes. It is safe for concurrent use.
+type QRCodeCache struct {
+	mutex    sync.Mutex
+	capacity int                      // Maximum number of cached QR codes
+	order    *list.List               // Entries from most to least recently used
+	entries  map[string]*list.Element // Entries by key
+	hits     uint64                   // Lookups answered from the cache
+	misses   uint64                   // Lookups that generated a QR code
+}
+
+// qrCodeCacheEntry is a cached QR code.
+type qrCodeCacheEntry struct {
+	key   string      // Cache key from QRCodeCacheKey
+	owner string      // Owner the entry is invalidated with (may be empty)
+	value interface{} // Generated QR code ([]byte for PNG, string otherwise)
+}
+
+// NewQRCodeCache creates a QR code cache holding up to capacity entries.
+// A capacity below 1 is treated as 1.
+// Returns a pointer to the newly created QRCodeCache.
+func NewQRCodeCache(capacity int) *QRCodeCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &QRCodeCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// QRCodeCacheKey returns the cache key for content rendered with options:
+// a SHA-256 hash of the content, size, format, recovery level and logo settings.
+// The content itself is not kept in the key since configs contain private keys.
+func QRCodeCacheKey(content string, options QRCodeOptions) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\x00%s\x00%d\x00%g\x00%s\x00", options.Size, options.Format, options.RecoveryLevel,
+		options.LogoRatio, options.LogoPath)
+	hash.Write(options.Logo)
+	hash.Write([]byte{0})
+	hash.Write([]byte(content))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns the cached QR code for key and marks it as recently used.
+// Returns the QR code and true on a hit, or nil and false on a miss.
+func (c *QRCodeCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*qrCodeCacheEntry).value, true
+}
+
+// Add caches a QR code under key for owner, evicting the least recently
+// used entry if the cache is full.
+func (c *QRCodeCache) Add(key, owner string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*qrCodeCacheEntry)
+		entry.owner = owner
+		entry.value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&qrCodeCacheEntry{key: key, owner: owner, value: value})
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// RemoveOwner drops all QR codes cached for owner, e.g. after the keys or IP
+// address of a client changed.
+// Returns the number of removed entries.
+func (c *QRCodeCache) RemoveOwner(owner string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := 0
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*qrCodeCacheEntry).owner == owner {
+			c.removeElement(element)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
+
+// Len returns the number of cached QR codes.
+func (c *QRCodeCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.order.Len()
+}
+
+// Stats returns the number of cache hits and misses so far.
+func (c *QRCodeCache) Stats() (hits, misses uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.hits, c.misses
+}
+
+// GenerateWireGuardConfigQR returns the cached QR code for the configuration
+// and options, generating and caching it for owner with
+// GenerateWireGuardConfigQR on a miss.
+// Returns the QR code or an error if generation fails; failures are not cached.
+func (c *QRCodeCache) GenerateWireGuardConfigQR(owner, config string, options QRCodeOptions) (interface{}, error) {
+	key := QRCodeCacheKey(config, options)
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := GenerateWireGuardConfigQR(config, options)
+	if err != nil {
+		return nil, err
+	}
+	c.Add(key, owner, value)
+	return value, nil
+}
+
+// removeElement drops an entry. The caller must hold the mutex.
+func (c *QRCodeCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*qrCodeCacheEntry).key)
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRCodeCacheKey(t *testing.T) {
+	options := QRCodeOptions{Size: 256, RecoveryLevel: qrcode.Medium, Format: "png"}
+
+	t.Run("should be stable for the same input", func(t *testing.T) {
+		assert.Equal(t, QRCodeCacheKey(sampleWireGuardConfig, options), QRCodeCacheKey(sampleWireGuardConfig, options))
+	})
+
+	t.Run("should differ when content or options change", func(t *testing.T) {
+		key := QRCodeCacheKey(sampleWireGuardConfig, options)
+
+		changed := []QRCodeOptions{
+			{Size: 512, RecoveryLevel: qrcode.Medium, Format: "png"},
+			{Size: 256, RecoveryLevel: qrcode.High, Format: "png"},
+			{Size: 256, RecoveryLevel: qrcode.Medium, Format: "base64"},
+		}
+		for _, other := range changed {
+			assert.NotEqual(t, key, QRCodeCacheKey(sampleWireGuardConfig, other))
+		}
+		assert.NotEqual(t, key, QRCodeCacheKey(sampleWireGuardConfig+"\n", options))
+	})
+
+	t.Run("should not contain the content", func(t *testing.T) {
+		key := QRCodeCacheKey(sampleWireGuardConfig, options)
+		assert.False(t, strings.Contains(key, "PrivateKey"))
+		assert.Len(t, key, 64)
+	})
+}
+
+func TestQRCodeCache(t *testing.T) {
+	t.Run("should return cached values and count hits", func(t *testing.T) {
+		cache := NewQRCodeCache(2)
+
+		_, ok := cache.Get("a")
+		assert.False(t, ok)
+
+		cache.Add("a", "1", []byte("png"))
+		value, ok := cache.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, []byte("png"), value)
+
+		hits, misses := cache.Stats()
+		assert.Equal(t, uint64(1), hits)
+		assert.Equal(t, uint64(1), misses)
+	})
+
+	t.Run("should evict the least recently used entry", func(t *testing.T) {
+		cache := NewQRCodeCache(2)
+		cache.Add("a", "", "A")
+		cache.Add("b", "", "B")
+		cache.Get("a")
+		cache.Add("c", "", "C")
+
+		assert.Equal(t, 2, cache.Len())
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		_, ok = cache.Get("a")
+		assert.True(t, ok)
+		_, ok = cache.Get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("should remove all entries of an owner", func(t *testing.T) {
+		cache := NewQRCodeCache(10)
+		cache.Add("a", "client-1", "A")
+		cache.Add("b", "client-1", "B")
+		cache.Add("c", "client-2", "C")
+
+		assert.Equal(t, 2, cache.RemoveOwner("client-1"))
+		assert.Equal(t, 1, cache.Len())
+		_, ok := cache.Get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		cache := NewQRCodeCache(8)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := fmt.Sprintf("%d-%d", i, j%10)
+					cache.Add(key, fmt.Sprint(i), j)
+					cache.Get(key)
+					if j%25 == 0 {
+						cache.RemoveOwner(fmt.Sprint(i))
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.LessOrEqual(t, cache.Len(), 8)
+	})
+}
+
+func TestQRCodeCache_GenerateWireGuardConfigQR(t *testing.T) {
+	options := QRCodeOptions{Size: 256, RecoveryLevel: qrcode.Medium, Format: "png"}
+
+	t.Run("should return identical bytes on a cache hit", func(t *testing.T) {
+		cache := NewQRCodeCache(4)
+
+		first, err := cache.GenerateWireGuardConfigQR("client-1", sampleWireGuardConfig, options)
+		require.NoError(t, err)
+		second, err := cache.GenerateWireGuardConfigQR("client-1", sampleWireGuardConfig, options)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		hits, misses := cache.Stats()
+		assert.Equal(t, uint64(1), hits)
+		assert.Equal(t, uint64(1), misses)
+	})
+
+	t.Run("should not cache failures", func(t *testing.T) {
+		cache := NewQRCodeCache(4)
+
+		_, err := cache.GenerateWireGuardConfigQR("client-1", "not a config", options)
+		assert.Error(t, err)
+		assert.Equal(t, 0, cache.Len())
+	})
+}
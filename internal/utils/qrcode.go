@@ -7,10 +7,24 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // Register JPEG decoding for logos
+	"image/png"
+	"os"
 
 	"github.com/skip2/go-qrcode"
 )
 
+// Logo overlay sizes, as a fraction of the QR code width. High error correction
+// recovers about 30% of damaged codewords, so a centered logo covering at most
+// a quarter of the width (about 6% of the area) keeps the code scannable.
+const (
+	defaultLogoRatio = 0.2
+	maxLogoRatio     = 0.25
+)
+
 // QRCodeGenerator provides functionality to generate QR codes for VPN client configurations.
 // It supports generating QR codes in different formats and sizes for easy sharing
 // of WireGuard client configurations via mobile devices and QR code scanners.
@@ -19,6 +33,12 @@ type QRCodeGenerator struct {
 	Size int
 	// RecoveryLevel determines the error correction level for the QR code
 	RecoveryLevel qrcode.RecoveryLevel
+	// Logo is an optional PNG or JPEG image composited onto the center of PNG output
+	Logo []byte
+	// LogoPath is read for the logo when Logo is empty
+	LogoPath string
+	// LogoRatio is the logo width as a fraction of the QR code width (0 uses 0.2)
+	LogoRatio float64
 }
 
 // QRCodeOptions represents configuration options for QR code generation.
@@ -26,6 +46,9 @@ type QRCodeOptions struct {
 	Size          int                    `json:"size"`           // QR code size in pixels (default: 256)
 	RecoveryLevel qrcode.RecoveryLevel   `json:"recovery_level"` // Error correction level (default: Medium)
 	Format        string                 `json:"format"`         // Output format: "png", "base64", "terminal"
+	Logo          []byte                 `json:"-"`              // Optional PNG or JPEG logo for the center (requires High recovery)
+	LogoPath      string                 `json:"logo_path"`      // Logo file used when Logo is empty
+	LogoRatio     float64                `json:"logo_ratio"`     // Logo width relative to the QR code (default: 0.2, max: 0.25)
 }
 
 // NewQRCodeGenerator creates a new QR code generator with default settings.
@@ -47,6 +70,9 @@ func NewQRCodeGeneratorWithOptions(options QRCodeOptions) *QRCodeGenerator {
 	generator := &QRCodeGenerator{
 		Size:          options.Size,
 		RecoveryLevel: options.RecoveryLevel,
+		Logo:          options.Logo,
+		LogoPath:      options.LogoPath,
+		LogoRatio:     options.LogoRatio,
 	}
 	
 	// Set defaults if not specified
@@ -60,8 +86,21 @@ func NewQRCodeGeneratorWithOptions(options QRCodeOptions) *QRCodeGenerator {
 // GeneratePNG generates a QR code as PNG image data.
 // It takes the content string (typically a WireGuard configuration) and returns
 // the PNG image data as a byte slice that can be saved to file or served over HTTP.
+// If a logo is configured, it is composited onto the center with GeneratePNGWithLogo.
 // Returns the PNG data or an error if generation fails.
 func (qr *QRCodeGenerator) GeneratePNG(content string) ([]byte, error) {
+	if len(qr.Logo) > 0 || qr.LogoPath != "" {
+		logo := qr.Logo
+		if len(logo) == 0 {
+			data, err := os.ReadFile(qr.LogoPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read logo: %w", err)
+			}
+			logo = data
+		}
+		return qr.GeneratePNGWithLogo(content, logo)
+	}
+
 	pngData, err := qrcode.Encode(content, qr.RecoveryLevel, qr.Size)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate QR code PNG: %w", err)
@@ -69,6 +108,83 @@ func (qr *QRCodeGenerator) GeneratePNG(content string) ([]byte, error) {
 	return pngData, nil
 }
 
+// GeneratePNGWithLogo generates a QR code as PNG image data with the logo
+// (PNG or JPEG data) scaled to LogoRatio of the code's width, keeping its
+// aspect ratio, and composited onto the center over a white backing.
+// The logo hides modules that must be recovered by error correction, so it
+// is only allowed with High or Highest recovery and a ratio of at most 0.25.
+// Returns the PNG data or an error if the logo is not allowed, cannot be
+// decoded or generation fails.
+func (qr *QRCodeGenerator) GeneratePNGWithLogo(content string, logo []byte) ([]byte, error) {
+	if qr.RecoveryLevel < qrcode.High {
+		return nil, fmt.Errorf("logo overlay requires High or Highest recovery level")
+	}
+	ratio := qr.LogoRatio
+	if ratio <= 0 {
+		ratio = defaultLogoRatio
+	}
+	if ratio > maxLogoRatio {
+		return nil, fmt.Errorf("logo ratio %.2f exceeds the safe maximum of %.2f", ratio, maxLogoRatio)
+	}
+
+	logoImage, _, err := image.Decode(bytes.NewReader(logo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+	logoBounds := logoImage.Bounds()
+	if logoBounds.Empty() {
+		return nil, fmt.Errorf("logo image is empty")
+	}
+
+	qrCode, err := qrcode.New(content, qr.RecoveryLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create QR code: %w", err)
+	}
+	code := qrCode.Image(qr.Size)
+	canvas := image.NewRGBA(code.Bounds())
+	draw.Draw(canvas, canvas.Bounds(), code, code.Bounds().Min, draw.Src)
+
+	// Fit the logo into a square of the configured ratio
+	maxSide := int(float64(canvas.Bounds().Dx()) * ratio)
+	width, height := maxSide, maxSide
+	if logoBounds.Dx() > logoBounds.Dy() {
+		height = maxSide * logoBounds.Dy() / logoBounds.Dx()
+	} else {
+		width = maxSide * logoBounds.Dx() / logoBounds.Dy()
+	}
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("QR code is too small for a logo")
+	}
+
+	center := image.Pt(canvas.Bounds().Dx()/2, canvas.Bounds().Dy()/2)
+	target := image.Rect(center.X-width/2, center.Y-height/2, center.X-width/2+width, center.Y-height/2+height)
+
+	// A white margin separates the logo from the surrounding modules
+	margin := maxSide / 10
+	draw.Draw(canvas, target.Inset(-margin), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(canvas, target, scaleImage(logoImage, width, height), image.Point{}, draw.Over)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleImage resizes an image to the given dimensions using nearest-neighbor sampling.
+func scaleImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			dst.Set(x, y, src.At(srcX, srcY))
+		}
+	}
+	return dst
+}
+
 // GenerateBase64 generates a QR code as base64-encoded PNG image.
 // This is useful for embedding QR codes directly in HTML pages or JSON responses
 // without requiring separate image files or endpoints.
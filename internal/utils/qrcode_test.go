@@ -4,6 +4,12 @@
 package utils
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -99,6 +105,104 @@ func TestQRCodeGenerator_GeneratePNG(t *testing.T) {
 	})
 }
 
+// encodeTestLogo returns a solid red PNG of the given size.
+func encodeTestLogo(t *testing.T, width, height int) []byte {
+	logo := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			logo.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, logo))
+	return buf.Bytes()
+}
+
+func TestQRCodeGenerator_GeneratePNGWithLogo(t *testing.T) {
+	generator := NewQRCodeGeneratorWithOptions(QRCodeOptions{Size: 256, RecoveryLevel: qrcode.High})
+	logo := encodeTestLogo(t, 40, 40)
+
+	decode := func(t *testing.T, data []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		return img
+	}
+
+	t.Run("should composite the logo onto the center", func(t *testing.T) {
+		withLogo, err := generator.GeneratePNGWithLogo(sampleWireGuardConfig, logo)
+		require.NoError(t, err)
+		plain, err := generator.GeneratePNG(sampleWireGuardConfig)
+		require.NoError(t, err)
+
+		logoImage := decode(t, withLogo)
+		plainImage := decode(t, plain)
+		assert.Equal(t, plainImage.Bounds(), logoImage.Bounds())
+
+		center := image.Pt(128, 128)
+		r, g, b, _ := logoImage.At(center.X, center.Y).RGBA()
+		assert.Equal(t, [3]uint32{0xffff, 0, 0}, [3]uint32{r, g, b})
+		assert.NotEqual(t, plainImage.At(center.X, center.Y), logoImage.At(center.X, center.Y))
+
+		// The corners with the finder patterns are untouched
+		assert.Equal(t, plainImage.At(20, 20), logoImage.At(20, 20))
+	})
+
+	t.Run("should use the configured logo in GeneratePNG", func(t *testing.T) {
+		logoPath := filepath.Join(t.TempDir(), "logo.png")
+		require.NoError(t, os.WriteFile(logoPath, logo, 0644))
+
+		fromPath := NewQRCodeGeneratorWithOptions(QRCodeOptions{RecoveryLevel: qrcode.High, LogoPath: logoPath})
+		pngData, err := fromPath.GeneratePNG(sampleWireGuardConfig)
+		require.NoError(t, err)
+
+		expected, err := generator.GeneratePNGWithLogo(sampleWireGuardConfig, logo)
+		require.NoError(t, err)
+		assert.Equal(t, expected, pngData)
+	})
+
+	t.Run("should keep the logo aspect ratio", func(t *testing.T) {
+		pngData, err := generator.GeneratePNGWithLogo(sampleWireGuardConfig, encodeTestLogo(t, 80, 20))
+		require.NoError(t, err)
+		img := decode(t, pngData)
+
+		// 20% of 256 is 51 pixels wide and 12 pixels high
+		r, _, _, _ := img.At(128-20, 128).RGBA()
+		assert.Equal(t, uint32(0xffff), r)
+		_, g, _, _ := img.At(128, 128-10).RGBA()
+		assert.Equal(t, uint32(0xffff), g, "pixel above the logo should be in the white margin")
+	})
+
+	t.Run("should require high error correction", func(t *testing.T) {
+		medium := NewQRCodeGeneratorWithOptions(QRCodeOptions{RecoveryLevel: qrcode.Medium})
+
+		_, err := medium.GeneratePNGWithLogo(sampleWireGuardConfig, logo)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "High")
+	})
+
+	t.Run("should reject logos above the safe ratio", func(t *testing.T) {
+		oversized := NewQRCodeGeneratorWithOptions(QRCodeOptions{RecoveryLevel: qrcode.High, LogoRatio: 0.3})
+
+		_, err := oversized.GeneratePNGWithLogo(sampleWireGuardConfig, logo)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds the safe maximum")
+	})
+
+	t.Run("should reject data that is not an image", func(t *testing.T) {
+		_, err := generator.GeneratePNGWithLogo(sampleWireGuardConfig, []byte("not an image"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decode logo")
+	})
+
+	t.Run("should report a missing logo file", func(t *testing.T) {
+		missing := NewQRCodeGeneratorWithOptions(QRCodeOptions{RecoveryLevel: qrcode.High, LogoPath: "/nonexistent/logo.png"})
+
+		_, err := missing.GeneratePNG(sampleWireGuardConfig)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to read logo")
+	})
+}
+
 func TestQRCodeGenerator_GenerateBase64(t *testing.T) {
 	generator := NewQRCodeGenerator()
 	
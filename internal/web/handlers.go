@@ -1,14 +1,41 @@
 package web
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
 	"my-vpn/internal/auth"
 	"my-vpn/internal/monitoring"
 )
 
+// Page sizes for the security feed endpoint.
+const (
+	defaultSecurityFeedPageSize = 50
+	maxSecurityFeedPageSize     = 200
+)
+
+// Settings for the live log stream.
+const (
+	logStreamBufferSize = 256              // Entries buffered per viewer before new ones are dropped
+	logStreamKeepalive  = 15 * time.Second // Interval of keepalive comments on idle streams
+)
+
+// metricsSocketWriteTimeout bounds how long a metrics frame may take to send
+// before the viewer is considered gone.
+const metricsSocketWriteTimeout = 10 * time.Second
+
+// Defaults for the metrics history endpoint.
+const (
+	defaultMetricsHistoryRange = time.Hour   // Range queried when from is omitted
+	defaultMetricsHistoryStep  = time.Minute // Bucket length when step is omitted
+)
+
 // loginPage serves the login page.
 func (s *Server) loginPage(c *gin.Context) {
 	c.HTML(http.StatusOK, "login.html", gin.H{
@@ -31,9 +58,20 @@ func (s *Server) handleLogin(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts while the username or IP is locked out
+	if _, locked := s.loginLimiter.Check(req.Username, c.ClientIP()); locked {
+		c.HTML(http.StatusTooManyRequests, "login.html", gin.H{
+			"title": "VPN Server - Login",
+			"error": "Too many failed login attempts, try again later",
+		})
+		return
+	}
+
 	// Authenticate user
 	user, err := s.db.AuthenticateUser(req.Username, req.Password)
 	if err != nil {
+		s.loginLimiter.RecordFailure(req.Username, c.ClientIP())
+		s.monitor.RecordFailedLogin(req.Username, c.ClientIP())
 		c.HTML(http.StatusUnauthorized, "login.html", gin.H{
 			"title": "VPN Server - Login",
 			"error": "Invalid username or password",
@@ -41,8 +79,8 @@ func (s *Server) handleLogin(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := s.authManager.GenerateToken(user.ID, user.Username)
+	// Generate JWT token and record it as a session
+	token, _, err := s.authAPI.IssueToken(c, user)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "login.html", gin.H{
 			"title": "VPN Server - Login",
@@ -51,8 +89,10 @@ func (s *Server) handleLogin(c *gin.Context) {
 		return
 	}
 
+	s.loginLimiter.Reset(req.Username)
+
 	// Set token as cookie and redirect to dashboard
-	c.SetCookie("auth_token", token, 3600*24, "/", "", false, true)
+	c.SetCookie("auth_token", token, int(s.authManager.GetTokenExpiry().Seconds()), "/", "", false, true)
 	c.Redirect(http.StatusFound, "/dashboard")
 }
 
@@ -68,7 +108,7 @@ func (s *Server) handleRegister(c *gin.Context) {
 	var req struct {
 		Username string `form:"username" json:"username" binding:"required"`
 		Email    string `form:"email" json:"email" binding:"required,email"`
-		Password string `form:"password" json:"password" binding:"required,min=6"`
+		Password string `form:"password" json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBind(&req); err != nil {
@@ -79,8 +119,21 @@ func (s *Server) handleRegister(c *gin.Context) {
 		return
 	}
 
-	// Create user
-	user, err := s.db.CreateUserWithCredentials(req.Username, req.Email, req.Password)
+	if err := s.authManager.ValidatePassword(req.Password); err != nil {
+		message := "Please choose a stronger password"
+		var policyErr *auth.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			message = "Password does not meet the requirements: " + strings.Join(policyErr.Unmet, ", ")
+		}
+		c.HTML(http.StatusBadRequest, "register.html", gin.H{
+			"title": "VPN Server - Register",
+			"error": message,
+		})
+		return
+	}
+
+	// Create user; the first account on a fresh installation becomes the admin
+	user, err := s.db.RegisterUserWithCredentials(req.Username, req.Email, req.Password)
 	if err != nil {
 		c.HTML(http.StatusBadRequest, "register.html", gin.H{
 			"title": "VPN Server - Register",
@@ -88,9 +141,15 @@ func (s *Server) handleRegister(c *gin.Context) {
 		})
 		return
 	}
+	logger := s.monitor.GetLogManager().ForComponent(monitoring.LogComponentAuth)
+	if user.Role == auth.RoleAdmin {
+		logger.Info(fmt.Sprintf("No users existed; registered %q as the initial admin", user.Username))
+	} else {
+		logger.Info(fmt.Sprintf("Registered user %q", user.Username))
+	}
 
-	// Generate JWT token
-	token, err := s.authManager.GenerateToken(user.ID, user.Username)
+	// Generate JWT token and record it as a session
+	token, _, err := s.authAPI.IssueToken(c, user)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "register.html", gin.H{
 			"title": "VPN Server - Register",
@@ -100,7 +159,7 @@ func (s *Server) handleRegister(c *gin.Context) {
 	}
 
 	// Set token as cookie and redirect to dashboard
-	c.SetCookie("auth_token", token, 3600*24, "/", "", false, true)
+	c.SetCookie("auth_token", token, int(s.authManager.GetTokenExpiry().Seconds()), "/", "", false, true)
 	c.Redirect(http.StatusFound, "/dashboard")
 }
 
@@ -217,51 +276,460 @@ func (s *Server) getMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
+// getMetricsHistory returns stored metrics aggregated into time buckets.
+// Query parameters: from and to (RFC 3339, default the last hour) and step
+// (a Go duration such as "5m", default one minute).
+func (s *Server) getMetricsHistory(c *gin.Context) {
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: must be an RFC 3339 timestamp"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultMetricsHistoryRange)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: must be an RFC 3339 timestamp"})
+			return
+		}
+		from = parsed
+	}
+
+	step := defaultMetricsHistoryStep
+	if stepStr := c.Query("step"); stepStr != "" {
+		parsed, err := time.ParseDuration(stepStr)
+		if err != nil || parsed < time.Second {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step: must be a duration of at least 1s"})
+			return
+		}
+		step = parsed
+	}
+
+	buckets, err := s.monitor.GetMetricsHistory(from, to, step)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid history query: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"step":    step.String(),
+		"buckets": buckets,
+	})
+}
+
 // getAlerts returns current alerts as JSON.
+// Alerts are read from the alert manager rather than the last metrics
+// snapshot so resolved and suppressed alerts drop out immediately.
 func (s *Server) getAlerts(c *gin.Context) {
-	metrics := s.monitor.GetMetrics()
 	c.JSON(http.StatusOK, gin.H{
-		"alerts": metrics.Alerts,
+		"alerts": s.monitor.GetAlertManager().GetActiveAlerts(),
 	})
 }
 
-// getLogs returns recent logs as JSON.
-func (s *Server) getLogs(c *gin.Context) {
-	// Get query parameters
-	countStr := c.DefaultQuery("count", "100")
-	levelStr := c.DefaultQuery("level", "")
+// resolveAlert manually resolves a single active alert.
+func (s *Server) resolveAlert(c *gin.Context) {
+	alertID := c.Param("id")
 
-	_, err := strconv.Atoi(countStr)
-	if err != nil {
+	if err := s.monitor.GetAlertManager().ResolveAlert(alertID); err != nil {
+		respondAlertError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":     alertID,
+		"status": monitoring.AlertStatusResolved,
+	})
+}
+
+// suppressAlert suppresses a single alert for a duration.
+func (s *Server) suppressAlert(c *gin.Context) {
+	var req struct {
+		Duration string `json:"duration" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration"})
+		return
+	}
+
+	alertID := c.Param("id")
+	if err := s.monitor.GetAlertManager().SuppressAlert(alertID, duration); err != nil {
+		respondAlertError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":               alertID,
+		"status":           monitoring.AlertStatusSuppressed,
+		"suppressed_until": time.Now().Add(duration),
+	})
+}
+
+// respondAlertError maps an alert manager error to a response.
+// An AlertError means there is no active alert with the ID, so it maps to 404.
+func respondAlertError(c *gin.Context, err error) {
+	var alertErr *monitoring.AlertError
+	if errors.As(err, &alertErr) {
+		c.JSON(http.StatusNotFound, gin.H{"error": alertErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// suppressAlertsByType suppresses all active alerts of a type for a duration.
+func (s *Server) suppressAlertsByType(c *gin.Context) {
+	var req struct {
+		Type     string `json:"type" binding:"required"`
+		Duration string `json:"duration" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	alertType := monitoring.AlertType(req.Type)
+	if !monitoring.IsValidAlertType(alertType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert type"})
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration"})
+		return
+	}
+
+	count := s.monitor.GetAlertManager().SuppressByType(alertType, duration)
+	c.JSON(http.StatusOK, gin.H{
+		"type":     alertType,
+		"affected": count,
+	})
+}
+
+// resolveAlertsByType resolves all active alerts of a type.
+func (s *Server) resolveAlertsByType(c *gin.Context) {
+	var req struct {
+		Type string `json:"type" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	alertType := monitoring.AlertType(req.Type)
+	if !monitoring.IsValidAlertType(alertType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert type"})
+		return
+	}
+
+	count := s.monitor.GetAlertManager().ResolveByType(alertType)
+	c.JSON(http.StatusOK, gin.H{
+		"type":     alertType,
+		"affected": count,
+	})
+}
+
+// purgeResolvedAlerts removes resolved alerts that were resolved before the given time.
+// Active and suppressed alerts are kept.
+func (s *Server) purgeResolvedAlerts(c *gin.Context) {
+	var req struct {
+		Before time.Time `json:"before" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	count := s.monitor.GetAlertManager().PurgeResolvedBefore(req.Before)
+	c.JSON(http.StatusOK, gin.H{
+		"before": req.Before,
+		"purged": count,
+	})
+}
+
+// getLogRetention returns how many days of connection logs are kept.
+func (s *Server) getLogRetention(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"days": s.monitor.GetLogRetentionDays(),
+	})
+}
+
+// updateLogRetention changes how many days of connection logs the monitor
+// keeps; 0 disables the automatic cleanup.
+func (s *Server) updateLogRetention(c *gin.Context) {
+	var req struct {
+		Days *int `json:"days" binding:"required,min=0"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	s.monitor.SetLogRetentionDays(*req.Days)
+	c.JSON(http.StatusOK, gin.H{
+		"days": *req.Days,
+	})
+}
+
+// getSecurityFeed returns a paginated, time-ordered feed of security events
+// (newest first) combining security alerts and security log entries.
+func (s *Server) getSecurityFeed(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultSecurityFeedPageSize)))
+	if err != nil || pageSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page size"})
+		return
+	}
+	if pageSize > maxSecurityFeedPageSize {
+		pageSize = maxSecurityFeedPageSize
+	}
+
+	events := s.monitor.GetSecurityFeed()
+	total := len(events)
+
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":    events[start:end],
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// getLiveness answers liveness probes. It always responds with 200 while
+// the process is able to serve requests and checks no dependencies.
+func (s *Server) getLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"alive":     true,
+		"timestamp": time.Now(),
+	})
+}
+
+// getReadiness answers readiness probes with per-component readiness as JSON.
+// It responds with 503 until the database, monitor and WireGuard configuration are ready.
+func (s *Server) getReadiness(c *gin.Context) {
+	report := s.monitor.GetReadiness()
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+// getDetailedHealth returns per-subsystem health as JSON.
+// It responds with 503 when any subsystem is down so load balancers can act on it.
+func (s *Server) getDetailedHealth(c *gin.Context) {
+	report := s.monitor.GetDetailedHealth()
+
+	status := http.StatusOK
+	if report.Status == monitoring.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
+
+// getLogs returns recent logs as JSON, oldest first.
+// The optional level and component query parameters restrict the entries to
+// one level and one component; count limits them to the most recent ones.
+func (s *Server) getLogs(c *gin.Context) {
+	count, err := strconv.Atoi(c.DefaultQuery("count", "100"))
+	if err != nil || count < 1 {
 		// Default to 100 if invalid
+		count = 100
 	}
 
-	var logs []monitoring.LogEntry
-	
-	if levelStr != "" {
-		// Parse log level
-		switch levelStr {
-		case "debug":
-			_ = monitoring.LogLevelDebug
-		case "info":
-			_ = monitoring.LogLevelInfo
-		case "warn":
-			_ = monitoring.LogLevelWarn
-		case "error":
-			_ = monitoring.LogLevelError
-		default:
+	filterLevel := false
+	var level monitoring.LogLevel
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err = monitoring.ParseLogLevel(levelStr)
+		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log level"})
 			return
 		}
-		
-		// Get logs by level (this would need to be implemented in LogManager)
-		logs = []monitoring.LogEntry{} // Placeholder
+		filterLevel = true
+	}
+
+	logManager := s.monitor.GetLogManager()
+	var entries []monitoring.LogEntry
+	if component := c.Query("component"); component != "" {
+		entries = logManager.GetLogsByComponent(component, 0)
 	} else {
-		// Get recent logs (this would need to be implemented in LogManager)
-		logs = []monitoring.LogEntry{} // Placeholder
+		entries = logManager.GetRecentLogs(0)
+	}
+
+	logs := make([]monitoring.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !filterLevel || entry.Level == level {
+			logs = append(logs, entry)
+		}
+	}
+	if len(logs) > count {
+		logs = logs[len(logs)-count:]
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"logs": logs,
 	})
-}
\ No newline at end of file
+}
+
+// streamLogs streams new log entries as Server-Sent Events until the client
+// disconnects. Each entry is sent as a "log" event carrying the JSON-encoded
+// LogEntry. The optional level query parameter sets the minimum level sent.
+// Entries are dropped for viewers that cannot keep up.
+func (s *Server) streamLogs(c *gin.Context) {
+	minLevel := monitoring.LogLevelTrace
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := monitoring.ParseLogLevel(levelStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid log level"})
+			return
+		}
+		minLevel = level
+	}
+
+	logManager := s.monitor.GetLogManager()
+	entries := logManager.Subscribe(logStreamBufferSize)
+	defer logManager.Unsubscribe(entries)
+
+	// The stream outlives the server's write timeout
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(logStreamKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			if entry.Level < minLevel {
+				continue
+			}
+			c.SSEvent("log", entry)
+			c.Writer.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// streamMetrics upgrades the request to a WebSocket and sends the latest
+// ServerMetrics as a JSON frame on connect and after each monitor update.
+// Messages from the viewer are ignored; the stream ends when the viewer
+// disconnects. Connections beyond MaxMetricsSockets are refused.
+func (s *Server) streamMetrics(c *gin.Context) {
+	if !s.acquireMetricsSocket() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many live metrics connections"})
+		return
+	}
+	defer s.releaseMetricsSocket()
+
+	updates := s.monitor.SubscribeMetrics()
+	defer s.monitor.UnsubscribeMetrics(updates)
+
+	handler := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		// The connection outlives the server's read and write timeouts
+		ws.SetDeadline(time.Time{})
+
+		// Drain incoming frames; a read error means the viewer went away
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var message []byte
+			for websocket.Message.Receive(ws, &message) == nil {
+			}
+		}()
+
+		send := func() bool {
+			ws.SetWriteDeadline(time.Now().Add(metricsSocketWriteTimeout))
+			return websocket.JSON.Send(ws, s.monitor.GetMetrics()) == nil
+		}
+
+		if !send() {
+			return
+		}
+		for {
+			select {
+			case <-closed:
+				return
+			case _, ok := <-updates:
+				if !ok || !send() {
+					return
+				}
+			}
+		}
+	}}
+	handler.ServeHTTP(c.Writer, c.Request)
+}
+
+// acquireMetricsSocket reserves a live metrics connection slot.
+// Returns false if all slots are taken.
+func (s *Server) acquireMetricsSocket() bool {
+	limit := s.config.MaxMetricsSockets
+	if limit <= 0 {
+		limit = defaultMaxMetricsSockets
+	}
+
+	s.socketMutex.Lock()
+	defer s.socketMutex.Unlock()
+
+	if s.metricsSockets >= limit {
+		return false
+	}
+	s.metricsSockets++
+	return true
+}
+
+// releaseMetricsSocket frees a slot reserved with acquireMetricsSocket.
+func (s *Server) releaseMetricsSocket() {
+	s.socketMutex.Lock()
+	defer s.socketMutex.Unlock()
+
+	s.metricsSockets--
+}
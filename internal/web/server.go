@@ -5,8 +5,13 @@ package web
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,18 +24,42 @@ import (
 	"my-vpn/internal/wireguard"
 )
 
+// tokenBlacklistCleanupInterval is how often expired revoked tokens are purged.
+const tokenBlacklistCleanupInterval = time.Hour
+
+// defaultShutdownTimeout bounds how long Stop waits for open connections to drain.
+const defaultShutdownTimeout = 30 * time.Second
+
+// defaultMaxMetricsSockets bounds the concurrent live metrics WebSocket connections.
+const defaultMaxMetricsSockets = 20
+
+// JWTSecretEnv is the environment variable NewServer reads the JWT signing secret from.
+const JWTSecretEnv = "VPN_JWT_SECRET"
+
+// defaultJWTSecret is the well-known placeholder secret. It is only accepted
+// in debug mode, where it is also used when no secret is configured.
+const defaultJWTSecret = "default-secret-key-change-in-production"
+
 // Server represents the HTTP server for the VPN management interface.
 // It provides both REST API endpoints and serves the web UI dashboard.
 type Server struct {
 	router       *gin.Engine                // Gin HTTP router
 	server       *http.Server               // HTTP server instance
+	metricsServer *http.Server              // Separate Prometheus metrics listener (nil if served on the main router)
 	config       *ServerConfig              // Server configuration
 	db           *database.Database         // Database connection
 	wgServer     *wireguard.WireGuardServer // WireGuard server instance
 	ipPool       *network.IPPool            // IP pool manager
-	pfctlManager *system.PfctlManager       // Firewall manager
+	firewall     system.FirewallManager     // Firewall manager (pfctl or iptables)
 	monitor      *monitoring.Monitor        // Monitoring system
 	authManager  *auth.AuthManager          // Authentication manager
+	authAPI      *api.AuthAPI               // Issues tokens as sessions, shared by the form and API logins
+	loginLimiter *auth.LoginLimiter         // Lockout of repeated failed logins, shared by the form and API logins
+	httpMetrics  *monitoring.HTTPMetrics    // Per-route request latency metrics
+	connMutex    sync.Mutex                 // Protects conns
+	conns        map[net.Conn]struct{}      // Open connections of the main listener
+	socketMutex  sync.Mutex                 // Protects metricsSockets
+	metricsSockets int                      // Open live metrics WebSocket connections
 }
 
 // ServerConfig represents configuration options for the web server.
@@ -42,40 +71,97 @@ type ServerConfig struct {
 	EnableTLS    bool          `json:"enable_tls"`    // Whether to enable HTTPS
 	CertFile     string        `json:"cert_file"`     // TLS certificate file path
 	KeyFile      string        `json:"key_file"`      // TLS private key file path
+	MinTLSVersion uint16       `json:"min_tls_version"` // Minimum TLS version (default: TLS 1.2)
+	CipherSuites []uint16      `json:"cipher_suites"` // Allowed TLS 1.0-1.2 cipher suites (empty uses Go defaults)
 	StaticDir    string        `json:"static_dir"`    // Static files directory
 	TemplateDir  string        `json:"template_dir"`  // Template files directory
 	Debug        bool          `json:"debug"`         // Enable debug mode
+	MetricsAddress string      `json:"metrics_address"` // Separate listen address for /metrics (e.g. "127.0.0.1:9100"); empty serves it on the main router
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"` // How long Stop drains connections before closing them (default: 30s)
+	ClientAPI    *api.ClientAPIConfig `json:"client_api"` // Client management behavior (nil uses api.DefaultClientAPIConfig)
+	LoginLimit   *auth.LoginLimiterConfig `json:"login_limit"` // Failed login thresholds and lockout duration (nil uses auth.DefaultLoginLimiterConfig)
+	PasswordResetWebhookURL string `json:"password_reset_webhook_url"` // Webhook delivering password reset tokens to users (empty disables resets)
+	TokenExpiry  time.Duration `json:"token_expiry"`  // Lifetime of issued JWT tokens (0 uses the auth default of 24h)
+	PasswordPolicy *auth.PasswordPolicy `json:"password_policy"` // Requirements for new passwords (nil uses auth.DefaultPasswordPolicy)
+	MaxMetricsSockets int      `json:"max_metrics_sockets"` // Concurrent live metrics WebSocket connections (0 uses the default of 20)
+	JWTSecret    string        `json:"-"`             // Secret signing the JWT tokens (required unless Debug is set)
 }
 
-// NewServer creates a new web server with default configuration.
-// It initializes the HTTP server, sets up routes, and configures middleware
-// for authentication, logging, and CORS. Returns a Server instance.
-func NewServer(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, pfctlManager *system.PfctlManager, monitor *monitoring.Monitor) *Server {
-	config := &ServerConfig{
+// ResolveJWTSecret returns the secret to sign JWT tokens with.
+// Outside debug mode the secret must be set and differ from the built-in
+// placeholder, since a shared secret lets anyone forge tokens. In debug mode
+// an empty secret falls back to the placeholder.
+// Returns an error if the configured secret is not acceptable.
+func (config *ServerConfig) ResolveJWTSecret() (string, error) {
+	if config.JWTSecret != "" && config.JWTSecret != defaultJWTSecret {
+		return config.JWTSecret, nil
+	}
+	if config.Debug {
+		return defaultJWTSecret, nil
+	}
+	if config.JWTSecret == "" {
+		return "", fmt.Errorf("JWT secret is not configured; set %s to a long random value", JWTSecretEnv)
+	}
+	return "", fmt.Errorf("JWT secret is the built-in default; set %s to a long random value", JWTSecretEnv)
+}
+
+// DefaultServerConfig returns the default web server configuration.
+// The server listens on localhost:8080 without TLS and reads the JWT secret
+// from the VPN_JWT_SECRET environment variable.
+func DefaultServerConfig() *ServerConfig {
+	return &ServerConfig{
 		Host:         "localhost",
 		Port:         8080,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		EnableTLS:    false,
+		MinTLSVersion: tls.VersionTLS12,
+		ShutdownTimeout: defaultShutdownTimeout,
 		StaticDir:    "web/static",
 		TemplateDir:  "web/templates",
 		Debug:        false,
+		JWTSecret:    os.Getenv(JWTSecretEnv),
 	}
+}
 
-	return NewServerWithConfig(db, wgServer, ipPool, pfctlManager, monitor, config)
+// NewServer creates a new web server with default configuration.
+// It initializes the HTTP server, sets up routes, and configures middleware
+// for authentication, logging, and CORS.
+// Returns a Server instance or an error if the configuration is invalid.
+func NewServer(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, firewall system.FirewallManager, monitor *monitoring.Monitor) (*Server, error) {
+	return NewServerWithConfig(db, wgServer, ipPool, firewall, monitor, DefaultServerConfig())
 }
 
 // NewServerWithConfig creates a new web server with custom configuration.
 // This allows fine-tuning of server behavior for specific deployment requirements.
-// Returns a Server instance with the specified configuration.
-func NewServerWithConfig(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, pfctlManager *system.PfctlManager, monitor *monitoring.Monitor, config *ServerConfig) *Server {
+// Returns a Server instance with the specified configuration, or an error if
+// the JWT secret is missing or still the default outside debug mode.
+func NewServerWithConfig(db *database.Database, wgServer *wireguard.WireGuardServer, ipPool *network.IPPool, firewall system.FirewallManager, monitor *monitoring.Monitor, config *ServerConfig) (*Server, error) {
+	jwtSecret, err := config.ResolveJWTSecret()
+	if err != nil {
+		return nil, fmt.Errorf("invalid web server configuration: %w", err)
+	}
+
 	// Set Gin mode based on debug setting
 	if !config.Debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create authentication manager with a default secret (should be from config in production)
-	authManager := auth.NewAuthManager("default-secret-key-change-in-production")
+	authManager := auth.NewAuthManager(jwtSecret)
+	if config.TokenExpiry > 0 {
+		authManager = auth.NewAuthManagerWithConfig(jwtSecret, config.TokenExpiry)
+	}
+	if config.PasswordPolicy != nil {
+		authManager.SetPasswordPolicy(config.PasswordPolicy)
+	}
+
+	// Persist logouts so revoked tokens stay rejected across restarts
+	blacklist, err := auth.NewTokenBlacklistWithStore(db)
+	if err != nil {
+		log.Printf("Failed to load revoked tokens, keeping them in memory only: %v", err)
+	} else {
+		authManager.SetBlacklist(blacklist)
+	}
 
 	server := &Server{
 		router:       gin.New(),
@@ -83,21 +169,44 @@ func NewServerWithConfig(db *database.Database, wgServer *wireguard.WireGuardSer
 		db:           db,
 		wgServer:     wgServer,
 		ipPool:       ipPool,
-		pfctlManager: pfctlManager,
+		firewall:     firewall,
 		monitor:      monitor,
 		authManager:  authManager,
+		httpMetrics:  monitoring.NewHTTPMetrics(),
+		conns:        make(map[net.Conn]struct{}),
+	}
+
+	loginLimitConfig := config.LoginLimit
+	if loginLimitConfig == nil {
+		loginLimitConfig = auth.DefaultLoginLimiterConfig()
 	}
+	server.loginLimiter = auth.NewLoginLimiterWithConfig(loginLimitConfig)
+
+	// Export the monitor's server metrics next to the HTTP request metrics
+	server.httpMetrics.Registry().MustRegister(monitoring.NewMetricsCollector(monitor))
 
 	server.setupRoutes()
 	server.setupHTTPServer()
 
-	return server
+	return server, nil
 }
 
 // Start starts the HTTP server.
 // It begins listening for HTTP requests on the configured host and port.
+// If a separate metrics address is configured, the metrics listener is started
+// in the background as well, along with the cleanup of expired revoked tokens.
 // This method is non-blocking and returns immediately after starting the server.
 func (s *Server) Start() error {
+	s.authManager.GetBlacklist().StartCleanup(tokenBlacklistCleanupInterval)
+
+	if s.metricsServer != nil {
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Metrics server error: %v", err)
+			}
+		}()
+	}
+
 	if s.config.EnableTLS {
 		return s.server.ListenAndServeTLS(s.config.CertFile, s.config.KeyFile)
 	}
@@ -105,10 +214,68 @@ func (s *Server) Start() error {
 }
 
 // Stop gracefully shuts down the HTTP server.
-// It waits for existing connections to complete before stopping.
+// It waits for existing connections to complete, but no longer than the
+// configured ShutdownTimeout (or the deadline of ctx, if earlier). Connections
+// still open at the deadline are closed forcibly. The number of drained and
+// forcibly closed connections is logged.
 // This method blocks until the server has shut down completely.
 func (s *Server) Stop(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+	s.authManager.GetBlacklist().StopCleanup()
+
+	timeout := s.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if s.metricsServer != nil {
+		if err := s.metricsServer.Shutdown(ctx); err != nil {
+			s.metricsServer.Close()
+			return fmt.Errorf("failed to stop metrics server: %w", err)
+		}
+	}
+
+	open := s.openConnections()
+	logManager := s.monitor.GetLogManager()
+	if err := s.server.Shutdown(ctx); err != nil {
+		if ctx.Err() == nil {
+			return fmt.Errorf("failed to stop server: %w", err)
+		}
+
+		forced := s.openConnections()
+		if err := s.server.Close(); err != nil {
+			return fmt.Errorf("failed to close server: %w", err)
+		}
+		logManager.LogWarn(fmt.Sprintf("Shutdown deadline of %s exceeded: drained %d connection(s), forcibly closed %d",
+			timeout, open-forced, forced))
+		return nil
+	}
+
+	logManager.LogInfo(fmt.Sprintf("Server stopped: drained %d connection(s)", open))
+	return nil
+}
+
+// trackConnection keeps count of the open connections, so shutdown can report
+// how many were drained and how many had to be closed forcibly.
+func (s *Server) trackConnection(conn net.Conn, state http.ConnState) {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+
+	switch state {
+	case http.StateNew:
+		s.conns[conn] = struct{}{}
+	case http.StateHijacked, http.StateClosed:
+		delete(s.conns, conn)
+	}
+}
+
+// openConnections returns the number of open connections of the main listener.
+func (s *Server) openConnections() int {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+
+	return len(s.conns)
 }
 
 // GetAddress returns the full server address including protocol, host, and port.
@@ -126,6 +293,7 @@ func (s *Server) GetAddress() string {
 func (s *Server) setupRoutes() {
 	// Middleware
 	s.router.Use(gin.Logger())
+	s.router.Use(s.metricsMiddleware())
 	s.router.Use(gin.Recovery())
 	s.router.Use(s.corsMiddleware())
 
@@ -141,6 +309,16 @@ func (s *Server) setupRoutes() {
 	// Public routes (no authentication required)
 	public := s.router.Group("/")
 	{
+		// Health check endpoints
+		public.GET("/healthz", s.getLiveness)
+		public.GET("/readyz", s.getReadiness)
+		public.GET("/health/detailed", s.getDetailedHealth)
+
+		// Prometheus metrics, unless they get their own listener
+		if s.config.MetricsAddress == "" {
+			public.GET("/metrics", gin.WrapH(s.httpMetrics.Handler()))
+		}
+
 		// Serve login page
 		public.GET("/login", s.loginPage)
 		public.POST("/login", s.handleLogin)
@@ -152,9 +330,20 @@ func (s *Server) setupRoutes() {
 	apiV1 := s.router.Group("/api/v1")
 	{
 		// Public API endpoints
+		logManager := s.monitor.GetLogManager()
 		authAPI := api.NewAuthAPI(s.db, s.authManager)
+		s.authAPI = authAPI
+		authAPI.SetFailedLoginRecorder(s.monitor)
+		authAPI.SetLogger(logManager.ForComponent(monitoring.LogComponentAuth))
+		authAPI.SetLoginLimiter(s.loginLimiter)
+		authMiddleware.SetAPIKeyResolver(authAPI)
+		if s.config.PasswordResetWebhookURL != "" {
+			authAPI.SetPasswordResetSender(api.NewWebhookPasswordResetSender(s.config.PasswordResetWebhookURL))
+		}
 		apiV1.POST("/auth/login", authAPI.Login)
 		apiV1.POST("/auth/register", authAPI.Register)
+		apiV1.POST("/auth/forgot-password", authAPI.ForgotPassword)
+		apiV1.POST("/auth/reset-password", authAPI.ResetPassword)
 
 		// Protected API endpoints
 		protected := apiV1.Group("/")
@@ -164,28 +353,87 @@ func (s *Server) setupRoutes() {
 			protected.POST("/auth/refresh", authAPI.RefreshToken)
 			protected.GET("/auth/profile", authAPI.GetProfile)
 			protected.POST("/auth/change-password", authAPI.ChangePassword)
+			protected.POST("/auth/logout", authAPI.Logout)
+			protected.GET("/auth/sessions", authAPI.ListSessions)
+			protected.DELETE("/auth/sessions/:jti", authAPI.RevokeSession)
 
 			// Server management endpoints
 			serverAPI := api.NewServerAPI(s.db, s.ipPool, s.wgServer)
+			serverAPI.SetLogger(logManager.ForComponent(monitoring.LogComponentAPI))
 			protected.GET("/server/status", serverAPI.GetStatus)
 			protected.POST("/server/start", serverAPI.StartServer)
 			protected.POST("/server/stop", serverAPI.StopServer)
 			protected.POST("/server/restart", serverAPI.RestartServer)
 
+			// Admin-only endpoints
+			admin := protected.Group("/")
+			admin.Use(s.requireAdmin())
+			{
+				admin.GET("/server/full-config", serverAPI.GetFullConfig)
+				admin.GET("/server/verify-keys", serverAPI.VerifyKeys)
+				admin.POST("/server/initialize", serverAPI.InitializeServer)
+				admin.DELETE("/server/config", serverAPI.DeleteConfig)
+				admin.DELETE("/server/logs", serverAPI.DeleteLogs)
+				admin.GET("/monitoring/log-retention", s.getLogRetention)
+				admin.PUT("/monitoring/log-retention", s.updateLogRetention)
+				admin.PUT("/server/endpoint", serverAPI.RotateEndpoint)
+				admin.POST("/monitoring/alerts/purge", s.purgeResolvedAlerts)
+				admin.POST("/monitoring/alerts/:id/resolve", s.resolveAlert)
+				admin.POST("/monitoring/alerts/:id/suppress", s.suppressAlert)
+				admin.POST("/monitoring/alerts/suppress-type", s.suppressAlertsByType)
+				admin.POST("/monitoring/alerts/resolve-type", s.resolveAlertsByType)
+				admin.POST("/auth/api-keys", authAPI.CreateAPIKey)
+				admin.GET("/auth/api-keys", authAPI.ListAPIKeys)
+				admin.DELETE("/auth/api-keys/:id", authAPI.RevokeAPIKey)
+				admin.GET("/auth/users", authAPI.ListUsers)
+				admin.POST("/auth/users/:id/deactivate", authAPI.DeactivateUser)
+				admin.POST("/auth/users/:id/activate", authAPI.ActivateUser)
+				admin.DELETE("/auth/users/:id", authAPI.DeleteUser)
+			}
+
 			// Client management endpoints
-			clientAPI := api.NewClientAPI(s.db, s.ipPool, s.wgServer)
+			clientAPIConfig := s.config.ClientAPI
+			if clientAPIConfig == nil {
+				clientAPIConfig = api.DefaultClientAPIConfig()
+			}
+			clientAPI := api.NewClientAPIWithConfig(s.db, s.ipPool, s.wgServer, clientAPIConfig)
+			clientAPI.SetAlertManager(s.monitor.GetAlertManager())
+			clientAPI.SetLogger(logManager.ForComponent(monitoring.LogComponentAPI))
+			// Restore the stored client rate limits where the firewall can shape traffic
+			if shaper, ok := s.firewall.(api.RateLimitShaper); ok {
+				clientAPI.SetRateLimitShaper(shaper)
+				if err := clientAPI.ApplyRateLimits(); err != nil {
+					log.Printf("Failed to apply client rate limits: %v", err)
+				}
+			}
+			serverAPI.SetClientConfigRenderer(clientAPI)
 			protected.GET("/clients", clientAPI.GetClients)
+			protected.GET("/clients/count", clientAPI.GetClientCount)
+			protected.GET("/clients/search", clientAPI.SearchClients)
 			protected.POST("/clients", clientAPI.CreateClient)
+			protected.POST("/clients/preview-config", clientAPI.PreviewClientConfig)
+			protected.POST("/clients/batch", clientAPI.BatchCreateClients)
+			protected.POST("/clients/bulk-delete", clientAPI.BulkDeleteClients)
+			protected.POST("/clients/bulk-toggle", clientAPI.BulkToggleClients)
+			admin.GET("/clients/top", clientAPI.GetTopClients)
 			protected.GET("/clients/:id", clientAPI.GetClient)
 			protected.PUT("/clients/:id", clientAPI.UpdateClient)
 			protected.DELETE("/clients/:id", clientAPI.DeleteClient)
+			protected.GET("/clients/:id/status", clientAPI.GetClientStatus)
 			protected.GET("/clients/:id/config", clientAPI.GetClientConfig)
 			protected.GET("/clients/:id/qr", clientAPI.GetClientQRCode)
+			protected.PUT("/clients/:id/ratelimit", clientAPI.SetClientRateLimit)
+			admin.POST("/clients/:id/quota/reset", clientAPI.ResetClientQuota)
+			admin.GET("/clients/export", clientAPI.ExportClientConfigs)
 
 			// Monitoring endpoints
 			protected.GET("/monitoring/metrics", s.getMetrics)
+			protected.GET("/monitoring/metrics/ws", s.streamMetrics)
+			protected.GET("/monitoring/metrics/history", s.getMetricsHistory)
 			protected.GET("/monitoring/alerts", s.getAlerts)
+			protected.GET("/monitoring/security-feed", s.getSecurityFeed)
 			protected.GET("/monitoring/logs", s.getLogs)
+			protected.GET("/monitoring/logs/stream", s.streamLogs)
 		}
 	}
 
@@ -210,6 +458,36 @@ func (s *Server) setupHTTPServer() {
 		Handler:      s.router,
 		ReadTimeout:  s.config.ReadTimeout,
 		WriteTimeout: s.config.WriteTimeout,
+		ConnState:    s.trackConnection,
+	}
+
+	if s.config.EnableTLS {
+		s.server.TLSConfig = s.tlsConfig()
+	}
+
+	if s.config.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", s.httpMetrics.Handler())
+		s.metricsServer = &http.Server{
+			Addr:         s.config.MetricsAddress,
+			Handler:      mux,
+			ReadTimeout:  s.config.ReadTimeout,
+			WriteTimeout: s.config.WriteTimeout,
+		}
+	}
+}
+
+// tlsConfig builds the TLS configuration enforcing the configured minimum
+// version and cipher suites. TLS 1.2 is used as the minimum when none is set.
+func (s *Server) tlsConfig() *tls.Config {
+	minVersion := s.config.MinTLSVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	return &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: s.config.CipherSuites,
 	}
 }
 
@@ -227,4 +505,37 @@ func (s *Server) corsMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// metricsMiddleware records the latency of every request into per-route histograms.
+// The matched route template is used as the label so that IDs in paths
+// (e.g. /api/v1/clients/:id) don't create a label value per client.
+func (s *Server) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		s.httpMetrics.ObserveRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
+
+// requireAdmin restricts a route to authenticated users with the admin role.
+// It must be used after the authentication middleware.
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		user, err := s.db.GetUser(userID.(uint))
+		if err != nil || !user.Active || user.Role != "admin" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,18 +1,27 @@
 package web
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"my-vpn/internal/auth"
 	"my-vpn/internal/database"
 	"my-vpn/internal/monitoring"
 	"my-vpn/internal/network"
@@ -36,7 +45,9 @@ func setupTestWebServer(t *testing.T) (*Server, func()) {
 	require.NoError(t, err)
 
 	pfctlManager := system.NewPfctlManager()
-	monitor := monitoring.NewMonitor(db, wgServer, ipPool, pfctlManager)
+	monitorConfig := monitoring.DefaultMonitorConfig()
+	monitorConfig.LogDirectory = filepath.Join(tempDir, "logs")
+	monitor := monitoring.NewMonitorWithConfig(db, wgServer, ipPool, pfctlManager, monitorConfig)
 
 	// Create test static and template directories
 	staticDir := filepath.Join(tempDir, "static")
@@ -60,9 +71,11 @@ func setupTestWebServer(t *testing.T) (*Server, func()) {
 		StaticDir:    staticDir,
 		TemplateDir:  templateDir,
 		Debug:        true,
+		JWTSecret:    "test-secret",
 	}
 
-	server := NewServerWithConfig(db, wgServer, ipPool, pfctlManager, monitor, config)
+	server, err := NewServerWithConfig(db, wgServer, ipPool, pfctlManager, monitor, config)
+	require.NoError(t, err)
 
 	cleanup := func() {
 		os.RemoveAll(tempDir)
@@ -92,6 +105,51 @@ func TestNewServerWithConfig(t *testing.T) {
 		assert.NotNil(t, server)
 		assert.True(t, server.config.Debug)
 	})
+
+	t.Run("should reject a missing or default JWT secret outside debug mode", func(t *testing.T) {
+		base, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		for _, secret := range []string{"", defaultJWTSecret} {
+			config := *base.config
+			config.Debug = false
+			config.JWTSecret = secret
+
+			server, err := NewServerWithConfig(base.db, base.wgServer, base.ipPool, base.firewall, base.monitor, &config)
+			assert.Nil(t, server)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), JWTSecretEnv)
+		}
+	})
+
+	t.Run("should fall back to the default JWT secret in debug mode", func(t *testing.T) {
+		base, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		config := *base.config
+		config.JWTSecret = ""
+
+		server, err := NewServerWithConfig(base.db, base.wgServer, base.ipPool, base.firewall, base.monitor, &config)
+		require.NoError(t, err)
+		assert.NotNil(t, server)
+	})
+
+	t.Run("should sign tokens with the configured secret", func(t *testing.T) {
+		base, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		config := *base.config
+		config.Debug = false
+		config.JWTSecret = "another-secret"
+
+		server, err := NewServerWithConfig(base.db, base.wgServer, base.ipPool, base.firewall, base.monitor, &config)
+		require.NoError(t, err)
+
+		token, err := base.authManager.GenerateToken(1, "admin", "admin")
+		require.NoError(t, err)
+		_, err = server.authManager.ValidateToken(token)
+		assert.Error(t, err)
+	})
 }
 
 func TestServer_GetAddress(t *testing.T) {
@@ -115,6 +173,19 @@ func TestServer_GetAddress(t *testing.T) {
 	})
 }
 
+// adminToken creates an admin user and returns a token for it, so tests can
+// reach routes behind requireAdmin.
+func adminToken(t *testing.T, server *Server) string {
+	admin, err := server.db.CreateUserWithCredentials("admin", "admin@example.com", "password123")
+	require.NoError(t, err)
+	admin.Role = "admin"
+	require.NoError(t, server.db.UpdateUser(admin))
+
+	token, err := server.authManager.GenerateToken(admin.ID, admin.Username, admin.Role)
+	require.NoError(t, err)
+	return token
+}
+
 func TestServer_Routes(t *testing.T) {
 	t.Run("should setup routes correctly", func(t *testing.T) {
 		server, cleanup := setupTestWebServer(t)
@@ -144,6 +215,64 @@ func TestServer_Routes(t *testing.T) {
 		}
 		assert.True(t, hasAPIRoutes, "Should have API routes")
 	})
+
+	t.Run("should mount the top clients endpoint for admins", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		token := adminToken(t, server)
+
+		req := httptest.NewRequest("GET", "/api/v1/clients/top", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("should mount the key verification endpoint for admins", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		token := adminToken(t, server)
+
+		req := httptest.NewRequest("GET", "/api/v1/server/verify-keys", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Server is not initialized")
+	})
+
+	t.Run("should mount server initialization for admins", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		token := adminToken(t, server)
+
+		req := httptest.NewRequest("POST", "/api/v1/server/initialize", strings.NewReader(`{"network":"10.0.0.0/24","listen_port":51820}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		_, err := server.db.GetServerConfig()
+		assert.NoError(t, err)
+	})
+
+	t.Run("should mount the quota reset endpoint for admins", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		token := adminToken(t, server)
+
+		req := httptest.NewRequest("POST", "/api/v1/clients/999/quota/reset", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), "Client not found")
+	})
 }
 
 func TestServer_StartStop(t *testing.T) {
@@ -191,6 +320,73 @@ func TestServer_StartStop(t *testing.T) {
 	})
 }
 
+func TestServer_ShutdownTimeout(t *testing.T) {
+	t.Run("should force-close stuck connections after the timeout", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		// A handler that doesn't finish until the test is done
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+		server.router.GET("/slow", func(c *gin.Context) {
+			close(entered)
+			<-release
+			c.Status(http.StatusOK)
+		})
+
+		server.config.Port = findAvailablePort()
+		server.config.ShutdownTimeout = 200 * time.Millisecond
+		server.setupHTTPServer()
+
+		go server.Start()
+		require.Eventually(t, func() bool {
+			conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", server.config.Port))
+			if err != nil {
+				return false
+			}
+			conn.Close()
+			return true
+		}, time.Second, 10*time.Millisecond)
+
+		go http.Get(fmt.Sprintf("http://localhost:%d/slow", server.config.Port))
+		select {
+		case <-entered:
+		case <-time.After(time.Second):
+			t.Fatal("slow handler was not reached")
+		}
+
+		start := time.Now()
+		require.NoError(t, server.Stop(context.Background()))
+		elapsed := time.Since(start)
+
+		assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+		assert.Less(t, elapsed, time.Second)
+
+		logs := server.monitor.GetLogManager().GetRecentLogs(10)
+		require.NotEmpty(t, logs)
+		last := logs[len(logs)-1]
+		assert.Equal(t, monitoring.LogLevelWarn, last.Level)
+		assert.Contains(t, last.Message, "forcibly closed 1")
+	})
+
+	t.Run("should use the default timeout when unset", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		server.config.Port = findAvailablePort()
+		server.setupHTTPServer()
+		go server.Start()
+		time.Sleep(50 * time.Millisecond)
+
+		require.NoError(t, server.Stop(context.Background()))
+
+		logs := server.monitor.GetLogManager().GetRecentLogs(10)
+		require.NotEmpty(t, logs)
+		assert.Contains(t, logs[len(logs)-1].Message, "drained")
+	})
+}
+
 func TestServer_CORSMiddleware(t *testing.T) {
 	t.Run("should set CORS headers", func(t *testing.T) {
 		server, cleanup := setupTestWebServer(t)
@@ -247,4 +443,984 @@ func findAvailablePort() int {
 	}
 	defer listener.Close()
 	return listener.Addr().(*net.TCPAddr).Port
-}
\ No newline at end of file
+}
+func TestServer_DetailedHealth(t *testing.T) {
+	t.Run("should return per-subsystem health without authentication", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		req := httptest.NewRequest("GET", "/health/detailed", nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+
+		assert.Contains(t, []int{http.StatusOK, http.StatusServiceUnavailable}, resp.Code)
+
+		var report monitoring.HealthReport
+		err := json.Unmarshal(resp.Body.Bytes(), &report)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, report.Status)
+		assert.Len(t, report.Subsystems, 5)
+	})
+}
+
+func TestServer_HealthProbes(t *testing.T) {
+	probe := func(server *Server, path string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		return resp.Code, response
+	}
+
+	t.Run("should report liveness without authentication", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		code, response := probe(server, "/healthz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, response["alive"])
+	})
+
+	t.Run("should report ready when all dependencies are up", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		require.NoError(t, os.WriteFile(server.wgServer.GetConfigPath(), []byte("[Interface]\n"), 0600))
+		require.NoError(t, server.monitor.Start(context.Background()))
+		defer server.monitor.Stop()
+
+		code, response := probe(server, "/readyz")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, response["ready"])
+		assert.Len(t, response["components"], 3)
+	})
+
+	t.Run("should report not ready when a dependency is down", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		require.NoError(t, os.WriteFile(server.wgServer.GetConfigPath(), []byte("[Interface]\n"), 0600))
+
+		// The monitor has not been started
+		code, response := probe(server, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, false, response["ready"])
+
+		for _, component := range response["components"].([]interface{}) {
+			component := component.(map[string]interface{})
+			assert.Equal(t, component["name"] != monitoring.ReadinessMonitor, component["ready"], component["name"])
+		}
+	})
+
+	t.Run("should report not ready when the database is unreachable", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		require.NoError(t, os.WriteFile(server.wgServer.GetConfigPath(), []byte("[Interface]\n"), 0600))
+		require.NoError(t, server.monitor.Start(context.Background()))
+		defer server.monitor.Stop()
+		require.NoError(t, server.db.Close())
+
+		code, response := probe(server, "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, false, response["ready"])
+	})
+}
+
+func TestServer_BulkAlertActions(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	token := adminToken(t, server)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should return affected count for suppress-type", func(t *testing.T) {
+		resp := post("/api/v1/monitoring/alerts/suppress-type", `{"type":"network","duration":"1h"}`)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "network", response["type"])
+		assert.Equal(t, float64(0), response["affected"])
+	})
+
+	t.Run("should reject unknown alert type", func(t *testing.T) {
+		resp := post("/api/v1/monitoring/alerts/resolve-type", `{"type":"bogus"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject invalid duration", func(t *testing.T) {
+		resp := post("/api/v1/monitoring/alerts/suppress-type", `{"type":"network","duration":"soon"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		user, err := server.db.CreateUserWithCredentials("operator", "operator@example.com", "password123")
+		require.NoError(t, err)
+		userToken, err := server.authManager.GenerateToken(user.ID, user.Username, user.Role)
+		require.NoError(t, err)
+
+		for _, path := range []string{"/api/v1/monitoring/alerts/suppress-type", "/api/v1/monitoring/alerts/resolve-type"} {
+			req := httptest.NewRequest("POST", path, strings.NewReader(`{"type":"network","duration":"1h"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+userToken)
+			resp := httptest.NewRecorder()
+			server.router.ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusForbidden, resp.Code, path)
+		}
+	})
+}
+
+func TestServer_PurgeResolvedAlerts(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	user, err := server.db.CreateUserWithCredentials("operator", "operator@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := server.db.CreateUserWithCredentials("admin", "admin@example.com", "password123")
+	require.NoError(t, err)
+	admin.Role = "admin"
+	require.NoError(t, server.db.UpdateUser(admin))
+
+	alertManager := server.monitor.GetAlertManager()
+	alertManager.RaiseAlert("network_resolved", monitoring.AlertTypeNetwork, monitoring.SeverityMedium, "Resolved", "desc", nil)
+	require.NoError(t, alertManager.ResolveAlert("network_resolved"))
+	alertManager.RaiseAlert("network_active", monitoring.AlertTypeNetwork, monitoring.SeverityMedium, "Active", "desc", nil)
+
+	post := func(userID uint, username, role, body string) *httptest.ResponseRecorder {
+		token, err := server.authManager.GenerateToken(userID, username, role)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/v1/monitoring/alerts/purge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		resp := post(user.ID, user.Username, user.Role, `{"before":"2100-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should reject missing cutoff", func(t *testing.T) {
+		resp := post(admin.ID, admin.Username, admin.Role, `{}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should purge resolved alerts before the cutoff", func(t *testing.T) {
+		resp := post(admin.ID, admin.Username, admin.Role, `{"before":"2100-01-01T00:00:00Z"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, float64(1), response["purged"])
+
+		alerts := alertManager.GetAllAlerts(time.Time{})
+		require.Len(t, alerts, 1)
+		assert.Equal(t, "network_active", alerts[0].ID)
+	})
+}
+
+func TestServer_AlertActions(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	user, err := server.db.CreateUserWithCredentials("operator", "operator@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := server.db.CreateUserWithCredentials("admin", "admin@example.com", "password123")
+	require.NoError(t, err)
+	admin.Role = "admin"
+	require.NoError(t, server.db.UpdateUser(admin))
+
+	// Force an evaluation that raises the CPU and memory alerts
+	alertManager := server.monitor.GetAlertManager()
+	metrics := &monitoring.ServerMetrics{}
+	metrics.SystemStats.CPUUsage = 99.0
+	metrics.SystemStats.MemoryUsage = 99.0
+	metrics.SecurityStats.FirewallEnabled = true
+	alertManager.EvaluateMetrics(metrics)
+
+	request := func(method, path string, userID uint, username, role, body string) *httptest.ResponseRecorder {
+		token, err := server.authManager.GenerateToken(userID, username, role)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	activeIDs := func() []string {
+		resp := request("GET", "/api/v1/monitoring/alerts", admin.ID, admin.Username, admin.Role, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response struct {
+			Alerts []monitoring.Alert `json:"alerts"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		var ids []string
+		for _, alert := range response.Alerts {
+			ids = append(ids, alert.ID)
+		}
+		return ids
+	}
+
+	t.Run("should list the raised alerts", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"system_cpu_high", "system_memory_high"}, activeIDs())
+	})
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/system_cpu_high/resolve", user.ID, user.Username, user.Role, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		resp = request("POST", "/api/v1/monitoring/alerts/system_cpu_high/suppress", user.ID, user.Username, user.Role, `{"duration":"1h"}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should resolve an alert and drop it from the active list", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/system_cpu_high/resolve", admin.ID, admin.Username, admin.Role, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "system_cpu_high", response["id"])
+		assert.Equal(t, "resolved", response["status"])
+
+		assert.Equal(t, []string{"system_memory_high"}, activeIDs())
+	})
+
+	t.Run("should return 404 for a resolved alert", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/system_cpu_high/resolve", admin.ID, admin.Username, admin.Role, "")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("should reject an invalid duration", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/system_memory_high/suppress", admin.ID, admin.Username, admin.Role, `{"duration":"soon"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = request("POST", "/api/v1/monitoring/alerts/system_memory_high/suppress", admin.ID, admin.Username, admin.Role, `{}`)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("should suppress an alert and drop it from the active list", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/system_memory_high/suppress", admin.ID, admin.Username, admin.Role, `{"duration":"1h"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, "suppressed", response["status"])
+		assert.NotEmpty(t, response["suppressed_until"])
+
+		assert.Empty(t, activeIDs())
+	})
+
+	t.Run("should return 404 for an unknown alert", func(t *testing.T) {
+		resp := request("POST", "/api/v1/monitoring/alerts/missing/resolve", admin.ID, admin.Username, admin.Role, "")
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		resp = request("POST", "/api/v1/monitoring/alerts/missing/suppress", admin.ID, admin.Username, admin.Role, `{"duration":"1h"}`)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestServer_GetLogs(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	token, err := server.authManager.GenerateToken(1, "admin", "admin")
+	require.NoError(t, err)
+
+	getLogs := func(query string) (int, []monitoring.LogEntry) {
+		req := httptest.NewRequest("GET", "/api/v1/monitoring/logs"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+
+		var response struct {
+			Logs []monitoring.LogEntry `json:"logs"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		return resp.Code, response.Logs
+	}
+
+	messages := func(entries []monitoring.LogEntry) []string {
+		result := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			result = append(result, entry.Message)
+		}
+		return result
+	}
+
+	// A login through the API is logged by the auth component
+	_, err = server.db.CreateUserWithCredentials("alice", "alice@example.com", "password123")
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"alice","password":"password123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	logManager := server.monitor.GetLogManager()
+	logManager.Log(monitoring.LogComponentWireGuard, monitoring.LogLevelInfo, "Added peer", nil)
+	logManager.Log(monitoring.LogComponentWireGuard, monitoring.LogLevelError, "Failed to sync", nil)
+
+	t.Run("should filter logs by component", func(t *testing.T) {
+		code, logs := getLogs("?component=auth")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{`User "alice" logged in from 192.0.2.1`}, messages(logs))
+
+		code, logs = getLogs("?component=wireguard")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"Added peer", "Failed to sync"}, messages(logs))
+	})
+
+	t.Run("should combine component, level and count", func(t *testing.T) {
+		_, logs := getLogs("?component=wireguard&level=error")
+		assert.Equal(t, []string{"Failed to sync"}, messages(logs))
+
+		_, logs = getLogs("?component=wireguard&count=1")
+		assert.Equal(t, []string{"Failed to sync"}, messages(logs))
+	})
+
+	t.Run("should return an empty list for an unknown component", func(t *testing.T) {
+		code, logs := getLogs("?component=bogus")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, logs)
+	})
+
+	t.Run("should reject an invalid level", func(t *testing.T) {
+		code, _ := getLogs("?level=loud")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestServer_LogRetention(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	user, err := server.db.CreateUserWithCredentials("operator", "operator@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := server.db.CreateUserWithCredentials("admin", "admin@example.com", "password123")
+	require.NoError(t, err)
+	admin.Role = "admin"
+	require.NoError(t, server.db.UpdateUser(admin))
+
+	request := func(method, path string, userID uint, username, role, body string) *httptest.ResponseRecorder {
+		token, err := server.authManager.GenerateToken(userID, username, role)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		resp := request("PUT", "/api/v1/monitoring/log-retention", user.ID, user.Username, user.Role, `{"days":7}`)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		resp = request("DELETE", "/api/v1/server/logs?before=2100-01-01T00:00:00Z", user.ID, user.Username, user.Role, "")
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should update the retention used by the monitor", func(t *testing.T) {
+		resp := request("PUT", "/api/v1/monitoring/log-retention", admin.ID, admin.Username, admin.Role, `{"days":7}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 7, server.monitor.GetLogRetentionDays())
+
+		resp = request("GET", "/api/v1/monitoring/log-retention", admin.ID, admin.Username, admin.Role, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, float64(7), response["days"])
+	})
+
+	t.Run("should allow disabling the retention", func(t *testing.T) {
+		resp := request("PUT", "/api/v1/monitoring/log-retention", admin.ID, admin.Username, admin.Role, `{"days":0}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, 0, server.monitor.GetLogRetentionDays())
+	})
+
+	t.Run("should reject an invalid retention", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"days":-1}`} {
+			resp := request("PUT", "/api/v1/monitoring/log-retention", admin.ID, admin.Username, admin.Role, body)
+			assert.Equal(t, http.StatusBadRequest, resp.Code, body)
+		}
+	})
+
+	t.Run("should purge connection logs as admin", func(t *testing.T) {
+		require.NoError(t, server.db.Create(&database.ConnectionLog{
+			ClientID:  1,
+			Action:    "connect",
+			IPAddress: "10.0.0.2",
+			Timestamp: time.Now().AddDate(0, 0, -30),
+		}).Error)
+
+		resp := request("DELETE", "/api/v1/server/logs?before="+time.Now().AddDate(0, 0, -1).Format(time.RFC3339), admin.ID, admin.Username, admin.Role, "")
+		require.Equal(t, http.StatusOK, resp.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+		assert.Equal(t, float64(1), response["deleted"])
+	})
+}
+
+func TestServer_SecurityFeed(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	token, err := server.authManager.GenerateToken(1, "admin", "admin")
+	require.NoError(t, err)
+
+	// Seed a failed login through the login endpoint, then a firewall-disabled alert
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"mallory","password":"wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	server.router.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	time.Sleep(5 * time.Millisecond)
+	server.monitor.GetAlertManager().RaiseAlert("security_firewall_disabled", monitoring.AlertTypeSecurity,
+		monitoring.SeverityCritical, "Firewall Disabled", "Firewall is disabled", nil)
+
+	getFeed := func(query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/v1/monitoring/security-feed"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		return resp.Code, response
+	}
+
+	t.Run("should list events from alerts and logs ordered by time", func(t *testing.T) {
+		code, response := getFeed("")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(2), response["total"])
+
+		events := response["events"].([]interface{})
+		require.Len(t, events, 2)
+
+		newest := events[0].(map[string]interface{})
+		oldest := events[1].(map[string]interface{})
+		assert.Equal(t, "firewall_disabled", newest["type"])
+		assert.Equal(t, "critical", newest["severity"])
+		assert.Equal(t, "failed_login", oldest["type"])
+		assert.Equal(t, "medium", oldest["severity"])
+	})
+
+	t.Run("should paginate events", func(t *testing.T) {
+		code, response := getFeed("?page=2&page_size=1")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, float64(2), response["total"])
+
+		events := response["events"].([]interface{})
+		require.Len(t, events, 1)
+		assert.Equal(t, "failed_login", events[0].(map[string]interface{})["type"])
+	})
+
+	t.Run("should return empty page past the end", func(t *testing.T) {
+		code, response := getFeed("?page=5&page_size=10")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response["events"])
+	})
+
+	t.Run("should reject invalid page", func(t *testing.T) {
+		code, _ := getFeed("?page=0")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestServer_LogStream(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	token, err := server.authManager.GenerateToken(1, "admin", "admin")
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	// openStream connects to the stream and returns a reader positioned after the headers
+	openStream := func(t *testing.T, query string) (*http.Response, *bufio.Reader) {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+"/api/v1/monitoring/logs/stream"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	// readEntry reads the next "log" event off the stream
+	readEntry := func(t *testing.T, reader *bufio.Reader) monitoring.LogEntry {
+		event := ""
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:") && event == "log":
+				var entry monitoring.LogEntry
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &entry))
+				return entry
+			}
+		}
+	}
+
+	t.Run("should stream new log entries", func(t *testing.T) {
+		resp, reader := openStream(t, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		logManager := server.monitor.GetLogManager()
+		logManager.LogInfo("Streamed message 1")
+		logManager.LogWarn("Streamed message 2")
+
+		assert.Equal(t, "Streamed message 1", readEntry(t, reader).Message)
+		entry := readEntry(t, reader)
+		assert.Equal(t, "Streamed message 2", entry.Message)
+		assert.Equal(t, monitoring.LogLevelWarn, entry.Level)
+	})
+
+	t.Run("should filter entries below the requested level", func(t *testing.T) {
+		resp, reader := openStream(t, "?level=error")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		logManager := server.monitor.GetLogManager()
+		logManager.LogInfo("Filtered info")
+		logManager.LogError("Streamed error")
+
+		assert.Equal(t, "Streamed error", readEntry(t, reader).Message)
+	})
+
+	t.Run("should reject invalid log level", func(t *testing.T) {
+		resp, _ := openStream(t, "?level=loud")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("should require authentication", func(t *testing.T) {
+		resp, err := http.Get(httpServer.URL + "/api/v1/monitoring/logs/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestServer_MetricsWebSocket(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+	server.config.MaxMetricsSockets = 1
+
+	token, err := server.authManager.GenerateToken(1, "admin", "admin")
+	require.NoError(t, err)
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	dial := func() (*websocket.Conn, error) {
+		config, err := websocket.NewConfig("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/api/v1/monitoring/metrics/ws", httpServer.URL)
+		require.NoError(t, err)
+		config.Header.Set("Authorization", "Bearer "+token)
+		return websocket.DialConfig(config)
+	}
+
+	// waitForRelease waits until the server noticed that all viewers disconnected
+	waitForRelease := func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			server.socketMutex.Lock()
+			defer server.socketMutex.Unlock()
+			return server.metricsSockets == 0
+		}, 2*time.Second, 10*time.Millisecond)
+	}
+
+	t.Run("should send metrics on connect", func(t *testing.T) {
+		ws, err := dial()
+		require.NoError(t, err)
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		var metrics monitoring.ServerMetrics
+		require.NoError(t, websocket.JSON.Receive(ws, &metrics))
+		assert.Equal(t, monitoring.StatusHealthy, metrics.ServerStatus)
+		assert.False(t, metrics.Timestamp.IsZero())
+
+		ws.Close()
+		waitForRelease(t)
+	})
+
+	t.Run("should refuse connections beyond the limit", func(t *testing.T) {
+		first, err := dial()
+		require.NoError(t, err)
+		first.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var metrics monitoring.ServerMetrics
+		require.NoError(t, websocket.JSON.Receive(first, &metrics))
+
+		_, err = dial()
+		assert.Error(t, err)
+
+		// The slot is released once the viewer disconnects
+		first.Close()
+		waitForRelease(t)
+
+		ws, err := dial()
+		require.NoError(t, err)
+		ws.Close()
+	})
+}
+
+func TestServer_MetricsHistory(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	token, err := server.authManager.GenerateToken(1, "admin", "admin")
+	require.NoError(t, err)
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		require.NoError(t, server.db.CreateMetricsSnapshot(&database.MetricsSnapshot{
+			Timestamp:     base.Add(time.Duration(i) * 30 * time.Second),
+			ActiveClients: i,
+			BytesSent:     uint64(100 * i),
+		}))
+	}
+
+	getHistory := func(query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/v1/monitoring/metrics/history"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(resp.Body.Bytes(), &response)
+		return resp.Code, response
+	}
+
+	t.Run("should return bucketed series for the range", func(t *testing.T) {
+		code, response := getHistory("?from=2024-03-01T12:00:00Z&to=2024-03-01T12:10:00Z&step=1m")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "1m0s", response["step"])
+
+		buckets := response["buckets"].([]interface{})
+		require.Len(t, buckets, 3)
+		first := buckets[0].(map[string]interface{})
+		assert.Equal(t, "2024-03-01T12:00:00Z", first["start"])
+		assert.Equal(t, float64(2), first["samples"])
+		assert.Equal(t, 0.5, first["active_clients"])
+		assert.Equal(t, float64(100), first["bytes_sent"])
+		last := buckets[2].(map[string]interface{})
+		assert.Equal(t, "2024-03-01T12:02:00Z", last["start"])
+		assert.Equal(t, float64(500), last["bytes_sent"])
+	})
+
+	t.Run("should return no buckets outside the stored range", func(t *testing.T) {
+		code, response := getHistory("?from=2024-03-02T12:00:00Z&to=2024-03-02T13:00:00Z")
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, response["buckets"])
+	})
+
+	t.Run("should reject invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"?from=yesterday",
+			"?to=2024-03-01",
+			"?step=fast",
+			"?step=100ms",
+			"?from=2024-03-01T13:00:00Z&to=2024-03-01T12:00:00Z",
+			"?from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z&step=1m",
+		} {
+			code, response := getHistory(query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+			assert.NotEmpty(t, response["error"], query)
+		}
+	})
+
+	t.Run("should require authentication", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/monitoring/metrics/history", nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+}
+
+func TestServer_LoginLockout(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	_, err := server.db.CreateUserWithCredentials("alice", "alice@example.com", "correct-password")
+	require.NoError(t, err)
+
+	formLogin := func(password string) int {
+		form := url.Values{"username": {"alice"}, "password": {password}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	t.Run("should lock the form login after repeated failures", func(t *testing.T) {
+		for i := 0; i < auth.DefaultLoginLimiterConfig().MaxAttempts; i++ {
+			assert.Equal(t, http.StatusUnauthorized, formLogin("wrong-password"))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, formLogin("correct-password"))
+	})
+
+	t.Run("should share the lockout with the API login", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"alice","password":"correct-password"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	})
+}
+
+func TestServer_FormLoginSession(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	user, err := server.db.CreateUserWithCredentials("alice", "alice@example.com", "correct-password")
+	require.NoError(t, err)
+
+	t.Run("should record the form login as a session", func(t *testing.T) {
+		form := url.Values{"username": {"alice"}, "password": {"correct-password"}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("User-Agent", "Firefox")
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusFound, resp.Code)
+
+		var token string
+		for _, cookie := range resp.Result().Cookies() {
+			if cookie.Name == "auth_token" {
+				token = cookie.Value
+			}
+		}
+		require.NotEmpty(t, token)
+		claims, err := server.authManager.ValidateToken(token)
+		require.NoError(t, err)
+
+		sessions, err := server.db.ListSessions(user.ID, time.Now())
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, claims.ID, sessions[0].TokenID)
+		assert.Contains(t, sessions[0].Label, "Firefox")
+	})
+}
+
+func TestServer_FormRegistration(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	register := func(username string) *httptest.ResponseRecorder {
+		form := url.Values{"username": {username}, "email": {username + "@example.com"}, "password": {"tunnel-keeper-42"}}
+		req := httptest.NewRequest("POST", "/register", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should make the first account on a fresh install the admin", func(t *testing.T) {
+		require.Equal(t, http.StatusFound, register("owner").Code)
+
+		user, err := server.db.GetUserByUsername("owner")
+		require.NoError(t, err)
+		assert.Equal(t, "admin", user.Role)
+	})
+
+	t.Run("should give later accounts the user role", func(t *testing.T) {
+		require.Equal(t, http.StatusFound, register("guest").Code)
+
+		user, err := server.db.GetUserByUsername("guest")
+		require.NoError(t, err)
+		assert.Equal(t, "user", user.Role)
+	})
+
+	t.Run("should record the automatic login as a session", func(t *testing.T) {
+		require.Equal(t, http.StatusFound, register("visitor").Code)
+
+		user, err := server.db.GetUserByUsername("visitor")
+		require.NoError(t, err)
+		sessions, err := server.db.ListSessions(user.ID, time.Now())
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+}
+
+func TestServer_RequireAdmin(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	user, err := server.db.CreateUserWithCredentials("operator", "operator@example.com", "password123")
+	require.NoError(t, err)
+	admin, err := server.db.CreateUserWithCredentials("admin", "admin@example.com", "password123")
+	require.NoError(t, err)
+	admin.Role = "admin"
+	require.NoError(t, server.db.UpdateUser(admin))
+
+	keyPair, err := wireguard.GenerateKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, server.db.CreateServerConfig(&database.ServerConfig{
+		PrivateKey: keyPair.PrivateKey,
+		PublicKey:  keyPair.PublicKey,
+		ListenPort: 51820,
+		Network:    "10.0.0.0/24",
+		Interface:  "wg0",
+	}))
+
+	get := func(userID uint, username, role string) *httptest.ResponseRecorder {
+		token, err := server.authManager.GenerateToken(userID, username, role)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/api/v1/server/full-config", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		resp := get(user.ID, user.Username, user.Role)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("should allow admin users", func(t *testing.T) {
+		resp := get(admin.ID, admin.Username, admin.Role)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "[Interface]")
+	})
+}
+
+func TestServer_RequestMetrics(t *testing.T) {
+	t.Run("should record request latency per normalized route", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		for _, path := range []string{"/api/v1/clients/1", "/api/v1/clients/2", "/login"} {
+			req := httptest.NewRequest("GET", path, nil)
+			server.router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/api/v1/clients/:id",status="401"} 2`)
+		assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/login",status="200"} 1`)
+		assert.NotContains(t, body, `route="/api/v1/clients/1"`)
+	})
+}
+
+func TestServer_PrometheusMetrics(t *testing.T) {
+	t.Run("should export server metrics without authentication", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		body := resp.Body.String()
+		for _, name := range []string{
+			"vpn_server_up",
+			"vpn_clients_total",
+			"vpn_clients_active",
+			"vpn_ip_pool_utilization_percent",
+			"vpn_bytes_transferred_total",
+			"vpn_goroutines",
+			"vpn_alerts_active",
+		} {
+			assert.Contains(t, body, name)
+		}
+	})
+
+	t.Run("should serve metrics only on the separate metrics address", func(t *testing.T) {
+		base, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		config := *base.config
+		config.MetricsAddress = "127.0.0.1:0"
+		server, err := NewServerWithConfig(base.db, base.wgServer, base.ipPool, base.firewall, base.monitor, &config)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		resp := httptest.NewRecorder()
+		server.router.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+
+		require.NotNil(t, server.metricsServer)
+		assert.Equal(t, "127.0.0.1:0", server.metricsServer.Addr)
+
+		resp = httptest.NewRecorder()
+		server.metricsServer.Handler.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), "vpn_server_up")
+	})
+}
+
+func TestServer_TLSConfig(t *testing.T) {
+	t.Run("should default to TLS 1.2 minimum", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		server.config.EnableTLS = true
+		server.setupHTTPServer()
+
+		require.NotNil(t, server.server.TLSConfig)
+		assert.Equal(t, uint16(tls.VersionTLS12), server.server.TLSConfig.MinVersion)
+		assert.Empty(t, server.server.TLSConfig.CipherSuites)
+	})
+
+	t.Run("should apply configured minimum version and cipher suites", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		ciphers := []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		}
+		server.config.EnableTLS = true
+		server.config.MinTLSVersion = tls.VersionTLS13
+		server.config.CipherSuites = ciphers
+		server.setupHTTPServer()
+
+		require.NotNil(t, server.server.TLSConfig)
+		assert.Equal(t, uint16(tls.VersionTLS13), server.server.TLSConfig.MinVersion)
+		assert.Equal(t, ciphers, server.server.TLSConfig.CipherSuites)
+	})
+
+	t.Run("should not set TLS config when TLS is disabled", func(t *testing.T) {
+		server, cleanup := setupTestWebServer(t)
+		defer cleanup()
+
+		assert.Nil(t, server.server.TLSConfig)
+	})
+}
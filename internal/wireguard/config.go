@@ -1,11 +1,30 @@
 package wireguard
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strings"
 )
 
+// ErrIncompleteConfig is returned by ClientConfig.Validate when a configuration
+// would not let a client connect because the server is not fully configured.
+var ErrIncompleteConfig = errors.New("server not fully configured")
+
+// placeholderTokens are fragments of values that stand in for real settings,
+// such as "your-server-ip" or "dummy.example.com". Matching is case-insensitive.
+var placeholderTokens = []string{"dummy", "placeholder", "your-server", "your_server", "changeme", "change-me"}
+
+// HostPrefix returns the CIDR covering only the given IP address: "/32" for an
+// IPv4 and "/128" for an IPv6 address. Client tunnel addresses and the
+// AllowedIPs of their peers use it.
+func HostPrefix(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return ip + "/128"
+	}
+	return ip + "/32"
+}
+
 // ServerConfig represents the WireGuard server configuration parameters.
 // It contains all necessary settings to generate a complete WireGuard server
 // configuration file, including cryptographic keys, network settings, and
@@ -15,6 +34,7 @@ type ServerConfig struct {
 	PublicKey  string   // Base64-encoded server public key
 	Address    string   // Server IP address with CIDR notation (e.g., "10.0.0.1/24")
 	ListenPort int      // UDP port for WireGuard to listen on
+	MTU        int      // Tunnel MTU in bytes (0 lets wg-quick choose)
 	DNS        []string // DNS servers to provide to clients
 	PostUp     []string // Commands to execute when the interface comes up
 	PostDown   []string // Commands to execute when the interface goes down
@@ -29,9 +49,12 @@ type ClientConfig struct {
 	PublicKey       string   // Base64-encoded client public key
 	Address         string   // Client IP address with CIDR notation (e.g., "10.0.0.2/32")
 	DNS             []string // DNS servers for the client to use
+	MTU             int      // Tunnel MTU in bytes (0 lets the client choose)
 	ServerPublicKey string   // Base64-encoded server public key for authentication
+	PresharedKey    string   // Base64-encoded symmetric key shared with the server (optional)
 	ServerEndpoint  string   // Server endpoint in "host:port" format
 	AllowedIPs      []string // IP ranges that should be routed through the VPN
+	PersistentKeepalive int  // Keepalive interval in seconds (0 disables keepalive)
 }
 
 // NewServerConfig creates a new server configuration with generated cryptographic keys.
@@ -72,7 +95,7 @@ func NewServerConfig(listenPort int, networkCIDR string) (*ServerConfig, error)
 
 // GenerateConfigFile creates a WireGuard configuration file content for the server.
 // It generates the [Interface] section with all server settings but does not include
-// any [Peer] sections. Peer configurations should be added separately using AddPeer.
+// any [Peer] sections. The MTU line is omitted when MTU is zero. Peer configurations should be added separately using AddPeer.
 // Returns the configuration file content as a string in WireGuard's INI-like format.
 func (sc *ServerConfig) GenerateConfigFile() string {
 	var config strings.Builder
@@ -81,6 +104,9 @@ func (sc *ServerConfig) GenerateConfigFile() string {
 	config.WriteString(fmt.Sprintf("PrivateKey = %s\n", sc.PrivateKey))
 	config.WriteString(fmt.Sprintf("Address = %s\n", sc.Address))
 	config.WriteString(fmt.Sprintf("ListenPort = %d\n", sc.ListenPort))
+	if sc.MTU > 0 {
+		config.WriteString(fmt.Sprintf("MTU = %d\n", sc.MTU))
+	}
 	
 	for _, cmd := range sc.PostUp {
 		config.WriteString(fmt.Sprintf("PostUp = %s\n", cmd))
@@ -96,10 +122,15 @@ func (sc *ServerConfig) GenerateConfigFile() string {
 // AddPeer generates a [Peer] section configuration for a client.
 // This method creates the configuration text that can be appended to the server
 // configuration file to allow a specific client to connect. The client is allowed
-// to use only their assigned IP address (/32 network).
+// to use only their assigned IP address (/32 or /128 network). The preshared key line is
+// omitted when presharedKey is empty.
 // Returns the peer configuration section as a string.
-func (sc *ServerConfig) AddPeer(clientPublicKey, clientIP string) string {
-	return fmt.Sprintf("\n[Peer]\nPublicKey = %s\nAllowedIPs = %s/32\n", clientPublicKey, clientIP)
+func (sc *ServerConfig) AddPeer(clientPublicKey, clientIP, presharedKey string) string {
+	peer := fmt.Sprintf("\n[Peer]\nPublicKey = %s\n", clientPublicKey)
+	if presharedKey != "" {
+		peer += fmt.Sprintf("PresharedKey = %s\n", presharedKey)
+	}
+	return peer + fmt.Sprintf("AllowedIPs = %s\n", HostPrefix(clientIP))
 }
 
 // NewClientConfig creates a new client configuration with generated cryptographic keys.
@@ -116,18 +147,21 @@ func NewClientConfig(serverConfig *ServerConfig, clientIP, serverEndpoint string
 	return &ClientConfig{
 		PrivateKey:      keyPair.PrivateKey,
 		PublicKey:       keyPair.PublicKey,
-		Address:         fmt.Sprintf("%s/32", clientIP),
+		Address:         HostPrefix(clientIP),
 		DNS:             serverConfig.DNS,
 		ServerPublicKey: serverConfig.PublicKey,
 		ServerEndpoint:  serverEndpoint,
 		AllowedIPs:      []string{"0.0.0.0/0"},
+		PersistentKeepalive: 25,
 	}, nil
 }
 
 // GenerateConfigFile creates a WireGuard configuration file content for the client.
 // It generates a complete client configuration including the [Interface] section
 // with client settings and a [Peer] section for connecting to the server.
-// The configuration includes persistent keepalive to maintain the connection.
+// Persistent keepalive is included only when PersistentKeepalive is greater than zero,
+// the MTU only when MTU is greater than zero and the preshared key only when
+// PresharedKey is set.
 // Returns the configuration file content as a string in WireGuard's INI-like format.
 func (cc *ClientConfig) GenerateConfigFile() string {
 	var config strings.Builder
@@ -136,16 +170,68 @@ func (cc *ClientConfig) GenerateConfigFile() string {
 	config.WriteString(fmt.Sprintf("PrivateKey = %s\n", cc.PrivateKey))
 	config.WriteString(fmt.Sprintf("Address = %s\n", cc.Address))
 	config.WriteString(fmt.Sprintf("DNS = %s\n", strings.Join(cc.DNS, ", ")))
+	if cc.MTU > 0 {
+		config.WriteString(fmt.Sprintf("MTU = %d\n", cc.MTU))
+	}
 	
 	config.WriteString("\n[Peer]\n")
 	config.WriteString(fmt.Sprintf("PublicKey = %s\n", cc.ServerPublicKey))
+	if cc.PresharedKey != "" {
+		config.WriteString(fmt.Sprintf("PresharedKey = %s\n", cc.PresharedKey))
+	}
 	config.WriteString(fmt.Sprintf("Endpoint = %s\n", cc.ServerEndpoint))
 	config.WriteString(fmt.Sprintf("AllowedIPs = %s\n", strings.Join(cc.AllowedIPs, ", ")))
-	config.WriteString("PersistentKeepalive = 25\n")
+	if cc.PersistentKeepalive > 0 {
+		config.WriteString(fmt.Sprintf("PersistentKeepalive = %d\n", cc.PersistentKeepalive))
+	}
 	
 	return config.String()
 }
 
+// Validate checks that the configuration can be handed to a client.
+// It fails if the server public key or endpoint is empty, if a key is not a
+// valid WireGuard key, or if the endpoint, address or DNS servers still contain
+// a known placeholder token.
+// Returns an error wrapping ErrIncompleteConfig describing the first problem found.
+func (cc *ClientConfig) Validate() error {
+	if strings.TrimSpace(cc.ServerPublicKey) == "" {
+		return fmt.Errorf("%w: server public key is empty", ErrIncompleteConfig)
+	}
+	host, _, err := net.SplitHostPort(cc.ServerEndpoint)
+	if err != nil || host == "" {
+		return fmt.Errorf("%w: server endpoint is empty", ErrIncompleteConfig)
+	}
+
+	keys := []struct{ name, value string }{
+		{"server public key", cc.ServerPublicKey},
+		{"private key", cc.PrivateKey},
+	}
+	if cc.PresharedKey != "" {
+		keys = append(keys, struct{ name, value string }{"preshared key", cc.PresharedKey})
+	}
+	for _, key := range keys {
+		if err := ValidateKey(key.value); err != nil {
+			return fmt.Errorf("%w: %s is invalid: %v", ErrIncompleteConfig, key.name, err)
+		}
+	}
+
+	fields := []struct{ name, value string }{
+		{"server endpoint", cc.ServerEndpoint},
+		{"address", cc.Address},
+		{"DNS", strings.Join(cc.DNS, ", ")},
+	}
+	for _, field := range fields {
+		lower := strings.ToLower(field.value)
+		for _, token := range placeholderTokens {
+			if strings.Contains(lower, token) {
+				return fmt.Errorf("%w: %s contains placeholder value %q", ErrIncompleteConfig, field.name, field.value)
+			}
+		}
+	}
+
+	return nil
+}
+
 // incrementIP increments an IP address by the given amount.
 // This helper function performs arithmetic on IP addresses, properly handling
 // byte overflow across octets. It's used for calculating server IP addresses
@@ -0,0 +1,124 @@
+package wireguard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConfig_GenerateConfigFile(t *testing.T) {
+	config := &ServerConfig{
+		PrivateKey: "server-private-key",
+		Address:    "10.0.0.1/24",
+		ListenPort: 51820,
+	}
+
+	t.Run("should omit MTU when zero", func(t *testing.T) {
+		assert.NotContains(t, config.GenerateConfigFile(), "MTU")
+	})
+
+	t.Run("should emit MTU in interface section when set", func(t *testing.T) {
+		config.MTU = 1380
+		defer func() { config.MTU = 0 }()
+
+		assert.Contains(t, config.GenerateConfigFile(), "ListenPort = 51820\nMTU = 1380\n")
+	})
+}
+
+func TestClientConfig_GenerateConfigFile(t *testing.T) {
+	config := &ClientConfig{
+		PrivateKey:      "client-private-key",
+		Address:         "10.0.0.2/32",
+		DNS:             []string{"1.1.1.1"},
+		ServerPublicKey: "server-public-key",
+		ServerEndpoint:  "vpn.example.com:51820",
+		AllowedIPs:      []string{"0.0.0.0/0"},
+	}
+
+	t.Run("should omit MTU when zero", func(t *testing.T) {
+		assert.NotContains(t, config.GenerateConfigFile(), "MTU")
+	})
+
+	t.Run("should emit MTU in interface section when set", func(t *testing.T) {
+		config.MTU = 1380
+		defer func() { config.MTU = 0 }()
+
+		assert.Contains(t, config.GenerateConfigFile(), "DNS = 1.1.1.1\nMTU = 1380\n\n[Peer]\n")
+	})
+
+	t.Run("should omit keepalive when zero", func(t *testing.T) {
+		assert.NotContains(t, config.GenerateConfigFile(), "PersistentKeepalive")
+	})
+
+	t.Run("should emit keepalive at the end of the peer section when set", func(t *testing.T) {
+		config.PersistentKeepalive = 25
+		defer func() { config.PersistentKeepalive = 0 }()
+
+		generated := config.GenerateConfigFile()
+		assert.True(t, strings.HasSuffix(generated, "AllowedIPs = 0.0.0.0/0\nPersistentKeepalive = 25\n"))
+		assert.Greater(t, strings.Index(generated, "PersistentKeepalive"), strings.Index(generated, "[Peer]"))
+	})
+}
+
+func TestClientConfig_Validate(t *testing.T) {
+	newConfig := func(t *testing.T) *ClientConfig {
+		clientKeys, err := GenerateKeyPair()
+		require.NoError(t, err)
+		serverKeys, err := GenerateKeyPair()
+		require.NoError(t, err)
+
+		return &ClientConfig{
+			PrivateKey:      clientKeys.PrivateKey,
+			PublicKey:       clientKeys.PublicKey,
+			Address:         "10.0.0.2/32",
+			DNS:             []string{"8.8.8.8"},
+			ServerPublicKey: serverKeys.PublicKey,
+			ServerEndpoint:  "vpn.example.com:51820",
+			AllowedIPs:      []string{"0.0.0.0/0"},
+		}
+	}
+
+	t.Run("should accept a complete config", func(t *testing.T) {
+		assert.NoError(t, newConfig(t).Validate())
+	})
+
+	t.Run("should reject keys that are not WireGuard keys", func(t *testing.T) {
+		config := newConfig(t)
+		config.ServerPublicKey = "server-public-key"
+		err := config.Validate()
+		assert.ErrorIs(t, err, ErrIncompleteConfig)
+		assert.Contains(t, err.Error(), "server public key is invalid")
+
+		config = newConfig(t)
+		config.PresharedKey = "short"
+		err = config.Validate()
+		assert.ErrorIs(t, err, ErrIncompleteConfig)
+		assert.Contains(t, err.Error(), "preshared key is invalid")
+	})
+
+	t.Run("should reject placeholder endpoint, address and DNS", func(t *testing.T) {
+		for _, mutate := range []func(*ClientConfig){
+			func(c *ClientConfig) { c.ServerEndpoint = "your-server-ip:51820" },
+			func(c *ClientConfig) { c.Address = "placeholder" },
+			func(c *ClientConfig) { c.DNS = []string{"changeme"} },
+		} {
+			config := newConfig(t)
+			mutate(config)
+			err := config.Validate()
+			assert.ErrorIs(t, err, ErrIncompleteConfig)
+			assert.Contains(t, err.Error(), "placeholder")
+		}
+	})
+}
+
+func TestHostPrefix(t *testing.T) {
+	t.Run("should use /32 for IPv4 addresses", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.2/32", HostPrefix("10.0.0.2"))
+	})
+
+	t.Run("should use /128 for IPv6 addresses", func(t *testing.T) {
+		assert.Equal(t, "fd00:10::2/128", HostPrefix("fd00:10::2"))
+	})
+}
@@ -0,0 +1,101 @@
+package wireguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigDrift compares the configuration file on disk with the configuration
+// the server last wrote. A mismatch means the file was edited by hand (or by
+// another tool) and the running interface may not match what the server expects.
+type ConfigDrift struct {
+	AppliedHash string `json:"applied_hash"` // Hash recorded when the server last wrote the file ("" if never)
+	CurrentHash string `json:"current_hash"` // Hash of the file on disk ("" if it doesn't exist)
+	Drifted     bool   `json:"drifted"`      // Whether the file changed since the server last wrote it
+}
+
+// ConfigHash returns the hex-encoded SHA-256 hash of a configuration file.
+// Comments, blank lines and surrounding whitespace are stripped first, so the
+// hash only changes when a setting does.
+func ConfigHash(content []byte) string {
+	var stripped strings.Builder
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		stripped.WriteString(line)
+		stripped.WriteString("\n")
+	}
+
+	sum := sha256.Sum256([]byte(stripped.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// configHashPath returns the path of the file holding the last-applied config hash.
+func (wg *WireGuardServer) configHashPath() string {
+	return filepath.Join(wg.configDir, wg.interfaceName+".conf.sha256")
+}
+
+// writeConfigFile atomically replaces the configuration file and records its hash
+// as the last-applied hash, so later edits made outside the server show up as drift.
+func (wg *WireGuardServer) writeConfigFile(path string, content []byte) error {
+	if err := writeFileAtomic(path, content, 0600); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(wg.configHashPath(), []byte(ConfigHash(content)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to record config hash: %w", err)
+	}
+	return nil
+}
+
+// AppliedConfigHash returns the hash recorded when the server last wrote the
+// configuration file, or an empty string if it never has.
+func (wg *WireGuardServer) AppliedConfigHash() (string, error) {
+	content, err := os.ReadFile(wg.configHashPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config hash: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// CurrentConfigHash returns the hash of the configuration file on disk, or an
+// empty string if the file doesn't exist.
+func (wg *WireGuardServer) CurrentConfigHash() (string, error) {
+	content, err := wg.readConfigFile(wg.GetConfigPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	return ConfigHash(content), nil
+}
+
+// CheckConfigDrift compares the hash of the configuration file on disk with the
+// last-applied hash. A file the server never wrote is not reported as drifted,
+// since there is nothing to compare it with.
+func (wg *WireGuardServer) CheckConfigDrift() (*ConfigDrift, error) {
+	applied, err := wg.AppliedConfigHash()
+	if err != nil {
+		return nil, err
+	}
+	current, err := wg.CurrentConfigHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigDrift{
+		AppliedHash: applied,
+		CurrentHash: current,
+		Drifted:     applied != "" && applied != current,
+	}, nil
+}
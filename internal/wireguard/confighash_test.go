@@ -0,0 +1,113 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHash(t *testing.T) {
+	content := "[Interface]\nPrivateKey = key\nListenPort = 51820\n"
+
+	t.Run("should ignore comments and whitespace", func(t *testing.T) {
+		formatted := "# Managed by VPN server\n[Interface]\n  PrivateKey = key\n\n# Port\nListenPort = 51820  \n\n"
+		assert.Equal(t, ConfigHash([]byte(content)), ConfigHash([]byte(formatted)))
+	})
+
+	t.Run("should change when a setting changes", func(t *testing.T) {
+		changed := "[Interface]\nPrivateKey = key\nListenPort = 51821\n"
+		assert.NotEqual(t, ConfigHash([]byte(content)), ConfigHash([]byte(changed)))
+	})
+
+	t.Run("should return a hex-encoded SHA-256 hash", func(t *testing.T) {
+		assert.Len(t, ConfigHash([]byte(content)), 64)
+	})
+}
+
+func TestWireGuardServer_CheckConfigDrift(t *testing.T) {
+	tempDir := t.TempDir()
+	server := NewWireGuardServerWithConfig(tempDir, "wg0")
+	configPath := filepath.Join(tempDir, "wg0.conf")
+
+	t.Run("should report nothing before the config is written", func(t *testing.T) {
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.Empty(t, drift.AppliedHash)
+		assert.Empty(t, drift.CurrentHash)
+		assert.False(t, drift.Drifted)
+	})
+
+	t.Run("should record the hash when writing the config", func(t *testing.T) {
+		require.NoError(t, server.WriteConfig(&ServerConfig{
+			PrivateKey: "server-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51820,
+		}))
+
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.Equal(t, ConfigHash(content), drift.AppliedHash)
+		assert.Equal(t, drift.AppliedHash, drift.CurrentHash)
+		assert.False(t, drift.Drifted)
+	})
+
+	t.Run("should change the hash when the server modifies the config", func(t *testing.T) {
+		before, err := server.AppliedConfigHash()
+		require.NoError(t, err)
+
+		require.NoError(t, server.AddPeer(&Peer{
+			PublicKey:  base64.StdEncoding.EncodeToString(make([]byte, 32)),
+			AllowedIPs: []string{"10.0.0.2/32"},
+		}))
+
+		after, err := server.AppliedConfigHash()
+		require.NoError(t, err)
+		assert.NotEqual(t, before, after)
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.False(t, drift.Drifted)
+	})
+
+	t.Run("should report drift after an outside edit", func(t *testing.T) {
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(configPath, append(content, []byte("\n[Peer]\nPublicKey = manual\nAllowedIPs = 10.0.0.9/32\n")...), 0600))
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.True(t, drift.Drifted)
+		assert.NotEqual(t, drift.AppliedHash, drift.CurrentHash)
+	})
+
+	t.Run("should not report drift for comment-only edits", func(t *testing.T) {
+		require.NoError(t, server.WriteConfig(&ServerConfig{
+			PrivateKey: "server-private-key",
+			Address:    "10.0.0.1/24",
+			ListenPort: 51820,
+		}))
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(configPath, append([]byte("# edited by hand\n"), content...), 0600))
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.False(t, drift.Drifted)
+	})
+
+	t.Run("should report drift when the config file is removed", func(t *testing.T) {
+		require.NoError(t, os.Remove(configPath))
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.Empty(t, drift.CurrentHash)
+		assert.True(t, drift.Drifted)
+	})
+}
@@ -45,6 +45,56 @@ func GenerateKeyPair() (*KeyPair, error) {
 	}, nil
 }
 
+// GeneratePresharedKey creates a random symmetric key that a peer pair can mix
+// into the handshake in addition to their key pairs, as with "wg genpsk".
+// This adds a layer of post-quantum resistance to recorded traffic.
+// Returns the base64-encoded 32-byte key or an error if random generation fails.
+func GeneratePresharedKey() (string, error) {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", fmt.Errorf("failed to generate preshared key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key[:]), nil
+}
+
+// DerivePublicKey derives the base64-encoded public key for a base64-encoded private key.
+// It performs the same Curve25519 operation as "wg pubkey" without requiring the
+// WireGuard tools to be installed, which makes it usable for verifying stored keys.
+// Returns the public key or an error if the private key is not a valid 32-byte key.
+func DerivePublicKey(privateKey string) (string, error) {
+	private, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key encoding: %w", err)
+	}
+	if len(private) != curve25519.ScalarSize {
+		return "", fmt.Errorf("invalid private key length: expected %d bytes, got %d", curve25519.ScalarSize, len(private))
+	}
+
+	public, err := curve25519.X25519(private, curve25519.Basepoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(public), nil
+}
+
+// ValidateKey checks that key is a base64-encoded 32-byte WireGuard key, the format
+// "wg" expects for private, public and preshared keys alike. Keys supplied from
+// outside should be validated before they are stored or written to a config file,
+// where a malformed key would make the whole interface fail to load.
+// Returns an error describing the problem or nil if the key is well-formed.
+func ValidateKey(key string) error {
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(decoded) != curve25519.ScalarSize {
+		return fmt.Errorf("key must be %d bytes, got %d", curve25519.ScalarSize, len(decoded))
+	}
+	return nil
+}
+
 // PrivateKeyBytes decodes the base64-encoded private key and returns it as a byte array.
 // This method is useful when the raw key bytes are needed for cryptographic operations
 // or when interfacing with lower-level WireGuard APIs that expect binary key data.
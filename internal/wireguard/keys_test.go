@@ -55,6 +55,55 @@ func TestGenerateKeyPair(t *testing.T) {
 	})
 }
 
+func TestGeneratePresharedKey(t *testing.T) {
+	t.Run("should generate base64 encoded 32-byte key", func(t *testing.T) {
+		key, err := GeneratePresharedKey()
+		require.NoError(t, err)
+
+		keyBytes, err := base64.StdEncoding.DecodeString(key)
+		require.NoError(t, err)
+		assert.Len(t, keyBytes, 32)
+	})
+
+	t.Run("should generate unique keys", func(t *testing.T) {
+		key1, err := GeneratePresharedKey()
+		require.NoError(t, err)
+		key2, err := GeneratePresharedKey()
+		require.NoError(t, err)
+
+		assert.NotEqual(t, key1, key2)
+	})
+}
+
+func TestValidateKey(t *testing.T) {
+	t.Run("should accept generated keys", func(t *testing.T) {
+		keyPair, err := GenerateKeyPair()
+		require.NoError(t, err)
+		presharedKey, err := GeneratePresharedKey()
+		require.NoError(t, err)
+
+		assert.NoError(t, ValidateKey(keyPair.PrivateKey))
+		assert.NoError(t, ValidateKey(keyPair.PublicKey))
+		assert.NoError(t, ValidateKey(presharedKey))
+	})
+
+	t.Run("should reject wrong length", func(t *testing.T) {
+		err := ValidateKey(base64.StdEncoding.EncodeToString(make([]byte, 16)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "must be 32 bytes, got 16")
+	})
+
+	t.Run("should reject non-base64 input", func(t *testing.T) {
+		err := ValidateKey("not-a-base64-key!@#")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not valid base64")
+	})
+
+	t.Run("should reject empty key", func(t *testing.T) {
+		assert.Error(t, ValidateKey(""))
+	})
+}
+
 func TestKeyPair_PrivateKeyBytes(t *testing.T) {
 	t.Run("should return correct private key bytes", func(t *testing.T) {
 		keyPair, err := GenerateKeyPair()
@@ -97,4 +146,25 @@ func TestKeyPair_PublicKeyBytes(t *testing.T) {
 		_, err := keyPair.PublicKeyBytes()
 		assert.Error(t, err)
 	})
-}
\ No newline at end of file
+}
+func TestDerivePublicKey(t *testing.T) {
+	t.Run("should derive the public key of a generated pair", func(t *testing.T) {
+		keyPair, err := GenerateKeyPair()
+		require.NoError(t, err)
+
+		publicKey, err := DerivePublicKey(keyPair.PrivateKey)
+		require.NoError(t, err)
+		assert.Equal(t, keyPair.PublicKey, publicKey)
+	})
+
+	t.Run("should reject invalid base64", func(t *testing.T) {
+		_, err := DerivePublicKey("not base64!")
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject keys of the wrong length", func(t *testing.T) {
+		_, err := DerivePublicKey(base64.StdEncoding.EncodeToString([]byte("short")))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid private key length")
+	})
+}
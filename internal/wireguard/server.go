@@ -1,14 +1,16 @@
 package wireguard
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"my-vpn/internal/system"
 )
 
 // WireGuardServer manages a WireGuard VPN server instance.
@@ -17,8 +19,48 @@ import (
 type WireGuardServer struct {
 	configDir     string // Directory where WireGuard configuration files are stored
 	interfaceName string // Name of the WireGuard network interface (e.g., "wg0")
+	maxConfigSize int64  // Maximum configuration file size in bytes accepted when parsing
+	runner        system.CommandRunner // Runs wg and wg-quick
+	configMutex   *sync.Mutex          // Serializes changes to the configuration file; shared per file
+	logger        Logger               // Records interface and peer changes (optional)
+}
+
+// Logger records notable changes to the interface and its configuration.
+// It is satisfied by *monitoring.ComponentLogger.
+type Logger interface {
+	Info(message string)
+}
+
+// SetLogger sets the logger that records interface and peer changes.
+// Without one, changes are not logged.
+func (wg *WireGuardServer) SetLogger(logger Logger) {
+	wg.logger = logger
+}
+
+// logInfo logs an info-level message if a logger is set.
+func (wg *WireGuardServer) logInfo(format string, args ...interface{}) {
+	if wg.logger != nil {
+		wg.logger.Info(fmt.Sprintf(format, args...))
+	}
+}
+
+// configLocks holds one mutex per configuration file, so servers created
+// separately for the same interface still serialize their changes.
+var configLocks sync.Map // Cleaned config path -> *sync.Mutex
+
+// configLock returns the mutex guarding the configuration file at path.
+func configLock(path string) *sync.Mutex {
+	lock, _ := configLocks.LoadOrStore(filepath.Clean(path), &sync.Mutex{})
+	return lock.(*sync.Mutex)
 }
 
+// DefaultMaxConfigSize is the default upper bound on the configuration file size.
+// Even a server with thousands of peers stays well below this limit.
+const DefaultMaxConfigSize int64 = 1 << 20 // 1 MiB
+
+// ErrConfigTooLarge is returned when the configuration file exceeds the maximum size.
+var ErrConfigTooLarge = errors.New("configuration file exceeds maximum size")
+
 // ServerStatus represents the current operational status of the WireGuard server.
 // It provides information about the server state, connected peers, and any error conditions.
 type ServerStatus struct {
@@ -33,20 +75,61 @@ type ServerStatus struct {
 // It contains the essential information needed to add or manage a peer connection.
 type Peer struct {
 	PublicKey     string   `json:"public_key"`                      // Base64-encoded peer public key
+	PresharedKey  string   `json:"-"`                               // Base64-encoded symmetric key shared with the peer (optional)
 	AllowedIPs    []string `json:"allowed_ips"`                     // IP addresses/ranges allowed for this peer
 	Endpoint      string   `json:"endpoint,omitempty"`              // Peer's endpoint address (optional)
 	PersistentKA  int      `json:"persistent_keepalive,omitempty"`  // Keepalive interval in seconds (optional)
 }
 
+// NewClientPeer returns the server-side peer of a VPN client, routing only the
+// client's tunnel address to it. A keepalive of 0 disables PersistentKeepalive.
+func NewClientPeer(publicKey, presharedKey, address string, keepalive int) *Peer {
+	return &Peer{
+		PublicKey:    publicKey,
+		PresharedKey: presharedKey,
+		AllowedIPs:   []string{HostPrefix(address)},
+		PersistentKA: keepalive,
+	}
+}
+
+// ConfigSection renders the peer as a [Peer] section of a configuration file.
+func (p *Peer) ConfigSection() string {
+	section := fmt.Sprintf("\n[Peer]\nPublicKey = %s\n", p.PublicKey)
+	if p.PresharedKey != "" {
+		section += fmt.Sprintf("PresharedKey = %s\n", p.PresharedKey)
+	}
+	section += fmt.Sprintf("AllowedIPs = %s\n", strings.Join(p.AllowedIPs, ", "))
+
+	if p.Endpoint != "" {
+		section += fmt.Sprintf("Endpoint = %s\n", p.Endpoint)
+	}
+
+	if p.PersistentKA > 0 {
+		section += fmt.Sprintf("PersistentKeepalive = %d\n", p.PersistentKA)
+	}
+
+	return section
+}
+
+// PeerStatus represents the live state of a peer as reported by the running interface.
+// Unlike Peer, which reflects the static configuration file, PeerStatus carries
+// runtime information such as the latest handshake and transfer counters.
+type PeerStatus struct {
+	PublicKey       string     `json:"public_key"`                 // Base64-encoded peer public key
+	Endpoint        string     `json:"endpoint,omitempty"`         // Current remote endpoint of the peer (if known)
+	AllowedIPs      []string   `json:"allowed_ips"`                // IP addresses/ranges allowed for this peer
+	LatestHandshake *time.Time `json:"latest_handshake,omitempty"` // Time of the most recent handshake (nil if never)
+	BytesReceived   uint64     `json:"bytes_received"`             // Bytes received from the peer
+	BytesSent       uint64     `json:"bytes_sent"`                 // Bytes sent to the peer
+	PersistentKA    int        `json:"persistent_keepalive,omitempty"` // Keepalive interval in seconds (0 if off)
+}
+
 // NewWireGuardServer creates a new WireGuard server with default configuration.
 // The server is configured to use the standard WireGuard configuration directory
 // (/usr/local/etc/wireguard) and the default interface name (wg0).
 // Returns a pointer to the newly created WireGuardServer instance.
 func NewWireGuardServer() *WireGuardServer {
-	return &WireGuardServer{
-		configDir:     "/usr/local/etc/wireguard",
-		interfaceName: "wg0",
-	}
+	return NewWireGuardServerWithConfig("/usr/local/etc/wireguard", "wg0")
 }
 
 // NewWireGuardServerWithConfig creates a new WireGuard server with custom configuration.
@@ -54,17 +137,32 @@ func NewWireGuardServer() *WireGuardServer {
 // which is useful for testing or non-standard deployments.
 // Returns a pointer to the newly created WireGuardServer instance.
 func NewWireGuardServerWithConfig(configDir, interfaceName string) *WireGuardServer {
+	return NewWireGuardServerWithRunner(configDir, interfaceName, system.DefaultRunner)
+}
+
+// NewWireGuardServerWithRunner creates a new WireGuard server that runs wg and
+// wg-quick through the given runner instead of executing them directly.
+// This allows the interface lifecycle to be tested with a system.MockRunner.
+// Returns a pointer to the newly created WireGuardServer instance.
+func NewWireGuardServerWithRunner(configDir, interfaceName string, runner system.CommandRunner) *WireGuardServer {
 	return &WireGuardServer{
 		configDir:     configDir,
 		interfaceName: interfaceName,
+		maxConfigSize: DefaultMaxConfigSize,
+		runner:        runner,
+		configMutex:   configLock(filepath.Join(configDir, interfaceName+".conf")),
 	}
 }
 
 // WriteConfig writes the server configuration to a WireGuard configuration file.
 // It creates the configuration directory if it doesn't exist and writes the
 // configuration with appropriate file permissions (0600) for security.
+// The hash of the written file is recorded for CheckConfigDrift.
 // Returns an error if directory creation or file writing fails.
 func (wg *WireGuardServer) WriteConfig(config *ServerConfig) error {
+	wg.configMutex.Lock()
+	defer wg.configMutex.Unlock()
+
 	// Ensure config directory exists
 	if err := os.MkdirAll(wg.configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
@@ -76,10 +174,11 @@ func (wg *WireGuardServer) WriteConfig(config *ServerConfig) error {
 	configContent := config.GenerateConfigFile()
 	
 	// Write config file with appropriate permissions
-	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+	if err := wg.writeConfigFile(configPath, []byte(configContent)); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
+	wg.logInfo("Wrote WireGuard configuration %s", configPath)
 	return nil
 }
 
@@ -93,12 +192,12 @@ func (wg *WireGuardServer) Start() error {
 	}
 
 	// Use wg-quick to start the interface
-	cmd := exec.Command("wg-quick", "up", configPath)
-	output, err := cmd.CombinedOutput()
+	output, err := wg.runner.Run("wg-quick", "up", configPath)
 	if err != nil {
 		return fmt.Errorf("failed to start WireGuard interface: %w, output: %s", err, string(output))
 	}
 
+	wg.logInfo("Started WireGuard interface %s", wg.interfaceName)
 	return nil
 }
 
@@ -107,8 +206,7 @@ func (wg *WireGuardServer) Stop() error {
 	configPath := filepath.Join(wg.configDir, wg.interfaceName+".conf")
 	
 	// Use wg-quick to stop the interface
-	cmd := exec.Command("wg-quick", "down", configPath)
-	output, err := cmd.CombinedOutput()
+	output, err := wg.runner.Run("wg-quick", "down", configPath)
 	if err != nil {
 		// Check if the error is because interface is not running
 		if strings.Contains(string(output), "is not a WireGuard interface") ||
@@ -119,6 +217,7 @@ func (wg *WireGuardServer) Stop() error {
 		return fmt.Errorf("failed to stop WireGuard interface: %w, output: %s", err, string(output))
 	}
 
+	wg.logInfo("Stopped WireGuard interface %s", wg.interfaceName)
 	return nil
 }
 
@@ -131,8 +230,7 @@ func (wg *WireGuardServer) Status() (*ServerStatus, error) {
 	}
 
 	// Check if interface exists
-	cmd := exec.Command("wg", "show", wg.interfaceName)
-	output, err := cmd.CombinedOutput()
+	output, err := wg.runner.Run("wg", "show", wg.interfaceName)
 	if err != nil {
 		if strings.Contains(string(output), "No such device") {
 			status.State = "stopped"
@@ -173,118 +271,273 @@ func (wg *WireGuardServer) Restart() error {
 
 // AddPeer adds a peer to the WireGuard configuration
 func (wg *WireGuardServer) AddPeer(peer *Peer) error {
+	if err := ValidateKey(peer.PublicKey); err != nil {
+		return fmt.Errorf("invalid peer public key: %w", err)
+	}
+	if peer.PresharedKey != "" {
+		if err := ValidateKey(peer.PresharedKey); err != nil {
+			return fmt.Errorf("invalid peer preshared key: %w", err)
+		}
+	}
+
+	wg.configMutex.Lock()
+	defer wg.configMutex.Unlock()
+
 	configPath := filepath.Join(wg.configDir, wg.interfaceName+".conf")
 	
 	// Read existing config
-	content, err := os.ReadFile(configPath)
+	content, err := wg.readConfigFile(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// Generate peer configuration
-	peerConfig := fmt.Sprintf("\n[Peer]\nPublicKey = %s\nAllowedIPs = %s\n",
-		peer.PublicKey,
-		strings.Join(peer.AllowedIPs, ", "))
-	
-	if peer.Endpoint != "" {
-		peerConfig += fmt.Sprintf("Endpoint = %s\n", peer.Endpoint)
-	}
-	
-	if peer.PersistentKA > 0 {
-		peerConfig += fmt.Sprintf("PersistentKeepalive = %d\n", peer.PersistentKA)
-	}
-
 	// Append peer configuration
-	newContent := string(content) + peerConfig
+	newContent := string(content) + peer.ConfigSection()
 	
 	// Write updated config
-	if err := os.WriteFile(configPath, []byte(newContent), 0600); err != nil {
+	if err := wg.writeConfigFile(configPath, []byte(newContent)); err != nil {
 		return fmt.Errorf("failed to write updated config: %w", err)
 	}
 
+	wg.logInfo("Added peer %s with allowed IPs %s", peer.PublicKey, strings.Join(peer.AllowedIPs, ", "))
 	return nil
 }
 
-// RemovePeer removes a peer from the WireGuard configuration
+// RemovePeer removes a peer from the WireGuard configuration.
+// The configuration is split into its [Interface] and [Peer] sections, the [Peer]
+// section whose PublicKey matches is dropped, and the remaining sections are
+// written back unchanged, preserving comments and formatting of all other peers.
 func (wg *WireGuardServer) RemovePeer(publicKey string) error {
+	wg.configMutex.Lock()
+	defer wg.configMutex.Unlock()
+
 	configPath := filepath.Join(wg.configDir, wg.interfaceName+".conf")
 	
 	// Read existing config
-	file, err := os.Open(configPath)
+	content, err := wg.readConfigFile(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
-	defer file.Close()
 
-	var newLines []string
-	scanner := bufio.NewScanner(file)
-	
-	skipPeerSection := false
-	for scanner.Scan() {
-		line := scanner.Text()
-		
-		// Check if this is the start of a peer section
-		if strings.TrimSpace(line) == "[Peer]" {
-			skipPeerSection = false
-			// Look ahead to see if this is the peer we want to remove
-			tempLines := []string{line}
-			
-			// Read the peer section
-			for scanner.Scan() {
-				nextLine := scanner.Text()
-				tempLines = append(tempLines, nextLine)
-				
-				if strings.HasPrefix(strings.TrimSpace(nextLine), "PublicKey = ") {
-					if strings.Contains(nextLine, publicKey) {
-						skipPeerSection = true
-						break
-					}
-				}
-				
-				// If we hit another section or empty line, break
-				if strings.HasPrefix(strings.TrimSpace(nextLine), "[") ||
-				   strings.TrimSpace(nextLine) == "" {
-					scanner = bufio.NewScanner(strings.NewReader(nextLine + "\n"))
-					break
-				}
-			}
-			
-			// If this is not the peer to remove, add the lines
-			if !skipPeerSection {
-				newLines = append(newLines, tempLines...)
-			}
+	var newContent strings.Builder
+	removed := false
+	for _, section := range splitConfigSections(string(content)) {
+		if section.isPeer() && section.value("PublicKey") == publicKey {
+			removed = true
 			continue
 		}
-		
-		// If we're not skipping this peer section, add the line
-		if !skipPeerSection {
-			newLines = append(newLines, line)
-		}
-		
-		// Check if we've reached the end of the peer section we're skipping
-		if skipPeerSection && (strings.HasPrefix(strings.TrimSpace(line), "[") || strings.TrimSpace(line) == "") {
-			skipPeerSection = false
+		newContent.WriteString(section.raw())
+	}
+
+	// Write the updated config
+	if err := wg.writeConfigFile(configPath, []byte(newContent.String())); err != nil {
+		return fmt.Errorf("failed to write updated config: %w", err)
+	}
+
+	if removed {
+		wg.logInfo("Removed peer %s", publicKey)
+	}
+	return nil
+}
+
+// HasPeer reports whether a peer with the public key is in the configuration file.
+// Returns an error if the configuration cannot be read.
+func (wg *WireGuardServer) HasPeer(publicKey string) (bool, error) {
+	content, err := wg.readConfigFile(wg.GetConfigPath())
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	for _, section := range splitConfigSections(string(content)) {
+		if section.isPeer() && section.value("PublicKey") == publicKey {
+			return true, nil
 		}
 	}
+	return false, nil
+}
+
+// SyncConfig applies the configuration file to the running interface without
+// restarting it, so established sessions of unchanged peers are kept.
+// wg-quick specific settings are stripped before handing the file to wg syncconf.
+// Peers can't be added or removed while the sync runs.
+// Returns an error if either command fails.
+func (wg *WireGuardServer) SyncConfig() error {
+	wg.configMutex.Lock()
+	defer wg.configMutex.Unlock()
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading config file: %w", err)
+	stripped, err := wg.runner.Run("wg-quick", "strip", wg.GetConfigPath())
+	if err != nil {
+		return fmt.Errorf("failed to strip WireGuard config: %w, output: %s", err, string(stripped))
 	}
 
-	// Write the updated config
-	newContent := strings.Join(newLines, "\n")
-	if err := os.WriteFile(configPath, []byte(newContent), 0600); err != nil {
-		return fmt.Errorf("failed to write updated config: %w", err)
+	tmpFile, err := os.CreateTemp("", wg.interfaceName+"-sync-*.conf")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary config: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(stripped); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary config: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary config: %w", err)
+	}
+
+	output, err := wg.runner.Run("wg", "syncconf", wg.interfaceName, tmpFile.Name())
+	if err != nil {
+		return fmt.Errorf("failed to sync WireGuard interface: %w, output: %s", err, string(output))
 	}
 
 	return nil
 }
 
+// ApplyConfig makes the interface reflect the configuration file after peers
+// were added or removed. A running interface is updated live with SyncConfig,
+// so existing tunnels stay up; a stopped interface is left alone and picks up
+// the file on its next start.
+// Returns an error if syncing the interface fails.
+func (wg *WireGuardServer) ApplyConfig() error {
+	if !wg.IsRunning() {
+		return nil
+	}
+	return wg.SyncConfig()
+}
+
+// configSection is a raw section of a WireGuard configuration file.
+// Lines keep their original line endings so that sections can be
+// re-serialized byte-for-byte.
+type configSection struct {
+	header string   // Section header (e.g. "[Peer]"), empty for content before the first section
+	lines  []string // Raw lines of the section, including the header line
+}
+
+// isPeer reports whether the section is a [Peer] section.
+func (cs configSection) isPeer() bool {
+	return cs.header == "[Peer]"
+}
+
+// value returns the trimmed value of the first "key = value" line in the section.
+func (cs configSection) value(key string) string {
+	for _, line := range cs.lines {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == key {
+			return strings.TrimSpace(parts[1])
+		}
+	}
+	return ""
+}
+
+// raw returns the original text of the section.
+func (cs configSection) raw() string {
+	return strings.Join(cs.lines, "")
+}
+
+// splitConfigSections splits configuration content into its sections.
+// Comment lines directly preceding a section header are treated as part of
+// that section, since they usually describe it (e.g. "# alice-laptop").
+func splitConfigSections(content string) []configSection {
+	sections := []configSection{{}}
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") {
+			current := &sections[len(sections)-1]
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		// Move trailing comments of the previous section to the new one
+		previous := &sections[len(sections)-1]
+		split := len(previous.lines)
+		for split > 0 && strings.HasPrefix(strings.TrimSpace(previous.lines[split-1]), "#") {
+			split--
+		}
+		leading := append([]string{}, previous.lines[split:]...)
+		previous.lines = previous.lines[:split]
+
+		sections = append(sections, configSection{
+			header: trimmed,
+			lines:  append(leading, line),
+		})
+	}
+
+	return sections
+}
+
 // GetConfigPath returns the path to the configuration file
 func (wg *WireGuardServer) GetConfigPath() string {
 	return filepath.Join(wg.configDir, wg.interfaceName+".conf")
 }
 
+// SetMaxConfigSize sets the maximum configuration file size in bytes accepted when parsing.
+// A value of zero or less disables the check.
+func (wg *WireGuardServer) SetMaxConfigSize(size int64) {
+	wg.maxConfigSize = size
+}
+
+// GetMaxConfigSize returns the maximum configuration file size in bytes accepted when parsing.
+func (wg *WireGuardServer) GetMaxConfigSize() int64 {
+	return wg.maxConfigSize
+}
+
+// readConfigFile reads a configuration file after checking its size against the limit,
+// so that a runaway or wrong file cannot exhaust memory.
+func (wg *WireGuardServer) readConfigFile(path string) ([]byte, error) {
+	if wg.maxConfigSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > wg.maxConfigSize {
+			return nil, fmt.Errorf("%w: %s is %d bytes, limit is %d bytes", ErrConfigTooLarge, path, info.Size(), wg.maxConfigSize)
+		}
+	}
+
+	return os.ReadFile(path)
+}
+
+// writeFileAtomic replaces the file at path with content and the given permissions.
+// The content is written to a temporary file in the same directory, which is
+// then renamed over the target, so readers and crashes never see a partial file.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Chmod(perm); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // IsRunning checks if the WireGuard interface is currently running
 func (wg *WireGuardServer) IsRunning() bool {
 	status, err := wg.Status()
@@ -308,7 +561,7 @@ func (wg *WireGuardServer) GetConfig() (*ServerConfig, error) {
 	}
 	
 	// Read configuration file
-	content, err := os.ReadFile(configPath)
+	content, err := wg.readConfigFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read configuration file: %w", err)
 	}
@@ -368,7 +621,7 @@ func (wg *WireGuardServer) GetPeers() ([]Peer, error) {
 	}
 	
 	// Read configuration file
-	content, err := os.ReadFile(configPath)
+	content, err := wg.readConfigFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read configuration file: %w", err)
 	}
@@ -408,6 +661,8 @@ func (wg *WireGuardServer) GetPeers() ([]Peer, error) {
 			switch key {
 			case "PublicKey":
 				currentPeer.PublicKey = value
+			case "PresharedKey":
+				currentPeer.PresharedKey = value
 			case "AllowedIPs":
 				// Parse comma-separated allowed IPs
 				allowedIPs := strings.Split(value, ",")
@@ -433,16 +688,91 @@ func (wg *WireGuardServer) GetPeers() ([]Peer, error) {
 	return peers, nil
 }
 
-// generatePublicKey generates a public key from a private key using wg command.
+// generatePublicKey generates a public key from a private key using Curve25519.
 // This is a helper method for deriving public keys when only private keys are available.
 func (wg *WireGuardServer) generatePublicKey(privateKey string) (string, error) {
-	cmd := exec.Command("wg", "pubkey")
-	cmd.Stdin = strings.NewReader(privateKey)
-	
-	output, err := cmd.Output()
+	publicKey, err := DerivePublicKey(strings.TrimSpace(privateKey))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate public key: %w", err)
 	}
-	
-	return strings.TrimSpace(string(output)), nil
-}
\ No newline at end of file
+
+	return publicKey, nil
+}
+
+// GetPeerStatus returns the live status of all peers on the running interface.
+// It runs `wg show <interface> dump` and parses the tab-separated output.
+// If the interface is not up, an empty slice is returned rather than an error.
+// Returns a slice of PeerStatus structs or an error if the status cannot be retrieved.
+func (wg *WireGuardServer) GetPeerStatus() ([]PeerStatus, error) {
+	output, err := wg.runner.Run("wg", "show", wg.interfaceName, "dump")
+	if err != nil {
+		if strings.Contains(string(output), "No such device") ||
+			strings.Contains(string(output), "Unable to access interface") {
+			return []PeerStatus{}, nil
+		}
+		return nil, fmt.Errorf("failed to get peer status: %w, output: %s", err, string(output))
+	}
+
+	return ParsePeerDump(string(output))
+}
+
+// ParsePeerDump parses the output of `wg show <interface> dump` into peer statuses.
+// The first line describes the interface itself and is skipped; every following
+// line describes one peer with the fields: public-key, preshared-key, endpoint,
+// allowed-ips, latest-handshake, transfer-rx, transfer-tx, persistent-keepalive.
+// Returns a slice of PeerStatus structs or an error if a peer line is malformed.
+func ParsePeerDump(output string) ([]PeerStatus, error) {
+	peers := []PeerStatus{}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+
+	for i, line := range lines {
+		// Skip the interface line and any blank lines
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("malformed peer line %d: expected 8 fields, got %d", i+1, len(fields))
+		}
+
+		peer := PeerStatus{
+			PublicKey:  fields[0],
+			AllowedIPs: []string{},
+		}
+
+		if fields[2] != "(none)" {
+			peer.Endpoint = fields[2]
+		}
+
+		if fields[3] != "(none)" {
+			for _, ip := range strings.Split(fields[3], ",") {
+				peer.AllowedIPs = append(peer.AllowedIPs, strings.TrimSpace(ip))
+			}
+		}
+
+		handshake, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latest-handshake on line %d: %w", i+1, err)
+		}
+		if handshake > 0 {
+			t := time.Unix(handshake, 0)
+			peer.LatestHandshake = &t
+		}
+
+		if peer.BytesReceived, err = strconv.ParseUint(fields[5], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid transfer-rx on line %d: %w", i+1, err)
+		}
+		if peer.BytesSent, err = strconv.ParseUint(fields[6], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid transfer-tx on line %d: %w", i+1, err)
+		}
+
+		if keepalive, err := strconv.Atoi(fields[7]); err == nil {
+			peer.PersistentKA = keepalive
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
@@ -1,12 +1,21 @@
 package wireguard
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"my-vpn/internal/system"
 )
 
 func TestNewWireGuardServer(t *testing.T) {
@@ -159,6 +168,10 @@ func TestWireGuardServer_AddPeer(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	server := NewWireGuardServerWithConfig(tempDir, "wg0")
+
+	peerPublicKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	pskPeerPublicKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32))
+	presharedKey := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{3}, 32))
 	
 	t.Run("should add peer to config", func(t *testing.T) {
 		// First create a basic config
@@ -172,7 +185,7 @@ func TestWireGuardServer_AddPeer(t *testing.T) {
 		require.NoError(t, err)
 		
 		peer := &Peer{
-			PublicKey:  "peer-public-key",
+			PublicKey:  peerPublicKey,
 			AllowedIPs: []string{"10.0.0.2/32"},
 		}
 		
@@ -185,8 +198,233 @@ func TestWireGuardServer_AddPeer(t *testing.T) {
 		
 		configStr := string(content)
 		assert.Contains(t, configStr, "[Peer]")
-		assert.Contains(t, configStr, "PublicKey = peer-public-key")
+		assert.Contains(t, configStr, "PublicKey = "+peerPublicKey)
 		assert.Contains(t, configStr, "AllowedIPs = 10.0.0.2/32")
+		assert.NotContains(t, configStr, "PresharedKey")
+	})
+
+	t.Run("should write and read back preshared key", func(t *testing.T) {
+		peer := &Peer{
+			PublicKey:    pskPeerPublicKey,
+			PresharedKey: presharedKey,
+			AllowedIPs:   []string{"10.0.0.3/32"},
+		}
+		require.NoError(t, server.AddPeer(peer))
+
+		content, err := os.ReadFile(filepath.Join(tempDir, "wg0.conf"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "PublicKey = "+pskPeerPublicKey+"\nPresharedKey = "+presharedKey+"\nAllowedIPs = 10.0.0.3/32\n")
+
+		peers, err := server.GetPeers()
+		require.NoError(t, err)
+		require.Len(t, peers, 2)
+		assert.Equal(t, presharedKey, peers[1].PresharedKey)
+	})
+
+	t.Run("should reject invalid keys without touching the config", func(t *testing.T) {
+		before, err := os.ReadFile(filepath.Join(tempDir, "wg0.conf"))
+		require.NoError(t, err)
+
+		err = server.AddPeer(&Peer{PublicKey: "peer-public-key", AllowedIPs: []string{"10.0.0.4/32"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid peer public key")
+
+		err = server.AddPeer(&Peer{
+			PublicKey:    peerPublicKey,
+			PresharedKey: base64.StdEncoding.EncodeToString([]byte("short")),
+			AllowedIPs:   []string{"10.0.0.4/32"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid peer preshared key")
+
+		after, err := os.ReadFile(filepath.Join(tempDir, "wg0.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, before, after)
+	})
+}
+
+func TestWireGuardServer_HasPeer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wireguard_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	server := NewWireGuardServerWithConfig(tempDir, "wg0")
+
+	t.Run("should report configured peers", func(t *testing.T) {
+		configContent := `[Interface]
+PrivateKey = test-private-key
+Address = 10.0.0.1/24
+
+[Peer]
+PublicKey = present-peer
+AllowedIPs = 10.0.0.2/32
+`
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(configContent), 0600))
+
+		present, err := server.HasPeer("present-peer")
+		require.NoError(t, err)
+		assert.True(t, present)
+
+		present, err = server.HasPeer("missing-peer")
+		require.NoError(t, err)
+		assert.False(t, present)
+	})
+
+	t.Run("should fail without config file", func(t *testing.T) {
+		_, err := NewWireGuardServerWithConfig(filepath.Join(tempDir, "missing"), "wg0").HasPeer("present-peer")
+		assert.Error(t, err)
+	})
+}
+
+// newMockedServer creates a server in a temporary directory with a minimal
+// config whose wg and wg-quick commands are answered by a mock runner.
+// The interface reports as up only when up is true.
+func newMockedServer(t *testing.T, up bool) (*WireGuardServer, *system.MockRunner) {
+	tempDir := t.TempDir()
+	configContent := "[Interface]\nPrivateKey = test-private-key\nAddress = 10.0.0.1/24\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "wg0.conf"), []byte(configContent), 0600))
+
+	runner := system.NewMockRunner()
+	if !up {
+		runner.On("wg show wg0", "Unable to access interface: No such device", errors.New("exit status 1"))
+	}
+	runner.On("wg-quick strip", "[Interface]\nPrivateKey = test-private-key\n", nil)
+	return NewWireGuardServerWithRunner(tempDir, "wg0", runner), runner
+}
+
+func TestWireGuardServer_Commands(t *testing.T) {
+	t.Run("should bring interface up and down with wg-quick", func(t *testing.T) {
+		server, runner := newMockedServer(t, false)
+		configPath := server.GetConfigPath()
+
+		require.NoError(t, server.Start())
+		require.NoError(t, server.Stop())
+
+		assert.Equal(t, []string{
+			"wg-quick up " + configPath,
+			"wg-quick down " + configPath,
+		}, runner.Commands())
+	})
+
+	t.Run("should include command output when start fails", func(t *testing.T) {
+		server, runner := newMockedServer(t, false)
+		runner.On("wg-quick up", "Line unrecognized", errors.New("exit status 1"))
+
+		err := server.Start()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Line unrecognized")
+	})
+
+	t.Run("should ignore stop of interface that is not up", func(t *testing.T) {
+		server, runner := newMockedServer(t, false)
+		runner.On("wg-quick down", "wg-quick: `wg0' is not a WireGuard interface", errors.New("exit status 1"))
+
+		assert.NoError(t, server.Stop())
+	})
+
+	t.Run("should report running status with peer count", func(t *testing.T) {
+		server, runner := newMockedServer(t, true)
+		runner.On("wg show wg0", "interface: wg0\n  listening port: 51820\n\npeer: a\npeer: b\n", nil)
+
+		status, err := server.Status()
+		require.NoError(t, err)
+		assert.Equal(t, "running", status.State)
+		assert.Equal(t, 2, status.PeerCount)
+		assert.Equal(t, []string{"wg show wg0"}, runner.Commands())
+	})
+
+	t.Run("should report stopped status without interface", func(t *testing.T) {
+		server, _ := newMockedServer(t, false)
+
+		status, err := server.Status()
+		require.NoError(t, err)
+		assert.Equal(t, "stopped", status.State)
+	})
+
+	t.Run("should read live peers from wg show dump", func(t *testing.T) {
+		server, runner := newMockedServer(t, true)
+		runner.On("wg show wg0 dump", "private\tpublic\t51820\toff\npeer-key\t(none)\t(none)\t10.0.0.2/32\t0\t0\t0\toff\n", nil)
+
+		peers, err := server.GetPeerStatus()
+		require.NoError(t, err)
+		require.Len(t, peers, 1)
+		assert.Equal(t, "peer-key", peers[0].PublicKey)
+		assert.Equal(t, []string{"wg show wg0 dump"}, runner.Commands())
+	})
+}
+
+// recordingLogger collects the messages logged by a WireGuardServer.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Info(message string) {
+	l.messages = append(l.messages, message)
+}
+
+func TestWireGuardServer_SetLogger(t *testing.T) {
+	t.Run("should log interface and peer changes", func(t *testing.T) {
+		server, _ := newMockedServer(t, false)
+		logger := &recordingLogger{}
+		server.SetLogger(logger)
+
+		keyPair, err := GenerateKeyPair()
+		require.NoError(t, err)
+
+		require.NoError(t, server.Start())
+		require.NoError(t, server.AddPeer(&Peer{PublicKey: keyPair.PublicKey, AllowedIPs: []string{"10.0.0.2/32"}}))
+		require.NoError(t, server.RemovePeer(keyPair.PublicKey))
+		require.NoError(t, server.Stop())
+
+		assert.Equal(t, []string{
+			"Started WireGuard interface wg0",
+			"Added peer " + keyPair.PublicKey + " with allowed IPs 10.0.0.2/32",
+			"Removed peer " + keyPair.PublicKey,
+			"Stopped WireGuard interface wg0",
+		}, logger.messages)
+	})
+
+	t.Run("should not log removal of an unknown peer", func(t *testing.T) {
+		server, _ := newMockedServer(t, false)
+		logger := &recordingLogger{}
+		server.SetLogger(logger)
+
+		require.NoError(t, server.RemovePeer("unknown"))
+		assert.Empty(t, logger.messages)
+	})
+}
+
+func TestWireGuardServer_ApplyConfig(t *testing.T) {
+	t.Run("should sync running interface without restarting it", func(t *testing.T) {
+		server, runner := newMockedServer(t, true)
+
+		require.NoError(t, server.ApplyConfig())
+
+		commands := runner.Commands()
+		require.Len(t, commands, 3)
+		assert.Equal(t, "wg show wg0", commands[0])
+		assert.Equal(t, "wg-quick strip "+server.GetConfigPath(), commands[1])
+		assert.True(t, strings.HasPrefix(commands[2], "wg syncconf wg0 "))
+	})
+
+	t.Run("should leave interface that is not up alone", func(t *testing.T) {
+		server, runner := newMockedServer(t, false)
+
+		require.NoError(t, server.ApplyConfig())
+
+		assert.Equal(t, []string{"wg show wg0"}, runner.Commands())
+	})
+
+	t.Run("should return error when syncconf fails", func(t *testing.T) {
+		server, runner := newMockedServer(t, true)
+		runner.On("wg syncconf", "command failed", errors.New("exit status 1"))
+
+		err := server.ApplyConfig()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to sync WireGuard interface")
+		for _, command := range runner.Commands() {
+			assert.False(t, strings.HasPrefix(command, "wg-quick down"))
+		}
 	})
 }
 
@@ -226,4 +464,305 @@ AllowedIPs = 10.0.0.3/32
 		assert.NotContains(t, configStr, "peer-to-remove")
 		assert.Contains(t, configStr, "peer-to-keep")
 	})
-}
\ No newline at end of file
+
+	t.Run("should preserve interface and remaining peers byte-for-byte", func(t *testing.T) {
+		interfaceSection := `# Managed by my-vpn
+[Interface]
+PrivateKey = test-private-key
+Address = 10.0.0.1/24
+ListenPort = 51820
+PostUp = iptables -A FORWARD -i wg0 -j ACCEPT
+
+`
+		firstPeer := `# alice-laptop
+[Peer]
+PublicKey = alice-key
+AllowedIPs = 10.0.0.2/32
+
+`
+		removedPeer := `# bob-phone
+[Peer]
+PublicKey = bob-key
+AllowedIPs = 10.0.0.3/32
+PersistentKeepalive = 25
+
+`
+		thirdPeer := `[Peer]
+PublicKey = carol-key
+AllowedIPs = 10.0.0.4/32
+PersistentKeepalive = 25
+
+`
+		lastPeer := `# dave-desktop
+[Peer]
+PublicKey = dave-key
+AllowedIPs = 10.0.0.5/32
+Endpoint = 203.0.113.5:51820
+`
+		configPath := filepath.Join(tempDir, "wg0.conf")
+		original := interfaceSection + firstPeer + removedPeer + thirdPeer + lastPeer
+		require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+		require.NoError(t, server.RemovePeer("bob-key"))
+
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, interfaceSection+firstPeer+thirdPeer+lastPeer, string(content))
+
+		peers, err := server.GetPeers()
+		require.NoError(t, err)
+		assert.Len(t, peers, 3)
+	})
+
+	t.Run("should only remove the exact matching public key", func(t *testing.T) {
+		configContent := `[Interface]
+PrivateKey = test-private-key
+
+[Peer]
+PublicKey = key
+AllowedIPs = 10.0.0.2/32
+
+[Peer]
+PublicKey = key-suffix
+AllowedIPs = 10.0.0.3/32
+
+[Peer]
+PublicKey = prefix-key
+AllowedIPs = 10.0.0.4/32
+`
+		configPath := filepath.Join(tempDir, "wg0.conf")
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0600))
+
+		require.NoError(t, server.RemovePeer("key"))
+
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		configStr := string(content)
+		assert.NotContains(t, configStr, "PublicKey = key\n")
+		assert.Contains(t, configStr, "PublicKey = key-suffix\nAllowedIPs = 10.0.0.3/32")
+		assert.Contains(t, configStr, "PublicKey = prefix-key\nAllowedIPs = 10.0.0.4/32")
+	})
+
+	t.Run("should remove the last peer in the file", func(t *testing.T) {
+		configContent := "[Interface]\nPrivateKey = test-private-key\n\n[Peer]\nPublicKey = first\nAllowedIPs = 10.0.0.2/32\n\n[Peer]\nPublicKey = last\nAllowedIPs = 10.0.0.3/32\n"
+		configPath := filepath.Join(tempDir, "wg0.conf")
+		require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0600))
+
+		require.NoError(t, server.RemovePeer("last"))
+
+		content, err := os.ReadFile(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, "[Interface]\nPrivateKey = test-private-key\n\n[Peer]\nPublicKey = first\nAllowedIPs = 10.0.0.2/32\n\n", string(content))
+	})
+}
+
+func TestWireGuardServer_ConcurrentConfigWrites(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wireguard_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	server := NewWireGuardServerWithConfig(tempDir, "wg0")
+	require.NoError(t, server.WriteConfig(&ServerConfig{
+		PrivateKey: "test-private-key",
+		Address:    "10.0.0.1/24",
+		ListenPort: 51820,
+		Interface:  "wg0",
+	}))
+
+	const peerCount = 20
+	publicKeys := make([]string, peerCount)
+	for i := range publicKeys {
+		publicKeys[i] = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{byte(i + 1)}, 32))
+	}
+
+	t.Run("should keep every peer added concurrently", func(t *testing.T) {
+		var wait sync.WaitGroup
+		errs := make(chan error, peerCount)
+		for i, publicKey := range publicKeys {
+			wait.Add(1)
+			go func(i int, publicKey string) {
+				defer wait.Done()
+				errs <- server.AddPeer(&Peer{
+					PublicKey:  publicKey,
+					AllowedIPs: []string{fmt.Sprintf("10.0.0.%d/32", i+2)},
+				})
+			}(i, publicKey)
+		}
+		wait.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		config, err := server.GetConfig()
+		require.NoError(t, err)
+		assert.Equal(t, "test-private-key", config.PrivateKey)
+
+		peers, err := server.GetPeers()
+		require.NoError(t, err)
+		require.Len(t, peers, peerCount)
+		found := make(map[string]bool)
+		for _, peer := range peers {
+			found[peer.PublicKey] = true
+		}
+		for _, publicKey := range publicKeys {
+			assert.True(t, found[publicKey], "peer %s is missing", publicKey)
+		}
+
+		drift, err := server.CheckConfigDrift()
+		require.NoError(t, err)
+		assert.False(t, drift.Drifted)
+	})
+
+	t.Run("should keep the remaining peers when removing concurrently", func(t *testing.T) {
+		var wait sync.WaitGroup
+		for _, publicKey := range publicKeys[:peerCount/2] {
+			wait.Add(1)
+			go func(publicKey string) {
+				defer wait.Done()
+				assert.NoError(t, server.RemovePeer(publicKey))
+			}(publicKey)
+		}
+		wait.Wait()
+
+		peers, err := server.GetPeers()
+		require.NoError(t, err)
+		var remaining []string
+		for _, peer := range peers {
+			remaining = append(remaining, peer.PublicKey)
+		}
+		assert.ElementsMatch(t, publicKeys[peerCount/2:], remaining)
+	})
+
+	t.Run("should serialize servers sharing a config file", func(t *testing.T) {
+		other := NewWireGuardServerWithConfig(tempDir+"/", "wg0")
+		assert.Same(t, server.configMutex, other.configMutex)
+		assert.NotSame(t, server.configMutex, NewWireGuardServerWithConfig(tempDir, "wg1").configMutex)
+
+		var wait sync.WaitGroup
+		for i, publicKey := range publicKeys[:peerCount/2] {
+			target := server
+			if i%2 == 1 {
+				target = other
+			}
+			wait.Add(1)
+			go func(target *WireGuardServer, i int, publicKey string) {
+				defer wait.Done()
+				assert.NoError(t, target.AddPeer(&Peer{
+					PublicKey:  publicKey,
+					AllowedIPs: []string{fmt.Sprintf("10.0.0.%d/32", i+2)},
+				}))
+			}(target, i, publicKey)
+		}
+		wait.Wait()
+
+		peers, err := server.GetPeers()
+		require.NoError(t, err)
+		assert.Len(t, peers, peerCount)
+	})
+
+	t.Run("should leave no temporary files and keep permissions", func(t *testing.T) {
+		entries, err := os.ReadDir(tempDir)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.ElementsMatch(t, []string{"wg0.conf", "wg0.conf.sha256"}, names)
+
+		info, err := os.Stat(filepath.Join(tempDir, "wg0.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+}
+
+func TestWireGuardServer_MaxConfigSize(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "wireguard_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	server := NewWireGuardServerWithConfig(tempDir, "wg0")
+	assert.Equal(t, DefaultMaxConfigSize, server.GetMaxConfigSize())
+
+	config := "[Interface]\nPrivateKey = test-private-key\nAddress = 10.0.0.1/24\nListenPort = 51820\n"
+	// Pad with comments so the file size can be tuned around the limit
+	padded := config + "#" + strings.Repeat("x", 200) + "\n"
+	configPath := filepath.Join(tempDir, "wg0.conf")
+	require.NoError(t, os.WriteFile(configPath, []byte(padded), 0600))
+	size := int64(len(padded))
+
+	t.Run("should read config just under the limit", func(t *testing.T) {
+		server.SetMaxConfigSize(size)
+
+		cfg, err := server.GetConfig()
+		require.NoError(t, err)
+		assert.Equal(t, 51820, cfg.ListenPort)
+
+		_, err = server.GetPeers()
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reject config just over the limit", func(t *testing.T) {
+		server.SetMaxConfigSize(size - 1)
+
+		_, err := server.GetConfig()
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrConfigTooLarge))
+		assert.Contains(t, err.Error(), "exceeds maximum size")
+
+		_, err = server.GetPeers()
+		assert.True(t, errors.Is(err, ErrConfigTooLarge))
+
+		err = server.AddPeer(&Peer{PublicKey: base64.StdEncoding.EncodeToString(make([]byte, 32)), AllowedIPs: []string{"10.0.0.2/32"}})
+		assert.True(t, errors.Is(err, ErrConfigTooLarge))
+	})
+}
+
+func TestParsePeerDump(t *testing.T) {
+	dump := "server-private-key\tserver-public-key\t51820\toff\n" +
+		"peer-a-key\t(none)\t203.0.113.5:51000\t10.0.0.2/32\t1700000000\t1024\t2048\t25\n" +
+		"peer-b-key\t(none)\t(none)\t10.0.0.3/32,fd00::3/128\t0\t0\t0\toff\n"
+
+	t.Run("should parse peer lines and skip the interface line", func(t *testing.T) {
+		peers, err := ParsePeerDump(dump)
+		require.NoError(t, err)
+		require.Len(t, peers, 2)
+
+		assert.Equal(t, "peer-a-key", peers[0].PublicKey)
+		assert.Equal(t, "203.0.113.5:51000", peers[0].Endpoint)
+		assert.Equal(t, []string{"10.0.0.2/32"}, peers[0].AllowedIPs)
+		require.NotNil(t, peers[0].LatestHandshake)
+		assert.True(t, peers[0].LatestHandshake.Equal(time.Unix(1700000000, 0)))
+		assert.Equal(t, uint64(1024), peers[0].BytesReceived)
+		assert.Equal(t, uint64(2048), peers[0].BytesSent)
+		assert.Equal(t, 25, peers[0].PersistentKA)
+	})
+
+	t.Run("should handle peers that never connected", func(t *testing.T) {
+		peers, err := ParsePeerDump(dump)
+		require.NoError(t, err)
+
+		assert.Empty(t, peers[1].Endpoint)
+		assert.Nil(t, peers[1].LatestHandshake)
+		assert.Equal(t, []string{"10.0.0.3/32", "fd00::3/128"}, peers[1].AllowedIPs)
+		assert.Equal(t, 0, peers[1].PersistentKA)
+	})
+
+	t.Run("should return empty slice for interface without peers", func(t *testing.T) {
+		peers, err := ParsePeerDump("server-private-key\tserver-public-key\t51820\toff\n")
+		require.NoError(t, err)
+		assert.Empty(t, peers)
+	})
+
+	t.Run("should fail on malformed peer line", func(t *testing.T) {
+		_, err := ParsePeerDump("interface-line\npeer-key\t(none)\n")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "malformed peer line")
+	})
+
+	t.Run("should fail on invalid transfer counter", func(t *testing.T) {
+		_, err := ParsePeerDump("interface-line\npeer-key\t(none)\t(none)\t10.0.0.2/32\t0\tabc\t0\toff\n")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "transfer-rx")
+	})
+}
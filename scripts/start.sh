@@ -128,7 +128,7 @@ start_server() {
             info "開発モードでサーバーを起動しています..."
             info "URL: http://localhost:$PORT"
             info "停止するには Ctrl+C を押してください"
-            go run ./cmd/server/main.go
+            go run ./cmd/server/main.go -debug
             ;;
         "--prod")
             info "プロダクションモードでサーバーを起動しています..."